@@ -70,8 +70,15 @@ func provideCleanup(
 	schedulerSnapshot *service.SchedulerSnapshotService,
 	tokenRefresh *service.TokenRefreshService,
 	accountExpiry *service.AccountExpiryService,
+	accountAvailability *service.AccountAvailabilityService,
 	subscriptionExpiry *service.SubscriptionExpiryService,
 	usageCleanup *service.UsageCleanupService,
+	messageBatch *service.MessageBatchService,
+	gatewayFile *service.GatewayFileService,
+	accountHealthCheck *service.AccountHealthCheckService,
+	balanceLedger *service.BalanceLedgerService,
+	pricingOverride *service.PricingOverrideService,
+	topup *service.TopupService,
 	pricing *service.PricingService,
 	emailQueue *service.EmailQueueService,
 	billingCache *service.BillingCacheService,
@@ -131,6 +138,38 @@ func provideCleanup(
 				}
 				return nil
 			}},
+			{"MessageBatchService", func() error {
+				if messageBatch != nil {
+					messageBatch.Stop()
+				}
+				return nil
+			}},
+			{"GatewayFileService", func() error {
+				if gatewayFile != nil {
+					gatewayFile.Stop()
+				}
+				return nil
+			}},
+			{"AccountHealthCheckService", func() error {
+				if accountHealthCheck != nil {
+					accountHealthCheck.Stop()
+				}
+				return nil
+			}},
+			{"BalanceLedgerService", func() error {
+				if balanceLedger != nil {
+					balanceLedger.Stop()
+				}
+				return nil
+			}},
+			{"PricingOverrideService", func() error {
+				pricingOverride.Stop()
+				return nil
+			}},
+			{"TopupService", func() error {
+				topup.Stop()
+				return nil
+			}},
 			{"TokenRefreshService", func() error {
 				tokenRefresh.Stop()
 				return nil
@@ -139,6 +178,10 @@ func provideCleanup(
 				accountExpiry.Stop()
 				return nil
 			}},
+			{"AccountAvailabilityService", func() error {
+				accountAvailability.Stop()
+				return nil
+			}},
 			{"SubscriptionExpiryService", func() error {
 				subscriptionExpiry.Stop()
 				return nil
@@ -8,6 +8,7 @@ import (
 	"entgo.io/ent"
 	"entgo.io/ent/dialect/sql"
 	"entgo.io/ent/dialect/sql/sqlgraph"
+	"github.com/lib/pq"
 )
 
 const (
@@ -65,6 +66,12 @@ const (
 	FieldSessionWindowEnd = "session_window_end"
 	// FieldSessionWindowStatus holds the string denoting the session_window_status field in the database.
 	FieldSessionWindowStatus = "session_window_status"
+	// FieldTags holds the string denoting the tags field in the database.
+	FieldTags = "tags"
+	// FieldAvailabilitySchedule holds the string denoting the availability_schedule field in the database.
+	FieldAvailabilitySchedule = "availability_schedule"
+	// FieldAvailabilityNextTransitionAt holds the string denoting the availability_next_transition_at field in the database.
+	FieldAvailabilityNextTransitionAt = "availability_next_transition_at"
 	// EdgeGroups holds the string denoting the groups edge name in mutations.
 	EdgeGroups = "groups"
 	// EdgeProxy holds the string denoting the proxy edge name in mutations.
@@ -131,6 +138,9 @@ var Columns = []string{
 	FieldSessionWindowStart,
 	FieldSessionWindowEnd,
 	FieldSessionWindowStatus,
+	FieldTags,
+	FieldAvailabilitySchedule,
+	FieldAvailabilityNextTransitionAt,
 }
 
 var (
@@ -189,6 +199,8 @@ var (
 	DefaultSchedulable bool
 	// SessionWindowStatusValidator is a validator for the "session_window_status" field. It is called by the builders before save.
 	SessionWindowStatusValidator func(string) error
+	// DefaultTags holds the default value on creation for the "tags" field.
+	DefaultTags pq.StringArray
 )
 
 // OrderOption defines the ordering options for the Account queries.
@@ -314,6 +326,16 @@ func BySessionWindowStatus(opts ...sql.OrderTermOption) OrderOption {
 	return sql.OrderByField(FieldSessionWindowStatus, opts...).ToFunc()
 }
 
+// ByTags orders the results by the tags field.
+func ByTags(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldTags, opts...).ToFunc()
+}
+
+// ByAvailabilityNextTransitionAt orders the results by the availability_next_transition_at field.
+func ByAvailabilityNextTransitionAt(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldAvailabilityNextTransitionAt, opts...).ToFunc()
+}
+
 // ByGroupsCount orders the results by groups count.
 func ByGroupsCount(opts ...sql.OrderTermOption) OrderOption {
 	return func(s *sql.Selector) {
@@ -8,6 +8,7 @@ import (
 	"entgo.io/ent/dialect/sql"
 	"entgo.io/ent/dialect/sql/sqlgraph"
 	"github.com/Wei-Shaw/sub2api/ent/predicate"
+	"github.com/lib/pq"
 )
 
 // ID filters vertices based on their ID field.
@@ -170,6 +171,16 @@ func SessionWindowStatus(v string) predicate.Account {
 	return predicate.Account(sql.FieldEQ(FieldSessionWindowStatus, v))
 }
 
+// Tags applies equality check predicate on the "tags" field. It's identical to TagsEQ.
+func Tags(v pq.StringArray) predicate.Account {
+	return predicate.Account(sql.FieldEQ(FieldTags, v))
+}
+
+// AvailabilityNextTransitionAt applies equality check predicate on the "availability_next_transition_at" field. It's identical to AvailabilityNextTransitionAtEQ.
+func AvailabilityNextTransitionAt(v time.Time) predicate.Account {
+	return predicate.Account(sql.FieldEQ(FieldAvailabilityNextTransitionAt, v))
+}
+
 // CreatedAtEQ applies the EQ predicate on the "created_at" field.
 func CreatedAtEQ(v time.Time) predicate.Account {
 	return predicate.Account(sql.FieldEQ(FieldCreatedAt, v))
@@ -1305,6 +1316,106 @@ func SessionWindowStatusContainsFold(v string) predicate.Account {
 	return predicate.Account(sql.FieldContainsFold(FieldSessionWindowStatus, v))
 }
 
+// TagsEQ applies the EQ predicate on the "tags" field.
+func TagsEQ(v pq.StringArray) predicate.Account {
+	return predicate.Account(sql.FieldEQ(FieldTags, v))
+}
+
+// TagsNEQ applies the NEQ predicate on the "tags" field.
+func TagsNEQ(v pq.StringArray) predicate.Account {
+	return predicate.Account(sql.FieldNEQ(FieldTags, v))
+}
+
+// TagsIn applies the In predicate on the "tags" field.
+func TagsIn(vs ...pq.StringArray) predicate.Account {
+	return predicate.Account(sql.FieldIn(FieldTags, vs...))
+}
+
+// TagsNotIn applies the NotIn predicate on the "tags" field.
+func TagsNotIn(vs ...pq.StringArray) predicate.Account {
+	return predicate.Account(sql.FieldNotIn(FieldTags, vs...))
+}
+
+// TagsGT applies the GT predicate on the "tags" field.
+func TagsGT(v pq.StringArray) predicate.Account {
+	return predicate.Account(sql.FieldGT(FieldTags, v))
+}
+
+// TagsGTE applies the GTE predicate on the "tags" field.
+func TagsGTE(v pq.StringArray) predicate.Account {
+	return predicate.Account(sql.FieldGTE(FieldTags, v))
+}
+
+// TagsLT applies the LT predicate on the "tags" field.
+func TagsLT(v pq.StringArray) predicate.Account {
+	return predicate.Account(sql.FieldLT(FieldTags, v))
+}
+
+// TagsLTE applies the LTE predicate on the "tags" field.
+func TagsLTE(v pq.StringArray) predicate.Account {
+	return predicate.Account(sql.FieldLTE(FieldTags, v))
+}
+
+// AvailabilityScheduleIsNil applies the IsNil predicate on the "availability_schedule" field.
+func AvailabilityScheduleIsNil() predicate.Account {
+	return predicate.Account(sql.FieldIsNull(FieldAvailabilitySchedule))
+}
+
+// AvailabilityScheduleNotNil applies the NotNil predicate on the "availability_schedule" field.
+func AvailabilityScheduleNotNil() predicate.Account {
+	return predicate.Account(sql.FieldNotNull(FieldAvailabilitySchedule))
+}
+
+// AvailabilityNextTransitionAtEQ applies the EQ predicate on the "availability_next_transition_at" field.
+func AvailabilityNextTransitionAtEQ(v time.Time) predicate.Account {
+	return predicate.Account(sql.FieldEQ(FieldAvailabilityNextTransitionAt, v))
+}
+
+// AvailabilityNextTransitionAtNEQ applies the NEQ predicate on the "availability_next_transition_at" field.
+func AvailabilityNextTransitionAtNEQ(v time.Time) predicate.Account {
+	return predicate.Account(sql.FieldNEQ(FieldAvailabilityNextTransitionAt, v))
+}
+
+// AvailabilityNextTransitionAtIn applies the In predicate on the "availability_next_transition_at" field.
+func AvailabilityNextTransitionAtIn(vs ...time.Time) predicate.Account {
+	return predicate.Account(sql.FieldIn(FieldAvailabilityNextTransitionAt, vs...))
+}
+
+// AvailabilityNextTransitionAtNotIn applies the NotIn predicate on the "availability_next_transition_at" field.
+func AvailabilityNextTransitionAtNotIn(vs ...time.Time) predicate.Account {
+	return predicate.Account(sql.FieldNotIn(FieldAvailabilityNextTransitionAt, vs...))
+}
+
+// AvailabilityNextTransitionAtGT applies the GT predicate on the "availability_next_transition_at" field.
+func AvailabilityNextTransitionAtGT(v time.Time) predicate.Account {
+	return predicate.Account(sql.FieldGT(FieldAvailabilityNextTransitionAt, v))
+}
+
+// AvailabilityNextTransitionAtGTE applies the GTE predicate on the "availability_next_transition_at" field.
+func AvailabilityNextTransitionAtGTE(v time.Time) predicate.Account {
+	return predicate.Account(sql.FieldGTE(FieldAvailabilityNextTransitionAt, v))
+}
+
+// AvailabilityNextTransitionAtLT applies the LT predicate on the "availability_next_transition_at" field.
+func AvailabilityNextTransitionAtLT(v time.Time) predicate.Account {
+	return predicate.Account(sql.FieldLT(FieldAvailabilityNextTransitionAt, v))
+}
+
+// AvailabilityNextTransitionAtLTE applies the LTE predicate on the "availability_next_transition_at" field.
+func AvailabilityNextTransitionAtLTE(v time.Time) predicate.Account {
+	return predicate.Account(sql.FieldLTE(FieldAvailabilityNextTransitionAt, v))
+}
+
+// AvailabilityNextTransitionAtIsNil applies the IsNil predicate on the "availability_next_transition_at" field.
+func AvailabilityNextTransitionAtIsNil() predicate.Account {
+	return predicate.Account(sql.FieldIsNull(FieldAvailabilityNextTransitionAt))
+}
+
+// AvailabilityNextTransitionAtNotNil applies the NotNil predicate on the "availability_next_transition_at" field.
+func AvailabilityNextTransitionAtNotNil() predicate.Account {
+	return predicate.Account(sql.FieldNotNull(FieldAvailabilityNextTransitionAt))
+}
+
 // HasGroups applies the HasEdge predicate on the "groups" edge.
 func HasGroups() predicate.Account {
 	return predicate.Account(func(s *sql.Selector) {
@@ -4,6 +4,7 @@ package ent
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
@@ -15,6 +16,7 @@ import (
 	"github.com/Wei-Shaw/sub2api/ent/group"
 	"github.com/Wei-Shaw/sub2api/ent/proxy"
 	"github.com/Wei-Shaw/sub2api/ent/usagelog"
+	"github.com/lib/pq"
 )
 
 // AccountCreate is the builder for creating a Account entity.
@@ -335,6 +337,32 @@ func (_c *AccountCreate) SetNillableSessionWindowStatus(v *string) *AccountCreat
 	return _c
 }
 
+// SetTags sets the "tags" field.
+func (_c *AccountCreate) SetTags(v pq.StringArray) *AccountCreate {
+	_c.mutation.SetTags(v)
+	return _c
+}
+
+// SetAvailabilitySchedule sets the "availability_schedule" field.
+func (_c *AccountCreate) SetAvailabilitySchedule(v json.RawMessage) *AccountCreate {
+	_c.mutation.SetAvailabilitySchedule(v)
+	return _c
+}
+
+// SetAvailabilityNextTransitionAt sets the "availability_next_transition_at" field.
+func (_c *AccountCreate) SetAvailabilityNextTransitionAt(v time.Time) *AccountCreate {
+	_c.mutation.SetAvailabilityNextTransitionAt(v)
+	return _c
+}
+
+// SetNillableAvailabilityNextTransitionAt sets the "availability_next_transition_at" field if the given value is not nil.
+func (_c *AccountCreate) SetNillableAvailabilityNextTransitionAt(v *time.Time) *AccountCreate {
+	if v != nil {
+		_c.SetAvailabilityNextTransitionAt(*v)
+	}
+	return _c
+}
+
 // AddGroupIDs adds the "groups" edge to the Group entity by IDs.
 func (_c *AccountCreate) AddGroupIDs(ids ...int64) *AccountCreate {
 	_c.mutation.AddGroupIDs(ids...)
@@ -459,6 +487,10 @@ func (_c *AccountCreate) defaults() error {
 		v := account.DefaultSchedulable
 		_c.mutation.SetSchedulable(v)
 	}
+	if _, ok := _c.mutation.Tags(); !ok {
+		v := account.DefaultTags
+		_c.mutation.SetTags(v)
+	}
 	return nil
 }
 
@@ -528,6 +560,9 @@ func (_c *AccountCreate) check() error {
 			return &ValidationError{Name: "session_window_status", err: fmt.Errorf(`ent: validator failed for field "Account.session_window_status": %w`, err)}
 		}
 	}
+	if _, ok := _c.mutation.Tags(); !ok {
+		return &ValidationError{Name: "tags", err: errors.New(`ent: missing required field "Account.tags"`)}
+	}
 	return nil
 }
 
@@ -651,6 +686,18 @@ func (_c *AccountCreate) createSpec() (*Account, *sqlgraph.CreateSpec) {
 		_spec.SetField(account.FieldSessionWindowStatus, field.TypeString, value)
 		_node.SessionWindowStatus = &value
 	}
+	if value, ok := _c.mutation.Tags(); ok {
+		_spec.SetField(account.FieldTags, field.TypeOther, value)
+		_node.Tags = value
+	}
+	if value, ok := _c.mutation.AvailabilitySchedule(); ok {
+		_spec.SetField(account.FieldAvailabilitySchedule, field.TypeJSON, value)
+		_node.AvailabilitySchedule = value
+	}
+	if value, ok := _c.mutation.AvailabilityNextTransitionAt(); ok {
+		_spec.SetField(account.FieldAvailabilityNextTransitionAt, field.TypeTime, value)
+		_node.AvailabilityNextTransitionAt = &value
+	}
 	if nodes := _c.mutation.GroupsIDs(); len(nodes) > 0 {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.M2M,
@@ -1134,6 +1181,54 @@ func (u *AccountUpsert) ClearSessionWindowStatus() *AccountUpsert {
 	return u
 }
 
+// SetTags sets the "tags" field.
+func (u *AccountUpsert) SetTags(v pq.StringArray) *AccountUpsert {
+	u.Set(account.FieldTags, v)
+	return u
+}
+
+// UpdateTags sets the "tags" field to the value that was provided on create.
+func (u *AccountUpsert) UpdateTags() *AccountUpsert {
+	u.SetExcluded(account.FieldTags)
+	return u
+}
+
+// SetAvailabilitySchedule sets the "availability_schedule" field.
+func (u *AccountUpsert) SetAvailabilitySchedule(v json.RawMessage) *AccountUpsert {
+	u.Set(account.FieldAvailabilitySchedule, v)
+	return u
+}
+
+// UpdateAvailabilitySchedule sets the "availability_schedule" field to the value that was provided on create.
+func (u *AccountUpsert) UpdateAvailabilitySchedule() *AccountUpsert {
+	u.SetExcluded(account.FieldAvailabilitySchedule)
+	return u
+}
+
+// ClearAvailabilitySchedule clears the value of the "availability_schedule" field.
+func (u *AccountUpsert) ClearAvailabilitySchedule() *AccountUpsert {
+	u.SetNull(account.FieldAvailabilitySchedule)
+	return u
+}
+
+// SetAvailabilityNextTransitionAt sets the "availability_next_transition_at" field.
+func (u *AccountUpsert) SetAvailabilityNextTransitionAt(v time.Time) *AccountUpsert {
+	u.Set(account.FieldAvailabilityNextTransitionAt, v)
+	return u
+}
+
+// UpdateAvailabilityNextTransitionAt sets the "availability_next_transition_at" field to the value that was provided on create.
+func (u *AccountUpsert) UpdateAvailabilityNextTransitionAt() *AccountUpsert {
+	u.SetExcluded(account.FieldAvailabilityNextTransitionAt)
+	return u
+}
+
+// ClearAvailabilityNextTransitionAt clears the value of the "availability_next_transition_at" field.
+func (u *AccountUpsert) ClearAvailabilityNextTransitionAt() *AccountUpsert {
+	u.SetNull(account.FieldAvailabilityNextTransitionAt)
+	return u
+}
+
 // UpdateNewValues updates the mutable fields using the new values that were set on create.
 // Using this option is equivalent to using:
 //
@@ -1620,6 +1715,62 @@ func (u *AccountUpsertOne) ClearSessionWindowStatus() *AccountUpsertOne {
 	})
 }
 
+// SetTags sets the "tags" field.
+func (u *AccountUpsertOne) SetTags(v pq.StringArray) *AccountUpsertOne {
+	return u.Update(func(s *AccountUpsert) {
+		s.SetTags(v)
+	})
+}
+
+// UpdateTags sets the "tags" field to the value that was provided on create.
+func (u *AccountUpsertOne) UpdateTags() *AccountUpsertOne {
+	return u.Update(func(s *AccountUpsert) {
+		s.UpdateTags()
+	})
+}
+
+// SetAvailabilitySchedule sets the "availability_schedule" field.
+func (u *AccountUpsertOne) SetAvailabilitySchedule(v json.RawMessage) *AccountUpsertOne {
+	return u.Update(func(s *AccountUpsert) {
+		s.SetAvailabilitySchedule(v)
+	})
+}
+
+// UpdateAvailabilitySchedule sets the "availability_schedule" field to the value that was provided on create.
+func (u *AccountUpsertOne) UpdateAvailabilitySchedule() *AccountUpsertOne {
+	return u.Update(func(s *AccountUpsert) {
+		s.UpdateAvailabilitySchedule()
+	})
+}
+
+// ClearAvailabilitySchedule clears the value of the "availability_schedule" field.
+func (u *AccountUpsertOne) ClearAvailabilitySchedule() *AccountUpsertOne {
+	return u.Update(func(s *AccountUpsert) {
+		s.ClearAvailabilitySchedule()
+	})
+}
+
+// SetAvailabilityNextTransitionAt sets the "availability_next_transition_at" field.
+func (u *AccountUpsertOne) SetAvailabilityNextTransitionAt(v time.Time) *AccountUpsertOne {
+	return u.Update(func(s *AccountUpsert) {
+		s.SetAvailabilityNextTransitionAt(v)
+	})
+}
+
+// UpdateAvailabilityNextTransitionAt sets the "availability_next_transition_at" field to the value that was provided on create.
+func (u *AccountUpsertOne) UpdateAvailabilityNextTransitionAt() *AccountUpsertOne {
+	return u.Update(func(s *AccountUpsert) {
+		s.UpdateAvailabilityNextTransitionAt()
+	})
+}
+
+// ClearAvailabilityNextTransitionAt clears the value of the "availability_next_transition_at" field.
+func (u *AccountUpsertOne) ClearAvailabilityNextTransitionAt() *AccountUpsertOne {
+	return u.Update(func(s *AccountUpsert) {
+		s.ClearAvailabilityNextTransitionAt()
+	})
+}
+
 // Exec executes the query.
 func (u *AccountUpsertOne) Exec(ctx context.Context) error {
 	if len(u.create.conflict) == 0 {
@@ -2272,6 +2423,62 @@ func (u *AccountUpsertBulk) ClearSessionWindowStatus() *AccountUpsertBulk {
 	})
 }
 
+// SetTags sets the "tags" field.
+func (u *AccountUpsertBulk) SetTags(v pq.StringArray) *AccountUpsertBulk {
+	return u.Update(func(s *AccountUpsert) {
+		s.SetTags(v)
+	})
+}
+
+// UpdateTags sets the "tags" field to the value that was provided on create.
+func (u *AccountUpsertBulk) UpdateTags() *AccountUpsertBulk {
+	return u.Update(func(s *AccountUpsert) {
+		s.UpdateTags()
+	})
+}
+
+// SetAvailabilitySchedule sets the "availability_schedule" field.
+func (u *AccountUpsertBulk) SetAvailabilitySchedule(v json.RawMessage) *AccountUpsertBulk {
+	return u.Update(func(s *AccountUpsert) {
+		s.SetAvailabilitySchedule(v)
+	})
+}
+
+// UpdateAvailabilitySchedule sets the "availability_schedule" field to the value that was provided on create.
+func (u *AccountUpsertBulk) UpdateAvailabilitySchedule() *AccountUpsertBulk {
+	return u.Update(func(s *AccountUpsert) {
+		s.UpdateAvailabilitySchedule()
+	})
+}
+
+// ClearAvailabilitySchedule clears the value of the "availability_schedule" field.
+func (u *AccountUpsertBulk) ClearAvailabilitySchedule() *AccountUpsertBulk {
+	return u.Update(func(s *AccountUpsert) {
+		s.ClearAvailabilitySchedule()
+	})
+}
+
+// SetAvailabilityNextTransitionAt sets the "availability_next_transition_at" field.
+func (u *AccountUpsertBulk) SetAvailabilityNextTransitionAt(v time.Time) *AccountUpsertBulk {
+	return u.Update(func(s *AccountUpsert) {
+		s.SetAvailabilityNextTransitionAt(v)
+	})
+}
+
+// UpdateAvailabilityNextTransitionAt sets the "availability_next_transition_at" field to the value that was provided on create.
+func (u *AccountUpsertBulk) UpdateAvailabilityNextTransitionAt() *AccountUpsertBulk {
+	return u.Update(func(s *AccountUpsert) {
+		s.UpdateAvailabilityNextTransitionAt()
+	})
+}
+
+// ClearAvailabilityNextTransitionAt clears the value of the "availability_next_transition_at" field.
+func (u *AccountUpsertBulk) ClearAvailabilityNextTransitionAt() *AccountUpsertBulk {
+	return u.Update(func(s *AccountUpsert) {
+		s.ClearAvailabilityNextTransitionAt()
+	})
+}
+
 // Exec executes the query.
 func (u *AccountUpsertBulk) Exec(ctx context.Context) error {
 	if u.create.err != nil {
@@ -4,18 +4,21 @@ package ent
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
 
 	"entgo.io/ent/dialect/sql"
 	"entgo.io/ent/dialect/sql/sqlgraph"
+	"entgo.io/ent/dialect/sql/sqljson"
 	"entgo.io/ent/schema/field"
 	"github.com/Wei-Shaw/sub2api/ent/account"
 	"github.com/Wei-Shaw/sub2api/ent/group"
 	"github.com/Wei-Shaw/sub2api/ent/predicate"
 	"github.com/Wei-Shaw/sub2api/ent/proxy"
 	"github.com/Wei-Shaw/sub2api/ent/usagelog"
+	"github.com/lib/pq"
 )
 
 // AccountUpdate is the builder for updating Account entities.
@@ -436,6 +439,50 @@ func (_u *AccountUpdate) ClearSessionWindowStatus() *AccountUpdate {
 	return _u
 }
 
+// SetTags sets the "tags" field.
+func (_u *AccountUpdate) SetTags(v pq.StringArray) *AccountUpdate {
+	_u.mutation.SetTags(v)
+	return _u
+}
+
+// SetAvailabilitySchedule sets the "availability_schedule" field.
+func (_u *AccountUpdate) SetAvailabilitySchedule(v json.RawMessage) *AccountUpdate {
+	_u.mutation.SetAvailabilitySchedule(v)
+	return _u
+}
+
+// AppendAvailabilitySchedule appends value to the "availability_schedule" field.
+func (_u *AccountUpdate) AppendAvailabilitySchedule(v json.RawMessage) *AccountUpdate {
+	_u.mutation.AppendAvailabilitySchedule(v)
+	return _u
+}
+
+// ClearAvailabilitySchedule clears the value of the "availability_schedule" field.
+func (_u *AccountUpdate) ClearAvailabilitySchedule() *AccountUpdate {
+	_u.mutation.ClearAvailabilitySchedule()
+	return _u
+}
+
+// SetAvailabilityNextTransitionAt sets the "availability_next_transition_at" field.
+func (_u *AccountUpdate) SetAvailabilityNextTransitionAt(v time.Time) *AccountUpdate {
+	_u.mutation.SetAvailabilityNextTransitionAt(v)
+	return _u
+}
+
+// SetNillableAvailabilityNextTransitionAt sets the "availability_next_transition_at" field if the given value is not nil.
+func (_u *AccountUpdate) SetNillableAvailabilityNextTransitionAt(v *time.Time) *AccountUpdate {
+	if v != nil {
+		_u.SetAvailabilityNextTransitionAt(*v)
+	}
+	return _u
+}
+
+// ClearAvailabilityNextTransitionAt clears the value of the "availability_next_transition_at" field.
+func (_u *AccountUpdate) ClearAvailabilityNextTransitionAt() *AccountUpdate {
+	_u.mutation.ClearAvailabilityNextTransitionAt()
+	return _u
+}
+
 // AddGroupIDs adds the "groups" edge to the Group entity by IDs.
 func (_u *AccountUpdate) AddGroupIDs(ids ...int64) *AccountUpdate {
 	_u.mutation.AddGroupIDs(ids...)
@@ -719,6 +766,26 @@ func (_u *AccountUpdate) sqlSave(ctx context.Context) (_node int, err error) {
 	if _u.mutation.SessionWindowStatusCleared() {
 		_spec.ClearField(account.FieldSessionWindowStatus, field.TypeString)
 	}
+	if value, ok := _u.mutation.Tags(); ok {
+		_spec.SetField(account.FieldTags, field.TypeOther, value)
+	}
+	if value, ok := _u.mutation.AvailabilitySchedule(); ok {
+		_spec.SetField(account.FieldAvailabilitySchedule, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.AppendedAvailabilitySchedule(); ok {
+		_spec.AddModifier(func(u *sql.UpdateBuilder) {
+			sqljson.Append(u, account.FieldAvailabilitySchedule, value)
+		})
+	}
+	if _u.mutation.AvailabilityScheduleCleared() {
+		_spec.ClearField(account.FieldAvailabilitySchedule, field.TypeJSON)
+	}
+	if value, ok := _u.mutation.AvailabilityNextTransitionAt(); ok {
+		_spec.SetField(account.FieldAvailabilityNextTransitionAt, field.TypeTime, value)
+	}
+	if _u.mutation.AvailabilityNextTransitionAtCleared() {
+		_spec.ClearField(account.FieldAvailabilityNextTransitionAt, field.TypeTime)
+	}
 	if _u.mutation.GroupsCleared() {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.M2M,
@@ -1275,6 +1342,50 @@ func (_u *AccountUpdateOne) ClearSessionWindowStatus() *AccountUpdateOne {
 	return _u
 }
 
+// SetTags sets the "tags" field.
+func (_u *AccountUpdateOne) SetTags(v pq.StringArray) *AccountUpdateOne {
+	_u.mutation.SetTags(v)
+	return _u
+}
+
+// SetAvailabilitySchedule sets the "availability_schedule" field.
+func (_u *AccountUpdateOne) SetAvailabilitySchedule(v json.RawMessage) *AccountUpdateOne {
+	_u.mutation.SetAvailabilitySchedule(v)
+	return _u
+}
+
+// AppendAvailabilitySchedule appends value to the "availability_schedule" field.
+func (_u *AccountUpdateOne) AppendAvailabilitySchedule(v json.RawMessage) *AccountUpdateOne {
+	_u.mutation.AppendAvailabilitySchedule(v)
+	return _u
+}
+
+// ClearAvailabilitySchedule clears the value of the "availability_schedule" field.
+func (_u *AccountUpdateOne) ClearAvailabilitySchedule() *AccountUpdateOne {
+	_u.mutation.ClearAvailabilitySchedule()
+	return _u
+}
+
+// SetAvailabilityNextTransitionAt sets the "availability_next_transition_at" field.
+func (_u *AccountUpdateOne) SetAvailabilityNextTransitionAt(v time.Time) *AccountUpdateOne {
+	_u.mutation.SetAvailabilityNextTransitionAt(v)
+	return _u
+}
+
+// SetNillableAvailabilityNextTransitionAt sets the "availability_next_transition_at" field if the given value is not nil.
+func (_u *AccountUpdateOne) SetNillableAvailabilityNextTransitionAt(v *time.Time) *AccountUpdateOne {
+	if v != nil {
+		_u.SetAvailabilityNextTransitionAt(*v)
+	}
+	return _u
+}
+
+// ClearAvailabilityNextTransitionAt clears the value of the "availability_next_transition_at" field.
+func (_u *AccountUpdateOne) ClearAvailabilityNextTransitionAt() *AccountUpdateOne {
+	_u.mutation.ClearAvailabilityNextTransitionAt()
+	return _u
+}
+
 // AddGroupIDs adds the "groups" edge to the Group entity by IDs.
 func (_u *AccountUpdateOne) AddGroupIDs(ids ...int64) *AccountUpdateOne {
 	_u.mutation.AddGroupIDs(ids...)
@@ -1588,6 +1699,26 @@ func (_u *AccountUpdateOne) sqlSave(ctx context.Context) (_node *Account, err er
 	if _u.mutation.SessionWindowStatusCleared() {
 		_spec.ClearField(account.FieldSessionWindowStatus, field.TypeString)
 	}
+	if value, ok := _u.mutation.Tags(); ok {
+		_spec.SetField(account.FieldTags, field.TypeOther, value)
+	}
+	if value, ok := _u.mutation.AvailabilitySchedule(); ok {
+		_spec.SetField(account.FieldAvailabilitySchedule, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.AppendedAvailabilitySchedule(); ok {
+		_spec.AddModifier(func(u *sql.UpdateBuilder) {
+			sqljson.Append(u, account.FieldAvailabilitySchedule, value)
+		})
+	}
+	if _u.mutation.AvailabilityScheduleCleared() {
+		_spec.ClearField(account.FieldAvailabilitySchedule, field.TypeJSON)
+	}
+	if value, ok := _u.mutation.AvailabilityNextTransitionAt(); ok {
+		_spec.SetField(account.FieldAvailabilityNextTransitionAt, field.TypeTime, value)
+	}
+	if _u.mutation.AvailabilityNextTransitionAtCleared() {
+		_spec.ClearField(account.FieldAvailabilityNextTransitionAt, field.TypeTime)
+	}
 	if _u.mutation.GroupsCleared() {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.M2M,
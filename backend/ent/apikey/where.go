@@ -95,6 +95,36 @@ func Status(v string) predicate.APIKey {
 	return predicate.APIKey(sql.FieldEQ(FieldStatus, v))
 }
 
+// ExpiresAt applies equality check predicate on the "expires_at" field. It's identical to ExpiresAtEQ.
+func ExpiresAt(v time.Time) predicate.APIKey {
+	return predicate.APIKey(sql.FieldEQ(FieldExpiresAt, v))
+}
+
+// TotalLimitUsd applies equality check predicate on the "total_limit_usd" field. It's identical to TotalLimitUsdEQ.
+func TotalLimitUsd(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldEQ(FieldTotalLimitUsd, v))
+}
+
+// DailyLimitUsd applies equality check predicate on the "daily_limit_usd" field. It's identical to DailyLimitUsdEQ.
+func DailyLimitUsd(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldEQ(FieldDailyLimitUsd, v))
+}
+
+// MonthlyLimitUsd applies equality check predicate on the "monthly_limit_usd" field. It's identical to MonthlyLimitUsdEQ.
+func MonthlyLimitUsd(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldEQ(FieldMonthlyLimitUsd, v))
+}
+
+// DailyRequestLimit applies equality check predicate on the "daily_request_limit" field. It's identical to DailyRequestLimitEQ.
+func DailyRequestLimit(v int64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldEQ(FieldDailyRequestLimit, v))
+}
+
+// MonthlyRequestLimit applies equality check predicate on the "monthly_request_limit" field. It's identical to MonthlyRequestLimitEQ.
+func MonthlyRequestLimit(v int64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldEQ(FieldMonthlyRequestLimit, v))
+}
+
 // CreatedAtEQ applies the EQ predicate on the "created_at" field.
 func CreatedAtEQ(v time.Time) predicate.APIKey {
 	return predicate.APIKey(sql.FieldEQ(FieldCreatedAt, v))
@@ -490,6 +520,326 @@ func IPBlacklistNotNil() predicate.APIKey {
 	return predicate.APIKey(sql.FieldNotNull(FieldIPBlacklist))
 }
 
+// ExpiresAtEQ applies the EQ predicate on the "expires_at" field.
+func ExpiresAtEQ(v time.Time) predicate.APIKey {
+	return predicate.APIKey(sql.FieldEQ(FieldExpiresAt, v))
+}
+
+// ExpiresAtNEQ applies the NEQ predicate on the "expires_at" field.
+func ExpiresAtNEQ(v time.Time) predicate.APIKey {
+	return predicate.APIKey(sql.FieldNEQ(FieldExpiresAt, v))
+}
+
+// ExpiresAtIn applies the In predicate on the "expires_at" field.
+func ExpiresAtIn(vs ...time.Time) predicate.APIKey {
+	return predicate.APIKey(sql.FieldIn(FieldExpiresAt, vs...))
+}
+
+// ExpiresAtNotIn applies the NotIn predicate on the "expires_at" field.
+func ExpiresAtNotIn(vs ...time.Time) predicate.APIKey {
+	return predicate.APIKey(sql.FieldNotIn(FieldExpiresAt, vs...))
+}
+
+// ExpiresAtGT applies the GT predicate on the "expires_at" field.
+func ExpiresAtGT(v time.Time) predicate.APIKey {
+	return predicate.APIKey(sql.FieldGT(FieldExpiresAt, v))
+}
+
+// ExpiresAtGTE applies the GTE predicate on the "expires_at" field.
+func ExpiresAtGTE(v time.Time) predicate.APIKey {
+	return predicate.APIKey(sql.FieldGTE(FieldExpiresAt, v))
+}
+
+// ExpiresAtLT applies the LT predicate on the "expires_at" field.
+func ExpiresAtLT(v time.Time) predicate.APIKey {
+	return predicate.APIKey(sql.FieldLT(FieldExpiresAt, v))
+}
+
+// ExpiresAtLTE applies the LTE predicate on the "expires_at" field.
+func ExpiresAtLTE(v time.Time) predicate.APIKey {
+	return predicate.APIKey(sql.FieldLTE(FieldExpiresAt, v))
+}
+
+// ExpiresAtIsNil applies the IsNil predicate on the "expires_at" field.
+func ExpiresAtIsNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldIsNull(FieldExpiresAt))
+}
+
+// ExpiresAtNotNil applies the NotNil predicate on the "expires_at" field.
+func ExpiresAtNotNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldNotNull(FieldExpiresAt))
+}
+
+// TotalLimitUsdEQ applies the EQ predicate on the "total_limit_usd" field.
+func TotalLimitUsdEQ(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldEQ(FieldTotalLimitUsd, v))
+}
+
+// TotalLimitUsdNEQ applies the NEQ predicate on the "total_limit_usd" field.
+func TotalLimitUsdNEQ(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldNEQ(FieldTotalLimitUsd, v))
+}
+
+// TotalLimitUsdIn applies the In predicate on the "total_limit_usd" field.
+func TotalLimitUsdIn(vs ...float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldIn(FieldTotalLimitUsd, vs...))
+}
+
+// TotalLimitUsdNotIn applies the NotIn predicate on the "total_limit_usd" field.
+func TotalLimitUsdNotIn(vs ...float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldNotIn(FieldTotalLimitUsd, vs...))
+}
+
+// TotalLimitUsdGT applies the GT predicate on the "total_limit_usd" field.
+func TotalLimitUsdGT(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldGT(FieldTotalLimitUsd, v))
+}
+
+// TotalLimitUsdGTE applies the GTE predicate on the "total_limit_usd" field.
+func TotalLimitUsdGTE(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldGTE(FieldTotalLimitUsd, v))
+}
+
+// TotalLimitUsdLT applies the LT predicate on the "total_limit_usd" field.
+func TotalLimitUsdLT(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldLT(FieldTotalLimitUsd, v))
+}
+
+// TotalLimitUsdLTE applies the LTE predicate on the "total_limit_usd" field.
+func TotalLimitUsdLTE(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldLTE(FieldTotalLimitUsd, v))
+}
+
+// TotalLimitUsdIsNil applies the IsNil predicate on the "total_limit_usd" field.
+func TotalLimitUsdIsNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldIsNull(FieldTotalLimitUsd))
+}
+
+// TotalLimitUsdNotNil applies the NotNil predicate on the "total_limit_usd" field.
+func TotalLimitUsdNotNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldNotNull(FieldTotalLimitUsd))
+}
+
+// DailyLimitUsdEQ applies the EQ predicate on the "daily_limit_usd" field.
+func DailyLimitUsdEQ(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldEQ(FieldDailyLimitUsd, v))
+}
+
+// DailyLimitUsdNEQ applies the NEQ predicate on the "daily_limit_usd" field.
+func DailyLimitUsdNEQ(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldNEQ(FieldDailyLimitUsd, v))
+}
+
+// DailyLimitUsdIn applies the In predicate on the "daily_limit_usd" field.
+func DailyLimitUsdIn(vs ...float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldIn(FieldDailyLimitUsd, vs...))
+}
+
+// DailyLimitUsdNotIn applies the NotIn predicate on the "daily_limit_usd" field.
+func DailyLimitUsdNotIn(vs ...float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldNotIn(FieldDailyLimitUsd, vs...))
+}
+
+// DailyLimitUsdGT applies the GT predicate on the "daily_limit_usd" field.
+func DailyLimitUsdGT(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldGT(FieldDailyLimitUsd, v))
+}
+
+// DailyLimitUsdGTE applies the GTE predicate on the "daily_limit_usd" field.
+func DailyLimitUsdGTE(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldGTE(FieldDailyLimitUsd, v))
+}
+
+// DailyLimitUsdLT applies the LT predicate on the "daily_limit_usd" field.
+func DailyLimitUsdLT(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldLT(FieldDailyLimitUsd, v))
+}
+
+// DailyLimitUsdLTE applies the LTE predicate on the "daily_limit_usd" field.
+func DailyLimitUsdLTE(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldLTE(FieldDailyLimitUsd, v))
+}
+
+// DailyLimitUsdIsNil applies the IsNil predicate on the "daily_limit_usd" field.
+func DailyLimitUsdIsNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldIsNull(FieldDailyLimitUsd))
+}
+
+// DailyLimitUsdNotNil applies the NotNil predicate on the "daily_limit_usd" field.
+func DailyLimitUsdNotNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldNotNull(FieldDailyLimitUsd))
+}
+
+// MonthlyLimitUsdEQ applies the EQ predicate on the "monthly_limit_usd" field.
+func MonthlyLimitUsdEQ(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldEQ(FieldMonthlyLimitUsd, v))
+}
+
+// MonthlyLimitUsdNEQ applies the NEQ predicate on the "monthly_limit_usd" field.
+func MonthlyLimitUsdNEQ(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldNEQ(FieldMonthlyLimitUsd, v))
+}
+
+// MonthlyLimitUsdIn applies the In predicate on the "monthly_limit_usd" field.
+func MonthlyLimitUsdIn(vs ...float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldIn(FieldMonthlyLimitUsd, vs...))
+}
+
+// MonthlyLimitUsdNotIn applies the NotIn predicate on the "monthly_limit_usd" field.
+func MonthlyLimitUsdNotIn(vs ...float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldNotIn(FieldMonthlyLimitUsd, vs...))
+}
+
+// MonthlyLimitUsdGT applies the GT predicate on the "monthly_limit_usd" field.
+func MonthlyLimitUsdGT(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldGT(FieldMonthlyLimitUsd, v))
+}
+
+// MonthlyLimitUsdGTE applies the GTE predicate on the "monthly_limit_usd" field.
+func MonthlyLimitUsdGTE(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldGTE(FieldMonthlyLimitUsd, v))
+}
+
+// MonthlyLimitUsdLT applies the LT predicate on the "monthly_limit_usd" field.
+func MonthlyLimitUsdLT(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldLT(FieldMonthlyLimitUsd, v))
+}
+
+// MonthlyLimitUsdLTE applies the LTE predicate on the "monthly_limit_usd" field.
+func MonthlyLimitUsdLTE(v float64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldLTE(FieldMonthlyLimitUsd, v))
+}
+
+// MonthlyLimitUsdIsNil applies the IsNil predicate on the "monthly_limit_usd" field.
+func MonthlyLimitUsdIsNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldIsNull(FieldMonthlyLimitUsd))
+}
+
+// MonthlyLimitUsdNotNil applies the NotNil predicate on the "monthly_limit_usd" field.
+func MonthlyLimitUsdNotNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldNotNull(FieldMonthlyLimitUsd))
+}
+
+// DailyRequestLimitEQ applies the EQ predicate on the "daily_request_limit" field.
+func DailyRequestLimitEQ(v int64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldEQ(FieldDailyRequestLimit, v))
+}
+
+// DailyRequestLimitNEQ applies the NEQ predicate on the "daily_request_limit" field.
+func DailyRequestLimitNEQ(v int64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldNEQ(FieldDailyRequestLimit, v))
+}
+
+// DailyRequestLimitIn applies the In predicate on the "daily_request_limit" field.
+func DailyRequestLimitIn(vs ...int64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldIn(FieldDailyRequestLimit, vs...))
+}
+
+// DailyRequestLimitNotIn applies the NotIn predicate on the "daily_request_limit" field.
+func DailyRequestLimitNotIn(vs ...int64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldNotIn(FieldDailyRequestLimit, vs...))
+}
+
+// DailyRequestLimitGT applies the GT predicate on the "daily_request_limit" field.
+func DailyRequestLimitGT(v int64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldGT(FieldDailyRequestLimit, v))
+}
+
+// DailyRequestLimitGTE applies the GTE predicate on the "daily_request_limit" field.
+func DailyRequestLimitGTE(v int64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldGTE(FieldDailyRequestLimit, v))
+}
+
+// DailyRequestLimitLT applies the LT predicate on the "daily_request_limit" field.
+func DailyRequestLimitLT(v int64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldLT(FieldDailyRequestLimit, v))
+}
+
+// DailyRequestLimitLTE applies the LTE predicate on the "daily_request_limit" field.
+func DailyRequestLimitLTE(v int64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldLTE(FieldDailyRequestLimit, v))
+}
+
+// DailyRequestLimitIsNil applies the IsNil predicate on the "daily_request_limit" field.
+func DailyRequestLimitIsNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldIsNull(FieldDailyRequestLimit))
+}
+
+// DailyRequestLimitNotNil applies the NotNil predicate on the "daily_request_limit" field.
+func DailyRequestLimitNotNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldNotNull(FieldDailyRequestLimit))
+}
+
+// MonthlyRequestLimitEQ applies the EQ predicate on the "monthly_request_limit" field.
+func MonthlyRequestLimitEQ(v int64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldEQ(FieldMonthlyRequestLimit, v))
+}
+
+// MonthlyRequestLimitNEQ applies the NEQ predicate on the "monthly_request_limit" field.
+func MonthlyRequestLimitNEQ(v int64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldNEQ(FieldMonthlyRequestLimit, v))
+}
+
+// MonthlyRequestLimitIn applies the In predicate on the "monthly_request_limit" field.
+func MonthlyRequestLimitIn(vs ...int64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldIn(FieldMonthlyRequestLimit, vs...))
+}
+
+// MonthlyRequestLimitNotIn applies the NotIn predicate on the "monthly_request_limit" field.
+func MonthlyRequestLimitNotIn(vs ...int64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldNotIn(FieldMonthlyRequestLimit, vs...))
+}
+
+// MonthlyRequestLimitGT applies the GT predicate on the "monthly_request_limit" field.
+func MonthlyRequestLimitGT(v int64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldGT(FieldMonthlyRequestLimit, v))
+}
+
+// MonthlyRequestLimitGTE applies the GTE predicate on the "monthly_request_limit" field.
+func MonthlyRequestLimitGTE(v int64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldGTE(FieldMonthlyRequestLimit, v))
+}
+
+// MonthlyRequestLimitLT applies the LT predicate on the "monthly_request_limit" field.
+func MonthlyRequestLimitLT(v int64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldLT(FieldMonthlyRequestLimit, v))
+}
+
+// MonthlyRequestLimitLTE applies the LTE predicate on the "monthly_request_limit" field.
+func MonthlyRequestLimitLTE(v int64) predicate.APIKey {
+	return predicate.APIKey(sql.FieldLTE(FieldMonthlyRequestLimit, v))
+}
+
+// MonthlyRequestLimitIsNil applies the IsNil predicate on the "monthly_request_limit" field.
+func MonthlyRequestLimitIsNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldIsNull(FieldMonthlyRequestLimit))
+}
+
+// MonthlyRequestLimitNotNil applies the NotNil predicate on the "monthly_request_limit" field.
+func MonthlyRequestLimitNotNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldNotNull(FieldMonthlyRequestLimit))
+}
+
+// AllowedModelsIsNil applies the IsNil predicate on the "allowed_models" field.
+func AllowedModelsIsNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldIsNull(FieldAllowedModels))
+}
+
+// AllowedModelsNotNil applies the NotNil predicate on the "allowed_models" field.
+func AllowedModelsNotNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldNotNull(FieldAllowedModels))
+}
+
+// AllowedPlatformsIsNil applies the IsNil predicate on the "allowed_platforms" field.
+func AllowedPlatformsIsNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldIsNull(FieldAllowedPlatforms))
+}
+
+// AllowedPlatformsNotNil applies the NotNil predicate on the "allowed_platforms" field.
+func AllowedPlatformsNotNil() predicate.APIKey {
+	return predicate.APIKey(sql.FieldNotNull(FieldAllowedPlatforms))
+}
+
 // HasUser applies the HasEdge predicate on the "user" edge.
 func HasUser() predicate.APIKey {
 	return predicate.APIKey(func(s *sql.Selector) {
@@ -125,6 +125,102 @@ func (_c *APIKeyCreate) SetIPBlacklist(v []string) *APIKeyCreate {
 	return _c
 }
 
+// SetExpiresAt sets the "expires_at" field.
+func (_c *APIKeyCreate) SetExpiresAt(v time.Time) *APIKeyCreate {
+	_c.mutation.SetExpiresAt(v)
+	return _c
+}
+
+// SetNillableExpiresAt sets the "expires_at" field if the given value is not nil.
+func (_c *APIKeyCreate) SetNillableExpiresAt(v *time.Time) *APIKeyCreate {
+	if v != nil {
+		_c.SetExpiresAt(*v)
+	}
+	return _c
+}
+
+// SetTotalLimitUsd sets the "total_limit_usd" field.
+func (_c *APIKeyCreate) SetTotalLimitUsd(v float64) *APIKeyCreate {
+	_c.mutation.SetTotalLimitUsd(v)
+	return _c
+}
+
+// SetNillableTotalLimitUsd sets the "total_limit_usd" field if the given value is not nil.
+func (_c *APIKeyCreate) SetNillableTotalLimitUsd(v *float64) *APIKeyCreate {
+	if v != nil {
+		_c.SetTotalLimitUsd(*v)
+	}
+	return _c
+}
+
+// SetDailyLimitUsd sets the "daily_limit_usd" field.
+func (_c *APIKeyCreate) SetDailyLimitUsd(v float64) *APIKeyCreate {
+	_c.mutation.SetDailyLimitUsd(v)
+	return _c
+}
+
+// SetNillableDailyLimitUsd sets the "daily_limit_usd" field if the given value is not nil.
+func (_c *APIKeyCreate) SetNillableDailyLimitUsd(v *float64) *APIKeyCreate {
+	if v != nil {
+		_c.SetDailyLimitUsd(*v)
+	}
+	return _c
+}
+
+// SetMonthlyLimitUsd sets the "monthly_limit_usd" field.
+func (_c *APIKeyCreate) SetMonthlyLimitUsd(v float64) *APIKeyCreate {
+	_c.mutation.SetMonthlyLimitUsd(v)
+	return _c
+}
+
+// SetNillableMonthlyLimitUsd sets the "monthly_limit_usd" field if the given value is not nil.
+func (_c *APIKeyCreate) SetNillableMonthlyLimitUsd(v *float64) *APIKeyCreate {
+	if v != nil {
+		_c.SetMonthlyLimitUsd(*v)
+	}
+	return _c
+}
+
+// SetDailyRequestLimit sets the "daily_request_limit" field.
+func (_c *APIKeyCreate) SetDailyRequestLimit(v int64) *APIKeyCreate {
+	_c.mutation.SetDailyRequestLimit(v)
+	return _c
+}
+
+// SetNillableDailyRequestLimit sets the "daily_request_limit" field if the given value is not nil.
+func (_c *APIKeyCreate) SetNillableDailyRequestLimit(v *int64) *APIKeyCreate {
+	if v != nil {
+		_c.SetDailyRequestLimit(*v)
+	}
+	return _c
+}
+
+// SetMonthlyRequestLimit sets the "monthly_request_limit" field.
+func (_c *APIKeyCreate) SetMonthlyRequestLimit(v int64) *APIKeyCreate {
+	_c.mutation.SetMonthlyRequestLimit(v)
+	return _c
+}
+
+// SetNillableMonthlyRequestLimit sets the "monthly_request_limit" field if the given value is not nil.
+func (_c *APIKeyCreate) SetNillableMonthlyRequestLimit(v *int64) *APIKeyCreate {
+	if v != nil {
+		_c.SetMonthlyRequestLimit(*v)
+	}
+	return _c
+}
+
+// SetAllowedModels sets the "allowed_models" field.
+func (_c *APIKeyCreate) SetAllowedModels(v []string) *APIKeyCreate {
+	_c.mutation.SetAllowedModels(v)
+	return _c
+}
+
+// SetAllowedPlatforms sets the "allowed_platforms" field.
+func (_c *APIKeyCreate) SetAllowedPlatforms(v []string) *APIKeyCreate {
+	_c.mutation.SetAllowedPlatforms(v)
+	return _c
+}
+
 // SetUser sets the "user" edge to the User entity.
 func (_c *APIKeyCreate) SetUser(v *User) *APIKeyCreate {
 	return _c.SetUserID(v.ID)
@@ -305,6 +401,38 @@ func (_c *APIKeyCreate) createSpec() (*APIKey, *sqlgraph.CreateSpec) {
 		_spec.SetField(apikey.FieldIPBlacklist, field.TypeJSON, value)
 		_node.IPBlacklist = value
 	}
+	if value, ok := _c.mutation.ExpiresAt(); ok {
+		_spec.SetField(apikey.FieldExpiresAt, field.TypeTime, value)
+		_node.ExpiresAt = &value
+	}
+	if value, ok := _c.mutation.TotalLimitUsd(); ok {
+		_spec.SetField(apikey.FieldTotalLimitUsd, field.TypeFloat64, value)
+		_node.TotalLimitUsd = &value
+	}
+	if value, ok := _c.mutation.DailyLimitUsd(); ok {
+		_spec.SetField(apikey.FieldDailyLimitUsd, field.TypeFloat64, value)
+		_node.DailyLimitUsd = &value
+	}
+	if value, ok := _c.mutation.MonthlyLimitUsd(); ok {
+		_spec.SetField(apikey.FieldMonthlyLimitUsd, field.TypeFloat64, value)
+		_node.MonthlyLimitUsd = &value
+	}
+	if value, ok := _c.mutation.DailyRequestLimit(); ok {
+		_spec.SetField(apikey.FieldDailyRequestLimit, field.TypeInt64, value)
+		_node.DailyRequestLimit = &value
+	}
+	if value, ok := _c.mutation.MonthlyRequestLimit(); ok {
+		_spec.SetField(apikey.FieldMonthlyRequestLimit, field.TypeInt64, value)
+		_node.MonthlyRequestLimit = &value
+	}
+	if value, ok := _c.mutation.AllowedModels(); ok {
+		_spec.SetField(apikey.FieldAllowedModels, field.TypeJSON, value)
+		_node.AllowedModels = value
+	}
+	if value, ok := _c.mutation.AllowedPlatforms(); ok {
+		_spec.SetField(apikey.FieldAllowedPlatforms, field.TypeJSON, value)
+		_node.AllowedPlatforms = value
+	}
 	if nodes := _c.mutation.UserIDs(); len(nodes) > 0 {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.M2O,
@@ -539,6 +667,180 @@ func (u *APIKeyUpsert) ClearIPBlacklist() *APIKeyUpsert {
 	return u
 }
 
+// SetExpiresAt sets the "expires_at" field.
+func (u *APIKeyUpsert) SetExpiresAt(v time.Time) *APIKeyUpsert {
+	u.Set(apikey.FieldExpiresAt, v)
+	return u
+}
+
+// UpdateExpiresAt sets the "expires_at" field to the value that was provided on create.
+func (u *APIKeyUpsert) UpdateExpiresAt() *APIKeyUpsert {
+	u.SetExcluded(apikey.FieldExpiresAt)
+	return u
+}
+
+// ClearExpiresAt clears the value of the "expires_at" field.
+func (u *APIKeyUpsert) ClearExpiresAt() *APIKeyUpsert {
+	u.SetNull(apikey.FieldExpiresAt)
+	return u
+}
+
+// SetTotalLimitUsd sets the "total_limit_usd" field.
+func (u *APIKeyUpsert) SetTotalLimitUsd(v float64) *APIKeyUpsert {
+	u.Set(apikey.FieldTotalLimitUsd, v)
+	return u
+}
+
+// UpdateTotalLimitUsd sets the "total_limit_usd" field to the value that was provided on create.
+func (u *APIKeyUpsert) UpdateTotalLimitUsd() *APIKeyUpsert {
+	u.SetExcluded(apikey.FieldTotalLimitUsd)
+	return u
+}
+
+// AddTotalLimitUsd adds v to the "total_limit_usd" field.
+func (u *APIKeyUpsert) AddTotalLimitUsd(v float64) *APIKeyUpsert {
+	u.Add(apikey.FieldTotalLimitUsd, v)
+	return u
+}
+
+// ClearTotalLimitUsd clears the value of the "total_limit_usd" field.
+func (u *APIKeyUpsert) ClearTotalLimitUsd() *APIKeyUpsert {
+	u.SetNull(apikey.FieldTotalLimitUsd)
+	return u
+}
+
+// SetDailyLimitUsd sets the "daily_limit_usd" field.
+func (u *APIKeyUpsert) SetDailyLimitUsd(v float64) *APIKeyUpsert {
+	u.Set(apikey.FieldDailyLimitUsd, v)
+	return u
+}
+
+// UpdateDailyLimitUsd sets the "daily_limit_usd" field to the value that was provided on create.
+func (u *APIKeyUpsert) UpdateDailyLimitUsd() *APIKeyUpsert {
+	u.SetExcluded(apikey.FieldDailyLimitUsd)
+	return u
+}
+
+// AddDailyLimitUsd adds v to the "daily_limit_usd" field.
+func (u *APIKeyUpsert) AddDailyLimitUsd(v float64) *APIKeyUpsert {
+	u.Add(apikey.FieldDailyLimitUsd, v)
+	return u
+}
+
+// ClearDailyLimitUsd clears the value of the "daily_limit_usd" field.
+func (u *APIKeyUpsert) ClearDailyLimitUsd() *APIKeyUpsert {
+	u.SetNull(apikey.FieldDailyLimitUsd)
+	return u
+}
+
+// SetMonthlyLimitUsd sets the "monthly_limit_usd" field.
+func (u *APIKeyUpsert) SetMonthlyLimitUsd(v float64) *APIKeyUpsert {
+	u.Set(apikey.FieldMonthlyLimitUsd, v)
+	return u
+}
+
+// UpdateMonthlyLimitUsd sets the "monthly_limit_usd" field to the value that was provided on create.
+func (u *APIKeyUpsert) UpdateMonthlyLimitUsd() *APIKeyUpsert {
+	u.SetExcluded(apikey.FieldMonthlyLimitUsd)
+	return u
+}
+
+// AddMonthlyLimitUsd adds v to the "monthly_limit_usd" field.
+func (u *APIKeyUpsert) AddMonthlyLimitUsd(v float64) *APIKeyUpsert {
+	u.Add(apikey.FieldMonthlyLimitUsd, v)
+	return u
+}
+
+// ClearMonthlyLimitUsd clears the value of the "monthly_limit_usd" field.
+func (u *APIKeyUpsert) ClearMonthlyLimitUsd() *APIKeyUpsert {
+	u.SetNull(apikey.FieldMonthlyLimitUsd)
+	return u
+}
+
+// SetDailyRequestLimit sets the "daily_request_limit" field.
+func (u *APIKeyUpsert) SetDailyRequestLimit(v int64) *APIKeyUpsert {
+	u.Set(apikey.FieldDailyRequestLimit, v)
+	return u
+}
+
+// UpdateDailyRequestLimit sets the "daily_request_limit" field to the value that was provided on create.
+func (u *APIKeyUpsert) UpdateDailyRequestLimit() *APIKeyUpsert {
+	u.SetExcluded(apikey.FieldDailyRequestLimit)
+	return u
+}
+
+// AddDailyRequestLimit adds v to the "daily_request_limit" field.
+func (u *APIKeyUpsert) AddDailyRequestLimit(v int64) *APIKeyUpsert {
+	u.Add(apikey.FieldDailyRequestLimit, v)
+	return u
+}
+
+// ClearDailyRequestLimit clears the value of the "daily_request_limit" field.
+func (u *APIKeyUpsert) ClearDailyRequestLimit() *APIKeyUpsert {
+	u.SetNull(apikey.FieldDailyRequestLimit)
+	return u
+}
+
+// SetMonthlyRequestLimit sets the "monthly_request_limit" field.
+func (u *APIKeyUpsert) SetMonthlyRequestLimit(v int64) *APIKeyUpsert {
+	u.Set(apikey.FieldMonthlyRequestLimit, v)
+	return u
+}
+
+// UpdateMonthlyRequestLimit sets the "monthly_request_limit" field to the value that was provided on create.
+func (u *APIKeyUpsert) UpdateMonthlyRequestLimit() *APIKeyUpsert {
+	u.SetExcluded(apikey.FieldMonthlyRequestLimit)
+	return u
+}
+
+// AddMonthlyRequestLimit adds v to the "monthly_request_limit" field.
+func (u *APIKeyUpsert) AddMonthlyRequestLimit(v int64) *APIKeyUpsert {
+	u.Add(apikey.FieldMonthlyRequestLimit, v)
+	return u
+}
+
+// ClearMonthlyRequestLimit clears the value of the "monthly_request_limit" field.
+func (u *APIKeyUpsert) ClearMonthlyRequestLimit() *APIKeyUpsert {
+	u.SetNull(apikey.FieldMonthlyRequestLimit)
+	return u
+}
+
+// SetAllowedModels sets the "allowed_models" field.
+func (u *APIKeyUpsert) SetAllowedModels(v []string) *APIKeyUpsert {
+	u.Set(apikey.FieldAllowedModels, v)
+	return u
+}
+
+// UpdateAllowedModels sets the "allowed_models" field to the value that was provided on create.
+func (u *APIKeyUpsert) UpdateAllowedModels() *APIKeyUpsert {
+	u.SetExcluded(apikey.FieldAllowedModels)
+	return u
+}
+
+// ClearAllowedModels clears the value of the "allowed_models" field.
+func (u *APIKeyUpsert) ClearAllowedModels() *APIKeyUpsert {
+	u.SetNull(apikey.FieldAllowedModels)
+	return u
+}
+
+// SetAllowedPlatforms sets the "allowed_platforms" field.
+func (u *APIKeyUpsert) SetAllowedPlatforms(v []string) *APIKeyUpsert {
+	u.Set(apikey.FieldAllowedPlatforms, v)
+	return u
+}
+
+// UpdateAllowedPlatforms sets the "allowed_platforms" field to the value that was provided on create.
+func (u *APIKeyUpsert) UpdateAllowedPlatforms() *APIKeyUpsert {
+	u.SetExcluded(apikey.FieldAllowedPlatforms)
+	return u
+}
+
+// ClearAllowedPlatforms clears the value of the "allowed_platforms" field.
+func (u *APIKeyUpsert) ClearAllowedPlatforms() *APIKeyUpsert {
+	u.SetNull(apikey.FieldAllowedPlatforms)
+	return u
+}
+
 // UpdateNewValues updates the mutable fields using the new values that were set on create.
 // Using this option is equivalent to using:
 //
@@ -738,6 +1040,209 @@ func (u *APIKeyUpsertOne) ClearIPBlacklist() *APIKeyUpsertOne {
 	})
 }
 
+// SetExpiresAt sets the "expires_at" field.
+func (u *APIKeyUpsertOne) SetExpiresAt(v time.Time) *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetExpiresAt(v)
+	})
+}
+
+// UpdateExpiresAt sets the "expires_at" field to the value that was provided on create.
+func (u *APIKeyUpsertOne) UpdateExpiresAt() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateExpiresAt()
+	})
+}
+
+// ClearExpiresAt clears the value of the "expires_at" field.
+func (u *APIKeyUpsertOne) ClearExpiresAt() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearExpiresAt()
+	})
+}
+
+// SetTotalLimitUsd sets the "total_limit_usd" field.
+func (u *APIKeyUpsertOne) SetTotalLimitUsd(v float64) *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetTotalLimitUsd(v)
+	})
+}
+
+// AddTotalLimitUsd adds v to the "total_limit_usd" field.
+func (u *APIKeyUpsertOne) AddTotalLimitUsd(v float64) *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.AddTotalLimitUsd(v)
+	})
+}
+
+// UpdateTotalLimitUsd sets the "total_limit_usd" field to the value that was provided on create.
+func (u *APIKeyUpsertOne) UpdateTotalLimitUsd() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateTotalLimitUsd()
+	})
+}
+
+// ClearTotalLimitUsd clears the value of the "total_limit_usd" field.
+func (u *APIKeyUpsertOne) ClearTotalLimitUsd() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearTotalLimitUsd()
+	})
+}
+
+// SetDailyLimitUsd sets the "daily_limit_usd" field.
+func (u *APIKeyUpsertOne) SetDailyLimitUsd(v float64) *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetDailyLimitUsd(v)
+	})
+}
+
+// AddDailyLimitUsd adds v to the "daily_limit_usd" field.
+func (u *APIKeyUpsertOne) AddDailyLimitUsd(v float64) *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.AddDailyLimitUsd(v)
+	})
+}
+
+// UpdateDailyLimitUsd sets the "daily_limit_usd" field to the value that was provided on create.
+func (u *APIKeyUpsertOne) UpdateDailyLimitUsd() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateDailyLimitUsd()
+	})
+}
+
+// ClearDailyLimitUsd clears the value of the "daily_limit_usd" field.
+func (u *APIKeyUpsertOne) ClearDailyLimitUsd() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearDailyLimitUsd()
+	})
+}
+
+// SetMonthlyLimitUsd sets the "monthly_limit_usd" field.
+func (u *APIKeyUpsertOne) SetMonthlyLimitUsd(v float64) *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetMonthlyLimitUsd(v)
+	})
+}
+
+// AddMonthlyLimitUsd adds v to the "monthly_limit_usd" field.
+func (u *APIKeyUpsertOne) AddMonthlyLimitUsd(v float64) *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.AddMonthlyLimitUsd(v)
+	})
+}
+
+// UpdateMonthlyLimitUsd sets the "monthly_limit_usd" field to the value that was provided on create.
+func (u *APIKeyUpsertOne) UpdateMonthlyLimitUsd() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateMonthlyLimitUsd()
+	})
+}
+
+// ClearMonthlyLimitUsd clears the value of the "monthly_limit_usd" field.
+func (u *APIKeyUpsertOne) ClearMonthlyLimitUsd() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearMonthlyLimitUsd()
+	})
+}
+
+// SetDailyRequestLimit sets the "daily_request_limit" field.
+func (u *APIKeyUpsertOne) SetDailyRequestLimit(v int64) *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetDailyRequestLimit(v)
+	})
+}
+
+// AddDailyRequestLimit adds v to the "daily_request_limit" field.
+func (u *APIKeyUpsertOne) AddDailyRequestLimit(v int64) *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.AddDailyRequestLimit(v)
+	})
+}
+
+// UpdateDailyRequestLimit sets the "daily_request_limit" field to the value that was provided on create.
+func (u *APIKeyUpsertOne) UpdateDailyRequestLimit() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateDailyRequestLimit()
+	})
+}
+
+// ClearDailyRequestLimit clears the value of the "daily_request_limit" field.
+func (u *APIKeyUpsertOne) ClearDailyRequestLimit() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearDailyRequestLimit()
+	})
+}
+
+// SetMonthlyRequestLimit sets the "monthly_request_limit" field.
+func (u *APIKeyUpsertOne) SetMonthlyRequestLimit(v int64) *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetMonthlyRequestLimit(v)
+	})
+}
+
+// AddMonthlyRequestLimit adds v to the "monthly_request_limit" field.
+func (u *APIKeyUpsertOne) AddMonthlyRequestLimit(v int64) *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.AddMonthlyRequestLimit(v)
+	})
+}
+
+// UpdateMonthlyRequestLimit sets the "monthly_request_limit" field to the value that was provided on create.
+func (u *APIKeyUpsertOne) UpdateMonthlyRequestLimit() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateMonthlyRequestLimit()
+	})
+}
+
+// ClearMonthlyRequestLimit clears the value of the "monthly_request_limit" field.
+func (u *APIKeyUpsertOne) ClearMonthlyRequestLimit() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearMonthlyRequestLimit()
+	})
+}
+
+// SetAllowedModels sets the "allowed_models" field.
+func (u *APIKeyUpsertOne) SetAllowedModels(v []string) *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetAllowedModels(v)
+	})
+}
+
+// UpdateAllowedModels sets the "allowed_models" field to the value that was provided on create.
+func (u *APIKeyUpsertOne) UpdateAllowedModels() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateAllowedModels()
+	})
+}
+
+// ClearAllowedModels clears the value of the "allowed_models" field.
+func (u *APIKeyUpsertOne) ClearAllowedModels() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearAllowedModels()
+	})
+}
+
+// SetAllowedPlatforms sets the "allowed_platforms" field.
+func (u *APIKeyUpsertOne) SetAllowedPlatforms(v []string) *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetAllowedPlatforms(v)
+	})
+}
+
+// UpdateAllowedPlatforms sets the "allowed_platforms" field to the value that was provided on create.
+func (u *APIKeyUpsertOne) UpdateAllowedPlatforms() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateAllowedPlatforms()
+	})
+}
+
+// ClearAllowedPlatforms clears the value of the "allowed_platforms" field.
+func (u *APIKeyUpsertOne) ClearAllowedPlatforms() *APIKeyUpsertOne {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearAllowedPlatforms()
+	})
+}
+
 // Exec executes the query.
 func (u *APIKeyUpsertOne) Exec(ctx context.Context) error {
 	if len(u.create.conflict) == 0 {
@@ -1103,6 +1608,209 @@ func (u *APIKeyUpsertBulk) ClearIPBlacklist() *APIKeyUpsertBulk {
 	})
 }
 
+// SetExpiresAt sets the "expires_at" field.
+func (u *APIKeyUpsertBulk) SetExpiresAt(v time.Time) *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetExpiresAt(v)
+	})
+}
+
+// UpdateExpiresAt sets the "expires_at" field to the value that was provided on create.
+func (u *APIKeyUpsertBulk) UpdateExpiresAt() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateExpiresAt()
+	})
+}
+
+// ClearExpiresAt clears the value of the "expires_at" field.
+func (u *APIKeyUpsertBulk) ClearExpiresAt() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearExpiresAt()
+	})
+}
+
+// SetTotalLimitUsd sets the "total_limit_usd" field.
+func (u *APIKeyUpsertBulk) SetTotalLimitUsd(v float64) *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetTotalLimitUsd(v)
+	})
+}
+
+// AddTotalLimitUsd adds v to the "total_limit_usd" field.
+func (u *APIKeyUpsertBulk) AddTotalLimitUsd(v float64) *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.AddTotalLimitUsd(v)
+	})
+}
+
+// UpdateTotalLimitUsd sets the "total_limit_usd" field to the value that was provided on create.
+func (u *APIKeyUpsertBulk) UpdateTotalLimitUsd() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateTotalLimitUsd()
+	})
+}
+
+// ClearTotalLimitUsd clears the value of the "total_limit_usd" field.
+func (u *APIKeyUpsertBulk) ClearTotalLimitUsd() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearTotalLimitUsd()
+	})
+}
+
+// SetDailyLimitUsd sets the "daily_limit_usd" field.
+func (u *APIKeyUpsertBulk) SetDailyLimitUsd(v float64) *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetDailyLimitUsd(v)
+	})
+}
+
+// AddDailyLimitUsd adds v to the "daily_limit_usd" field.
+func (u *APIKeyUpsertBulk) AddDailyLimitUsd(v float64) *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.AddDailyLimitUsd(v)
+	})
+}
+
+// UpdateDailyLimitUsd sets the "daily_limit_usd" field to the value that was provided on create.
+func (u *APIKeyUpsertBulk) UpdateDailyLimitUsd() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateDailyLimitUsd()
+	})
+}
+
+// ClearDailyLimitUsd clears the value of the "daily_limit_usd" field.
+func (u *APIKeyUpsertBulk) ClearDailyLimitUsd() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearDailyLimitUsd()
+	})
+}
+
+// SetMonthlyLimitUsd sets the "monthly_limit_usd" field.
+func (u *APIKeyUpsertBulk) SetMonthlyLimitUsd(v float64) *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetMonthlyLimitUsd(v)
+	})
+}
+
+// AddMonthlyLimitUsd adds v to the "monthly_limit_usd" field.
+func (u *APIKeyUpsertBulk) AddMonthlyLimitUsd(v float64) *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.AddMonthlyLimitUsd(v)
+	})
+}
+
+// UpdateMonthlyLimitUsd sets the "monthly_limit_usd" field to the value that was provided on create.
+func (u *APIKeyUpsertBulk) UpdateMonthlyLimitUsd() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateMonthlyLimitUsd()
+	})
+}
+
+// ClearMonthlyLimitUsd clears the value of the "monthly_limit_usd" field.
+func (u *APIKeyUpsertBulk) ClearMonthlyLimitUsd() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearMonthlyLimitUsd()
+	})
+}
+
+// SetDailyRequestLimit sets the "daily_request_limit" field.
+func (u *APIKeyUpsertBulk) SetDailyRequestLimit(v int64) *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetDailyRequestLimit(v)
+	})
+}
+
+// AddDailyRequestLimit adds v to the "daily_request_limit" field.
+func (u *APIKeyUpsertBulk) AddDailyRequestLimit(v int64) *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.AddDailyRequestLimit(v)
+	})
+}
+
+// UpdateDailyRequestLimit sets the "daily_request_limit" field to the value that was provided on create.
+func (u *APIKeyUpsertBulk) UpdateDailyRequestLimit() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateDailyRequestLimit()
+	})
+}
+
+// ClearDailyRequestLimit clears the value of the "daily_request_limit" field.
+func (u *APIKeyUpsertBulk) ClearDailyRequestLimit() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearDailyRequestLimit()
+	})
+}
+
+// SetMonthlyRequestLimit sets the "monthly_request_limit" field.
+func (u *APIKeyUpsertBulk) SetMonthlyRequestLimit(v int64) *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetMonthlyRequestLimit(v)
+	})
+}
+
+// AddMonthlyRequestLimit adds v to the "monthly_request_limit" field.
+func (u *APIKeyUpsertBulk) AddMonthlyRequestLimit(v int64) *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.AddMonthlyRequestLimit(v)
+	})
+}
+
+// UpdateMonthlyRequestLimit sets the "monthly_request_limit" field to the value that was provided on create.
+func (u *APIKeyUpsertBulk) UpdateMonthlyRequestLimit() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateMonthlyRequestLimit()
+	})
+}
+
+// ClearMonthlyRequestLimit clears the value of the "monthly_request_limit" field.
+func (u *APIKeyUpsertBulk) ClearMonthlyRequestLimit() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearMonthlyRequestLimit()
+	})
+}
+
+// SetAllowedModels sets the "allowed_models" field.
+func (u *APIKeyUpsertBulk) SetAllowedModels(v []string) *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetAllowedModels(v)
+	})
+}
+
+// UpdateAllowedModels sets the "allowed_models" field to the value that was provided on create.
+func (u *APIKeyUpsertBulk) UpdateAllowedModels() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateAllowedModels()
+	})
+}
+
+// ClearAllowedModels clears the value of the "allowed_models" field.
+func (u *APIKeyUpsertBulk) ClearAllowedModels() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearAllowedModels()
+	})
+}
+
+// SetAllowedPlatforms sets the "allowed_platforms" field.
+func (u *APIKeyUpsertBulk) SetAllowedPlatforms(v []string) *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.SetAllowedPlatforms(v)
+	})
+}
+
+// UpdateAllowedPlatforms sets the "allowed_platforms" field to the value that was provided on create.
+func (u *APIKeyUpsertBulk) UpdateAllowedPlatforms() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.UpdateAllowedPlatforms()
+	})
+}
+
+// ClearAllowedPlatforms clears the value of the "allowed_platforms" field.
+func (u *APIKeyUpsertBulk) ClearAllowedPlatforms() *APIKeyUpsertBulk {
+	return u.Update(func(s *APIKeyUpsert) {
+		s.ClearAllowedPlatforms()
+	})
+}
+
 // Exec executes the query.
 func (u *APIKeyUpsertBulk) Exec(ctx context.Context) error {
 	if u.create.err != nil {
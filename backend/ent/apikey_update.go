@@ -170,6 +170,197 @@ func (_u *APIKeyUpdate) ClearIPBlacklist() *APIKeyUpdate {
 	return _u
 }
 
+// SetExpiresAt sets the "expires_at" field.
+func (_u *APIKeyUpdate) SetExpiresAt(v time.Time) *APIKeyUpdate {
+	_u.mutation.SetExpiresAt(v)
+	return _u
+}
+
+// SetNillableExpiresAt sets the "expires_at" field if the given value is not nil.
+func (_u *APIKeyUpdate) SetNillableExpiresAt(v *time.Time) *APIKeyUpdate {
+	if v != nil {
+		_u.SetExpiresAt(*v)
+	}
+	return _u
+}
+
+// ClearExpiresAt clears the value of the "expires_at" field.
+func (_u *APIKeyUpdate) ClearExpiresAt() *APIKeyUpdate {
+	_u.mutation.ClearExpiresAt()
+	return _u
+}
+
+// SetTotalLimitUsd sets the "total_limit_usd" field.
+func (_u *APIKeyUpdate) SetTotalLimitUsd(v float64) *APIKeyUpdate {
+	_u.mutation.ResetTotalLimitUsd()
+	_u.mutation.SetTotalLimitUsd(v)
+	return _u
+}
+
+// SetNillableTotalLimitUsd sets the "total_limit_usd" field if the given value is not nil.
+func (_u *APIKeyUpdate) SetNillableTotalLimitUsd(v *float64) *APIKeyUpdate {
+	if v != nil {
+		_u.SetTotalLimitUsd(*v)
+	}
+	return _u
+}
+
+// AddTotalLimitUsd adds value to the "total_limit_usd" field.
+func (_u *APIKeyUpdate) AddTotalLimitUsd(v float64) *APIKeyUpdate {
+	_u.mutation.AddTotalLimitUsd(v)
+	return _u
+}
+
+// ClearTotalLimitUsd clears the value of the "total_limit_usd" field.
+func (_u *APIKeyUpdate) ClearTotalLimitUsd() *APIKeyUpdate {
+	_u.mutation.ClearTotalLimitUsd()
+	return _u
+}
+
+// SetDailyLimitUsd sets the "daily_limit_usd" field.
+func (_u *APIKeyUpdate) SetDailyLimitUsd(v float64) *APIKeyUpdate {
+	_u.mutation.ResetDailyLimitUsd()
+	_u.mutation.SetDailyLimitUsd(v)
+	return _u
+}
+
+// SetNillableDailyLimitUsd sets the "daily_limit_usd" field if the given value is not nil.
+func (_u *APIKeyUpdate) SetNillableDailyLimitUsd(v *float64) *APIKeyUpdate {
+	if v != nil {
+		_u.SetDailyLimitUsd(*v)
+	}
+	return _u
+}
+
+// AddDailyLimitUsd adds value to the "daily_limit_usd" field.
+func (_u *APIKeyUpdate) AddDailyLimitUsd(v float64) *APIKeyUpdate {
+	_u.mutation.AddDailyLimitUsd(v)
+	return _u
+}
+
+// ClearDailyLimitUsd clears the value of the "daily_limit_usd" field.
+func (_u *APIKeyUpdate) ClearDailyLimitUsd() *APIKeyUpdate {
+	_u.mutation.ClearDailyLimitUsd()
+	return _u
+}
+
+// SetMonthlyLimitUsd sets the "monthly_limit_usd" field.
+func (_u *APIKeyUpdate) SetMonthlyLimitUsd(v float64) *APIKeyUpdate {
+	_u.mutation.ResetMonthlyLimitUsd()
+	_u.mutation.SetMonthlyLimitUsd(v)
+	return _u
+}
+
+// SetNillableMonthlyLimitUsd sets the "monthly_limit_usd" field if the given value is not nil.
+func (_u *APIKeyUpdate) SetNillableMonthlyLimitUsd(v *float64) *APIKeyUpdate {
+	if v != nil {
+		_u.SetMonthlyLimitUsd(*v)
+	}
+	return _u
+}
+
+// AddMonthlyLimitUsd adds value to the "monthly_limit_usd" field.
+func (_u *APIKeyUpdate) AddMonthlyLimitUsd(v float64) *APIKeyUpdate {
+	_u.mutation.AddMonthlyLimitUsd(v)
+	return _u
+}
+
+// ClearMonthlyLimitUsd clears the value of the "monthly_limit_usd" field.
+func (_u *APIKeyUpdate) ClearMonthlyLimitUsd() *APIKeyUpdate {
+	_u.mutation.ClearMonthlyLimitUsd()
+	return _u
+}
+
+// SetDailyRequestLimit sets the "daily_request_limit" field.
+func (_u *APIKeyUpdate) SetDailyRequestLimit(v int64) *APIKeyUpdate {
+	_u.mutation.ResetDailyRequestLimit()
+	_u.mutation.SetDailyRequestLimit(v)
+	return _u
+}
+
+// SetNillableDailyRequestLimit sets the "daily_request_limit" field if the given value is not nil.
+func (_u *APIKeyUpdate) SetNillableDailyRequestLimit(v *int64) *APIKeyUpdate {
+	if v != nil {
+		_u.SetDailyRequestLimit(*v)
+	}
+	return _u
+}
+
+// AddDailyRequestLimit adds value to the "daily_request_limit" field.
+func (_u *APIKeyUpdate) AddDailyRequestLimit(v int64) *APIKeyUpdate {
+	_u.mutation.AddDailyRequestLimit(v)
+	return _u
+}
+
+// ClearDailyRequestLimit clears the value of the "daily_request_limit" field.
+func (_u *APIKeyUpdate) ClearDailyRequestLimit() *APIKeyUpdate {
+	_u.mutation.ClearDailyRequestLimit()
+	return _u
+}
+
+// SetMonthlyRequestLimit sets the "monthly_request_limit" field.
+func (_u *APIKeyUpdate) SetMonthlyRequestLimit(v int64) *APIKeyUpdate {
+	_u.mutation.ResetMonthlyRequestLimit()
+	_u.mutation.SetMonthlyRequestLimit(v)
+	return _u
+}
+
+// SetNillableMonthlyRequestLimit sets the "monthly_request_limit" field if the given value is not nil.
+func (_u *APIKeyUpdate) SetNillableMonthlyRequestLimit(v *int64) *APIKeyUpdate {
+	if v != nil {
+		_u.SetMonthlyRequestLimit(*v)
+	}
+	return _u
+}
+
+// AddMonthlyRequestLimit adds value to the "monthly_request_limit" field.
+func (_u *APIKeyUpdate) AddMonthlyRequestLimit(v int64) *APIKeyUpdate {
+	_u.mutation.AddMonthlyRequestLimit(v)
+	return _u
+}
+
+// ClearMonthlyRequestLimit clears the value of the "monthly_request_limit" field.
+func (_u *APIKeyUpdate) ClearMonthlyRequestLimit() *APIKeyUpdate {
+	_u.mutation.ClearMonthlyRequestLimit()
+	return _u
+}
+
+// SetAllowedModels sets the "allowed_models" field.
+func (_u *APIKeyUpdate) SetAllowedModels(v []string) *APIKeyUpdate {
+	_u.mutation.SetAllowedModels(v)
+	return _u
+}
+
+// AppendAllowedModels appends value to the "allowed_models" field.
+func (_u *APIKeyUpdate) AppendAllowedModels(v []string) *APIKeyUpdate {
+	_u.mutation.AppendAllowedModels(v)
+	return _u
+}
+
+// ClearAllowedModels clears the value of the "allowed_models" field.
+func (_u *APIKeyUpdate) ClearAllowedModels() *APIKeyUpdate {
+	_u.mutation.ClearAllowedModels()
+	return _u
+}
+
+// SetAllowedPlatforms sets the "allowed_platforms" field.
+func (_u *APIKeyUpdate) SetAllowedPlatforms(v []string) *APIKeyUpdate {
+	_u.mutation.SetAllowedPlatforms(v)
+	return _u
+}
+
+// AppendAllowedPlatforms appends value to the "allowed_platforms" field.
+func (_u *APIKeyUpdate) AppendAllowedPlatforms(v []string) *APIKeyUpdate {
+	_u.mutation.AppendAllowedPlatforms(v)
+	return _u
+}
+
+// ClearAllowedPlatforms clears the value of the "allowed_platforms" field.
+func (_u *APIKeyUpdate) ClearAllowedPlatforms() *APIKeyUpdate {
+	_u.mutation.ClearAllowedPlatforms()
+	return _u
+}
+
 // SetUser sets the "user" edge to the User entity.
 func (_u *APIKeyUpdate) SetUser(v *User) *APIKeyUpdate {
 	return _u.SetUserID(v.ID)
@@ -350,6 +541,79 @@ func (_u *APIKeyUpdate) sqlSave(ctx context.Context) (_node int, err error) {
 	if _u.mutation.IPBlacklistCleared() {
 		_spec.ClearField(apikey.FieldIPBlacklist, field.TypeJSON)
 	}
+	if value, ok := _u.mutation.ExpiresAt(); ok {
+		_spec.SetField(apikey.FieldExpiresAt, field.TypeTime, value)
+	}
+	if _u.mutation.ExpiresAtCleared() {
+		_spec.ClearField(apikey.FieldExpiresAt, field.TypeTime)
+	}
+	if value, ok := _u.mutation.TotalLimitUsd(); ok {
+		_spec.SetField(apikey.FieldTotalLimitUsd, field.TypeFloat64, value)
+	}
+	if value, ok := _u.mutation.AddedTotalLimitUsd(); ok {
+		_spec.AddField(apikey.FieldTotalLimitUsd, field.TypeFloat64, value)
+	}
+	if _u.mutation.TotalLimitUsdCleared() {
+		_spec.ClearField(apikey.FieldTotalLimitUsd, field.TypeFloat64)
+	}
+	if value, ok := _u.mutation.DailyLimitUsd(); ok {
+		_spec.SetField(apikey.FieldDailyLimitUsd, field.TypeFloat64, value)
+	}
+	if value, ok := _u.mutation.AddedDailyLimitUsd(); ok {
+		_spec.AddField(apikey.FieldDailyLimitUsd, field.TypeFloat64, value)
+	}
+	if _u.mutation.DailyLimitUsdCleared() {
+		_spec.ClearField(apikey.FieldDailyLimitUsd, field.TypeFloat64)
+	}
+	if value, ok := _u.mutation.MonthlyLimitUsd(); ok {
+		_spec.SetField(apikey.FieldMonthlyLimitUsd, field.TypeFloat64, value)
+	}
+	if value, ok := _u.mutation.AddedMonthlyLimitUsd(); ok {
+		_spec.AddField(apikey.FieldMonthlyLimitUsd, field.TypeFloat64, value)
+	}
+	if _u.mutation.MonthlyLimitUsdCleared() {
+		_spec.ClearField(apikey.FieldMonthlyLimitUsd, field.TypeFloat64)
+	}
+	if value, ok := _u.mutation.DailyRequestLimit(); ok {
+		_spec.SetField(apikey.FieldDailyRequestLimit, field.TypeInt64, value)
+	}
+	if value, ok := _u.mutation.AddedDailyRequestLimit(); ok {
+		_spec.AddField(apikey.FieldDailyRequestLimit, field.TypeInt64, value)
+	}
+	if _u.mutation.DailyRequestLimitCleared() {
+		_spec.ClearField(apikey.FieldDailyRequestLimit, field.TypeInt64)
+	}
+	if value, ok := _u.mutation.MonthlyRequestLimit(); ok {
+		_spec.SetField(apikey.FieldMonthlyRequestLimit, field.TypeInt64, value)
+	}
+	if value, ok := _u.mutation.AddedMonthlyRequestLimit(); ok {
+		_spec.AddField(apikey.FieldMonthlyRequestLimit, field.TypeInt64, value)
+	}
+	if _u.mutation.MonthlyRequestLimitCleared() {
+		_spec.ClearField(apikey.FieldMonthlyRequestLimit, field.TypeInt64)
+	}
+	if value, ok := _u.mutation.AllowedModels(); ok {
+		_spec.SetField(apikey.FieldAllowedModels, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.AppendedAllowedModels(); ok {
+		_spec.AddModifier(func(u *sql.UpdateBuilder) {
+			sqljson.Append(u, apikey.FieldAllowedModels, value)
+		})
+	}
+	if _u.mutation.AllowedModelsCleared() {
+		_spec.ClearField(apikey.FieldAllowedModels, field.TypeJSON)
+	}
+	if value, ok := _u.mutation.AllowedPlatforms(); ok {
+		_spec.SetField(apikey.FieldAllowedPlatforms, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.AppendedAllowedPlatforms(); ok {
+		_spec.AddModifier(func(u *sql.UpdateBuilder) {
+			sqljson.Append(u, apikey.FieldAllowedPlatforms, value)
+		})
+	}
+	if _u.mutation.AllowedPlatformsCleared() {
+		_spec.ClearField(apikey.FieldAllowedPlatforms, field.TypeJSON)
+	}
 	if _u.mutation.UserCleared() {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.M2O,
@@ -611,6 +875,197 @@ func (_u *APIKeyUpdateOne) ClearIPBlacklist() *APIKeyUpdateOne {
 	return _u
 }
 
+// SetExpiresAt sets the "expires_at" field.
+func (_u *APIKeyUpdateOne) SetExpiresAt(v time.Time) *APIKeyUpdateOne {
+	_u.mutation.SetExpiresAt(v)
+	return _u
+}
+
+// SetNillableExpiresAt sets the "expires_at" field if the given value is not nil.
+func (_u *APIKeyUpdateOne) SetNillableExpiresAt(v *time.Time) *APIKeyUpdateOne {
+	if v != nil {
+		_u.SetExpiresAt(*v)
+	}
+	return _u
+}
+
+// ClearExpiresAt clears the value of the "expires_at" field.
+func (_u *APIKeyUpdateOne) ClearExpiresAt() *APIKeyUpdateOne {
+	_u.mutation.ClearExpiresAt()
+	return _u
+}
+
+// SetTotalLimitUsd sets the "total_limit_usd" field.
+func (_u *APIKeyUpdateOne) SetTotalLimitUsd(v float64) *APIKeyUpdateOne {
+	_u.mutation.ResetTotalLimitUsd()
+	_u.mutation.SetTotalLimitUsd(v)
+	return _u
+}
+
+// SetNillableTotalLimitUsd sets the "total_limit_usd" field if the given value is not nil.
+func (_u *APIKeyUpdateOne) SetNillableTotalLimitUsd(v *float64) *APIKeyUpdateOne {
+	if v != nil {
+		_u.SetTotalLimitUsd(*v)
+	}
+	return _u
+}
+
+// AddTotalLimitUsd adds value to the "total_limit_usd" field.
+func (_u *APIKeyUpdateOne) AddTotalLimitUsd(v float64) *APIKeyUpdateOne {
+	_u.mutation.AddTotalLimitUsd(v)
+	return _u
+}
+
+// ClearTotalLimitUsd clears the value of the "total_limit_usd" field.
+func (_u *APIKeyUpdateOne) ClearTotalLimitUsd() *APIKeyUpdateOne {
+	_u.mutation.ClearTotalLimitUsd()
+	return _u
+}
+
+// SetDailyLimitUsd sets the "daily_limit_usd" field.
+func (_u *APIKeyUpdateOne) SetDailyLimitUsd(v float64) *APIKeyUpdateOne {
+	_u.mutation.ResetDailyLimitUsd()
+	_u.mutation.SetDailyLimitUsd(v)
+	return _u
+}
+
+// SetNillableDailyLimitUsd sets the "daily_limit_usd" field if the given value is not nil.
+func (_u *APIKeyUpdateOne) SetNillableDailyLimitUsd(v *float64) *APIKeyUpdateOne {
+	if v != nil {
+		_u.SetDailyLimitUsd(*v)
+	}
+	return _u
+}
+
+// AddDailyLimitUsd adds value to the "daily_limit_usd" field.
+func (_u *APIKeyUpdateOne) AddDailyLimitUsd(v float64) *APIKeyUpdateOne {
+	_u.mutation.AddDailyLimitUsd(v)
+	return _u
+}
+
+// ClearDailyLimitUsd clears the value of the "daily_limit_usd" field.
+func (_u *APIKeyUpdateOne) ClearDailyLimitUsd() *APIKeyUpdateOne {
+	_u.mutation.ClearDailyLimitUsd()
+	return _u
+}
+
+// SetMonthlyLimitUsd sets the "monthly_limit_usd" field.
+func (_u *APIKeyUpdateOne) SetMonthlyLimitUsd(v float64) *APIKeyUpdateOne {
+	_u.mutation.ResetMonthlyLimitUsd()
+	_u.mutation.SetMonthlyLimitUsd(v)
+	return _u
+}
+
+// SetNillableMonthlyLimitUsd sets the "monthly_limit_usd" field if the given value is not nil.
+func (_u *APIKeyUpdateOne) SetNillableMonthlyLimitUsd(v *float64) *APIKeyUpdateOne {
+	if v != nil {
+		_u.SetMonthlyLimitUsd(*v)
+	}
+	return _u
+}
+
+// AddMonthlyLimitUsd adds value to the "monthly_limit_usd" field.
+func (_u *APIKeyUpdateOne) AddMonthlyLimitUsd(v float64) *APIKeyUpdateOne {
+	_u.mutation.AddMonthlyLimitUsd(v)
+	return _u
+}
+
+// ClearMonthlyLimitUsd clears the value of the "monthly_limit_usd" field.
+func (_u *APIKeyUpdateOne) ClearMonthlyLimitUsd() *APIKeyUpdateOne {
+	_u.mutation.ClearMonthlyLimitUsd()
+	return _u
+}
+
+// SetDailyRequestLimit sets the "daily_request_limit" field.
+func (_u *APIKeyUpdateOne) SetDailyRequestLimit(v int64) *APIKeyUpdateOne {
+	_u.mutation.ResetDailyRequestLimit()
+	_u.mutation.SetDailyRequestLimit(v)
+	return _u
+}
+
+// SetNillableDailyRequestLimit sets the "daily_request_limit" field if the given value is not nil.
+func (_u *APIKeyUpdateOne) SetNillableDailyRequestLimit(v *int64) *APIKeyUpdateOne {
+	if v != nil {
+		_u.SetDailyRequestLimit(*v)
+	}
+	return _u
+}
+
+// AddDailyRequestLimit adds value to the "daily_request_limit" field.
+func (_u *APIKeyUpdateOne) AddDailyRequestLimit(v int64) *APIKeyUpdateOne {
+	_u.mutation.AddDailyRequestLimit(v)
+	return _u
+}
+
+// ClearDailyRequestLimit clears the value of the "daily_request_limit" field.
+func (_u *APIKeyUpdateOne) ClearDailyRequestLimit() *APIKeyUpdateOne {
+	_u.mutation.ClearDailyRequestLimit()
+	return _u
+}
+
+// SetMonthlyRequestLimit sets the "monthly_request_limit" field.
+func (_u *APIKeyUpdateOne) SetMonthlyRequestLimit(v int64) *APIKeyUpdateOne {
+	_u.mutation.ResetMonthlyRequestLimit()
+	_u.mutation.SetMonthlyRequestLimit(v)
+	return _u
+}
+
+// SetNillableMonthlyRequestLimit sets the "monthly_request_limit" field if the given value is not nil.
+func (_u *APIKeyUpdateOne) SetNillableMonthlyRequestLimit(v *int64) *APIKeyUpdateOne {
+	if v != nil {
+		_u.SetMonthlyRequestLimit(*v)
+	}
+	return _u
+}
+
+// AddMonthlyRequestLimit adds value to the "monthly_request_limit" field.
+func (_u *APIKeyUpdateOne) AddMonthlyRequestLimit(v int64) *APIKeyUpdateOne {
+	_u.mutation.AddMonthlyRequestLimit(v)
+	return _u
+}
+
+// ClearMonthlyRequestLimit clears the value of the "monthly_request_limit" field.
+func (_u *APIKeyUpdateOne) ClearMonthlyRequestLimit() *APIKeyUpdateOne {
+	_u.mutation.ClearMonthlyRequestLimit()
+	return _u
+}
+
+// SetAllowedModels sets the "allowed_models" field.
+func (_u *APIKeyUpdateOne) SetAllowedModels(v []string) *APIKeyUpdateOne {
+	_u.mutation.SetAllowedModels(v)
+	return _u
+}
+
+// AppendAllowedModels appends value to the "allowed_models" field.
+func (_u *APIKeyUpdateOne) AppendAllowedModels(v []string) *APIKeyUpdateOne {
+	_u.mutation.AppendAllowedModels(v)
+	return _u
+}
+
+// ClearAllowedModels clears the value of the "allowed_models" field.
+func (_u *APIKeyUpdateOne) ClearAllowedModels() *APIKeyUpdateOne {
+	_u.mutation.ClearAllowedModels()
+	return _u
+}
+
+// SetAllowedPlatforms sets the "allowed_platforms" field.
+func (_u *APIKeyUpdateOne) SetAllowedPlatforms(v []string) *APIKeyUpdateOne {
+	_u.mutation.SetAllowedPlatforms(v)
+	return _u
+}
+
+// AppendAllowedPlatforms appends value to the "allowed_platforms" field.
+func (_u *APIKeyUpdateOne) AppendAllowedPlatforms(v []string) *APIKeyUpdateOne {
+	_u.mutation.AppendAllowedPlatforms(v)
+	return _u
+}
+
+// ClearAllowedPlatforms clears the value of the "allowed_platforms" field.
+func (_u *APIKeyUpdateOne) ClearAllowedPlatforms() *APIKeyUpdateOne {
+	_u.mutation.ClearAllowedPlatforms()
+	return _u
+}
+
 // SetUser sets the "user" edge to the User entity.
 func (_u *APIKeyUpdateOne) SetUser(v *User) *APIKeyUpdateOne {
 	return _u.SetUserID(v.ID)
@@ -821,6 +1276,79 @@ func (_u *APIKeyUpdateOne) sqlSave(ctx context.Context) (_node *APIKey, err erro
 	if _u.mutation.IPBlacklistCleared() {
 		_spec.ClearField(apikey.FieldIPBlacklist, field.TypeJSON)
 	}
+	if value, ok := _u.mutation.ExpiresAt(); ok {
+		_spec.SetField(apikey.FieldExpiresAt, field.TypeTime, value)
+	}
+	if _u.mutation.ExpiresAtCleared() {
+		_spec.ClearField(apikey.FieldExpiresAt, field.TypeTime)
+	}
+	if value, ok := _u.mutation.TotalLimitUsd(); ok {
+		_spec.SetField(apikey.FieldTotalLimitUsd, field.TypeFloat64, value)
+	}
+	if value, ok := _u.mutation.AddedTotalLimitUsd(); ok {
+		_spec.AddField(apikey.FieldTotalLimitUsd, field.TypeFloat64, value)
+	}
+	if _u.mutation.TotalLimitUsdCleared() {
+		_spec.ClearField(apikey.FieldTotalLimitUsd, field.TypeFloat64)
+	}
+	if value, ok := _u.mutation.DailyLimitUsd(); ok {
+		_spec.SetField(apikey.FieldDailyLimitUsd, field.TypeFloat64, value)
+	}
+	if value, ok := _u.mutation.AddedDailyLimitUsd(); ok {
+		_spec.AddField(apikey.FieldDailyLimitUsd, field.TypeFloat64, value)
+	}
+	if _u.mutation.DailyLimitUsdCleared() {
+		_spec.ClearField(apikey.FieldDailyLimitUsd, field.TypeFloat64)
+	}
+	if value, ok := _u.mutation.MonthlyLimitUsd(); ok {
+		_spec.SetField(apikey.FieldMonthlyLimitUsd, field.TypeFloat64, value)
+	}
+	if value, ok := _u.mutation.AddedMonthlyLimitUsd(); ok {
+		_spec.AddField(apikey.FieldMonthlyLimitUsd, field.TypeFloat64, value)
+	}
+	if _u.mutation.MonthlyLimitUsdCleared() {
+		_spec.ClearField(apikey.FieldMonthlyLimitUsd, field.TypeFloat64)
+	}
+	if value, ok := _u.mutation.DailyRequestLimit(); ok {
+		_spec.SetField(apikey.FieldDailyRequestLimit, field.TypeInt64, value)
+	}
+	if value, ok := _u.mutation.AddedDailyRequestLimit(); ok {
+		_spec.AddField(apikey.FieldDailyRequestLimit, field.TypeInt64, value)
+	}
+	if _u.mutation.DailyRequestLimitCleared() {
+		_spec.ClearField(apikey.FieldDailyRequestLimit, field.TypeInt64)
+	}
+	if value, ok := _u.mutation.MonthlyRequestLimit(); ok {
+		_spec.SetField(apikey.FieldMonthlyRequestLimit, field.TypeInt64, value)
+	}
+	if value, ok := _u.mutation.AddedMonthlyRequestLimit(); ok {
+		_spec.AddField(apikey.FieldMonthlyRequestLimit, field.TypeInt64, value)
+	}
+	if _u.mutation.MonthlyRequestLimitCleared() {
+		_spec.ClearField(apikey.FieldMonthlyRequestLimit, field.TypeInt64)
+	}
+	if value, ok := _u.mutation.AllowedModels(); ok {
+		_spec.SetField(apikey.FieldAllowedModels, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.AppendedAllowedModels(); ok {
+		_spec.AddModifier(func(u *sql.UpdateBuilder) {
+			sqljson.Append(u, apikey.FieldAllowedModels, value)
+		})
+	}
+	if _u.mutation.AllowedModelsCleared() {
+		_spec.ClearField(apikey.FieldAllowedModels, field.TypeJSON)
+	}
+	if value, ok := _u.mutation.AllowedPlatforms(); ok {
+		_spec.SetField(apikey.FieldAllowedPlatforms, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.AppendedAllowedPlatforms(); ok {
+		_spec.AddModifier(func(u *sql.UpdateBuilder) {
+			sqljson.Append(u, apikey.FieldAllowedPlatforms, value)
+		})
+	}
+	if _u.mutation.AllowedPlatformsCleared() {
+		_spec.ClearField(apikey.FieldAllowedPlatforms, field.TypeJSON)
+	}
 	if _u.mutation.UserCleared() {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.M2O,
@@ -60,6 +60,18 @@ type Group struct {
 	ModelRouting map[string][]int64 `json:"model_routing,omitempty"`
 	// 是否启用模型路由配置
 	ModelRoutingEnabled bool `json:"model_routing_enabled,omitempty"`
+	// 模型映射配置：模型模式 -> 上游模型
+	ModelMapping map[string]string `json:"model_mapping,omitempty"`
+	// 粘性会话策略：content_hash/metadata_user_id/api_key/none
+	StickyPolicy string `json:"sticky_policy,omitempty"`
+	// 粘性会话 TTL（秒），0 表示使用默认值
+	StickyTTLSeconds int `json:"sticky_ttl_seconds,omitempty"`
+	// 账号标签选择器，匹配的可调度账号自动加入分组
+	AccountTagSelector string `json:"account_tag_selector,omitempty"`
+	// 模型路由标签选择器：模型模式 -> 标签选择器
+	ModelRoutingTags map[string]string `json:"model_routing_tags,omitempty"`
+	// 成本优先路由策略：空表示关闭，lowest_cost 优先选择有效成本最低的账号
+	CostRoutingPolicy string `json:"cost_routing_policy,omitempty"`
 	// Edges holds the relations/edges for other nodes in the graph.
 	// The values are being populated by the GroupQuery when eager-loading is set.
 	Edges        GroupEdges `json:"edges"`
@@ -166,15 +178,15 @@ func (*Group) scanValues(columns []string) ([]any, error) {
 	values := make([]any, len(columns))
 	for i := range columns {
 		switch columns[i] {
-		case group.FieldModelRouting:
+		case group.FieldModelRouting, group.FieldModelMapping, group.FieldModelRoutingTags:
 			values[i] = new([]byte)
 		case group.FieldIsExclusive, group.FieldClaudeCodeOnly, group.FieldModelRoutingEnabled:
 			values[i] = new(sql.NullBool)
 		case group.FieldRateMultiplier, group.FieldDailyLimitUsd, group.FieldWeeklyLimitUsd, group.FieldMonthlyLimitUsd, group.FieldImagePrice1k, group.FieldImagePrice2k, group.FieldImagePrice4k:
 			values[i] = new(sql.NullFloat64)
-		case group.FieldID, group.FieldDefaultValidityDays, group.FieldFallbackGroupID:
+		case group.FieldID, group.FieldDefaultValidityDays, group.FieldFallbackGroupID, group.FieldStickyTTLSeconds:
 			values[i] = new(sql.NullInt64)
-		case group.FieldName, group.FieldDescription, group.FieldStatus, group.FieldPlatform, group.FieldSubscriptionType:
+		case group.FieldName, group.FieldDescription, group.FieldStatus, group.FieldPlatform, group.FieldSubscriptionType, group.FieldStickyPolicy, group.FieldAccountTagSelector, group.FieldCostRoutingPolicy:
 			values[i] = new(sql.NullString)
 		case group.FieldCreatedAt, group.FieldUpdatedAt, group.FieldDeletedAt:
 			values[i] = new(sql.NullTime)
@@ -336,6 +348,46 @@ func (_m *Group) assignValues(columns []string, values []any) error {
 			} else if value.Valid {
 				_m.ModelRoutingEnabled = value.Bool
 			}
+		case group.FieldModelMapping:
+			if value, ok := values[i].(*[]byte); !ok {
+				return fmt.Errorf("unexpected type %T for field model_mapping", values[i])
+			} else if value != nil && len(*value) > 0 {
+				if err := json.Unmarshal(*value, &_m.ModelMapping); err != nil {
+					return fmt.Errorf("unmarshal field model_mapping: %w", err)
+				}
+			}
+		case group.FieldStickyPolicy:
+			if value, ok := values[i].(*sql.NullString); !ok {
+				return fmt.Errorf("unexpected type %T for field sticky_policy", values[i])
+			} else if value.Valid {
+				_m.StickyPolicy = value.String
+			}
+		case group.FieldStickyTTLSeconds:
+			if value, ok := values[i].(*sql.NullInt64); !ok {
+				return fmt.Errorf("unexpected type %T for field sticky_ttl_seconds", values[i])
+			} else if value.Valid {
+				_m.StickyTTLSeconds = int(value.Int64)
+			}
+		case group.FieldAccountTagSelector:
+			if value, ok := values[i].(*sql.NullString); !ok {
+				return fmt.Errorf("unexpected type %T for field account_tag_selector", values[i])
+			} else if value.Valid {
+				_m.AccountTagSelector = value.String
+			}
+		case group.FieldModelRoutingTags:
+			if value, ok := values[i].(*[]byte); !ok {
+				return fmt.Errorf("unexpected type %T for field model_routing_tags", values[i])
+			} else if value != nil && len(*value) > 0 {
+				if err := json.Unmarshal(*value, &_m.ModelRoutingTags); err != nil {
+					return fmt.Errorf("unmarshal field model_routing_tags: %w", err)
+				}
+			}
+		case group.FieldCostRoutingPolicy:
+			if value, ok := values[i].(*sql.NullString); !ok {
+				return fmt.Errorf("unexpected type %T for field cost_routing_policy", values[i])
+			} else if value.Valid {
+				_m.CostRoutingPolicy = value.String
+			}
 		default:
 			_m.selectValues.Set(columns[i], values[i])
 		}
@@ -492,6 +544,24 @@ func (_m *Group) String() string {
 	builder.WriteString(", ")
 	builder.WriteString("model_routing_enabled=")
 	builder.WriteString(fmt.Sprintf("%v", _m.ModelRoutingEnabled))
+	builder.WriteString(", ")
+	builder.WriteString("model_mapping=")
+	builder.WriteString(fmt.Sprintf("%v", _m.ModelMapping))
+	builder.WriteString(", ")
+	builder.WriteString("sticky_policy=")
+	builder.WriteString(_m.StickyPolicy)
+	builder.WriteString(", ")
+	builder.WriteString("sticky_ttl_seconds=")
+	builder.WriteString(fmt.Sprintf("%v", _m.StickyTTLSeconds))
+	builder.WriteString(", ")
+	builder.WriteString("account_tag_selector=")
+	builder.WriteString(_m.AccountTagSelector)
+	builder.WriteString(", ")
+	builder.WriteString("model_routing_tags=")
+	builder.WriteString(fmt.Sprintf("%v", _m.ModelRoutingTags))
+	builder.WriteString(", ")
+	builder.WriteString("cost_routing_policy=")
+	builder.WriteString(_m.CostRoutingPolicy)
 	builder.WriteByte(')')
 	return builder.String()
 }
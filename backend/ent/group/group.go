@@ -57,6 +57,18 @@ const (
 	FieldModelRouting = "model_routing"
 	// FieldModelRoutingEnabled holds the string denoting the model_routing_enabled field in the database.
 	FieldModelRoutingEnabled = "model_routing_enabled"
+	// FieldModelMapping holds the string denoting the model_mapping field in the database.
+	FieldModelMapping = "model_mapping"
+	// FieldStickyPolicy holds the string denoting the sticky_policy field in the database.
+	FieldStickyPolicy = "sticky_policy"
+	// FieldStickyTTLSeconds holds the string denoting the sticky_ttl_seconds field in the database.
+	FieldStickyTTLSeconds = "sticky_ttl_seconds"
+	// FieldAccountTagSelector holds the string denoting the account_tag_selector field in the database.
+	FieldAccountTagSelector = "account_tag_selector"
+	// FieldModelRoutingTags holds the string denoting the model_routing_tags field in the database.
+	FieldModelRoutingTags = "model_routing_tags"
+	// FieldCostRoutingPolicy holds the string denoting the cost_routing_policy field in the database.
+	FieldCostRoutingPolicy = "cost_routing_policy"
 	// EdgeAPIKeys holds the string denoting the api_keys edge name in mutations.
 	EdgeAPIKeys = "api_keys"
 	// EdgeRedeemCodes holds the string denoting the redeem_codes edge name in mutations.
@@ -153,6 +165,12 @@ var Columns = []string{
 	FieldFallbackGroupID,
 	FieldModelRouting,
 	FieldModelRoutingEnabled,
+	FieldModelMapping,
+	FieldStickyPolicy,
+	FieldStickyTTLSeconds,
+	FieldAccountTagSelector,
+	FieldModelRoutingTags,
+	FieldCostRoutingPolicy,
 }
 
 var (
@@ -212,6 +230,24 @@ var (
 	DefaultClaudeCodeOnly bool
 	// DefaultModelRoutingEnabled holds the default value on creation for the "model_routing_enabled" field.
 	DefaultModelRoutingEnabled bool
+	// DefaultModelMapping holds the default value on creation for the "model_mapping" field.
+	DefaultModelMapping map[string]string
+	// DefaultStickyPolicy holds the default value on creation for the "sticky_policy" field.
+	DefaultStickyPolicy string
+	// StickyPolicyValidator is a validator for the "sticky_policy" field. It is called by the builders before save.
+	StickyPolicyValidator func(string) error
+	// DefaultStickyTTLSeconds holds the default value on creation for the "sticky_ttl_seconds" field.
+	DefaultStickyTTLSeconds int
+	// DefaultAccountTagSelector holds the default value on creation for the "account_tag_selector" field.
+	DefaultAccountTagSelector string
+	// AccountTagSelectorValidator is a validator for the "account_tag_selector" field. It is called by the builders before save.
+	AccountTagSelectorValidator func(string) error
+	// DefaultModelRoutingTags holds the default value on creation for the "model_routing_tags" field.
+	DefaultModelRoutingTags map[string]string
+	// DefaultCostRoutingPolicy holds the default value on creation for the "cost_routing_policy" field.
+	DefaultCostRoutingPolicy string
+	// CostRoutingPolicyValidator is a validator for the "cost_routing_policy" field. It is called by the builders before save.
+	CostRoutingPolicyValidator func(string) error
 )
 
 // OrderOption defines the ordering options for the Group queries.
@@ -322,6 +358,26 @@ func ByModelRoutingEnabled(opts ...sql.OrderTermOption) OrderOption {
 	return sql.OrderByField(FieldModelRoutingEnabled, opts...).ToFunc()
 }
 
+// ByStickyPolicy orders the results by the sticky_policy field.
+func ByStickyPolicy(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldStickyPolicy, opts...).ToFunc()
+}
+
+// ByStickyTTLSeconds orders the results by the sticky_ttl_seconds field.
+func ByStickyTTLSeconds(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldStickyTTLSeconds, opts...).ToFunc()
+}
+
+// ByAccountTagSelector orders the results by the account_tag_selector field.
+func ByAccountTagSelector(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldAccountTagSelector, opts...).ToFunc()
+}
+
+// ByCostRoutingPolicy orders the results by the cost_routing_policy field.
+func ByCostRoutingPolicy(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldCostRoutingPolicy, opts...).ToFunc()
+}
+
 // ByAPIKeysCount orders the results by api_keys count.
 func ByAPIKeysCount(opts ...sql.OrderTermOption) OrderOption {
 	return func(s *sql.Selector) {
@@ -155,6 +155,26 @@ func ModelRoutingEnabled(v bool) predicate.Group {
 	return predicate.Group(sql.FieldEQ(FieldModelRoutingEnabled, v))
 }
 
+// StickyPolicy applies equality check predicate on the "sticky_policy" field. It's identical to StickyPolicyEQ.
+func StickyPolicy(v string) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldStickyPolicy, v))
+}
+
+// StickyTTLSeconds applies equality check predicate on the "sticky_ttl_seconds" field. It's identical to StickyTTLSecondsEQ.
+func StickyTTLSeconds(v int) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldStickyTTLSeconds, v))
+}
+
+// AccountTagSelector applies equality check predicate on the "account_tag_selector" field. It's identical to AccountTagSelectorEQ.
+func AccountTagSelector(v string) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldAccountTagSelector, v))
+}
+
+// CostRoutingPolicy applies equality check predicate on the "cost_routing_policy" field. It's identical to CostRoutingPolicyEQ.
+func CostRoutingPolicy(v string) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldCostRoutingPolicy, v))
+}
+
 // CreatedAtEQ applies the EQ predicate on the "created_at" field.
 func CreatedAtEQ(v time.Time) predicate.Group {
 	return predicate.Group(sql.FieldEQ(FieldCreatedAt, v))
@@ -1090,6 +1110,241 @@ func ModelRoutingEnabledNEQ(v bool) predicate.Group {
 	return predicate.Group(sql.FieldNEQ(FieldModelRoutingEnabled, v))
 }
 
+// StickyPolicyEQ applies the EQ predicate on the "sticky_policy" field.
+func StickyPolicyEQ(v string) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldStickyPolicy, v))
+}
+
+// StickyPolicyNEQ applies the NEQ predicate on the "sticky_policy" field.
+func StickyPolicyNEQ(v string) predicate.Group {
+	return predicate.Group(sql.FieldNEQ(FieldStickyPolicy, v))
+}
+
+// StickyPolicyIn applies the In predicate on the "sticky_policy" field.
+func StickyPolicyIn(vs ...string) predicate.Group {
+	return predicate.Group(sql.FieldIn(FieldStickyPolicy, vs...))
+}
+
+// StickyPolicyNotIn applies the NotIn predicate on the "sticky_policy" field.
+func StickyPolicyNotIn(vs ...string) predicate.Group {
+	return predicate.Group(sql.FieldNotIn(FieldStickyPolicy, vs...))
+}
+
+// StickyPolicyGT applies the GT predicate on the "sticky_policy" field.
+func StickyPolicyGT(v string) predicate.Group {
+	return predicate.Group(sql.FieldGT(FieldStickyPolicy, v))
+}
+
+// StickyPolicyGTE applies the GTE predicate on the "sticky_policy" field.
+func StickyPolicyGTE(v string) predicate.Group {
+	return predicate.Group(sql.FieldGTE(FieldStickyPolicy, v))
+}
+
+// StickyPolicyLT applies the LT predicate on the "sticky_policy" field.
+func StickyPolicyLT(v string) predicate.Group {
+	return predicate.Group(sql.FieldLT(FieldStickyPolicy, v))
+}
+
+// StickyPolicyLTE applies the LTE predicate on the "sticky_policy" field.
+func StickyPolicyLTE(v string) predicate.Group {
+	return predicate.Group(sql.FieldLTE(FieldStickyPolicy, v))
+}
+
+// StickyPolicyContains applies the Contains predicate on the "sticky_policy" field.
+func StickyPolicyContains(v string) predicate.Group {
+	return predicate.Group(sql.FieldContains(FieldStickyPolicy, v))
+}
+
+// StickyPolicyHasPrefix applies the HasPrefix predicate on the "sticky_policy" field.
+func StickyPolicyHasPrefix(v string) predicate.Group {
+	return predicate.Group(sql.FieldHasPrefix(FieldStickyPolicy, v))
+}
+
+// StickyPolicyHasSuffix applies the HasSuffix predicate on the "sticky_policy" field.
+func StickyPolicyHasSuffix(v string) predicate.Group {
+	return predicate.Group(sql.FieldHasSuffix(FieldStickyPolicy, v))
+}
+
+// StickyPolicyEqualFold applies the EqualFold predicate on the "sticky_policy" field.
+func StickyPolicyEqualFold(v string) predicate.Group {
+	return predicate.Group(sql.FieldEqualFold(FieldStickyPolicy, v))
+}
+
+// StickyPolicyContainsFold applies the ContainsFold predicate on the "sticky_policy" field.
+func StickyPolicyContainsFold(v string) predicate.Group {
+	return predicate.Group(sql.FieldContainsFold(FieldStickyPolicy, v))
+}
+
+// StickyTTLSecondsEQ applies the EQ predicate on the "sticky_ttl_seconds" field.
+func StickyTTLSecondsEQ(v int) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldStickyTTLSeconds, v))
+}
+
+// StickyTTLSecondsNEQ applies the NEQ predicate on the "sticky_ttl_seconds" field.
+func StickyTTLSecondsNEQ(v int) predicate.Group {
+	return predicate.Group(sql.FieldNEQ(FieldStickyTTLSeconds, v))
+}
+
+// StickyTTLSecondsIn applies the In predicate on the "sticky_ttl_seconds" field.
+func StickyTTLSecondsIn(vs ...int) predicate.Group {
+	return predicate.Group(sql.FieldIn(FieldStickyTTLSeconds, vs...))
+}
+
+// StickyTTLSecondsNotIn applies the NotIn predicate on the "sticky_ttl_seconds" field.
+func StickyTTLSecondsNotIn(vs ...int) predicate.Group {
+	return predicate.Group(sql.FieldNotIn(FieldStickyTTLSeconds, vs...))
+}
+
+// StickyTTLSecondsGT applies the GT predicate on the "sticky_ttl_seconds" field.
+func StickyTTLSecondsGT(v int) predicate.Group {
+	return predicate.Group(sql.FieldGT(FieldStickyTTLSeconds, v))
+}
+
+// StickyTTLSecondsGTE applies the GTE predicate on the "sticky_ttl_seconds" field.
+func StickyTTLSecondsGTE(v int) predicate.Group {
+	return predicate.Group(sql.FieldGTE(FieldStickyTTLSeconds, v))
+}
+
+// StickyTTLSecondsLT applies the LT predicate on the "sticky_ttl_seconds" field.
+func StickyTTLSecondsLT(v int) predicate.Group {
+	return predicate.Group(sql.FieldLT(FieldStickyTTLSeconds, v))
+}
+
+// StickyTTLSecondsLTE applies the LTE predicate on the "sticky_ttl_seconds" field.
+func StickyTTLSecondsLTE(v int) predicate.Group {
+	return predicate.Group(sql.FieldLTE(FieldStickyTTLSeconds, v))
+}
+
+// AccountTagSelectorEQ applies the EQ predicate on the "account_tag_selector" field.
+func AccountTagSelectorEQ(v string) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldAccountTagSelector, v))
+}
+
+// AccountTagSelectorNEQ applies the NEQ predicate on the "account_tag_selector" field.
+func AccountTagSelectorNEQ(v string) predicate.Group {
+	return predicate.Group(sql.FieldNEQ(FieldAccountTagSelector, v))
+}
+
+// AccountTagSelectorIn applies the In predicate on the "account_tag_selector" field.
+func AccountTagSelectorIn(vs ...string) predicate.Group {
+	return predicate.Group(sql.FieldIn(FieldAccountTagSelector, vs...))
+}
+
+// AccountTagSelectorNotIn applies the NotIn predicate on the "account_tag_selector" field.
+func AccountTagSelectorNotIn(vs ...string) predicate.Group {
+	return predicate.Group(sql.FieldNotIn(FieldAccountTagSelector, vs...))
+}
+
+// AccountTagSelectorGT applies the GT predicate on the "account_tag_selector" field.
+func AccountTagSelectorGT(v string) predicate.Group {
+	return predicate.Group(sql.FieldGT(FieldAccountTagSelector, v))
+}
+
+// AccountTagSelectorGTE applies the GTE predicate on the "account_tag_selector" field.
+func AccountTagSelectorGTE(v string) predicate.Group {
+	return predicate.Group(sql.FieldGTE(FieldAccountTagSelector, v))
+}
+
+// AccountTagSelectorLT applies the LT predicate on the "account_tag_selector" field.
+func AccountTagSelectorLT(v string) predicate.Group {
+	return predicate.Group(sql.FieldLT(FieldAccountTagSelector, v))
+}
+
+// AccountTagSelectorLTE applies the LTE predicate on the "account_tag_selector" field.
+func AccountTagSelectorLTE(v string) predicate.Group {
+	return predicate.Group(sql.FieldLTE(FieldAccountTagSelector, v))
+}
+
+// AccountTagSelectorContains applies the Contains predicate on the "account_tag_selector" field.
+func AccountTagSelectorContains(v string) predicate.Group {
+	return predicate.Group(sql.FieldContains(FieldAccountTagSelector, v))
+}
+
+// AccountTagSelectorHasPrefix applies the HasPrefix predicate on the "account_tag_selector" field.
+func AccountTagSelectorHasPrefix(v string) predicate.Group {
+	return predicate.Group(sql.FieldHasPrefix(FieldAccountTagSelector, v))
+}
+
+// AccountTagSelectorHasSuffix applies the HasSuffix predicate on the "account_tag_selector" field.
+func AccountTagSelectorHasSuffix(v string) predicate.Group {
+	return predicate.Group(sql.FieldHasSuffix(FieldAccountTagSelector, v))
+}
+
+// AccountTagSelectorEqualFold applies the EqualFold predicate on the "account_tag_selector" field.
+func AccountTagSelectorEqualFold(v string) predicate.Group {
+	return predicate.Group(sql.FieldEqualFold(FieldAccountTagSelector, v))
+}
+
+// AccountTagSelectorContainsFold applies the ContainsFold predicate on the "account_tag_selector" field.
+func AccountTagSelectorContainsFold(v string) predicate.Group {
+	return predicate.Group(sql.FieldContainsFold(FieldAccountTagSelector, v))
+}
+
+// CostRoutingPolicyEQ applies the EQ predicate on the "cost_routing_policy" field.
+func CostRoutingPolicyEQ(v string) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldCostRoutingPolicy, v))
+}
+
+// CostRoutingPolicyNEQ applies the NEQ predicate on the "cost_routing_policy" field.
+func CostRoutingPolicyNEQ(v string) predicate.Group {
+	return predicate.Group(sql.FieldNEQ(FieldCostRoutingPolicy, v))
+}
+
+// CostRoutingPolicyIn applies the In predicate on the "cost_routing_policy" field.
+func CostRoutingPolicyIn(vs ...string) predicate.Group {
+	return predicate.Group(sql.FieldIn(FieldCostRoutingPolicy, vs...))
+}
+
+// CostRoutingPolicyNotIn applies the NotIn predicate on the "cost_routing_policy" field.
+func CostRoutingPolicyNotIn(vs ...string) predicate.Group {
+	return predicate.Group(sql.FieldNotIn(FieldCostRoutingPolicy, vs...))
+}
+
+// CostRoutingPolicyGT applies the GT predicate on the "cost_routing_policy" field.
+func CostRoutingPolicyGT(v string) predicate.Group {
+	return predicate.Group(sql.FieldGT(FieldCostRoutingPolicy, v))
+}
+
+// CostRoutingPolicyGTE applies the GTE predicate on the "cost_routing_policy" field.
+func CostRoutingPolicyGTE(v string) predicate.Group {
+	return predicate.Group(sql.FieldGTE(FieldCostRoutingPolicy, v))
+}
+
+// CostRoutingPolicyLT applies the LT predicate on the "cost_routing_policy" field.
+func CostRoutingPolicyLT(v string) predicate.Group {
+	return predicate.Group(sql.FieldLT(FieldCostRoutingPolicy, v))
+}
+
+// CostRoutingPolicyLTE applies the LTE predicate on the "cost_routing_policy" field.
+func CostRoutingPolicyLTE(v string) predicate.Group {
+	return predicate.Group(sql.FieldLTE(FieldCostRoutingPolicy, v))
+}
+
+// CostRoutingPolicyContains applies the Contains predicate on the "cost_routing_policy" field.
+func CostRoutingPolicyContains(v string) predicate.Group {
+	return predicate.Group(sql.FieldContains(FieldCostRoutingPolicy, v))
+}
+
+// CostRoutingPolicyHasPrefix applies the HasPrefix predicate on the "cost_routing_policy" field.
+func CostRoutingPolicyHasPrefix(v string) predicate.Group {
+	return predicate.Group(sql.FieldHasPrefix(FieldCostRoutingPolicy, v))
+}
+
+// CostRoutingPolicyHasSuffix applies the HasSuffix predicate on the "cost_routing_policy" field.
+func CostRoutingPolicyHasSuffix(v string) predicate.Group {
+	return predicate.Group(sql.FieldHasSuffix(FieldCostRoutingPolicy, v))
+}
+
+// CostRoutingPolicyEqualFold applies the EqualFold predicate on the "cost_routing_policy" field.
+func CostRoutingPolicyEqualFold(v string) predicate.Group {
+	return predicate.Group(sql.FieldEqualFold(FieldCostRoutingPolicy, v))
+}
+
+// CostRoutingPolicyContainsFold applies the ContainsFold predicate on the "cost_routing_policy" field.
+func CostRoutingPolicyContainsFold(v string) predicate.Group {
+	return predicate.Group(sql.FieldContainsFold(FieldCostRoutingPolicy, v))
+}
+
 // HasAPIKeys applies the HasEdge predicate on the "api_keys" edge.
 func HasAPIKeys() predicate.Group {
 	return predicate.Group(func(s *sql.Selector) {
@@ -306,6 +306,74 @@ func (_c *GroupCreate) SetNillableModelRoutingEnabled(v *bool) *GroupCreate {
 	return _c
 }
 
+// SetModelMapping sets the "model_mapping" field.
+func (_c *GroupCreate) SetModelMapping(v map[string]string) *GroupCreate {
+	_c.mutation.SetModelMapping(v)
+	return _c
+}
+
+// SetStickyPolicy sets the "sticky_policy" field.
+func (_c *GroupCreate) SetStickyPolicy(v string) *GroupCreate {
+	_c.mutation.SetStickyPolicy(v)
+	return _c
+}
+
+// SetNillableStickyPolicy sets the "sticky_policy" field if the given value is not nil.
+func (_c *GroupCreate) SetNillableStickyPolicy(v *string) *GroupCreate {
+	if v != nil {
+		_c.SetStickyPolicy(*v)
+	}
+	return _c
+}
+
+// SetStickyTTLSeconds sets the "sticky_ttl_seconds" field.
+func (_c *GroupCreate) SetStickyTTLSeconds(v int) *GroupCreate {
+	_c.mutation.SetStickyTTLSeconds(v)
+	return _c
+}
+
+// SetNillableStickyTTLSeconds sets the "sticky_ttl_seconds" field if the given value is not nil.
+func (_c *GroupCreate) SetNillableStickyTTLSeconds(v *int) *GroupCreate {
+	if v != nil {
+		_c.SetStickyTTLSeconds(*v)
+	}
+	return _c
+}
+
+// SetAccountTagSelector sets the "account_tag_selector" field.
+func (_c *GroupCreate) SetAccountTagSelector(v string) *GroupCreate {
+	_c.mutation.SetAccountTagSelector(v)
+	return _c
+}
+
+// SetNillableAccountTagSelector sets the "account_tag_selector" field if the given value is not nil.
+func (_c *GroupCreate) SetNillableAccountTagSelector(v *string) *GroupCreate {
+	if v != nil {
+		_c.SetAccountTagSelector(*v)
+	}
+	return _c
+}
+
+// SetModelRoutingTags sets the "model_routing_tags" field.
+func (_c *GroupCreate) SetModelRoutingTags(v map[string]string) *GroupCreate {
+	_c.mutation.SetModelRoutingTags(v)
+	return _c
+}
+
+// SetCostRoutingPolicy sets the "cost_routing_policy" field.
+func (_c *GroupCreate) SetCostRoutingPolicy(v string) *GroupCreate {
+	_c.mutation.SetCostRoutingPolicy(v)
+	return _c
+}
+
+// SetNillableCostRoutingPolicy sets the "cost_routing_policy" field if the given value is not nil.
+func (_c *GroupCreate) SetNillableCostRoutingPolicy(v *string) *GroupCreate {
+	if v != nil {
+		_c.SetCostRoutingPolicy(*v)
+	}
+	return _c
+}
+
 // AddAPIKeyIDs adds the "api_keys" edge to the APIKey entity by IDs.
 func (_c *GroupCreate) AddAPIKeyIDs(ids ...int64) *GroupCreate {
 	_c.mutation.AddAPIKeyIDs(ids...)
@@ -479,6 +547,30 @@ func (_c *GroupCreate) defaults() error {
 		v := group.DefaultModelRoutingEnabled
 		_c.mutation.SetModelRoutingEnabled(v)
 	}
+	if _, ok := _c.mutation.ModelMapping(); !ok {
+		v := group.DefaultModelMapping
+		_c.mutation.SetModelMapping(v)
+	}
+	if _, ok := _c.mutation.StickyPolicy(); !ok {
+		v := group.DefaultStickyPolicy
+		_c.mutation.SetStickyPolicy(v)
+	}
+	if _, ok := _c.mutation.StickyTTLSeconds(); !ok {
+		v := group.DefaultStickyTTLSeconds
+		_c.mutation.SetStickyTTLSeconds(v)
+	}
+	if _, ok := _c.mutation.AccountTagSelector(); !ok {
+		v := group.DefaultAccountTagSelector
+		_c.mutation.SetAccountTagSelector(v)
+	}
+	if _, ok := _c.mutation.ModelRoutingTags(); !ok {
+		v := group.DefaultModelRoutingTags
+		_c.mutation.SetModelRoutingTags(v)
+	}
+	if _, ok := _c.mutation.CostRoutingPolicy(); !ok {
+		v := group.DefaultCostRoutingPolicy
+		_c.mutation.SetCostRoutingPolicy(v)
+	}
 	return nil
 }
 
@@ -537,6 +629,39 @@ func (_c *GroupCreate) check() error {
 	if _, ok := _c.mutation.ModelRoutingEnabled(); !ok {
 		return &ValidationError{Name: "model_routing_enabled", err: errors.New(`ent: missing required field "Group.model_routing_enabled"`)}
 	}
+	if _, ok := _c.mutation.ModelMapping(); !ok {
+		return &ValidationError{Name: "model_mapping", err: errors.New(`ent: missing required field "Group.model_mapping"`)}
+	}
+	if _, ok := _c.mutation.StickyPolicy(); !ok {
+		return &ValidationError{Name: "sticky_policy", err: errors.New(`ent: missing required field "Group.sticky_policy"`)}
+	}
+	if v, ok := _c.mutation.StickyPolicy(); ok {
+		if err := group.StickyPolicyValidator(v); err != nil {
+			return &ValidationError{Name: "sticky_policy", err: fmt.Errorf(`ent: validator failed for field "Group.sticky_policy": %w`, err)}
+		}
+	}
+	if _, ok := _c.mutation.StickyTTLSeconds(); !ok {
+		return &ValidationError{Name: "sticky_ttl_seconds", err: errors.New(`ent: missing required field "Group.sticky_ttl_seconds"`)}
+	}
+	if _, ok := _c.mutation.AccountTagSelector(); !ok {
+		return &ValidationError{Name: "account_tag_selector", err: errors.New(`ent: missing required field "Group.account_tag_selector"`)}
+	}
+	if v, ok := _c.mutation.AccountTagSelector(); ok {
+		if err := group.AccountTagSelectorValidator(v); err != nil {
+			return &ValidationError{Name: "account_tag_selector", err: fmt.Errorf(`ent: validator failed for field "Group.account_tag_selector": %w`, err)}
+		}
+	}
+	if _, ok := _c.mutation.ModelRoutingTags(); !ok {
+		return &ValidationError{Name: "model_routing_tags", err: errors.New(`ent: missing required field "Group.model_routing_tags"`)}
+	}
+	if _, ok := _c.mutation.CostRoutingPolicy(); !ok {
+		return &ValidationError{Name: "cost_routing_policy", err: errors.New(`ent: missing required field "Group.cost_routing_policy"`)}
+	}
+	if v, ok := _c.mutation.CostRoutingPolicy(); ok {
+		if err := group.CostRoutingPolicyValidator(v); err != nil {
+			return &ValidationError{Name: "cost_routing_policy", err: fmt.Errorf(`ent: validator failed for field "Group.cost_routing_policy": %w`, err)}
+		}
+	}
 	return nil
 }
 
@@ -648,6 +773,30 @@ func (_c *GroupCreate) createSpec() (*Group, *sqlgraph.CreateSpec) {
 		_spec.SetField(group.FieldModelRoutingEnabled, field.TypeBool, value)
 		_node.ModelRoutingEnabled = value
 	}
+	if value, ok := _c.mutation.ModelMapping(); ok {
+		_spec.SetField(group.FieldModelMapping, field.TypeJSON, value)
+		_node.ModelMapping = value
+	}
+	if value, ok := _c.mutation.StickyPolicy(); ok {
+		_spec.SetField(group.FieldStickyPolicy, field.TypeString, value)
+		_node.StickyPolicy = value
+	}
+	if value, ok := _c.mutation.StickyTTLSeconds(); ok {
+		_spec.SetField(group.FieldStickyTTLSeconds, field.TypeInt, value)
+		_node.StickyTTLSeconds = value
+	}
+	if value, ok := _c.mutation.AccountTagSelector(); ok {
+		_spec.SetField(group.FieldAccountTagSelector, field.TypeString, value)
+		_node.AccountTagSelector = value
+	}
+	if value, ok := _c.mutation.ModelRoutingTags(); ok {
+		_spec.SetField(group.FieldModelRoutingTags, field.TypeJSON, value)
+		_node.ModelRoutingTags = value
+	}
+	if value, ok := _c.mutation.CostRoutingPolicy(); ok {
+		_spec.SetField(group.FieldCostRoutingPolicy, field.TypeString, value)
+		_node.CostRoutingPolicy = value
+	}
 	if nodes := _c.mutation.APIKeysIDs(); len(nodes) > 0 {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.O2M,
@@ -1158,6 +1307,84 @@ func (u *GroupUpsert) UpdateModelRoutingEnabled() *GroupUpsert {
 	return u
 }
 
+// SetModelMapping sets the "model_mapping" field.
+func (u *GroupUpsert) SetModelMapping(v map[string]string) *GroupUpsert {
+	u.Set(group.FieldModelMapping, v)
+	return u
+}
+
+// UpdateModelMapping sets the "model_mapping" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateModelMapping() *GroupUpsert {
+	u.SetExcluded(group.FieldModelMapping)
+	return u
+}
+
+// SetStickyPolicy sets the "sticky_policy" field.
+func (u *GroupUpsert) SetStickyPolicy(v string) *GroupUpsert {
+	u.Set(group.FieldStickyPolicy, v)
+	return u
+}
+
+// UpdateStickyPolicy sets the "sticky_policy" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateStickyPolicy() *GroupUpsert {
+	u.SetExcluded(group.FieldStickyPolicy)
+	return u
+}
+
+// SetStickyTTLSeconds sets the "sticky_ttl_seconds" field.
+func (u *GroupUpsert) SetStickyTTLSeconds(v int) *GroupUpsert {
+	u.Set(group.FieldStickyTTLSeconds, v)
+	return u
+}
+
+// UpdateStickyTTLSeconds sets the "sticky_ttl_seconds" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateStickyTTLSeconds() *GroupUpsert {
+	u.SetExcluded(group.FieldStickyTTLSeconds)
+	return u
+}
+
+// AddStickyTTLSeconds adds v to the "sticky_ttl_seconds" field.
+func (u *GroupUpsert) AddStickyTTLSeconds(v int) *GroupUpsert {
+	u.Add(group.FieldStickyTTLSeconds, v)
+	return u
+}
+
+// SetAccountTagSelector sets the "account_tag_selector" field.
+func (u *GroupUpsert) SetAccountTagSelector(v string) *GroupUpsert {
+	u.Set(group.FieldAccountTagSelector, v)
+	return u
+}
+
+// UpdateAccountTagSelector sets the "account_tag_selector" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateAccountTagSelector() *GroupUpsert {
+	u.SetExcluded(group.FieldAccountTagSelector)
+	return u
+}
+
+// SetModelRoutingTags sets the "model_routing_tags" field.
+func (u *GroupUpsert) SetModelRoutingTags(v map[string]string) *GroupUpsert {
+	u.Set(group.FieldModelRoutingTags, v)
+	return u
+}
+
+// UpdateModelRoutingTags sets the "model_routing_tags" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateModelRoutingTags() *GroupUpsert {
+	u.SetExcluded(group.FieldModelRoutingTags)
+	return u
+}
+
+// SetCostRoutingPolicy sets the "cost_routing_policy" field.
+func (u *GroupUpsert) SetCostRoutingPolicy(v string) *GroupUpsert {
+	u.Set(group.FieldCostRoutingPolicy, v)
+	return u
+}
+
+// UpdateCostRoutingPolicy sets the "cost_routing_policy" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateCostRoutingPolicy() *GroupUpsert {
+	u.SetExcluded(group.FieldCostRoutingPolicy)
+	return u
+}
+
 // UpdateNewValues updates the mutable fields using the new values that were set on create.
 // Using this option is equivalent to using:
 //
@@ -1616,6 +1843,97 @@ func (u *GroupUpsertOne) UpdateModelRoutingEnabled() *GroupUpsertOne {
 	})
 }
 
+// SetModelMapping sets the "model_mapping" field.
+func (u *GroupUpsertOne) SetModelMapping(v map[string]string) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetModelMapping(v)
+	})
+}
+
+// UpdateModelMapping sets the "model_mapping" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateModelMapping() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateModelMapping()
+	})
+}
+
+// SetStickyPolicy sets the "sticky_policy" field.
+func (u *GroupUpsertOne) SetStickyPolicy(v string) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetStickyPolicy(v)
+	})
+}
+
+// UpdateStickyPolicy sets the "sticky_policy" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateStickyPolicy() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateStickyPolicy()
+	})
+}
+
+// SetStickyTTLSeconds sets the "sticky_ttl_seconds" field.
+func (u *GroupUpsertOne) SetStickyTTLSeconds(v int) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetStickyTTLSeconds(v)
+	})
+}
+
+// AddStickyTTLSeconds adds v to the "sticky_ttl_seconds" field.
+func (u *GroupUpsertOne) AddStickyTTLSeconds(v int) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.AddStickyTTLSeconds(v)
+	})
+}
+
+// UpdateStickyTTLSeconds sets the "sticky_ttl_seconds" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateStickyTTLSeconds() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateStickyTTLSeconds()
+	})
+}
+
+// SetAccountTagSelector sets the "account_tag_selector" field.
+func (u *GroupUpsertOne) SetAccountTagSelector(v string) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetAccountTagSelector(v)
+	})
+}
+
+// UpdateAccountTagSelector sets the "account_tag_selector" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateAccountTagSelector() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateAccountTagSelector()
+	})
+}
+
+// SetModelRoutingTags sets the "model_routing_tags" field.
+func (u *GroupUpsertOne) SetModelRoutingTags(v map[string]string) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetModelRoutingTags(v)
+	})
+}
+
+// UpdateModelRoutingTags sets the "model_routing_tags" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateModelRoutingTags() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateModelRoutingTags()
+	})
+}
+
+// SetCostRoutingPolicy sets the "cost_routing_policy" field.
+func (u *GroupUpsertOne) SetCostRoutingPolicy(v string) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetCostRoutingPolicy(v)
+	})
+}
+
+// UpdateCostRoutingPolicy sets the "cost_routing_policy" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateCostRoutingPolicy() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateCostRoutingPolicy()
+	})
+}
+
 // Exec executes the query.
 func (u *GroupUpsertOne) Exec(ctx context.Context) error {
 	if len(u.create.conflict) == 0 {
@@ -2240,6 +2558,97 @@ func (u *GroupUpsertBulk) UpdateModelRoutingEnabled() *GroupUpsertBulk {
 	})
 }
 
+// SetModelMapping sets the "model_mapping" field.
+func (u *GroupUpsertBulk) SetModelMapping(v map[string]string) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetModelMapping(v)
+	})
+}
+
+// UpdateModelMapping sets the "model_mapping" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateModelMapping() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateModelMapping()
+	})
+}
+
+// SetStickyPolicy sets the "sticky_policy" field.
+func (u *GroupUpsertBulk) SetStickyPolicy(v string) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetStickyPolicy(v)
+	})
+}
+
+// UpdateStickyPolicy sets the "sticky_policy" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateStickyPolicy() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateStickyPolicy()
+	})
+}
+
+// SetStickyTTLSeconds sets the "sticky_ttl_seconds" field.
+func (u *GroupUpsertBulk) SetStickyTTLSeconds(v int) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetStickyTTLSeconds(v)
+	})
+}
+
+// AddStickyTTLSeconds adds v to the "sticky_ttl_seconds" field.
+func (u *GroupUpsertBulk) AddStickyTTLSeconds(v int) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.AddStickyTTLSeconds(v)
+	})
+}
+
+// UpdateStickyTTLSeconds sets the "sticky_ttl_seconds" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateStickyTTLSeconds() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateStickyTTLSeconds()
+	})
+}
+
+// SetAccountTagSelector sets the "account_tag_selector" field.
+func (u *GroupUpsertBulk) SetAccountTagSelector(v string) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetAccountTagSelector(v)
+	})
+}
+
+// UpdateAccountTagSelector sets the "account_tag_selector" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateAccountTagSelector() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateAccountTagSelector()
+	})
+}
+
+// SetModelRoutingTags sets the "model_routing_tags" field.
+func (u *GroupUpsertBulk) SetModelRoutingTags(v map[string]string) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetModelRoutingTags(v)
+	})
+}
+
+// UpdateModelRoutingTags sets the "model_routing_tags" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateModelRoutingTags() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateModelRoutingTags()
+	})
+}
+
+// SetCostRoutingPolicy sets the "cost_routing_policy" field.
+func (u *GroupUpsertBulk) SetCostRoutingPolicy(v string) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetCostRoutingPolicy(v)
+	})
+}
+
+// UpdateCostRoutingPolicy sets the "cost_routing_policy" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateCostRoutingPolicy() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateCostRoutingPolicy()
+	})
+}
+
 // Exec executes the query.
 func (u *GroupUpsertBulk) Exec(ctx context.Context) error {
 	if u.create.err != nil {
@@ -421,6 +421,81 @@ func (_u *GroupUpdate) SetNillableModelRoutingEnabled(v *bool) *GroupUpdate {
 	return _u
 }
 
+// SetModelMapping sets the "model_mapping" field.
+func (_u *GroupUpdate) SetModelMapping(v map[string]string) *GroupUpdate {
+	_u.mutation.SetModelMapping(v)
+	return _u
+}
+
+// SetStickyPolicy sets the "sticky_policy" field.
+func (_u *GroupUpdate) SetStickyPolicy(v string) *GroupUpdate {
+	_u.mutation.SetStickyPolicy(v)
+	return _u
+}
+
+// SetNillableStickyPolicy sets the "sticky_policy" field if the given value is not nil.
+func (_u *GroupUpdate) SetNillableStickyPolicy(v *string) *GroupUpdate {
+	if v != nil {
+		_u.SetStickyPolicy(*v)
+	}
+	return _u
+}
+
+// SetStickyTTLSeconds sets the "sticky_ttl_seconds" field.
+func (_u *GroupUpdate) SetStickyTTLSeconds(v int) *GroupUpdate {
+	_u.mutation.ResetStickyTTLSeconds()
+	_u.mutation.SetStickyTTLSeconds(v)
+	return _u
+}
+
+// SetNillableStickyTTLSeconds sets the "sticky_ttl_seconds" field if the given value is not nil.
+func (_u *GroupUpdate) SetNillableStickyTTLSeconds(v *int) *GroupUpdate {
+	if v != nil {
+		_u.SetStickyTTLSeconds(*v)
+	}
+	return _u
+}
+
+// AddStickyTTLSeconds adds value to the "sticky_ttl_seconds" field.
+func (_u *GroupUpdate) AddStickyTTLSeconds(v int) *GroupUpdate {
+	_u.mutation.AddStickyTTLSeconds(v)
+	return _u
+}
+
+// SetAccountTagSelector sets the "account_tag_selector" field.
+func (_u *GroupUpdate) SetAccountTagSelector(v string) *GroupUpdate {
+	_u.mutation.SetAccountTagSelector(v)
+	return _u
+}
+
+// SetNillableAccountTagSelector sets the "account_tag_selector" field if the given value is not nil.
+func (_u *GroupUpdate) SetNillableAccountTagSelector(v *string) *GroupUpdate {
+	if v != nil {
+		_u.SetAccountTagSelector(*v)
+	}
+	return _u
+}
+
+// SetModelRoutingTags sets the "model_routing_tags" field.
+func (_u *GroupUpdate) SetModelRoutingTags(v map[string]string) *GroupUpdate {
+	_u.mutation.SetModelRoutingTags(v)
+	return _u
+}
+
+// SetCostRoutingPolicy sets the "cost_routing_policy" field.
+func (_u *GroupUpdate) SetCostRoutingPolicy(v string) *GroupUpdate {
+	_u.mutation.SetCostRoutingPolicy(v)
+	return _u
+}
+
+// SetNillableCostRoutingPolicy sets the "cost_routing_policy" field if the given value is not nil.
+func (_u *GroupUpdate) SetNillableCostRoutingPolicy(v *string) *GroupUpdate {
+	if v != nil {
+		_u.SetCostRoutingPolicy(*v)
+	}
+	return _u
+}
+
 // AddAPIKeyIDs adds the "api_keys" edge to the APIKey entity by IDs.
 func (_u *GroupUpdate) AddAPIKeyIDs(ids ...int64) *GroupUpdate {
 	_u.mutation.AddAPIKeyIDs(ids...)
@@ -706,6 +781,21 @@ func (_u *GroupUpdate) check() error {
 			return &ValidationError{Name: "subscription_type", err: fmt.Errorf(`ent: validator failed for field "Group.subscription_type": %w`, err)}
 		}
 	}
+	if v, ok := _u.mutation.StickyPolicy(); ok {
+		if err := group.StickyPolicyValidator(v); err != nil {
+			return &ValidationError{Name: "sticky_policy", err: fmt.Errorf(`ent: validator failed for field "Group.sticky_policy": %w`, err)}
+		}
+	}
+	if v, ok := _u.mutation.AccountTagSelector(); ok {
+		if err := group.AccountTagSelectorValidator(v); err != nil {
+			return &ValidationError{Name: "account_tag_selector", err: fmt.Errorf(`ent: validator failed for field "Group.account_tag_selector": %w`, err)}
+		}
+	}
+	if v, ok := _u.mutation.CostRoutingPolicy(); ok {
+		if err := group.CostRoutingPolicyValidator(v); err != nil {
+			return &ValidationError{Name: "cost_routing_policy", err: fmt.Errorf(`ent: validator failed for field "Group.cost_routing_policy": %w`, err)}
+		}
+	}
 	return nil
 }
 
@@ -838,6 +928,27 @@ func (_u *GroupUpdate) sqlSave(ctx context.Context) (_node int, err error) {
 	if value, ok := _u.mutation.ModelRoutingEnabled(); ok {
 		_spec.SetField(group.FieldModelRoutingEnabled, field.TypeBool, value)
 	}
+	if value, ok := _u.mutation.ModelMapping(); ok {
+		_spec.SetField(group.FieldModelMapping, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.StickyPolicy(); ok {
+		_spec.SetField(group.FieldStickyPolicy, field.TypeString, value)
+	}
+	if value, ok := _u.mutation.StickyTTLSeconds(); ok {
+		_spec.SetField(group.FieldStickyTTLSeconds, field.TypeInt, value)
+	}
+	if value, ok := _u.mutation.AddedStickyTTLSeconds(); ok {
+		_spec.AddField(group.FieldStickyTTLSeconds, field.TypeInt, value)
+	}
+	if value, ok := _u.mutation.AccountTagSelector(); ok {
+		_spec.SetField(group.FieldAccountTagSelector, field.TypeString, value)
+	}
+	if value, ok := _u.mutation.ModelRoutingTags(); ok {
+		_spec.SetField(group.FieldModelRoutingTags, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.CostRoutingPolicy(); ok {
+		_spec.SetField(group.FieldCostRoutingPolicy, field.TypeString, value)
+	}
 	if _u.mutation.APIKeysCleared() {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.O2M,
@@ -1539,6 +1650,81 @@ func (_u *GroupUpdateOne) SetNillableModelRoutingEnabled(v *bool) *GroupUpdateOn
 	return _u
 }
 
+// SetModelMapping sets the "model_mapping" field.
+func (_u *GroupUpdateOne) SetModelMapping(v map[string]string) *GroupUpdateOne {
+	_u.mutation.SetModelMapping(v)
+	return _u
+}
+
+// SetStickyPolicy sets the "sticky_policy" field.
+func (_u *GroupUpdateOne) SetStickyPolicy(v string) *GroupUpdateOne {
+	_u.mutation.SetStickyPolicy(v)
+	return _u
+}
+
+// SetNillableStickyPolicy sets the "sticky_policy" field if the given value is not nil.
+func (_u *GroupUpdateOne) SetNillableStickyPolicy(v *string) *GroupUpdateOne {
+	if v != nil {
+		_u.SetStickyPolicy(*v)
+	}
+	return _u
+}
+
+// SetStickyTTLSeconds sets the "sticky_ttl_seconds" field.
+func (_u *GroupUpdateOne) SetStickyTTLSeconds(v int) *GroupUpdateOne {
+	_u.mutation.ResetStickyTTLSeconds()
+	_u.mutation.SetStickyTTLSeconds(v)
+	return _u
+}
+
+// SetNillableStickyTTLSeconds sets the "sticky_ttl_seconds" field if the given value is not nil.
+func (_u *GroupUpdateOne) SetNillableStickyTTLSeconds(v *int) *GroupUpdateOne {
+	if v != nil {
+		_u.SetStickyTTLSeconds(*v)
+	}
+	return _u
+}
+
+// AddStickyTTLSeconds adds value to the "sticky_ttl_seconds" field.
+func (_u *GroupUpdateOne) AddStickyTTLSeconds(v int) *GroupUpdateOne {
+	_u.mutation.AddStickyTTLSeconds(v)
+	return _u
+}
+
+// SetAccountTagSelector sets the "account_tag_selector" field.
+func (_u *GroupUpdateOne) SetAccountTagSelector(v string) *GroupUpdateOne {
+	_u.mutation.SetAccountTagSelector(v)
+	return _u
+}
+
+// SetNillableAccountTagSelector sets the "account_tag_selector" field if the given value is not nil.
+func (_u *GroupUpdateOne) SetNillableAccountTagSelector(v *string) *GroupUpdateOne {
+	if v != nil {
+		_u.SetAccountTagSelector(*v)
+	}
+	return _u
+}
+
+// SetModelRoutingTags sets the "model_routing_tags" field.
+func (_u *GroupUpdateOne) SetModelRoutingTags(v map[string]string) *GroupUpdateOne {
+	_u.mutation.SetModelRoutingTags(v)
+	return _u
+}
+
+// SetCostRoutingPolicy sets the "cost_routing_policy" field.
+func (_u *GroupUpdateOne) SetCostRoutingPolicy(v string) *GroupUpdateOne {
+	_u.mutation.SetCostRoutingPolicy(v)
+	return _u
+}
+
+// SetNillableCostRoutingPolicy sets the "cost_routing_policy" field if the given value is not nil.
+func (_u *GroupUpdateOne) SetNillableCostRoutingPolicy(v *string) *GroupUpdateOne {
+	if v != nil {
+		_u.SetCostRoutingPolicy(*v)
+	}
+	return _u
+}
+
 // AddAPIKeyIDs adds the "api_keys" edge to the APIKey entity by IDs.
 func (_u *GroupUpdateOne) AddAPIKeyIDs(ids ...int64) *GroupUpdateOne {
 	_u.mutation.AddAPIKeyIDs(ids...)
@@ -1837,6 +2023,21 @@ func (_u *GroupUpdateOne) check() error {
 			return &ValidationError{Name: "subscription_type", err: fmt.Errorf(`ent: validator failed for field "Group.subscription_type": %w`, err)}
 		}
 	}
+	if v, ok := _u.mutation.StickyPolicy(); ok {
+		if err := group.StickyPolicyValidator(v); err != nil {
+			return &ValidationError{Name: "sticky_policy", err: fmt.Errorf(`ent: validator failed for field "Group.sticky_policy": %w`, err)}
+		}
+	}
+	if v, ok := _u.mutation.AccountTagSelector(); ok {
+		if err := group.AccountTagSelectorValidator(v); err != nil {
+			return &ValidationError{Name: "account_tag_selector", err: fmt.Errorf(`ent: validator failed for field "Group.account_tag_selector": %w`, err)}
+		}
+	}
+	if v, ok := _u.mutation.CostRoutingPolicy(); ok {
+		if err := group.CostRoutingPolicyValidator(v); err != nil {
+			return &ValidationError{Name: "cost_routing_policy", err: fmt.Errorf(`ent: validator failed for field "Group.cost_routing_policy": %w`, err)}
+		}
+	}
 	return nil
 }
 
@@ -1986,6 +2187,27 @@ func (_u *GroupUpdateOne) sqlSave(ctx context.Context) (_node *Group, err error)
 	if value, ok := _u.mutation.ModelRoutingEnabled(); ok {
 		_spec.SetField(group.FieldModelRoutingEnabled, field.TypeBool, value)
 	}
+	if value, ok := _u.mutation.ModelMapping(); ok {
+		_spec.SetField(group.FieldModelMapping, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.StickyPolicy(); ok {
+		_spec.SetField(group.FieldStickyPolicy, field.TypeString, value)
+	}
+	if value, ok := _u.mutation.StickyTTLSeconds(); ok {
+		_spec.SetField(group.FieldStickyTTLSeconds, field.TypeInt, value)
+	}
+	if value, ok := _u.mutation.AddedStickyTTLSeconds(); ok {
+		_spec.AddField(group.FieldStickyTTLSeconds, field.TypeInt, value)
+	}
+	if value, ok := _u.mutation.AccountTagSelector(); ok {
+		_spec.SetField(group.FieldAccountTagSelector, field.TypeString, value)
+	}
+	if value, ok := _u.mutation.ModelRoutingTags(); ok {
+		_spec.SetField(group.FieldModelRoutingTags, field.TypeJSON, value)
+	}
+	if value, ok := _u.mutation.CostRoutingPolicy(); ok {
+		_spec.SetField(group.FieldCostRoutingPolicy, field.TypeString, value)
+	}
 	if _u.mutation.APIKeysCleared() {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.O2M,
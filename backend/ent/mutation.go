@@ -29,6 +29,7 @@ import (
 	"github.com/Wei-Shaw/sub2api/ent/userattributedefinition"
 	"github.com/Wei-Shaw/sub2api/ent/userattributevalue"
 	"github.com/Wei-Shaw/sub2api/ent/usersubscription"
+	"github.com/lib/pq"
 )
 
 const (
@@ -61,30 +62,45 @@ const (
 // APIKeyMutation represents an operation that mutates the APIKey nodes in the graph.
 type APIKeyMutation struct {
 	config
-	op                 Op
-	typ                string
-	id                 *int64
-	created_at         *time.Time
-	updated_at         *time.Time
-	deleted_at         *time.Time
-	key                *string
-	name               *string
-	status             *string
-	ip_whitelist       *[]string
-	appendip_whitelist []string
-	ip_blacklist       *[]string
-	appendip_blacklist []string
-	clearedFields      map[string]struct{}
-	user               *int64
-	cleareduser        bool
-	group              *int64
-	clearedgroup       bool
-	usage_logs         map[int64]struct{}
-	removedusage_logs  map[int64]struct{}
-	clearedusage_logs  bool
-	done               bool
-	oldValue           func(context.Context) (*APIKey, error)
-	predicates         []predicate.APIKey
+	op                       Op
+	typ                      string
+	id                       *int64
+	created_at               *time.Time
+	updated_at               *time.Time
+	deleted_at               *time.Time
+	key                      *string
+	name                     *string
+	status                   *string
+	ip_whitelist             *[]string
+	appendip_whitelist       []string
+	ip_blacklist             *[]string
+	appendip_blacklist       []string
+	expires_at               *time.Time
+	total_limit_usd          *float64
+	addtotal_limit_usd       *float64
+	daily_limit_usd          *float64
+	adddaily_limit_usd       *float64
+	monthly_limit_usd        *float64
+	addmonthly_limit_usd     *float64
+	daily_request_limit      *int64
+	adddaily_request_limit   *int64
+	monthly_request_limit    *int64
+	addmonthly_request_limit *int64
+	allowed_models           *[]string
+	appendallowed_models     []string
+	allowed_platforms        *[]string
+	appendallowed_platforms  []string
+	clearedFields            map[string]struct{}
+	user                     *int64
+	cleareduser              bool
+	group                    *int64
+	clearedgroup             bool
+	usage_logs               map[int64]struct{}
+	removedusage_logs        map[int64]struct{}
+	clearedusage_logs        bool
+	done                     bool
+	oldValue                 func(context.Context) (*APIKey, error)
+	predicates               []predicate.APIKey
 }
 
 var _ ent.Mutation = (*APIKeyMutation)(nil)
@@ -606,27 +622,556 @@ func (m *APIKeyMutation) AppendedIPBlacklist() ([]string, bool) {
 	if len(m.appendip_blacklist) == 0 {
 		return nil, false
 	}
-	return m.appendip_blacklist, true
+	return m.appendip_blacklist, true
+}
+
+// ClearIPBlacklist clears the value of the "ip_blacklist" field.
+func (m *APIKeyMutation) ClearIPBlacklist() {
+	m.ip_blacklist = nil
+	m.appendip_blacklist = nil
+	m.clearedFields[apikey.FieldIPBlacklist] = struct{}{}
+}
+
+// IPBlacklistCleared returns if the "ip_blacklist" field was cleared in this mutation.
+func (m *APIKeyMutation) IPBlacklistCleared() bool {
+	_, ok := m.clearedFields[apikey.FieldIPBlacklist]
+	return ok
+}
+
+// ResetIPBlacklist resets all changes to the "ip_blacklist" field.
+func (m *APIKeyMutation) ResetIPBlacklist() {
+	m.ip_blacklist = nil
+	m.appendip_blacklist = nil
+	delete(m.clearedFields, apikey.FieldIPBlacklist)
+}
+
+// SetExpiresAt sets the "expires_at" field.
+func (m *APIKeyMutation) SetExpiresAt(t time.Time) {
+	m.expires_at = &t
+}
+
+// ExpiresAt returns the value of the "expires_at" field in the mutation.
+func (m *APIKeyMutation) ExpiresAt() (r time.Time, exists bool) {
+	v := m.expires_at
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldExpiresAt returns the old "expires_at" field's value of the APIKey entity.
+// If the APIKey object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *APIKeyMutation) OldExpiresAt(ctx context.Context) (v *time.Time, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldExpiresAt is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldExpiresAt requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldExpiresAt: %w", err)
+	}
+	return oldValue.ExpiresAt, nil
+}
+
+// ClearExpiresAt clears the value of the "expires_at" field.
+func (m *APIKeyMutation) ClearExpiresAt() {
+	m.expires_at = nil
+	m.clearedFields[apikey.FieldExpiresAt] = struct{}{}
+}
+
+// ExpiresAtCleared returns if the "expires_at" field was cleared in this mutation.
+func (m *APIKeyMutation) ExpiresAtCleared() bool {
+	_, ok := m.clearedFields[apikey.FieldExpiresAt]
+	return ok
+}
+
+// ResetExpiresAt resets all changes to the "expires_at" field.
+func (m *APIKeyMutation) ResetExpiresAt() {
+	m.expires_at = nil
+	delete(m.clearedFields, apikey.FieldExpiresAt)
+}
+
+// SetTotalLimitUsd sets the "total_limit_usd" field.
+func (m *APIKeyMutation) SetTotalLimitUsd(f float64) {
+	m.total_limit_usd = &f
+	m.addtotal_limit_usd = nil
+}
+
+// TotalLimitUsd returns the value of the "total_limit_usd" field in the mutation.
+func (m *APIKeyMutation) TotalLimitUsd() (r float64, exists bool) {
+	v := m.total_limit_usd
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldTotalLimitUsd returns the old "total_limit_usd" field's value of the APIKey entity.
+// If the APIKey object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *APIKeyMutation) OldTotalLimitUsd(ctx context.Context) (v *float64, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldTotalLimitUsd is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldTotalLimitUsd requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldTotalLimitUsd: %w", err)
+	}
+	return oldValue.TotalLimitUsd, nil
+}
+
+// AddTotalLimitUsd adds f to the "total_limit_usd" field.
+func (m *APIKeyMutation) AddTotalLimitUsd(f float64) {
+	if m.addtotal_limit_usd != nil {
+		*m.addtotal_limit_usd += f
+	} else {
+		m.addtotal_limit_usd = &f
+	}
+}
+
+// AddedTotalLimitUsd returns the value that was added to the "total_limit_usd" field in this mutation.
+func (m *APIKeyMutation) AddedTotalLimitUsd() (r float64, exists bool) {
+	v := m.addtotal_limit_usd
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ClearTotalLimitUsd clears the value of the "total_limit_usd" field.
+func (m *APIKeyMutation) ClearTotalLimitUsd() {
+	m.total_limit_usd = nil
+	m.addtotal_limit_usd = nil
+	m.clearedFields[apikey.FieldTotalLimitUsd] = struct{}{}
+}
+
+// TotalLimitUsdCleared returns if the "total_limit_usd" field was cleared in this mutation.
+func (m *APIKeyMutation) TotalLimitUsdCleared() bool {
+	_, ok := m.clearedFields[apikey.FieldTotalLimitUsd]
+	return ok
+}
+
+// ResetTotalLimitUsd resets all changes to the "total_limit_usd" field.
+func (m *APIKeyMutation) ResetTotalLimitUsd() {
+	m.total_limit_usd = nil
+	m.addtotal_limit_usd = nil
+	delete(m.clearedFields, apikey.FieldTotalLimitUsd)
+}
+
+// SetDailyLimitUsd sets the "daily_limit_usd" field.
+func (m *APIKeyMutation) SetDailyLimitUsd(f float64) {
+	m.daily_limit_usd = &f
+	m.adddaily_limit_usd = nil
+}
+
+// DailyLimitUsd returns the value of the "daily_limit_usd" field in the mutation.
+func (m *APIKeyMutation) DailyLimitUsd() (r float64, exists bool) {
+	v := m.daily_limit_usd
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldDailyLimitUsd returns the old "daily_limit_usd" field's value of the APIKey entity.
+// If the APIKey object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *APIKeyMutation) OldDailyLimitUsd(ctx context.Context) (v *float64, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldDailyLimitUsd is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldDailyLimitUsd requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldDailyLimitUsd: %w", err)
+	}
+	return oldValue.DailyLimitUsd, nil
+}
+
+// AddDailyLimitUsd adds f to the "daily_limit_usd" field.
+func (m *APIKeyMutation) AddDailyLimitUsd(f float64) {
+	if m.adddaily_limit_usd != nil {
+		*m.adddaily_limit_usd += f
+	} else {
+		m.adddaily_limit_usd = &f
+	}
+}
+
+// AddedDailyLimitUsd returns the value that was added to the "daily_limit_usd" field in this mutation.
+func (m *APIKeyMutation) AddedDailyLimitUsd() (r float64, exists bool) {
+	v := m.adddaily_limit_usd
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ClearDailyLimitUsd clears the value of the "daily_limit_usd" field.
+func (m *APIKeyMutation) ClearDailyLimitUsd() {
+	m.daily_limit_usd = nil
+	m.adddaily_limit_usd = nil
+	m.clearedFields[apikey.FieldDailyLimitUsd] = struct{}{}
+}
+
+// DailyLimitUsdCleared returns if the "daily_limit_usd" field was cleared in this mutation.
+func (m *APIKeyMutation) DailyLimitUsdCleared() bool {
+	_, ok := m.clearedFields[apikey.FieldDailyLimitUsd]
+	return ok
+}
+
+// ResetDailyLimitUsd resets all changes to the "daily_limit_usd" field.
+func (m *APIKeyMutation) ResetDailyLimitUsd() {
+	m.daily_limit_usd = nil
+	m.adddaily_limit_usd = nil
+	delete(m.clearedFields, apikey.FieldDailyLimitUsd)
+}
+
+// SetMonthlyLimitUsd sets the "monthly_limit_usd" field.
+func (m *APIKeyMutation) SetMonthlyLimitUsd(f float64) {
+	m.monthly_limit_usd = &f
+	m.addmonthly_limit_usd = nil
+}
+
+// MonthlyLimitUsd returns the value of the "monthly_limit_usd" field in the mutation.
+func (m *APIKeyMutation) MonthlyLimitUsd() (r float64, exists bool) {
+	v := m.monthly_limit_usd
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldMonthlyLimitUsd returns the old "monthly_limit_usd" field's value of the APIKey entity.
+// If the APIKey object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *APIKeyMutation) OldMonthlyLimitUsd(ctx context.Context) (v *float64, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldMonthlyLimitUsd is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldMonthlyLimitUsd requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldMonthlyLimitUsd: %w", err)
+	}
+	return oldValue.MonthlyLimitUsd, nil
+}
+
+// AddMonthlyLimitUsd adds f to the "monthly_limit_usd" field.
+func (m *APIKeyMutation) AddMonthlyLimitUsd(f float64) {
+	if m.addmonthly_limit_usd != nil {
+		*m.addmonthly_limit_usd += f
+	} else {
+		m.addmonthly_limit_usd = &f
+	}
+}
+
+// AddedMonthlyLimitUsd returns the value that was added to the "monthly_limit_usd" field in this mutation.
+func (m *APIKeyMutation) AddedMonthlyLimitUsd() (r float64, exists bool) {
+	v := m.addmonthly_limit_usd
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ClearMonthlyLimitUsd clears the value of the "monthly_limit_usd" field.
+func (m *APIKeyMutation) ClearMonthlyLimitUsd() {
+	m.monthly_limit_usd = nil
+	m.addmonthly_limit_usd = nil
+	m.clearedFields[apikey.FieldMonthlyLimitUsd] = struct{}{}
+}
+
+// MonthlyLimitUsdCleared returns if the "monthly_limit_usd" field was cleared in this mutation.
+func (m *APIKeyMutation) MonthlyLimitUsdCleared() bool {
+	_, ok := m.clearedFields[apikey.FieldMonthlyLimitUsd]
+	return ok
+}
+
+// ResetMonthlyLimitUsd resets all changes to the "monthly_limit_usd" field.
+func (m *APIKeyMutation) ResetMonthlyLimitUsd() {
+	m.monthly_limit_usd = nil
+	m.addmonthly_limit_usd = nil
+	delete(m.clearedFields, apikey.FieldMonthlyLimitUsd)
+}
+
+// SetDailyRequestLimit sets the "daily_request_limit" field.
+func (m *APIKeyMutation) SetDailyRequestLimit(i int64) {
+	m.daily_request_limit = &i
+	m.adddaily_request_limit = nil
+}
+
+// DailyRequestLimit returns the value of the "daily_request_limit" field in the mutation.
+func (m *APIKeyMutation) DailyRequestLimit() (r int64, exists bool) {
+	v := m.daily_request_limit
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldDailyRequestLimit returns the old "daily_request_limit" field's value of the APIKey entity.
+// If the APIKey object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *APIKeyMutation) OldDailyRequestLimit(ctx context.Context) (v *int64, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldDailyRequestLimit is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldDailyRequestLimit requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldDailyRequestLimit: %w", err)
+	}
+	return oldValue.DailyRequestLimit, nil
+}
+
+// AddDailyRequestLimit adds i to the "daily_request_limit" field.
+func (m *APIKeyMutation) AddDailyRequestLimit(i int64) {
+	if m.adddaily_request_limit != nil {
+		*m.adddaily_request_limit += i
+	} else {
+		m.adddaily_request_limit = &i
+	}
+}
+
+// AddedDailyRequestLimit returns the value that was added to the "daily_request_limit" field in this mutation.
+func (m *APIKeyMutation) AddedDailyRequestLimit() (r int64, exists bool) {
+	v := m.adddaily_request_limit
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ClearDailyRequestLimit clears the value of the "daily_request_limit" field.
+func (m *APIKeyMutation) ClearDailyRequestLimit() {
+	m.daily_request_limit = nil
+	m.adddaily_request_limit = nil
+	m.clearedFields[apikey.FieldDailyRequestLimit] = struct{}{}
+}
+
+// DailyRequestLimitCleared returns if the "daily_request_limit" field was cleared in this mutation.
+func (m *APIKeyMutation) DailyRequestLimitCleared() bool {
+	_, ok := m.clearedFields[apikey.FieldDailyRequestLimit]
+	return ok
+}
+
+// ResetDailyRequestLimit resets all changes to the "daily_request_limit" field.
+func (m *APIKeyMutation) ResetDailyRequestLimit() {
+	m.daily_request_limit = nil
+	m.adddaily_request_limit = nil
+	delete(m.clearedFields, apikey.FieldDailyRequestLimit)
+}
+
+// SetMonthlyRequestLimit sets the "monthly_request_limit" field.
+func (m *APIKeyMutation) SetMonthlyRequestLimit(i int64) {
+	m.monthly_request_limit = &i
+	m.addmonthly_request_limit = nil
+}
+
+// MonthlyRequestLimit returns the value of the "monthly_request_limit" field in the mutation.
+func (m *APIKeyMutation) MonthlyRequestLimit() (r int64, exists bool) {
+	v := m.monthly_request_limit
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldMonthlyRequestLimit returns the old "monthly_request_limit" field's value of the APIKey entity.
+// If the APIKey object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *APIKeyMutation) OldMonthlyRequestLimit(ctx context.Context) (v *int64, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldMonthlyRequestLimit is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldMonthlyRequestLimit requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldMonthlyRequestLimit: %w", err)
+	}
+	return oldValue.MonthlyRequestLimit, nil
+}
+
+// AddMonthlyRequestLimit adds i to the "monthly_request_limit" field.
+func (m *APIKeyMutation) AddMonthlyRequestLimit(i int64) {
+	if m.addmonthly_request_limit != nil {
+		*m.addmonthly_request_limit += i
+	} else {
+		m.addmonthly_request_limit = &i
+	}
+}
+
+// AddedMonthlyRequestLimit returns the value that was added to the "monthly_request_limit" field in this mutation.
+func (m *APIKeyMutation) AddedMonthlyRequestLimit() (r int64, exists bool) {
+	v := m.addmonthly_request_limit
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ClearMonthlyRequestLimit clears the value of the "monthly_request_limit" field.
+func (m *APIKeyMutation) ClearMonthlyRequestLimit() {
+	m.monthly_request_limit = nil
+	m.addmonthly_request_limit = nil
+	m.clearedFields[apikey.FieldMonthlyRequestLimit] = struct{}{}
+}
+
+// MonthlyRequestLimitCleared returns if the "monthly_request_limit" field was cleared in this mutation.
+func (m *APIKeyMutation) MonthlyRequestLimitCleared() bool {
+	_, ok := m.clearedFields[apikey.FieldMonthlyRequestLimit]
+	return ok
+}
+
+// ResetMonthlyRequestLimit resets all changes to the "monthly_request_limit" field.
+func (m *APIKeyMutation) ResetMonthlyRequestLimit() {
+	m.monthly_request_limit = nil
+	m.addmonthly_request_limit = nil
+	delete(m.clearedFields, apikey.FieldMonthlyRequestLimit)
+}
+
+// SetAllowedModels sets the "allowed_models" field.
+func (m *APIKeyMutation) SetAllowedModels(s []string) {
+	m.allowed_models = &s
+	m.appendallowed_models = nil
+}
+
+// AllowedModels returns the value of the "allowed_models" field in the mutation.
+func (m *APIKeyMutation) AllowedModels() (r []string, exists bool) {
+	v := m.allowed_models
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldAllowedModels returns the old "allowed_models" field's value of the APIKey entity.
+// If the APIKey object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *APIKeyMutation) OldAllowedModels(ctx context.Context) (v []string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldAllowedModels is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldAllowedModels requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldAllowedModels: %w", err)
+	}
+	return oldValue.AllowedModels, nil
+}
+
+// AppendAllowedModels adds s to the "allowed_models" field.
+func (m *APIKeyMutation) AppendAllowedModels(s []string) {
+	m.appendallowed_models = append(m.appendallowed_models, s...)
+}
+
+// AppendedAllowedModels returns the list of values that were appended to the "allowed_models" field in this mutation.
+func (m *APIKeyMutation) AppendedAllowedModels() ([]string, bool) {
+	if len(m.appendallowed_models) == 0 {
+		return nil, false
+	}
+	return m.appendallowed_models, true
+}
+
+// ClearAllowedModels clears the value of the "allowed_models" field.
+func (m *APIKeyMutation) ClearAllowedModels() {
+	m.allowed_models = nil
+	m.appendallowed_models = nil
+	m.clearedFields[apikey.FieldAllowedModels] = struct{}{}
+}
+
+// AllowedModelsCleared returns if the "allowed_models" field was cleared in this mutation.
+func (m *APIKeyMutation) AllowedModelsCleared() bool {
+	_, ok := m.clearedFields[apikey.FieldAllowedModels]
+	return ok
+}
+
+// ResetAllowedModels resets all changes to the "allowed_models" field.
+func (m *APIKeyMutation) ResetAllowedModels() {
+	m.allowed_models = nil
+	m.appendallowed_models = nil
+	delete(m.clearedFields, apikey.FieldAllowedModels)
+}
+
+// SetAllowedPlatforms sets the "allowed_platforms" field.
+func (m *APIKeyMutation) SetAllowedPlatforms(s []string) {
+	m.allowed_platforms = &s
+	m.appendallowed_platforms = nil
+}
+
+// AllowedPlatforms returns the value of the "allowed_platforms" field in the mutation.
+func (m *APIKeyMutation) AllowedPlatforms() (r []string, exists bool) {
+	v := m.allowed_platforms
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldAllowedPlatforms returns the old "allowed_platforms" field's value of the APIKey entity.
+// If the APIKey object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *APIKeyMutation) OldAllowedPlatforms(ctx context.Context) (v []string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldAllowedPlatforms is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldAllowedPlatforms requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldAllowedPlatforms: %w", err)
+	}
+	return oldValue.AllowedPlatforms, nil
+}
+
+// AppendAllowedPlatforms adds s to the "allowed_platforms" field.
+func (m *APIKeyMutation) AppendAllowedPlatforms(s []string) {
+	m.appendallowed_platforms = append(m.appendallowed_platforms, s...)
+}
+
+// AppendedAllowedPlatforms returns the list of values that were appended to the "allowed_platforms" field in this mutation.
+func (m *APIKeyMutation) AppendedAllowedPlatforms() ([]string, bool) {
+	if len(m.appendallowed_platforms) == 0 {
+		return nil, false
+	}
+	return m.appendallowed_platforms, true
 }
 
-// ClearIPBlacklist clears the value of the "ip_blacklist" field.
-func (m *APIKeyMutation) ClearIPBlacklist() {
-	m.ip_blacklist = nil
-	m.appendip_blacklist = nil
-	m.clearedFields[apikey.FieldIPBlacklist] = struct{}{}
+// ClearAllowedPlatforms clears the value of the "allowed_platforms" field.
+func (m *APIKeyMutation) ClearAllowedPlatforms() {
+	m.allowed_platforms = nil
+	m.appendallowed_platforms = nil
+	m.clearedFields[apikey.FieldAllowedPlatforms] = struct{}{}
 }
 
-// IPBlacklistCleared returns if the "ip_blacklist" field was cleared in this mutation.
-func (m *APIKeyMutation) IPBlacklistCleared() bool {
-	_, ok := m.clearedFields[apikey.FieldIPBlacklist]
+// AllowedPlatformsCleared returns if the "allowed_platforms" field was cleared in this mutation.
+func (m *APIKeyMutation) AllowedPlatformsCleared() bool {
+	_, ok := m.clearedFields[apikey.FieldAllowedPlatforms]
 	return ok
 }
 
-// ResetIPBlacklist resets all changes to the "ip_blacklist" field.
-func (m *APIKeyMutation) ResetIPBlacklist() {
-	m.ip_blacklist = nil
-	m.appendip_blacklist = nil
-	delete(m.clearedFields, apikey.FieldIPBlacklist)
+// ResetAllowedPlatforms resets all changes to the "allowed_platforms" field.
+func (m *APIKeyMutation) ResetAllowedPlatforms() {
+	m.allowed_platforms = nil
+	m.appendallowed_platforms = nil
+	delete(m.clearedFields, apikey.FieldAllowedPlatforms)
 }
 
 // ClearUser clears the "user" edge to the User entity.
@@ -771,7 +1316,7 @@ func (m *APIKeyMutation) Type() string {
 // order to get all numeric fields that were incremented/decremented, call
 // AddedFields().
 func (m *APIKeyMutation) Fields() []string {
-	fields := make([]string, 0, 10)
+	fields := make([]string, 0, 18)
 	if m.created_at != nil {
 		fields = append(fields, apikey.FieldCreatedAt)
 	}
@@ -802,6 +1347,30 @@ func (m *APIKeyMutation) Fields() []string {
 	if m.ip_blacklist != nil {
 		fields = append(fields, apikey.FieldIPBlacklist)
 	}
+	if m.expires_at != nil {
+		fields = append(fields, apikey.FieldExpiresAt)
+	}
+	if m.total_limit_usd != nil {
+		fields = append(fields, apikey.FieldTotalLimitUsd)
+	}
+	if m.daily_limit_usd != nil {
+		fields = append(fields, apikey.FieldDailyLimitUsd)
+	}
+	if m.monthly_limit_usd != nil {
+		fields = append(fields, apikey.FieldMonthlyLimitUsd)
+	}
+	if m.daily_request_limit != nil {
+		fields = append(fields, apikey.FieldDailyRequestLimit)
+	}
+	if m.monthly_request_limit != nil {
+		fields = append(fields, apikey.FieldMonthlyRequestLimit)
+	}
+	if m.allowed_models != nil {
+		fields = append(fields, apikey.FieldAllowedModels)
+	}
+	if m.allowed_platforms != nil {
+		fields = append(fields, apikey.FieldAllowedPlatforms)
+	}
 	return fields
 }
 
@@ -830,6 +1399,22 @@ func (m *APIKeyMutation) Field(name string) (ent.Value, bool) {
 		return m.IPWhitelist()
 	case apikey.FieldIPBlacklist:
 		return m.IPBlacklist()
+	case apikey.FieldExpiresAt:
+		return m.ExpiresAt()
+	case apikey.FieldTotalLimitUsd:
+		return m.TotalLimitUsd()
+	case apikey.FieldDailyLimitUsd:
+		return m.DailyLimitUsd()
+	case apikey.FieldMonthlyLimitUsd:
+		return m.MonthlyLimitUsd()
+	case apikey.FieldDailyRequestLimit:
+		return m.DailyRequestLimit()
+	case apikey.FieldMonthlyRequestLimit:
+		return m.MonthlyRequestLimit()
+	case apikey.FieldAllowedModels:
+		return m.AllowedModels()
+	case apikey.FieldAllowedPlatforms:
+		return m.AllowedPlatforms()
 	}
 	return nil, false
 }
@@ -859,6 +1444,22 @@ func (m *APIKeyMutation) OldField(ctx context.Context, name string) (ent.Value,
 		return m.OldIPWhitelist(ctx)
 	case apikey.FieldIPBlacklist:
 		return m.OldIPBlacklist(ctx)
+	case apikey.FieldExpiresAt:
+		return m.OldExpiresAt(ctx)
+	case apikey.FieldTotalLimitUsd:
+		return m.OldTotalLimitUsd(ctx)
+	case apikey.FieldDailyLimitUsd:
+		return m.OldDailyLimitUsd(ctx)
+	case apikey.FieldMonthlyLimitUsd:
+		return m.OldMonthlyLimitUsd(ctx)
+	case apikey.FieldDailyRequestLimit:
+		return m.OldDailyRequestLimit(ctx)
+	case apikey.FieldMonthlyRequestLimit:
+		return m.OldMonthlyRequestLimit(ctx)
+	case apikey.FieldAllowedModels:
+		return m.OldAllowedModels(ctx)
+	case apikey.FieldAllowedPlatforms:
+		return m.OldAllowedPlatforms(ctx)
 	}
 	return nil, fmt.Errorf("unknown APIKey field %s", name)
 }
@@ -938,6 +1539,62 @@ func (m *APIKeyMutation) SetField(name string, value ent.Value) error {
 		}
 		m.SetIPBlacklist(v)
 		return nil
+	case apikey.FieldExpiresAt:
+		v, ok := value.(time.Time)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetExpiresAt(v)
+		return nil
+	case apikey.FieldTotalLimitUsd:
+		v, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetTotalLimitUsd(v)
+		return nil
+	case apikey.FieldDailyLimitUsd:
+		v, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetDailyLimitUsd(v)
+		return nil
+	case apikey.FieldMonthlyLimitUsd:
+		v, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetMonthlyLimitUsd(v)
+		return nil
+	case apikey.FieldDailyRequestLimit:
+		v, ok := value.(int64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetDailyRequestLimit(v)
+		return nil
+	case apikey.FieldMonthlyRequestLimit:
+		v, ok := value.(int64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetMonthlyRequestLimit(v)
+		return nil
+	case apikey.FieldAllowedModels:
+		v, ok := value.([]string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetAllowedModels(v)
+		return nil
+	case apikey.FieldAllowedPlatforms:
+		v, ok := value.([]string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetAllowedPlatforms(v)
+		return nil
 	}
 	return fmt.Errorf("unknown APIKey field %s", name)
 }
@@ -946,6 +1603,21 @@ func (m *APIKeyMutation) SetField(name string, value ent.Value) error {
 // this mutation.
 func (m *APIKeyMutation) AddedFields() []string {
 	var fields []string
+	if m.addtotal_limit_usd != nil {
+		fields = append(fields, apikey.FieldTotalLimitUsd)
+	}
+	if m.adddaily_limit_usd != nil {
+		fields = append(fields, apikey.FieldDailyLimitUsd)
+	}
+	if m.addmonthly_limit_usd != nil {
+		fields = append(fields, apikey.FieldMonthlyLimitUsd)
+	}
+	if m.adddaily_request_limit != nil {
+		fields = append(fields, apikey.FieldDailyRequestLimit)
+	}
+	if m.addmonthly_request_limit != nil {
+		fields = append(fields, apikey.FieldMonthlyRequestLimit)
+	}
 	return fields
 }
 
@@ -954,6 +1626,16 @@ func (m *APIKeyMutation) AddedFields() []string {
 // was not set, or was not defined in the schema.
 func (m *APIKeyMutation) AddedField(name string) (ent.Value, bool) {
 	switch name {
+	case apikey.FieldTotalLimitUsd:
+		return m.AddedTotalLimitUsd()
+	case apikey.FieldDailyLimitUsd:
+		return m.AddedDailyLimitUsd()
+	case apikey.FieldMonthlyLimitUsd:
+		return m.AddedMonthlyLimitUsd()
+	case apikey.FieldDailyRequestLimit:
+		return m.AddedDailyRequestLimit()
+	case apikey.FieldMonthlyRequestLimit:
+		return m.AddedMonthlyRequestLimit()
 	}
 	return nil, false
 }
@@ -963,6 +1645,41 @@ func (m *APIKeyMutation) AddedField(name string) (ent.Value, bool) {
 // type.
 func (m *APIKeyMutation) AddField(name string, value ent.Value) error {
 	switch name {
+	case apikey.FieldTotalLimitUsd:
+		v, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddTotalLimitUsd(v)
+		return nil
+	case apikey.FieldDailyLimitUsd:
+		v, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddDailyLimitUsd(v)
+		return nil
+	case apikey.FieldMonthlyLimitUsd:
+		v, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddMonthlyLimitUsd(v)
+		return nil
+	case apikey.FieldDailyRequestLimit:
+		v, ok := value.(int64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddDailyRequestLimit(v)
+		return nil
+	case apikey.FieldMonthlyRequestLimit:
+		v, ok := value.(int64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddMonthlyRequestLimit(v)
+		return nil
 	}
 	return fmt.Errorf("unknown APIKey numeric field %s", name)
 }
@@ -983,6 +1700,30 @@ func (m *APIKeyMutation) ClearedFields() []string {
 	if m.FieldCleared(apikey.FieldIPBlacklist) {
 		fields = append(fields, apikey.FieldIPBlacklist)
 	}
+	if m.FieldCleared(apikey.FieldExpiresAt) {
+		fields = append(fields, apikey.FieldExpiresAt)
+	}
+	if m.FieldCleared(apikey.FieldTotalLimitUsd) {
+		fields = append(fields, apikey.FieldTotalLimitUsd)
+	}
+	if m.FieldCleared(apikey.FieldDailyLimitUsd) {
+		fields = append(fields, apikey.FieldDailyLimitUsd)
+	}
+	if m.FieldCleared(apikey.FieldMonthlyLimitUsd) {
+		fields = append(fields, apikey.FieldMonthlyLimitUsd)
+	}
+	if m.FieldCleared(apikey.FieldDailyRequestLimit) {
+		fields = append(fields, apikey.FieldDailyRequestLimit)
+	}
+	if m.FieldCleared(apikey.FieldMonthlyRequestLimit) {
+		fields = append(fields, apikey.FieldMonthlyRequestLimit)
+	}
+	if m.FieldCleared(apikey.FieldAllowedModels) {
+		fields = append(fields, apikey.FieldAllowedModels)
+	}
+	if m.FieldCleared(apikey.FieldAllowedPlatforms) {
+		fields = append(fields, apikey.FieldAllowedPlatforms)
+	}
 	return fields
 }
 
@@ -1009,6 +1750,30 @@ func (m *APIKeyMutation) ClearField(name string) error {
 	case apikey.FieldIPBlacklist:
 		m.ClearIPBlacklist()
 		return nil
+	case apikey.FieldExpiresAt:
+		m.ClearExpiresAt()
+		return nil
+	case apikey.FieldTotalLimitUsd:
+		m.ClearTotalLimitUsd()
+		return nil
+	case apikey.FieldDailyLimitUsd:
+		m.ClearDailyLimitUsd()
+		return nil
+	case apikey.FieldMonthlyLimitUsd:
+		m.ClearMonthlyLimitUsd()
+		return nil
+	case apikey.FieldDailyRequestLimit:
+		m.ClearDailyRequestLimit()
+		return nil
+	case apikey.FieldMonthlyRequestLimit:
+		m.ClearMonthlyRequestLimit()
+		return nil
+	case apikey.FieldAllowedModels:
+		m.ClearAllowedModels()
+		return nil
+	case apikey.FieldAllowedPlatforms:
+		m.ClearAllowedPlatforms()
+		return nil
 	}
 	return fmt.Errorf("unknown APIKey nullable field %s", name)
 }
@@ -1047,6 +1812,30 @@ func (m *APIKeyMutation) ResetField(name string) error {
 	case apikey.FieldIPBlacklist:
 		m.ResetIPBlacklist()
 		return nil
+	case apikey.FieldExpiresAt:
+		m.ResetExpiresAt()
+		return nil
+	case apikey.FieldTotalLimitUsd:
+		m.ResetTotalLimitUsd()
+		return nil
+	case apikey.FieldDailyLimitUsd:
+		m.ResetDailyLimitUsd()
+		return nil
+	case apikey.FieldMonthlyLimitUsd:
+		m.ResetMonthlyLimitUsd()
+		return nil
+	case apikey.FieldDailyRequestLimit:
+		m.ResetDailyRequestLimit()
+		return nil
+	case apikey.FieldMonthlyRequestLimit:
+		m.ResetMonthlyRequestLimit()
+		return nil
+	case apikey.FieldAllowedModels:
+		m.ResetAllowedModels()
+		return nil
+	case apikey.FieldAllowedPlatforms:
+		m.ResetAllowedPlatforms()
+		return nil
 	}
 	return fmt.Errorf("unknown APIKey field %s", name)
 }
@@ -1174,48 +1963,52 @@ func (m *APIKeyMutation) ResetEdge(name string) error {
 // AccountMutation represents an operation that mutates the Account nodes in the graph.
 type AccountMutation struct {
 	config
-	op                    Op
-	typ                   string
-	id                    *int64
-	created_at            *time.Time
-	updated_at            *time.Time
-	deleted_at            *time.Time
-	name                  *string
-	notes                 *string
-	platform              *string
-	_type                 *string
-	credentials           *map[string]interface{}
-	extra                 *map[string]interface{}
-	concurrency           *int
-	addconcurrency        *int
-	priority              *int
-	addpriority           *int
-	rate_multiplier       *float64
-	addrate_multiplier    *float64
-	status                *string
-	error_message         *string
-	last_used_at          *time.Time
-	expires_at            *time.Time
-	auto_pause_on_expired *bool
-	schedulable           *bool
-	rate_limited_at       *time.Time
-	rate_limit_reset_at   *time.Time
-	overload_until        *time.Time
-	session_window_start  *time.Time
-	session_window_end    *time.Time
-	session_window_status *string
-	clearedFields         map[string]struct{}
-	groups                map[int64]struct{}
-	removedgroups         map[int64]struct{}
-	clearedgroups         bool
-	proxy                 *int64
-	clearedproxy          bool
-	usage_logs            map[int64]struct{}
-	removedusage_logs     map[int64]struct{}
-	clearedusage_logs     bool
-	done                  bool
-	oldValue              func(context.Context) (*Account, error)
-	predicates            []predicate.Account
+	op                              Op
+	typ                             string
+	id                              *int64
+	created_at                      *time.Time
+	updated_at                      *time.Time
+	deleted_at                      *time.Time
+	name                            *string
+	notes                           *string
+	platform                        *string
+	_type                           *string
+	credentials                     *map[string]interface{}
+	extra                           *map[string]interface{}
+	concurrency                     *int
+	addconcurrency                  *int
+	priority                        *int
+	addpriority                     *int
+	rate_multiplier                 *float64
+	addrate_multiplier              *float64
+	status                          *string
+	error_message                   *string
+	last_used_at                    *time.Time
+	expires_at                      *time.Time
+	auto_pause_on_expired           *bool
+	schedulable                     *bool
+	rate_limited_at                 *time.Time
+	rate_limit_reset_at             *time.Time
+	overload_until                  *time.Time
+	session_window_start            *time.Time
+	session_window_end              *time.Time
+	session_window_status           *string
+	tags                            *pq.StringArray
+	availability_schedule           *json.RawMessage
+	appendavailability_schedule     json.RawMessage
+	availability_next_transition_at *time.Time
+	clearedFields                   map[string]struct{}
+	groups                          map[int64]struct{}
+	removedgroups                   map[int64]struct{}
+	clearedgroups                   bool
+	proxy                           *int64
+	clearedproxy                    bool
+	usage_logs                      map[int64]struct{}
+	removedusage_logs               map[int64]struct{}
+	clearedusage_logs               bool
+	done                            bool
+	oldValue                        func(context.Context) (*Account, error)
+	predicates                      []predicate.Account
 }
 
 var _ ent.Mutation = (*AccountMutation)(nil)
@@ -2339,97 +3132,247 @@ func (m *AccountMutation) SetSessionWindowEnd(t time.Time) {
 	m.session_window_end = &t
 }
 
-// SessionWindowEnd returns the value of the "session_window_end" field in the mutation.
-func (m *AccountMutation) SessionWindowEnd() (r time.Time, exists bool) {
-	v := m.session_window_end
+// SessionWindowEnd returns the value of the "session_window_end" field in the mutation.
+func (m *AccountMutation) SessionWindowEnd() (r time.Time, exists bool) {
+	v := m.session_window_end
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldSessionWindowEnd returns the old "session_window_end" field's value of the Account entity.
+// If the Account object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *AccountMutation) OldSessionWindowEnd(ctx context.Context) (v *time.Time, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldSessionWindowEnd is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldSessionWindowEnd requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldSessionWindowEnd: %w", err)
+	}
+	return oldValue.SessionWindowEnd, nil
+}
+
+// ClearSessionWindowEnd clears the value of the "session_window_end" field.
+func (m *AccountMutation) ClearSessionWindowEnd() {
+	m.session_window_end = nil
+	m.clearedFields[account.FieldSessionWindowEnd] = struct{}{}
+}
+
+// SessionWindowEndCleared returns if the "session_window_end" field was cleared in this mutation.
+func (m *AccountMutation) SessionWindowEndCleared() bool {
+	_, ok := m.clearedFields[account.FieldSessionWindowEnd]
+	return ok
+}
+
+// ResetSessionWindowEnd resets all changes to the "session_window_end" field.
+func (m *AccountMutation) ResetSessionWindowEnd() {
+	m.session_window_end = nil
+	delete(m.clearedFields, account.FieldSessionWindowEnd)
+}
+
+// SetSessionWindowStatus sets the "session_window_status" field.
+func (m *AccountMutation) SetSessionWindowStatus(s string) {
+	m.session_window_status = &s
+}
+
+// SessionWindowStatus returns the value of the "session_window_status" field in the mutation.
+func (m *AccountMutation) SessionWindowStatus() (r string, exists bool) {
+	v := m.session_window_status
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldSessionWindowStatus returns the old "session_window_status" field's value of the Account entity.
+// If the Account object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *AccountMutation) OldSessionWindowStatus(ctx context.Context) (v *string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldSessionWindowStatus is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldSessionWindowStatus requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldSessionWindowStatus: %w", err)
+	}
+	return oldValue.SessionWindowStatus, nil
+}
+
+// ClearSessionWindowStatus clears the value of the "session_window_status" field.
+func (m *AccountMutation) ClearSessionWindowStatus() {
+	m.session_window_status = nil
+	m.clearedFields[account.FieldSessionWindowStatus] = struct{}{}
+}
+
+// SessionWindowStatusCleared returns if the "session_window_status" field was cleared in this mutation.
+func (m *AccountMutation) SessionWindowStatusCleared() bool {
+	_, ok := m.clearedFields[account.FieldSessionWindowStatus]
+	return ok
+}
+
+// ResetSessionWindowStatus resets all changes to the "session_window_status" field.
+func (m *AccountMutation) ResetSessionWindowStatus() {
+	m.session_window_status = nil
+	delete(m.clearedFields, account.FieldSessionWindowStatus)
+}
+
+// SetTags sets the "tags" field.
+func (m *AccountMutation) SetTags(pa pq.StringArray) {
+	m.tags = &pa
+}
+
+// Tags returns the value of the "tags" field in the mutation.
+func (m *AccountMutation) Tags() (r pq.StringArray, exists bool) {
+	v := m.tags
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldTags returns the old "tags" field's value of the Account entity.
+// If the Account object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *AccountMutation) OldTags(ctx context.Context) (v pq.StringArray, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldTags is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldTags requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldTags: %w", err)
+	}
+	return oldValue.Tags, nil
+}
+
+// ResetTags resets all changes to the "tags" field.
+func (m *AccountMutation) ResetTags() {
+	m.tags = nil
+}
+
+// SetAvailabilitySchedule sets the "availability_schedule" field.
+func (m *AccountMutation) SetAvailabilitySchedule(jm json.RawMessage) {
+	m.availability_schedule = &jm
+	m.appendavailability_schedule = nil
+}
+
+// AvailabilitySchedule returns the value of the "availability_schedule" field in the mutation.
+func (m *AccountMutation) AvailabilitySchedule() (r json.RawMessage, exists bool) {
+	v := m.availability_schedule
 	if v == nil {
 		return
 	}
 	return *v, true
 }
 
-// OldSessionWindowEnd returns the old "session_window_end" field's value of the Account entity.
+// OldAvailabilitySchedule returns the old "availability_schedule" field's value of the Account entity.
 // If the Account object wasn't provided to the builder, the object is fetched from the database.
 // An error is returned if the mutation operation is not UpdateOne, or the database query fails.
-func (m *AccountMutation) OldSessionWindowEnd(ctx context.Context) (v *time.Time, err error) {
+func (m *AccountMutation) OldAvailabilitySchedule(ctx context.Context) (v json.RawMessage, err error) {
 	if !m.op.Is(OpUpdateOne) {
-		return v, errors.New("OldSessionWindowEnd is only allowed on UpdateOne operations")
+		return v, errors.New("OldAvailabilitySchedule is only allowed on UpdateOne operations")
 	}
 	if m.id == nil || m.oldValue == nil {
-		return v, errors.New("OldSessionWindowEnd requires an ID field in the mutation")
+		return v, errors.New("OldAvailabilitySchedule requires an ID field in the mutation")
 	}
 	oldValue, err := m.oldValue(ctx)
 	if err != nil {
-		return v, fmt.Errorf("querying old value for OldSessionWindowEnd: %w", err)
+		return v, fmt.Errorf("querying old value for OldAvailabilitySchedule: %w", err)
 	}
-	return oldValue.SessionWindowEnd, nil
+	return oldValue.AvailabilitySchedule, nil
 }
 
-// ClearSessionWindowEnd clears the value of the "session_window_end" field.
-func (m *AccountMutation) ClearSessionWindowEnd() {
-	m.session_window_end = nil
-	m.clearedFields[account.FieldSessionWindowEnd] = struct{}{}
+// AppendAvailabilitySchedule adds jm to the "availability_schedule" field.
+func (m *AccountMutation) AppendAvailabilitySchedule(jm json.RawMessage) {
+	m.appendavailability_schedule = append(m.appendavailability_schedule, jm...)
 }
 
-// SessionWindowEndCleared returns if the "session_window_end" field was cleared in this mutation.
-func (m *AccountMutation) SessionWindowEndCleared() bool {
-	_, ok := m.clearedFields[account.FieldSessionWindowEnd]
+// AppendedAvailabilitySchedule returns the list of values that were appended to the "availability_schedule" field in this mutation.
+func (m *AccountMutation) AppendedAvailabilitySchedule() (json.RawMessage, bool) {
+	if len(m.appendavailability_schedule) == 0 {
+		return nil, false
+	}
+	return m.appendavailability_schedule, true
+}
+
+// ClearAvailabilitySchedule clears the value of the "availability_schedule" field.
+func (m *AccountMutation) ClearAvailabilitySchedule() {
+	m.availability_schedule = nil
+	m.appendavailability_schedule = nil
+	m.clearedFields[account.FieldAvailabilitySchedule] = struct{}{}
+}
+
+// AvailabilityScheduleCleared returns if the "availability_schedule" field was cleared in this mutation.
+func (m *AccountMutation) AvailabilityScheduleCleared() bool {
+	_, ok := m.clearedFields[account.FieldAvailabilitySchedule]
 	return ok
 }
 
-// ResetSessionWindowEnd resets all changes to the "session_window_end" field.
-func (m *AccountMutation) ResetSessionWindowEnd() {
-	m.session_window_end = nil
-	delete(m.clearedFields, account.FieldSessionWindowEnd)
+// ResetAvailabilitySchedule resets all changes to the "availability_schedule" field.
+func (m *AccountMutation) ResetAvailabilitySchedule() {
+	m.availability_schedule = nil
+	m.appendavailability_schedule = nil
+	delete(m.clearedFields, account.FieldAvailabilitySchedule)
 }
 
-// SetSessionWindowStatus sets the "session_window_status" field.
-func (m *AccountMutation) SetSessionWindowStatus(s string) {
-	m.session_window_status = &s
+// SetAvailabilityNextTransitionAt sets the "availability_next_transition_at" field.
+func (m *AccountMutation) SetAvailabilityNextTransitionAt(t time.Time) {
+	m.availability_next_transition_at = &t
 }
 
-// SessionWindowStatus returns the value of the "session_window_status" field in the mutation.
-func (m *AccountMutation) SessionWindowStatus() (r string, exists bool) {
-	v := m.session_window_status
+// AvailabilityNextTransitionAt returns the value of the "availability_next_transition_at" field in the mutation.
+func (m *AccountMutation) AvailabilityNextTransitionAt() (r time.Time, exists bool) {
+	v := m.availability_next_transition_at
 	if v == nil {
 		return
 	}
 	return *v, true
 }
 
-// OldSessionWindowStatus returns the old "session_window_status" field's value of the Account entity.
+// OldAvailabilityNextTransitionAt returns the old "availability_next_transition_at" field's value of the Account entity.
 // If the Account object wasn't provided to the builder, the object is fetched from the database.
 // An error is returned if the mutation operation is not UpdateOne, or the database query fails.
-func (m *AccountMutation) OldSessionWindowStatus(ctx context.Context) (v *string, err error) {
+func (m *AccountMutation) OldAvailabilityNextTransitionAt(ctx context.Context) (v *time.Time, err error) {
 	if !m.op.Is(OpUpdateOne) {
-		return v, errors.New("OldSessionWindowStatus is only allowed on UpdateOne operations")
+		return v, errors.New("OldAvailabilityNextTransitionAt is only allowed on UpdateOne operations")
 	}
 	if m.id == nil || m.oldValue == nil {
-		return v, errors.New("OldSessionWindowStatus requires an ID field in the mutation")
+		return v, errors.New("OldAvailabilityNextTransitionAt requires an ID field in the mutation")
 	}
 	oldValue, err := m.oldValue(ctx)
 	if err != nil {
-		return v, fmt.Errorf("querying old value for OldSessionWindowStatus: %w", err)
+		return v, fmt.Errorf("querying old value for OldAvailabilityNextTransitionAt: %w", err)
 	}
-	return oldValue.SessionWindowStatus, nil
+	return oldValue.AvailabilityNextTransitionAt, nil
 }
 
-// ClearSessionWindowStatus clears the value of the "session_window_status" field.
-func (m *AccountMutation) ClearSessionWindowStatus() {
-	m.session_window_status = nil
-	m.clearedFields[account.FieldSessionWindowStatus] = struct{}{}
+// ClearAvailabilityNextTransitionAt clears the value of the "availability_next_transition_at" field.
+func (m *AccountMutation) ClearAvailabilityNextTransitionAt() {
+	m.availability_next_transition_at = nil
+	m.clearedFields[account.FieldAvailabilityNextTransitionAt] = struct{}{}
 }
 
-// SessionWindowStatusCleared returns if the "session_window_status" field was cleared in this mutation.
-func (m *AccountMutation) SessionWindowStatusCleared() bool {
-	_, ok := m.clearedFields[account.FieldSessionWindowStatus]
+// AvailabilityNextTransitionAtCleared returns if the "availability_next_transition_at" field was cleared in this mutation.
+func (m *AccountMutation) AvailabilityNextTransitionAtCleared() bool {
+	_, ok := m.clearedFields[account.FieldAvailabilityNextTransitionAt]
 	return ok
 }
 
-// ResetSessionWindowStatus resets all changes to the "session_window_status" field.
-func (m *AccountMutation) ResetSessionWindowStatus() {
-	m.session_window_status = nil
-	delete(m.clearedFields, account.FieldSessionWindowStatus)
+// ResetAvailabilityNextTransitionAt resets all changes to the "availability_next_transition_at" field.
+func (m *AccountMutation) ResetAvailabilityNextTransitionAt() {
+	m.availability_next_transition_at = nil
+	delete(m.clearedFields, account.FieldAvailabilityNextTransitionAt)
 }
 
 // AddGroupIDs adds the "groups" edge to the Group entity by ids.
@@ -2601,7 +3544,7 @@ func (m *AccountMutation) Type() string {
 // order to get all numeric fields that were incremented/decremented, call
 // AddedFields().
 func (m *AccountMutation) Fields() []string {
-	fields := make([]string, 0, 25)
+	fields := make([]string, 0, 28)
 	if m.created_at != nil {
 		fields = append(fields, account.FieldCreatedAt)
 	}
@@ -2677,6 +3620,15 @@ func (m *AccountMutation) Fields() []string {
 	if m.session_window_status != nil {
 		fields = append(fields, account.FieldSessionWindowStatus)
 	}
+	if m.tags != nil {
+		fields = append(fields, account.FieldTags)
+	}
+	if m.availability_schedule != nil {
+		fields = append(fields, account.FieldAvailabilitySchedule)
+	}
+	if m.availability_next_transition_at != nil {
+		fields = append(fields, account.FieldAvailabilityNextTransitionAt)
+	}
 	return fields
 }
 
@@ -2735,6 +3687,12 @@ func (m *AccountMutation) Field(name string) (ent.Value, bool) {
 		return m.SessionWindowEnd()
 	case account.FieldSessionWindowStatus:
 		return m.SessionWindowStatus()
+	case account.FieldTags:
+		return m.Tags()
+	case account.FieldAvailabilitySchedule:
+		return m.AvailabilitySchedule()
+	case account.FieldAvailabilityNextTransitionAt:
+		return m.AvailabilityNextTransitionAt()
 	}
 	return nil, false
 }
@@ -2794,6 +3752,12 @@ func (m *AccountMutation) OldField(ctx context.Context, name string) (ent.Value,
 		return m.OldSessionWindowEnd(ctx)
 	case account.FieldSessionWindowStatus:
 		return m.OldSessionWindowStatus(ctx)
+	case account.FieldTags:
+		return m.OldTags(ctx)
+	case account.FieldAvailabilitySchedule:
+		return m.OldAvailabilitySchedule(ctx)
+	case account.FieldAvailabilityNextTransitionAt:
+		return m.OldAvailabilityNextTransitionAt(ctx)
 	}
 	return nil, fmt.Errorf("unknown Account field %s", name)
 }
@@ -2978,6 +3942,27 @@ func (m *AccountMutation) SetField(name string, value ent.Value) error {
 		}
 		m.SetSessionWindowStatus(v)
 		return nil
+	case account.FieldTags:
+		v, ok := value.(pq.StringArray)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetTags(v)
+		return nil
+	case account.FieldAvailabilitySchedule:
+		v, ok := value.(json.RawMessage)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetAvailabilitySchedule(v)
+		return nil
+	case account.FieldAvailabilityNextTransitionAt:
+		v, ok := value.(time.Time)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetAvailabilityNextTransitionAt(v)
+		return nil
 	}
 	return fmt.Errorf("unknown Account field %s", name)
 }
@@ -3083,6 +4068,12 @@ func (m *AccountMutation) ClearedFields() []string {
 	if m.FieldCleared(account.FieldSessionWindowStatus) {
 		fields = append(fields, account.FieldSessionWindowStatus)
 	}
+	if m.FieldCleared(account.FieldAvailabilitySchedule) {
+		fields = append(fields, account.FieldAvailabilitySchedule)
+	}
+	if m.FieldCleared(account.FieldAvailabilityNextTransitionAt) {
+		fields = append(fields, account.FieldAvailabilityNextTransitionAt)
+	}
 	return fields
 }
 
@@ -3133,6 +4124,12 @@ func (m *AccountMutation) ClearField(name string) error {
 	case account.FieldSessionWindowStatus:
 		m.ClearSessionWindowStatus()
 		return nil
+	case account.FieldAvailabilitySchedule:
+		m.ClearAvailabilitySchedule()
+		return nil
+	case account.FieldAvailabilityNextTransitionAt:
+		m.ClearAvailabilityNextTransitionAt()
+		return nil
 	}
 	return fmt.Errorf("unknown Account nullable field %s", name)
 }
@@ -3216,6 +4213,15 @@ func (m *AccountMutation) ResetField(name string) error {
 	case account.FieldSessionWindowStatus:
 		m.ResetSessionWindowStatus()
 		return nil
+	case account.FieldTags:
+		m.ResetTags()
+		return nil
+	case account.FieldAvailabilitySchedule:
+		m.ResetAvailabilitySchedule()
+		return nil
+	case account.FieldAvailabilityNextTransitionAt:
+		m.ResetAvailabilityNextTransitionAt()
+		return nil
 	}
 	return fmt.Errorf("unknown Account field %s", name)
 }
@@ -3869,6 +4875,13 @@ type GroupMutation struct {
 	addfallback_group_id     *int64
 	model_routing            *map[string][]int64
 	model_routing_enabled    *bool
+	model_mapping            *map[string]string
+	sticky_policy            *string
+	sticky_ttl_seconds       *int
+	addsticky_ttl_seconds    *int
+	account_tag_selector     *string
+	model_routing_tags       *map[string]string
+	cost_routing_policy      *string
 	clearedFields            map[string]struct{}
 	api_keys                 map[int64]struct{}
 	removedapi_keys          map[int64]struct{}
@@ -5064,6 +6077,242 @@ func (m *GroupMutation) ResetModelRoutingEnabled() {
 	m.model_routing_enabled = nil
 }
 
+// SetModelMapping sets the "model_mapping" field.
+func (m *GroupMutation) SetModelMapping(value map[string]string) {
+	m.model_mapping = &value
+}
+
+// ModelMapping returns the value of the "model_mapping" field in the mutation.
+func (m *GroupMutation) ModelMapping() (r map[string]string, exists bool) {
+	v := m.model_mapping
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldModelMapping returns the old "model_mapping" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldModelMapping(ctx context.Context) (v map[string]string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldModelMapping is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldModelMapping requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldModelMapping: %w", err)
+	}
+	return oldValue.ModelMapping, nil
+}
+
+// ResetModelMapping resets all changes to the "model_mapping" field.
+func (m *GroupMutation) ResetModelMapping() {
+	m.model_mapping = nil
+}
+
+// SetStickyPolicy sets the "sticky_policy" field.
+func (m *GroupMutation) SetStickyPolicy(s string) {
+	m.sticky_policy = &s
+}
+
+// StickyPolicy returns the value of the "sticky_policy" field in the mutation.
+func (m *GroupMutation) StickyPolicy() (r string, exists bool) {
+	v := m.sticky_policy
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldStickyPolicy returns the old "sticky_policy" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldStickyPolicy(ctx context.Context) (v string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldStickyPolicy is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldStickyPolicy requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldStickyPolicy: %w", err)
+	}
+	return oldValue.StickyPolicy, nil
+}
+
+// ResetStickyPolicy resets all changes to the "sticky_policy" field.
+func (m *GroupMutation) ResetStickyPolicy() {
+	m.sticky_policy = nil
+}
+
+// SetStickyTTLSeconds sets the "sticky_ttl_seconds" field.
+func (m *GroupMutation) SetStickyTTLSeconds(i int) {
+	m.sticky_ttl_seconds = &i
+	m.addsticky_ttl_seconds = nil
+}
+
+// StickyTTLSeconds returns the value of the "sticky_ttl_seconds" field in the mutation.
+func (m *GroupMutation) StickyTTLSeconds() (r int, exists bool) {
+	v := m.sticky_ttl_seconds
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldStickyTTLSeconds returns the old "sticky_ttl_seconds" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldStickyTTLSeconds(ctx context.Context) (v int, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldStickyTTLSeconds is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldStickyTTLSeconds requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldStickyTTLSeconds: %w", err)
+	}
+	return oldValue.StickyTTLSeconds, nil
+}
+
+// AddStickyTTLSeconds adds i to the "sticky_ttl_seconds" field.
+func (m *GroupMutation) AddStickyTTLSeconds(i int) {
+	if m.addsticky_ttl_seconds != nil {
+		*m.addsticky_ttl_seconds += i
+	} else {
+		m.addsticky_ttl_seconds = &i
+	}
+}
+
+// AddedStickyTTLSeconds returns the value that was added to the "sticky_ttl_seconds" field in this mutation.
+func (m *GroupMutation) AddedStickyTTLSeconds() (r int, exists bool) {
+	v := m.addsticky_ttl_seconds
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ResetStickyTTLSeconds resets all changes to the "sticky_ttl_seconds" field.
+func (m *GroupMutation) ResetStickyTTLSeconds() {
+	m.sticky_ttl_seconds = nil
+	m.addsticky_ttl_seconds = nil
+}
+
+// SetAccountTagSelector sets the "account_tag_selector" field.
+func (m *GroupMutation) SetAccountTagSelector(s string) {
+	m.account_tag_selector = &s
+}
+
+// AccountTagSelector returns the value of the "account_tag_selector" field in the mutation.
+func (m *GroupMutation) AccountTagSelector() (r string, exists bool) {
+	v := m.account_tag_selector
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldAccountTagSelector returns the old "account_tag_selector" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldAccountTagSelector(ctx context.Context) (v string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldAccountTagSelector is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldAccountTagSelector requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldAccountTagSelector: %w", err)
+	}
+	return oldValue.AccountTagSelector, nil
+}
+
+// ResetAccountTagSelector resets all changes to the "account_tag_selector" field.
+func (m *GroupMutation) ResetAccountTagSelector() {
+	m.account_tag_selector = nil
+}
+
+// SetModelRoutingTags sets the "model_routing_tags" field.
+func (m *GroupMutation) SetModelRoutingTags(value map[string]string) {
+	m.model_routing_tags = &value
+}
+
+// ModelRoutingTags returns the value of the "model_routing_tags" field in the mutation.
+func (m *GroupMutation) ModelRoutingTags() (r map[string]string, exists bool) {
+	v := m.model_routing_tags
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldModelRoutingTags returns the old "model_routing_tags" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldModelRoutingTags(ctx context.Context) (v map[string]string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldModelRoutingTags is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldModelRoutingTags requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldModelRoutingTags: %w", err)
+	}
+	return oldValue.ModelRoutingTags, nil
+}
+
+// ResetModelRoutingTags resets all changes to the "model_routing_tags" field.
+func (m *GroupMutation) ResetModelRoutingTags() {
+	m.model_routing_tags = nil
+}
+
+// SetCostRoutingPolicy sets the "cost_routing_policy" field.
+func (m *GroupMutation) SetCostRoutingPolicy(s string) {
+	m.cost_routing_policy = &s
+}
+
+// CostRoutingPolicy returns the value of the "cost_routing_policy" field in the mutation.
+func (m *GroupMutation) CostRoutingPolicy() (r string, exists bool) {
+	v := m.cost_routing_policy
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldCostRoutingPolicy returns the old "cost_routing_policy" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldCostRoutingPolicy(ctx context.Context) (v string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldCostRoutingPolicy is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldCostRoutingPolicy requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldCostRoutingPolicy: %w", err)
+	}
+	return oldValue.CostRoutingPolicy, nil
+}
+
+// ResetCostRoutingPolicy resets all changes to the "cost_routing_policy" field.
+func (m *GroupMutation) ResetCostRoutingPolicy() {
+	m.cost_routing_policy = nil
+}
+
 // AddAPIKeyIDs adds the "api_keys" edge to the APIKey entity by ids.
 func (m *GroupMutation) AddAPIKeyIDs(ids ...int64) {
 	if m.api_keys == nil {
@@ -5422,7 +6671,7 @@ func (m *GroupMutation) Type() string {
 // order to get all numeric fields that were incremented/decremented, call
 // AddedFields().
 func (m *GroupMutation) Fields() []string {
-	fields := make([]string, 0, 21)
+	fields := make([]string, 0, 27)
 	if m.created_at != nil {
 		fields = append(fields, group.FieldCreatedAt)
 	}
@@ -5486,6 +6735,24 @@ func (m *GroupMutation) Fields() []string {
 	if m.model_routing_enabled != nil {
 		fields = append(fields, group.FieldModelRoutingEnabled)
 	}
+	if m.model_mapping != nil {
+		fields = append(fields, group.FieldModelMapping)
+	}
+	if m.sticky_policy != nil {
+		fields = append(fields, group.FieldStickyPolicy)
+	}
+	if m.sticky_ttl_seconds != nil {
+		fields = append(fields, group.FieldStickyTTLSeconds)
+	}
+	if m.account_tag_selector != nil {
+		fields = append(fields, group.FieldAccountTagSelector)
+	}
+	if m.model_routing_tags != nil {
+		fields = append(fields, group.FieldModelRoutingTags)
+	}
+	if m.cost_routing_policy != nil {
+		fields = append(fields, group.FieldCostRoutingPolicy)
+	}
 	return fields
 }
 
@@ -5536,6 +6803,18 @@ func (m *GroupMutation) Field(name string) (ent.Value, bool) {
 		return m.ModelRouting()
 	case group.FieldModelRoutingEnabled:
 		return m.ModelRoutingEnabled()
+	case group.FieldModelMapping:
+		return m.ModelMapping()
+	case group.FieldStickyPolicy:
+		return m.StickyPolicy()
+	case group.FieldStickyTTLSeconds:
+		return m.StickyTTLSeconds()
+	case group.FieldAccountTagSelector:
+		return m.AccountTagSelector()
+	case group.FieldModelRoutingTags:
+		return m.ModelRoutingTags()
+	case group.FieldCostRoutingPolicy:
+		return m.CostRoutingPolicy()
 	}
 	return nil, false
 }
@@ -5587,6 +6866,18 @@ func (m *GroupMutation) OldField(ctx context.Context, name string) (ent.Value, e
 		return m.OldModelRouting(ctx)
 	case group.FieldModelRoutingEnabled:
 		return m.OldModelRoutingEnabled(ctx)
+	case group.FieldModelMapping:
+		return m.OldModelMapping(ctx)
+	case group.FieldStickyPolicy:
+		return m.OldStickyPolicy(ctx)
+	case group.FieldStickyTTLSeconds:
+		return m.OldStickyTTLSeconds(ctx)
+	case group.FieldAccountTagSelector:
+		return m.OldAccountTagSelector(ctx)
+	case group.FieldModelRoutingTags:
+		return m.OldModelRoutingTags(ctx)
+	case group.FieldCostRoutingPolicy:
+		return m.OldCostRoutingPolicy(ctx)
 	}
 	return nil, fmt.Errorf("unknown Group field %s", name)
 }
@@ -5743,6 +7034,48 @@ func (m *GroupMutation) SetField(name string, value ent.Value) error {
 		}
 		m.SetModelRoutingEnabled(v)
 		return nil
+	case group.FieldModelMapping:
+		v, ok := value.(map[string]string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetModelMapping(v)
+		return nil
+	case group.FieldStickyPolicy:
+		v, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetStickyPolicy(v)
+		return nil
+	case group.FieldStickyTTLSeconds:
+		v, ok := value.(int)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetStickyTTLSeconds(v)
+		return nil
+	case group.FieldAccountTagSelector:
+		v, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetAccountTagSelector(v)
+		return nil
+	case group.FieldModelRoutingTags:
+		v, ok := value.(map[string]string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetModelRoutingTags(v)
+		return nil
+	case group.FieldCostRoutingPolicy:
+		v, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetCostRoutingPolicy(v)
+		return nil
 	}
 	return fmt.Errorf("unknown Group field %s", name)
 }
@@ -5778,6 +7111,9 @@ func (m *GroupMutation) AddedFields() []string {
 	if m.addfallback_group_id != nil {
 		fields = append(fields, group.FieldFallbackGroupID)
 	}
+	if m.addsticky_ttl_seconds != nil {
+		fields = append(fields, group.FieldStickyTTLSeconds)
+	}
 	return fields
 }
 
@@ -5804,6 +7140,8 @@ func (m *GroupMutation) AddedField(name string) (ent.Value, bool) {
 		return m.AddedImagePrice4k()
 	case group.FieldFallbackGroupID:
 		return m.AddedFallbackGroupID()
+	case group.FieldStickyTTLSeconds:
+		return m.AddedStickyTTLSeconds()
 	}
 	return nil, false
 }
@@ -5876,6 +7214,13 @@ func (m *GroupMutation) AddField(name string, value ent.Value) error {
 		}
 		m.AddFallbackGroupID(v)
 		return nil
+	case group.FieldStickyTTLSeconds:
+		v, ok := value.(int)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddStickyTTLSeconds(v)
+		return nil
 	}
 	return fmt.Errorf("unknown Group numeric field %s", name)
 }
@@ -6029,6 +7374,24 @@ func (m *GroupMutation) ResetField(name string) error {
 	case group.FieldModelRoutingEnabled:
 		m.ResetModelRoutingEnabled()
 		return nil
+	case group.FieldModelMapping:
+		m.ResetModelMapping()
+		return nil
+	case group.FieldStickyPolicy:
+		m.ResetStickyPolicy()
+		return nil
+	case group.FieldStickyTTLSeconds:
+		m.ResetStickyTTLSeconds()
+		return nil
+	case group.FieldAccountTagSelector:
+		m.ResetAccountTagSelector()
+		return nil
+	case group.FieldModelRoutingTags:
+		m.ResetModelRoutingTags()
+		return nil
+	case group.FieldCostRoutingPolicy:
+		m.ResetCostRoutingPolicy()
+		return nil
 	}
 	return fmt.Errorf("unknown Group field %s", name)
 }
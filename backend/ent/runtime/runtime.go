@@ -22,6 +22,7 @@ import (
 	"github.com/Wei-Shaw/sub2api/ent/userattributedefinition"
 	"github.com/Wei-Shaw/sub2api/ent/userattributevalue"
 	"github.com/Wei-Shaw/sub2api/ent/usersubscription"
+	"github.com/lib/pq"
 )
 
 // The init function reads all schema descriptors with runtime code
@@ -200,6 +201,10 @@ func init() {
 	accountDescSessionWindowStatus := accountFields[21].Descriptor()
 	// account.SessionWindowStatusValidator is a validator for the "session_window_status" field. It is called by the builders before save.
 	account.SessionWindowStatusValidator = accountDescSessionWindowStatus.Validators[0].(func(string) error)
+	// accountDescTags is the schema descriptor for tags field.
+	accountDescTags := accountFields[22].Descriptor()
+	// account.DefaultTags holds the default value on creation for the tags field.
+	account.DefaultTags = accountDescTags.Default.(pq.StringArray)
 	accountgroupFields := schema.AccountGroup{}.Fields()
 	_ = accountgroupFields
 	// accountgroupDescPriority is the schema descriptor for priority field.
@@ -285,6 +290,36 @@ func init() {
 	groupDescModelRoutingEnabled := groupFields[17].Descriptor()
 	// group.DefaultModelRoutingEnabled holds the default value on creation for the model_routing_enabled field.
 	group.DefaultModelRoutingEnabled = groupDescModelRoutingEnabled.Default.(bool)
+	// groupDescModelMapping is the schema descriptor for model_mapping field.
+	groupDescModelMapping := groupFields[18].Descriptor()
+	// group.DefaultModelMapping holds the default value on creation for the model_mapping field.
+	group.DefaultModelMapping = groupDescModelMapping.Default.(map[string]string)
+	// groupDescStickyPolicy is the schema descriptor for sticky_policy field.
+	groupDescStickyPolicy := groupFields[19].Descriptor()
+	// group.DefaultStickyPolicy holds the default value on creation for the sticky_policy field.
+	group.DefaultStickyPolicy = groupDescStickyPolicy.Default.(string)
+	// group.StickyPolicyValidator is a validator for the "sticky_policy" field. It is called by the builders before save.
+	group.StickyPolicyValidator = groupDescStickyPolicy.Validators[0].(func(string) error)
+	// groupDescStickyTTLSeconds is the schema descriptor for sticky_ttl_seconds field.
+	groupDescStickyTTLSeconds := groupFields[20].Descriptor()
+	// group.DefaultStickyTTLSeconds holds the default value on creation for the sticky_ttl_seconds field.
+	group.DefaultStickyTTLSeconds = groupDescStickyTTLSeconds.Default.(int)
+	// groupDescAccountTagSelector is the schema descriptor for account_tag_selector field.
+	groupDescAccountTagSelector := groupFields[21].Descriptor()
+	// group.DefaultAccountTagSelector holds the default value on creation for the account_tag_selector field.
+	group.DefaultAccountTagSelector = groupDescAccountTagSelector.Default.(string)
+	// group.AccountTagSelectorValidator is a validator for the "account_tag_selector" field. It is called by the builders before save.
+	group.AccountTagSelectorValidator = groupDescAccountTagSelector.Validators[0].(func(string) error)
+	// groupDescModelRoutingTags is the schema descriptor for model_routing_tags field.
+	groupDescModelRoutingTags := groupFields[22].Descriptor()
+	// group.DefaultModelRoutingTags holds the default value on creation for the model_routing_tags field.
+	group.DefaultModelRoutingTags = groupDescModelRoutingTags.Default.(map[string]string)
+	// groupDescCostRoutingPolicy is the schema descriptor for cost_routing_policy field.
+	groupDescCostRoutingPolicy := groupFields[23].Descriptor()
+	// group.DefaultCostRoutingPolicy holds the default value on creation for the cost_routing_policy field.
+	group.DefaultCostRoutingPolicy = groupDescCostRoutingPolicy.Default.(string)
+	// group.CostRoutingPolicyValidator is a validator for the "cost_routing_policy" field. It is called by the builders before save.
+	group.CostRoutingPolicyValidator = groupDescCostRoutingPolicy.Validators[0].(func(string) error)
 	promocodeFields := schema.PromoCode{}.Fields()
 	_ = promocodeFields
 	// promocodeDescCode is the schema descriptor for code field.
@@ -3,6 +3,8 @@
 package schema
 
 import (
+	"encoding/json"
+
 	"github.com/Wei-Shaw/sub2api/ent/schema/mixins"
 	"github.com/Wei-Shaw/sub2api/internal/service"
 
@@ -13,6 +15,7 @@ import (
 	"entgo.io/ent/schema/edge"
 	"entgo.io/ent/schema/field"
 	"entgo.io/ent/schema/index"
+	"github.com/lib/pq"
 )
 
 // Account 定义 AI API 账户实体的 schema。
@@ -178,6 +181,22 @@ func (Account) Fields() []ent.Field {
 			Optional().
 			Nillable().
 			MaxLen(20),
+
+		// tags: 账号自由标签（小写，去重排序），分组标签选择器与 model_routing 标签据此匹配
+		// (added by migration 050，GIN 索引见迁移文件)
+		field.Other("tags", pq.StringArray{}).
+			Default(pq.StringArray{}).
+			SchemaType(map[string]string{dialect.Postgres: "text[]", dialect.SQLite: "text"}),
+
+		// availability_schedule: 按周配置的可用时段（带时区），NULL 表示始终可用 (added by migration 051)
+		field.JSON("availability_schedule", json.RawMessage{}).
+			Optional().
+			SchemaType(map[string]string{dialect.Postgres: "jsonb"}),
+		// availability_next_transition_at: 下一次可用状态切换时间，后台任务到期后投递调度 outbox 事件
+		field.Time("availability_next_transition_at").
+			Optional().
+			Nillable().
+			SchemaType(map[string]string{dialect.Postgres: "timestamptz"}),
 	}
 }
 
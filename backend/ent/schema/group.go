@@ -106,6 +106,37 @@ func (Group) Fields() []ent.Field {
 		field.Bool("model_routing_enabled").
 			Default(false).
 			Comment("是否启用模型路由配置"),
+
+		// 跨协议模型映射 (added by migration 046)
+		field.JSON("model_mapping", map[string]string{}).
+			Default(map[string]string{}).
+			SchemaType(map[string]string{dialect.Postgres: "jsonb"}).
+			Comment("模型映射配置：模型模式 -> 上游模型"),
+
+		// 粘性会话策略 (added by migration 048)
+		field.String("sticky_policy").
+			MaxLen(20).
+			Default(service.StickyPolicyContentHash).
+			Comment("粘性会话策略：content_hash/metadata_user_id/api_key/none"),
+		field.Int("sticky_ttl_seconds").
+			Default(0).
+			Comment("粘性会话 TTL（秒），0 表示使用默认值"),
+
+		// 账号标签路由 (added by migration 050)
+		field.String("account_tag_selector").
+			MaxLen(512).
+			Default("").
+			Comment("账号标签选择器，匹配的可调度账号自动加入分组"),
+		field.JSON("model_routing_tags", map[string]string{}).
+			Default(map[string]string{}).
+			SchemaType(map[string]string{dialect.Postgres: "jsonb"}).
+			Comment("模型路由标签选择器：模型模式 -> 标签选择器"),
+
+		// 成本优先路由 (added by migration 052)
+		field.String("cost_routing_policy").
+			MaxLen(20).
+			Default("").
+			Comment("成本优先路由策略：空表示关闭，lowest_cost 优先选择有效成本最低的账号"),
 	}
 }
 
@@ -57,8 +57,12 @@ type Config struct {
 	Dashboard    DashboardCacheConfig       `mapstructure:"dashboard_cache"`
 	DashboardAgg DashboardAggregationConfig `mapstructure:"dashboard_aggregation"`
 	UsageCleanup UsageCleanupConfig         `mapstructure:"usage_cleanup"`
+	MessageBatch MessageBatchConfig         `mapstructure:"message_batch"`
 	Concurrency  ConcurrencyConfig          `mapstructure:"concurrency"`
 	TokenRefresh TokenRefreshConfig         `mapstructure:"token_refresh"`
+	HealthCheck  AccountHealthCheckConfig   `mapstructure:"account_health_check"`
+	Ledger       BalanceLedgerConfig        `mapstructure:"balance_ledger"`
+	Payment      PaymentConfig              `mapstructure:"payment"`
 	RunMode      string                     `mapstructure:"run_mode" yaml:"run_mode"`
 	Timezone     string                     `mapstructure:"timezone"` // e.g. "Asia/Shanghai", "UTC"
 	Gemini       GeminiConfig               `mapstructure:"gemini"`
@@ -128,6 +132,90 @@ type TokenRefreshConfig struct {
 	RetryBackoffSeconds int `mapstructure:"retry_backoff_seconds"`
 }
 
+// AccountHealthCheckConfig 账号定时健康巡检配置
+// 定期对空闲账号发送最小测试请求，提前发现过期的 OAuth token 或被吊销的 API Key。
+type AccountHealthCheckConfig struct {
+	// 是否启用（巡检请求会消耗少量上游额度，默认关闭）
+	Enabled bool `mapstructure:"enabled"`
+	// 巡检间隔（分钟）
+	IntervalMinutes int `mapstructure:"interval_minutes"`
+	// 仅巡检空闲超过该时长（分钟）的账号，0 表示巡检全部账号
+	IdleMinutes int `mapstructure:"idle_minutes"`
+	// 并发巡检数
+	Concurrency int `mapstructure:"concurrency"`
+	// 单个账号巡检超时（秒）
+	TimeoutSeconds int `mapstructure:"timeout_seconds"`
+	// 连续失败多少次后将账号标记为错误
+	FailureThreshold int `mapstructure:"failure_threshold"`
+	// 巡检成功后是否自动恢复由巡检标记为错误的账号
+	AutoRecover bool `mapstructure:"auto_recover"`
+	// 巡检记录保留天数
+	RetentionDays int `mapstructure:"retention_days"`
+}
+
+// BalanceLedgerConfig 余额流水对账配置
+// 定期比对流水合计、users.balance 与 Redis 余额缓存，报告漂移（只报告不修正）。
+type BalanceLedgerConfig struct {
+	// 是否启用定时对账
+	ReconcileEnabled bool `mapstructure:"reconcile_enabled"`
+	// 对账间隔（分钟）
+	ReconcileIntervalMinutes int `mapstructure:"reconcile_interval_minutes"`
+	// 允许的误差（美元），超过即视为漂移
+	DriftTolerance float64 `mapstructure:"drift_tolerance"`
+	// 是否同时比对 Redis 余额缓存
+	CheckCache bool `mapstructure:"check_cache"`
+	// 单批读取的用户数
+	BatchSize int `mapstructure:"batch_size"`
+	// 报告中最多保留的漂移明细条数
+	MaxReportedDrifts int `mapstructure:"max_reported_drifts"`
+}
+
+// PaymentConfig 充值订单与支付渠道配置
+type PaymentConfig struct {
+	// 是否启用充值订单
+	Enabled bool `mapstructure:"enabled"`
+	// 支付币种
+	Currency string `mapstructure:"currency"`
+	// 余额充值汇率：每 1 单位支付金额入账的余额（美元）
+	CreditRate float64 `mapstructure:"credit_rate"`
+	// 单笔余额充值的最小/最大支付金额
+	MinAmount float64 `mapstructure:"min_amount"`
+	MaxAmount float64 `mapstructure:"max_amount"`
+	// 待支付订单的有效期（分钟），超时未支付自动过期
+	OrderExpireMinutes int `mapstructure:"order_expire_minutes"`
+	// 可购买的订阅套餐
+	SubscriptionPlans []PaymentSubscriptionPlan `mapstructure:"subscription_plans"`
+	// 通用 HMAC 签名回调渠道
+	Webhook PaymentWebhookProviderConfig `mapstructure:"webhook"`
+	// 本地模拟渠道（仅用于测试，回调无签名校验，release 模式禁止启用）
+	Mock PaymentMockProviderConfig `mapstructure:"mock"`
+}
+
+// PaymentSubscriptionPlan 订阅套餐：支付 Price 后分配或续期 GroupID 对应的订阅
+type PaymentSubscriptionPlan struct {
+	ID           string  `mapstructure:"id"`
+	Name         string  `mapstructure:"name"`
+	GroupID      int64   `mapstructure:"group_id"`
+	ValidityDays int     `mapstructure:"validity_days"`
+	Price        float64 `mapstructure:"price"`
+}
+
+// PaymentWebhookProviderConfig 通用 HMAC 签名回调渠道配置
+type PaymentWebhookProviderConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// 回调签名密钥：签名为 hex(HMAC-SHA256(secret, timestamp + "." + body))
+	Secret string `mapstructure:"secret"`
+	// 收银台地址，支持 {order_no}、{amount}、{currency} 占位符
+	CheckoutURL string `mapstructure:"checkout_url"`
+	// 回调时间戳允许的偏差（秒），用于防重放
+	SignatureToleranceSeconds int `mapstructure:"signature_tolerance_seconds"`
+}
+
+// PaymentMockProviderConfig 本地模拟支付渠道配置
+type PaymentMockProviderConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+}
+
 type PricingConfig struct {
 	// 价格数据远程URL（默认使用LiteLLM镜像）
 	RemoteURL string `mapstructure:"remote_url"`
@@ -141,6 +229,8 @@ type PricingConfig struct {
 	UpdateIntervalHours int `mapstructure:"update_interval_hours"`
 	// 哈希校验间隔（分钟）
 	HashCheckIntervalMinutes int `mapstructure:"hash_check_interval_minutes"`
+	// 管理员自定义价格刷新间隔（秒），多实例部署时其他实例的修改在此间隔内生效
+	OverrideRefreshSeconds int `mapstructure:"override_refresh_seconds"`
 }
 
 type ServerConfig struct {
@@ -191,6 +281,17 @@ type ProxyProbeConfig struct {
 
 type BillingConfig struct {
 	CircuitBreaker CircuitBreakerConfig `mapstructure:"circuit_breaker"`
+	Holds          BalanceHoldConfig    `mapstructure:"holds"`
+}
+
+// BalanceHoldConfig 余额模式预授权冻结配置
+type BalanceHoldConfig struct {
+	// Enabled: 请求开始时按估算费用冻结可用余额，完成时按实际费用结算
+	Enabled bool `mapstructure:"enabled"`
+	// DefaultOutputTokens: 请求未指定 max_tokens 时用于估算的输出 token 数
+	DefaultOutputTokens int `mapstructure:"default_output_tokens"`
+	// TTLSeconds: 冻结最长保留时间（秒），超时未结算的冻结自动失效
+	TTLSeconds int `mapstructure:"ttl_seconds"`
 }
 
 type CircuitBreakerConfig struct {
@@ -270,6 +371,21 @@ type GatewayConfig struct {
 	// Scheduling: 账号调度相关配置
 	Scheduling GatewaySchedulingConfig `mapstructure:"scheduling"`
 
+	// CountTokens: count_tokens / countTokens 本地估算配置
+	CountTokens GatewayCountTokensConfig `mapstructure:"count_tokens"`
+
+	// OpenAIResponseState: Responses API previous_response_id 跨账号续链配置
+	OpenAIResponseState GatewayOpenAIResponseStateConfig `mapstructure:"openai_response_state"`
+
+	// CircuitBreaker: 账号级熔断配置
+	CircuitBreaker GatewayCircuitBreakerConfig `mapstructure:"circuit_breaker"`
+
+	// FairQueue: 账号排队加权公平调度配置
+	FairQueue GatewayFairQueueConfig `mapstructure:"fair_queue"`
+
+	// Hedging: 非流式请求对冲配置
+	Hedging GatewayHedgingConfig `mapstructure:"hedging"`
+
 	// TLSFingerprint: TLS指纹伪装配置
 	TLSFingerprint TLSFingerprintConfig `mapstructure:"tls_fingerprint"`
 }
@@ -298,6 +414,88 @@ type TLSProfileConfig struct {
 	PointFormats []uint8 `mapstructure:"point_formats"`
 }
 
+// count_tokens 处理模式
+const (
+	// CountTokensModeUpstream 始终转发上游（不支持的平台返回 0），默认
+	CountTokensModeUpstream = "upstream"
+	// CountTokensModeFallback 优先转发上游；上游不支持或失败时使用本地估算
+	CountTokensModeFallback = "fallback"
+	// CountTokensModeLocal 始终本地估算，不占用上游账号
+	CountTokensModeLocal = "local"
+)
+
+// GatewayCountTokensConfig count_tokens 本地估算配置
+type GatewayCountTokensConfig struct {
+	// Mode: upstream/fallback/local
+	Mode string `mapstructure:"mode"`
+	// DriftSampleRate: 上游返回结果时与本地估算对比的采样率（0-1），结果用于运维监控的偏差报告
+	DriftSampleRate float64 `mapstructure:"drift_sample_rate"`
+}
+
+// GatewayOpenAIResponseStateConfig Responses API 响应状态保存配置
+// 网关保存每轮输入与输出，previous_response_id 被调度到其他账号时在本地还原完整输入链。
+type GatewayOpenAIResponseStateConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// TTLSeconds: 响应状态保存时长（秒）
+	TTLSeconds int `mapstructure:"ttl_seconds"`
+	// MaxBytes: 单条响应状态（本轮 input + output）最大字节数，超出则不保存
+	MaxBytes int `mapstructure:"max_bytes"`
+	// MaxChainDepth: 还原时最多回溯的响应数，超出则按原样转发
+	MaxChainDepth int `mapstructure:"max_chain_depth"`
+}
+
+// GatewayCircuitBreakerConfig 账号级熔断配置
+// 滚动窗口内上游 5xx/超时/请求失败比例过高时熔断账号（open），到期后进入半开（half_open）
+// 按间隔放行探测请求，连续成功后恢复（closed），探测失败则重新熔断。
+type GatewayCircuitBreakerConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// WindowSeconds: 滚动统计窗口（秒）
+	WindowSeconds int `mapstructure:"window_seconds"`
+	// MinRequests: 窗口内最少请求数，低于该值不熔断
+	MinRequests int `mapstructure:"min_requests"`
+	// FailureRatio: 触发熔断的失败比例（0-1）
+	FailureRatio float64 `mapstructure:"failure_ratio"`
+	// OpenSeconds: 熔断持续时间（秒）
+	OpenSeconds int `mapstructure:"open_seconds"`
+	// ProbeIntervalSeconds: 半开状态下放行探测请求的最小间隔（秒）
+	ProbeIntervalSeconds int `mapstructure:"probe_interval_seconds"`
+	// HalfOpenSuccesses: 半开状态下恢复所需的连续成功次数
+	HalfOpenSuccesses int `mapstructure:"half_open_successes"`
+}
+
+// GatewayFairQueueConfig 账号排队加权公平调度配置
+// 等待账号槽位的请求按分组进入 Redis 加权公平队列（WFQ），以用户为流、按权重轮转放行，
+// 避免高并发用户占满排队位置导致同组其他用户饥饿。Redis 异常时退化为原有竞争式等待。
+type GatewayFairQueueConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// StaleSeconds: 排队请求心跳超时（秒），超时未轮询的排队项会被清除
+	StaleSeconds int `mapstructure:"stale_seconds"`
+	// MaxWeight: 单用户权重上限（权重默认取用户并发数）
+	MaxWeight int `mapstructure:"max_weight"`
+	// SubscriptionWeightMultiplier: 订阅分组请求的权重倍数
+	SubscriptionWeightMultiplier float64 `mapstructure:"subscription_weight_multiplier"`
+}
+
+// GatewayHedgingConfig 非流式请求对冲配置
+// 首个尝试在近期响应头延迟的指定分位内仍未收到上游响应头时，在另一个账号上发起第二个尝试，
+// 先完成者返回给客户端并取消另一个，只对胜出的尝试计费。仅用于延迟敏感的短请求。
+type GatewayHedgingConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Percentile: 对冲延迟取近期成功请求首个响应头耗时的分位数（0-100，如 95 表示 P95）
+	Percentile float64 `mapstructure:"percentile"`
+	// MinDelayMs/MaxDelayMs: 对冲延迟的上下限（毫秒）；样本不足时使用 MaxDelayMs
+	MinDelayMs int `mapstructure:"min_delay_ms"`
+	MaxDelayMs int `mapstructure:"max_delay_ms"`
+	// WindowSize: 每个模型保留的近期耗时样本数
+	WindowSize int `mapstructure:"window_size"`
+	// MinSamples: 计算分位所需的最少样本数
+	MinSamples int `mapstructure:"min_samples"`
+	// MaxOutputTokens: 仅对 max_tokens 不超过该值的 Messages 请求对冲（0 表示不限制）
+	MaxOutputTokens int `mapstructure:"max_output_tokens"`
+	// CountTokens: count_tokens 转发上游时是否对冲
+	CountTokens bool `mapstructure:"count_tokens"`
+}
+
 // GatewaySchedulingConfig accounts scheduling configuration.
 type GatewaySchedulingConfig struct {
 	// 粘性会话排队配置
@@ -308,8 +506,14 @@ type GatewaySchedulingConfig struct {
 	FallbackWaitTimeout time.Duration `mapstructure:"fallback_wait_timeout"`
 	FallbackMaxWaiting  int           `mapstructure:"fallback_max_waiting"`
 
-	// 兜底层账户选择策略: "last_used"(按最后使用时间排序，默认) 或 "random"(随机)
+	// 兜底层账户选择策略: "last_used"(按最后使用时间排序，默认)、"random"(随机)、
+	// "least_latency"(按 TTFT/错误率/负载评分取最优) 或 "p2c"(随机取两个候选择优)。
+	// 评分类策略同时作用于负载感知层，同优先级内按评分代替负载率+最后使用时间排序。
 	FallbackSelectionMode string `mapstructure:"fallback_selection_mode"`
+	// 评分 EWMA 平滑系数（0-1），越大越偏向最近样本
+	ScoringEWMAAlpha float64 `mapstructure:"scoring_ewma_alpha"`
+	// 错误率权重：评分 = TTFT × (1 + 负载率) × (1 + 权重 × 错误率)
+	ScoringErrorRateWeight float64 `mapstructure:"scoring_error_rate_weight"`
 
 	// 负载计算
 	LoadBatchEnabled bool `mapstructure:"load_batch_enabled"`
@@ -557,6 +761,26 @@ type UsageCleanupConfig struct {
 	TaskTimeoutSeconds int `mapstructure:"task_timeout_seconds"`
 }
 
+// MessageBatchConfig Message Batches 网关侧批处理配置
+type MessageBatchConfig struct {
+	// Enabled: 是否启用批处理执行器（关闭后仍可创建/查询批次，但不会执行）
+	Enabled bool `mapstructure:"enabled"`
+	// WorkerIntervalSeconds: 后台执行器轮询间隔（秒）
+	WorkerIntervalSeconds int `mapstructure:"worker_interval_seconds"`
+	// WorkerConcurrency: 单个实例同时执行的批处理请求数上限
+	WorkerConcurrency int `mapstructure:"worker_concurrency"`
+	// MaxRequestsPerBatch: 单个批次允许的最大请求数
+	MaxRequestsPerBatch int `mapstructure:"max_requests_per_batch"`
+	// ExpireHours: 批次创建后多久未完成的请求标记为 expired（小时）
+	ExpireHours int `mapstructure:"expire_hours"`
+	// ResultRetentionDays: 已结束批次结果保留天数，超出后删除
+	ResultRetentionDays int `mapstructure:"result_retention_days"`
+	// ItemTimeoutSeconds: 单条请求最大执行时长（秒），同时作为 running 状态的回收阈值
+	ItemTimeoutSeconds int `mapstructure:"item_timeout_seconds"`
+	// MaxAttempts: 单条请求因上游可重试错误最多执行次数
+	MaxAttempts int `mapstructure:"max_attempts"`
+}
+
 func NormalizeRunMode(value string) string {
 	normalized := strings.ToLower(strings.TrimSpace(value))
 	switch normalized {
@@ -720,6 +944,9 @@ func setDefaults() {
 	viper.SetDefault("billing.circuit_breaker.failure_threshold", 5)
 	viper.SetDefault("billing.circuit_breaker.reset_timeout_seconds", 30)
 	viper.SetDefault("billing.circuit_breaker.half_open_requests", 3)
+	viper.SetDefault("billing.holds.enabled", true)
+	viper.SetDefault("billing.holds.default_output_tokens", 4096)
+	viper.SetDefault("billing.holds.ttl_seconds", 1800)
 
 	// Turnstile
 	viper.SetDefault("turnstile.required", false)
@@ -804,6 +1031,7 @@ func setDefaults() {
 	viper.SetDefault("pricing.fallback_file", "./resources/model-pricing/model_prices_and_context_window.json")
 	viper.SetDefault("pricing.update_interval_hours", 24)
 	viper.SetDefault("pricing.hash_check_interval_minutes", 10)
+	viper.SetDefault("pricing.override_refresh_seconds", 60)
 
 	// Timezone (default to Asia/Shanghai for Chinese users)
 	viper.SetDefault("timezone", "Asia/Shanghai")
@@ -841,6 +1069,16 @@ func setDefaults() {
 	viper.SetDefault("usage_cleanup.worker_interval_seconds", 10)
 	viper.SetDefault("usage_cleanup.task_timeout_seconds", 1800)
 
+	// Message batches
+	viper.SetDefault("message_batch.enabled", true)
+	viper.SetDefault("message_batch.worker_interval_seconds", 5)
+	viper.SetDefault("message_batch.worker_concurrency", 4)
+	viper.SetDefault("message_batch.max_requests_per_batch", 10000)
+	viper.SetDefault("message_batch.expire_hours", 24)
+	viper.SetDefault("message_batch.result_retention_days", 29)
+	viper.SetDefault("message_batch.item_timeout_seconds", 600)
+	viper.SetDefault("message_batch.max_attempts", 3)
+
 	// Gateway
 	viper.SetDefault("gateway.response_header_timeout", 600) // 600秒(10分钟)等待上游响应头，LLM高负载时可能排队较久
 	viper.SetDefault("gateway.log_upstream_error_body", true)
@@ -863,11 +1101,38 @@ func setDefaults() {
 	viper.SetDefault("gateway.stream_data_interval_timeout", 180)
 	viper.SetDefault("gateway.stream_keepalive_interval", 10)
 	viper.SetDefault("gateway.max_line_size", 40*1024*1024)
+	viper.SetDefault("gateway.count_tokens.mode", CountTokensModeUpstream)
+	viper.SetDefault("gateway.count_tokens.drift_sample_rate", 0.0)
+	viper.SetDefault("gateway.openai_response_state.enabled", true)
+	viper.SetDefault("gateway.openai_response_state.ttl_seconds", 86400)
+	viper.SetDefault("gateway.openai_response_state.max_bytes", 2*1024*1024)
+	viper.SetDefault("gateway.openai_response_state.max_chain_depth", 100)
+	viper.SetDefault("gateway.circuit_breaker.enabled", true)
+	viper.SetDefault("gateway.circuit_breaker.window_seconds", 60)
+	viper.SetDefault("gateway.circuit_breaker.min_requests", 20)
+	viper.SetDefault("gateway.circuit_breaker.failure_ratio", 0.5)
+	viper.SetDefault("gateway.circuit_breaker.open_seconds", 60)
+	viper.SetDefault("gateway.circuit_breaker.probe_interval_seconds", 5)
+	viper.SetDefault("gateway.circuit_breaker.half_open_successes", 3)
+	viper.SetDefault("gateway.fair_queue.enabled", true)
+	viper.SetDefault("gateway.fair_queue.stale_seconds", 10)
+	viper.SetDefault("gateway.fair_queue.max_weight", 20)
+	viper.SetDefault("gateway.fair_queue.subscription_weight_multiplier", 1.0)
+	viper.SetDefault("gateway.hedging.enabled", false)
+	viper.SetDefault("gateway.hedging.percentile", 95.0)
+	viper.SetDefault("gateway.hedging.min_delay_ms", 300)
+	viper.SetDefault("gateway.hedging.max_delay_ms", 10000)
+	viper.SetDefault("gateway.hedging.window_size", 200)
+	viper.SetDefault("gateway.hedging.min_samples", 20)
+	viper.SetDefault("gateway.hedging.max_output_tokens", 1024)
+	viper.SetDefault("gateway.hedging.count_tokens", true)
 	viper.SetDefault("gateway.scheduling.sticky_session_max_waiting", 3)
 	viper.SetDefault("gateway.scheduling.sticky_session_wait_timeout", 120*time.Second)
 	viper.SetDefault("gateway.scheduling.fallback_wait_timeout", 30*time.Second)
 	viper.SetDefault("gateway.scheduling.fallback_max_waiting", 100)
 	viper.SetDefault("gateway.scheduling.fallback_selection_mode", "last_used")
+	viper.SetDefault("gateway.scheduling.scoring_ewma_alpha", 0.2)
+	viper.SetDefault("gateway.scheduling.scoring_error_rate_weight", 4.0)
 	viper.SetDefault("gateway.scheduling.load_batch_enabled", true)
 	viper.SetDefault("gateway.scheduling.slot_cleanup_interval", 30*time.Second)
 	viper.SetDefault("gateway.scheduling.db_fallback_enabled", true)
@@ -890,6 +1155,37 @@ func setDefaults() {
 	viper.SetDefault("token_refresh.max_retries", 3)                   // 最多重试3次
 	viper.SetDefault("token_refresh.retry_backoff_seconds", 2)         // 重试退避基础2秒
 
+	// AccountHealthCheck
+	viper.SetDefault("account_health_check.enabled", false)
+	viper.SetDefault("account_health_check.interval_minutes", 30)
+	viper.SetDefault("account_health_check.idle_minutes", 30)
+	viper.SetDefault("account_health_check.concurrency", 4)
+	viper.SetDefault("account_health_check.timeout_seconds", 60)
+	viper.SetDefault("account_health_check.failure_threshold", 2)
+	viper.SetDefault("account_health_check.auto_recover", true)
+	viper.SetDefault("account_health_check.retention_days", 7)
+
+	// BalanceLedger
+	viper.SetDefault("balance_ledger.reconcile_enabled", true)
+	viper.SetDefault("balance_ledger.reconcile_interval_minutes", 60)
+	viper.SetDefault("balance_ledger.drift_tolerance", 0.0001)
+	viper.SetDefault("balance_ledger.check_cache", true)
+	viper.SetDefault("balance_ledger.batch_size", 500)
+	viper.SetDefault("balance_ledger.max_reported_drifts", 200)
+
+	// Payment - 充值订单（默认关闭）
+	viper.SetDefault("payment.enabled", false)
+	viper.SetDefault("payment.currency", "USD")
+	viper.SetDefault("payment.credit_rate", 1.0)
+	viper.SetDefault("payment.min_amount", 1.0)
+	viper.SetDefault("payment.max_amount", 10000.0)
+	viper.SetDefault("payment.order_expire_minutes", 30)
+	viper.SetDefault("payment.webhook.enabled", false)
+	viper.SetDefault("payment.webhook.secret", "")
+	viper.SetDefault("payment.webhook.checkout_url", "")
+	viper.SetDefault("payment.webhook.signature_tolerance_seconds", 300)
+	viper.SetDefault("payment.mock.enabled", false)
+
 	// Gemini OAuth - configure via environment variables or config file
 	// GEMINI_OAUTH_CLIENT_ID and GEMINI_OAUTH_CLIENT_SECRET
 	// Default: uses Gemini CLI public credentials (set via environment)
@@ -978,6 +1274,14 @@ func (c *Config) Validate() error {
 			return fmt.Errorf("billing.circuit_breaker.half_open_requests must be positive")
 		}
 	}
+	if c.Billing.Holds.Enabled {
+		if c.Billing.Holds.DefaultOutputTokens < 0 {
+			return fmt.Errorf("billing.holds.default_output_tokens must be non-negative")
+		}
+		if c.Billing.Holds.TTLSeconds <= 0 {
+			return fmt.Errorf("billing.holds.ttl_seconds must be positive")
+		}
+	}
 	if c.Database.MaxOpenConns <= 0 {
 		return fmt.Errorf("database.max_open_conns must be positive")
 	}
@@ -1110,6 +1414,29 @@ func (c *Config) Validate() error {
 			return fmt.Errorf("usage_cleanup.task_timeout_seconds must be non-negative")
 		}
 	}
+	if c.MessageBatch.Enabled {
+		if c.MessageBatch.WorkerIntervalSeconds <= 0 {
+			return fmt.Errorf("message_batch.worker_interval_seconds must be positive")
+		}
+		if c.MessageBatch.WorkerConcurrency <= 0 {
+			return fmt.Errorf("message_batch.worker_concurrency must be positive")
+		}
+		if c.MessageBatch.MaxRequestsPerBatch <= 0 {
+			return fmt.Errorf("message_batch.max_requests_per_batch must be positive")
+		}
+		if c.MessageBatch.ExpireHours <= 0 {
+			return fmt.Errorf("message_batch.expire_hours must be positive")
+		}
+		if c.MessageBatch.ItemTimeoutSeconds <= 0 {
+			return fmt.Errorf("message_batch.item_timeout_seconds must be positive")
+		}
+		if c.MessageBatch.MaxAttempts <= 0 {
+			return fmt.Errorf("message_batch.max_attempts must be positive")
+		}
+	}
+	if c.MessageBatch.ResultRetentionDays < 0 {
+		return fmt.Errorf("message_batch.result_retention_days must be non-negative")
+	}
 	if c.Gateway.MaxBodySize <= 0 {
 		return fmt.Errorf("gateway.max_body_size must be positive")
 	}
@@ -1165,6 +1492,163 @@ func (c *Config) Validate() error {
 	if c.Gateway.MaxLineSize != 0 && c.Gateway.MaxLineSize < 1024*1024 {
 		return fmt.Errorf("gateway.max_line_size must be at least 1MB")
 	}
+	switch c.Gateway.CountTokens.Mode {
+	case "", CountTokensModeUpstream, CountTokensModeFallback, CountTokensModeLocal:
+	default:
+		return fmt.Errorf("gateway.count_tokens.mode must be one of: upstream, fallback, local")
+	}
+	if c.Gateway.CountTokens.DriftSampleRate < 0 || c.Gateway.CountTokens.DriftSampleRate > 1 {
+		return fmt.Errorf("gateway.count_tokens.drift_sample_rate must be between 0 and 1")
+	}
+	if c.Gateway.OpenAIResponseState.Enabled {
+		if c.Gateway.OpenAIResponseState.TTLSeconds <= 0 {
+			return fmt.Errorf("gateway.openai_response_state.ttl_seconds must be positive")
+		}
+		if c.Gateway.OpenAIResponseState.MaxBytes <= 0 {
+			return fmt.Errorf("gateway.openai_response_state.max_bytes must be positive")
+		}
+		if c.Gateway.OpenAIResponseState.MaxChainDepth <= 0 {
+			return fmt.Errorf("gateway.openai_response_state.max_chain_depth must be positive")
+		}
+	}
+	if c.HealthCheck.Enabled {
+		if c.HealthCheck.IntervalMinutes <= 0 {
+			return fmt.Errorf("account_health_check.interval_minutes must be positive")
+		}
+		if c.HealthCheck.IdleMinutes < 0 {
+			return fmt.Errorf("account_health_check.idle_minutes must be non-negative")
+		}
+		if c.HealthCheck.Concurrency <= 0 {
+			return fmt.Errorf("account_health_check.concurrency must be positive")
+		}
+		if c.HealthCheck.TimeoutSeconds <= 0 {
+			return fmt.Errorf("account_health_check.timeout_seconds must be positive")
+		}
+		if c.HealthCheck.FailureThreshold <= 0 {
+			return fmt.Errorf("account_health_check.failure_threshold must be positive")
+		}
+		if c.HealthCheck.RetentionDays <= 0 {
+			return fmt.Errorf("account_health_check.retention_days must be positive")
+		}
+	}
+	if c.Ledger.ReconcileEnabled {
+		if c.Ledger.ReconcileIntervalMinutes <= 0 {
+			return fmt.Errorf("balance_ledger.reconcile_interval_minutes must be positive")
+		}
+		if c.Ledger.DriftTolerance < 0 {
+			return fmt.Errorf("balance_ledger.drift_tolerance must be non-negative")
+		}
+		if c.Ledger.BatchSize <= 0 {
+			return fmt.Errorf("balance_ledger.batch_size must be positive")
+		}
+		if c.Ledger.MaxReportedDrifts < 0 {
+			return fmt.Errorf("balance_ledger.max_reported_drifts must be non-negative")
+		}
+	}
+	if c.Payment.Enabled {
+		if strings.TrimSpace(c.Payment.Currency) == "" {
+			return fmt.Errorf("payment.currency is required when payment.enabled=true")
+		}
+		if c.Payment.CreditRate <= 0 {
+			return fmt.Errorf("payment.credit_rate must be positive")
+		}
+		if c.Payment.MinAmount <= 0 {
+			return fmt.Errorf("payment.min_amount must be positive")
+		}
+		if c.Payment.MaxAmount < c.Payment.MinAmount {
+			return fmt.Errorf("payment.max_amount cannot be less than payment.min_amount")
+		}
+		if c.Payment.OrderExpireMinutes <= 0 {
+			return fmt.Errorf("payment.order_expire_minutes must be positive")
+		}
+		if !c.Payment.Webhook.Enabled && !c.Payment.Mock.Enabled {
+			return fmt.Errorf("at least one payment provider must be enabled when payment.enabled=true")
+		}
+		planIDs := make(map[string]struct{}, len(c.Payment.SubscriptionPlans))
+		for i, plan := range c.Payment.SubscriptionPlans {
+			if strings.TrimSpace(plan.ID) == "" {
+				return fmt.Errorf("payment.subscription_plans[%d].id is required", i)
+			}
+			if _, ok := planIDs[plan.ID]; ok {
+				return fmt.Errorf("payment.subscription_plans[%d].id %q is duplicated", i, plan.ID)
+			}
+			planIDs[plan.ID] = struct{}{}
+			if plan.GroupID <= 0 {
+				return fmt.Errorf("payment.subscription_plans[%d].group_id must be positive", i)
+			}
+			if plan.ValidityDays <= 0 {
+				return fmt.Errorf("payment.subscription_plans[%d].validity_days must be positive", i)
+			}
+			if plan.Price <= 0 {
+				return fmt.Errorf("payment.subscription_plans[%d].price must be positive", i)
+			}
+		}
+	}
+	if c.Payment.Webhook.Enabled {
+		if strings.TrimSpace(c.Payment.Webhook.Secret) == "" {
+			return fmt.Errorf("payment.webhook.secret is required when payment.webhook.enabled=true")
+		}
+		if strings.TrimSpace(c.Payment.Webhook.CheckoutURL) == "" {
+			return fmt.Errorf("payment.webhook.checkout_url is required when payment.webhook.enabled=true")
+		}
+		if c.Payment.Webhook.SignatureToleranceSeconds <= 0 {
+			return fmt.Errorf("payment.webhook.signature_tolerance_seconds must be positive")
+		}
+	}
+	if c.Payment.Mock.Enabled && c.Server.Mode == "release" {
+		return fmt.Errorf("payment.mock.enabled must be false in release mode")
+	}
+	if c.Gateway.CircuitBreaker.Enabled {
+		if c.Gateway.CircuitBreaker.WindowSeconds <= 0 {
+			return fmt.Errorf("gateway.circuit_breaker.window_seconds must be positive")
+		}
+		if c.Gateway.CircuitBreaker.MinRequests <= 0 {
+			return fmt.Errorf("gateway.circuit_breaker.min_requests must be positive")
+		}
+		if c.Gateway.CircuitBreaker.FailureRatio <= 0 || c.Gateway.CircuitBreaker.FailureRatio > 1 {
+			return fmt.Errorf("gateway.circuit_breaker.failure_ratio must be in (0, 1]")
+		}
+		if c.Gateway.CircuitBreaker.OpenSeconds <= 0 {
+			return fmt.Errorf("gateway.circuit_breaker.open_seconds must be positive")
+		}
+		if c.Gateway.CircuitBreaker.ProbeIntervalSeconds <= 0 {
+			return fmt.Errorf("gateway.circuit_breaker.probe_interval_seconds must be positive")
+		}
+		if c.Gateway.CircuitBreaker.HalfOpenSuccesses <= 0 {
+			return fmt.Errorf("gateway.circuit_breaker.half_open_successes must be positive")
+		}
+	}
+	if c.Gateway.FairQueue.Enabled {
+		if c.Gateway.FairQueue.StaleSeconds <= 0 {
+			return fmt.Errorf("gateway.fair_queue.stale_seconds must be positive")
+		}
+		if c.Gateway.FairQueue.MaxWeight <= 0 {
+			return fmt.Errorf("gateway.fair_queue.max_weight must be positive")
+		}
+		if c.Gateway.FairQueue.SubscriptionWeightMultiplier <= 0 {
+			return fmt.Errorf("gateway.fair_queue.subscription_weight_multiplier must be positive")
+		}
+	}
+	if c.Gateway.Hedging.Enabled {
+		if c.Gateway.Hedging.Percentile <= 0 || c.Gateway.Hedging.Percentile >= 100 {
+			return fmt.Errorf("gateway.hedging.percentile must be between 0 and 100")
+		}
+		if c.Gateway.Hedging.MinDelayMs <= 0 {
+			return fmt.Errorf("gateway.hedging.min_delay_ms must be positive")
+		}
+		if c.Gateway.Hedging.MaxDelayMs < c.Gateway.Hedging.MinDelayMs {
+			return fmt.Errorf("gateway.hedging.max_delay_ms must be >= min_delay_ms")
+		}
+		if c.Gateway.Hedging.WindowSize <= 0 {
+			return fmt.Errorf("gateway.hedging.window_size must be positive")
+		}
+		if c.Gateway.Hedging.MinSamples <= 0 || c.Gateway.Hedging.MinSamples > c.Gateway.Hedging.WindowSize {
+			return fmt.Errorf("gateway.hedging.min_samples must be between 1 and window_size")
+		}
+		if c.Gateway.Hedging.MaxOutputTokens < 0 {
+			return fmt.Errorf("gateway.hedging.max_output_tokens must be non-negative")
+		}
+	}
 	if c.Gateway.Scheduling.StickySessionMaxWaiting <= 0 {
 		return fmt.Errorf("gateway.scheduling.sticky_session_max_waiting must be positive")
 	}
@@ -1177,6 +1661,17 @@ func (c *Config) Validate() error {
 	if c.Gateway.Scheduling.FallbackMaxWaiting <= 0 {
 		return fmt.Errorf("gateway.scheduling.fallback_max_waiting must be positive")
 	}
+	switch c.Gateway.Scheduling.FallbackSelectionMode {
+	case "", "last_used", "random", "least_latency", "p2c":
+	default:
+		return fmt.Errorf("gateway.scheduling.fallback_selection_mode must be one of last_used/random/least_latency/p2c")
+	}
+	if c.Gateway.Scheduling.ScoringEWMAAlpha < 0 || c.Gateway.Scheduling.ScoringEWMAAlpha > 1 {
+		return fmt.Errorf("gateway.scheduling.scoring_ewma_alpha must be between 0 and 1")
+	}
+	if c.Gateway.Scheduling.ScoringErrorRateWeight < 0 {
+		return fmt.Errorf("gateway.scheduling.scoring_error_rate_weight must be non-negative")
+	}
 	if c.Gateway.Scheduling.SlotCleanupInterval < 0 {
 		return fmt.Errorf("gateway.scheduling.slot_cleanup_interval must be non-negative")
 	}
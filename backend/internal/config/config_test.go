@@ -306,6 +306,44 @@ func TestLoadDefaultUsageCleanupConfig(t *testing.T) {
 	}
 }
 
+func TestLoadDefaultCountTokensConfig(t *testing.T) {
+	viper.Reset()
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+
+	if cfg.Gateway.CountTokens.Mode != CountTokensModeUpstream {
+		t.Fatalf("Gateway.CountTokens.Mode = %q, want %q", cfg.Gateway.CountTokens.Mode, CountTokensModeUpstream)
+	}
+	if cfg.Gateway.CountTokens.DriftSampleRate != 0 {
+		t.Fatalf("Gateway.CountTokens.DriftSampleRate = %v, want 0", cfg.Gateway.CountTokens.DriftSampleRate)
+	}
+}
+
+func TestValidateCountTokensConfig(t *testing.T) {
+	viper.Reset()
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+
+	cfg.Gateway.CountTokens.Mode = "remote"
+	err = cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "gateway.count_tokens.mode") {
+		t.Fatalf("Validate() expected count_tokens.mode error, got: %v", err)
+	}
+
+	cfg.Gateway.CountTokens.Mode = CountTokensModeFallback
+	cfg.Gateway.CountTokens.DriftSampleRate = 1.5
+	err = cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "gateway.count_tokens.drift_sample_rate") {
+		t.Fatalf("Validate() expected drift_sample_rate error, got: %v", err)
+	}
+}
+
 func TestValidateUsageCleanupConfigEnabled(t *testing.T) {
 	viper.Reset()
 
@@ -850,3 +888,39 @@ func TestValidateConfigErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestValidatePaymentConfig(t *testing.T) {
+	viper.Reset()
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+
+	cfg.Payment.Enabled = true
+	cfg.Payment.Webhook.Enabled = true
+	cfg.Payment.Webhook.CheckoutURL = "https://pay.example.com/checkout?order={order_no}"
+	err = cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "payment.webhook.secret") {
+		t.Fatalf("Validate() expected payment.webhook.secret error, got: %v", err)
+	}
+
+	cfg.Payment.Webhook.Secret = "secret"
+	cfg.Payment.SubscriptionPlans = []PaymentSubscriptionPlan{{ID: "pro", GroupID: 1, ValidityDays: 30, Price: 0}}
+	err = cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "payment.subscription_plans[0].price") {
+		t.Fatalf("Validate() expected subscription plan price error, got: %v", err)
+	}
+
+	cfg.Payment.SubscriptionPlans[0].Price = 20
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() unexpected error: %v", err)
+	}
+
+	cfg.Payment.Mock.Enabled = true
+	cfg.Server.Mode = "release"
+	err = cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "payment.mock.enabled") {
+		t.Fatalf("Validate() expected payment.mock.enabled error in release mode, got: %v", err)
+	}
+}
@@ -46,6 +46,7 @@ type AccountHandler struct {
 	crsSyncService          *service.CRSSyncService
 	sessionLimitCache       service.SessionLimitCache
 	tokenCacheInvalidator   service.TokenCacheInvalidator
+	healthCheckService      *service.AccountHealthCheckService
 }
 
 // NewAccountHandler creates a new admin account handler
@@ -62,6 +63,7 @@ func NewAccountHandler(
 	crsSyncService *service.CRSSyncService,
 	sessionLimitCache service.SessionLimitCache,
 	tokenCacheInvalidator service.TokenCacheInvalidator,
+	healthCheckService *service.AccountHealthCheckService,
 ) *AccountHandler {
 	return &AccountHandler{
 		adminService:            adminService,
@@ -76,44 +78,49 @@ func NewAccountHandler(
 		crsSyncService:          crsSyncService,
 		sessionLimitCache:       sessionLimitCache,
 		tokenCacheInvalidator:   tokenCacheInvalidator,
+		healthCheckService:      healthCheckService,
 	}
 }
 
 // CreateAccountRequest represents create account request
 type CreateAccountRequest struct {
-	Name                    string         `json:"name" binding:"required"`
-	Notes                   *string        `json:"notes"`
-	Platform                string         `json:"platform" binding:"required"`
-	Type                    string         `json:"type" binding:"required,oneof=oauth setup-token apikey"`
-	Credentials             map[string]any `json:"credentials" binding:"required"`
-	Extra                   map[string]any `json:"extra"`
-	ProxyID                 *int64         `json:"proxy_id"`
-	Concurrency             int            `json:"concurrency"`
-	Priority                int            `json:"priority"`
-	RateMultiplier          *float64       `json:"rate_multiplier"`
-	GroupIDs                []int64        `json:"group_ids"`
-	ExpiresAt               *int64         `json:"expires_at"`
-	AutoPauseOnExpired      *bool          `json:"auto_pause_on_expired"`
-	ConfirmMixedChannelRisk *bool          `json:"confirm_mixed_channel_risk"` // 用户确认混合渠道风险
+	Name                    string                        `json:"name" binding:"required"`
+	Notes                   *string                       `json:"notes"`
+	Platform                string                        `json:"platform" binding:"required"`
+	Type                    string                        `json:"type" binding:"required,oneof=oauth setup-token apikey"`
+	Credentials             map[string]any                `json:"credentials" binding:"required"`
+	Extra                   map[string]any                `json:"extra"`
+	ProxyID                 *int64                        `json:"proxy_id"`
+	Concurrency             int                           `json:"concurrency"`
+	Priority                int                           `json:"priority"`
+	RateMultiplier          *float64                      `json:"rate_multiplier"`
+	GroupIDs                []int64                       `json:"group_ids"`
+	Tags                    []string                      `json:"tags"`
+	AvailabilitySchedule    *service.AvailabilitySchedule `json:"availability_schedule"`
+	ExpiresAt               *int64                        `json:"expires_at"`
+	AutoPauseOnExpired      *bool                         `json:"auto_pause_on_expired"`
+	ConfirmMixedChannelRisk *bool                         `json:"confirm_mixed_channel_risk"` // 用户确认混合渠道风险
 }
 
 // UpdateAccountRequest represents update account request
 // 使用指针类型来区分"未提供"和"设置为0"
 type UpdateAccountRequest struct {
-	Name                    string         `json:"name"`
-	Notes                   *string        `json:"notes"`
-	Type                    string         `json:"type" binding:"omitempty,oneof=oauth setup-token apikey"`
-	Credentials             map[string]any `json:"credentials"`
-	Extra                   map[string]any `json:"extra"`
-	ProxyID                 *int64         `json:"proxy_id"`
-	Concurrency             *int           `json:"concurrency"`
-	Priority                *int           `json:"priority"`
-	RateMultiplier          *float64       `json:"rate_multiplier"`
-	Status                  string         `json:"status" binding:"omitempty,oneof=active inactive"`
-	GroupIDs                *[]int64       `json:"group_ids"`
-	ExpiresAt               *int64         `json:"expires_at"`
-	AutoPauseOnExpired      *bool          `json:"auto_pause_on_expired"`
-	ConfirmMixedChannelRisk *bool          `json:"confirm_mixed_channel_risk"` // 用户确认混合渠道风险
+	Name                    string                        `json:"name"`
+	Notes                   *string                       `json:"notes"`
+	Type                    string                        `json:"type" binding:"omitempty,oneof=oauth setup-token apikey"`
+	Credentials             map[string]any                `json:"credentials"`
+	Extra                   map[string]any                `json:"extra"`
+	ProxyID                 *int64                        `json:"proxy_id"`
+	Concurrency             *int                          `json:"concurrency"`
+	Priority                *int                          `json:"priority"`
+	RateMultiplier          *float64                      `json:"rate_multiplier"`
+	Status                  string                        `json:"status" binding:"omitempty,oneof=active inactive"`
+	GroupIDs                *[]int64                      `json:"group_ids"`
+	Tags                    *[]string                     `json:"tags"`
+	AvailabilitySchedule    *service.AvailabilitySchedule `json:"availability_schedule"` // windows 为空表示清除
+	ExpiresAt               *int64                        `json:"expires_at"`
+	AutoPauseOnExpired      *bool                         `json:"auto_pause_on_expired"`
+	ConfirmMixedChannelRisk *bool                         `json:"confirm_mixed_channel_risk"` // 用户确认混合渠道风险
 }
 
 // BulkUpdateAccountsRequest represents the payload for bulk editing accounts
@@ -127,6 +134,8 @@ type BulkUpdateAccountsRequest struct {
 	Status                  string         `json:"status" binding:"omitempty,oneof=active inactive error"`
 	Schedulable             *bool          `json:"schedulable"`
 	GroupIDs                *[]int64       `json:"group_ids"`
+	AddTags                 []string       `json:"add_tags"`
+	RemoveTags              []string       `json:"remove_tags"`
 	Credentials             map[string]any `json:"credentials"`
 	Extra                   map[string]any `json:"extra"`
 	ConfirmMixedChannelRisk *bool          `json:"confirm_mixed_channel_risk"` // 用户确认混合渠道风险
@@ -305,6 +314,8 @@ func (h *AccountHandler) Create(c *gin.Context) {
 		Priority:              req.Priority,
 		RateMultiplier:        req.RateMultiplier,
 		GroupIDs:              req.GroupIDs,
+		Tags:                  req.Tags,
+		AvailabilitySchedule:  req.AvailabilitySchedule,
 		ExpiresAt:             req.ExpiresAt,
 		AutoPauseOnExpired:    req.AutoPauseOnExpired,
 		SkipMixedChannelCheck: skipCheck,
@@ -369,6 +380,8 @@ func (h *AccountHandler) Update(c *gin.Context) {
 		RateMultiplier:        req.RateMultiplier,
 		Status:                req.Status,
 		GroupIDs:              req.GroupIDs,
+		Tags:                  req.Tags,
+		AvailabilitySchedule:  req.AvailabilitySchedule,
 		ExpiresAt:             req.ExpiresAt,
 		AutoPauseOnExpired:    req.AutoPauseOnExpired,
 		SkipMixedChannelCheck: skipCheck,
@@ -658,6 +671,39 @@ func (h *AccountHandler) GetStats(c *gin.Context) {
 	response.Success(c, stats)
 }
 
+// GetHealthChecks handles getting account health check timeline
+// GET /api/v1/admin/accounts/:id/health-checks
+func (h *AccountHandler) GetHealthChecks(c *gin.Context) {
+	accountID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid account ID")
+		return
+	}
+
+	// Parse hours parameter (default 24, max 7 days)
+	hours := 24
+	if hoursStr := c.Query("hours"); hoursStr != "" {
+		if v, err := strconv.Atoi(hoursStr); err == nil && v > 0 && v <= 168 {
+			hours = v
+		}
+	}
+	limit := 200
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if v, err := strconv.Atoi(limitStr); err == nil && v > 0 && v <= 1000 {
+			limit = v
+		}
+	}
+
+	since := time.Now().Add(-time.Duration(hours) * time.Hour)
+	timeline, err := h.healthCheckService.GetTimeline(c.Request.Context(), accountID, since, limit)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, timeline)
+}
+
 // ClearError handles clearing account error
 // POST /api/v1/admin/accounts/:id/clear-error
 func (h *AccountHandler) ClearError(c *gin.Context) {
@@ -816,6 +862,8 @@ func (h *AccountHandler) BulkUpdate(c *gin.Context) {
 		req.Status != "" ||
 		req.Schedulable != nil ||
 		req.GroupIDs != nil ||
+		len(req.AddTags) > 0 ||
+		len(req.RemoveTags) > 0 ||
 		len(req.Credentials) > 0 ||
 		len(req.Extra) > 0
 
@@ -834,6 +882,8 @@ func (h *AccountHandler) BulkUpdate(c *gin.Context) {
 		Status:                req.Status,
 		Schedulable:           req.Schedulable,
 		GroupIDs:              req.GroupIDs,
+		AddTags:               req.AddTags,
+		RemoveTags:            req.RemoveTags,
 		Credentials:           req.Credentials,
 		Extra:                 req.Extra,
 		SkipMixedChannelCheck: skipCheck,
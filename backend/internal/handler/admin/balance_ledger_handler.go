@@ -0,0 +1,96 @@
+package admin
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// balanceReconcileRequestTimeout 手动触发对账的超时
+const balanceReconcileRequestTimeout = 5 * time.Minute
+
+// BalanceLedgerHandler handles admin balance ledger and reconciliation
+type BalanceLedgerHandler struct {
+	ledgerService *service.BalanceLedgerService
+}
+
+// NewBalanceLedgerHandler creates a new admin balance ledger handler
+func NewBalanceLedgerHandler(ledgerService *service.BalanceLedgerService) *BalanceLedgerHandler {
+	return &BalanceLedgerHandler{
+		ledgerService: ledgerService,
+	}
+}
+
+// ListUserEntries handles listing a user's balance ledger
+// GET /api/v1/admin/users/:id/balance-ledger
+// Query: page, page_size, entry_type, start_date, end_date (YYYY-MM-DD), timezone
+func (h *BalanceLedgerHandler) ListUserEntries(c *gin.Context) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID")
+		return
+	}
+
+	page, pageSize := response.ParsePagination(c)
+	filters := service.BalanceLedgerFilters{EntryType: c.Query("entry_type")}
+
+	userTZ := c.Query("timezone")
+	if startDateStr := c.Query("start_date"); startDateStr != "" {
+		t, err := timezone.ParseInUserLocation("2006-01-02", startDateStr, userTZ)
+		if err != nil {
+			response.BadRequest(c, "Invalid start_date format, use YYYY-MM-DD")
+			return
+		}
+		filters.StartTime = &t
+	}
+	if endDateStr := c.Query("end_date"); endDateStr != "" {
+		t, err := timezone.ParseInUserLocation("2006-01-02", endDateStr, userTZ)
+		if err != nil {
+			response.BadRequest(c, "Invalid end_date format, use YYYY-MM-DD")
+			return
+		}
+		t = t.Add(24*time.Hour - time.Nanosecond)
+		filters.EndTime = &t
+	}
+
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	entries, result, err := h.ledgerService.ListStatement(c.Request.Context(), userID, params, filters)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.AdminBalanceLedgerEntry, 0, len(entries))
+	for i := range entries {
+		out = append(out, *dto.BalanceLedgerEntryFromServiceAdmin(&entries[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
+
+// GetReconciliation returns the latest reconciliation report of this instance (null if none has run)
+// GET /api/v1/admin/balance-ledger/reconciliation
+func (h *BalanceLedgerHandler) GetReconciliation(c *gin.Context) {
+	response.Success(c, h.ledgerService.LastReport())
+}
+
+// RunReconciliation runs a reconciliation pass immediately and returns its report
+// POST /api/v1/admin/balance-ledger/reconciliation/run
+func (h *BalanceLedgerHandler) RunReconciliation(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), balanceReconcileRequestTimeout)
+	defer cancel()
+
+	report, err := h.ledgerService.Reconcile(ctx)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, report)
+}
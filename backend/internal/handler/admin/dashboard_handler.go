@@ -16,14 +16,16 @@ import (
 type DashboardHandler struct {
 	dashboardService   *service.DashboardService
 	aggregationService *service.DashboardAggregationService
+	promptCacheService *service.PromptCacheStatsService
 	startTime          time.Time // Server start time for uptime calculation
 }
 
 // NewDashboardHandler creates a new admin dashboard handler
-func NewDashboardHandler(dashboardService *service.DashboardService, aggregationService *service.DashboardAggregationService) *DashboardHandler {
+func NewDashboardHandler(dashboardService *service.DashboardService, aggregationService *service.DashboardAggregationService, promptCacheService *service.PromptCacheStatsService) *DashboardHandler {
 	return &DashboardHandler{
 		dashboardService:   dashboardService,
 		aggregationService: aggregationService,
+		promptCacheService: promptCacheService,
 		startTime:          time.Now(),
 	}
 }
@@ -415,3 +417,57 @@ func (h *DashboardHandler) GetBatchAPIKeysUsage(c *gin.Context) {
 
 	response.Success(c, gin.H{"stats": stats})
 }
+
+// parsePromptCacheStatsFilter parses time range, group_id, account_id and limit for prompt cache stats
+func parsePromptCacheStatsFilter(c *gin.Context) service.PromptCacheStatsFilter {
+	startTime, endTime := parseTimeRange(c)
+	filter := service.PromptCacheStatsFilter{StartTime: startTime, EndTime: endTime}
+	if id, err := strconv.ParseInt(c.Query("group_id"), 10, 64); err == nil {
+		filter.GroupID = id
+	}
+	if id, err := strconv.ParseInt(c.Query("account_id"), 10, 64); err == nil {
+		filter.AccountID = id
+	}
+	if limit, err := strconv.Atoi(c.Query("limit")); err == nil {
+		filter.Limit = limit
+	}
+	return filter
+}
+
+// GetPromptCacheStats handles getting per-account prompt cache hit statistics
+// GET /api/v1/admin/dashboard/cache-stats
+// Query params: start_date, end_date (YYYY-MM-DD), group_id, account_id, limit (default 50)
+func (h *DashboardHandler) GetPromptCacheStats(c *gin.Context) {
+	filter := parsePromptCacheStatsFilter(c)
+
+	stats, err := h.promptCacheService.GetAccountStats(c.Request.Context(), filter)
+	if err != nil {
+		response.Error(c, 500, "Failed to get prompt cache statistics")
+		return
+	}
+
+	response.Success(c, gin.H{
+		"accounts":   stats,
+		"start_date": filter.StartTime.Format("2006-01-02"),
+		"end_date":   filter.EndTime.Add(-24 * time.Hour).Format("2006-01-02"),
+	})
+}
+
+// GetPromptCacheSessionStats handles getting per-session prompt cache hit statistics
+// GET /api/v1/admin/dashboard/cache-stats/sessions
+// Query params: start_date, end_date (YYYY-MM-DD), group_id, account_id, limit (default 50)
+func (h *DashboardHandler) GetPromptCacheSessionStats(c *gin.Context) {
+	filter := parsePromptCacheStatsFilter(c)
+
+	stats, err := h.promptCacheService.GetSessionStats(c.Request.Context(), filter)
+	if err != nil {
+		response.Error(c, 500, "Failed to get prompt cache session statistics")
+		return
+	}
+
+	response.Success(c, gin.H{
+		"sessions":   stats,
+		"start_date": filter.StartTime.Format("2006-01-02"),
+		"end_date":   filter.EndTime.Add(-24 * time.Hour).Format("2006-01-02"),
+	})
+}
@@ -13,13 +13,15 @@ import (
 
 // GroupHandler handles admin group management
 type GroupHandler struct {
-	adminService service.AdminService
+	adminService        service.AdminService
+	modelCatalogService *service.ModelCatalogService
 }
 
 // NewGroupHandler creates a new admin group handler
-func NewGroupHandler(adminService service.AdminService) *GroupHandler {
+func NewGroupHandler(adminService service.AdminService, modelCatalogService *service.ModelCatalogService) *GroupHandler {
 	return &GroupHandler{
-		adminService: adminService,
+		adminService:        adminService,
+		modelCatalogService: modelCatalogService,
 	}
 }
 
@@ -43,6 +45,17 @@ type CreateGroupRequest struct {
 	// 模型路由配置（仅 anthropic 平台使用）
 	ModelRouting        map[string][]int64 `json:"model_routing"`
 	ModelRoutingEnabled bool               `json:"model_routing_enabled"`
+	// 模型路由标签选择器（模型模式 -> 账号标签选择器，如 "tier:max,region:us"）
+	ModelRoutingTags map[string]string `json:"model_routing_tags"`
+	// 账号标签选择器：匹配的账号自动加入该分组
+	AccountTagSelector string `json:"account_tag_selector"`
+	// 成本优先路由策略（空表示关闭）
+	CostRoutingPolicy string `json:"cost_routing_policy" binding:"omitempty,oneof=lowest_cost"`
+	// 模型映射配置（Gemini 原生 API 转发到 anthropic/openai 平台时使用）
+	ModelMapping map[string]string `json:"model_mapping"`
+	// 粘性会话策略与 TTL（秒，0 表示默认值）
+	StickyPolicy     string `json:"sticky_policy" binding:"omitempty,oneof=content_hash metadata_user_id api_key none"`
+	StickyTTLSeconds int    `json:"sticky_ttl_seconds" binding:"min=0,max=604800"`
 }
 
 // UpdateGroupRequest represents update group request
@@ -66,6 +79,17 @@ type UpdateGroupRequest struct {
 	// 模型路由配置（仅 anthropic 平台使用）
 	ModelRouting        map[string][]int64 `json:"model_routing"`
 	ModelRoutingEnabled *bool              `json:"model_routing_enabled"`
+	// 模型路由标签选择器（传入空对象表示清除）
+	ModelRoutingTags map[string]string `json:"model_routing_tags"`
+	// 账号标签选择器（传入空字符串表示清除）
+	AccountTagSelector *string `json:"account_tag_selector"`
+	// 成本优先路由策略（传入空字符串表示关闭）
+	CostRoutingPolicy *string `json:"cost_routing_policy" binding:"omitempty,oneof='' lowest_cost"`
+	// 模型映射配置（传入空对象表示清除）
+	ModelMapping map[string]string `json:"model_mapping"`
+	// 粘性会话策略与 TTL（秒，0 表示默认值）
+	StickyPolicy     string `json:"sticky_policy" binding:"omitempty,oneof=content_hash metadata_user_id api_key none"`
+	StickyTTLSeconds *int   `json:"sticky_ttl_seconds" binding:"omitempty,min=0,max=604800"`
 }
 
 // List handles listing all groups with pagination
@@ -171,6 +195,12 @@ func (h *GroupHandler) Create(c *gin.Context) {
 		FallbackGroupID:     req.FallbackGroupID,
 		ModelRouting:        req.ModelRouting,
 		ModelRoutingEnabled: req.ModelRoutingEnabled,
+		ModelRoutingTags:    req.ModelRoutingTags,
+		AccountTagSelector:  req.AccountTagSelector,
+		CostRoutingPolicy:   req.CostRoutingPolicy,
+		ModelMapping:        req.ModelMapping,
+		StickyPolicy:        req.StickyPolicy,
+		StickyTTLSeconds:    req.StickyTTLSeconds,
 	})
 	if err != nil {
 		response.ErrorFrom(c, err)
@@ -213,6 +243,12 @@ func (h *GroupHandler) Update(c *gin.Context) {
 		FallbackGroupID:     req.FallbackGroupID,
 		ModelRouting:        req.ModelRouting,
 		ModelRoutingEnabled: req.ModelRoutingEnabled,
+		ModelRoutingTags:    req.ModelRoutingTags,
+		AccountTagSelector:  req.AccountTagSelector,
+		CostRoutingPolicy:   req.CostRoutingPolicy,
+		ModelMapping:        req.ModelMapping,
+		StickyPolicy:        req.StickyPolicy,
+		StickyTTLSeconds:    req.StickyTTLSeconds,
 	})
 	if err != nil {
 		response.ErrorFrom(c, err)
@@ -259,6 +295,34 @@ func (h *GroupHandler) GetStats(c *gin.Context) {
 	_ = groupID // TODO: implement actual stats
 }
 
+// GetModels 返回分组实际可路由的模型目录（含来源、账号与基础/实际价格）
+// GET /api/v1/admin/groups/:id/models
+func (h *GroupHandler) GetModels(c *gin.Context) {
+	groupID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid group ID")
+		return
+	}
+
+	group, err := h.adminService.GetGroup(c.Request.Context(), groupID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	models, err := h.modelCatalogService.ListModels(c.Request.Context(), group, "")
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{
+		"group_id":        group.ID,
+		"platform":        group.Platform,
+		"rate_multiplier": group.RateMultiplier,
+		"models":          models,
+	})
+}
+
 // GetGroupAPIKeys handles getting API keys in a group
 // GET /api/v1/admin/groups/:id/api-keys
 func (h *GroupHandler) GetGroupAPIKeys(c *gin.Context) {
@@ -241,3 +241,33 @@ func parseOpsQueryMode(c *gin.Context) service.OpsQueryMode {
 	}
 	return service.ParseOpsQueryMode(raw)
 }
+
+// GetTokenCountDrift returns local count_tokens estimate drift against upstream results.
+// GET /api/v1/admin/ops/token-count-drift?hours=24
+func (h *OpsHandler) GetTokenCountDrift(c *gin.Context) {
+	if h.opsService == nil {
+		response.Error(c, http.StatusServiceUnavailable, "Ops service not available")
+		return
+	}
+	if err := h.opsService.RequireMonitoringEnabled(c.Request.Context()); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	hours := 24
+	if v := strings.TrimSpace(c.Query("hours")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 168 {
+			response.BadRequest(c, "Invalid hours (must be 1-168)")
+			return
+		}
+		hours = n
+	}
+
+	data, err := h.tokenCountEstimator.GetDriftReport(c.Request.Context(), hours)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, data)
+}
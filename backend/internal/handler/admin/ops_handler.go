@@ -16,7 +16,8 @@ import (
 )
 
 type OpsHandler struct {
-	opsService *service.OpsService
+	opsService          *service.OpsService
+	tokenCountEstimator *service.TokenCountEstimator
 }
 
 // GetErrorLogByID returns ops error log detail.
@@ -70,8 +71,8 @@ func parseOpsViewParam(c *gin.Context) string {
 	}
 }
 
-func NewOpsHandler(opsService *service.OpsService) *OpsHandler {
-	return &OpsHandler{opsService: opsService}
+func NewOpsHandler(opsService *service.OpsService, tokenCountEstimator *service.TokenCountEstimator) *OpsHandler {
+	return &OpsHandler{opsService: opsService, tokenCountEstimator: tokenCountEstimator}
 }
 
 // GetErrorLogs lists ops error logs.
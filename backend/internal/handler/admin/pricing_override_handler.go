@@ -0,0 +1,161 @@
+package admin
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PricingOverrideHandler handles admin-managed custom pricing
+type PricingOverrideHandler struct {
+	overrideService *service.PricingOverrideService
+}
+
+// NewPricingOverrideHandler creates a new admin pricing override handler
+func NewPricingOverrideHandler(overrideService *service.PricingOverrideService) *PricingOverrideHandler {
+	return &PricingOverrideHandler{
+		overrideService: overrideService,
+	}
+}
+
+// PricingOverrideRequest represents create/update pricing override request.
+// Token prices are USD per million tokens and image_price is USD per image (null keeps default image pricing);
+// group_id null means global; effective_from defaults to now on create.
+type PricingOverrideRequest struct {
+	GroupID            *int64     `json:"group_id"`
+	ModelPattern       string     `json:"model_pattern" binding:"required"`
+	InputPrice         float64    `json:"input_price" binding:"min=0"`
+	OutputPrice        float64    `json:"output_price" binding:"min=0"`
+	CacheCreationPrice float64    `json:"cache_creation_price" binding:"min=0"`
+	CacheReadPrice     float64    `json:"cache_read_price" binding:"min=0"`
+	ImagePrice         *float64   `json:"image_price" binding:"omitempty,min=0"`
+	EffectiveFrom      *time.Time `json:"effective_from"`
+	Notes              string     `json:"notes"`
+}
+
+func (r *PricingOverrideRequest) toService() *service.PricingOverride {
+	o := &service.PricingOverride{
+		GroupID:            r.GroupID,
+		ModelPattern:       r.ModelPattern,
+		InputPrice:         r.InputPrice,
+		OutputPrice:        r.OutputPrice,
+		CacheCreationPrice: r.CacheCreationPrice,
+		CacheReadPrice:     r.CacheReadPrice,
+		ImagePrice:         r.ImagePrice,
+		Notes:              r.Notes,
+	}
+	if r.EffectiveFrom != nil {
+		o.EffectiveFrom = *r.EffectiveFrom
+	}
+	return o
+}
+
+// List handles listing pricing overrides, including scheduled versions
+// GET /api/v1/admin/pricing-overrides
+// Query: group_id (omit for all, "global" for global only), model
+func (h *PricingOverrideHandler) List(c *gin.Context) {
+	filters := service.PricingOverrideFilters{Model: c.Query("model")}
+	if groupIDStr := c.Query("group_id"); groupIDStr != "" {
+		if groupIDStr == "global" {
+			filters.GlobalOnly = true
+		} else {
+			groupID, err := strconv.ParseInt(groupIDStr, 10, 64)
+			if err != nil {
+				response.BadRequest(c, "Invalid group_id")
+				return
+			}
+			filters.GroupID = &groupID
+		}
+	}
+
+	overrides, err := h.overrideService.List(c.Request.Context(), filters)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.PricingOverride, 0, len(overrides))
+	for i := range overrides {
+		out = append(out, *dto.PricingOverrideFromService(&overrides[i]))
+	}
+	response.Success(c, out)
+}
+
+// GetByID handles getting a pricing override
+// GET /api/v1/admin/pricing-overrides/:id
+func (h *PricingOverrideHandler) GetByID(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid pricing override ID")
+		return
+	}
+
+	override, err := h.overrideService.GetByID(c.Request.Context(), id)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.PricingOverrideFromService(override))
+}
+
+// Create handles creating a pricing override version
+// POST /api/v1/admin/pricing-overrides
+func (h *PricingOverrideHandler) Create(c *gin.Context) {
+	var req PricingOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	override := req.toService()
+	if err := h.overrideService.Create(c.Request.Context(), override); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.PricingOverrideFromService(override))
+}
+
+// Update handles updating a pricing override that is not yet in effect
+// PUT /api/v1/admin/pricing-overrides/:id
+func (h *PricingOverrideHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid pricing override ID")
+		return
+	}
+
+	var req PricingOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	override := req.toService()
+	override.ID = id
+	if err := h.overrideService.Update(c.Request.Context(), override); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.PricingOverrideFromService(override))
+}
+
+// Delete handles deleting a pricing override (stops it from applying to new usage)
+// DELETE /api/v1/admin/pricing-overrides/:id
+func (h *PricingOverrideHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid pricing override ID")
+		return
+	}
+
+	if err := h.overrideService.Delete(c.Request.Context(), id); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"message": "Pricing override deleted successfully"})
+}
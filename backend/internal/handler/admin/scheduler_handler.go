@@ -0,0 +1,81 @@
+package admin
+
+import (
+	"strconv"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SchedulerHandler handles admin scheduler diagnostics
+type SchedulerHandler struct {
+	explainService     *service.SchedulerExplainService
+	costRoutingService *service.CostRoutingReportService
+}
+
+// NewSchedulerHandler creates a new admin scheduler handler
+func NewSchedulerHandler(explainService *service.SchedulerExplainService, costRoutingService *service.CostRoutingReportService) *SchedulerHandler {
+	return &SchedulerHandler{
+		explainService:     explainService,
+		costRoutingService: costRoutingService,
+	}
+}
+
+// SchedulerExplainRequest represents scheduler dry-run request
+type SchedulerExplainRequest struct {
+	GroupID          *int64  `json:"group_id"`
+	APIKeyID         *int64  `json:"api_key_id"` // 优先于 group_id，使用该 Key 绑定的分组
+	Platform         string  `json:"platform" binding:"omitempty,oneof=anthropic openai gemini antigravity"`
+	Model            string  `json:"model"`
+	SessionHash      string  `json:"session_hash"`
+	ExcludedIDs      []int64 `json:"excluded_account_ids"`
+	ClaudeCodeClient bool    `json:"claude_code_client"`
+}
+
+// Explain handles scheduler dry-run: evaluates every candidate account without acquiring slots
+// POST /api/v1/admin/scheduler/explain
+func (h *SchedulerHandler) Explain(c *gin.Context) {
+	var req SchedulerExplainRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	result, err := h.explainService.Explain(c.Request.Context(), service.SchedulerExplainInput{
+		GroupID:          req.GroupID,
+		APIKeyID:         req.APIKeyID,
+		Platform:         req.Platform,
+		Model:            req.Model,
+		SessionHash:      req.SessionHash,
+		ExcludedIDs:      req.ExcludedIDs,
+		ClaudeCodeClient: req.ClaudeCodeClient,
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, result)
+}
+
+// GetCostRoutingReport handles the per-group report of spend shifted by the lowest-cost routing policy
+// GET /api/v1/admin/scheduler/cost-routing/report
+// Query params: group_id (required), start_date, end_date (YYYY-MM-DD, default last 7 days), timezone
+func (h *SchedulerHandler) GetCostRoutingReport(c *gin.Context) {
+	groupID, err := strconv.ParseInt(c.Query("group_id"), 10, 64)
+	if err != nil || groupID <= 0 {
+		response.BadRequest(c, "Invalid group_id")
+		return
+	}
+	startTime, endTime := parseTimeRange(c)
+
+	report, err := h.costRoutingService.GetGroupReport(c.Request.Context(), groupID, startTime, endTime)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, report)
+}
@@ -0,0 +1,56 @@
+package admin
+
+import (
+	"strconv"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TopupHandler handles admin top-up order queries
+type TopupHandler struct {
+	topupService *service.TopupService
+}
+
+// NewTopupHandler creates a new admin top-up handler
+func NewTopupHandler(topupService *service.TopupService) *TopupHandler {
+	return &TopupHandler{
+		topupService: topupService,
+	}
+}
+
+// List handles listing top-up orders
+// GET /api/v1/admin/topup-orders
+// Query: page, page_size, user_id, status, provider
+func (h *TopupHandler) List(c *gin.Context) {
+	page, pageSize := response.ParsePagination(c)
+	filters := service.TopupOrderFilters{
+		Status:   c.Query("status"),
+		Provider: c.Query("provider"),
+	}
+	if userIDStr := c.Query("user_id"); userIDStr != "" {
+		userID, err := strconv.ParseInt(userIDStr, 10, 64)
+		if err != nil {
+			response.BadRequest(c, "Invalid user_id")
+			return
+		}
+		filters.UserID = &userID
+	}
+
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	orders, result, err := h.topupService.ListOrders(c.Request.Context(), params, filters)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.AdminTopupOrder, 0, len(orders))
+	for i := range orders {
+		out = append(out, *dto.TopupOrderFromServiceAdmin(&orders[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
@@ -2,7 +2,9 @@
 package handler
 
 import (
+	"context"
 	"strconv"
+	"time"
 
 	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
@@ -15,23 +17,26 @@ import (
 
 // APIKeyHandler handles API key-related requests
 type APIKeyHandler struct {
-	apiKeyService *service.APIKeyService
+	apiKeyService       *service.APIKeyService
+	billingCacheService *service.BillingCacheService
 }
 
 // NewAPIKeyHandler creates a new APIKeyHandler
-func NewAPIKeyHandler(apiKeyService *service.APIKeyService) *APIKeyHandler {
+func NewAPIKeyHandler(apiKeyService *service.APIKeyService, billingCacheService *service.BillingCacheService) *APIKeyHandler {
 	return &APIKeyHandler{
-		apiKeyService: apiKeyService,
+		apiKeyService:       apiKeyService,
+		billingCacheService: billingCacheService,
 	}
 }
 
 // CreateAPIKeyRequest represents the create API key request payload
 type CreateAPIKeyRequest struct {
-	Name        string   `json:"name" binding:"required"`
-	GroupID     *int64   `json:"group_id"`     // nullable
-	CustomKey   *string  `json:"custom_key"`   // 可选的自定义key
-	IPWhitelist []string `json:"ip_whitelist"` // IP 白名单
-	IPBlacklist []string `json:"ip_blacklist"` // IP 黑名单
+	Name        string               `json:"name" binding:"required"`
+	GroupID     *int64               `json:"group_id"`     // nullable
+	CustomKey   *string              `json:"custom_key"`   // 可选的自定义key
+	IPWhitelist []string             `json:"ip_whitelist"` // IP 白名单
+	IPBlacklist []string             `json:"ip_blacklist"` // IP 黑名单
+	Limits      *APIKeyLimitsRequest `json:"limits"`
 }
 
 // UpdateAPIKeyRequest represents the update API key request payload
@@ -41,6 +46,46 @@ type UpdateAPIKeyRequest struct {
 	Status      string   `json:"status" binding:"omitempty,oneof=active inactive"`
 	IPWhitelist []string `json:"ip_whitelist"` // IP 白名单
 	IPBlacklist []string `json:"ip_blacklist"` // IP 黑名单
+	// Limits replaces all key limits when present; omit to keep the current limits
+	Limits *APIKeyLimitsRequest `json:"limits"`
+}
+
+// APIKeyLimitsRequest represents per-key limits; null/omitted fields mean unlimited
+type APIKeyLimitsRequest struct {
+	ExpiresAt           *time.Time `json:"expires_at"`
+	TotalLimitUSD       *float64   `json:"total_limit_usd" binding:"omitempty,gte=0"`
+	DailyLimitUSD       *float64   `json:"daily_limit_usd" binding:"omitempty,gte=0"`
+	MonthlyLimitUSD     *float64   `json:"monthly_limit_usd" binding:"omitempty,gte=0"`
+	DailyRequestLimit   *int64     `json:"daily_request_limit" binding:"omitempty,gte=0"`
+	MonthlyRequestLimit *int64     `json:"monthly_request_limit" binding:"omitempty,gte=0"`
+	AllowedModels       []string   `json:"allowed_models"`
+	AllowedPlatforms    []string   `json:"allowed_platforms"`
+}
+
+func (r *APIKeyLimitsRequest) toService() service.APIKeyLimits {
+	return service.APIKeyLimits{
+		ExpiresAt:           r.ExpiresAt,
+		TotalLimitUSD:       r.TotalLimitUSD,
+		DailyLimitUSD:       r.DailyLimitUSD,
+		MonthlyLimitUSD:     r.MonthlyLimitUSD,
+		DailyRequestLimit:   r.DailyRequestLimit,
+		MonthlyRequestLimit: r.MonthlyRequestLimit,
+		AllowedModels:       r.AllowedModels,
+		AllowedPlatforms:    r.AllowedPlatforms,
+	}
+}
+
+// apiKeyWithUsage converts a key to DTO and attaches its quota counters when the key has spend/request limits
+func (h *APIKeyHandler) apiKeyWithUsage(ctx context.Context, key *service.APIKey) *dto.APIKey {
+	out := dto.APIKeyFromService(key)
+	if out == nil || h.billingCacheService == nil || !key.Limits.HasQuota() {
+		return out
+	}
+	usage, err := h.billingCacheService.GetAPIKeyUsage(ctx, key.ID)
+	if err == nil {
+		out.Usage = dto.APIKeyUsageFromService(usage)
+	}
+	return out
 }
 
 // List handles listing user's API keys with pagination
@@ -63,7 +108,7 @@ func (h *APIKeyHandler) List(c *gin.Context) {
 
 	out := make([]dto.APIKey, 0, len(keys))
 	for i := range keys {
-		out = append(out, *dto.APIKeyFromService(&keys[i]))
+		out = append(out, *h.apiKeyWithUsage(c.Request.Context(), &keys[i]))
 	}
 	response.Paginated(c, out, result.Total, page, pageSize)
 }
@@ -95,7 +140,7 @@ func (h *APIKeyHandler) GetByID(c *gin.Context) {
 		return
 	}
 
-	response.Success(c, dto.APIKeyFromService(key))
+	response.Success(c, h.apiKeyWithUsage(c.Request.Context(), key))
 }
 
 // Create handles creating a new API key
@@ -120,6 +165,9 @@ func (h *APIKeyHandler) Create(c *gin.Context) {
 		IPWhitelist: req.IPWhitelist,
 		IPBlacklist: req.IPBlacklist,
 	}
+	if req.Limits != nil {
+		svcReq.Limits = req.Limits.toService()
+	}
 	key, err := h.apiKeyService.Create(c.Request.Context(), subject.UserID, svcReq)
 	if err != nil {
 		response.ErrorFrom(c, err)
@@ -161,6 +209,10 @@ func (h *APIKeyHandler) Update(c *gin.Context) {
 	if req.Status != "" {
 		svcReq.Status = &req.Status
 	}
+	if req.Limits != nil {
+		limits := req.Limits.toService()
+		svcReq.Limits = &limits
+	}
 
 	key, err := h.apiKeyService.Update(c.Request.Context(), keyID, subject.UserID, svcReq)
 	if err != nil {
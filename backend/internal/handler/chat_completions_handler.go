@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/openai"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ChatCompletions handles OpenAI Chat Completions compatible endpoint
+// POST /v1/chat/completions
+// 请求转换为 Claude Messages 格式后复用 Messages 处理链（Anthropic/Gemini/Antigravity 账号），
+// 响应再转换回 Chat Completions 格式。
+func (h *GatewayHandler) ChatCompletions(c *gin.Context) {
+	req, ok := readChatCompletionRequest(c)
+	if !ok {
+		return
+	}
+	body, err := openai.ChatToClaudeRequest(req)
+	if err != nil {
+		chatCompletionsError(c, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+	includeUsage := req.StreamOptions != nil && req.StreamOptions.IncludeUsage
+	w := newChatCompletionsWriter(c.Writer, req.Stream,
+		openai.NewClaudeChatStreamConverter(req.Model, includeUsage),
+		func(b []byte) ([]byte, error) { return openai.ClaudeToChatResponse(b, req.Model) },
+	)
+	serveChatCompletions(c, body, w, h.Messages)
+}
+
+// ChatCompletions handles OpenAI Chat Completions compatible endpoint for OpenAI groups
+// POST /v1/chat/completions
+// 请求转换为 Responses API 格式后复用 Responses 处理链，响应再转换回 Chat Completions 格式。
+func (h *OpenAIGatewayHandler) ChatCompletions(c *gin.Context) {
+	req, ok := readChatCompletionRequest(c)
+	if !ok {
+		return
+	}
+	body, err := openai.ChatToResponsesRequest(req)
+	if err != nil {
+		chatCompletionsError(c, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+	includeUsage := req.StreamOptions != nil && req.StreamOptions.IncludeUsage
+	w := newChatCompletionsWriter(c.Writer, req.Stream,
+		openai.NewResponsesChatStreamConverter(req.Model, includeUsage),
+		func(b []byte) ([]byte, error) { return openai.ResponsesToChatResponse(b, req.Model) },
+	)
+	serveChatCompletions(c, body, w, h.Responses)
+}
+
+// readChatCompletionRequest 读取并校验 Chat Completions 请求体，失败时已写出错误响应
+func readChatCompletionRequest(c *gin.Context) (*openai.ChatCompletionRequest, bool) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		if maxErr, ok := extractMaxBytesError(err); ok {
+			chatCompletionsError(c, http.StatusRequestEntityTooLarge, "invalid_request_error", buildBodyTooLargeMessage(maxErr.Limit))
+			return nil, false
+		}
+		chatCompletionsError(c, http.StatusBadRequest, "invalid_request_error", "Failed to read request body")
+		return nil, false
+	}
+	if len(body) == 0 {
+		chatCompletionsError(c, http.StatusBadRequest, "invalid_request_error", "Request body is empty")
+		return nil, false
+	}
+
+	var req openai.ChatCompletionRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		chatCompletionsError(c, http.StatusBadRequest, "invalid_request_error", "Failed to parse request body")
+		return nil, false
+	}
+	if req.Model == "" {
+		chatCompletionsError(c, http.StatusBadRequest, "invalid_request_error", "model is required")
+		return nil, false
+	}
+	if len(req.Messages) == 0 {
+		chatCompletionsError(c, http.StatusBadRequest, "invalid_request_error", "messages is required")
+		return nil, false
+	}
+	return &req, true
+}
+
+// serveChatCompletions 用转换后的请求体替换原请求，并在响应转换写入器下执行原处理链
+func serveChatCompletions(c *gin.Context, body []byte, w *protocolConvertWriter, next gin.HandlerFunc) {
+	serveConverted(c, body, w, next)
+}
+
+// chatCompletionsError 返回 OpenAI 格式的错误响应
+func chatCompletionsError(c *gin.Context, status int, errType, message string) {
+	c.JSON(status, gin.H{
+		"error": gin.H{
+			"type":    errType,
+			"message": message,
+		},
+	})
+}
+
+// newChatCompletionsWriter 创建将响应转换为 Chat Completions 格式的写入器
+func newChatCompletionsWriter(w gin.ResponseWriter, stream bool, converter streamLineConverter, convertBody func([]byte) ([]byte, error)) *protocolConvertWriter {
+	return newProtocolConvertWriter(w, stream, converter, convertBody,
+		func(_ int, body []byte) []byte { return openai.ChatErrorBody(body) },
+		openai.ChatStreamErrorEvent,
+	)
+}
@@ -78,11 +78,42 @@ func APIKeyFromService(k *service.APIKey) *APIKey {
 		IPBlacklist: k.IPBlacklist,
 		CreatedAt:   k.CreatedAt,
 		UpdatedAt:   k.UpdatedAt,
+		Limits:      APIKeyLimitsFromService(k.Limits),
 		User:        UserFromServiceShallow(k.User),
 		Group:       GroupFromServiceShallow(k.Group),
 	}
 }
 
+// APIKeyLimitsFromService 未配置任何限制时返回 nil
+func APIKeyLimitsFromService(l service.APIKeyLimits) *APIKeyLimits {
+	if l.IsZero() {
+		return nil
+	}
+	return &APIKeyLimits{
+		ExpiresAt:           l.ExpiresAt,
+		TotalLimitUSD:       l.TotalLimitUSD,
+		DailyLimitUSD:       l.DailyLimitUSD,
+		MonthlyLimitUSD:     l.MonthlyLimitUSD,
+		DailyRequestLimit:   l.DailyRequestLimit,
+		MonthlyRequestLimit: l.MonthlyRequestLimit,
+		AllowedModels:       l.AllowedModels,
+		AllowedPlatforms:    l.AllowedPlatforms,
+	}
+}
+
+func APIKeyUsageFromService(u *service.APIKeyUsage) *APIKeyUsage {
+	if u == nil {
+		return nil
+	}
+	return &APIKeyUsage{
+		TotalCost:       u.TotalCost,
+		DailyCost:       u.DailyCost,
+		MonthlyCost:     u.MonthlyCost,
+		DailyRequests:   u.DailyRequests,
+		MonthlyRequests: u.MonthlyRequests,
+	}
+}
+
 func GroupFromServiceShallow(g *service.Group) *Group {
 	if g == nil {
 		return nil
@@ -108,6 +139,12 @@ func GroupFromServiceAdmin(g *service.Group) *AdminGroup {
 		Group:               groupFromServiceBase(g),
 		ModelRouting:        g.ModelRouting,
 		ModelRoutingEnabled: g.ModelRoutingEnabled,
+		ModelRoutingTags:    g.ModelRoutingTags,
+		AccountTagSelector:  g.AccountTagSelector,
+		CostRoutingPolicy:   g.CostRoutingPolicy,
+		ModelMapping:        g.ModelMapping,
+		StickyPolicy:        g.EffectiveStickyPolicy(),
+		StickyTTLSeconds:    g.StickyTTLSeconds,
 		AccountCount:        g.AccountCount,
 	}
 	if len(g.AccountGroups) > 0 {
@@ -176,6 +213,10 @@ func AccountFromServiceShallow(a *service.Account) *Account {
 		SessionWindowEnd:        a.SessionWindowEnd,
 		SessionWindowStatus:     a.SessionWindowStatus,
 		GroupIDs:                a.GroupIDs,
+		Tags:                    a.Tags,
+		TagGroupIDs:             a.TagGroupIDs,
+		AvailabilitySchedule:    availabilityScheduleFromService(a.AvailabilitySchedule),
+		InAvailabilityWindow:    a.IsWithinAvailabilitySchedule(time.Now()),
 	}
 
 	// 提取 5h 窗口费用控制和会话数量控制配置（仅 Anthropic OAuth/SetupToken 账号有效）
@@ -204,6 +245,14 @@ func AccountFromServiceShallow(a *service.Account) *Account {
 		}
 	}
 
+	// RPM/TPM 预算（所有平台账号有效）
+	if rpm := a.GetRPMLimit(); rpm > 0 {
+		out.RPMLimit = &rpm
+	}
+	if tpm := a.GetTPMLimit(); tpm > 0 {
+		out.TPMLimit = &tpm
+	}
+
 	return out
 }
 
@@ -229,6 +278,21 @@ func AccountFromService(a *service.Account) *Account {
 	return out
 }
 
+func availabilityScheduleFromService(schedule *service.AvailabilitySchedule) *AvailabilitySchedule {
+	if schedule == nil {
+		return nil
+	}
+	out := &AvailabilitySchedule{
+		Timezone:         schedule.Timezone,
+		Windows:          make([]AvailabilityWindow, 0, len(schedule.Windows)),
+		NextTransitionAt: schedule.NextTransition(time.Now()),
+	}
+	for _, w := range schedule.Windows {
+		out.Windows = append(out.Windows, AvailabilityWindow{Days: w.Days, Start: w.Start, End: w.End})
+	}
+	return out
+}
+
 func timeToUnixSeconds(value *time.Time) *int64 {
 	if value == nil {
 		return nil
@@ -320,6 +384,57 @@ func RedeemCodeFromServiceAdmin(rc *service.RedeemCode) *AdminRedeemCode {
 	}
 }
 
+// BalanceLedgerEntryFromService converts a service BalanceLedgerEntry to DTO for the owning user.
+func BalanceLedgerEntryFromService(e *service.BalanceLedgerEntry) *BalanceLedgerEntry {
+	if e == nil {
+		return nil
+	}
+	out := balanceLedgerEntryFromServiceBase(e)
+	return &out
+}
+
+// BalanceHoldFromService converts a service BalanceHold to DTO.
+func BalanceHoldFromService(h *service.BalanceHold) *BalanceHold {
+	if h == nil {
+		return nil
+	}
+	return &BalanceHold{
+		ID:        h.ID,
+		Model:     h.Model,
+		Amount:    h.Amount,
+		CreatedAt: h.CreatedAt,
+		ExpiresAt: h.ExpiresAt,
+	}
+}
+
+// BalanceLedgerEntryFromServiceAdmin converts a service BalanceLedgerEntry to DTO for admin users.
+// It includes notes and the operator - user-facing endpoints must not use this.
+func BalanceLedgerEntryFromServiceAdmin(e *service.BalanceLedgerEntry) *AdminBalanceLedgerEntry {
+	if e == nil {
+		return nil
+	}
+	return &AdminBalanceLedgerEntry{
+		BalanceLedgerEntry: balanceLedgerEntryFromServiceBase(e),
+		UserID:             e.UserID,
+		AdminID:            e.AdminID,
+		Notes:              e.Notes,
+	}
+}
+
+func balanceLedgerEntryFromServiceBase(e *service.BalanceLedgerEntry) BalanceLedgerEntry {
+	return BalanceLedgerEntry{
+		ID:           e.ID,
+		EntryType:    e.EntryType,
+		Amount:       e.Amount,
+		BalanceAfter: e.BalanceAfter,
+		UsageLogID:   e.UsageLogID,
+		RedeemCodeID: e.RedeemCodeID,
+		PromoCodeID:  e.PromoCodeID,
+		TopupOrderID: e.TopupOrderID,
+		CreatedAt:    e.CreatedAt,
+	}
+}
+
 func redeemCodeFromServiceBase(rc *service.RedeemCode) RedeemCode {
 	return RedeemCode{
 		ID:           rc.ID,
@@ -545,3 +660,96 @@ func PromoCodeUsageFromService(u *service.PromoCodeUsage) *PromoCodeUsage {
 		User:        UserFromServiceShallow(u.User),
 	}
 }
+
+// PricingOverrideFromService converts a service PricingOverride to DTO.
+func PricingOverrideFromService(o *service.PricingOverride) *PricingOverride {
+	if o == nil {
+		return nil
+	}
+	return &PricingOverride{
+		ID:                 o.ID,
+		GroupID:            o.GroupID,
+		ModelPattern:       o.ModelPattern,
+		InputPrice:         o.InputPrice,
+		OutputPrice:        o.OutputPrice,
+		CacheCreationPrice: o.CacheCreationPrice,
+		CacheReadPrice:     o.CacheReadPrice,
+		ImagePrice:         o.ImagePrice,
+		EffectiveFrom:      o.EffectiveFrom,
+		Notes:              o.Notes,
+		CreatedAt:          o.CreatedAt,
+		UpdatedAt:          o.UpdatedAt,
+	}
+}
+
+// TopupOrderFromService converts a service TopupOrder to DTO.
+func TopupOrderFromService(o *service.TopupOrder) *TopupOrder {
+	if o == nil {
+		return nil
+	}
+	out := topupOrderFromServiceBase(o)
+	return &out
+}
+
+// TopupOrderFromServiceAdmin converts a service TopupOrder to DTO for admin users.
+func TopupOrderFromServiceAdmin(o *service.TopupOrder) *AdminTopupOrder {
+	if o == nil {
+		return nil
+	}
+	return &AdminTopupOrder{
+		TopupOrder: topupOrderFromServiceBase(o),
+		UserID:     o.UserID,
+		UpdatedAt:  o.UpdatedAt,
+	}
+}
+
+func topupOrderFromServiceBase(o *service.TopupOrder) TopupOrder {
+	return TopupOrder{
+		ID:              o.ID,
+		OrderNo:         o.OrderNo,
+		OrderType:       o.OrderType,
+		Amount:          o.Amount,
+		Currency:        o.Currency,
+		CreditAmount:    o.CreditAmount,
+		PlanID:          o.PlanID,
+		GroupID:         o.GroupID,
+		ValidityDays:    o.ValidityDays,
+		Provider:        o.Provider,
+		ProviderOrderID: o.ProviderOrderID,
+		PayURL:          o.PayURL,
+		Status:          o.Status,
+		PaidAt:          o.PaidAt,
+		ExpiresAt:       o.ExpiresAt,
+		CreatedAt:       o.CreatedAt,
+	}
+}
+
+// TopupOptionsFromService converts service TopupOptions to DTO.
+func TopupOptionsFromService(o *service.TopupOptions) *TopupOptions {
+	if o == nil {
+		return nil
+	}
+	plans := make([]TopupPlan, 0, len(o.Plans))
+	for _, p := range o.Plans {
+		plans = append(plans, TopupPlan{
+			ID:           p.ID,
+			Name:         p.Name,
+			GroupID:      p.GroupID,
+			ValidityDays: p.ValidityDays,
+			Price:        p.Price,
+		})
+	}
+	providers := o.Providers
+	if providers == nil {
+		providers = []string{}
+	}
+	return &TopupOptions{
+		Enabled:    o.Enabled,
+		Currency:   o.Currency,
+		CreditRate: o.CreditRate,
+		MinAmount:  o.MinAmount,
+		MaxAmount:  o.MaxAmount,
+		Providers:  providers,
+		Plans:      plans,
+	}
+}
@@ -38,10 +38,35 @@ type APIKey struct {
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 
+	// Limits 仅在配置了任一限制时返回；Usage 仅在配置了额度/请求数限制时返回
+	Limits *APIKeyLimits `json:"limits,omitempty"`
+	Usage  *APIKeyUsage  `json:"usage,omitempty"`
+
 	User  *User  `json:"user,omitempty"`
 	Group *Group `json:"group,omitempty"`
 }
 
+// APIKeyLimits API Key 级限额（有效期、消费/请求数上限、模型/平台白名单），null 表示不限制
+type APIKeyLimits struct {
+	ExpiresAt           *time.Time `json:"expires_at"`
+	TotalLimitUSD       *float64   `json:"total_limit_usd"`
+	DailyLimitUSD       *float64   `json:"daily_limit_usd"`
+	MonthlyLimitUSD     *float64   `json:"monthly_limit_usd"`
+	DailyRequestLimit   *int64     `json:"daily_request_limit"`
+	MonthlyRequestLimit *int64     `json:"monthly_request_limit"`
+	AllowedModels       []string   `json:"allowed_models"`
+	AllowedPlatforms    []string   `json:"allowed_platforms"`
+}
+
+// APIKeyUsage API Key 限额计数（累计与当日/当月）
+type APIKeyUsage struct {
+	TotalCost       float64 `json:"total_cost"`
+	DailyCost       float64 `json:"daily_cost"`
+	MonthlyCost     float64 `json:"monthly_cost"`
+	DailyRequests   int64   `json:"daily_requests"`
+	MonthlyRequests int64   `json:"monthly_requests"`
+}
+
 type Group struct {
 	ID             int64   `json:"id"`
 	Name           string  `json:"name"`
@@ -77,6 +102,21 @@ type AdminGroup struct {
 	// 模型路由配置（仅 anthropic 平台使用）
 	ModelRouting        map[string][]int64 `json:"model_routing"`
 	ModelRoutingEnabled bool               `json:"model_routing_enabled"`
+	// 模型路由标签选择器（模型模式 -> 账号标签选择器）
+	ModelRoutingTags map[string]string `json:"model_routing_tags"`
+
+	// 账号标签选择器：匹配的账号自动加入该分组
+	AccountTagSelector string `json:"account_tag_selector"`
+
+	// 成本优先路由策略（空表示关闭）
+	CostRoutingPolicy string `json:"cost_routing_policy"`
+
+	// 模型映射配置（跨协议转发时使用）
+	ModelMapping map[string]string `json:"model_mapping"`
+
+	// 粘性会话策略与 TTL（秒，0 表示默认值）
+	StickyPolicy     string `json:"sticky_policy"`
+	StickyTTLSeconds int    `json:"sticky_ttl_seconds"`
 
 	AccountGroups []AccountGroup `json:"account_groups,omitempty"`
 	AccountCount  int64          `json:"account_count,omitempty"`
@@ -125,6 +165,11 @@ type Account struct {
 	MaxSessions           *int `json:"max_sessions,omitempty"`
 	SessionIdleTimeoutMin *int `json:"session_idle_timeout_minutes,omitempty"`
 
+	// 每分钟请求数 / token 预算（调度时主动跳过已打满的账号）
+	// 从 extra 字段提取，方便前端显示和编辑
+	RPMLimit *int `json:"rpm_limit,omitempty"`
+	TPMLimit *int `json:"tpm_limit,omitempty"`
+
 	// TLS指纹伪装（仅 Anthropic OAuth/SetupToken 账号有效）
 	// 从 extra 字段提取，方便前端显示和编辑
 	EnableTLSFingerprint *bool `json:"enable_tls_fingerprint,omitempty"`
@@ -139,6 +184,28 @@ type Account struct {
 
 	GroupIDs []int64  `json:"group_ids,omitempty"`
 	Groups   []*Group `json:"groups,omitempty"`
+
+	// 账号标签，以及通过标签选择器自动加入的分组
+	Tags        []string `json:"tags"`
+	TagGroupIDs []int64  `json:"tag_group_ids,omitempty"`
+
+	// 可用时间窗口（nil 表示始终可用）及当前是否处于窗口内
+	AvailabilitySchedule *AvailabilitySchedule `json:"availability_schedule"`
+	InAvailabilityWindow bool                  `json:"in_availability_window"`
+}
+
+// AvailabilitySchedule 账号可用时间窗口
+type AvailabilitySchedule struct {
+	Timezone string               `json:"timezone,omitempty"`
+	Windows  []AvailabilityWindow `json:"windows"`
+	// 下一次可用状态切换时间
+	NextTransitionAt *time.Time `json:"next_transition_at,omitempty"`
+}
+
+type AvailabilityWindow struct {
+	Days  []string `json:"days,omitempty"`
+	Start string   `json:"start"`
+	End   string   `json:"end"`
 }
 
 type AccountGroup struct {
@@ -202,6 +269,37 @@ type RedeemCode struct {
 	Group *Group `json:"group,omitempty"`
 }
 
+// BalanceLedgerEntry 余额流水（用户账单）
+type BalanceLedgerEntry struct {
+	ID           int64     `json:"id"`
+	EntryType    string    `json:"entry_type"`
+	Amount       float64   `json:"amount"`
+	BalanceAfter float64   `json:"balance_after"`
+	UsageLogID   *int64    `json:"usage_log_id,omitempty"`
+	RedeemCodeID *int64    `json:"redeem_code_id,omitempty"`
+	PromoCodeID  *int64    `json:"promo_code_id,omitempty"`
+	TopupOrderID *int64    `json:"topup_order_id,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// BalanceHold 进行中请求的余额预授权冻结
+type BalanceHold struct {
+	ID        string    `json:"id"`
+	Model     string    `json:"model"`
+	Amount    float64   `json:"amount"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// AdminBalanceLedgerEntry 是管理员接口使用的余额流水 DTO（包含操作人与备注）。
+// 注意：普通用户接口不得返回 notes 等内部信息。
+type AdminBalanceLedgerEntry struct {
+	BalanceLedgerEntry
+	UserID  int64  `json:"user_id"`
+	AdminID *int64 `json:"admin_id,omitempty"`
+	Notes   string `json:"notes"`
+}
+
 // AdminRedeemCode 是管理员接口使用的 redeem code DTO（包含 notes 等字段）。
 // 注意：普通用户接口不得返回 notes 等内部信息。
 type AdminRedeemCode struct {
@@ -380,3 +478,66 @@ type PromoCodeUsage struct {
 
 	User *User `json:"user,omitempty"`
 }
+
+// PricingOverride 管理员自定义价格（token 价格为 USD / 百万 token，图片价格为 USD / 张）
+type PricingOverride struct {
+	ID                 int64     `json:"id"`
+	GroupID            *int64    `json:"group_id"`
+	ModelPattern       string    `json:"model_pattern"`
+	InputPrice         float64   `json:"input_price"`
+	OutputPrice        float64   `json:"output_price"`
+	CacheCreationPrice float64   `json:"cache_creation_price"`
+	CacheReadPrice     float64   `json:"cache_read_price"`
+	ImagePrice         *float64  `json:"image_price"`
+	EffectiveFrom      time.Time `json:"effective_from"`
+	Notes              string    `json:"notes"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// TopupOrder 充值订单
+type TopupOrder struct {
+	ID              int64      `json:"id"`
+	OrderNo         string     `json:"order_no"`
+	OrderType       string     `json:"order_type"`
+	Amount          float64    `json:"amount"`
+	Currency        string     `json:"currency"`
+	CreditAmount    float64    `json:"credit_amount"`
+	PlanID          string     `json:"plan_id,omitempty"`
+	GroupID         *int64     `json:"group_id,omitempty"`
+	ValidityDays    int        `json:"validity_days,omitempty"`
+	Provider        string     `json:"provider"`
+	ProviderOrderID string     `json:"provider_order_id,omitempty"`
+	PayURL          string     `json:"pay_url,omitempty"`
+	Status          string     `json:"status"`
+	PaidAt          *time.Time `json:"paid_at,omitempty"`
+	ExpiresAt       time.Time  `json:"expires_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// AdminTopupOrder 是管理员接口使用的充值订单 DTO（包含用户）。
+type AdminTopupOrder struct {
+	TopupOrder
+	UserID    int64     `json:"user_id"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TopupPlan 可购买的订阅套餐
+type TopupPlan struct {
+	ID           string  `json:"id"`
+	Name         string  `json:"name"`
+	GroupID      int64   `json:"group_id"`
+	ValidityDays int     `json:"validity_days"`
+	Price        float64 `json:"price"`
+}
+
+// TopupOptions 充值选项
+type TopupOptions struct {
+	Enabled    bool        `json:"enabled"`
+	Currency   string      `json:"currency"`
+	CreditRate float64     `json:"credit_rate"`
+	MinAmount  float64     `json:"min_amount"`
+	MaxAmount  float64     `json:"max_amount"`
+	Providers  []string    `json:"providers"`
+	Plans      []TopupPlan `json:"plans"`
+}
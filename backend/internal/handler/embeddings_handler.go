@@ -0,0 +1,320 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ip"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/openai"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// embeddingsUsageRecorder 在转发成功后异步记录用量（不可访问 gin.Context）
+type embeddingsUsageRecorder func(ctx context.Context, apiKey *service.APIKey, subscription *service.UserSubscription, ua, ip string) error
+
+// embeddingsRoute 描述一次 embeddings 请求使用的调度与转发实现
+type embeddingsRoute struct {
+	concurrencyHelper   *ConcurrencyHelper
+	billingCacheService *service.BillingCacheService
+	maxAccountSwitches  int
+	selectAccount       func(ctx context.Context, groupID *int64, model string, excludedIDs map[int64]struct{}) (*service.AccountSelectionResult, error)
+	reserveRequest      func(ctx context.Context, account *service.Account) bool
+	forward             func(ctx context.Context, c *gin.Context, account *service.Account, body []byte) (embeddingsUsageRecorder, error)
+}
+
+// Embeddings handles OpenAI Embeddings API endpoint for OpenAI groups
+// POST /v1/embeddings
+// 仅调度 OpenAI API Key 账号，请求与响应原样透传。
+func (h *OpenAIGatewayHandler) Embeddings(c *gin.Context) {
+	serveEmbeddings(c, embeddingsRoute{
+		concurrencyHelper:   h.concurrencyHelper,
+		billingCacheService: h.billingCacheService,
+		maxAccountSwitches:  h.maxAccountSwitches,
+		selectAccount: func(ctx context.Context, groupID *int64, model string, excludedIDs map[int64]struct{}) (*service.AccountSelectionResult, error) {
+			return h.gatewayService.SelectAccountWithLoadAwareness(ctx, groupID, "", model, excludedIDs)
+		},
+		reserveRequest: h.gatewayService.ReserveAccountRequest,
+		forward: func(ctx context.Context, c *gin.Context, account *service.Account, body []byte) (embeddingsUsageRecorder, error) {
+			result, err := h.gatewayService.ForwardEmbeddings(ctx, c, account, body)
+			if err != nil {
+				return nil, err
+			}
+			return func(ctx context.Context, apiKey *service.APIKey, subscription *service.UserSubscription, ua, ip string) error {
+				return h.gatewayService.RecordUsage(ctx, &service.OpenAIRecordUsageInput{
+					Result:       result,
+					APIKey:       apiKey,
+					User:         apiKey.User,
+					Account:      account,
+					Subscription: subscription,
+					UserAgent:    ua,
+					IPAddress:    ip,
+				})
+			}, nil
+		},
+	})
+}
+
+// Embeddings handles OpenAI Embeddings API endpoint for Gemini groups
+// POST /v1/embeddings
+// 仅调度 Gemini API Key 账号，请求转换为 batchEmbedContents，响应转换回 OpenAI 格式。
+func (h *GatewayHandler) Embeddings(c *gin.Context) {
+	apiKey, ok := middleware2.GetAPIKeyFromContext(c)
+	if !ok {
+		chatCompletionsError(c, http.StatusUnauthorized, "authentication_error", "Invalid API key")
+		return
+	}
+	if apiKey.Group == nil || apiKey.Group.Platform != service.PlatformGemini {
+		chatCompletionsError(c, http.StatusBadRequest, "invalid_request_error", "Embeddings are only available for OpenAI and Gemini groups")
+		return
+	}
+
+	serveEmbeddings(c, embeddingsRoute{
+		concurrencyHelper:   h.concurrencyHelper,
+		billingCacheService: h.billingCacheService,
+		maxAccountSwitches:  h.maxAccountSwitchesGemini,
+		selectAccount: func(ctx context.Context, groupID *int64, model string, excludedIDs map[int64]struct{}) (*service.AccountSelectionResult, error) {
+			return h.gatewayService.SelectAccountWithLoadAwareness(ctx, groupID, "", model, excludedIDs, "")
+		},
+		reserveRequest: h.gatewayService.ReserveAccountRequest,
+		forward: func(ctx context.Context, c *gin.Context, account *service.Account, body []byte) (embeddingsUsageRecorder, error) {
+			result, err := h.geminiCompatService.ForwardEmbeddings(ctx, c, account, body)
+			if err != nil {
+				return nil, err
+			}
+			return func(ctx context.Context, apiKey *service.APIKey, subscription *service.UserSubscription, ua, ip string) error {
+				return h.gatewayService.RecordUsage(ctx, &service.RecordUsageInput{
+					Result:       result,
+					APIKey:       apiKey,
+					User:         apiKey.User,
+					Account:      account,
+					Subscription: subscription,
+					UserAgent:    ua,
+					IPAddress:    ip,
+				})
+			}, nil
+		},
+	})
+}
+
+// serveEmbeddings 执行 embeddings 请求的通用流程：
+// 用户并发 → 计费资格校验 → 账号选择（跳过不支持 embeddings 的账号）→ 转发/failover → 异步记录用量
+func serveEmbeddings(c *gin.Context, route embeddingsRoute) {
+	apiKey, ok := middleware2.GetAPIKeyFromContext(c)
+	if !ok {
+		chatCompletionsError(c, http.StatusUnauthorized, "authentication_error", "Invalid API key")
+		return
+	}
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		chatCompletionsError(c, http.StatusInternalServerError, "api_error", "User context not found")
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		if maxErr, ok := extractMaxBytesError(err); ok {
+			chatCompletionsError(c, http.StatusRequestEntityTooLarge, "invalid_request_error", buildBodyTooLargeMessage(maxErr.Limit))
+			return
+		}
+		chatCompletionsError(c, http.StatusBadRequest, "invalid_request_error", "Failed to read request body")
+		return
+	}
+	if len(body) == 0 {
+		chatCompletionsError(c, http.StatusBadRequest, "invalid_request_error", "Request body is empty")
+		return
+	}
+
+	var req openai.EmbeddingRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		chatCompletionsError(c, http.StatusBadRequest, "invalid_request_error", "Failed to parse request body")
+		return
+	}
+	if req.Model == "" {
+		chatCompletionsError(c, http.StatusBadRequest, "invalid_request_error", "model is required")
+		return
+	}
+	if len(req.Input) == 0 {
+		chatCompletionsError(c, http.StatusBadRequest, "invalid_request_error", "input is required")
+		return
+	}
+
+	setOpsRequestContext(c, req.Model, false, body)
+
+	subscription, _ := middleware2.GetSubscriptionFromContext(c)
+	streamStarted := false
+
+	// 0. Check if wait queue is full
+	maxWait := service.CalculateMaxWait(subject.Concurrency)
+	canWait, err := route.concurrencyHelper.IncrementWaitCount(c.Request.Context(), subject.UserID, maxWait)
+	waitCounted := false
+	if err != nil {
+		log.Printf("Increment wait count failed: %v", err)
+	} else if !canWait {
+		chatCompletionsError(c, http.StatusTooManyRequests, "rate_limit_error", "Too many pending requests, please retry later")
+		return
+	}
+	if err == nil && canWait {
+		waitCounted = true
+	}
+	defer func() {
+		if waitCounted {
+			route.concurrencyHelper.DecrementWaitCount(c.Request.Context(), subject.UserID)
+		}
+	}()
+
+	// 1. Acquire user concurrency slot
+	userReleaseFunc, err := route.concurrencyHelper.AcquireUserSlotWithWait(c, subject.UserID, subject.Concurrency, false, &streamStarted)
+	if err != nil {
+		log.Printf("User concurrency acquire failed: %v", err)
+		chatCompletionsError(c, http.StatusTooManyRequests, "rate_limit_error", "Concurrency limit exceeded for user, please retry later")
+		return
+	}
+	if waitCounted {
+		route.concurrencyHelper.DecrementWaitCount(c.Request.Context(), subject.UserID)
+		waitCounted = false
+	}
+	userReleaseFunc = wrapReleaseOnDone(c.Request.Context(), userReleaseFunc)
+	if userReleaseFunc != nil {
+		defer userReleaseFunc()
+	}
+
+	// 2. Billing eligibility (balance / subscription limits)
+	if err := route.billingCacheService.CheckBillingEligibility(c.Request.Context(), apiKey.User, apiKey, apiKey.Group, subscription, req.Model); err != nil {
+		log.Printf("Billing eligibility check failed after wait: %v", err)
+		status, code, message := billingErrorDetails(err)
+		chatCompletionsError(c, status, code, message)
+		return
+	}
+	// Request admitted: count it toward the API key request quota
+	route.billingCacheService.CountAPIKeyRequest(c.Request.Context(), apiKey)
+
+	switchCount := 0
+	failedAccountIDs := make(map[int64]struct{})
+	lastFailoverStatus := 0
+
+	for {
+		selection, err := route.selectAccount(c.Request.Context(), apiKey.GroupID, req.Model, failedAccountIDs)
+		if err != nil {
+			log.Printf("[Embeddings] SelectAccount failed: %v", err)
+			if lastFailoverStatus == 0 {
+				chatCompletionsError(c, http.StatusServiceUnavailable, "api_error", "No available accounts supporting embeddings")
+				return
+			}
+			handleEmbeddingsFailoverExhausted(c, lastFailoverStatus)
+			return
+		}
+		account := selection.Account
+
+		// 分组内的 OAuth / Antigravity 账号无 embeddings 接口，排除后重新选择（不计入切换次数）
+		if !account.SupportsEmbeddings() {
+			if selection.Acquired && selection.ReleaseFunc != nil {
+				selection.ReleaseFunc()
+			}
+			failedAccountIDs[account.ID] = struct{}{}
+			continue
+		}
+		setOpsSelectedAccount(c, account.ID)
+
+		// 3. Acquire account concurrency slot
+		accountReleaseFunc := selection.ReleaseFunc
+		if !selection.Acquired {
+			if selection.WaitPlan == nil {
+				chatCompletionsError(c, http.StatusServiceUnavailable, "api_error", "No available accounts")
+				return
+			}
+			accountWaitCounted := false
+			canWait, err := route.concurrencyHelper.IncrementAccountWaitCount(c.Request.Context(), account.ID, selection.WaitPlan.MaxWaiting)
+			if err != nil {
+				log.Printf("Increment account wait count failed: %v", err)
+			} else if !canWait {
+				log.Printf("Account wait queue full: account=%d", account.ID)
+				chatCompletionsError(c, http.StatusTooManyRequests, "rate_limit_error", "Too many pending requests, please retry later")
+				return
+			}
+			if err == nil && canWait {
+				accountWaitCounted = true
+			}
+			defer func() {
+				if accountWaitCounted {
+					route.concurrencyHelper.DecrementAccountWaitCount(c.Request.Context(), account.ID)
+				}
+			}()
+
+			accountReleaseFunc, err = route.concurrencyHelper.AcquireAccountSlotWithWaitTimeout(
+				c,
+				account.ID,
+				selection.WaitPlan.MaxConcurrency,
+				selection.WaitPlan.Timeout,
+				false,
+				&streamStarted,
+			)
+			if err != nil {
+				log.Printf("Account concurrency acquire failed: %v", err)
+				chatCompletionsError(c, http.StatusTooManyRequests, "rate_limit_error", "Concurrency limit exceeded for account, please retry later")
+				return
+			}
+			if accountWaitCounted {
+				route.concurrencyHelper.DecrementAccountWaitCount(c.Request.Context(), account.ID)
+				accountWaitCounted = false
+			}
+			// Circuit probe permit and RPM budget are claimed only once the slot is acquired; if taken while waiting, release and pick another account
+			if !route.reserveRequest(c.Request.Context(), account) {
+				accountReleaseFunc()
+				failedAccountIDs[account.ID] = struct{}{}
+				continue
+			}
+		}
+		accountReleaseFunc = wrapReleaseOnDone(c.Request.Context(), accountReleaseFunc)
+
+		record, err := route.forward(c.Request.Context(), c, account, body)
+		if accountReleaseFunc != nil {
+			accountReleaseFunc()
+		}
+		if err != nil {
+			var failoverErr *service.UpstreamFailoverError
+			if errors.As(err, &failoverErr) {
+				failedAccountIDs[account.ID] = struct{}{}
+				lastFailoverStatus = failoverErr.StatusCode
+				if switchCount >= route.maxAccountSwitches {
+					handleEmbeddingsFailoverExhausted(c, lastFailoverStatus)
+					return
+				}
+				switchCount++
+				log.Printf("Account %d: upstream error %d, switching account %d/%d", account.ID, failoverErr.StatusCode, switchCount, route.maxAccountSwitches)
+				continue
+			}
+			// Error response already written by forward
+			log.Printf("Account %d: embeddings forward failed: %v", account.ID, err)
+			return
+		}
+
+		userAgent := c.GetHeader("User-Agent")
+		clientIP := ip.GetClientIP(c)
+
+		go func(ua, ip string) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := record(ctx, apiKey, subscription, ua, ip); err != nil {
+				log.Printf("Record usage failed: %v", err)
+			}
+		}(userAgent, clientIP)
+		return
+	}
+}
+
+func handleEmbeddingsFailoverExhausted(c *gin.Context, statusCode int) {
+	status, message := mapGeminiUpstreamError(statusCode)
+	errType := "upstream_error"
+	if status == http.StatusTooManyRequests {
+		errType = "rate_limit_error"
+	}
+	chatCompletionsError(c, status, errType, message)
+}
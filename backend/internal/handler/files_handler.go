@@ -0,0 +1,232 @@
+package handler
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	pkgerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FilesHandler handles the Claude / OpenAI Files API (passthrough with account affinity)
+type FilesHandler struct {
+	fileService *service.GatewayFileService
+}
+
+// NewFilesHandler creates a new FilesHandler
+func NewFilesHandler(fileService *service.GatewayFileService) *FilesHandler {
+	return &FilesHandler{fileService: fileService}
+}
+
+// filesPlatform 按分组平台决定 Files API 协议：openai 分组使用 OpenAI 格式，其余（含未分组）使用 Anthropic 格式
+func filesPlatform(apiKey *service.APIKey) (string, bool) {
+	if apiKey.Group == nil {
+		return service.PlatformAnthropic, true
+	}
+	switch apiKey.Group.Platform {
+	case service.PlatformOpenAI, service.PlatformAnthropic:
+		return apiKey.Group.Platform, true
+	default:
+		return apiKey.Group.Platform, false
+	}
+}
+
+// context 取出 API Key 与 Files API 平台；失败时已写入错误响应
+func (h *FilesHandler) context(c *gin.Context) (*service.APIKey, string, bool) {
+	apiKey, ok := middleware2.GetAPIKeyFromContext(c)
+	if !ok {
+		h.errorResponse(c, service.PlatformAnthropic, http.StatusUnauthorized, "authentication_error", "Invalid API key")
+		return nil, "", false
+	}
+	platform, ok := filesPlatform(apiKey)
+	if !ok {
+		h.errorResponse(c, service.PlatformAnthropic, http.StatusNotFound, "not_found_error", "Files API is not supported for "+platform+" groups")
+		return nil, "", false
+	}
+	return apiKey, platform, true
+}
+
+// Upload handles file upload
+// POST /v1/files
+func (h *FilesHandler) Upload(c *gin.Context) {
+	apiKey, platform, ok := h.context(c)
+	if !ok {
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		if maxErr, ok := extractMaxBytesError(err); ok {
+			h.errorResponse(c, platform, http.StatusRequestEntityTooLarge, "invalid_request_error", buildBodyTooLargeMessage(maxErr.Limit))
+			return
+		}
+		h.errorResponse(c, platform, http.StatusBadRequest, "invalid_request_error", "Failed to read request body")
+		return
+	}
+	if len(body) == 0 {
+		h.errorResponse(c, platform, http.StatusBadRequest, "invalid_request_error", "Request body is empty")
+		return
+	}
+	if !strings.HasPrefix(strings.ToLower(c.GetHeader("Content-Type")), "multipart/form-data") {
+		h.errorResponse(c, platform, http.StatusBadRequest, "invalid_request_error", "Content-Type must be multipart/form-data")
+		return
+	}
+
+	if err := h.fileService.Upload(c.Request.Context(), c, apiKey, platform, body); err != nil {
+		h.serviceError(c, platform, err)
+	}
+}
+
+// List handles file listing (files uploaded through this gateway, newest first)
+// GET /v1/files?limit=&before_id=&after_id=&after=&purpose=
+func (h *FilesHandler) List(c *gin.Context) {
+	apiKey, platform, ok := h.context(c)
+	if !ok {
+		return
+	}
+
+	params := service.GatewayFileListParams{
+		BeforeID: c.Query("before_id"),
+		AfterID:  c.Query("after_id"),
+		Purpose:  c.Query("purpose"),
+	}
+	if params.AfterID == "" {
+		// OpenAI 使用 after 作为游标
+		params.AfterID = c.Query("after")
+	}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			h.errorResponse(c, platform, http.StatusBadRequest, "invalid_request_error", "limit: must be a positive integer")
+			return
+		}
+		params.Limit = limit
+	}
+
+	files, hasMore, err := h.fileService.List(c.Request.Context(), apiKey.UserID, platform, params)
+	if err != nil {
+		h.serviceError(c, platform, err)
+		return
+	}
+
+	var firstID, lastID *string
+	if len(files) > 0 {
+		firstID = &files[0].FileID
+		lastID = &files[len(files)-1].FileID
+	}
+	data := make([]gin.H, 0, len(files))
+	for i := range files {
+		data = append(data, fileObject(platform, &files[i]))
+	}
+	out := gin.H{
+		"data":     data,
+		"has_more": hasMore,
+		"first_id": firstID,
+		"last_id":  lastID,
+	}
+	if platform == service.PlatformOpenAI {
+		out["object"] = "list"
+	}
+	c.JSON(http.StatusOK, out)
+}
+
+// Get handles file metadata retrieval (forwarded to the owning account)
+// GET /v1/files/:file_id
+func (h *FilesHandler) Get(c *gin.Context) {
+	h.forward(c, http.MethodGet, "")
+}
+
+// Content handles file download (forwarded to the owning account)
+// GET /v1/files/:file_id/content
+func (h *FilesHandler) Content(c *gin.Context) {
+	h.forward(c, http.MethodGet, "content")
+}
+
+// Delete handles file deletion (forwarded to the owning account)
+// DELETE /v1/files/:file_id
+func (h *FilesHandler) Delete(c *gin.Context) {
+	h.forward(c, http.MethodDelete, "")
+}
+
+func (h *FilesHandler) forward(c *gin.Context, method, subPath string) {
+	apiKey, platform, ok := h.context(c)
+	if !ok {
+		return
+	}
+	if err := h.fileService.Forward(c.Request.Context(), c, apiKey.UserID, platform, c.Param("file_id"), method, subPath); err != nil {
+		h.serviceError(c, platform, err)
+	}
+}
+
+// fileObject 将本地映射转换为对应平台的 file 对象
+func fileObject(platform string, f *service.GatewayFile) gin.H {
+	if platform == service.PlatformOpenAI {
+		obj := gin.H{
+			"id":         f.FileID,
+			"object":     "file",
+			"bytes":      f.SizeBytes,
+			"created_at": f.CreatedAt.Unix(),
+			"filename":   f.Filename,
+			"purpose":    f.Purpose,
+		}
+		if f.ExpiresAt != nil {
+			obj["expires_at"] = f.ExpiresAt.Unix()
+		}
+		return obj
+	}
+	return gin.H{
+		"id":           f.FileID,
+		"type":         "file",
+		"filename":     f.Filename,
+		"mime_type":    f.MimeType,
+		"size_bytes":   f.SizeBytes,
+		"created_at":   f.CreatedAt.UTC().Format(time.RFC3339),
+		"downloadable": false,
+	}
+}
+
+// serviceError 将 service 层错误转换为对应平台的错误格式
+func (h *FilesHandler) serviceError(c *gin.Context, platform string, err error) {
+	status := pkgerrors.Code(err)
+	message := pkgerrors.Message(err)
+	if status == http.StatusInternalServerError {
+		log.Printf("[Files] request failed: %v", err)
+		message = "Internal server error"
+	}
+	errType := "api_error"
+	switch status {
+	case http.StatusBadRequest:
+		errType = "invalid_request_error"
+	case http.StatusNotFound:
+		errType = "not_found_error"
+	case http.StatusBadGateway:
+		errType = "upstream_error"
+	}
+	h.errorResponse(c, platform, status, errType, message)
+}
+
+func (h *FilesHandler) errorResponse(c *gin.Context, platform string, status int, errType, message string) {
+	if platform == service.PlatformOpenAI {
+		c.JSON(status, gin.H{
+			"error": gin.H{
+				"type":    errType,
+				"message": message,
+			},
+		})
+		return
+	}
+	c.JSON(status, gin.H{
+		"type": "error",
+		"error": gin.H{
+			"type":    errType,
+			"message": message,
+		},
+	})
+}
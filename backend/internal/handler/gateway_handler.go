@@ -28,8 +28,12 @@ type GatewayHandler struct {
 	gatewayService            *service.GatewayService
 	geminiCompatService       *service.GeminiMessagesCompatService
 	antigravityGatewayService *service.AntigravityGatewayService
+	openAIGatewayService      *service.OpenAIGatewayService
 	userService               *service.UserService
 	billingCacheService       *service.BillingCacheService
+	modelCatalogService       *service.ModelCatalogService
+	fileService               *service.GatewayFileService
+	hedgingService            *service.RequestHedgingService
 	concurrencyHelper         *ConcurrencyHelper
 	maxAccountSwitches        int
 	maxAccountSwitchesGemini  int
@@ -40,9 +44,13 @@ func NewGatewayHandler(
 	gatewayService *service.GatewayService,
 	geminiCompatService *service.GeminiMessagesCompatService,
 	antigravityGatewayService *service.AntigravityGatewayService,
+	openAIGatewayService *service.OpenAIGatewayService,
 	userService *service.UserService,
 	concurrencyService *service.ConcurrencyService,
 	billingCacheService *service.BillingCacheService,
+	modelCatalogService *service.ModelCatalogService,
+	fileService *service.GatewayFileService,
+	hedgingService *service.RequestHedgingService,
 	cfg *config.Config,
 ) *GatewayHandler {
 	pingInterval := time.Duration(0)
@@ -61,8 +69,12 @@ func NewGatewayHandler(
 		gatewayService:            gatewayService,
 		geminiCompatService:       geminiCompatService,
 		antigravityGatewayService: antigravityGatewayService,
+		openAIGatewayService:      openAIGatewayService,
 		userService:               userService,
 		billingCacheService:       billingCacheService,
+		modelCatalogService:       modelCatalogService,
+		fileService:               fileService,
+		hedgingService:            hedgingService,
 		concurrencyHelper:         NewConcurrencyHelper(concurrencyService, SSEPingFormatClaude, pingInterval),
 		maxAccountSwitches:        maxAccountSwitches,
 		maxAccountSwitchesGemini:  maxAccountSwitchesGemini,
@@ -168,15 +180,31 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 	}
 
 	// 2. 【新增】Wait后二次检查余额/订阅
-	if err := h.billingCacheService.CheckBillingEligibility(c.Request.Context(), apiKey.User, apiKey, apiKey.Group, subscription); err != nil {
+	if err := h.billingCacheService.CheckBillingEligibility(c.Request.Context(), apiKey.User, apiKey, apiKey.Group, subscription, reqModel); err != nil {
 		log.Printf("Billing eligibility check failed after wait: %v", err)
 		status, code, message := billingErrorDetails(err)
 		h.handleStreamingAwareError(c, status, code, message, streamStarted)
 		return
 	}
 
-	// 计算粘性会话hash
-	sessionHash := h.gatewayService.GenerateSessionHash(parsedReq)
+	// 余额模式下按估算费用预授权冻结，由 RecordUsage 按实际费用结算；未交给 RecordUsage 时在返回前释放
+	balanceHold, err := h.billingCacheService.ReserveBalanceHold(c.Request.Context(), apiKey.User, apiKey, apiKey.Group, subscription, reqModel, body)
+	if err != nil {
+		status, code, message := billingErrorDetails(err)
+		h.handleStreamingAwareError(c, status, code, message, streamStarted)
+		return
+	}
+	holdHandedOff := false
+	defer func() {
+		if !holdHandedOff {
+			h.billingCacheService.ReleaseBalanceHold(balanceHold)
+		}
+	}()
+	// 请求已被接纳，计入 API Key 请求数限额
+	h.billingCacheService.CountAPIKeyRequest(c.Request.Context(), apiKey)
+
+	// 按分组粘性策略计算会话hash
+	sessionHash := h.gatewayService.ResolveSessionHash(apiKey.Group, apiKey.ID, parsedReq)
 
 	// 获取平台：优先使用强制平台（/antigravity 路由，中间件已设置 request.Context），否则使用分组平台
 	platform := ""
@@ -189,6 +217,14 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 	if platform == service.PlatformGemini && sessionHash != "" {
 		sessionKey = "gemini:" + sessionHash
 	}
+	// 引用 Files API 上传的文件时固定到文件归属账号（文件仅存在于上传账号）
+	fileBound := false
+	if platform == service.PlatformAnthropic || platform == "" {
+		if fileSessionKey := h.fileService.BindFileAffinity(c.Request.Context(), apiKey, service.PlatformAnthropic, body); fileSessionKey != "" {
+			sessionKey = fileSessionKey
+			fileBound = true
+		}
+	}
 
 	if platform == service.PlatformGemini {
 		maxAccountSwitches := h.maxAccountSwitchesGemini
@@ -269,6 +305,12 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 					h.concurrencyHelper.DecrementAccountWaitCount(c.Request.Context(), account.ID)
 					accountWaitCounted = false
 				}
+				// 等待后获取到槽位才领取熔断探测名额并计入 RPM 预算；已被其他请求占用时释放槽位并改选账号
+				if !h.gatewayService.ReserveAccountRequest(c.Request.Context(), account) {
+					accountReleaseFunc()
+					failedAccountIDs[account.ID] = struct{}{}
+					continue
+				}
 				if err := h.gatewayService.BindStickySession(c.Request.Context(), apiKey.GroupID, sessionKey, account.ID); err != nil {
 					log.Printf("Bind sticky session failed: %v", err)
 				}
@@ -308,8 +350,9 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 			userAgent := c.GetHeader("User-Agent")
 			clientIP := ip.GetClientIP(c)
 
-			// 异步记录使用量（subscription已在函数开头获取）
-			go func(result *service.ForwardResult, usedAccount *service.Account, ua, clientIP string) {
+			// 异步记录使用量（subscription已在函数开头获取），冻结交由 RecordUsage 结算
+			holdHandedOff = true
+			go func(result *service.ForwardResult, usedAccount *service.Account, ua, clientIP string, costRouting *service.CostRoutingDecision) {
 				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 				defer cancel()
 				if err := h.gatewayService.RecordUsage(ctx, &service.RecordUsageInput{
@@ -320,10 +363,13 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 					Subscription: subscription,
 					UserAgent:    ua,
 					IPAddress:    clientIP,
+					SessionHash:  sessionHash,
+					CostRouting:  costRouting,
+					BalanceHold:  balanceHold,
 				}); err != nil {
 					log.Printf("Record usage failed: %v", err)
 				}
-			}(result, account, userAgent, clientIP)
+			}(result, account, userAgent, clientIP, selection.CostRouting)
 			return
 		}
 	}
@@ -332,6 +378,8 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 	switchCount := 0
 	failedAccountIDs := make(map[int64]struct{})
 	lastFailoverStatus := 0
+	// 绑定文件归属账号的请求不能在其他账号上对冲
+	hedgeable := !fileBound && h.hedgingService.ShouldHedgeMessages(parsedReq)
 
 	for {
 		// 选择支持该模型的账号
@@ -405,6 +453,12 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 				h.concurrencyHelper.DecrementAccountWaitCount(c.Request.Context(), account.ID)
 				accountWaitCounted = false
 			}
+			// 等待后获取到槽位才领取熔断探测名额并计入 RPM 预算；已被其他请求占用时释放槽位并改选账号
+			if !h.gatewayService.ReserveAccountRequest(c.Request.Context(), account) {
+				accountReleaseFunc()
+				failedAccountIDs[account.ID] = struct{}{}
+				continue
+			}
 			if err := h.gatewayService.BindStickySession(c.Request.Context(), apiKey.GroupID, sessionKey, account.ID); err != nil {
 				log.Printf("Bind sticky session failed: %v", err)
 			}
@@ -413,14 +467,54 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 		accountReleaseFunc = wrapReleaseOnDone(c.Request.Context(), accountReleaseFunc)
 
 		// 转发请求 - 根据账号平台分流
+		forwardRequest := func(ctx context.Context, c *gin.Context, account *service.Account, req *service.ParsedRequest) (*service.ForwardResult, error) {
+			if account.Platform == service.PlatformAntigravity {
+				return h.antigravityGatewayService.Forward(ctx, c, account, req.Body)
+			}
+			if account.Platform == service.PlatformOpenAI {
+				// 混合调度的 OpenAI 账号：Messages 请求转换为 Responses 请求
+				return h.openAIGatewayService.ForwardAsClaude(ctx, c, account, req.Body)
+			}
+			return h.gatewayService.Forward(ctx, c, account, req)
+		}
+		forward := func(ctx context.Context, c *gin.Context, account *service.Account) (*service.ForwardResult, error) {
+			return forwardRequest(ctx, c, account, parsedReq)
+		}
 		var result *service.ForwardResult
-		if account.Platform == service.PlatformAntigravity {
-			result, err = h.antigravityGatewayService.Forward(c.Request.Context(), c, account, body)
+		costRouting := selection.CostRouting
+		if hedgeable {
+			// 非流式短请求：超过近期延迟分位仍未返回时在另一个账号上对冲，只对胜出者计费
+			primary := *selection
+			primary.ReleaseFunc = accountReleaseFunc
+			// 对冲尝试并发执行，每个尝试使用独立的请求体与解析结果副本
+			hedgedForward := func(ctx context.Context, c *gin.Context, account *service.Account) (*service.ForwardResult, error) {
+				req, err := parsedReq.Clone()
+				if err != nil {
+					return nil, fmt.Errorf("clone request: %w", err)
+				}
+				return forwardRequest(ctx, c, account, req)
+			}
+			outcome := runHedged(c, h.hedgingService.Delay(service.HedgeKindMessages, reqModel), &primary, failedAccountIDs, hedgedForward,
+				func(excluded map[int64]struct{}) *service.AccountSelectionResult {
+					return h.selectHedgeAccount(c, apiKey.GroupID, reqModel, body, excluded)
+				})
+			account, result, err = outcome.selection.Account, outcome.result, outcome.err
+			costRouting = outcome.selection.CostRouting
+			for _, id := range outcome.otherFailedIDs {
+				failedAccountIDs[id] = struct{}{}
+			}
+			if outcome.hedged {
+				setOpsSelectedAccount(c, account.ID)
+				log.Printf("[Hedging] model=%s winner account=%d backup_won=%v", reqModel, account.ID, outcome.winnerIsBackup)
+			}
+			if err == nil {
+				h.hedgingService.RecordLatency(service.HedgeKindMessages, reqModel, outcome.winnerHeaderLatency)
+			}
 		} else {
-			result, err = h.gatewayService.Forward(c.Request.Context(), c, account, parsedReq)
-		}
-		if accountReleaseFunc != nil {
-			accountReleaseFunc()
+			result, err = forward(c.Request.Context(), c, account)
+			if accountReleaseFunc != nil {
+				accountReleaseFunc()
+			}
 		}
 		if err != nil {
 			var failoverErr *service.UpstreamFailoverError
@@ -444,8 +538,9 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 		userAgent := c.GetHeader("User-Agent")
 		clientIP := ip.GetClientIP(c)
 
-		// 异步记录使用量（subscription已在函数开头获取）
-		go func(result *service.ForwardResult, usedAccount *service.Account, ua, clientIP string) {
+		// 异步记录使用量（subscription已在函数开头获取），冻结交由 RecordUsage 结算
+		holdHandedOff = true
+		go func(result *service.ForwardResult, usedAccount *service.Account, ua, clientIP string, costRouting *service.CostRoutingDecision) {
 			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 			defer cancel()
 			if err := h.gatewayService.RecordUsage(ctx, &service.RecordUsageInput{
@@ -456,72 +551,89 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 				Subscription: subscription,
 				UserAgent:    ua,
 				IPAddress:    clientIP,
+				SessionHash:  sessionHash,
+				CostRouting:  costRouting,
+				BalanceHold:  balanceHold,
 			}); err != nil {
 				log.Printf("Record usage failed: %v", err)
 			}
-		}(result, account, userAgent, clientIP)
+		}(result, account, userAgent, clientIP, costRouting)
 		return
 	}
 }
 
+// selectHedgeAccount 为对冲尝试选择另一个可立即获取槽位的账号（不排队、不绑定粘性会话），无可用账号时返回 nil
+func (h *GatewayHandler) selectHedgeAccount(c *gin.Context, groupID *int64, reqModel string, body []byte, excluded map[int64]struct{}) *service.AccountSelectionResult {
+	selection, err := h.gatewayService.SelectAccountWithLoadAwareness(c.Request.Context(), groupID, "", reqModel, excluded, "")
+	if err != nil || selection == nil {
+		return nil
+	}
+	if !selection.Acquired {
+		return nil
+	}
+	if selection.Account.IsInterceptWarmupEnabled() && detectInterceptType(body) != InterceptTypeNone {
+		if selection.ReleaseFunc != nil {
+			selection.ReleaseFunc()
+		}
+		return nil
+	}
+	selection.ReleaseFunc = wrapReleaseOnDone(c.Request.Context(), selection.ReleaseFunc)
+	return selection
+}
+
 // Models handles listing available models
 // GET /v1/models
-// Returns models based on account configurations (model_mapping whitelist)
-// Falls back to default models if no whitelist is configured
+// 返回当前 API Key 分组实际可路由的模型目录（含上下文长度、模态与分组倍率后的价格）。
+// 响应格式：?format=openai|anthropic|gemini；未指定时带 anthropic-version 头返回 Anthropic 格式，
+// openai 分组返回 OpenAI 格式，其余返回 Anthropic 格式。
 func (h *GatewayHandler) Models(c *gin.Context) {
 	apiKey, _ := middleware2.GetAPIKeyFromContext(c)
 
-	var groupID *int64
+	var group *service.Group
 	var platform string
-
 	if apiKey != nil && apiKey.Group != nil {
-		groupID = &apiKey.Group.ID
+		group = apiKey.Group
 		platform = apiKey.Group.Platform
 	}
 
-	// Get available models from account configurations (without platform filter)
-	availableModels := h.gatewayService.GetAvailableModels(c.Request.Context(), groupID, "")
-
-	if len(availableModels) > 0 {
-		// Build model list from whitelist
-		models := make([]claude.Model, 0, len(availableModels))
-		for _, modelID := range availableModels {
-			models = append(models, claude.Model{
-				ID:          modelID,
-				Type:        "model",
-				DisplayName: modelID,
-				CreatedAt:   "2024-01-01T00:00:00Z",
+	format := resolveModelCatalogFormat(c, platform)
+	entries, err := h.modelCatalogService.ListModels(c.Request.Context(), group, "")
+	if err != nil {
+		log.Printf("[ModelCatalog] list models failed: platform=%s err=%v", platform, err)
+		// Fallback to default models
+		if platform == service.PlatformOpenAI {
+			c.JSON(http.StatusOK, gin.H{
+				"object": "list",
+				"data":   openai.DefaultModels,
 			})
+			return
 		}
 		c.JSON(http.StatusOK, gin.H{
 			"object": "list",
-			"data":   models,
+			"data":   claude.DefaultModels,
 		})
 		return
 	}
+	writeModelCatalog(c, format, entries)
+}
 
-	// Fallback to default models
-	if platform == "openai" {
+// AntigravityModels 返回当前分组内 Antigravity 账号可路由的模型
+// GET /antigravity/models
+func (h *GatewayHandler) AntigravityModels(c *gin.Context) {
+	apiKey, _ := middleware2.GetAPIKeyFromContext(c)
+	var group *service.Group
+	if apiKey != nil {
+		group = apiKey.Group
+	}
+	entries, err := h.modelCatalogService.ListModels(c.Request.Context(), group, service.PlatformAntigravity)
+	if err != nil {
 		c.JSON(http.StatusOK, gin.H{
 			"object": "list",
-			"data":   openai.DefaultModels,
+			"data":   antigravity.DefaultModels(),
 		})
 		return
 	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"object": "list",
-		"data":   claude.DefaultModels,
-	})
-}
-
-// AntigravityModels 返回 Antigravity 支持的全部模型
-// GET /antigravity/models
-func (h *GatewayHandler) AntigravityModels(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"object": "list",
-		"data":   antigravity.DefaultModels(),
-	})
+	writeModelCatalog(c, resolveModelCatalogFormat(c, service.PlatformAntigravity), entries)
 }
 
 // Usage handles getting account balance for CC Switch integration
@@ -746,23 +858,62 @@ func (h *GatewayHandler) CountTokens(c *gin.Context) {
 
 	// 校验 billing eligibility（订阅/余额）
 	// 【注意】不计算并发，但需要校验订阅/余额
-	if err := h.billingCacheService.CheckBillingEligibility(c.Request.Context(), apiKey.User, apiKey, apiKey.Group, subscription); err != nil {
+	if err := h.billingCacheService.CheckBillingEligibility(c.Request.Context(), apiKey.User, apiKey, apiKey.Group, subscription, parsedReq.Model); err != nil {
 		status, code, message := billingErrorDetails(err)
 		h.errorResponse(c, status, code, message)
 		return
 	}
 
-	// 计算粘性会话 hash
-	sessionHash := h.gatewayService.GenerateSessionHash(parsedReq)
+	// local 模式：直接本地估算，不占用上游账号
+	if h.gatewayService.CountTokensLocalOnly() {
+		h.gatewayService.CountTokensFallback(c, parsedReq)
+		return
+	}
+
+	// 按分组粘性策略计算会话 hash
+	sessionHash := h.gatewayService.ResolveSessionHash(apiKey.Group, apiKey.ID, parsedReq)
 
 	// 选择支持该模型的账号
 	account, err := h.gatewayService.SelectAccountForModel(c.Request.Context(), apiKey.GroupID, sessionHash, parsedReq.Model)
 	if err != nil {
+		if h.gatewayService.CountTokensFallback(c, parsedReq) {
+			return
+		}
 		h.errorResponse(c, http.StatusServiceUnavailable, "api_error", "No available accounts: "+err.Error())
 		return
 	}
 	setOpsSelectedAccount(c, account.ID)
 
+	// 对冲：超过近期延迟分位仍未返回时在另一个账号上重发（count_tokens 不占用账号槽位、不计费）
+	if h.hedgingService.ShouldHedgeCountTokens() {
+		// 对冲尝试并发执行，每个尝试使用独立的请求体与解析结果副本
+		forward := func(ctx context.Context, c *gin.Context, account *service.Account) (*service.ForwardResult, error) {
+			req, err := parsedReq.Clone()
+			if err != nil {
+				return nil, fmt.Errorf("clone request: %w", err)
+			}
+			return nil, h.gatewayService.ForwardCountTokens(ctx, c, account, req)
+		}
+		outcome := runHedged(c, h.hedgingService.Delay(service.HedgeKindCountTokens, parsedReq.Model),
+			&service.AccountSelectionResult{Account: account, Acquired: true}, nil, forward,
+			func(excluded map[int64]struct{}) *service.AccountSelectionResult {
+				backup, err := h.gatewayService.SelectAccountForModelWithExclusions(c.Request.Context(), apiKey.GroupID, "", parsedReq.Model, excluded)
+				if err != nil || backup == nil {
+					return nil
+				}
+				return &service.AccountSelectionResult{Account: backup, Acquired: true}
+			})
+		if outcome.hedged {
+			setOpsSelectedAccount(c, outcome.selection.Account.ID)
+		}
+		if outcome.err != nil {
+			log.Printf("Forward count_tokens request failed: %v", outcome.err)
+			return
+		}
+		h.hedgingService.RecordLatency(service.HedgeKindCountTokens, parsedReq.Model, outcome.winnerHeaderLatency)
+		return
+	}
+
 	// 转发请求（不记录使用量）
 	if err := h.gatewayService.ForwardCountTokens(c.Request.Context(), c, account, parsedReq); err != nil {
 		log.Printf("Forward count_tokens request failed: %v", err)
@@ -973,5 +1124,9 @@ func billingErrorDetails(err error) (status int, code, message string) {
 	if msg == "" {
 		msg = err.Error()
 	}
+	// Expired API keys are rejected with 401, same as the auth middleware
+	if pkgerrors.Code(err) == http.StatusUnauthorized {
+		return http.StatusUnauthorized, "authentication_error", msg
+	}
 	return http.StatusForbidden, "billing_error", msg
 }
@@ -4,11 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log"
 	"math/rand"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
 	"github.com/Wei-Shaw/sub2api/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -204,18 +207,52 @@ func (h *ConcurrencyHelper) waitForSlotWithPingTimeout(c *gin.Context, slotType
 	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
 	defer cancel()
 
-	// Try immediate acquire first (avoid unnecessary wait)
-	var result *service.AcquireResult
-	var err error
-	if slotType == "user" {
-		result, err = h.concurrencyService.AcquireUserSlot(ctx, id, maxConcurrency)
-	} else {
-		result, err = h.concurrencyService.AcquireAccountSlot(ctx, id, maxConcurrency)
+	// 账号槽位等待优先走公平队列：按用户权重轮转放行，Redis 异常时退化为竞争式等待
+	var ticket *service.FairQueueTicket
+	if slotType == "account" && maxConcurrency > 0 {
+		ticket = h.enqueueFairWaiter(ctx, c, id)
 	}
+	acquired := false
+	defer func() {
+		if ticket != nil && !acquired {
+			leaveCtx, leaveCancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer leaveCancel()
+			h.concurrencyService.LeaveAccountQueue(leaveCtx, ticket)
+		}
+	}()
+
+	tryAcquire := func() (*service.AcquireResult, error) {
+		if slotType == "user" {
+			return h.concurrencyService.AcquireUserSlot(ctx, id, maxConcurrency)
+		}
+		if ticket != nil {
+			result, err := h.concurrencyService.TryAcquireAccountSlotFair(ctx, ticket, maxConcurrency)
+			if err == nil {
+				if result.Acquired {
+					clearQueueHeaders(c)
+				} else {
+					setQueueHeaders(c, ticket, streamStarted)
+				}
+				return result, nil
+			}
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			log.Printf("Warning: fair queue unavailable for account %d, falling back: %v", id, err)
+			h.concurrencyService.LeaveAccountQueue(ctx, ticket)
+			ticket = nil
+			clearQueueHeaders(c)
+		}
+		return h.concurrencyService.AcquireAccountSlot(ctx, id, maxConcurrency)
+	}
+
+	// Try immediate acquire first (avoid unnecessary wait)
+	result, err := tryAcquire()
 	if err != nil {
 		return nil, err
 	}
 	if result.Acquired {
+		acquired = true
 		return result.ReleaseFunc, nil
 	}
 
@@ -268,20 +305,13 @@ func (h *ConcurrencyHelper) waitForSlotWithPingTimeout(c *gin.Context, slotType
 
 		case <-timer.C:
 			// Try to acquire slot
-			var result *service.AcquireResult
-			var err error
-
-			if slotType == "user" {
-				result, err = h.concurrencyService.AcquireUserSlot(ctx, id, maxConcurrency)
-			} else {
-				result, err = h.concurrencyService.AcquireAccountSlot(ctx, id, maxConcurrency)
-			}
-
+			result, err := tryAcquire()
 			if err != nil {
 				return nil, err
 			}
 
 			if result.Acquired {
+				acquired = true
 				return result.ReleaseFunc, nil
 			}
 			backoff = nextBackoff(backoff, rng)
@@ -290,6 +320,49 @@ func (h *ConcurrencyHelper) waitForSlotWithPingTimeout(c *gin.Context, slotType
 	}
 }
 
+// enqueueFairWaiter 将账号槽位等待请求加入所属分组的公平队列，未启用或失败时返回 nil
+func (h *ConcurrencyHelper) enqueueFairWaiter(ctx context.Context, c *gin.Context, accountID int64) *service.FairQueueTicket {
+	if !h.concurrencyService.FairQueueEnabled() {
+		return nil
+	}
+	apiKey, ok := middleware2.GetAPIKeyFromContext(c)
+	if !ok || apiKey == nil || apiKey.User == nil {
+		return nil
+	}
+	groupID := int64(0)
+	if apiKey.GroupID != nil {
+		groupID = *apiKey.GroupID
+	}
+	_, isSubscription := middleware2.GetSubscriptionFromContext(c)
+	weight := h.concurrencyService.FairQueueWeight(apiKey.User.Concurrency, isSubscription)
+	ticket, err := h.concurrencyService.EnqueueAccountWaiter(ctx, groupID, apiKey.User.ID, accountID, weight)
+	if err != nil {
+		log.Printf("Warning: fair queue enqueue failed for account %d, falling back: %v", accountID, err)
+		return nil
+	}
+	return ticket
+}
+
+// setQueueHeaders 在响应头写出前暴露排队位置与预计等待秒数
+func setQueueHeaders(c *gin.Context, ticket *service.FairQueueTicket, streamStarted *bool) {
+	if streamStarted != nil && *streamStarted {
+		return
+	}
+	c.Header("X-Queue-Position", strconv.Itoa(ticket.Position))
+	if ticket.ETA > 0 {
+		c.Header("X-Queue-ETA", strconv.Itoa(int(ticket.ETA/time.Second)))
+	} else {
+		c.Header("X-Queue-ETA", "")
+	}
+}
+
+// clearQueueHeaders 获取到槽位（或退出公平队列）后移除排队头，避免响应携带过期的排队位置
+// gin 的 c.Header 传空值即删除该头；响应头已写出时删除不生效也无副作用。
+func clearQueueHeaders(c *gin.Context) {
+	c.Header("X-Queue-Position", "")
+	c.Header("X-Queue-ETA", "")
+}
+
 // AcquireAccountSlotWithWaitTimeout acquires an account slot with a custom timeout (keeps SSE ping).
 func (h *ConcurrencyHelper) AcquireAccountSlotWithWaitTimeout(c *gin.Context, accountID int64, maxConcurrency int, timeout time.Duration, isStream bool, streamStarted *bool) (func(), error) {
 	return h.waitForSlotWithPingTimeout(c, "account", accountID, maxConcurrency, timeout, isStream, streamStarted)
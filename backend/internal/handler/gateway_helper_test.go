@@ -2,10 +2,14 @@ package handler
 
 import (
 	"context"
+	"net/http/httptest"
 	"runtime"
 	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
 )
 
 // TestWrapReleaseOnDone_NoGoroutineLeak 验证 wrapReleaseOnDone 修复后不会泄露 goroutine
@@ -129,6 +133,27 @@ func TestWrapReleaseOnDone_ConcurrentCalls(t *testing.T) {
 }
 
 // BenchmarkWrapReleaseOnDone 性能基准测试
+// TestQueueHeaders_ClearedAfterAcquire 验证获取到槽位后不再携带过期的排队头
+func TestQueueHeaders_ClearedAfterAcquire(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	streamStarted := false
+	setQueueHeaders(c, &service.FairQueueTicket{Position: 3, ETA: 2 * time.Second}, &streamStarted)
+	if got := c.Writer.Header().Get("X-Queue-Position"); got != "3" {
+		t.Fatalf("expected X-Queue-Position 3, got %q", got)
+	}
+
+	clearQueueHeaders(c)
+	if got := c.Writer.Header().Values("X-Queue-Position"); len(got) != 0 {
+		t.Errorf("expected X-Queue-Position to be removed, got %v", got)
+	}
+	if got := c.Writer.Header().Values("X-Queue-ETA"); len(got) != 0 {
+		t.Errorf("expected X-Queue-ETA to be removed, got %v", got)
+	}
+}
+
 func BenchmarkWrapReleaseOnDone(b *testing.B) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
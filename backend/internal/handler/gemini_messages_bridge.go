@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/antigravity"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// isGeminiBridgeGroup 分组是否通过 Claude Messages 处理链承接 Gemini 原生 API（anthropic/openai 平台）
+func isGeminiBridgeGroup(group *service.Group) bool {
+	if group == nil {
+		return false
+	}
+	return group.Platform == service.PlatformAnthropic || group.Platform == service.PlatformOpenAI
+}
+
+// geminiViaMessages 处理 anthropic/openai 分组上的 Gemini 原生请求：
+// generateContent/streamGenerateContent 转换为 Claude Messages 请求（模型名按分组 model_mapping 映射）
+// 后复用 Messages 处理链，响应再转换回 Gemini 格式。
+func (h *GatewayHandler) geminiViaMessages(c *gin.Context, group *service.Group) {
+	modelName, action, err := parseGeminiModelAction(strings.TrimPrefix(c.Param("modelAction"), "/"))
+	if err != nil {
+		googleError(c, http.StatusNotFound, err.Error())
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		if maxErr, ok := extractMaxBytesError(err); ok {
+			googleError(c, http.StatusRequestEntityTooLarge, buildBodyTooLargeMessage(maxErr.Limit))
+			return
+		}
+		googleError(c, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+	if len(body) == 0 {
+		googleError(c, http.StatusBadRequest, "Request body is empty")
+		return
+	}
+
+	model := group.MapModel(modelName)
+	switch action {
+	case "generateContent", "streamGenerateContent":
+	case "countTokens":
+		// 上游不提供 Gemini countTokens，使用本地估算
+		h.geminiCompatService.WriteLocalCountTokens(c, model, body)
+		return
+	default:
+		googleError(c, http.StatusBadRequest, "Action "+action+" is not supported for "+group.Platform+" groups")
+		return
+	}
+	stream := action == "streamGenerateContent"
+
+	var geminiReq antigravity.GeminiRequest
+	if err := json.Unmarshal(body, &geminiReq); err != nil {
+		googleError(c, http.StatusBadRequest, "Failed to parse request body")
+		return
+	}
+	claudeReq, err := antigravity.TransformGeminiRequestToClaude(&geminiReq, model, stream)
+	if err != nil {
+		googleError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	claudeBody, err := json.Marshal(claudeReq)
+	if err != nil {
+		googleError(c, http.StatusInternalServerError, "Failed to build upstream request")
+		return
+	}
+
+	w := newProtocolConvertWriter(c.Writer, stream,
+		antigravity.NewClaudeToGeminiStreamProcessor(model),
+		antigravity.TransformClaudeResponseToGemini,
+		antigravity.ClaudeErrorToGemini,
+		antigravity.ClaudeStreamErrorToGemini,
+	)
+	serveConverted(c, claudeBody, w, h.Messages)
+}
@@ -44,6 +44,11 @@ func (h *GatewayHandler) GeminiV1BetaListModels(c *gin.Context) {
 	}
 	// 检查平台：优先使用强制平台（/antigravity 路由），否则要求 gemini 分组
 	forcePlatform, hasForcePlatform := middleware.GetForcePlatformFromContext(c)
+	// anthropic/openai 分组：Gemini 请求经 Messages 处理链转发，返回分组模型目录
+	if !hasForcePlatform && isGeminiBridgeGroup(apiKey.Group) {
+		c.JSON(http.StatusOK, h.geminiCatalogModels(c, apiKey.Group))
+		return
+	}
 	if !hasForcePlatform && (apiKey.Group == nil || apiKey.Group.Platform != service.PlatformGemini) {
 		googleError(c, http.StatusBadRequest, "API key group platform is not gemini")
 		return
@@ -60,8 +65,8 @@ func (h *GatewayHandler) GeminiV1BetaListModels(c *gin.Context) {
 		// 没有 gemini 账户，检查是否有 antigravity 账户可用
 		hasAntigravity, _ := h.geminiCompatService.HasAntigravityAccounts(c.Request.Context(), apiKey.GroupID)
 		if hasAntigravity {
-			// antigravity 账户使用静态模型列表
-			c.JSON(http.StatusOK, gemini.FallbackModelsList())
+			// antigravity 账户使用模型目录
+			c.JSON(http.StatusOK, h.geminiCatalogModels(c, apiKey.Group))
 			return
 		}
 		googleError(c, http.StatusServiceUnavailable, "No available Gemini accounts: "+err.Error())
@@ -74,7 +79,7 @@ func (h *GatewayHandler) GeminiV1BetaListModels(c *gin.Context) {
 		return
 	}
 	if shouldFallbackGeminiModels(res) {
-		c.JSON(http.StatusOK, gemini.FallbackModelsList())
+		c.JSON(http.StatusOK, h.geminiCatalogModels(c, apiKey.Group))
 		return
 	}
 	writeUpstreamResponse(c, res)
@@ -90,7 +95,8 @@ func (h *GatewayHandler) GeminiV1BetaGetModel(c *gin.Context) {
 	}
 	// 检查平台：优先使用强制平台（/antigravity 路由），否则要求 gemini 分组
 	forcePlatform, hasForcePlatform := middleware.GetForcePlatformFromContext(c)
-	if !hasForcePlatform && (apiKey.Group == nil || apiKey.Group.Platform != service.PlatformGemini) {
+	bridged := !hasForcePlatform && isGeminiBridgeGroup(apiKey.Group)
+	if !hasForcePlatform && !bridged && (apiKey.Group == nil || apiKey.Group.Platform != service.PlatformGemini) {
 		googleError(c, http.StatusBadRequest, "API key group platform is not gemini")
 		return
 	}
@@ -101,6 +107,12 @@ func (h *GatewayHandler) GeminiV1BetaGetModel(c *gin.Context) {
 		return
 	}
 
+	// anthropic/openai 分组：返回模型目录中的模型信息
+	if bridged {
+		c.JSON(http.StatusOK, h.geminiCatalogModel(c, apiKey.Group, modelName))
+		return
+	}
+
 	// 强制 antigravity 模式：返回 antigravity 模型信息
 	if forcePlatform == service.PlatformAntigravity {
 		c.JSON(http.StatusOK, antigravity.FallbackGeminiModel(modelName))
@@ -135,6 +147,7 @@ func (h *GatewayHandler) GeminiV1BetaGetModel(c *gin.Context) {
 // GeminiV1BetaModels proxies Gemini native REST endpoints like:
 // POST /v1beta/models/{model}:generateContent
 // POST /v1beta/models/{model}:streamGenerateContent?alt=sse
+// POST /v1beta/models/{model}:embedContent / :batchEmbedContents（仅 Gemini API Key 账号）
 func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 	apiKey, ok := middleware.GetAPIKeyFromContext(c)
 	if !ok || apiKey == nil {
@@ -148,7 +161,12 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 	}
 
 	// 检查平台：优先使用强制平台（/antigravity 路由，中间件已设置 request.Context），否则要求 gemini 分组
+	// anthropic/openai 分组的请求转换为 Claude Messages 请求后转发
 	if !middleware.HasForcePlatform(c) {
+		if isGeminiBridgeGroup(apiKey.Group) {
+			h.geminiViaMessages(c, apiKey.Group)
+			return
+		}
 		if apiKey.Group == nil || apiKey.Group.Platform != service.PlatformGemini {
 			googleError(c, http.StatusBadRequest, "API key group platform is not gemini")
 			return
@@ -222,19 +240,48 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 	}
 
 	// 2) billing eligibility check (after wait)
-	if err := h.billingCacheService.CheckBillingEligibility(c.Request.Context(), apiKey.User, apiKey, apiKey.Group, subscription); err != nil {
+	if err := h.billingCacheService.CheckBillingEligibility(c.Request.Context(), apiKey.User, apiKey, apiKey.Group, subscription, modelName); err != nil {
 		status, _, message := billingErrorDetails(err)
 		googleError(c, status, message)
 		return
 	}
 
+	// local 模式：countTokens 直接本地估算，不占用上游账号
+	if action == "countTokens" && h.geminiCompatService.CountTokensLocalOnly() {
+		h.geminiCompatService.WriteLocalCountTokens(c, modelName, body)
+		return
+	}
+
+	// 余额模式下按估算费用预授权冻结（countTokens 不计费，无需冻结），由 RecordUsage 按实际费用结算
+	var balanceHold *service.BalanceHold
+	if action != "countTokens" {
+		hold, err := h.billingCacheService.ReserveBalanceHold(c.Request.Context(), apiKey.User, apiKey, apiKey.Group, subscription, modelName, body)
+		if err != nil {
+			status, _, message := billingErrorDetails(err)
+			googleError(c, status, message)
+			return
+		}
+		balanceHold = hold
+		// 请求已被接纳，计入 API Key 请求数限额
+		h.billingCacheService.CountAPIKeyRequest(c.Request.Context(), apiKey)
+	}
+	holdHandedOff := false
+	defer func() {
+		if !holdHandedOff {
+			h.billingCacheService.ReleaseBalanceHold(balanceHold)
+		}
+	}()
+
 	// 3) select account (sticky session based on request body)
-	// 优先使用 Gemini CLI 的会话标识（privileged-user-id + tmp 目录哈希）
-	sessionHash := extractGeminiCLISessionHash(c, body)
+	// 默认策略下优先使用 Gemini CLI 的会话标识（privileged-user-id + tmp 目录哈希）
+	sessionHash := ""
+	if apiKey.Group.EffectiveStickyPolicy() == service.StickyPolicyContentHash {
+		sessionHash = extractGeminiCLISessionHash(c, body)
+	}
 	if sessionHash == "" {
-		// Fallback: 使用通用的会话哈希生成逻辑（适用于其他客户端）
+		// Fallback: 按分组粘性策略计算会话哈希（适用于其他客户端）
 		parsedReq, _ := service.ParseGatewayRequest(body)
-		sessionHash = h.gatewayService.GenerateSessionHash(parsedReq)
+		sessionHash = h.gatewayService.ResolveSessionHash(apiKey.Group, apiKey.ID, parsedReq)
 	}
 	sessionKey := sessionHash
 	if sessionHash != "" {
@@ -257,7 +304,7 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 	for {
 		selection, err := h.gatewayService.SelectAccountWithLoadAwareness(c.Request.Context(), apiKey.GroupID, sessionKey, modelName, failedAccountIDs, "") // Gemini 不使用会话限制
 		if err != nil {
-			if len(failedAccountIDs) == 0 {
+			if len(failedAccountIDs) == 0 || lastFailoverStatus == 0 {
 				googleError(c, http.StatusServiceUnavailable, "No available Gemini accounts: "+err.Error())
 				return
 			}
@@ -265,6 +312,15 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 			return
 		}
 		account := selection.Account
+
+		// embedding 动作仅 Gemini API Key 账号支持，其余账号排除后重新选择（不计入切换次数）
+		if service.IsGeminiEmbeddingAction(action) && !account.SupportsEmbeddings() {
+			if selection.Acquired && selection.ReleaseFunc != nil {
+				selection.ReleaseFunc()
+			}
+			failedAccountIDs[account.ID] = struct{}{}
+			continue
+		}
 		setOpsSelectedAccount(c, account.ID)
 
 		// 检测账号切换：如果粘性会话绑定的账号与当前选择的账号不同，清除 thoughtSignature
@@ -326,6 +382,12 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 				geminiConcurrency.DecrementAccountWaitCount(c.Request.Context(), account.ID)
 				accountWaitCounted = false
 			}
+			// 等待后获取到槽位才领取熔断探测名额并计入 RPM 预算；已被其他请求占用时释放槽位并改选账号
+			if !h.gatewayService.ReserveAccountRequest(c.Request.Context(), account) {
+				accountReleaseFunc()
+				failedAccountIDs[account.ID] = struct{}{}
+				continue
+			}
 			if err := h.gatewayService.BindStickySession(c.Request.Context(), apiKey.GroupID, sessionKey, account.ID); err != nil {
 				log.Printf("Bind sticky session failed: %v", err)
 			}
@@ -366,8 +428,9 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 		userAgent := c.GetHeader("User-Agent")
 		clientIP := ip.GetClientIP(c)
 
-		// 6) record usage async
-		go func(result *service.ForwardResult, usedAccount *service.Account, ua, ip string) {
+		// 6) record usage async（冻结交由 RecordUsage 结算）
+		holdHandedOff = true
+		go func(result *service.ForwardResult, usedAccount *service.Account, ua, ip string, costRouting *service.CostRoutingDecision) {
 			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 			defer cancel()
 			if err := h.gatewayService.RecordUsage(ctx, &service.RecordUsageInput{
@@ -378,10 +441,13 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 				Subscription: subscription,
 				UserAgent:    ua,
 				IPAddress:    ip,
+				SessionHash:  sessionHash,
+				CostRouting:  costRouting,
+				BalanceHold:  balanceHold,
 			}); err != nil {
 				log.Printf("Record usage failed: %v", err)
 			}
-		}(result, account, userAgent, clientIP)
+		}(result, account, userAgent, clientIP, selection.CostRouting)
 		return
 	}
 }
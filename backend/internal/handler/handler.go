@@ -23,6 +23,10 @@ type AdminHandlers struct {
 	Subscription     *admin.SubscriptionHandler
 	Usage            *admin.UsageHandler
 	UserAttribute    *admin.UserAttributeHandler
+	Scheduler        *admin.SchedulerHandler
+	BalanceLedger    *admin.BalanceLedgerHandler
+	PricingOverride  *admin.PricingOverrideHandler
+	Topup            *admin.TopupHandler
 }
 
 // Handlers contains all HTTP handlers
@@ -38,6 +42,9 @@ type Handlers struct {
 	OpenAIGateway *OpenAIGatewayHandler
 	Setting       *SettingHandler
 	Totp          *TotpHandler
+	MessageBatch  *MessageBatchHandler
+	Files         *FilesHandler
+	Topup         *TopupHandler
 }
 
 // BuildInfo contains build-time information
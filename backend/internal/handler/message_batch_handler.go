@@ -0,0 +1,243 @@
+package handler
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	pkgerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MessageBatchHandler handles the Anthropic Message Batches API (gateway-side execution)
+type MessageBatchHandler struct {
+	messageBatchService *service.MessageBatchService
+}
+
+// NewMessageBatchHandler creates a new MessageBatchHandler
+func NewMessageBatchHandler(messageBatchService *service.MessageBatchService) *MessageBatchHandler {
+	return &MessageBatchHandler{messageBatchService: messageBatchService}
+}
+
+// messageBatchResponse Anthropic message_batch 对象
+type messageBatchResponse struct {
+	ID                string                            `json:"id"`
+	Type              string                            `json:"type"`
+	ProcessingStatus  string                            `json:"processing_status"`
+	RequestCounts     service.MessageBatchRequestCounts `json:"request_counts"`
+	EndedAt           *time.Time                        `json:"ended_at"`
+	CreatedAt         time.Time                         `json:"created_at"`
+	ExpiresAt         time.Time                         `json:"expires_at"`
+	ArchivedAt        *time.Time                        `json:"archived_at"`
+	CancelInitiatedAt *time.Time                        `json:"cancel_initiated_at"`
+	ResultsURL        *string                           `json:"results_url"`
+}
+
+func toMessageBatchResponse(b *service.MessageBatch) messageBatchResponse {
+	resp := messageBatchResponse{
+		ID:                b.BatchID,
+		Type:              "message_batch",
+		ProcessingStatus:  b.ProcessingStatus,
+		RequestCounts:     b.RequestCounts,
+		EndedAt:           utcTimePtr(b.EndedAt),
+		CreatedAt:         b.CreatedAt.UTC(),
+		ExpiresAt:         b.ExpiresAt.UTC(),
+		CancelInitiatedAt: utcTimePtr(b.CancelInitiatedAt),
+	}
+	if b.ProcessingStatus == service.MessageBatchStatusEnded {
+		url := "/v1/messages/batches/" + b.BatchID + "/results"
+		resp.ResultsURL = &url
+	}
+	return resp
+}
+
+func utcTimePtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	v := t.UTC()
+	return &v
+}
+
+// Create handles batch creation
+// POST /v1/messages/batches
+func (h *MessageBatchHandler) Create(c *gin.Context) {
+	apiKey, ok := middleware2.GetAPIKeyFromContext(c)
+	if !ok {
+		h.errorResponse(c, http.StatusUnauthorized, "authentication_error", "Invalid API key")
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		if maxErr, ok := extractMaxBytesError(err); ok {
+			h.errorResponse(c, http.StatusRequestEntityTooLarge, "invalid_request_error", buildBodyTooLargeMessage(maxErr.Limit))
+			return
+		}
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "Failed to read request body")
+		return
+	}
+	if len(body) == 0 {
+		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "Request body is empty")
+		return
+	}
+
+	batch, err := h.messageBatchService.CreateBatch(c.Request.Context(), apiKey, body)
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toMessageBatchResponse(batch))
+}
+
+// Get handles batch retrieval
+// GET /v1/messages/batches/:batch_id
+func (h *MessageBatchHandler) Get(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		h.errorResponse(c, http.StatusInternalServerError, "api_error", "User context not found")
+		return
+	}
+	batch, err := h.messageBatchService.GetBatch(c.Request.Context(), subject.UserID, c.Param("batch_id"))
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toMessageBatchResponse(batch))
+}
+
+// List handles batch listing (newest first, cursor pagination)
+// GET /v1/messages/batches?limit=&before_id=&after_id=
+func (h *MessageBatchHandler) List(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		h.errorResponse(c, http.StatusInternalServerError, "api_error", "User context not found")
+		return
+	}
+
+	params := service.MessageBatchListParams{
+		BeforeID: c.Query("before_id"),
+		AfterID:  c.Query("after_id"),
+	}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "limit: must be a positive integer")
+			return
+		}
+		params.Limit = limit
+	}
+
+	batches, hasMore, err := h.messageBatchService.ListBatches(c.Request.Context(), subject.UserID, params)
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+
+	data := make([]messageBatchResponse, 0, len(batches))
+	for i := range batches {
+		data = append(data, toMessageBatchResponse(&batches[i]))
+	}
+	var firstID, lastID *string
+	if len(batches) > 0 {
+		firstID = &batches[0].BatchID
+		lastID = &batches[len(batches)-1].BatchID
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":     data,
+		"has_more": hasMore,
+		"first_id": firstID,
+		"last_id":  lastID,
+	})
+}
+
+// Cancel handles batch cancellation
+// POST /v1/messages/batches/:batch_id/cancel
+func (h *MessageBatchHandler) Cancel(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		h.errorResponse(c, http.StatusInternalServerError, "api_error", "User context not found")
+		return
+	}
+	batch, err := h.messageBatchService.CancelBatch(c.Request.Context(), subject.UserID, c.Param("batch_id"))
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, toMessageBatchResponse(batch))
+}
+
+// Results streams batch results as JSONL
+// GET /v1/messages/batches/:batch_id/results
+func (h *MessageBatchHandler) Results(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		h.errorResponse(c, http.StatusInternalServerError, "api_error", "User context not found")
+		return
+	}
+
+	started := false
+	err := h.messageBatchService.StreamResults(c.Request.Context(), subject.UserID, c.Param("batch_id"), func(line []byte) error {
+		if !started {
+			started = true
+			c.Header("Content-Type", "application/x-jsonl")
+			c.Status(http.StatusOK)
+		}
+		if _, err := c.Writer.Write(line); err != nil {
+			return err
+		}
+		_, err := c.Writer.Write([]byte("\n"))
+		return err
+	})
+	if err != nil {
+		if started {
+			// 已开始输出，无法再返回错误响应
+			log.Printf("[MessageBatch] stream results aborted: %v", err)
+			return
+		}
+		h.serviceError(c, err)
+		return
+	}
+	if !started {
+		c.Data(http.StatusOK, "application/x-jsonl", nil)
+	}
+}
+
+// serviceError 将 service 层错误转换为 Anthropic 错误格式
+func (h *MessageBatchHandler) serviceError(c *gin.Context, err error) {
+	status := pkgerrors.Code(err)
+	message := pkgerrors.Message(err)
+	if status == http.StatusInternalServerError {
+		log.Printf("[MessageBatch] request failed: %v", err)
+		message = "Internal server error"
+	}
+	errType := "api_error"
+	switch status {
+	case http.StatusBadRequest:
+		errType = "invalid_request_error"
+	case http.StatusUnauthorized:
+		errType = "authentication_error"
+	case http.StatusForbidden:
+		errType = "permission_error"
+	case http.StatusNotFound:
+		errType = "not_found_error"
+	case http.StatusTooManyRequests:
+		errType = "rate_limit_error"
+	}
+	h.errorResponse(c, status, errType, message)
+}
+
+func (h *MessageBatchHandler) errorResponse(c *gin.Context, status int, errType, message string) {
+	c.JSON(status, gin.H{
+		"type": "error",
+		"error": gin.H{
+			"type":    errType,
+			"message": message,
+		},
+	})
+}
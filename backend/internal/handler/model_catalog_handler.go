@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/gemini"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 模型目录响应格式
+const (
+	modelCatalogFormatOpenAI    = "openai"
+	modelCatalogFormatAnthropic = "anthropic"
+	modelCatalogFormatGemini    = "gemini"
+)
+
+// modelCatalogCreatedFallback 模型缺少发布时间时使用的占位时间
+var modelCatalogCreatedFallback = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// modelCatalogMetadata 模型目录的扩展元数据（附加在各协议的标准字段之后）
+type modelCatalogMetadata struct {
+	ContextWindow     int                        `json:"context_window"`
+	MaxOutputTokens   int                        `json:"max_output_tokens"`
+	InputModalities   []string                   `json:"input_modalities"`
+	OutputModalities  []string                   `json:"output_modalities"`
+	SupportsTools     bool                       `json:"supports_tools"`
+	SupportsReasoning bool                       `json:"supports_reasoning"`
+	Pricing           *modelCatalogPublicPricing `json:"pricing,omitempty"`
+}
+
+// modelCatalogPublicPricing 面向用户的价格（已乘分组倍率，不暴露基础价格）
+type modelCatalogPublicPricing struct {
+	Currency          string  `json:"currency"`
+	InputPerMTok      float64 `json:"input_per_mtok"`
+	OutputPerMTok     float64 `json:"output_per_mtok"`
+	CacheWritePerMTok float64 `json:"cache_write_per_mtok"`
+	CacheReadPerMTok  float64 `json:"cache_read_per_mtok"`
+}
+
+type openAICatalogModel struct {
+	ID          string `json:"id"`
+	Object      string `json:"object"`
+	Created     int64  `json:"created"`
+	OwnedBy     string `json:"owned_by"`
+	Type        string `json:"type"`
+	DisplayName string `json:"display_name"`
+	modelCatalogMetadata
+}
+
+type anthropicCatalogModel struct {
+	ID          string `json:"id"`
+	Type        string `json:"type"`
+	DisplayName string `json:"display_name"`
+	CreatedAt   string `json:"created_at"`
+	modelCatalogMetadata
+}
+
+// resolveModelCatalogFormat 按 ?format 参数、anthropic-version 头与分组平台选择响应格式
+func resolveModelCatalogFormat(c *gin.Context, platform string) string {
+	switch strings.ToLower(strings.TrimSpace(c.Query("format"))) {
+	case modelCatalogFormatOpenAI:
+		return modelCatalogFormatOpenAI
+	case modelCatalogFormatAnthropic:
+		return modelCatalogFormatAnthropic
+	case modelCatalogFormatGemini:
+		return modelCatalogFormatGemini
+	}
+	if c.GetHeader("anthropic-version") != "" {
+		return modelCatalogFormatAnthropic
+	}
+	if platform == service.PlatformOpenAI {
+		return modelCatalogFormatOpenAI
+	}
+	return modelCatalogFormatAnthropic
+}
+
+// writeModelCatalog 以指定格式输出模型目录
+func writeModelCatalog(c *gin.Context, format string, entries []service.ModelCatalogEntry) {
+	switch format {
+	case modelCatalogFormatGemini:
+		c.JSON(http.StatusOK, modelCatalogToGemini(entries))
+	case modelCatalogFormatOpenAI:
+		data := make([]openAICatalogModel, 0, len(entries))
+		for i := range entries {
+			e := &entries[i]
+			data = append(data, openAICatalogModel{
+				ID:                   e.ID,
+				Object:               "model",
+				Created:              modelCatalogCreatedAt(e).Unix(),
+				OwnedBy:              e.OwnedBy,
+				Type:                 "model",
+				DisplayName:          e.DisplayName,
+				modelCatalogMetadata: newModelCatalogMetadata(e),
+			})
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"object": "list",
+			"data":   data,
+		})
+	default:
+		data := make([]anthropicCatalogModel, 0, len(entries))
+		for i := range entries {
+			e := &entries[i]
+			data = append(data, anthropicCatalogModel{
+				ID:                   e.ID,
+				Type:                 "model",
+				DisplayName:          e.DisplayName,
+				CreatedAt:            modelCatalogCreatedAt(e).Format(time.RFC3339),
+				modelCatalogMetadata: newModelCatalogMetadata(e),
+			})
+		}
+		resp := gin.H{
+			"object":   "list",
+			"data":     data,
+			"has_more": false,
+			"first_id": nil,
+			"last_id":  nil,
+		}
+		if len(data) > 0 {
+			resp["first_id"] = data[0].ID
+			resp["last_id"] = data[len(data)-1].ID
+		}
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// modelCatalogToGemini 转换为 Gemini v1beta models.list 格式
+func modelCatalogToGemini(entries []service.ModelCatalogEntry) gemini.ModelsListResponse {
+	methods := []string{"generateContent", "streamGenerateContent", "countTokens"}
+	models := make([]gemini.Model, 0, len(entries))
+	for i := range entries {
+		e := &entries[i]
+		models = append(models, gemini.Model{
+			Name:                       "models/" + e.ID,
+			DisplayName:                e.DisplayName,
+			InputTokenLimit:            e.ContextWindow,
+			OutputTokenLimit:           e.MaxOutputTokens,
+			SupportedGenerationMethods: methods,
+		})
+	}
+	return gemini.ModelsListResponse{Models: models}
+}
+
+func newModelCatalogMetadata(e *service.ModelCatalogEntry) modelCatalogMetadata {
+	meta := modelCatalogMetadata{
+		ContextWindow:     e.ContextWindow,
+		MaxOutputTokens:   e.MaxOutputTokens,
+		InputModalities:   e.InputModalities,
+		OutputModalities:  e.OutputModalities,
+		SupportsTools:     e.SupportsTools,
+		SupportsReasoning: e.SupportsReasoning,
+	}
+	if e.Pricing != nil {
+		meta.Pricing = &modelCatalogPublicPricing{
+			Currency:          e.Pricing.Currency,
+			InputPerMTok:      e.Pricing.InputPerMTok,
+			OutputPerMTok:     e.Pricing.OutputPerMTok,
+			CacheWritePerMTok: e.Pricing.CacheWritePerMTok,
+			CacheReadPerMTok:  e.Pricing.CacheReadPerMTok,
+		}
+	}
+	return meta
+}
+
+func modelCatalogCreatedAt(e *service.ModelCatalogEntry) time.Time {
+	if e.CreatedAt.IsZero() {
+		return modelCatalogCreatedFallback
+	}
+	return e.CreatedAt
+}
+
+// geminiCatalogModels 返回分组模型目录的 Gemini 格式；目录为空或失败时回退静态列表
+func (h *GatewayHandler) geminiCatalogModels(c *gin.Context, group *service.Group) gemini.ModelsListResponse {
+	entries, err := h.modelCatalogService.ListModels(c.Request.Context(), group, "")
+	if err != nil || len(entries) == 0 {
+		return gemini.FallbackModelsList()
+	}
+	return modelCatalogToGemini(entries)
+}
+
+// geminiCatalogModel 返回单个模型的 Gemini 格式信息
+func (h *GatewayHandler) geminiCatalogModel(c *gin.Context, group *service.Group, modelName string) gemini.Model {
+	id := strings.TrimPrefix(modelName, "models/")
+	entries, err := h.modelCatalogService.ListModels(c.Request.Context(), group, "")
+	if err == nil {
+		for i := range entries {
+			if entries[i].ID == id {
+				return modelCatalogToGemini(entries[i : i+1]).Models[0]
+			}
+		}
+	}
+	return gemini.FallbackModel(modelName)
+}
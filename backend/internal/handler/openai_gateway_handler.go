@@ -24,6 +24,7 @@ import (
 type OpenAIGatewayHandler struct {
 	gatewayService      *service.OpenAIGatewayService
 	billingCacheService *service.BillingCacheService
+	fileService         *service.GatewayFileService
 	concurrencyHelper   *ConcurrencyHelper
 	maxAccountSwitches  int
 }
@@ -33,6 +34,7 @@ func NewOpenAIGatewayHandler(
 	gatewayService *service.OpenAIGatewayService,
 	concurrencyService *service.ConcurrencyService,
 	billingCacheService *service.BillingCacheService,
+	fileService *service.GatewayFileService,
 	cfg *config.Config,
 ) *OpenAIGatewayHandler {
 	pingInterval := time.Duration(0)
@@ -46,6 +48,7 @@ func NewOpenAIGatewayHandler(
 	return &OpenAIGatewayHandler{
 		gatewayService:      gatewayService,
 		billingCacheService: billingCacheService,
+		fileService:         fileService,
 		concurrencyHelper:   NewConcurrencyHelper(concurrencyService, SSEPingFormatComment, pingInterval),
 		maxAccountSwitches:  maxAccountSwitches,
 	}
@@ -185,15 +188,40 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 	}
 
 	// 2. Re-check billing eligibility after wait
-	if err := h.billingCacheService.CheckBillingEligibility(c.Request.Context(), apiKey.User, apiKey, apiKey.Group, subscription); err != nil {
+	if err := h.billingCacheService.CheckBillingEligibility(c.Request.Context(), apiKey.User, apiKey, apiKey.Group, subscription, reqModel); err != nil {
 		log.Printf("Billing eligibility check failed after wait: %v", err)
 		status, code, message := billingErrorDetails(err)
 		h.handleStreamingAwareError(c, status, code, message, streamStarted)
 		return
 	}
 
-	// Generate session hash (header first; fallback to prompt_cache_key)
-	sessionHash := h.gatewayService.GenerateSessionHash(c, reqBody)
+	// 3. Hold the estimated cost from the available balance (balance mode); RecordUsage settles it,
+	// otherwise it is released when the handler returns
+	balanceHold, err := h.billingCacheService.ReserveBalanceHold(c.Request.Context(), apiKey.User, apiKey, apiKey.Group, subscription, reqModel, body)
+	if err != nil {
+		status, code, message := billingErrorDetails(err)
+		h.handleStreamingAwareError(c, status, code, message, streamStarted)
+		return
+	}
+	holdHandedOff := false
+	defer func() {
+		if !holdHandedOff {
+			h.billingCacheService.ReleaseBalanceHold(balanceHold)
+		}
+	}()
+	// Request admitted: count it toward the API key request quota
+	h.billingCacheService.CountAPIKeyRequest(c.Request.Context(), apiKey)
+
+	// Generate session hash per group sticky policy (default: header first; fallback to prompt_cache_key)
+	sessionHash := h.gatewayService.ResolveSessionHash(c, apiKey.Group, apiKey.ID, reqBody)
+	// 携带 previous_response_id 且无会话标识时，优先调度到生成该响应的账号（其他账号需在本地还原输入链）
+	if sessionHash == "" && apiKey.Group.EffectiveStickyPolicy() != service.StickyPolicyNone {
+		sessionHash = h.gatewayService.BindResponseAffinity(c.Request.Context(), apiKey.GroupID, apiKey.UserID, reqBody)
+	}
+	// 引用 Files API 上传的文件时固定到文件归属账号（文件仅存在于上传账号）
+	if fileSessionKey := h.fileService.BindFileAffinity(c.Request.Context(), apiKey, service.PlatformOpenAI, body); fileSessionKey != "" {
+		sessionHash = fileSessionKey
+	}
 
 	maxAccountSwitches := h.maxAccountSwitches
 	switchCount := 0
@@ -259,6 +287,12 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 				h.concurrencyHelper.DecrementAccountWaitCount(c.Request.Context(), account.ID)
 				accountWaitCounted = false
 			}
+			// Circuit probe permit and RPM budget are claimed only once the slot is acquired; if taken while waiting, release and pick another account
+			if !h.gatewayService.ReserveAccountRequest(c.Request.Context(), account) {
+				accountReleaseFunc()
+				failedAccountIDs[account.ID] = struct{}{}
+				continue
+			}
 			if err := h.gatewayService.BindStickySession(c.Request.Context(), apiKey.GroupID, sessionHash, account.ID); err != nil {
 				log.Printf("Bind sticky session failed: %v", err)
 			}
@@ -294,8 +328,9 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 		userAgent := c.GetHeader("User-Agent")
 		clientIP := ip.GetClientIP(c)
 
-		// Async record usage
-		go func(result *service.OpenAIForwardResult, usedAccount *service.Account, ua, ip string) {
+		// Async record usage; the balance hold is settled by RecordUsage
+		holdHandedOff = true
+		go func(result *service.OpenAIForwardResult, usedAccount *service.Account, ua, ip string, costRouting *service.CostRoutingDecision) {
 			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 			defer cancel()
 			if err := h.gatewayService.RecordUsage(ctx, &service.OpenAIRecordUsageInput{
@@ -306,10 +341,13 @@ func (h *OpenAIGatewayHandler) Responses(c *gin.Context) {
 				Subscription: subscription,
 				UserAgent:    ua,
 				IPAddress:    ip,
+				SessionHash:  sessionHash,
+				CostRouting:  costRouting,
+				BalanceHold:  balanceHold,
 			}); err != nil {
 				log.Printf("Record usage failed: %v", err)
 			}
-		}(result, account, userAgent, clientIP)
+		}(result, account, userAgent, clientIP, selection.CostRouting)
 		return
 	}
 }
@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// streamLineConverter 将上游格式的 SSE 行转换为目标协议的流式事件
+type streamLineConverter interface {
+	ProcessLine(line string) []byte
+	Finish() []byte
+}
+
+// protocolConvertWriter 拦截 Messages/Responses 处理链写出的响应并转换为客户端协议格式
+// （Chat Completions、Gemini 原生等）。
+//   - 流式成功响应：按行转换 SSE 事件并透传 flush
+//   - 非流式成功响应：缓冲完整响应体后整体转换
+//   - 错误响应（status >= 400）：缓冲后转换为目标协议的错误格式
+type protocolConvertWriter struct {
+	gin.ResponseWriter
+	stream      bool
+	converter   streamLineConverter
+	convertBody func([]byte) ([]byte, error)
+	errorBody   func(status int, body []byte) []byte
+	streamError func(body []byte) []byte
+
+	status    int
+	streaming bool
+	buffered  bool
+	pending   []byte
+	body      bytes.Buffer
+}
+
+func newProtocolConvertWriter(
+	w gin.ResponseWriter,
+	stream bool,
+	converter streamLineConverter,
+	convertBody func([]byte) ([]byte, error),
+	errorBody func(status int, body []byte) []byte,
+	streamError func(body []byte) []byte,
+) *protocolConvertWriter {
+	return &protocolConvertWriter{
+		ResponseWriter: w,
+		stream:         stream,
+		converter:      converter,
+		convertBody:    convertBody,
+		errorBody:      errorBody,
+		streamError:    streamError,
+	}
+}
+
+// serveConverted 用转换后的请求体替换原请求，并在响应转换写入器下执行原处理链
+func serveConverted(c *gin.Context, body []byte, w *protocolConvertWriter, next gin.HandlerFunc) {
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	c.Request.ContentLength = int64(len(body))
+
+	original := c.Writer
+	c.Writer = w
+	defer func() {
+		w.finish()
+		c.Writer = original
+	}()
+	next(c)
+}
+
+func (w *protocolConvertWriter) WriteHeader(code int) {
+	if w.streaming || w.buffered {
+		return
+	}
+	w.status = code
+}
+
+func (w *protocolConvertWriter) WriteHeaderNow() {}
+
+func (w *protocolConvertWriter) Status() int {
+	if w.status != 0 {
+		return w.status
+	}
+	return w.ResponseWriter.Status()
+}
+
+func (w *protocolConvertWriter) Written() bool {
+	return w.streaming || w.buffered || w.ResponseWriter.Written()
+}
+
+func (w *protocolConvertWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *protocolConvertWriter) Write(b []byte) (int, error) {
+	if !w.streaming && !w.buffered {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		contentType := strings.ToLower(w.Header().Get("Content-Type"))
+		if w.stream && w.status < http.StatusBadRequest && strings.Contains(contentType, "text/event-stream") {
+			w.streaming = true
+			w.Header().Del("Content-Length")
+			w.ResponseWriter.WriteHeader(w.status)
+		} else {
+			w.buffered = true
+		}
+	}
+
+	if w.buffered {
+		return w.body.Write(b)
+	}
+
+	w.pending = append(w.pending, b...)
+	for {
+		idx := bytes.IndexByte(w.pending, '\n')
+		if idx < 0 {
+			break
+		}
+		line := string(bytes.TrimRight(w.pending[:idx], "\r"))
+		w.pending = w.pending[idx+1:]
+		if err := w.writeLine(line); err != nil {
+			return 0, err
+		}
+	}
+	return len(b), nil
+}
+
+func (w *protocolConvertWriter) writeLine(line string) error {
+	var out []byte
+	switch {
+	case strings.HasPrefix(line, ":"):
+		// SSE 注释（keepalive）原样透传
+		out = []byte(line + "\n\n")
+	case strings.HasPrefix(line, "{"):
+		// 流已开始后写出的 JSON 错误体
+		out = w.streamError([]byte(line))
+	default:
+		out = w.converter.ProcessLine(line)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	_, err := w.ResponseWriter.Write(out)
+	return err
+}
+
+func (w *protocolConvertWriter) Flush() {
+	if w.streaming {
+		w.ResponseWriter.Flush()
+	}
+}
+
+// finish 在处理链返回后输出剩余内容
+func (w *protocolConvertWriter) finish() {
+	if w.streaming {
+		if len(w.pending) > 0 {
+			_ = w.writeLine(string(w.pending))
+			w.pending = nil
+		}
+		if out := w.converter.Finish(); len(out) > 0 {
+			_, _ = w.ResponseWriter.Write(out)
+		}
+		w.ResponseWriter.Flush()
+		return
+	}
+	if !w.buffered {
+		return
+	}
+
+	status := w.status
+	var out []byte
+	if status >= http.StatusBadRequest {
+		out = w.errorBody(status, w.body.Bytes())
+	} else {
+		converted, err := w.convertBody(w.body.Bytes())
+		if err != nil {
+			status = http.StatusBadGateway
+			out = w.errorBody(status, []byte(`{"error":{"type":"upstream_error","message":"Failed to convert upstream response"}}`))
+		} else {
+			out = converted
+		}
+	}
+	w.Header().Del("Content-Length")
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.ResponseWriter.WriteHeader(status)
+	_, _ = w.ResponseWriter.Write(out)
+}
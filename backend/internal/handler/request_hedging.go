@@ -0,0 +1,277 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// hedgeForwardFunc 在指定账号上执行一次转发，响应写入传入的 gin.Context。
+// 两个尝试并发执行，实现不得共享可变的请求状态（请求体、解析结果需按尝试复制）。
+type hedgeForwardFunc func(ctx context.Context, c *gin.Context, account *service.Account) (*service.ForwardResult, error)
+
+// hedgeBackupFunc 在排除给定账号后选择对冲账号（必须已获取槽位），返回 nil 表示本次不对冲
+type hedgeBackupFunc func(excluded map[int64]struct{}) *service.AccountSelectionResult
+
+// hedgeAttempt 一次对冲尝试：在独立的 gin.Context 副本与缓冲写入器上执行，胜出后才写回客户端
+type hedgeAttempt struct {
+	selection *service.AccountSelectionResult
+	c         *gin.Context
+	w         *hedgeResponseWriter
+	cancel    context.CancelFunc
+	start     time.Time
+
+	// headersReady 在收到上游首个响应字节（响应头）时关闭；headerLatency 为此时距开始的耗时
+	headersOnce   sync.Once
+	headersReady  chan struct{}
+	headerLatency time.Duration
+
+	result *service.ForwardResult
+	err    error
+}
+
+// hedgeOutcome 对冲结果：胜出（或最后失败）的尝试，以及其他 failover 失败的账号
+type hedgeOutcome struct {
+	selection      *service.AccountSelectionResult
+	result         *service.ForwardResult
+	err            error
+	otherFailedIDs []int64
+	hedged         bool
+	winnerIsBackup bool
+	// winnerHeaderLatency 胜出尝试收到上游响应头的耗时（用于对冲延迟统计）
+	winnerHeaderLatency time.Duration
+}
+
+func isFailoverError(err error) bool {
+	var failoverErr *service.UpstreamFailoverError
+	return errors.As(err, &failoverErr)
+}
+
+// startHedgeAttempt 启动一次尝试，完成后释放账号槽位并投递到 done
+// 通过 httptrace 观察上游首个响应字节，作为"已收到响应头"的信号。
+func startHedgeAttempt(parent *gin.Context, selection *service.AccountSelectionResult, forward hedgeForwardFunc, done chan<- *hedgeAttempt) *hedgeAttempt {
+	ctx, cancel := context.WithCancel(parent.Request.Context())
+	w := newHedgeResponseWriter()
+	a := &hedgeAttempt{selection: selection, w: w, cancel: cancel, start: time.Now(), headersReady: make(chan struct{})}
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{GotFirstResponseByte: a.markHeaders})
+
+	cp := parent.Copy()
+	cp.Request = parent.Request.WithContext(ctx)
+	cp.Writer = w
+	a.c = cp
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("[Hedging] attempt panic: account=%d err=%v", selection.Account.ID, r)
+				a.result = nil
+				a.err = fmt.Errorf("hedge attempt panic: %v", r)
+				if !w.Written() {
+					cp.JSON(http.StatusBadGateway, gin.H{
+						"type":  "error",
+						"error": gin.H{"type": "upstream_error", "message": "Upstream request failed"},
+					})
+				}
+			}
+			if selection.ReleaseFunc != nil {
+				selection.ReleaseFunc()
+			}
+			// 未观察到上游响应（如本地错误）时以完成时间兜底
+			a.markHeaders()
+			done <- a
+		}()
+		a.result, a.err = forward(ctx, cp, selection.Account)
+	}()
+	return a
+}
+
+// markHeaders 记录收到响应头的时间（仅首次生效）
+func (a *hedgeAttempt) markHeaders() {
+	a.headersOnce.Do(func() {
+		a.headerLatency = time.Since(a.start)
+		close(a.headersReady)
+	})
+}
+
+func (a *hedgeAttempt) headersReceived() bool {
+	select {
+	case <-a.headersReady:
+		return true
+	default:
+		return false
+	}
+}
+
+// runHedged 执行可对冲的转发：首个尝试超过对冲延迟仍未收到上游响应头时，在另一个账号上发起第二个尝试。
+// 先成功完成（或返回非 failover 错误）的尝试胜出：其响应写回客户端，另一个被取消。
+// 首个尝试在对冲前就 failover 失败时直接返回，由调用方按原有 failover 流程切换账号。
+func runHedged(c *gin.Context, delay time.Duration, primary *service.AccountSelectionResult, excluded map[int64]struct{}, forward hedgeForwardFunc, pickBackup hedgeBackupFunc) hedgeOutcome {
+	done := make(chan *hedgeAttempt, 2)
+	attempts := []*hedgeAttempt{startHedgeAttempt(c, primary, forward, done)}
+	pending := 1
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	timerC := timer.C
+
+	var failed []*hedgeAttempt
+	for pending > 0 {
+		select {
+		case <-timerC:
+			timerC = nil
+			if attempts[0].headersReceived() {
+				// 首个尝试已收到响应头，只是响应体较慢，不再对冲
+				continue
+			}
+			exclusions := make(map[int64]struct{}, len(excluded)+len(attempts))
+			for id := range excluded {
+				exclusions[id] = struct{}{}
+			}
+			for _, a := range attempts {
+				exclusions[a.selection.Account.ID] = struct{}{}
+			}
+			if backup := pickBackup(exclusions); backup != nil {
+				log.Printf("[Hedging] primary account %d got no response headers within %s, hedging on account %d", primary.Account.ID, delay, backup.Account.ID)
+				attempts = append(attempts, startHedgeAttempt(c, backup, forward, done))
+				pending++
+			}
+		case a := <-done:
+			pending--
+			if a.err != nil && isFailoverError(a.err) {
+				// 失败后不再发起新的对冲，等待仍在进行的尝试
+				timerC = nil
+				failed = append(failed, a)
+				continue
+			}
+			for _, other := range attempts {
+				if other != a {
+					other.cancel()
+				}
+			}
+			a.commit(c)
+			a.cancel()
+			return newHedgeOutcome(a, attempts, failed)
+		}
+	}
+
+	// 所有尝试均 failover 失败：返回最后一个，其余账号由调用方加入排除列表
+	last := failed[len(failed)-1]
+	last.commitKeys(c)
+	last.cancel()
+	return newHedgeOutcome(last, attempts, failed[:len(failed)-1])
+}
+
+func newHedgeOutcome(main *hedgeAttempt, attempts []*hedgeAttempt, otherFailed []*hedgeAttempt) hedgeOutcome {
+	out := hedgeOutcome{
+		selection:           main.selection,
+		result:              main.result,
+		err:                 main.err,
+		hedged:              len(attempts) > 1,
+		winnerIsBackup:      main != attempts[0],
+		winnerHeaderLatency: main.headerLatency,
+	}
+	for _, a := range otherFailed {
+		if a != main {
+			out.otherFailedIDs = append(out.otherFailedIDs, a.selection.Account.ID)
+		}
+	}
+	return out
+}
+
+// commit 将尝试的上下文键值与缓冲响应写回客户端上下文
+func (a *hedgeAttempt) commit(c *gin.Context) {
+	a.commitKeys(c)
+	a.w.flushTo(c.Writer)
+}
+
+// commitKeys 回写尝试期间设置的上下文键值（ops 上游错误、请求体快照等）
+func (a *hedgeAttempt) commitKeys(c *gin.Context) {
+	for k, v := range a.c.Keys {
+		c.Set(k, v)
+	}
+}
+
+// hedgeResponseWriter 缓冲一次尝试的完整响应（仅用于非流式请求）
+type hedgeResponseWriter struct {
+	header http.Header
+	status int
+	size   int
+	body   bytes.Buffer
+}
+
+var _ gin.ResponseWriter = (*hedgeResponseWriter)(nil)
+
+func newHedgeResponseWriter() *hedgeResponseWriter {
+	return &hedgeResponseWriter{header: make(http.Header), status: http.StatusOK, size: -1}
+}
+
+func (w *hedgeResponseWriter) Header() http.Header { return w.header }
+
+func (w *hedgeResponseWriter) WriteHeader(code int) {
+	if code > 0 && !w.Written() {
+		w.status = code
+	}
+}
+
+func (w *hedgeResponseWriter) WriteHeaderNow() {
+	if !w.Written() {
+		w.size = 0
+	}
+}
+
+func (w *hedgeResponseWriter) Write(b []byte) (int, error) {
+	w.WriteHeaderNow()
+	n, err := w.body.Write(b)
+	w.size += n
+	return n, err
+}
+
+func (w *hedgeResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *hedgeResponseWriter) Status() int { return w.status }
+
+func (w *hedgeResponseWriter) Size() int { return w.size }
+
+func (w *hedgeResponseWriter) Written() bool { return w.size != -1 }
+
+func (w *hedgeResponseWriter) Flush() {}
+
+func (w *hedgeResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, errors.New("hijack not supported for hedged requests")
+}
+
+func (w *hedgeResponseWriter) CloseNotify() <-chan bool {
+	return make(chan bool)
+}
+
+func (w *hedgeResponseWriter) Pusher() http.Pusher { return nil }
+
+// flushTo 将缓冲的响应头、状态码与响应体写入客户端
+func (w *hedgeResponseWriter) flushTo(dst gin.ResponseWriter) {
+	if !w.Written() {
+		return
+	}
+	for k, v := range w.header {
+		dst.Header()[k] = v
+	}
+	dst.WriteHeader(w.status)
+	if w.body.Len() == 0 {
+		dst.WriteHeaderNow()
+		return
+	}
+	_, _ = dst.Write(w.body.Bytes())
+}
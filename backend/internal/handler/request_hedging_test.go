@@ -0,0 +1,133 @@
+//go:build unit
+
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func newHedgeTestContext() (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/messages", nil)
+	return c, rec
+}
+
+func TestRunHedged_BackupWinsAndPrimaryIsCanceled(t *testing.T) {
+	c, rec := newHedgeTestContext()
+	primaryCanceled := make(chan struct{})
+	released := make(chan int64, 2)
+
+	forward := func(ctx context.Context, c *gin.Context, account *service.Account) (*service.ForwardResult, error) {
+		if account.ID == 1 {
+			<-ctx.Done()
+			close(primaryCanceled)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "canceled"})
+			return nil, ctx.Err()
+		}
+		c.Header("X-Account", "2")
+		c.JSON(http.StatusOK, gin.H{"id": "msg_backup"})
+		return &service.ForwardResult{RequestID: "backup"}, nil
+	}
+	selection := func(id int64) *service.AccountSelectionResult {
+		return &service.AccountSelectionResult{
+			Account:     &service.Account{ID: id},
+			Acquired:    true,
+			ReleaseFunc: func() { released <- id },
+		}
+	}
+
+	var gotExcluded map[int64]struct{}
+	outcome := runHedged(c, 10*time.Millisecond, selection(1), map[int64]struct{}{9: {}}, forward,
+		func(excluded map[int64]struct{}) *service.AccountSelectionResult {
+			gotExcluded = excluded
+			return selection(2)
+		})
+
+	require.NoError(t, outcome.err)
+	require.True(t, outcome.hedged)
+	require.True(t, outcome.winnerIsBackup)
+	require.Equal(t, int64(2), outcome.selection.Account.ID)
+	require.Equal(t, "backup", outcome.result.RequestID)
+	require.Equal(t, map[int64]struct{}{1: {}, 9: {}}, gotExcluded)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "2", rec.Header().Get("X-Account"))
+	require.JSONEq(t, `{"id":"msg_backup"}`, rec.Body.String())
+
+	select {
+	case <-primaryCanceled:
+	case <-time.After(time.Second):
+		t.Fatal("primary attempt was not canceled")
+	}
+	require.ElementsMatch(t, []int64{<-released, <-released}, []int64{1, 2})
+}
+
+func TestRunHedged_PrimaryFinishesBeforeDelay(t *testing.T) {
+	c, rec := newHedgeTestContext()
+	forward := func(ctx context.Context, c *gin.Context, account *service.Account) (*service.ForwardResult, error) {
+		c.JSON(http.StatusOK, gin.H{"id": "msg_primary"})
+		return &service.ForwardResult{RequestID: "primary"}, nil
+	}
+	outcome := runHedged(c, time.Minute, &service.AccountSelectionResult{Account: &service.Account{ID: 1}, Acquired: true}, nil, forward,
+		func(map[int64]struct{}) *service.AccountSelectionResult {
+			t.Fatal("backup should not be selected")
+			return nil
+		})
+
+	require.NoError(t, outcome.err)
+	require.False(t, outcome.hedged)
+	require.JSONEq(t, `{"id":"msg_primary"}`, rec.Body.String())
+}
+
+func TestRunHedged_AllAttemptsFailover(t *testing.T) {
+	c, rec := newHedgeTestContext()
+	forward := func(ctx context.Context, c *gin.Context, account *service.Account) (*service.ForwardResult, error) {
+		if account.ID == 1 {
+			time.Sleep(30 * time.Millisecond)
+		}
+		return nil, &service.UpstreamFailoverError{StatusCode: http.StatusServiceUnavailable}
+	}
+	outcome := runHedged(c, 5*time.Millisecond, &service.AccountSelectionResult{Account: &service.Account{ID: 1}, Acquired: true}, nil, forward,
+		func(map[int64]struct{}) *service.AccountSelectionResult {
+			return &service.AccountSelectionResult{Account: &service.Account{ID: 2}, Acquired: true}
+		})
+
+	require.True(t, isFailoverError(outcome.err))
+	require.Equal(t, int64(1), outcome.selection.Account.ID)
+	require.Equal(t, []int64{2}, outcome.otherFailedIDs)
+	require.False(t, c.Writer.Written())
+	require.Equal(t, 0, rec.Body.Len())
+}
+
+func TestRunHedged_NoHedgeOnceHeadersArrive(t *testing.T) {
+	c, rec := newHedgeTestContext()
+	forward := func(ctx context.Context, c *gin.Context, account *service.Account) (*service.ForwardResult, error) {
+		// 响应头很快到达，但响应体超过对冲延迟
+		time.Sleep(5 * time.Millisecond)
+		httptrace.ContextClientTrace(ctx).GotFirstResponseByte()
+		time.Sleep(50 * time.Millisecond)
+		c.JSON(http.StatusOK, gin.H{"id": "msg_primary"})
+		return &service.ForwardResult{RequestID: "primary"}, nil
+	}
+	outcome := runHedged(c, 20*time.Millisecond, &service.AccountSelectionResult{Account: &service.Account{ID: 1}, Acquired: true}, nil, forward,
+		func(map[int64]struct{}) *service.AccountSelectionResult {
+			t.Error("backup should not be selected after response headers arrived")
+			return nil
+		})
+
+	require.NoError(t, outcome.err)
+	require.False(t, outcome.hedged)
+	require.Less(t, outcome.winnerHeaderLatency, 50*time.Millisecond, "latency is measured to the first response header")
+	require.JSONEq(t, `{"id":"msg_primary"}`, rec.Body.String())
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPaymentWebhookBodySize limits payment webhook payloads
+const maxPaymentWebhookBodySize = 1 << 20
+
+// TopupHandler handles top-up orders and payment webhooks
+type TopupHandler struct {
+	topupService *service.TopupService
+}
+
+// NewTopupHandler creates a new TopupHandler
+func NewTopupHandler(topupService *service.TopupService) *TopupHandler {
+	return &TopupHandler{
+		topupService: topupService,
+	}
+}
+
+// CreateTopupOrderRequest represents the create top-up order payload.
+// Balance orders set amount; subscription orders set plan_id.
+type CreateTopupOrderRequest struct {
+	OrderType string  `json:"order_type" binding:"required,oneof=balance subscription"`
+	Amount    float64 `json:"amount"`
+	PlanID    string  `json:"plan_id"`
+	Provider  string  `json:"provider"`
+}
+
+// GetOptions returns top-up options (currency, limits, providers and subscription plans)
+// GET /api/v1/topup/options
+func (h *TopupHandler) GetOptions(c *gin.Context) {
+	response.Success(c, dto.TopupOptionsFromService(h.topupService.Options()))
+}
+
+// CreateOrder creates a top-up order and returns it with the payment URL
+// POST /api/v1/topup/orders
+func (h *TopupHandler) CreateOrder(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req CreateTopupOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	order, err := h.topupService.CreateOrder(c.Request.Context(), subject.UserID, &service.CreateTopupOrderInput{
+		OrderType: req.OrderType,
+		Amount:    req.Amount,
+		PlanID:    req.PlanID,
+		Provider:  req.Provider,
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.TopupOrderFromService(order))
+}
+
+// ListOrders lists the current user's top-up orders
+// GET /api/v1/topup/orders
+func (h *TopupHandler) ListOrders(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	page, pageSize := response.ParsePagination(c)
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	orders, result, err := h.topupService.ListUserOrders(c.Request.Context(), subject.UserID, params)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.TopupOrder, 0, len(orders))
+	for i := range orders {
+		out = append(out, *dto.TopupOrderFromService(&orders[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
+
+// GetOrder returns one of the current user's top-up orders
+// GET /api/v1/topup/orders/:order_no
+func (h *TopupHandler) GetOrder(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	order, err := h.topupService.GetUserOrder(c.Request.Context(), subject.UserID, c.Param("order_no"))
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.TopupOrderFromService(order))
+}
+
+// CancelOrder cancels a pending top-up order
+// POST /api/v1/topup/orders/:order_no/cancel
+func (h *TopupHandler) CancelOrder(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	order, err := h.topupService.CancelOrder(c.Request.Context(), subject.UserID, c.Param("order_no"))
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.TopupOrderFromService(order))
+}
+
+// HandleWebhook handles payment provider callbacks (public, verified by the provider's signature).
+// Duplicate callbacks for a paid order succeed without crediting twice; errors return non-2xx so the provider retries.
+// POST /api/v1/payment/webhook/:provider
+func (h *TopupHandler) HandleWebhook(c *gin.Context) {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxPaymentWebhookBodySize))
+	if err != nil {
+		response.BadRequest(c, "Failed to read request body")
+		return
+	}
+
+	if err := h.topupService.HandleWebhook(c.Request.Context(), c.Param("provider"), c.Request.Header, body); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"message": "ok"})
+}
@@ -1,8 +1,12 @@
 package handler
 
 import (
+	"time"
+
 	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
 	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
 	"github.com/Wei-Shaw/sub2api/internal/service"
 
@@ -11,13 +15,17 @@ import (
 
 // UserHandler handles user-related requests
 type UserHandler struct {
-	userService *service.UserService
+	userService         *service.UserService
+	ledgerService       *service.BalanceLedgerService
+	billingCacheService *service.BillingCacheService
 }
 
 // NewUserHandler creates a new UserHandler
-func NewUserHandler(userService *service.UserService) *UserHandler {
+func NewUserHandler(userService *service.UserService, ledgerService *service.BalanceLedgerService, billingCacheService *service.BillingCacheService) *UserHandler {
 	return &UserHandler{
-		userService: userService,
+		userService:         userService,
+		ledgerService:       ledgerService,
+		billingCacheService: billingCacheService,
 	}
 }
 
@@ -104,3 +112,78 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 
 	response.Success(c, dto.UserFromService(updatedUser))
 }
+
+// GetBalanceLedger returns the user's balance statement (newest first)
+// GET /api/v1/user/balance-ledger
+// Query: page, page_size, entry_type, start_date, end_date (YYYY-MM-DD), timezone
+func (h *UserHandler) GetBalanceLedger(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	page, pageSize := response.ParsePagination(c)
+	filters := service.BalanceLedgerFilters{EntryType: c.Query("entry_type")}
+
+	userTZ := c.Query("timezone")
+	if startDateStr := c.Query("start_date"); startDateStr != "" {
+		t, err := timezone.ParseInUserLocation("2006-01-02", startDateStr, userTZ)
+		if err != nil {
+			response.BadRequest(c, "Invalid start_date format, use YYYY-MM-DD")
+			return
+		}
+		filters.StartTime = &t
+	}
+	if endDateStr := c.Query("end_date"); endDateStr != "" {
+		t, err := timezone.ParseInUserLocation("2006-01-02", endDateStr, userTZ)
+		if err != nil {
+			response.BadRequest(c, "Invalid end_date format, use YYYY-MM-DD")
+			return
+		}
+		t = t.Add(24*time.Hour - time.Nanosecond)
+		filters.EndTime = &t
+	}
+
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	entries, result, err := h.ledgerService.ListStatement(c.Request.Context(), subject.UserID, params, filters)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.BalanceLedgerEntry, 0, len(entries))
+	for i := range entries {
+		out = append(out, *dto.BalanceLedgerEntryFromService(&entries[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
+
+// BalanceHoldsResponse lists the balance currently held by in-flight requests
+type BalanceHoldsResponse struct {
+	Holds     []dto.BalanceHold `json:"holds"`
+	TotalHeld float64           `json:"total_held"`
+}
+
+// GetBalanceHolds returns the balance holds of the user's in-flight requests
+// GET /api/v1/user/balance-holds
+func (h *UserHandler) GetBalanceHolds(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	holds, err := h.billingCacheService.ListBalanceHolds(c.Request.Context(), subject.UserID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := BalanceHoldsResponse{Holds: make([]dto.BalanceHold, 0, len(holds))}
+	for i := range holds {
+		out.Holds = append(out.Holds, *dto.BalanceHoldFromService(&holds[i]))
+		out.TotalHeld += holds[i].Amount
+	}
+	response.Success(c, out)
+}
@@ -26,6 +26,10 @@ func ProvideAdminHandlers(
 	subscriptionHandler *admin.SubscriptionHandler,
 	usageHandler *admin.UsageHandler,
 	userAttributeHandler *admin.UserAttributeHandler,
+	schedulerHandler *admin.SchedulerHandler,
+	balanceLedgerHandler *admin.BalanceLedgerHandler,
+	pricingOverrideHandler *admin.PricingOverrideHandler,
+	topupHandler *admin.TopupHandler,
 ) *AdminHandlers {
 	return &AdminHandlers{
 		Dashboard:        dashboardHandler,
@@ -45,6 +49,10 @@ func ProvideAdminHandlers(
 		Subscription:     subscriptionHandler,
 		Usage:            usageHandler,
 		UserAttribute:    userAttributeHandler,
+		Scheduler:        schedulerHandler,
+		BalanceLedger:    balanceLedgerHandler,
+		PricingOverride:  pricingOverrideHandler,
+		Topup:            topupHandler,
 	}
 }
 
@@ -71,6 +79,9 @@ func ProvideHandlers(
 	openaiGatewayHandler *OpenAIGatewayHandler,
 	settingHandler *SettingHandler,
 	totpHandler *TotpHandler,
+	messageBatchHandler *MessageBatchHandler,
+	filesHandler *FilesHandler,
+	topupHandler *TopupHandler,
 ) *Handlers {
 	return &Handlers{
 		Auth:          authHandler,
@@ -84,6 +95,9 @@ func ProvideHandlers(
 		OpenAIGateway: openaiGatewayHandler,
 		Setting:       settingHandler,
 		Totp:          totpHandler,
+		MessageBatch:  messageBatchHandler,
+		Files:         filesHandler,
+		Topup:         topupHandler,
 	}
 }
 
@@ -99,6 +113,9 @@ var ProviderSet = wire.NewSet(
 	NewGatewayHandler,
 	NewOpenAIGatewayHandler,
 	NewTotpHandler,
+	NewMessageBatchHandler,
+	NewFilesHandler,
+	NewTopupHandler,
 	ProvideSettingHandler,
 
 	// Admin handlers
@@ -119,6 +136,10 @@ var ProviderSet = wire.NewSet(
 	admin.NewSubscriptionHandler,
 	admin.NewUsageHandler,
 	admin.NewUserAttributeHandler,
+	admin.NewSchedulerHandler,
+	admin.NewBalanceLedgerHandler,
+	admin.NewPricingOverrideHandler,
+	admin.NewTopupHandler,
 
 	// AdminHandlers and Handlers constructors
 	ProvideAdminHandlers,
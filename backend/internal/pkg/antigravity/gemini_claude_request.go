@@ -0,0 +1,328 @@
+package antigravity
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Gemini 原生请求 → Claude Messages 请求（反向转换，供 Gemini CLI/SDK 使用 Claude/OpenAI 账号）
+
+const (
+	// geminiToClaudeDefaultMaxTokens Gemini 请求未指定 maxOutputTokens 时的默认值（Claude 必填）
+	geminiToClaudeDefaultMaxTokens = 8192
+	// geminiToClaudeDynamicThinkingBudget thinkingBudget=-1（动态）时使用的预算
+	geminiToClaudeDynamicThinkingBudget = 8192
+	// claudeMinThinkingBudget Claude 要求的最小 thinking 预算
+	claudeMinThinkingBudget = 1024
+)
+
+// TransformGeminiRequestToClaude 将 Gemini generateContent 请求转换为 Claude Messages 请求
+func TransformGeminiRequestToClaude(geminiReq *GeminiRequest, model string, stream bool) (*ClaudeRequest, error) {
+	if geminiReq == nil {
+		return nil, fmt.Errorf("empty gemini request")
+	}
+
+	req := &ClaudeRequest{
+		Model:     model,
+		MaxTokens: geminiToClaudeDefaultMaxTokens,
+		Stream:    stream,
+	}
+
+	if geminiReq.SystemInstruction != nil {
+		var blocks []SystemBlock
+		for _, part := range geminiReq.SystemInstruction.Parts {
+			if strings.TrimSpace(part.Text) == "" {
+				continue
+			}
+			blocks = append(blocks, SystemBlock{Type: "text", Text: part.Text})
+		}
+		if len(blocks) > 0 {
+			system, err := json.Marshal(blocks)
+			if err != nil {
+				return nil, fmt.Errorf("marshal system: %w", err)
+			}
+			req.System = system
+		}
+	}
+
+	messages, err := buildClaudeMessagesFromGemini(geminiReq.Contents)
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("contents is required")
+	}
+	req.Messages = messages
+
+	applyGeminiGenerationConfig(req, geminiReq.GenerationConfig)
+
+	req.Tools = buildClaudeToolsFromGemini(geminiReq.Tools)
+	if len(req.Tools) > 0 {
+		req.ToolChoice = buildClaudeToolChoice(geminiReq.ToolConfig)
+	}
+
+	return req, nil
+}
+
+// buildClaudeMessagesFromGemini 转换 contents，并合并相邻的同角色消息（Claude 要求 user/assistant 交替）
+func buildClaudeMessagesFromGemini(contents []GeminiContent) ([]ClaudeMessage, error) {
+	var (
+		messages []ClaudeMessage
+		role     string
+		blocks   []ContentBlock
+	)
+	// Gemini functionCall 的 id 可选；按函数名排队，为 functionResponse 找到对应的 tool_use_id
+	pendingToolIDs := make(map[string][]string)
+	toolSeq := 0
+
+	flush := func() error {
+		if len(blocks) == 0 {
+			return nil
+		}
+		content, err := json.Marshal(blocks)
+		if err != nil {
+			return fmt.Errorf("marshal content: %w", err)
+		}
+		messages = append(messages, ClaudeMessage{Role: role, Content: content})
+		blocks = nil
+		return nil
+	}
+
+	for _, content := range contents {
+		msgRole := "user"
+		if content.Role == "model" {
+			msgRole = "assistant"
+		}
+
+		var msgBlocks []ContentBlock
+		for i := range content.Parts {
+			part := &content.Parts[i]
+			switch {
+			case part.FunctionCall != nil:
+				id := part.FunctionCall.ID
+				if id == "" {
+					toolSeq++
+					id = fmt.Sprintf("toolu_gemini_%d", toolSeq)
+				}
+				pendingToolIDs[part.FunctionCall.Name] = append(pendingToolIDs[part.FunctionCall.Name], id)
+				input := part.FunctionCall.Args
+				if input == nil {
+					input = map[string]any{}
+				}
+				msgBlocks = append(msgBlocks, ContentBlock{Type: "tool_use", ID: id, Name: part.FunctionCall.Name, Input: input})
+			case part.FunctionResponse != nil:
+				fr := part.FunctionResponse
+				id := fr.ID
+				if queue := pendingToolIDs[fr.Name]; len(queue) > 0 {
+					if id == "" {
+						id = queue[0]
+					}
+					pendingToolIDs[fr.Name] = removeToolID(queue, id)
+				}
+				if id == "" {
+					toolSeq++
+					id = fmt.Sprintf("toolu_gemini_%d", toolSeq)
+				}
+				text, isError := geminiFunctionResponseText(fr.Response)
+				result, err := json.Marshal(text)
+				if err != nil {
+					return nil, fmt.Errorf("marshal function response: %w", err)
+				}
+				msgBlocks = append(msgBlocks, ContentBlock{Type: "tool_result", ToolUseID: id, Content: result, IsError: isError})
+			case part.InlineData != nil:
+				block, err := geminiInlineDataToClaude(part.InlineData)
+				if err != nil {
+					return nil, err
+				}
+				msgBlocks = append(msgBlocks, block)
+			case part.Thought:
+				// 历史 thought 无法复用签名，直接丢弃
+				continue
+			case part.Text != "":
+				msgBlocks = append(msgBlocks, ContentBlock{Type: "text", Text: part.Text})
+			}
+		}
+		if len(msgBlocks) == 0 {
+			continue
+		}
+
+		if msgRole != role {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			role = msgRole
+		}
+		blocks = append(blocks, msgBlocks...)
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+func removeToolID(queue []string, id string) []string {
+	for i, v := range queue {
+		if v == id {
+			return append(queue[:i:i], queue[i+1:]...)
+		}
+	}
+	return queue
+}
+
+// geminiFunctionResponseText 提取 functionResponse 的结果文本
+// Gemini CLI 使用 {"output": "..."} / {"error": "..."}，其余结构整体序列化
+func geminiFunctionResponseText(response map[string]any) (string, bool) {
+	if len(response) == 0 {
+		return "", false
+	}
+	if len(response) == 1 {
+		if v, ok := response["output"].(string); ok {
+			return v, false
+		}
+		if v, ok := response["content"].(string); ok {
+			return v, false
+		}
+		if v, ok := response["error"].(string); ok {
+			return v, true
+		}
+	}
+	b, err := json.Marshal(response)
+	if err != nil {
+		return "", false
+	}
+	return string(b), false
+}
+
+// geminiInlineDataToClaude 转换 inlineData：图片 → image，PDF → document，文本 → text
+func geminiInlineDataToClaude(data *GeminiInlineData) (ContentBlock, error) {
+	mimeType := strings.ToLower(strings.TrimSpace(data.MimeType))
+	switch {
+	case strings.HasPrefix(mimeType, "image/"):
+		return ContentBlock{Type: "image", Source: &ImageSource{Type: "base64", MediaType: mimeType, Data: data.Data}}, nil
+	case mimeType == "application/pdf":
+		return ContentBlock{Type: "document", Source: &ImageSource{Type: "base64", MediaType: mimeType, Data: data.Data}}, nil
+	case strings.HasPrefix(mimeType, "text/"):
+		decoded, err := base64.StdEncoding.DecodeString(data.Data)
+		if err != nil {
+			return ContentBlock{}, fmt.Errorf("decode inlineData: %w", err)
+		}
+		return ContentBlock{Type: "text", Text: string(decoded)}, nil
+	default:
+		return ContentBlock{}, fmt.Errorf("unsupported inlineData mimeType: %s", data.MimeType)
+	}
+}
+
+// applyGeminiGenerationConfig 转换 generationConfig（thinking 开启时 Claude 不接受 temperature/top_p/top_k）
+func applyGeminiGenerationConfig(req *ClaudeRequest, cfg *GeminiGenerationConfig) {
+	if cfg == nil {
+		return
+	}
+	if cfg.MaxOutputTokens > 0 {
+		req.MaxTokens = cfg.MaxOutputTokens
+	}
+	if len(cfg.StopSequences) > 0 {
+		req.StopSequences = cfg.StopSequences
+	}
+
+	if tc := cfg.ThinkingConfig; tc != nil && tc.ThinkingBudget != 0 {
+		budget := tc.ThinkingBudget
+		if budget < 0 {
+			budget = geminiToClaudeDynamicThinkingBudget
+		}
+		if budget < claudeMinThinkingBudget {
+			budget = claudeMinThinkingBudget
+		}
+		if req.MaxTokens <= budget {
+			req.MaxTokens = budget + geminiToClaudeDefaultMaxTokens
+		}
+		req.Thinking = &ThinkingConfig{Type: "enabled", BudgetTokens: budget}
+		return
+	}
+
+	req.Temperature = cfg.Temperature
+	req.TopP = cfg.TopP
+	req.TopK = cfg.TopK
+}
+
+// buildClaudeToolsFromGemini 转换 functionDeclarations；googleSearch 转为 Claude web_search 工具
+func buildClaudeToolsFromGemini(tools []GeminiToolDeclaration) []ClaudeTool {
+	var out []ClaudeTool
+	for _, tool := range tools {
+		for _, decl := range tool.FunctionDeclarations {
+			if strings.TrimSpace(decl.Name) == "" {
+				continue
+			}
+			schema := decl.ParametersJSONSchema
+			if schema == nil {
+				schema = decl.Parameters
+			}
+			if schema == nil {
+				schema = map[string]any{"type": "object", "properties": map[string]any{}}
+			} else {
+				schema, _ = normalizeGeminiSchema(schema).(map[string]any)
+			}
+			out = append(out, ClaudeTool{Name: decl.Name, Description: decl.Description, InputSchema: schema})
+		}
+		if tool.GoogleSearch != nil {
+			out = append(out, ClaudeTool{Type: "web_search_20250305", Name: "web_search"})
+		}
+	}
+	return out
+}
+
+// normalizeGeminiSchema 将 Gemini OpenAPI 风格 schema（type 为大写 OBJECT/STRING）转换为标准 JSON Schema
+func normalizeGeminiSchema(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, child := range val {
+			if k == "type" {
+				switch t := child.(type) {
+				case string:
+					out[k] = strings.ToLower(t)
+					continue
+				case []any:
+					types := make([]any, 0, len(t))
+					for _, item := range t {
+						if s, ok := item.(string); ok {
+							types = append(types, strings.ToLower(s))
+						} else {
+							types = append(types, item)
+						}
+					}
+					out[k] = types
+					continue
+				}
+			}
+			out[k] = normalizeGeminiSchema(child)
+		}
+		return out
+	case []any:
+		out := make([]any, len(val))
+		for i, item := range val {
+			out[i] = normalizeGeminiSchema(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// buildClaudeToolChoice 转换 toolConfig.functionCallingConfig.mode
+func buildClaudeToolChoice(cfg *GeminiToolConfig) json.RawMessage {
+	if cfg == nil || cfg.FunctionCallingConfig == nil {
+		return nil
+	}
+	switch strings.ToUpper(cfg.FunctionCallingConfig.Mode) {
+	case "ANY":
+		return json.RawMessage(`{"type":"any"}`)
+	case "NONE":
+		return json.RawMessage(`{"type":"none"}`)
+	case "AUTO", "VALIDATED":
+		return json.RawMessage(`{"type":"auto"}`)
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,256 @@
+package antigravity
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/googleapi"
+)
+
+// Claude Messages 响应 → Gemini 原生响应（反向转换）
+
+// TransformClaudeResponseToGemini 将 Claude 非流式响应转换为 Gemini generateContent 响应
+func TransformClaudeResponseToGemini(claudeResp []byte) ([]byte, error) {
+	var resp ClaudeResponse
+	if err := json.Unmarshal(claudeResp, &resp); err != nil {
+		return nil, fmt.Errorf("parse claude response: %w", err)
+	}
+
+	parts := make([]GeminiPart, 0, len(resp.Content))
+	for _, item := range resp.Content {
+		switch item.Type {
+		case "text":
+			if item.Text != "" {
+				parts = append(parts, GeminiPart{Text: item.Text})
+			}
+		case "thinking":
+			if item.Thinking != "" {
+				parts = append(parts, GeminiPart{Text: item.Thinking, Thought: true})
+			}
+		case "tool_use":
+			parts = append(parts, GeminiPart{FunctionCall: &GeminiFunctionCall{Name: item.Name, Args: item.Input, ID: item.ID}})
+		}
+	}
+
+	out := GeminiResponse{
+		Candidates: []GeminiCandidate{{
+			Content:      &GeminiContent{Role: "model", Parts: parts},
+			FinishReason: claudeStopReasonToGemini(resp.StopReason),
+		}},
+		UsageMetadata: claudeUsageToGemini(resp.Usage),
+		ResponseID:    resp.ID,
+		ModelVersion:  resp.Model,
+	}
+	return json.Marshal(out)
+}
+
+// claudeStopReasonToGemini 映射 stop_reason → finishReason
+func claudeStopReasonToGemini(stopReason string) string {
+	switch stopReason {
+	case "max_tokens":
+		return "MAX_TOKENS"
+	case "refusal":
+		return "SAFETY"
+	default:
+		return "STOP"
+	}
+}
+
+func claudeUsageToGemini(usage ClaudeUsage) *GeminiUsageMetadata {
+	prompt := usage.InputTokens + usage.CacheReadInputTokens + usage.CacheCreationInputTokens
+	return &GeminiUsageMetadata{
+		PromptTokenCount:        prompt,
+		CandidatesTokenCount:    usage.OutputTokens,
+		CachedContentTokenCount: usage.CacheReadInputTokens,
+		TotalTokenCount:         prompt + usage.OutputTokens,
+	}
+}
+
+// ClaudeErrorToGemini 将 Claude 错误响应转换为 Google 错误格式
+func ClaudeErrorToGemini(status int, body []byte) []byte {
+	message := strings.TrimSpace(string(body))
+	var claudeErr ClaudeError
+	if err := json.Unmarshal(body, &claudeErr); err == nil && claudeErr.Error.Message != "" {
+		message = claudeErr.Error.Message
+	} else {
+		// OpenAI 风格 {"error":{"message":...}}
+		var generic struct {
+			Error struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(body, &generic); err == nil && generic.Error.Message != "" {
+			message = generic.Error.Message
+		}
+	}
+	if message == "" {
+		message = "Upstream request failed"
+	}
+	out, _ := json.Marshal(map[string]any{
+		"error": map[string]any{
+			"code":    status,
+			"message": message,
+			"status":  googleapi.HTTPStatusToGoogleStatus(status),
+		},
+	})
+	return out
+}
+
+// ClaudeToGeminiStreamProcessor 将 Claude SSE 事件转换为 Gemini streamGenerateContent SSE 事件
+type ClaudeToGeminiStreamProcessor struct {
+	responseID string
+	model      string
+	usage      ClaudeUsage
+	finished   bool
+
+	// 当前 tool_use 块（参数以 input_json_delta 增量到达，块结束时整体输出）
+	toolID   string
+	toolName string
+	toolArgs strings.Builder
+	inTool   bool
+}
+
+// NewClaudeToGeminiStreamProcessor 创建流式转换处理器
+func NewClaudeToGeminiStreamProcessor(model string) *ClaudeToGeminiStreamProcessor {
+	return &ClaudeToGeminiStreamProcessor{model: model}
+}
+
+// ProcessLine 处理一行 Claude SSE，返回零或多个 Gemini SSE 事件
+func (p *ClaudeToGeminiStreamProcessor) ProcessLine(line string) []byte {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "data:") {
+		return nil
+	}
+	data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+	if data == "" || data == "[DONE]" {
+		return nil
+	}
+
+	var event struct {
+		Type    string          `json:"type"`
+		Message *ClaudeResponse `json:"message"`
+		Index   int             `json:"index"`
+		Block   *struct {
+			Type string `json:"type"`
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"content_block"`
+		Delta *struct {
+			Type        string `json:"type"`
+			Text        string `json:"text"`
+			Thinking    string `json:"thinking"`
+			PartialJSON string `json:"partial_json"`
+			StopReason  string `json:"stop_reason"`
+		} `json:"delta"`
+		Usage *ClaudeUsage `json:"usage"`
+		Error *ErrorDetail `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		return nil
+	}
+
+	switch event.Type {
+	case "message_start":
+		if event.Message != nil {
+			p.responseID = event.Message.ID
+			if event.Message.Model != "" {
+				p.model = event.Message.Model
+			}
+			p.usage = event.Message.Usage
+		}
+	case "content_block_start":
+		if event.Block != nil && event.Block.Type == "tool_use" {
+			p.inTool = true
+			p.toolID = event.Block.ID
+			p.toolName = event.Block.Name
+			p.toolArgs.Reset()
+		}
+	case "content_block_delta":
+		if event.Delta == nil {
+			return nil
+		}
+		switch event.Delta.Type {
+		case "text_delta":
+			if event.Delta.Text != "" {
+				return p.emitParts([]GeminiPart{{Text: event.Delta.Text}}, "", nil)
+			}
+		case "thinking_delta":
+			if event.Delta.Thinking != "" {
+				return p.emitParts([]GeminiPart{{Text: event.Delta.Thinking, Thought: true}}, "", nil)
+			}
+		case "input_json_delta":
+			if p.inTool {
+				p.toolArgs.WriteString(event.Delta.PartialJSON)
+			}
+		}
+	case "content_block_stop":
+		if p.inTool {
+			p.inTool = false
+			var args any = map[string]any{}
+			if raw := strings.TrimSpace(p.toolArgs.String()); raw != "" {
+				_ = json.Unmarshal([]byte(raw), &args)
+			}
+			return p.emitParts([]GeminiPart{{FunctionCall: &GeminiFunctionCall{Name: p.toolName, Args: args, ID: p.toolID}}}, "", nil)
+		}
+	case "message_delta":
+		if event.Usage != nil {
+			p.usage.OutputTokens = event.Usage.OutputTokens
+			if event.Usage.InputTokens > 0 {
+				p.usage.InputTokens = event.Usage.InputTokens
+			}
+		}
+		stopReason := ""
+		if event.Delta != nil {
+			stopReason = event.Delta.StopReason
+		}
+		p.finished = true
+		return p.emitParts(nil, claudeStopReasonToGemini(stopReason), claudeUsageToGemini(p.usage))
+	case "error":
+		message := "Upstream stream error"
+		if event.Error != nil && event.Error.Message != "" {
+			message = event.Error.Message
+		}
+		p.finished = true
+		out, _ := json.Marshal(map[string]any{
+			"error": map[string]any{"code": 500, "message": message, "status": "INTERNAL"},
+		})
+		return formatGeminiSSE(out)
+	}
+	return nil
+}
+
+// Finish 流结束时调用；上游未发送 message_delta 时补发结束事件
+func (p *ClaudeToGeminiStreamProcessor) Finish() []byte {
+	if p.finished {
+		return nil
+	}
+	p.finished = true
+	return p.emitParts(nil, "STOP", claudeUsageToGemini(p.usage))
+}
+
+func (p *ClaudeToGeminiStreamProcessor) emitParts(parts []GeminiPart, finishReason string, usage *GeminiUsageMetadata) []byte {
+	candidate := GeminiCandidate{FinishReason: finishReason}
+	if len(parts) > 0 {
+		candidate.Content = &GeminiContent{Role: "model", Parts: parts}
+	}
+	out, err := json.Marshal(GeminiResponse{
+		Candidates:    []GeminiCandidate{candidate},
+		UsageMetadata: usage,
+		ResponseID:    p.responseID,
+		ModelVersion:  p.model,
+	})
+	if err != nil {
+		return nil
+	}
+	return formatGeminiSSE(out)
+}
+
+func formatGeminiSSE(payload []byte) []byte {
+	return []byte("data: " + string(payload) + "\n\n")
+}
+
+// ClaudeStreamErrorToGemini 将流开始后写出的 Claude JSON 错误体转换为 Gemini SSE 错误事件
+func ClaudeStreamErrorToGemini(body []byte) []byte {
+	return formatGeminiSSE(ClaudeErrorToGemini(500, body))
+}
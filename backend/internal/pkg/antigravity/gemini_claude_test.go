@@ -0,0 +1,178 @@
+package antigravity
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestTransformGeminiRequestToClaude(t *testing.T) {
+	body := `{
+		"systemInstruction": {"parts": [{"text": "You are helpful."}]},
+		"contents": [
+			{"role": "user", "parts": [{"text": "What is the weather?"}, {"inlineData": {"mimeType": "image/png", "data": "AAAA"}}]},
+			{"role": "model", "parts": [{"functionCall": {"name": "get_weather", "args": {"city": "Paris"}}}]},
+			{"role": "user", "parts": [{"functionResponse": {"name": "get_weather", "response": {"output": "sunny"}}}]}
+		],
+		"tools": [{"functionDeclarations": [{"name": "get_weather", "description": "Get weather", "parameters": {"type": "OBJECT", "properties": {"city": {"type": "STRING"}}}}]}],
+		"toolConfig": {"functionCallingConfig": {"mode": "ANY"}},
+		"generationConfig": {"maxOutputTokens": 512, "temperature": 0.3, "stopSequences": ["END"]}
+	}`
+	var req GeminiRequest
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	out, err := TransformGeminiRequestToClaude(&req, "claude-sonnet-4-5", true)
+	if err != nil {
+		t.Fatalf("transform: %v", err)
+	}
+	if out.Model != "claude-sonnet-4-5" || !out.Stream || out.MaxTokens != 512 {
+		t.Fatalf("unexpected request header fields: %+v", out)
+	}
+	if out.Temperature == nil || *out.Temperature != 0.3 {
+		t.Fatalf("temperature not copied")
+	}
+	if len(out.StopSequences) != 1 || out.StopSequences[0] != "END" {
+		t.Fatalf("stop sequences not copied: %v", out.StopSequences)
+	}
+	if !strings.Contains(string(out.System), "You are helpful.") {
+		t.Fatalf("system not converted: %s", out.System)
+	}
+	if string(out.ToolChoice) != `{"type":"any"}` {
+		t.Fatalf("tool choice = %s", out.ToolChoice)
+	}
+	if len(out.Tools) != 1 || out.Tools[0].InputSchema["type"] != "object" {
+		t.Fatalf("tool schema not normalized: %+v", out.Tools)
+	}
+
+	if len(out.Messages) != 3 {
+		t.Fatalf("messages = %d, want 3", len(out.Messages))
+	}
+	var first, second, third []ContentBlock
+	_ = json.Unmarshal(out.Messages[0].Content, &first)
+	_ = json.Unmarshal(out.Messages[1].Content, &second)
+	_ = json.Unmarshal(out.Messages[2].Content, &third)
+	if len(first) != 2 || first[1].Type != "image" || first[1].Source.MediaType != "image/png" {
+		t.Fatalf("inlineData not converted: %+v", first)
+	}
+	if out.Messages[1].Role != "assistant" || second[0].Type != "tool_use" || second[0].ID == "" {
+		t.Fatalf("functionCall not converted: %+v", second)
+	}
+	if third[0].Type != "tool_result" || third[0].ToolUseID != second[0].ID {
+		t.Fatalf("functionResponse not paired with tool_use: %+v", third)
+	}
+}
+
+func TestTransformGeminiRequestToClaude_Thinking(t *testing.T) {
+	budget := -1
+	req := &GeminiRequest{
+		Contents: []GeminiContent{{Role: "user", Parts: []GeminiPart{{Text: "hi"}}}},
+		GenerationConfig: &GeminiGenerationConfig{
+			MaxOutputTokens: 1000,
+			ThinkingConfig:  &GeminiThinkingConfig{ThinkingBudget: budget},
+		},
+	}
+	out, err := TransformGeminiRequestToClaude(req, "claude-sonnet-4-5", false)
+	if err != nil {
+		t.Fatalf("transform: %v", err)
+	}
+	if out.Thinking == nil || out.Thinking.BudgetTokens != geminiToClaudeDynamicThinkingBudget {
+		t.Fatalf("thinking = %+v", out.Thinking)
+	}
+	if out.MaxTokens <= out.Thinking.BudgetTokens {
+		t.Fatalf("max_tokens %d must exceed thinking budget", out.MaxTokens)
+	}
+}
+
+func TestTransformGeminiRequestToClaude_UnsupportedInlineData(t *testing.T) {
+	req := &GeminiRequest{Contents: []GeminiContent{{Role: "user", Parts: []GeminiPart{
+		{InlineData: &GeminiInlineData{MimeType: "audio/wav", Data: "AAAA"}},
+	}}}}
+	if _, err := TransformGeminiRequestToClaude(req, "claude-sonnet-4-5", false); err == nil {
+		t.Fatal("expected error for unsupported mimeType")
+	}
+}
+
+func TestTransformClaudeResponseToGemini(t *testing.T) {
+	claude := `{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4-5",
+		"content":[{"type":"text","text":"Hello"},{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{"city":"Paris"}}],
+		"stop_reason":"tool_use","usage":{"input_tokens":10,"output_tokens":5,"cache_read_input_tokens":2}}`
+	out, err := TransformClaudeResponseToGemini([]byte(claude))
+	if err != nil {
+		t.Fatalf("transform: %v", err)
+	}
+	var resp GeminiResponse
+	if err := json.Unmarshal(out, &resp); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	parts := resp.Candidates[0].Content.Parts
+	if len(parts) != 2 || parts[0].Text != "Hello" || parts[1].FunctionCall == nil || parts[1].FunctionCall.Name != "get_weather" {
+		t.Fatalf("unexpected parts: %+v", parts)
+	}
+	if resp.Candidates[0].FinishReason != "STOP" {
+		t.Fatalf("finishReason = %s", resp.Candidates[0].FinishReason)
+	}
+	if resp.UsageMetadata.PromptTokenCount != 12 || resp.UsageMetadata.TotalTokenCount != 17 {
+		t.Fatalf("usage = %+v", resp.UsageMetadata)
+	}
+}
+
+func TestClaudeToGeminiStreamProcessor(t *testing.T) {
+	p := NewClaudeToGeminiStreamProcessor("claude-sonnet-4-5")
+	lines := []string{
+		`event: message_start`,
+		`data: {"type":"message_start","message":{"id":"msg_1","model":"claude-sonnet-4-5","usage":{"input_tokens":10}}}`,
+		`data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hi"}}`,
+		`data: {"type":"content_block_stop","index":0}`,
+		`data: {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"lookup"}}`,
+		`data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"q\":"}}`,
+		`data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"x\"}"}}`,
+		`data: {"type":"content_block_stop","index":1}`,
+		`data: {"type":"message_delta","delta":{"stop_reason":"max_tokens"},"usage":{"output_tokens":7}}`,
+	}
+	var out strings.Builder
+	for _, line := range lines {
+		out.Write(p.ProcessLine(line))
+	}
+	if extra := p.Finish(); len(extra) != 0 {
+		t.Fatalf("unexpected trailing event: %s", extra)
+	}
+
+	events := strings.Split(strings.TrimSpace(out.String()), "\n\n")
+	if len(events) != 3 {
+		t.Fatalf("events = %d, want 3: %s", len(events), out.String())
+	}
+	var text, call, final GeminiResponse
+	_ = json.Unmarshal([]byte(strings.TrimPrefix(events[0], "data: ")), &text)
+	_ = json.Unmarshal([]byte(strings.TrimPrefix(events[1], "data: ")), &call)
+	_ = json.Unmarshal([]byte(strings.TrimPrefix(events[2], "data: ")), &final)
+	if text.Candidates[0].Content.Parts[0].Text != "Hi" || text.ResponseID != "msg_1" {
+		t.Fatalf("text event = %+v", text)
+	}
+	args, _ := call.Candidates[0].Content.Parts[0].FunctionCall.Args.(map[string]any)
+	if args["q"] != "x" {
+		t.Fatalf("function call args = %+v", call.Candidates[0].Content.Parts[0].FunctionCall)
+	}
+	if final.Candidates[0].FinishReason != "MAX_TOKENS" || final.UsageMetadata.TotalTokenCount != 17 {
+		t.Fatalf("final event = %+v", final)
+	}
+}
+
+func TestClaudeErrorToGemini(t *testing.T) {
+	out := ClaudeErrorToGemini(429, []byte(`{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`))
+	var resp struct {
+		Error struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+			Status  string `json:"status"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(out, &resp); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if resp.Error.Code != 429 || resp.Error.Message != "slow down" || resp.Error.Status != "RESOURCE_EXHAUSTED" {
+		t.Fatalf("error = %+v", resp.Error)
+	}
+}
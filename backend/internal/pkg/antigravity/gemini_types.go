@@ -93,6 +93,8 @@ type GeminiFunctionDecl struct {
 	Name        string         `json:"name"`
 	Description string         `json:"description,omitempty"`
 	Parameters  map[string]any `json:"parameters,omitempty"`
+	// ParametersJSONSchema 标准 JSON Schema 形式的参数（Gemini CLI 等新版客户端使用）
+	ParametersJSONSchema map[string]any `json:"parametersJsonSchema,omitempty"`
 }
 
 // GeminiGoogleSearch Gemini Google 搜索工具
@@ -18,4 +18,6 @@ const (
 	IsClaudeCodeClient Key = "ctx_is_claude_code_client"
 	// Group 认证后的分组信息，由 API Key 认证中间件设置
 	Group Key = "ctx_group"
+	// UserID 认证后的用户 ID，由 API Key 认证中间件设置
+	UserID Key = "ctx_user_id"
 )
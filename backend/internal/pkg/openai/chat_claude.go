@@ -0,0 +1,436 @@
+package openai
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// defaultClaudeMaxTokens Chat Completions 未指定 max_tokens 时使用的默认值（Claude 要求必填）
+const defaultClaudeMaxTokens = 8192
+
+// reasoning_effort -> Claude thinking budget
+var claudeThinkingBudgets = map[string]int{
+	"minimal": 1024,
+	"low":     2048,
+	"medium":  8192,
+	"high":    16384,
+}
+
+// ChatToClaudeRequest 将 Chat Completions 请求转换为 Claude Messages 请求体
+func ChatToClaudeRequest(req *ChatCompletionRequest) ([]byte, error) {
+	if req == nil {
+		return nil, fmt.Errorf("empty request")
+	}
+
+	out := map[string]any{
+		"model": req.Model,
+	}
+
+	maxTokens := defaultClaudeMaxTokens
+	if req.MaxCompletionTokens != nil && *req.MaxCompletionTokens > 0 {
+		maxTokens = *req.MaxCompletionTokens
+	} else if req.MaxTokens != nil && *req.MaxTokens > 0 {
+		maxTokens = *req.MaxTokens
+	}
+
+	var systemBlocks []map[string]any
+	var messages []map[string]any
+	appendBlocks := func(role string, blocks []map[string]any) {
+		if len(blocks) == 0 {
+			return
+		}
+		// Claude 要求 user/assistant 交替，连续同角色消息合并
+		if n := len(messages); n > 0 && messages[n-1]["role"] == role {
+			prev, _ := messages[n-1]["content"].([]map[string]any)
+			messages[n-1]["content"] = append(prev, blocks...)
+			return
+		}
+		messages = append(messages, map[string]any{"role": role, "content": blocks})
+	}
+
+	for i, msg := range req.Messages {
+		switch msg.Role {
+		case "system", "developer":
+			if text := ChatContentText(msg.Content); text != "" {
+				systemBlocks = append(systemBlocks, map[string]any{"type": "text", "text": text})
+			}
+		case "user":
+			blocks, err := chatPartsToClaudeBlocks(msg.Content)
+			if err != nil {
+				return nil, fmt.Errorf("messages[%d]: %w", i, err)
+			}
+			appendBlocks("user", blocks)
+		case "assistant":
+			blocks, err := chatPartsToClaudeBlocks(msg.Content)
+			if err != nil {
+				return nil, fmt.Errorf("messages[%d]: %w", i, err)
+			}
+			for _, call := range msg.ToolCalls {
+				blocks = append(blocks, map[string]any{
+					"type":  "tool_use",
+					"id":    call.ID,
+					"name":  call.Function.Name,
+					"input": parseToolArguments(call.Function.Arguments),
+				})
+			}
+			appendBlocks("assistant", blocks)
+		case "tool":
+			appendBlocks("user", []map[string]any{{
+				"type":        "tool_result",
+				"tool_use_id": msg.ToolCallID,
+				"content":     ChatContentText(msg.Content),
+			}})
+		default:
+			return nil, fmt.Errorf("messages[%d]: unsupported role %q", i, msg.Role)
+		}
+	}
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("messages is required")
+	}
+	out["messages"] = messages
+	if len(systemBlocks) > 0 {
+		out["system"] = systemBlocks
+	}
+
+	if req.Stream {
+		out["stream"] = true
+	}
+	if stops := ParseChatStop(req.Stop); len(stops) > 0 {
+		out["stop_sequences"] = stops
+	}
+
+	if len(req.Tools) > 0 {
+		tools := make([]map[string]any, 0, len(req.Tools))
+		for _, tool := range req.Tools {
+			if tool.Type != "" && tool.Type != "function" {
+				continue
+			}
+			schema := tool.Function.Parameters
+			if schema == nil {
+				schema = map[string]any{"type": "object", "properties": map[string]any{}}
+			}
+			def := map[string]any{
+				"name":         tool.Function.Name,
+				"input_schema": schema,
+			}
+			if tool.Function.Description != "" {
+				def["description"] = tool.Function.Description
+			}
+			tools = append(tools, def)
+		}
+		if len(tools) > 0 {
+			out["tools"] = tools
+		}
+	}
+	switch mode, name := parseChatToolChoice(req.ToolChoice); mode {
+	case "auto":
+		out["tool_choice"] = map[string]any{"type": "auto"}
+	case "none":
+		out["tool_choice"] = map[string]any{"type": "none"}
+	case "required":
+		out["tool_choice"] = map[string]any{"type": "any"}
+	case "function":
+		out["tool_choice"] = map[string]any{"type": "tool", "name": name}
+	}
+
+	if budget, ok := claudeThinkingBudgets[strings.ToLower(req.ReasoningEffort)]; ok {
+		// thinking 模式要求 max_tokens > budget_tokens，且不支持自定义 temperature/top_p
+		if maxTokens <= budget {
+			maxTokens = budget + defaultClaudeMaxTokens
+		}
+		out["thinking"] = map[string]any{"type": "enabled", "budget_tokens": budget}
+	} else {
+		if req.Temperature != nil {
+			out["temperature"] = *req.Temperature
+		}
+		if req.TopP != nil {
+			out["top_p"] = *req.TopP
+		}
+	}
+	out["max_tokens"] = maxTokens
+
+	return json.Marshal(out)
+}
+
+func chatPartsToClaudeBlocks(raw json.RawMessage) ([]map[string]any, error) {
+	parts, err := ParseChatContent(raw)
+	if err != nil {
+		return nil, err
+	}
+	blocks := make([]map[string]any, 0, len(parts))
+	for _, part := range parts {
+		switch part.Type {
+		case "text":
+			if part.Text == "" {
+				continue
+			}
+			blocks = append(blocks, map[string]any{"type": "text", "text": part.Text})
+		case "image_url":
+			if part.ImageURL == nil || part.ImageURL.URL == "" {
+				continue
+			}
+			source := map[string]any{"type": "url", "url": part.ImageURL.URL}
+			if mediaType, data, ok := ParseDataURL(part.ImageURL.URL); ok {
+				source = map[string]any{"type": "base64", "media_type": mediaType, "data": data}
+			}
+			blocks = append(blocks, map[string]any{"type": "image", "source": source})
+		}
+	}
+	return blocks, nil
+}
+
+// parseToolArguments 将 JSON 字符串参数解析为对象，非法时返回空对象
+func parseToolArguments(arguments string) any {
+	var input any
+	if strings.TrimSpace(arguments) == "" || json.Unmarshal([]byte(arguments), &input) != nil {
+		return map[string]any{}
+	}
+	if _, ok := input.(map[string]any); !ok {
+		return map[string]any{}
+	}
+	return input
+}
+
+// claudeResponse Claude 非流式响应（仅转换所需字段）
+type claudeResponse struct {
+	ID      string `json:"id"`
+	Content []struct {
+		Type     string          `json:"type"`
+		Text     string          `json:"text"`
+		Thinking string          `json:"thinking"`
+		ID       string          `json:"id"`
+		Name     string          `json:"name"`
+		Input    json.RawMessage `json:"input"`
+	} `json:"content"`
+	StopReason string      `json:"stop_reason"`
+	Usage      claudeUsage `json:"usage"`
+}
+
+type claudeUsage struct {
+	InputTokens              int `json:"input_tokens"`
+	OutputTokens             int `json:"output_tokens"`
+	CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+}
+
+func (u claudeUsage) chatUsage() *ChatUsage {
+	prompt := u.InputTokens + u.CacheCreationInputTokens + u.CacheReadInputTokens
+	return newChatUsage(prompt, u.OutputTokens, u.CacheReadInputTokens)
+}
+
+// ClaudeToChatResponse 将 Claude 非流式响应转换为 Chat Completions 响应
+func ClaudeToChatResponse(body []byte, model string) ([]byte, error) {
+	var resp claudeResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("parse claude response: %w", err)
+	}
+
+	msg := ChatResponseMessage{Role: "assistant"}
+	var text, reasoning strings.Builder
+	for _, block := range resp.Content {
+		switch block.Type {
+		case "text":
+			text.WriteString(block.Text)
+		case "thinking":
+			reasoning.WriteString(block.Thinking)
+		case "tool_use":
+			args := string(block.Input)
+			if args == "" || args == "null" {
+				args = "{}"
+			}
+			msg.ToolCalls = append(msg.ToolCalls, ChatToolCall{
+				ID:       block.ID,
+				Type:     "function",
+				Function: ChatFunctionCall{Name: block.Name, Arguments: args},
+			})
+		}
+	}
+	if text.Len() > 0 || len(msg.ToolCalls) == 0 {
+		msg.Content = stringPtr(text.String())
+	}
+	msg.ReasoningContent = reasoning.String()
+
+	w := newChatChunkWriter(model)
+	out := ChatCompletionResponse{
+		ID:      w.id,
+		Object:  "chat.completion",
+		Created: w.created,
+		Model:   model,
+		Choices: []ChatChoice{{
+			Index:        0,
+			Message:      msg,
+			FinishReason: claudeStopToFinishReason(resp.StopReason),
+		}},
+		Usage: resp.Usage.chatUsage(),
+	}
+	return json.Marshal(out)
+}
+
+func claudeStopToFinishReason(stopReason string) string {
+	switch stopReason {
+	case "max_tokens":
+		return ChatFinishLength
+	case "tool_use":
+		return ChatFinishToolCalls
+	default:
+		return ChatFinishStop
+	}
+}
+
+// ClaudeChatStreamConverter 将 Claude Messages SSE 转换为 Chat Completions chunk 流
+type ClaudeChatStreamConverter struct {
+	chunks       chatChunkWriter
+	includeUsage bool
+
+	started      bool
+	finished     bool
+	finishReason string
+	usage        claudeUsage
+
+	// Claude content block index -> tool_calls index
+	toolIndexes map[int]int
+	toolCount   int
+}
+
+// NewClaudeChatStreamConverter 创建流式转换器
+func NewClaudeChatStreamConverter(model string, includeUsage bool) *ClaudeChatStreamConverter {
+	return &ClaudeChatStreamConverter{
+		chunks:       newChatChunkWriter(model),
+		includeUsage: includeUsage,
+		toolIndexes:  make(map[int]int),
+	}
+}
+
+// ProcessLine 处理一行 Claude SSE，返回转换后的 Chat Completions SSE 帧
+func (p *ClaudeChatStreamConverter) ProcessLine(line string) []byte {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "data:") || p.finished {
+		return nil
+	}
+	data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+	if data == "" || data == "[DONE]" {
+		return nil
+	}
+
+	var event struct {
+		Type    string `json:"type"`
+		Index   int    `json:"index"`
+		Message struct {
+			Usage claudeUsage `json:"usage"`
+		} `json:"message"`
+		ContentBlock struct {
+			Type string `json:"type"`
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"content_block"`
+		Delta struct {
+			Type        string `json:"type"`
+			Text        string `json:"text"`
+			Thinking    string `json:"thinking"`
+			PartialJSON string `json:"partial_json"`
+			StopReason  string `json:"stop_reason"`
+		} `json:"delta"`
+		Usage claudeUsage     `json:"usage"`
+		Error json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		return nil
+	}
+
+	var result bytes.Buffer
+	switch event.Type {
+	case "message_start":
+		p.usage = event.Message.Usage
+		_, _ = result.Write(p.emitStart())
+	case "content_block_start":
+		_, _ = result.Write(p.emitStart())
+		if event.ContentBlock.Type == "tool_use" {
+			idx := p.toolCount
+			p.toolCount++
+			p.toolIndexes[event.Index] = idx
+			_, _ = result.Write(p.chunks.frame(ChatChunkDelta{ToolCalls: []ChatToolCall{{
+				Index:    &idx,
+				ID:       event.ContentBlock.ID,
+				Type:     "function",
+				Function: ChatFunctionCall{Name: event.ContentBlock.Name, Arguments: ""},
+			}}}, nil, nil))
+		}
+	case "content_block_delta":
+		_, _ = result.Write(p.emitStart())
+		switch event.Delta.Type {
+		case "text_delta":
+			_, _ = result.Write(p.chunks.frame(ChatChunkDelta{Content: stringPtr(event.Delta.Text)}, nil, nil))
+		case "thinking_delta":
+			_, _ = result.Write(p.chunks.frame(ChatChunkDelta{ReasoningContent: stringPtr(event.Delta.Thinking)}, nil, nil))
+		case "input_json_delta":
+			if idx, ok := p.toolIndexes[event.Index]; ok && event.Delta.PartialJSON != "" {
+				_, _ = result.Write(p.chunks.frame(ChatChunkDelta{ToolCalls: []ChatToolCall{{
+					Index:    &idx,
+					Function: ChatFunctionCall{Arguments: event.Delta.PartialJSON},
+				}}}, nil, nil))
+			}
+		}
+	case "message_delta":
+		if event.Delta.StopReason != "" {
+			p.finishReason = claudeStopToFinishReason(event.Delta.StopReason)
+		}
+		// message_delta 仅覆盖非 0 字段（兼容把全部 usage 放在 delta 中的上游）
+		if event.Usage.InputTokens > 0 {
+			p.usage.InputTokens = event.Usage.InputTokens
+		}
+		if event.Usage.OutputTokens > 0 {
+			p.usage.OutputTokens = event.Usage.OutputTokens
+		}
+		if event.Usage.CacheCreationInputTokens > 0 {
+			p.usage.CacheCreationInputTokens = event.Usage.CacheCreationInputTokens
+		}
+		if event.Usage.CacheReadInputTokens > 0 {
+			p.usage.CacheReadInputTokens = event.Usage.CacheReadInputTokens
+		}
+	case "message_stop":
+		_, _ = result.Write(p.Finish())
+	case "ping":
+		return []byte(": ping\n\n")
+	case "error", "":
+		if len(event.Error) == 0 {
+			return nil
+		}
+		errType, message := extractErrorDetail([]byte(data))
+		if errType == "" {
+			errType = "upstream_error"
+		}
+		_, _ = result.Write(chatErrorEvent(errType, message))
+	}
+	return result.Bytes()
+}
+
+// Finish 结束流：补发 finish_reason、usage（可选）与 [DONE]
+func (p *ClaudeChatStreamConverter) Finish() []byte {
+	if p.finished {
+		return nil
+	}
+	p.finished = true
+
+	var result bytes.Buffer
+	_, _ = result.Write(p.emitStart())
+	finishReason := p.finishReason
+	if finishReason == "" {
+		finishReason = ChatFinishStop
+	}
+	_, _ = result.Write(p.chunks.frame(ChatChunkDelta{}, &finishReason, nil))
+	if p.includeUsage {
+		_, _ = result.Write(p.chunks.frame(ChatChunkDelta{}, nil, p.usage.chatUsage()))
+	}
+	_, _ = result.WriteString(chatDone)
+	return result.Bytes()
+}
+
+func (p *ClaudeChatStreamConverter) emitStart() []byte {
+	if p.started {
+		return nil
+	}
+	p.started = true
+	return p.chunks.frame(ChatChunkDelta{Role: "assistant", Content: stringPtr("")}, nil, nil)
+}
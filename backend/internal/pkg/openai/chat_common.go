@@ -0,0 +1,212 @@
+package openai
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ParseChatContent 解析消息 content（string 或内容块数组）
+func ParseChatContent(raw json.RawMessage) ([]ChatContentPart, error) {
+	trimmed := strings.TrimSpace(string(raw))
+	if trimmed == "" || trimmed == "null" {
+		return nil, nil
+	}
+	if strings.HasPrefix(trimmed, "\"") {
+		var text string
+		if err := json.Unmarshal(raw, &text); err != nil {
+			return nil, fmt.Errorf("parse content string: %w", err)
+		}
+		return []ChatContentPart{{Type: "text", Text: text}}, nil
+	}
+	var parts []ChatContentPart
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return nil, fmt.Errorf("parse content parts: %w", err)
+	}
+	return parts, nil
+}
+
+// ChatContentText 拼接内容块中的全部文本
+func ChatContentText(raw json.RawMessage) string {
+	parts, err := ParseChatContent(raw)
+	if err != nil {
+		return ""
+	}
+	var sb strings.Builder
+	for _, part := range parts {
+		if part.Type == "text" && part.Text != "" {
+			if sb.Len() > 0 {
+				sb.WriteString("\n")
+			}
+			sb.WriteString(part.Text)
+		}
+	}
+	return sb.String()
+}
+
+// ParseDataURL 解析 data:<media_type>;base64,<data> 形式的图片地址
+func ParseDataURL(url string) (mediaType, data string, ok bool) {
+	if !strings.HasPrefix(url, "data:") {
+		return "", "", false
+	}
+	meta, payload, found := strings.Cut(strings.TrimPrefix(url, "data:"), ",")
+	if !found || !strings.HasSuffix(meta, ";base64") {
+		return "", "", false
+	}
+	return strings.TrimSuffix(meta, ";base64"), payload, true
+}
+
+// ParseChatStop 解析 stop 参数（string 或 []string）
+func ParseChatStop(raw json.RawMessage) []string {
+	if len(raw) == 0 {
+		return nil
+	}
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		if single == "" {
+			return nil
+		}
+		return []string{single}
+	}
+	var list []string
+	if err := json.Unmarshal(raw, &list); err == nil {
+		return list
+	}
+	return nil
+}
+
+// parseChatToolChoice 解析 tool_choice，返回模式（auto/none/required/function）与指定的函数名
+func parseChatToolChoice(raw json.RawMessage) (mode, name string) {
+	if len(raw) == 0 {
+		return "", ""
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s, ""
+	}
+	var obj struct {
+		Type     string `json:"type"`
+		Function struct {
+			Name string `json:"name"`
+		} `json:"function"`
+	}
+	if err := json.Unmarshal(raw, &obj); err == nil && obj.Function.Name != "" {
+		return "function", obj.Function.Name
+	}
+	return "", ""
+}
+
+// ChatErrorBody 将上游（Claude/OpenAI/Gemini 格式）错误响应体转换为 OpenAI 错误格式
+func ChatErrorBody(body []byte) []byte {
+	errType, message := extractErrorDetail(body)
+	if errType == "" {
+		errType = "upstream_error"
+	}
+	if message == "" {
+		message = "Upstream request failed"
+	}
+	out, _ := json.Marshal(map[string]any{
+		"error": map[string]any{
+			"message": message,
+			"type":    errType,
+			"code":    nil,
+		},
+	})
+	return out
+}
+
+// ChatStreamErrorEvent 将错误响应体转换为流式错误事件
+func ChatStreamErrorEvent(body []byte) []byte {
+	return []byte("data: " + string(ChatErrorBody(body)) + "\n\n")
+}
+
+// chatErrorEvent 构造流式错误事件
+func chatErrorEvent(errType, message string) []byte {
+	out, _ := json.Marshal(map[string]any{
+		"error": map[string]any{
+			"message": message,
+			"type":    errType,
+			"code":    nil,
+		},
+	})
+	return []byte("data: " + string(out) + "\n\n")
+}
+
+func extractErrorDetail(body []byte) (errType, message string) {
+	var payload struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil || len(payload.Error) == 0 {
+		return "", strings.TrimSpace(string(body))
+	}
+	var reason string
+	if err := json.Unmarshal(payload.Error, &reason); err == nil {
+		return "upstream_error", reason
+	}
+	var detail struct {
+		Type    string `json:"type"`
+		Status  string `json:"status"` // Gemini
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(payload.Error, &detail); err != nil {
+		return "", ""
+	}
+	if detail.Type == "" {
+		detail.Type = strings.ToLower(detail.Status)
+	}
+	return detail.Type, detail.Message
+}
+
+// chatChunkWriter 负责生成 chat.completion.chunk SSE 帧
+type chatChunkWriter struct {
+	id      string
+	model   string
+	created int64
+}
+
+func newChatChunkWriter(model string) chatChunkWriter {
+	return chatChunkWriter{
+		id:      "chatcmpl-" + strings.ReplaceAll(uuid.NewString(), "-", ""),
+		model:   model,
+		created: time.Now().Unix(),
+	}
+}
+
+func (w *chatChunkWriter) frame(delta ChatChunkDelta, finishReason *string, usage *ChatUsage) []byte {
+	chunk := ChatCompletionChunk{
+		ID:      w.id,
+		Object:  "chat.completion.chunk",
+		Created: w.created,
+		Model:   w.model,
+		Choices: []ChatChunkChoice{{Index: 0, Delta: delta, FinishReason: finishReason}},
+	}
+	if usage != nil {
+		// include_usage 的最后一个 chunk choices 为空数组
+		chunk.Choices = []ChatChunkChoice{}
+		chunk.Usage = usage
+	}
+	data, _ := json.Marshal(chunk)
+	return []byte("data: " + string(data) + "\n\n")
+}
+
+// chatDone 流结束标记
+const chatDone = "data: [DONE]\n\n"
+
+func newChatUsage(input, output, cached int) *ChatUsage {
+	usage := &ChatUsage{
+		PromptTokens:     input,
+		CompletionTokens: output,
+		TotalTokens:      input + output,
+	}
+	if cached > 0 {
+		usage.PromptTokensDetails = &ChatPromptTokenDetails{CachedTokens: cached}
+	}
+	return usage
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
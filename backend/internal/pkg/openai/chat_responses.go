@@ -0,0 +1,379 @@
+package openai
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ChatToResponsesRequest 将 Chat Completions 请求转换为 Responses API 请求体
+func ChatToResponsesRequest(req *ChatCompletionRequest) ([]byte, error) {
+	if req == nil {
+		return nil, fmt.Errorf("empty request")
+	}
+
+	input := make([]map[string]any, 0, len(req.Messages))
+	for i, msg := range req.Messages {
+		switch msg.Role {
+		case "system", "developer":
+			if text := ChatContentText(msg.Content); text != "" {
+				input = append(input, map[string]any{
+					"type":    "message",
+					"role":    "developer",
+					"content": []map[string]any{{"type": "input_text", "text": text}},
+				})
+			}
+		case "user":
+			parts, err := ParseChatContent(msg.Content)
+			if err != nil {
+				return nil, fmt.Errorf("messages[%d]: %w", i, err)
+			}
+			content := make([]map[string]any, 0, len(parts))
+			for _, part := range parts {
+				switch part.Type {
+				case "text":
+					content = append(content, map[string]any{"type": "input_text", "text": part.Text})
+				case "image_url":
+					if part.ImageURL == nil || part.ImageURL.URL == "" {
+						continue
+					}
+					image := map[string]any{"type": "input_image", "image_url": part.ImageURL.URL}
+					if part.ImageURL.Detail != "" {
+						image["detail"] = part.ImageURL.Detail
+					}
+					content = append(content, image)
+				}
+			}
+			if len(content) > 0 {
+				input = append(input, map[string]any{"type": "message", "role": "user", "content": content})
+			}
+		case "assistant":
+			if text := ChatContentText(msg.Content); text != "" {
+				input = append(input, map[string]any{
+					"type":    "message",
+					"role":    "assistant",
+					"content": []map[string]any{{"type": "output_text", "text": text}},
+				})
+			}
+			for _, call := range msg.ToolCalls {
+				args := call.Function.Arguments
+				if strings.TrimSpace(args) == "" {
+					args = "{}"
+				}
+				input = append(input, map[string]any{
+					"type":      "function_call",
+					"call_id":   call.ID,
+					"name":      call.Function.Name,
+					"arguments": args,
+				})
+			}
+		case "tool":
+			input = append(input, map[string]any{
+				"type":    "function_call_output",
+				"call_id": msg.ToolCallID,
+				"output":  ChatContentText(msg.Content),
+			})
+		default:
+			return nil, fmt.Errorf("messages[%d]: unsupported role %q", i, msg.Role)
+		}
+	}
+	if len(input) == 0 {
+		return nil, fmt.Errorf("messages is required")
+	}
+
+	out := map[string]any{
+		"model":  req.Model,
+		"input":  input,
+		"stream": req.Stream,
+	}
+	if req.MaxCompletionTokens != nil && *req.MaxCompletionTokens > 0 {
+		out["max_output_tokens"] = *req.MaxCompletionTokens
+	} else if req.MaxTokens != nil && *req.MaxTokens > 0 {
+		out["max_output_tokens"] = *req.MaxTokens
+	}
+	if req.Temperature != nil {
+		out["temperature"] = *req.Temperature
+	}
+	if req.TopP != nil {
+		out["top_p"] = *req.TopP
+	}
+	if req.ReasoningEffort != "" {
+		out["reasoning"] = map[string]any{"effort": req.ReasoningEffort}
+	}
+
+	if len(req.Tools) > 0 {
+		tools := make([]map[string]any, 0, len(req.Tools))
+		for _, tool := range req.Tools {
+			if tool.Type != "" && tool.Type != "function" {
+				continue
+			}
+			def := map[string]any{
+				"type": "function",
+				"name": tool.Function.Name,
+			}
+			if tool.Function.Description != "" {
+				def["description"] = tool.Function.Description
+			}
+			if tool.Function.Parameters != nil {
+				def["parameters"] = tool.Function.Parameters
+			}
+			tools = append(tools, def)
+		}
+		if len(tools) > 0 {
+			out["tools"] = tools
+		}
+	}
+	switch mode, name := parseChatToolChoice(req.ToolChoice); mode {
+	case "auto", "none", "required":
+		out["tool_choice"] = mode
+	case "function":
+		out["tool_choice"] = map[string]any{"type": "function", "name": name}
+	}
+
+	return json.Marshal(out)
+}
+
+// responsesResult Responses API 最终响应（仅转换所需字段）
+type responsesResult struct {
+	ID                string `json:"id"`
+	Status            string `json:"status"`
+	IncompleteDetails *struct {
+		Reason string `json:"reason"`
+	} `json:"incomplete_details"`
+	Output []responsesOutputItem `json:"output"`
+	Usage  *responsesUsage       `json:"usage"`
+	Error  *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type responsesOutputItem struct {
+	Type      string `json:"type"`
+	ID        string `json:"id"`
+	CallID    string `json:"call_id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+	Content   []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	Summary []struct {
+		Text string `json:"text"`
+	} `json:"summary"`
+}
+
+type responsesUsage struct {
+	InputTokens        int `json:"input_tokens"`
+	OutputTokens       int `json:"output_tokens"`
+	InputTokensDetails struct {
+		CachedTokens int `json:"cached_tokens"`
+	} `json:"input_tokens_details"`
+}
+
+func (u *responsesUsage) chatUsage() *ChatUsage {
+	if u == nil {
+		return newChatUsage(0, 0, 0)
+	}
+	return newChatUsage(u.InputTokens, u.OutputTokens, u.InputTokensDetails.CachedTokens)
+}
+
+func (r *responsesResult) finishReason(hasToolCalls bool) string {
+	if hasToolCalls {
+		return ChatFinishToolCalls
+	}
+	if r.Status == "incomplete" && r.IncompleteDetails != nil && r.IncompleteDetails.Reason == "max_output_tokens" {
+		return ChatFinishLength
+	}
+	return ChatFinishStop
+}
+
+// ResponsesToChatResponse 将 Responses API 非流式响应转换为 Chat Completions 响应
+func ResponsesToChatResponse(body []byte, model string) ([]byte, error) {
+	var resp responsesResult
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("parse responses result: %w", err)
+	}
+
+	msg := ChatResponseMessage{Role: "assistant"}
+	var text, reasoning strings.Builder
+	for _, item := range resp.Output {
+		switch item.Type {
+		case "message":
+			for _, part := range item.Content {
+				if part.Type == "output_text" {
+					text.WriteString(part.Text)
+				}
+			}
+		case "reasoning":
+			for _, summary := range item.Summary {
+				reasoning.WriteString(summary.Text)
+			}
+		case "function_call":
+			msg.ToolCalls = append(msg.ToolCalls, ChatToolCall{
+				ID:       item.CallID,
+				Type:     "function",
+				Function: ChatFunctionCall{Name: item.Name, Arguments: item.Arguments},
+			})
+		}
+	}
+	if text.Len() > 0 || len(msg.ToolCalls) == 0 {
+		msg.Content = stringPtr(text.String())
+	}
+	msg.ReasoningContent = reasoning.String()
+
+	w := newChatChunkWriter(model)
+	out := ChatCompletionResponse{
+		ID:      w.id,
+		Object:  "chat.completion",
+		Created: w.created,
+		Model:   model,
+		Choices: []ChatChoice{{
+			Index:        0,
+			Message:      msg,
+			FinishReason: resp.finishReason(len(msg.ToolCalls) > 0),
+		}},
+		Usage: resp.Usage.chatUsage(),
+	}
+	return json.Marshal(out)
+}
+
+// ResponsesChatStreamConverter 将 Responses API SSE 转换为 Chat Completions chunk 流
+type ResponsesChatStreamConverter struct {
+	chunks       chatChunkWriter
+	includeUsage bool
+
+	started      bool
+	finished     bool
+	finishReason string
+	usage        *responsesUsage
+
+	// output_index -> tool_calls index
+	toolIndexes map[int]int
+	toolCount   int
+}
+
+// NewResponsesChatStreamConverter 创建流式转换器
+func NewResponsesChatStreamConverter(model string, includeUsage bool) *ResponsesChatStreamConverter {
+	return &ResponsesChatStreamConverter{
+		chunks:       newChatChunkWriter(model),
+		includeUsage: includeUsage,
+		toolIndexes:  make(map[int]int),
+	}
+}
+
+// ProcessLine 处理一行 Responses SSE，返回转换后的 Chat Completions SSE 帧
+func (p *ResponsesChatStreamConverter) ProcessLine(line string) []byte {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "data:") || p.finished {
+		return nil
+	}
+	data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+	if data == "" || data == "[DONE]" {
+		return nil
+	}
+
+	var event struct {
+		Type        string              `json:"type"`
+		OutputIndex int                 `json:"output_index"`
+		Delta       string              `json:"delta"`
+		Item        responsesOutputItem `json:"item"`
+		Response    responsesResult     `json:"response"`
+		Message     string              `json:"message"`
+		Error       json.RawMessage     `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		return nil
+	}
+
+	var result bytes.Buffer
+	switch event.Type {
+	case "response.created", "response.in_progress":
+		_, _ = result.Write(p.emitStart())
+	case "response.output_item.added":
+		_, _ = result.Write(p.emitStart())
+		if event.Item.Type == "function_call" {
+			idx := p.toolCount
+			p.toolCount++
+			p.toolIndexes[event.OutputIndex] = idx
+			_, _ = result.Write(p.chunks.frame(ChatChunkDelta{ToolCalls: []ChatToolCall{{
+				Index:    &idx,
+				ID:       event.Item.CallID,
+				Type:     "function",
+				Function: ChatFunctionCall{Name: event.Item.Name, Arguments: ""},
+			}}}, nil, nil))
+		}
+	case "response.output_text.delta":
+		_, _ = result.Write(p.emitStart())
+		_, _ = result.Write(p.chunks.frame(ChatChunkDelta{Content: stringPtr(event.Delta)}, nil, nil))
+	case "response.reasoning_summary_text.delta", "response.reasoning_text.delta":
+		_, _ = result.Write(p.emitStart())
+		_, _ = result.Write(p.chunks.frame(ChatChunkDelta{ReasoningContent: stringPtr(event.Delta)}, nil, nil))
+	case "response.function_call_arguments.delta":
+		if idx, ok := p.toolIndexes[event.OutputIndex]; ok && event.Delta != "" {
+			_, _ = result.Write(p.chunks.frame(ChatChunkDelta{ToolCalls: []ChatToolCall{{
+				Index:    &idx,
+				Function: ChatFunctionCall{Arguments: event.Delta},
+			}}}, nil, nil))
+		}
+	case "response.completed", "response.incomplete":
+		p.usage = event.Response.Usage
+		p.finishReason = event.Response.finishReason(p.toolCount > 0)
+		_, _ = result.Write(p.Finish())
+	case "response.failed":
+		message := "Upstream response failed"
+		if event.Response.Error != nil && event.Response.Error.Message != "" {
+			message = event.Response.Error.Message
+		}
+		p.usage = event.Response.Usage
+		_, _ = result.Write(chatErrorEvent("upstream_error", message))
+		_, _ = result.Write(p.Finish())
+	case "error", "":
+		if event.Type == "error" && event.Message != "" {
+			_, _ = result.Write(chatErrorEvent("upstream_error", event.Message))
+			break
+		}
+		if len(event.Error) == 0 {
+			return nil
+		}
+		errType, message := extractErrorDetail([]byte(data))
+		if errType == "" {
+			errType = "upstream_error"
+		}
+		_, _ = result.Write(chatErrorEvent(errType, message))
+	}
+	return result.Bytes()
+}
+
+// Finish 结束流：补发 finish_reason、usage（可选）与 [DONE]
+func (p *ResponsesChatStreamConverter) Finish() []byte {
+	if p.finished {
+		return nil
+	}
+	p.finished = true
+
+	var result bytes.Buffer
+	_, _ = result.Write(p.emitStart())
+	finishReason := p.finishReason
+	if finishReason == "" {
+		finishReason = ChatFinishStop
+		if p.toolCount > 0 {
+			finishReason = ChatFinishToolCalls
+		}
+	}
+	_, _ = result.Write(p.chunks.frame(ChatChunkDelta{}, &finishReason, nil))
+	if p.includeUsage {
+		_, _ = result.Write(p.chunks.frame(ChatChunkDelta{}, nil, p.usage.chatUsage()))
+	}
+	_, _ = result.WriteString(chatDone)
+	return result.Bytes()
+}
+
+func (p *ResponsesChatStreamConverter) emitStart() []byte {
+	if p.started {
+		return nil
+	}
+	p.started = true
+	return p.chunks.frame(ChatChunkDelta{Role: "assistant", Content: stringPtr("")}, nil, nil)
+}
@@ -0,0 +1,232 @@
+package openai
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func mustParseChatRequest(t *testing.T, raw string) *ChatCompletionRequest {
+	t.Helper()
+	var req ChatCompletionRequest
+	if err := json.Unmarshal([]byte(raw), &req); err != nil {
+		t.Fatalf("parse chat request: %v", err)
+	}
+	return &req
+}
+
+func TestChatToClaudeRequest_ToolsImagesAndSystem(t *testing.T) {
+	req := mustParseChatRequest(t, `{
+		"model": "claude-sonnet-4-5",
+		"max_tokens": 512,
+		"stop": "END",
+		"messages": [
+			{"role": "system", "content": "be brief"},
+			{"role": "user", "content": [
+				{"type": "text", "text": "what is this?"},
+				{"type": "image_url", "image_url": {"url": "data:image/png;base64,AAAA"}}
+			]},
+			{"role": "assistant", "content": null, "tool_calls": [
+				{"id": "call_1", "type": "function", "function": {"name": "lookup", "arguments": "{\"q\":\"x\"}"}}
+			]},
+			{"role": "tool", "tool_call_id": "call_1", "content": "result"},
+			{"role": "user", "content": "thanks"}
+		],
+		"tools": [{"type": "function", "function": {"name": "lookup", "parameters": {"type": "object"}}}],
+		"tool_choice": "required"
+	}`)
+
+	body, err := ChatToClaudeRequest(req)
+	if err != nil {
+		t.Fatalf("ChatToClaudeRequest() error = %v", err)
+	}
+
+	var out struct {
+		MaxTokens     int      `json:"max_tokens"`
+		StopSequences []string `json:"stop_sequences"`
+		System        []struct {
+			Text string `json:"text"`
+		} `json:"system"`
+		Messages []struct {
+			Role    string           `json:"role"`
+			Content []map[string]any `json:"content"`
+		} `json:"messages"`
+		Tools      []map[string]any `json:"tools"`
+		ToolChoice map[string]any   `json:"tool_choice"`
+	}
+	if err := json.Unmarshal(body, &out); err != nil {
+		t.Fatalf("unmarshal converted body: %v", err)
+	}
+
+	if out.MaxTokens != 512 {
+		t.Fatalf("max_tokens = %d, want 512", out.MaxTokens)
+	}
+	if len(out.StopSequences) != 1 || out.StopSequences[0] != "END" {
+		t.Fatalf("stop_sequences = %v", out.StopSequences)
+	}
+	if len(out.System) != 1 || out.System[0].Text != "be brief" {
+		t.Fatalf("system = %+v", out.System)
+	}
+	// tool result 与后续 user 文本合并为同一条 user 消息
+	if len(out.Messages) != 3 {
+		t.Fatalf("messages = %d, want 3", len(out.Messages))
+	}
+	if out.Messages[0].Content[1]["type"] != "image" {
+		t.Fatalf("expected image block, got %v", out.Messages[0].Content[1])
+	}
+	if out.Messages[1].Content[0]["type"] != "tool_use" || out.Messages[1].Content[0]["id"] != "call_1" {
+		t.Fatalf("expected tool_use block, got %v", out.Messages[1].Content[0])
+	}
+	if out.Messages[2].Role != "user" || len(out.Messages[2].Content) != 2 || out.Messages[2].Content[0]["type"] != "tool_result" {
+		t.Fatalf("expected merged tool_result + text user message, got %+v", out.Messages[2])
+	}
+	if out.ToolChoice["type"] != "any" {
+		t.Fatalf("tool_choice = %v, want any", out.ToolChoice)
+	}
+	if len(out.Tools) != 1 || out.Tools[0]["name"] != "lookup" {
+		t.Fatalf("tools = %v", out.Tools)
+	}
+}
+
+func TestClaudeChatStreamConverter(t *testing.T) {
+	p := NewClaudeChatStreamConverter("claude-sonnet-4-5", true)
+	lines := []string{
+		`event: message_start`,
+		`data: {"type":"message_start","message":{"id":"msg_1","usage":{"input_tokens":10,"cache_read_input_tokens":4}}}`,
+		`data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hi"}}`,
+		`data: {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"lookup"}}`,
+		`data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"q\":1}"}}`,
+		`data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":7}}`,
+		`data: {"type":"message_stop"}`,
+	}
+	var sb strings.Builder
+	for _, line := range lines {
+		sb.Write(p.ProcessLine(line))
+	}
+	sb.Write(p.Finish())
+	out := sb.String()
+
+	for _, want := range []string{
+		`"role":"assistant"`,
+		`"content":"Hi"`,
+		`"id":"toolu_1"`,
+		`"arguments":"{\"q\":1}"`,
+		`"finish_reason":"tool_calls"`,
+		`"prompt_tokens":14`,
+		`"completion_tokens":7`,
+		`"cached_tokens":4`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("stream output missing %s:\n%s", want, out)
+		}
+	}
+	if strings.Count(out, "data: [DONE]") != 1 {
+		t.Fatalf("expected exactly one [DONE], got:\n%s", out)
+	}
+}
+
+func TestChatToResponsesRequest(t *testing.T) {
+	req := mustParseChatRequest(t, `{
+		"model": "gpt-5.1",
+		"max_completion_tokens": 100,
+		"reasoning_effort": "low",
+		"messages": [
+			{"role": "system", "content": "sys"},
+			{"role": "user", "content": "hi"},
+			{"role": "assistant", "tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "f", "arguments": ""}}]},
+			{"role": "tool", "tool_call_id": "call_1", "content": "ok"}
+		],
+		"tool_choice": {"type": "function", "function": {"name": "f"}}
+	}`)
+
+	body, err := ChatToResponsesRequest(req)
+	if err != nil {
+		t.Fatalf("ChatToResponsesRequest() error = %v", err)
+	}
+	var out struct {
+		Input           []map[string]any `json:"input"`
+		MaxOutputTokens int              `json:"max_output_tokens"`
+		Reasoning       map[string]any   `json:"reasoning"`
+		ToolChoice      map[string]any   `json:"tool_choice"`
+	}
+	if err := json.Unmarshal(body, &out); err != nil {
+		t.Fatalf("unmarshal converted body: %v", err)
+	}
+	if len(out.Input) != 4 {
+		t.Fatalf("input items = %d, want 4", len(out.Input))
+	}
+	if out.Input[0]["role"] != "developer" || out.Input[2]["type"] != "function_call" || out.Input[2]["arguments"] != "{}" {
+		t.Fatalf("unexpected input items: %v", out.Input)
+	}
+	if out.Input[3]["type"] != "function_call_output" || out.Input[3]["call_id"] != "call_1" {
+		t.Fatalf("unexpected tool output item: %v", out.Input[3])
+	}
+	if out.MaxOutputTokens != 100 || out.Reasoning["effort"] != "low" || out.ToolChoice["name"] != "f" {
+		t.Fatalf("unexpected options: %+v", out)
+	}
+}
+
+func TestResponsesToChatResponse(t *testing.T) {
+	body := []byte(`{
+		"id": "resp_1",
+		"status": "incomplete",
+		"incomplete_details": {"reason": "max_output_tokens"},
+		"output": [
+			{"type": "reasoning", "summary": [{"text": "thinking"}]},
+			{"type": "message", "content": [{"type": "output_text", "text": "hello"}]}
+		],
+		"usage": {"input_tokens": 20, "output_tokens": 5, "input_tokens_details": {"cached_tokens": 8}}
+	}`)
+	out, err := ResponsesToChatResponse(body, "gpt-5.1")
+	if err != nil {
+		t.Fatalf("ResponsesToChatResponse() error = %v", err)
+	}
+	var resp ChatCompletionResponse
+	if err := json.Unmarshal(out, &resp); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	choice := resp.Choices[0]
+	if choice.FinishReason != ChatFinishLength || choice.Message.Content == nil || *choice.Message.Content != "hello" {
+		t.Fatalf("unexpected choice: %+v", choice)
+	}
+	if choice.Message.ReasoningContent != "thinking" {
+		t.Fatalf("reasoning_content = %q", choice.Message.ReasoningContent)
+	}
+	if resp.Usage.PromptTokens != 20 || resp.Usage.TotalTokens != 25 || resp.Usage.PromptTokensDetails.CachedTokens != 8 {
+		t.Fatalf("unexpected usage: %+v", resp.Usage)
+	}
+}
+
+func TestResponsesChatStreamConverter_FunctionCall(t *testing.T) {
+	p := NewResponsesChatStreamConverter("gpt-5.1", false)
+	lines := []string{
+		`data: {"type":"response.created","response":{"id":"resp_1"}}`,
+		`data: {"type":"response.output_item.added","output_index":0,"item":{"type":"function_call","call_id":"call_9","name":"f"}}`,
+		`data: {"type":"response.function_call_arguments.delta","output_index":0,"delta":"{\"a\""}`,
+		`data: {"type":"response.function_call_arguments.delta","output_index":0,"delta":":1}"}`,
+		`data: {"type":"response.completed","response":{"status":"completed","usage":{"input_tokens":3,"output_tokens":2}}}`,
+	}
+	var sb strings.Builder
+	for _, line := range lines {
+		sb.Write(p.ProcessLine(line))
+	}
+	sb.Write(p.Finish())
+	out := sb.String()
+
+	for _, want := range []string{`"id":"call_9"`, `"name":"f"`, `"arguments":"{\"a\""`, `"finish_reason":"tool_calls"`} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("stream output missing %s:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, `"usage"`) {
+		t.Fatalf("usage chunk should be omitted without include_usage:\n%s", out)
+	}
+}
+
+func TestChatErrorBody(t *testing.T) {
+	out := ChatErrorBody([]byte(`{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`))
+	if !strings.Contains(string(out), `"type":"rate_limit_error"`) || !strings.Contains(string(out), `"message":"slow down"`) {
+		t.Fatalf("unexpected error body: %s", out)
+	}
+}
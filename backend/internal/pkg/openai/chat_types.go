@@ -0,0 +1,146 @@
+package openai
+
+import "encoding/json"
+
+// Chat Completions API 请求/响应类型定义
+
+// ChatCompletionRequest OpenAI Chat Completions 请求
+type ChatCompletionRequest struct {
+	Model               string             `json:"model"`
+	Messages            []ChatMessage      `json:"messages"`
+	Stream              bool               `json:"stream,omitempty"`
+	StreamOptions       *ChatStreamOptions `json:"stream_options,omitempty"`
+	MaxTokens           *int               `json:"max_tokens,omitempty"`
+	MaxCompletionTokens *int               `json:"max_completion_tokens,omitempty"`
+	Temperature         *float64           `json:"temperature,omitempty"`
+	TopP                *float64           `json:"top_p,omitempty"`
+	Stop                json.RawMessage    `json:"stop,omitempty"` // string 或 []string
+	Tools               []ChatTool         `json:"tools,omitempty"`
+	ToolChoice          json.RawMessage    `json:"tool_choice,omitempty"` // string 或 {"type":"function","function":{"name":...}}
+	ReasoningEffort     string             `json:"reasoning_effort,omitempty"`
+	User                string             `json:"user,omitempty"`
+}
+
+// ChatStreamOptions 流式选项
+type ChatStreamOptions struct {
+	IncludeUsage bool `json:"include_usage,omitempty"`
+}
+
+// ChatMessage Chat Completions 消息
+type ChatMessage struct {
+	Role       string          `json:"role"`              // system, developer, user, assistant, tool
+	Content    json.RawMessage `json:"content,omitempty"` // string 或 []ChatContentPart
+	Name       string          `json:"name,omitempty"`
+	ToolCalls  []ChatToolCall  `json:"tool_calls,omitempty"`
+	ToolCallID string          `json:"tool_call_id,omitempty"`
+}
+
+// ChatContentPart 消息内容块
+type ChatContentPart struct {
+	Type     string        `json:"type"` // text, image_url
+	Text     string        `json:"text,omitempty"`
+	ImageURL *ChatImageURL `json:"image_url,omitempty"`
+}
+
+// ChatImageURL 图片地址（http(s) URL 或 data URL）
+type ChatImageURL struct {
+	URL    string `json:"url"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// ChatTool 工具定义
+type ChatTool struct {
+	Type     string           `json:"type"` // function
+	Function ChatToolFunction `json:"function"`
+}
+
+// ChatToolFunction 函数工具定义
+type ChatToolFunction struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Parameters  map[string]any `json:"parameters,omitempty"`
+}
+
+// ChatToolCall assistant 发起的工具调用
+type ChatToolCall struct {
+	Index    *int             `json:"index,omitempty"` // 仅流式 delta 使用
+	ID       string           `json:"id,omitempty"`
+	Type     string           `json:"type,omitempty"`
+	Function ChatFunctionCall `json:"function"`
+}
+
+// ChatFunctionCall 函数调用名与参数（JSON 字符串）
+type ChatFunctionCall struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments"`
+}
+
+// ChatCompletionResponse 非流式响应
+type ChatCompletionResponse struct {
+	ID      string       `json:"id"`
+	Object  string       `json:"object"` // chat.completion
+	Created int64        `json:"created"`
+	Model   string       `json:"model"`
+	Choices []ChatChoice `json:"choices"`
+	Usage   *ChatUsage   `json:"usage,omitempty"`
+}
+
+// ChatChoice 非流式响应选项
+type ChatChoice struct {
+	Index        int                 `json:"index"`
+	Message      ChatResponseMessage `json:"message"`
+	FinishReason string              `json:"finish_reason"`
+}
+
+// ChatResponseMessage 非流式响应中的 assistant 消息
+type ChatResponseMessage struct {
+	Role             string         `json:"role"`
+	Content          *string        `json:"content"`
+	ReasoningContent string         `json:"reasoning_content,omitempty"`
+	ToolCalls        []ChatToolCall `json:"tool_calls,omitempty"`
+}
+
+// ChatCompletionChunk 流式响应块
+type ChatCompletionChunk struct {
+	ID      string            `json:"id"`
+	Object  string            `json:"object"` // chat.completion.chunk
+	Created int64             `json:"created"`
+	Model   string            `json:"model"`
+	Choices []ChatChunkChoice `json:"choices"`
+	Usage   *ChatUsage        `json:"usage,omitempty"`
+}
+
+// ChatChunkChoice 流式响应选项
+type ChatChunkChoice struct {
+	Index        int            `json:"index"`
+	Delta        ChatChunkDelta `json:"delta"`
+	FinishReason *string        `json:"finish_reason"`
+}
+
+// ChatChunkDelta 流式增量
+type ChatChunkDelta struct {
+	Role             string         `json:"role,omitempty"`
+	Content          *string        `json:"content,omitempty"`
+	ReasoningContent *string        `json:"reasoning_content,omitempty"`
+	ToolCalls        []ChatToolCall `json:"tool_calls,omitempty"`
+}
+
+// ChatUsage 用量统计
+type ChatUsage struct {
+	PromptTokens        int                     `json:"prompt_tokens"`
+	CompletionTokens    int                     `json:"completion_tokens"`
+	TotalTokens         int                     `json:"total_tokens"`
+	PromptTokensDetails *ChatPromptTokenDetails `json:"prompt_tokens_details,omitempty"`
+}
+
+// ChatPromptTokenDetails 输入 token 明细
+type ChatPromptTokenDetails struct {
+	CachedTokens int `json:"cached_tokens"`
+}
+
+// Chat Completions finish_reason 取值
+const (
+	ChatFinishStop      = "stop"
+	ChatFinishLength    = "length"
+	ChatFinishToolCalls = "tool_calls"
+)
@@ -0,0 +1,124 @@
+package openai
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Embeddings API 请求/响应类型定义，以及与 Gemini batchEmbedContents 的互转
+
+// EmbeddingRequest OpenAI Embeddings 请求
+type EmbeddingRequest struct {
+	Model          string          `json:"model"`
+	Input          json.RawMessage `json:"input"`                     // string、[]string 或 token 数组
+	EncodingFormat string          `json:"encoding_format,omitempty"` // float（默认）或 base64
+	Dimensions     int             `json:"dimensions,omitempty"`
+	User           string          `json:"user,omitempty"`
+}
+
+// EmbeddingResponse OpenAI Embeddings 响应
+type EmbeddingResponse struct {
+	Object string          `json:"object"`
+	Data   []EmbeddingData `json:"data"`
+	Model  string          `json:"model"`
+	Usage  EmbeddingUsage  `json:"usage"`
+}
+
+// EmbeddingData 单条向量结果（embedding 为 []float64 或 base64 字符串）
+type EmbeddingData struct {
+	Object    string `json:"object"`
+	Index     int    `json:"index"`
+	Embedding any    `json:"embedding"`
+}
+
+// EmbeddingUsage Embeddings 用量（仅输入 token）
+type EmbeddingUsage struct {
+	PromptTokens int `json:"prompt_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
+
+// InputTexts 解析 input 为文本列表；token 数组形式无法转换为其他平台，返回错误
+func (r *EmbeddingRequest) InputTexts() ([]string, error) {
+	trimmed := strings.TrimSpace(string(r.Input))
+	if trimmed == "" || trimmed == "null" {
+		return nil, fmt.Errorf("input is required")
+	}
+	var text string
+	if err := json.Unmarshal(r.Input, &text); err == nil {
+		return []string{text}, nil
+	}
+	var texts []string
+	if err := json.Unmarshal(r.Input, &texts); err == nil {
+		if len(texts) == 0 {
+			return nil, fmt.Errorf("input is required")
+		}
+		return texts, nil
+	}
+	return nil, fmt.Errorf("input must be a string or an array of strings")
+}
+
+// EmbeddingToGeminiBatchRequest 将 Embeddings 请求转换为 Gemini batchEmbedContents 请求体
+func EmbeddingToGeminiBatchRequest(req *EmbeddingRequest, mappedModel string) ([]byte, error) {
+	texts, err := req.InputTexts()
+	if err != nil {
+		return nil, err
+	}
+	modelName := "models/" + strings.TrimPrefix(mappedModel, "models/")
+	requests := make([]map[string]any, 0, len(texts))
+	for _, text := range texts {
+		item := map[string]any{
+			"model":   modelName,
+			"content": map[string]any{"parts": []map[string]any{{"text": text}}},
+		}
+		if req.Dimensions > 0 {
+			item["outputDimensionality"] = req.Dimensions
+		}
+		requests = append(requests, item)
+	}
+	return json.Marshal(map[string]any{"requests": requests})
+}
+
+// GeminiBatchEmbedToEmbeddingResponse 将 Gemini batchEmbedContents 响应转换为 Embeddings 响应
+// Gemini 不返回用量，promptTokens 由调用方估算后传入。
+func GeminiBatchEmbedToEmbeddingResponse(body []byte, model, encodingFormat string, promptTokens int) ([]byte, error) {
+	var geminiResp struct {
+		Embeddings []struct {
+			Values []float64 `json:"values"`
+		} `json:"embeddings"`
+	}
+	if err := json.Unmarshal(body, &geminiResp); err != nil {
+		return nil, fmt.Errorf("parse gemini embeddings: %w", err)
+	}
+
+	data := make([]EmbeddingData, 0, len(geminiResp.Embeddings))
+	for i, emb := range geminiResp.Embeddings {
+		var embedding any = emb.Values
+		if emb.Values == nil {
+			embedding = []float64{}
+		}
+		if encodingFormat == "base64" {
+			embedding = encodeEmbeddingBase64(emb.Values)
+		}
+		data = append(data, EmbeddingData{Object: "embedding", Index: i, Embedding: embedding})
+	}
+
+	return json.Marshal(EmbeddingResponse{
+		Object: "list",
+		Data:   data,
+		Model:  model,
+		Usage:  EmbeddingUsage{PromptTokens: promptTokens, TotalTokens: promptTokens},
+	})
+}
+
+// encodeEmbeddingBase64 按 OpenAI 约定将向量编码为 little-endian float32 的 base64
+func encodeEmbeddingBase64(values []float64) string {
+	buf := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(buf[i*4:], math.Float32bits(float32(v)))
+	}
+	return base64.StdEncoding.EncodeToString(buf)
+}
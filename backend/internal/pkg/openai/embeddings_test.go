@@ -0,0 +1,105 @@
+package openai
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEmbeddingRequestInputTexts(t *testing.T) {
+	cases := []struct {
+		input   string
+		want    int
+		wantErr bool
+	}{
+		{`"hello"`, 1, false},
+		{`["a","b","c"]`, 3, false},
+		{`[]`, 0, true},
+		{`[1,2,3]`, 0, true},
+		{`null`, 0, true},
+	}
+	for _, tc := range cases {
+		req := EmbeddingRequest{Model: "m", Input: json.RawMessage(tc.input)}
+		texts, err := req.InputTexts()
+		if (err != nil) != tc.wantErr {
+			t.Fatalf("input %s: err = %v, wantErr %v", tc.input, err, tc.wantErr)
+		}
+		if len(texts) != tc.want {
+			t.Fatalf("input %s: texts = %v, want %d", tc.input, texts, tc.want)
+		}
+	}
+}
+
+func TestEmbeddingToGeminiBatchRequest(t *testing.T) {
+	req := &EmbeddingRequest{Model: "text-embedding", Input: json.RawMessage(`["a","b"]`), Dimensions: 256}
+	body, err := EmbeddingToGeminiBatchRequest(req, "gemini-embedding-001")
+	if err != nil {
+		t.Fatalf("EmbeddingToGeminiBatchRequest() error = %v", err)
+	}
+	var out struct {
+		Requests []struct {
+			Model   string `json:"model"`
+			Content struct {
+				Parts []struct {
+					Text string `json:"text"`
+				} `json:"parts"`
+			} `json:"content"`
+			OutputDimensionality int `json:"outputDimensionality"`
+		} `json:"requests"`
+	}
+	if err := json.Unmarshal(body, &out); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(out.Requests) != 2 || out.Requests[1].Content.Parts[0].Text != "b" {
+		t.Fatalf("unexpected requests: %+v", out.Requests)
+	}
+	if out.Requests[0].Model != "models/gemini-embedding-001" || out.Requests[0].OutputDimensionality != 256 {
+		t.Fatalf("unexpected request fields: %+v", out.Requests[0])
+	}
+}
+
+func TestGeminiBatchEmbedToEmbeddingResponse(t *testing.T) {
+	gemini := []byte(`{"embeddings":[{"values":[0.5,-1]},{"values":[1]}]}`)
+
+	out, err := GeminiBatchEmbedToEmbeddingResponse(gemini, "text-embedding", "", 9)
+	if err != nil {
+		t.Fatalf("GeminiBatchEmbedToEmbeddingResponse() error = %v", err)
+	}
+	var resp struct {
+		Object string `json:"object"`
+		Model  string `json:"model"`
+		Data   []struct {
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		} `json:"data"`
+		Usage EmbeddingUsage `json:"usage"`
+	}
+	if err := json.Unmarshal(out, &resp); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if resp.Object != "list" || resp.Model != "text-embedding" || len(resp.Data) != 2 {
+		t.Fatalf("unexpected response: %s", out)
+	}
+	if resp.Data[1].Index != 1 || resp.Data[0].Embedding[1] != -1 {
+		t.Fatalf("unexpected data: %+v", resp.Data)
+	}
+	if resp.Usage.PromptTokens != 9 || resp.Usage.TotalTokens != 9 {
+		t.Fatalf("unexpected usage: %+v", resp.Usage)
+	}
+
+	out, err = GeminiBatchEmbedToEmbeddingResponse(gemini, "text-embedding", "base64", 9)
+	if err != nil {
+		t.Fatalf("GeminiBatchEmbedToEmbeddingResponse(base64) error = %v", err)
+	}
+	var b64 struct {
+		Data []struct {
+			Embedding string `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(out, &b64); err != nil {
+		t.Fatalf("unmarshal base64: %v", err)
+	}
+	// 0.5 => 0x3f000000, -1 => 0xbf800000 (little-endian)
+	if b64.Data[0].Embedding != "AAAAPwAAgL8=" {
+		t.Fatalf("unexpected base64 embedding: %s", b64.Data[0].Embedding)
+	}
+}
@@ -0,0 +1,287 @@
+package openai
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/antigravity"
+)
+
+// TransformClaudeToResponses 将 Claude Messages 请求转换为 Responses API 请求体
+// 上游统一使用流式请求，客户端非流式请求由调用方收集流式响应后再转换。
+//   - system → developer 消息
+//   - text/image → input_text/input_image，tool_use → function_call，tool_result → function_call_output
+//   - thinking 块无法回传给 OpenAI（缺少加密推理内容），直接丢弃
+//   - thinking 配置 → reasoning.effort（按 budget_tokens 分档）
+func TransformClaudeToResponses(claudeReq *antigravity.ClaudeRequest, mappedModel string) ([]byte, error) {
+	if claudeReq == nil {
+		return nil, fmt.Errorf("empty request")
+	}
+
+	input := make([]map[string]any, 0, len(claudeReq.Messages)+1)
+	if system := claudeSystemText(claudeReq.System); system != "" {
+		input = append(input, map[string]any{
+			"type":    "message",
+			"role":    "developer",
+			"content": []map[string]any{{"type": "input_text", "text": system}},
+		})
+	}
+
+	for i, msg := range claudeReq.Messages {
+		items, err := claudeMessageToResponsesInput(msg)
+		if err != nil {
+			return nil, fmt.Errorf("messages[%d]: %w", i, err)
+		}
+		input = append(input, items...)
+	}
+	if len(input) == 0 {
+		return nil, fmt.Errorf("messages is required")
+	}
+
+	out := map[string]any{
+		"model":  mappedModel,
+		"input":  input,
+		"stream": true,
+		"store":  false,
+	}
+	if claudeReq.MaxTokens > 0 {
+		out["max_output_tokens"] = claudeReq.MaxTokens
+	}
+	// Responses 推理模型不接受 temperature/top_p/top_k，这里统一忽略
+
+	if claudeReq.Thinking != nil && claudeReq.Thinking.Type == "enabled" {
+		out["reasoning"] = map[string]any{
+			"effort":  reasoningEffortForBudget(claudeReq.Thinking.BudgetTokens),
+			"summary": "auto",
+		}
+	}
+
+	if tools := claudeToolsToResponses(claudeReq.Tools); len(tools) > 0 {
+		out["tools"] = tools
+		if choice := claudeToolChoiceToResponses(claudeReq.ToolChoice); choice != nil {
+			out["tool_choice"] = choice
+		}
+	}
+
+	return json.Marshal(out)
+}
+
+// reasoningEffortForBudget 将 Claude thinking budget 映射为 reasoning.effort
+func reasoningEffortForBudget(budget int) string {
+	switch {
+	case budget <= 0:
+		return "medium"
+	case budget <= 4096:
+		return "low"
+	case budget <= 16384:
+		return "medium"
+	default:
+		return "high"
+	}
+}
+
+// claudeSystemText 提取 system（string 或 []SystemBlock）中的文本
+func claudeSystemText(system json.RawMessage) string {
+	if len(system) == 0 {
+		return ""
+	}
+	var text string
+	if err := json.Unmarshal(system, &text); err == nil {
+		return strings.TrimSpace(text)
+	}
+	var blocks []antigravity.SystemBlock
+	if err := json.Unmarshal(system, &blocks); err != nil {
+		return ""
+	}
+	parts := make([]string, 0, len(blocks))
+	for _, block := range blocks {
+		if block.Type == "text" && strings.TrimSpace(block.Text) != "" {
+			parts = append(parts, block.Text)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// parseClaudeContent 解析消息 content（string 或内容块数组）
+func parseClaudeContent(content json.RawMessage) ([]antigravity.ContentBlock, error) {
+	trimmed := strings.TrimSpace(string(content))
+	if trimmed == "" || trimmed == "null" {
+		return nil, nil
+	}
+	if strings.HasPrefix(trimmed, "\"") {
+		var text string
+		if err := json.Unmarshal(content, &text); err != nil {
+			return nil, fmt.Errorf("parse content string: %w", err)
+		}
+		return []antigravity.ContentBlock{{Type: "text", Text: text}}, nil
+	}
+	var blocks []antigravity.ContentBlock
+	if err := json.Unmarshal(content, &blocks); err != nil {
+		return nil, fmt.Errorf("parse content blocks: %w", err)
+	}
+	return blocks, nil
+}
+
+// claudeMessageToResponsesInput 将单条 Claude 消息转换为 Responses input 项（保持块顺序）
+func claudeMessageToResponsesInput(msg antigravity.ClaudeMessage) ([]map[string]any, error) {
+	blocks, err := parseClaudeContent(msg.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	role := msg.Role
+	textType := "input_text"
+	if role == "assistant" {
+		textType = "output_text"
+	} else if role != "user" {
+		return nil, fmt.Errorf("unsupported role %q", role)
+	}
+
+	var items []map[string]any
+	var content []map[string]any
+	flush := func() {
+		if len(content) == 0 {
+			return
+		}
+		items = append(items, map[string]any{"type": "message", "role": role, "content": content})
+		content = nil
+	}
+
+	for _, block := range blocks {
+		switch block.Type {
+		case "text":
+			if block.Text == "" {
+				continue
+			}
+			content = append(content, map[string]any{"type": textType, "text": block.Text})
+		case "image":
+			if role != "user" || block.Source == nil || block.Source.Type != "base64" || block.Source.Data == "" {
+				continue
+			}
+			content = append(content, map[string]any{
+				"type":      "input_image",
+				"image_url": "data:" + block.Source.MediaType + ";base64," + block.Source.Data,
+			})
+		case "tool_use":
+			flush()
+			args := "{}"
+			if block.Input != nil {
+				b, err := json.Marshal(block.Input)
+				if err != nil {
+					return nil, fmt.Errorf("marshal tool_use input: %w", err)
+				}
+				args = string(b)
+			}
+			items = append(items, map[string]any{
+				"type":      "function_call",
+				"call_id":   block.ID,
+				"name":      block.Name,
+				"arguments": args,
+			})
+		case "tool_result":
+			flush()
+			items = append(items, map[string]any{
+				"type":    "function_call_output",
+				"call_id": block.ToolUseID,
+				"output":  claudeToolResultText(block.Content, block.IsError),
+			})
+		case "thinking", "redacted_thinking":
+			// OpenAI 无法校验 Claude 的 thinking 签名，历史推理内容直接丢弃
+			continue
+		}
+	}
+	flush()
+	return items, nil
+}
+
+// claudeToolResultText 提取 tool_result 的文本内容
+func claudeToolResultText(content json.RawMessage, isError bool) string {
+	var text string
+	if len(content) > 0 {
+		if err := json.Unmarshal(content, &text); err != nil {
+			blocks, parseErr := parseClaudeContent(content)
+			if parseErr != nil {
+				text = string(content)
+			} else {
+				parts := make([]string, 0, len(blocks))
+				for _, block := range blocks {
+					if block.Type == "text" && block.Text != "" {
+						parts = append(parts, block.Text)
+					}
+				}
+				text = strings.Join(parts, "\n")
+			}
+		}
+	}
+	if isError {
+		if strings.TrimSpace(text) == "" {
+			return "Error: tool execution failed"
+		}
+		return "Error: " + text
+	}
+	return text
+}
+
+// claudeToolsToResponses 转换工具定义（仅支持自定义函数工具，服务端工具忽略）
+func claudeToolsToResponses(tools []antigravity.ClaudeTool) []map[string]any {
+	out := make([]map[string]any, 0, len(tools))
+	for _, tool := range tools {
+		if tool.Type != "" && tool.Type != "custom" {
+			continue
+		}
+		if tool.Name == "" {
+			continue
+		}
+		description := tool.Description
+		schema := tool.InputSchema
+		if tool.Custom != nil {
+			if description == "" {
+				description = tool.Custom.Description
+			}
+			if schema == nil {
+				schema = tool.Custom.InputSchema
+			}
+		}
+		if schema == nil {
+			schema = map[string]any{"type": "object", "properties": map[string]any{}}
+		}
+		def := map[string]any{
+			"type":       "function",
+			"name":       tool.Name,
+			"parameters": schema,
+		}
+		if description != "" {
+			def["description"] = description
+		}
+		out = append(out, def)
+	}
+	return out
+}
+
+// claudeToolChoiceToResponses 转换 tool_choice：auto→auto，any→required，tool→function，none→none
+func claudeToolChoiceToResponses(raw json.RawMessage) any {
+	if len(raw) == 0 {
+		return nil
+	}
+	var choice struct {
+		Type string `json:"type"`
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(raw, &choice); err != nil {
+		return nil
+	}
+	switch choice.Type {
+	case "auto", "none":
+		return choice.Type
+	case "any":
+		return "required"
+	case "tool":
+		if choice.Name == "" {
+			return nil
+		}
+		return map[string]any{"type": "function", "name": choice.Name}
+	default:
+		return nil
+	}
+}
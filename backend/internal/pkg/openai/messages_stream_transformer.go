@@ -0,0 +1,390 @@
+package openai
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/antigravity"
+	"github.com/google/uuid"
+)
+
+// claudeBlockType 当前打开的 Claude 内容块类型
+type claudeBlockType int
+
+const (
+	claudeBlockNone claudeBlockType = iota
+	claudeBlockText
+	claudeBlockThinking
+	claudeBlockToolUse
+)
+
+// ClaudeStreamingProcessor 将 Responses API SSE 转换为 Claude Messages SSE，
+// 同时累积完整响应内容，供非流式请求构造最终响应。
+type ClaudeStreamingProcessor struct {
+	originalModel string
+	messageID     string
+
+	messageStartSent bool
+	messageStopSent  bool
+	usedTool         bool
+	maxTokensHit     bool
+
+	blockType        claudeBlockType
+	blockIndex       int
+	blockOutputIndex int
+	blockHasArgs     bool
+
+	// 累积内容（非流式使用）
+	content []antigravity.ClaudeContentItem
+	buffers []*strings.Builder
+
+	usage antigravity.ClaudeUsage
+
+	errType    string
+	errMessage string
+}
+
+// NewClaudeStreamingProcessor 创建 Responses → Claude 流式转换处理器
+func NewClaudeStreamingProcessor(originalModel string) *ClaudeStreamingProcessor {
+	return &ClaudeStreamingProcessor{
+		originalModel:    originalModel,
+		blockOutputIndex: -1,
+	}
+}
+
+// responsesStreamEvent Responses SSE 事件（仅转换所需字段）
+type responsesStreamEvent struct {
+	Type         string               `json:"type"`
+	OutputIndex  int                  `json:"output_index"`
+	SummaryIndex int                  `json:"summary_index"`
+	Delta        string               `json:"delta"`
+	Item         *responsesOutputItem `json:"item"`
+	Response     *responsesResult     `json:"response"`
+	Code         string               `json:"code"`
+	Message      string               `json:"message"`
+}
+
+// ProcessLine 处理 SSE 行，返回 Claude SSE 事件
+func (p *ClaudeStreamingProcessor) ProcessLine(line string) []byte {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "data:") {
+		return nil
+	}
+	data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+	if data == "" || data == "[DONE]" {
+		return nil
+	}
+
+	var event responsesStreamEvent
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		return nil
+	}
+	if p.messageStopSent {
+		return nil
+	}
+
+	var result bytes.Buffer
+	switch event.Type {
+	case "response.created", "response.in_progress":
+		if event.Response != nil && p.messageID == "" {
+			p.messageID = event.Response.ID
+		}
+		_, _ = result.Write(p.emitMessageStart())
+
+	case "response.output_item.added":
+		_, _ = result.Write(p.emitMessageStart())
+		if event.Item == nil {
+			break
+		}
+		switch event.Item.Type {
+		case "function_call":
+			p.usedTool = true
+			id := event.Item.CallID
+			if id == "" {
+				id = "toolu_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+			}
+			_, _ = result.Write(p.startBlock(claudeBlockToolUse, event.OutputIndex, antigravity.ClaudeContentItem{
+				Type:  "tool_use",
+				ID:    id,
+				Name:  event.Item.Name,
+				Input: map[string]any{},
+			}))
+		case "reasoning":
+			_, _ = result.Write(p.startBlock(claudeBlockThinking, event.OutputIndex, antigravity.ClaudeContentItem{Type: "thinking"}))
+		}
+
+	case "response.reasoning_summary_part.added":
+		// 多段 summary 之间补充空行
+		if event.SummaryIndex > 0 && p.blockType == claudeBlockThinking {
+			_, _ = result.Write(p.emitDelta("thinking_delta", "thinking", "\n\n"))
+		}
+
+	case "response.reasoning_summary_text.delta", "response.reasoning_text.delta":
+		_, _ = result.Write(p.emitMessageStart())
+		if p.blockType != claudeBlockThinking || p.blockOutputIndex != event.OutputIndex {
+			_, _ = result.Write(p.startBlock(claudeBlockThinking, event.OutputIndex, antigravity.ClaudeContentItem{Type: "thinking"}))
+		}
+		_, _ = result.Write(p.emitDelta("thinking_delta", "thinking", event.Delta))
+
+	case "response.output_text.delta":
+		_, _ = result.Write(p.emitMessageStart())
+		if p.blockType != claudeBlockText || p.blockOutputIndex != event.OutputIndex {
+			_, _ = result.Write(p.startBlock(claudeBlockText, event.OutputIndex, antigravity.ClaudeContentItem{Type: "text"}))
+		}
+		_, _ = result.Write(p.emitDelta("text_delta", "text", event.Delta))
+
+	case "response.function_call_arguments.delta":
+		if p.blockType != claudeBlockToolUse || p.blockOutputIndex != event.OutputIndex {
+			break
+		}
+		p.blockHasArgs = true
+		_, _ = result.Write(p.emitDelta("input_json_delta", "partial_json", event.Delta))
+
+	case "response.output_item.done":
+		if p.blockOutputIndex != event.OutputIndex {
+			break
+		}
+		// 部分上游只在 done 事件中给出完整参数
+		if p.blockType == claudeBlockToolUse && !p.blockHasArgs && event.Item != nil && event.Item.Arguments != "" {
+			_, _ = result.Write(p.emitDelta("input_json_delta", "partial_json", event.Item.Arguments))
+		}
+		_, _ = result.Write(p.endBlock())
+
+	case "response.completed", "response.done", "response.incomplete":
+		_, _ = result.Write(p.emitMessageStart())
+		if event.Response != nil {
+			p.captureUsage(event.Response.Usage)
+			if event.Response.Status == "incomplete" && event.Response.IncompleteDetails != nil &&
+				event.Response.IncompleteDetails.Reason == "max_output_tokens" {
+				p.maxTokensHit = true
+			}
+		}
+		_, _ = result.Write(p.emitFinish())
+
+	case "response.failed":
+		message := "Upstream response failed"
+		if event.Response != nil && event.Response.Error != nil && event.Response.Error.Message != "" {
+			message = event.Response.Error.Message
+		}
+		_, _ = result.Write(p.emitError("api_error", message))
+
+	case "error":
+		message := event.Message
+		if message == "" {
+			message = "Upstream stream error"
+		}
+		_, _ = result.Write(p.emitError("api_error", message))
+	}
+
+	return result.Bytes()
+}
+
+// Finish 结束处理，返回剩余事件和用量
+func (p *ClaudeStreamingProcessor) Finish() ([]byte, *antigravity.ClaudeUsage) {
+	usage := p.usage
+	if !p.messageStartSent || p.messageStopSent {
+		return nil, &usage
+	}
+	return p.emitFinish(), &usage
+}
+
+// Err 返回上游在流中报告的错误（无错误时返回 nil）
+func (p *ClaudeStreamingProcessor) Err() error {
+	if p.errMessage == "" {
+		return nil
+	}
+	return fmt.Errorf("%s: %s", p.errType, p.errMessage)
+}
+
+// HasContent 是否已收到任何可转换的上游事件
+func (p *ClaudeStreamingProcessor) HasContent() bool {
+	return p.messageStartSent
+}
+
+// BuildResponse 根据累积内容构造 Claude 非流式响应
+func (p *ClaudeStreamingProcessor) BuildResponse() *antigravity.ClaudeResponse {
+	content := make([]antigravity.ClaudeContentItem, 0, len(p.content))
+	for i, item := range p.content {
+		text := p.buffers[i].String()
+		switch item.Type {
+		case "text":
+			if text == "" {
+				continue
+			}
+			item.Text = text
+		case "thinking":
+			if text == "" {
+				continue
+			}
+			item.Thinking = text
+		case "tool_use":
+			input := map[string]any{}
+			if strings.TrimSpace(text) != "" {
+				_ = json.Unmarshal([]byte(text), &input)
+			}
+			item.Input = input
+		}
+		content = append(content, item)
+	}
+	return &antigravity.ClaudeResponse{
+		ID:         p.responseMessageID(),
+		Type:       "message",
+		Role:       "assistant",
+		Model:      p.originalModel,
+		Content:    content,
+		StopReason: p.stopReason(),
+		Usage:      p.usage,
+	}
+}
+
+func (p *ClaudeStreamingProcessor) captureUsage(usage *responsesUsage) {
+	if usage == nil {
+		return
+	}
+	// Responses 的 input_tokens 包含缓存命中部分，Claude 的 input_tokens 不包含
+	cached := usage.InputTokensDetails.CachedTokens
+	p.usage = antigravity.ClaudeUsage{
+		InputTokens:          usage.InputTokens - cached,
+		OutputTokens:         usage.OutputTokens,
+		CacheReadInputTokens: cached,
+	}
+}
+
+func (p *ClaudeStreamingProcessor) responseMessageID() string {
+	if p.messageID == "" {
+		p.messageID = "msg_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	}
+	if strings.HasPrefix(p.messageID, "msg_") {
+		return p.messageID
+	}
+	return "msg_" + strings.TrimPrefix(p.messageID, "resp_")
+}
+
+func (p *ClaudeStreamingProcessor) stopReason() string {
+	switch {
+	case p.usedTool:
+		return "tool_use"
+	case p.maxTokensHit:
+		return "max_tokens"
+	default:
+		return "end_turn"
+	}
+}
+
+// emitMessageStart 发送 message_start 事件（仅一次）
+func (p *ClaudeStreamingProcessor) emitMessageStart() []byte {
+	if p.messageStartSent {
+		return nil
+	}
+	p.messageStartSent = true
+	return formatClaudeSSE("message_start", map[string]any{
+		"type": "message_start",
+		"message": map[string]any{
+			"id":            p.responseMessageID(),
+			"type":          "message",
+			"role":          "assistant",
+			"content":       []any{},
+			"model":         p.originalModel,
+			"stop_reason":   nil,
+			"stop_sequence": nil,
+			"usage":         antigravity.ClaudeUsage{},
+		},
+	})
+}
+
+// startBlock 开始新的内容块（自动关闭当前块）
+func (p *ClaudeStreamingProcessor) startBlock(blockType claudeBlockType, outputIndex int, item antigravity.ClaudeContentItem) []byte {
+	var result bytes.Buffer
+	_, _ = result.Write(p.endBlock())
+
+	contentBlock := map[string]any{"type": item.Type}
+	switch item.Type {
+	case "text":
+		contentBlock["text"] = ""
+	case "thinking":
+		contentBlock["thinking"] = ""
+	case "tool_use":
+		contentBlock["id"] = item.ID
+		contentBlock["name"] = item.Name
+		contentBlock["input"] = map[string]any{}
+	}
+	_, _ = result.Write(formatClaudeSSE("content_block_start", map[string]any{
+		"type":          "content_block_start",
+		"index":         p.blockIndex,
+		"content_block": contentBlock,
+	}))
+
+	p.blockType = blockType
+	p.blockOutputIndex = outputIndex
+	p.blockHasArgs = false
+	p.content = append(p.content, item)
+	p.buffers = append(p.buffers, &strings.Builder{})
+	return result.Bytes()
+}
+
+// endBlock 结束当前内容块
+func (p *ClaudeStreamingProcessor) endBlock() []byte {
+	if p.blockType == claudeBlockNone {
+		return nil
+	}
+	event := formatClaudeSSE("content_block_stop", map[string]any{
+		"type":  "content_block_stop",
+		"index": p.blockIndex,
+	})
+	p.blockIndex++
+	p.blockType = claudeBlockNone
+	p.blockOutputIndex = -1
+	return event
+}
+
+// emitDelta 发送 content_block_delta 事件并累积内容
+func (p *ClaudeStreamingProcessor) emitDelta(deltaType, field, value string) []byte {
+	if value == "" || p.blockType == claudeBlockNone {
+		return nil
+	}
+	p.buffers[len(p.buffers)-1].WriteString(value)
+	return formatClaudeSSE("content_block_delta", map[string]any{
+		"type":  "content_block_delta",
+		"index": p.blockIndex,
+		"delta": map[string]any{"type": deltaType, field: value},
+	})
+}
+
+// emitFinish 发送 message_delta + message_stop 事件
+func (p *ClaudeStreamingProcessor) emitFinish() []byte {
+	var result bytes.Buffer
+	_, _ = result.Write(p.endBlock())
+	_, _ = result.Write(formatClaudeSSE("message_delta", map[string]any{
+		"type": "message_delta",
+		"delta": map[string]any{
+			"stop_reason":   p.stopReason(),
+			"stop_sequence": nil,
+		},
+		"usage": p.usage,
+	}))
+	_, _ = result.Write(formatClaudeSSE("message_stop", map[string]any{"type": "message_stop"}))
+	p.messageStopSent = true
+	return result.Bytes()
+}
+
+// emitError 发送 Claude error 事件并终止流
+func (p *ClaudeStreamingProcessor) emitError(errType, message string) []byte {
+	p.errType = errType
+	p.errMessage = message
+	p.messageStopSent = true
+	return formatClaudeSSE("error", antigravity.ClaudeError{
+		Type:  "error",
+		Error: antigravity.ErrorDetail{Type: errType, Message: message},
+	})
+}
+
+// formatClaudeSSE 格式化 Claude SSE 事件
+func formatClaudeSSE(eventType string, data any) []byte {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return nil
+	}
+	return []byte(fmt.Sprintf("event: %s\ndata: %s\n\n", eventType, string(jsonData)))
+}
@@ -0,0 +1,182 @@
+package openai
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/antigravity"
+)
+
+func TestTransformClaudeToResponses(t *testing.T) {
+	raw := `{
+		"model": "claude-sonnet-4-5",
+		"max_tokens": 1024,
+		"temperature": 0.5,
+		"system": [{"type": "text", "text": "You are helpful."}],
+		"thinking": {"type": "enabled", "budget_tokens": 20000},
+		"messages": [
+			{"role": "user", "content": [
+				{"type": "text", "text": "look at this"},
+				{"type": "image", "source": {"type": "base64", "media_type": "image/png", "data": "AAAA"}}
+			]},
+			{"role": "assistant", "content": [
+				{"type": "thinking", "thinking": "hmm", "signature": "sig"},
+				{"type": "text", "text": "calling tool"},
+				{"type": "tool_use", "id": "toolu_1", "name": "read", "input": {"path": "a.txt"}}
+			]},
+			{"role": "user", "content": [
+				{"type": "tool_result", "tool_use_id": "toolu_1", "content": [{"type": "text", "text": "file body"}]},
+				{"type": "text", "text": "go on"}
+			]}
+		],
+		"tools": [
+			{"name": "read", "description": "read file", "input_schema": {"type": "object"}},
+			{"type": "web_search_20250305", "name": "web_search"}
+		],
+		"tool_choice": {"type": "any"}
+	}`
+	var req antigravity.ClaudeRequest
+	if err := json.Unmarshal([]byte(raw), &req); err != nil {
+		t.Fatalf("unmarshal claude request: %v", err)
+	}
+
+	body, err := TransformClaudeToResponses(&req, "gpt-5.1-codex")
+	if err != nil {
+		t.Fatalf("TransformClaudeToResponses() error = %v", err)
+	}
+
+	var out struct {
+		Model           string           `json:"model"`
+		Stream          bool             `json:"stream"`
+		MaxOutputTokens int              `json:"max_output_tokens"`
+		Temperature     *float64         `json:"temperature"`
+		Input           []map[string]any `json:"input"`
+		Tools           []map[string]any `json:"tools"`
+		ToolChoice      any              `json:"tool_choice"`
+		Reasoning       map[string]any   `json:"reasoning"`
+	}
+	if err := json.Unmarshal(body, &out); err != nil {
+		t.Fatalf("unmarshal responses body: %v", err)
+	}
+
+	if out.Model != "gpt-5.1-codex" || !out.Stream || out.MaxOutputTokens != 1024 || out.Temperature != nil {
+		t.Fatalf("unexpected top-level fields: %+v", out)
+	}
+	if out.Reasoning["effort"] != "high" {
+		t.Fatalf("reasoning = %v, want effort high", out.Reasoning)
+	}
+
+	wantTypes := []string{"message", "message", "message", "function_call", "function_call_output", "message"}
+	if len(out.Input) != len(wantTypes) {
+		t.Fatalf("input items = %d, want %d: %v", len(out.Input), len(wantTypes), out.Input)
+	}
+	for i, typ := range wantTypes {
+		if out.Input[i]["type"] != typ {
+			t.Fatalf("input[%d].type = %v, want %s", i, out.Input[i]["type"], typ)
+		}
+	}
+	if out.Input[0]["role"] != "developer" {
+		t.Fatalf("system should become developer message, got %v", out.Input[0])
+	}
+	userContent := out.Input[1]["content"].([]any)
+	if len(userContent) != 2 || userContent[1].(map[string]any)["image_url"] != "data:image/png;base64,AAAA" {
+		t.Fatalf("unexpected user content: %v", userContent)
+	}
+	// thinking 块被丢弃，assistant 仅保留文本
+	assistantContent := out.Input[2]["content"].([]any)
+	if len(assistantContent) != 1 || assistantContent[0].(map[string]any)["type"] != "output_text" {
+		t.Fatalf("unexpected assistant content: %v", assistantContent)
+	}
+	if out.Input[3]["call_id"] != "toolu_1" || out.Input[3]["arguments"] != `{"path":"a.txt"}` {
+		t.Fatalf("unexpected function_call: %v", out.Input[3])
+	}
+	if out.Input[4]["output"] != "file body" {
+		t.Fatalf("unexpected function_call_output: %v", out.Input[4])
+	}
+
+	if len(out.Tools) != 1 || out.Tools[0]["name"] != "read" {
+		t.Fatalf("server tools should be skipped, got %v", out.Tools)
+	}
+	if out.ToolChoice != "required" {
+		t.Fatalf("tool_choice = %v, want required", out.ToolChoice)
+	}
+}
+
+func runClaudeStreamingProcessor(lines []string) (*ClaudeStreamingProcessor, string) {
+	p := NewClaudeStreamingProcessor("claude-sonnet-4-5")
+	var sb strings.Builder
+	for _, line := range lines {
+		sb.Write(p.ProcessLine(line))
+	}
+	final, _ := p.Finish()
+	sb.Write(final)
+	return p, sb.String()
+}
+
+func TestClaudeStreamingProcessor_TextAndToolUse(t *testing.T) {
+	p, out := runClaudeStreamingProcessor([]string{
+		`event: response.created`,
+		`data: {"type":"response.created","response":{"id":"resp_abc"}}`,
+		`data: {"type":"response.output_item.added","output_index":0,"item":{"type":"reasoning"}}`,
+		`data: {"type":"response.reasoning_summary_text.delta","output_index":0,"delta":"think"}`,
+		`data: {"type":"response.output_item.done","output_index":0,"item":{"type":"reasoning"}}`,
+		`data: {"type":"response.output_item.added","output_index":1,"item":{"type":"message"}}`,
+		`data: {"type":"response.output_text.delta","output_index":1,"delta":"Hello"}`,
+		`data: {"type":"response.output_item.done","output_index":1,"item":{"type":"message"}}`,
+		`data: {"type":"response.output_item.added","output_index":2,"item":{"type":"function_call","call_id":"call_1","name":"read"}}`,
+		`data: {"type":"response.function_call_arguments.delta","output_index":2,"delta":"{\"path\":"}`,
+		`data: {"type":"response.function_call_arguments.delta","output_index":2,"delta":"\"a\"}"}`,
+		`data: {"type":"response.output_item.done","output_index":2,"item":{"type":"function_call"}}`,
+		`data: {"type":"response.completed","response":{"status":"completed","usage":{"input_tokens":100,"output_tokens":20,"input_tokens_details":{"cached_tokens":60}}}}`,
+	})
+
+	for _, want := range []string{
+		"event: message_start",
+		`"id":"msg_abc"`,
+		`"thinking":"think","type":"thinking_delta"`,
+		`"text":"Hello","type":"text_delta"`,
+		`"type":"tool_use"`,
+		`"id":"call_1"`,
+		`"partial_json":"{\"path\":"`,
+		`"stop_reason":"tool_use"`,
+		"event: message_stop",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("stream output missing %s:\n%s", want, out)
+		}
+	}
+	if strings.Count(out, "event: message_stop") != 1 {
+		t.Fatalf("expected exactly one message_stop:\n%s", out)
+	}
+
+	resp := p.BuildResponse()
+	if len(resp.Content) != 3 || resp.Content[0].Thinking != "think" || resp.Content[1].Text != "Hello" {
+		t.Fatalf("unexpected content: %+v", resp.Content)
+	}
+	input, _ := resp.Content[2].Input.(map[string]any)
+	if input["path"] != "a" {
+		t.Fatalf("unexpected tool input: %+v", resp.Content[2].Input)
+	}
+	if resp.Usage.InputTokens != 40 || resp.Usage.CacheReadInputTokens != 60 || resp.Usage.OutputTokens != 20 {
+		t.Fatalf("unexpected usage: %+v", resp.Usage)
+	}
+}
+
+func TestClaudeStreamingProcessor_IncompleteAndFailed(t *testing.T) {
+	p, out := runClaudeStreamingProcessor([]string{
+		`data: {"type":"response.output_text.delta","output_index":0,"delta":"partial"}`,
+		`data: {"type":"response.incomplete","response":{"status":"incomplete","incomplete_details":{"reason":"max_output_tokens"}}}`,
+	})
+	if !strings.Contains(out, `"stop_reason":"max_tokens"`) || p.Err() != nil {
+		t.Fatalf("expected max_tokens stop reason:\n%s", out)
+	}
+
+	p, out = runClaudeStreamingProcessor([]string{
+		`data: {"type":"response.created","response":{"id":"resp_x"}}`,
+		`data: {"type":"response.failed","response":{"error":{"code":"server_error","message":"boom"}}}`,
+	})
+	if !strings.Contains(out, "event: error") || strings.Contains(out, "message_stop") || p.Err() == nil {
+		t.Fatalf("expected error event without message_stop:\n%s", out)
+	}
+}
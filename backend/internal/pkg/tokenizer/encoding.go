@@ -0,0 +1,126 @@
+package tokenizer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"path"
+	"strconv"
+	"sync"
+
+	"github.com/eliben/go-sentencepiece"
+	"github.com/pkoukk/tiktoken-go"
+)
+
+// encoder turns text into token ids.
+type encoder interface {
+	encode(text string) []int
+}
+
+var (
+	encodersMu sync.Mutex
+	encoders   = map[string]encoder{}
+	bpeOnce    sync.Once
+)
+
+// loadEncoder parses a bundled vocabulary once and caches it.
+func loadEncoder(name string) (encoder, error) {
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+	if enc, ok := encoders[name]; ok {
+		return enc, nil
+	}
+
+	var (
+		enc encoder
+		err error
+	)
+	switch name {
+	case EncodingO200K, EncodingCL100K:
+		enc, err = newBPEEncoder(name)
+	case EncodingGemma:
+		enc, err = newSentencePieceEncoder("data/gemma2_tokenizer.model")
+	default:
+		err = fmt.Errorf("unknown encoding %q", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load %s encoding: %w", name, err)
+	}
+	encoders[name] = enc
+	return enc, nil
+}
+
+// bpeEncoder is a tiktoken byte-pair encoding.
+type bpeEncoder struct {
+	tk *tiktoken.Tiktoken
+}
+
+func newBPEEncoder(name string) (*bpeEncoder, error) {
+	// tiktoken-go downloads vocabularies by default; serve the bundled copies instead.
+	bpeOnce.Do(func() { tiktoken.SetBpeLoader(embeddedBpeLoader{}) })
+	tk, err := tiktoken.GetEncoding(name)
+	if err != nil {
+		return nil, err
+	}
+	return &bpeEncoder{tk: tk}, nil
+}
+
+func (e *bpeEncoder) encode(text string) []int {
+	return e.tk.EncodeOrdinary(text)
+}
+
+// embeddedBpeLoader resolves tiktoken vocabulary URLs to files under data/.
+type embeddedBpeLoader struct{}
+
+func (embeddedBpeLoader) LoadTiktokenBpe(file string) (map[string]int, error) {
+	raw, err := dataFS.ReadFile("data/" + path.Base(file))
+	if err != nil {
+		return nil, err
+	}
+	ranks := make(map[string]int, bytes.Count(raw, []byte{'\n'}))
+	for _, line := range bytes.Split(raw, []byte{'\n'}) {
+		if len(line) == 0 {
+			continue
+		}
+		token, rank, ok := bytes.Cut(line, []byte{' '})
+		if !ok {
+			return nil, fmt.Errorf("malformed line %q", line)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(string(token))
+		if err != nil {
+			return nil, err
+		}
+		r, err := strconv.Atoi(string(rank))
+		if err != nil {
+			return nil, err
+		}
+		ranks[string(decoded)] = r
+	}
+	return ranks, nil
+}
+
+// sentencePieceEncoder is a SentencePiece model.
+type sentencePieceEncoder struct {
+	proc *sentencepiece.Processor
+}
+
+func newSentencePieceEncoder(file string) (*sentencePieceEncoder, error) {
+	raw, err := dataFS.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	proc, err := sentencepiece.NewProcessor(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	return &sentencePieceEncoder{proc: proc}, nil
+}
+
+func (e *sentencePieceEncoder) encode(text string) []int {
+	tokens := e.proc.Encode(text)
+	ids := make([]int, len(tokens))
+	for i, t := range tokens {
+		ids[i] = t.ID
+	}
+	return ids
+}
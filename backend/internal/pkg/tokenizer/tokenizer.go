@@ -0,0 +1,196 @@
+// Package tokenizer provides in-process token counting for Claude, Gemini and
+// OpenAI models.
+//
+// The real public vocabularies are bundled under data/ and encoded without any
+// network call:
+//   - OpenAI: tiktoken o200k_base (GPT-4o and later) and cl100k_base
+//     (GPT-4, GPT-3.5, text-embedding-*) byte-pair encodings.
+//   - Gemini: the Gemma SentencePiece model Google publishes for local Gemini
+//     token counting.
+//
+// Anthropic does not publish the Claude 3+ tokenizer, so the Claude family is a
+// heuristic estimate: cl100k_base counts scaled by the profile's token_scale.
+// Profile.Heuristic reports this so callers can label the result accordingly.
+//
+// Each family profile (data/<family>.json) also carries the per-request,
+// per-message and per-tool overheads and the flat image cost used by request
+// level estimators.
+package tokenizer
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+)
+
+//go:embed data/*.json data/*.tiktoken data/*.model
+var dataFS embed.FS
+
+// Family identifies a tokenizer family.
+type Family string
+
+const (
+	FamilyClaude Family = "claude"
+	FamilyGemini Family = "gemini"
+	FamilyOpenAI Family = "openai"
+)
+
+// Families lists all bundled tokenizer families.
+var Families = []Family{FamilyClaude, FamilyGemini, FamilyOpenAI}
+
+// Bundled encodings.
+const (
+	EncodingO200K  = "o200k_base"
+	EncodingCL100K = "cl100k_base"
+	EncodingGemma  = "gemma2"
+)
+
+// Profile describes how a tokenizer family counts tokens.
+type Profile struct {
+	Name string `json:"name"`
+	// Encoding: bundled vocabulary used to encode text (o200k_base, cl100k_base or gemma2)
+	Encoding string `json:"encoding"`
+	// Heuristic: the family's tokenizer is not public; counts are Encoding counts times TokenScale
+	Heuristic bool `json:"heuristic"`
+	// TokenScale: multiplier applied to Encoding counts when Heuristic is set
+	TokenScale float64 `json:"token_scale"`
+
+	// RequestOverhead: fixed tokens added once per request
+	RequestOverhead int `json:"request_overhead"`
+	// MessageOverhead: fixed tokens added per message/turn
+	MessageOverhead int `json:"message_overhead"`
+	// ToolOverhead: fixed tokens added per tool definition
+	ToolOverhead int `json:"tool_overhead"`
+	// ImageTokens: tokens charged per image/document block when dimensions are unknown
+	ImageTokens int `json:"image_tokens"`
+}
+
+// Tokenizer counts tokens for one family and encoding.
+type Tokenizer struct {
+	profile Profile
+	enc     encoder
+}
+
+var (
+	loadOnce   sync.Once
+	profiles   map[Family]Profile
+	loadErr    error
+	tokenizers sync.Map // "family/encoding" -> *Tokenizer
+)
+
+func load() {
+	profiles = make(map[Family]Profile, len(Families))
+	for _, family := range Families {
+		raw, err := dataFS.ReadFile("data/" + string(family) + ".json")
+		if err != nil {
+			loadErr = fmt.Errorf("read %s profile: %w", family, err)
+			return
+		}
+		var profile Profile
+		if err := json.Unmarshal(raw, &profile); err != nil {
+			loadErr = fmt.Errorf("parse %s profile: %w", family, err)
+			return
+		}
+		profiles[family] = profile
+	}
+}
+
+// Get returns the tokenizer of a family, falling back to Claude for unknown families.
+func Get(family Family) *Tokenizer {
+	loadOnce.Do(load)
+	if loadErr != nil {
+		panic(loadErr)
+	}
+	if _, ok := profiles[family]; !ok {
+		family = FamilyClaude
+	}
+	return get(family, profiles[family].Encoding)
+}
+
+func get(family Family, encoding string) *Tokenizer {
+	key := string(family) + "/" + encoding
+	if t, ok := tokenizers.Load(key); ok {
+		return t.(*Tokenizer)
+	}
+	enc, err := loadEncoder(encoding)
+	if err != nil {
+		panic(err)
+	}
+	profile := profiles[family]
+	profile.Encoding = encoding
+	t, _ := tokenizers.LoadOrStore(key, &Tokenizer{profile: profile, enc: enc})
+	return t.(*Tokenizer)
+}
+
+// FamilyForModel picks the tokenizer family for a model name.
+func FamilyForModel(model string) Family {
+	m := normalizeModel(model)
+	switch {
+	case strings.HasPrefix(m, "gemini"), strings.HasPrefix(m, "gemma"):
+		return FamilyGemini
+	case strings.HasPrefix(m, "gpt"), strings.HasPrefix(m, "o1"), strings.HasPrefix(m, "o3"),
+		strings.HasPrefix(m, "o4"), strings.HasPrefix(m, "codex"), strings.HasPrefix(m, "text-embedding"),
+		strings.HasPrefix(m, "chatgpt"):
+		return FamilyOpenAI
+	default:
+		return FamilyClaude
+	}
+}
+
+// ForModel returns the tokenizer for a model name. OpenAI models released
+// before GPT-4o use cl100k_base, later ones o200k_base.
+func ForModel(model string) *Tokenizer {
+	family := FamilyForModel(model)
+	t := Get(family)
+	if family == FamilyOpenAI && usesCL100K(normalizeModel(model)) {
+		return get(FamilyOpenAI, EncodingCL100K)
+	}
+	return t
+}
+
+func normalizeModel(model string) string {
+	m := strings.ToLower(strings.TrimSpace(model))
+	return strings.TrimPrefix(m, "models/")
+}
+
+func usesCL100K(model string) bool {
+	switch {
+	case strings.HasPrefix(model, "gpt-3.5"), strings.HasPrefix(model, "text-embedding"):
+		return true
+	case model == "gpt-4", strings.HasPrefix(model, "gpt-4-"):
+		return true
+	default:
+		return false
+	}
+}
+
+// Profile returns the profile of the tokenizer.
+func (t *Tokenizer) Profile() Profile {
+	return t.profile
+}
+
+// Family returns the tokenizer family.
+func (t *Tokenizer) Family() Family {
+	return Family(t.profile.Name)
+}
+
+// Heuristic reports whether counts are an estimate rather than the upstream tokenizer's exact result.
+func (t *Tokenizer) Heuristic() bool {
+	return t.profile.Heuristic
+}
+
+// Count returns the number of tokens in text. Special-token markers such as
+// <|endoftext|> are counted as ordinary text.
+func (t *Tokenizer) Count(text string) int {
+	if text == "" {
+		return 0
+	}
+	n := len(t.enc.encode(text))
+	if t.profile.Heuristic && t.profile.TokenScale > 0 {
+		return int(math.Ceil(float64(n) * t.profile.TokenScale))
+	}
+	return n
+}
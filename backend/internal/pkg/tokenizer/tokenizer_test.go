@@ -0,0 +1,120 @@
+package tokenizer
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestBundledProfilesLoad(t *testing.T) {
+	for _, family := range Families {
+		tk := Get(family)
+		if tk == nil {
+			t.Fatalf("Get(%s) = nil", family)
+		}
+		if tk.Family() != family {
+			t.Fatalf("Get(%s).Family() = %s", family, tk.Family())
+		}
+		if tk.Count("hello world") == 0 {
+			t.Fatalf("%s counted no tokens", family)
+		}
+	}
+	if !Get(FamilyClaude).Heuristic() {
+		t.Fatal("claude counts must be labeled heuristic")
+	}
+	if Get(FamilyOpenAI).Heuristic() || Get(FamilyGemini).Heuristic() {
+		t.Fatal("openai and gemini use their real vocabularies")
+	}
+}
+
+func TestFamilyForModel(t *testing.T) {
+	cases := map[string]Family{
+		"claude-sonnet-4-5":        FamilyClaude,
+		"models/gemini-2.5-pro":    FamilyGemini,
+		"gpt-5-codex":              FamilyOpenAI,
+		"o3-mini":                  FamilyOpenAI,
+		"text-embedding-3-small":   FamilyOpenAI,
+		"some-unknown-model":       FamilyClaude,
+		"gemma-3-27b-it":           FamilyGemini,
+		"  GEMINI-2.0-FLASH-LITE ": FamilyGemini,
+	}
+	for model, want := range cases {
+		if got := FamilyForModel(model); got != want {
+			t.Fatalf("FamilyForModel(%q) = %s, want %s", model, got, want)
+		}
+	}
+}
+
+func TestForModelEncoding(t *testing.T) {
+	cases := map[string]string{
+		"gpt-4o-mini":            EncodingO200K,
+		"gpt-5":                  EncodingO200K,
+		"o3-mini":                EncodingO200K,
+		"gpt-4.1":                EncodingO200K,
+		"gpt-4":                  EncodingCL100K,
+		"gpt-4-turbo":            EncodingCL100K,
+		"gpt-3.5-turbo":          EncodingCL100K,
+		"text-embedding-3-small": EncodingCL100K,
+		"gemini-2.5-flash":       EncodingGemma,
+		"claude-opus-4-1":        EncodingCL100K,
+	}
+	for model, want := range cases {
+		if got := ForModel(model).Profile().Encoding; got != want {
+			t.Fatalf("ForModel(%q) encoding = %s, want %s", model, got, want)
+		}
+	}
+}
+
+// Expected ids come from the reference implementations: OpenAI tiktoken and
+// Google SentencePiece with the same vocabulary files.
+func TestEncodeMatchesReferenceTokenizers(t *testing.T) {
+	cases := []struct {
+		encoding string
+		text     string
+		want     []int
+	}{
+		{EncodingCL100K, "hello world", []int{15339, 1917}},
+		{EncodingCL100K, "tiktoken is great!", []int{83, 1609, 5963, 374, 2294, 0}},
+		{EncodingCL100K, "hello world!你好，世界！", []int{15339, 1917, 0, 57668, 53901, 3922, 3574, 244, 98220, 6447}},
+		{EncodingO200K, "hello world", []int{24912, 2375}},
+		{EncodingO200K, "tiktoken is great!", []int{83, 8251, 2488, 382, 2212, 0}},
+		{EncodingGemma, "hello world", []int{17534, 2134}},
+		{EncodingGemma, "12345", []int{235274, 235284, 235304, 235310, 235308}},
+		{EncodingGemma, "one line\nand another line", []int{785, 2017, 108, 639, 2550, 2017}},
+		{EncodingGemma, "Bienvenido a este proyecto", []int{176831, 476, 4004, 25431}},
+		{EncodingGemma, "if allow == true { return x;} else {return x+y;}", []int{648, 2765, 1159, 1382, 612, 2203, 1141, 22505, 1354, 612, 773, 1141, 235340, 235267, 22505}},
+	}
+	for _, tc := range cases {
+		enc, err := loadEncoder(tc.encoding)
+		if err != nil {
+			t.Fatalf("loadEncoder(%s): %v", tc.encoding, err)
+		}
+		if got := enc.encode(tc.text); !slices.Equal(got, tc.want) {
+			t.Fatalf("%s encode(%q) = %v, want %v", tc.encoding, tc.text, got, tc.want)
+		}
+	}
+}
+
+func TestCount(t *testing.T) {
+	openai := Get(FamilyOpenAI)
+	if got := openai.Count("tiktoken is great!"); got != 6 {
+		t.Fatalf("openai count = %d, want 6", got)
+	}
+	if got := openai.Count(""); got != 0 {
+		t.Fatalf("Count(empty) = %d, want 0", got)
+	}
+	// Special-token markers in user text are plain text, not control tokens.
+	if got := openai.Count("<|endoftext|>"); got <= 1 {
+		t.Fatalf("special token marker counted as %d token(s)", got)
+	}
+
+	gemini := Get(FamilyGemini)
+	if got := gemini.Count("12345"); got != 5 {
+		t.Fatalf("gemini digits = %d, want 5", got)
+	}
+
+	// Claude: cl100k count (6) scaled by token_scale, rounded up.
+	claude := Get(FamilyClaude)
+	if got := claude.Count("tiktoken is great!"); got != 7 {
+		t.Fatalf("claude count = %d, want 7", got)
+	}
+}
@@ -225,4 +225,30 @@ type AccountUsageStatsResponse struct {
 	History []AccountUsageHistory `json:"history"`
 	Summary AccountUsageSummary   `json:"summary"`
 	Models  []ModelStat           `json:"models"`
+
+	// RateBudget 当前 RPM/TPM 预算与滑动 1 分钟用量（未配置预算时为空）
+	RateBudget *AccountRateBudget `json:"rate_budget,omitempty"`
+
+	// CircuitBreaker 熔断器状态（未启用熔断时为空）
+	CircuitBreaker *AccountCircuitBreaker `json:"circuit_breaker,omitempty"`
+}
+
+// AccountRateBudget represents an account's per-minute request/token budget and current usage
+type AccountRateBudget struct {
+	RPMLimit  int   `json:"rpm_limit"`
+	RPMUsed   int64 `json:"rpm_used"`
+	TPMLimit  int   `json:"tpm_limit"`
+	TPMUsed   int64 `json:"tpm_used"`
+	Saturated bool  `json:"saturated"`
+}
+
+// AccountCircuitBreaker represents an account's circuit breaker state and rolling-window counters
+type AccountCircuitBreaker struct {
+	State          string     `json:"state"` // closed / open / half_open
+	WindowRequests int64      `json:"window_requests"`
+	WindowFailures int64      `json:"window_failures"`
+	FailureRatio   float64    `json:"failure_ratio"`
+	OpenUntil      *time.Time `json:"open_until,omitempty"`
+	ProbeSuccesses int64      `json:"probe_successes"`
+	Trips          int64      `json:"trips"`
 }
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	dbent "github.com/Wei-Shaw/sub2api/ent"
+	dbaccount "github.com/Wei-Shaw/sub2api/ent/account"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+// 账号可用时间窗口（accounts.availability_schedule）：
+// availability_next_transition_at 记录下一次可用状态切换时间，后台任务到期后投递 outbox 事件，
+// 调度快照据此按时把账号移入/移出分桶。
+
+const availabilityTransitionBatchSize = 500
+
+// encodeAccountAvailability 编码账号可用时间窗口，并计算下一次切换时间；未配置时均返回 nil
+func encodeAccountAvailability(account *service.Account, now time.Time) (json.RawMessage, *time.Time, error) {
+	if account.AvailabilitySchedule == nil {
+		return nil, nil, nil
+	}
+	encoded, err := json.Marshal(account.AvailabilitySchedule)
+	if err != nil {
+		return nil, nil, err
+	}
+	return encoded, account.AvailabilitySchedule.NextTransition(now), nil
+}
+
+func decodeAvailabilitySchedule(raw []byte) *service.AvailabilitySchedule {
+	if len(raw) == 0 {
+		return nil
+	}
+	var schedule service.AvailabilitySchedule
+	if err := json.Unmarshal(raw, &schedule); err != nil || len(schedule.Windows) == 0 {
+		return nil
+	}
+	return &schedule
+}
+
+// AdvanceAvailabilityTransitions 处理已到期的可用状态切换：推进下一次切换时间并投递账号变更事件
+func (r *accountRepository) AdvanceAvailabilityTransitions(ctx context.Context, now time.Time) (int64, error) {
+	due, err := r.client.Account.Query().
+		Where(dbaccount.AvailabilityNextTransitionAtLTE(now)).
+		Order(dbent.Asc(dbaccount.FieldAvailabilityNextTransitionAt)).
+		Limit(availabilityTransitionBatchSize).
+		Select(dbaccount.FieldID, dbaccount.FieldAvailabilitySchedule).
+		All(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var advanced int64
+	for _, item := range due {
+		update := r.client.Account.UpdateOneID(item.ID)
+		if next := decodeAvailabilitySchedule(item.AvailabilitySchedule).NextTransition(now); next != nil {
+			update.SetAvailabilityNextTransitionAt(*next)
+		} else {
+			update.ClearAvailabilityNextTransitionAt()
+		}
+		if err := update.Exec(ctx); err != nil {
+			return advanced, err
+		}
+		accountID := item.ID
+		if err := enqueueSchedulerOutbox(ctx, r.sql, service.SchedulerOutboxEventAccountChanged, &accountID, nil, nil); err != nil {
+			log.Printf("[SchedulerOutbox] enqueue availability transition failed: account=%d err=%v", accountID, err)
+		}
+		advanced++
+	}
+	return advanced, nil
+}
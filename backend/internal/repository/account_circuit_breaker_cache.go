@@ -0,0 +1,324 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/redis/go-redis/v9"
+)
+
+// 账号熔断状态
+//
+// 设计说明：
+// - 状态 Key: circuit:account:{accountID}（Hash：state/opened_at/open_until/probe_ok/trips），不存在即 closed
+// - 窗口 Key: circuit:account:{accountID}:w:{bucket}（Hash：n 请求数、f 失败数），按 10 秒分桶
+// - 探测 Key: circuit:account:{accountID}:probe（SET NX EX，半开状态下按间隔放行一个探测请求）
+// open 到期后在下一次读写时惰性转为 half_open。
+const (
+	circuitBreakerKeyPrefix = "circuit:account:"
+	// 状态 Key TTL（秒），长时间无流量的半开账号自然回到 closed
+	circuitBreakerStateTTLSeconds = 24 * 60 * 60
+)
+
+var (
+	// recordCircuitResultScript 记录请求结果并推进状态机
+	// KEYS[1] = circuit:account:{accountID}
+	// ARGV[1] = 是否失败（0/1）；ARGV[2] = 窗口秒数；ARGV[3] = 最少请求数；ARGV[4] = 失败比例阈值
+	// ARGV[5] = 熔断秒数；ARGV[6] = 半开恢复所需连续成功数；ARGV[7] = 状态 TTL
+	// 返回: {state, changed, requests, failures, opened_at, open_until, probe_ok, trips}
+	recordCircuitResultScript = redis.NewScript(`
+		local base = KEYS[1]
+		local failed = tonumber(ARGV[1])
+		local windowSec = tonumber(ARGV[2])
+		local minRequests = tonumber(ARGV[3])
+		local ratio = tonumber(ARGV[4])
+		local openSec = tonumber(ARGV[5])
+		local needOK = tonumber(ARGV[6])
+		local stateTTL = tonumber(ARGV[7])
+		local bucketSize = 10
+
+		local now = tonumber(redis.call('TIME')[1])
+		local st = redis.call('HMGET', base, 'state', 'open_until', 'trips')
+		local state = st[1] or 'closed'
+		local openUntil = tonumber(st[2]) or 0
+		local trips = tonumber(st[3]) or 0
+
+		local function clearWindow()
+			local bucket = math.floor(now / bucketSize)
+			for i = 0, math.ceil(windowSec / bucketSize) do
+				redis.call('DEL', base .. ':w:' .. (bucket - i))
+			end
+		end
+
+		local function trip(requests, failures)
+			openUntil = now + openSec
+			trips = trips + 1
+			redis.call('HSET', base, 'state', 'open', 'opened_at', now, 'open_until', openUntil, 'probe_ok', 0, 'trips', trips)
+			redis.call('EXPIRE', base, stateTTL)
+			clearWindow()
+			return {'open', 1, requests, failures, now, openUntil, 0, trips}
+		end
+
+		if state == 'open' then
+			if now < openUntil then
+				-- 熔断期间仍在进行中的请求结果不计入
+				return {'open', 0, 0, 0, 0, openUntil, 0, trips}
+			end
+			state = 'half_open'
+			redis.call('HSET', base, 'state', 'half_open', 'probe_ok', 0)
+		end
+
+		if state == 'half_open' then
+			if failed == 1 then
+				return trip(0, 0)
+			end
+			local ok = redis.call('HINCRBY', base, 'probe_ok', 1)
+			if ok >= needOK then
+				redis.call('HSET', base, 'state', 'closed', 'probe_ok', 0)
+				redis.call('EXPIRE', base, stateTTL)
+				redis.call('DEL', base .. ':probe')
+				return {'closed', 1, 0, 0, 0, 0, 0, trips}
+			end
+			return {'half_open', 0, 0, 0, 0, 0, ok, trips}
+		end
+
+		local bucket = math.floor(now / bucketSize)
+		local bucketKey = base .. ':w:' .. bucket
+		redis.call('HINCRBY', bucketKey, 'n', 1)
+		if failed == 1 then
+			redis.call('HINCRBY', bucketKey, 'f', 1)
+		end
+		redis.call('EXPIRE', bucketKey, windowSec + bucketSize)
+		if failed == 0 then
+			return {'closed', 0, 0, 0, 0, 0, 0, trips}
+		end
+
+		local requests, failures = 0, 0
+		for i = 0, math.ceil(windowSec / bucketSize) - 1 do
+			local v = redis.call('HMGET', base .. ':w:' .. (bucket - i), 'n', 'f')
+			requests = requests + (tonumber(v[1]) or 0)
+			failures = failures + (tonumber(v[2]) or 0)
+		end
+		if requests >= minRequests and failures / requests >= ratio then
+			return trip(requests, failures)
+		end
+		return {'closed', 0, requests, failures, 0, 0, 0, trips}
+	`)
+
+	// peekCircuitPermitsScript 批量只读判断账号是否可放行（不领取探测名额，供候选筛选使用）
+	// KEYS[i] = circuit:account:{accountID}
+	// 返回: [allowed1, allowed2, ...]（1 可放行，0 拒绝）
+	peekCircuitPermitsScript = redis.NewScript(`
+		local now = tonumber(redis.call('TIME')[1])
+		local result = {}
+		for i = 1, #KEYS do
+			local st = redis.call('HMGET', KEYS[i], 'state', 'open_until')
+			local state = st[1] or 'closed'
+			local allowed = 1
+			if state == 'open' and now < (tonumber(st[2]) or 0) then
+				allowed = 0
+			elseif state ~= 'closed' and redis.call('EXISTS', KEYS[i] .. ':probe') == 1 then
+				-- 半开（或熔断已到期）且本轮探测名额已被领取
+				allowed = 0
+			end
+			table.insert(result, allowed)
+		end
+		return result
+	`)
+
+	// acquireCircuitPermitScript 为最终选中的账号领取放行名额：open 拒绝；half_open 按间隔放行一个探测请求
+	// KEYS[1] = circuit:account:{accountID}；ARGV[1] = 探测间隔（秒）
+	// 返回: 1 放行，0 拒绝
+	acquireCircuitPermitScript = redis.NewScript(`
+		local now = tonumber(redis.call('TIME')[1])
+		local st = redis.call('HMGET', KEYS[1], 'state', 'open_until')
+		local state = st[1] or 'closed'
+		if state == 'open' then
+			if now < (tonumber(st[2]) or 0) then
+				return 0
+			end
+			state = 'half_open'
+			redis.call('HSET', KEYS[1], 'state', 'half_open', 'probe_ok', 0)
+		end
+		if state == 'half_open' then
+			if not redis.call('SET', KEYS[1] .. ':probe', '1', 'NX', 'EX', tonumber(ARGV[1])) then
+				return 0
+			end
+		end
+		return 1
+	`)
+
+	// getCircuitStatesScript 批量只读状态
+	// KEYS[i] = circuit:account:{accountID}；ARGV[1] = 窗口秒数
+	// 返回: 每个账号 7 项 {state, requests, failures, opened_at, open_until, probe_ok, trips}
+	getCircuitStatesScript = redis.NewScript(`
+		local now = tonumber(redis.call('TIME')[1])
+		local windowSec = tonumber(ARGV[1])
+		local bucketSize = 10
+		local bucket = math.floor(now / bucketSize)
+		local result = {}
+		for i = 1, #KEYS do
+			local st = redis.call('HMGET', KEYS[i], 'state', 'opened_at', 'open_until', 'probe_ok', 'trips')
+			local state = st[1] or 'closed'
+			local openUntil = tonumber(st[3]) or 0
+			if state == 'open' and now >= openUntil then
+				state = 'half_open'
+			end
+			local requests, failures = 0, 0
+			for j = 0, math.ceil(windowSec / bucketSize) - 1 do
+				local v = redis.call('HMGET', KEYS[i] .. ':w:' .. (bucket - j), 'n', 'f')
+				requests = requests + (tonumber(v[1]) or 0)
+				failures = failures + (tonumber(v[2]) or 0)
+			end
+			table.insert(result, state)
+			table.insert(result, requests)
+			table.insert(result, failures)
+			table.insert(result, tonumber(st[2]) or 0)
+			table.insert(result, openUntil)
+			table.insert(result, tonumber(st[4]) or 0)
+			table.insert(result, tonumber(st[5]) or 0)
+		end
+		return result
+	`)
+
+	// resetCircuitScript 清除状态、探测锁与窗口计数
+	// KEYS[1] = circuit:account:{accountID}；ARGV[1] = 窗口秒数
+	resetCircuitScript = redis.NewScript(`
+		local base = KEYS[1]
+		local now = tonumber(redis.call('TIME')[1])
+		local bucket = math.floor(now / 10)
+		for i = 0, math.ceil(tonumber(ARGV[1]) / 10) do
+			redis.call('DEL', base .. ':w:' .. (bucket - i))
+		end
+		redis.call('DEL', base, base .. ':probe')
+		return 1
+	`)
+)
+
+// 重置时清理的最大窗口（秒），覆盖配置调整前的旧窗口
+const circuitBreakerResetWindowSeconds = 3600
+
+type accountCircuitBreakerCache struct {
+	rdb *redis.Client
+}
+
+// NewAccountCircuitBreakerCache 创建账号熔断状态缓存
+func NewAccountCircuitBreakerCache(rdb *redis.Client) service.AccountCircuitBreakerCache {
+	return &accountCircuitBreakerCache{rdb: rdb}
+}
+
+func circuitBreakerKey(accountID int64) string {
+	return circuitBreakerKeyPrefix + strconv.FormatInt(accountID, 10)
+}
+
+func (c *accountCircuitBreakerCache) RecordCircuitResult(ctx context.Context, accountID int64, failed bool, settings service.CircuitBreakerSettings) (*service.CircuitBreakerState, bool, error) {
+	failedFlag := 0
+	if failed {
+		failedFlag = 1
+	}
+	values, err := recordCircuitResultScript.Run(ctx, c.rdb, []string{circuitBreakerKey(accountID)},
+		failedFlag,
+		settings.WindowSeconds,
+		settings.MinRequests,
+		strconv.FormatFloat(settings.FailureRatio, 'f', -1, 64),
+		settings.OpenSeconds,
+		settings.HalfOpenSuccesses,
+		circuitBreakerStateTTLSeconds,
+	).Slice()
+	if err != nil {
+		return nil, false, fmt.Errorf("record circuit result: %w", err)
+	}
+	if len(values) != 8 {
+		return nil, false, fmt.Errorf("record circuit result: unexpected reply length %d", len(values))
+	}
+	state := &service.CircuitBreakerState{
+		State:          fmt.Sprint(values[0]),
+		Requests:       toInt64(values[2]),
+		Failures:       toInt64(values[3]),
+		OpenedAtUnix:   toInt64(values[4]),
+		OpenUntilUnix:  toInt64(values[5]),
+		ProbeSuccesses: toInt64(values[6]),
+		Trips:          toInt64(values[7]),
+	}
+	return state, toInt64(values[1]) == 1, nil
+}
+
+func (c *accountCircuitBreakerCache) PeekCircuitPermits(ctx context.Context, accountIDs []int64) (map[int64]bool, error) {
+	result := make(map[int64]bool, len(accountIDs))
+	if len(accountIDs) == 0 {
+		return result, nil
+	}
+	keys := make([]string, 0, len(accountIDs))
+	for _, id := range accountIDs {
+		keys = append(keys, circuitBreakerKey(id))
+	}
+	values, err := peekCircuitPermitsScript.Run(ctx, c.rdb, keys).Int64Slice()
+	if err != nil {
+		return nil, fmt.Errorf("peek circuit permits: %w", err)
+	}
+	for i, id := range accountIDs {
+		if i >= len(values) {
+			break
+		}
+		result[id] = values[i] == 1
+	}
+	return result, nil
+}
+
+func (c *accountCircuitBreakerCache) AcquireCircuitPermit(ctx context.Context, accountID int64, settings service.CircuitBreakerSettings) (bool, error) {
+	allowed, err := acquireCircuitPermitScript.Run(ctx, c.rdb, []string{circuitBreakerKey(accountID)}, settings.ProbeIntervalSeconds).Int()
+	if err != nil {
+		return false, fmt.Errorf("acquire circuit permit: %w", err)
+	}
+	return allowed == 1, nil
+}
+
+func (c *accountCircuitBreakerCache) GetCircuitStatesBatch(ctx context.Context, accountIDs []int64, settings service.CircuitBreakerSettings) (map[int64]*service.CircuitBreakerState, error) {
+	result := make(map[int64]*service.CircuitBreakerState, len(accountIDs))
+	if len(accountIDs) == 0 {
+		return result, nil
+	}
+	keys := make([]string, 0, len(accountIDs))
+	for _, id := range accountIDs {
+		keys = append(keys, circuitBreakerKey(id))
+	}
+	values, err := getCircuitStatesScript.Run(ctx, c.rdb, keys, settings.WindowSeconds).Slice()
+	if err != nil {
+		return nil, fmt.Errorf("get circuit states: %w", err)
+	}
+	const fields = 7
+	for i, id := range accountIDs {
+		offset := i * fields
+		if offset+fields > len(values) {
+			break
+		}
+		result[id] = &service.CircuitBreakerState{
+			State:          fmt.Sprint(values[offset]),
+			Requests:       toInt64(values[offset+1]),
+			Failures:       toInt64(values[offset+2]),
+			OpenedAtUnix:   toInt64(values[offset+3]),
+			OpenUntilUnix:  toInt64(values[offset+4]),
+			ProbeSuccesses: toInt64(values[offset+5]),
+			Trips:          toInt64(values[offset+6]),
+		}
+	}
+	return result, nil
+}
+
+func (c *accountCircuitBreakerCache) ResetCircuit(ctx context.Context, accountID int64) error {
+	return resetCircuitScript.Run(ctx, c.rdb, []string{circuitBreakerKey(accountID)}, circuitBreakerResetWindowSeconds).Err()
+}
+
+func toInt64(v any) int64 {
+	switch n := v.(type) {
+	case int64:
+		return n
+	case string:
+		parsed, _ := strconv.ParseInt(n, 10, 64)
+		return parsed
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type accountHealthCheckRepository struct {
+	db *sql.DB
+}
+
+func NewAccountHealthCheckRepository(db *sql.DB) service.AccountHealthCheckRepository {
+	return &accountHealthCheckRepository{db: db}
+}
+
+func (r *accountHealthCheckRepository) Create(ctx context.Context, check *service.AccountHealthCheck) error {
+	if check == nil {
+		return nil
+	}
+	return r.db.QueryRowContext(ctx, `
+		INSERT INTO account_health_checks (account_id, platform, success, latency_ms, error_message, trigger)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`, check.AccountID, check.Platform, check.Success, check.LatencyMs, check.ErrorMessage, check.Trigger).
+		Scan(&check.ID, &check.CreatedAt)
+}
+
+func (r *accountHealthCheckRepository) ListByAccount(ctx context.Context, accountID int64, since time.Time, limit int) ([]service.AccountHealthCheck, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, account_id, platform, success, latency_ms, error_message, trigger, created_at
+		FROM account_health_checks
+		WHERE account_id = $1 AND created_at >= $2
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3
+	`, accountID, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	checks := make([]service.AccountHealthCheck, 0)
+	for rows.Next() {
+		var c service.AccountHealthCheck
+		if err := rows.Scan(&c.ID, &c.AccountID, &c.Platform, &c.Success, &c.LatencyMs, &c.ErrorMessage, &c.Trigger, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		checks = append(checks, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return checks, nil
+}
+
+func (r *accountHealthCheckRepository) ListRecentResults(ctx context.Context, accountID int64, limit int) ([]bool, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT success
+		FROM account_health_checks
+		WHERE account_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`, accountID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	results := make([]bool, 0, limit)
+	for rows.Next() {
+		var ok bool
+		if err := rows.Scan(&ok); err != nil {
+			return nil, err
+		}
+		results = append(results, ok)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (r *accountHealthCheckRepository) DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM account_health_checks WHERE created_at < $1", cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/redis/go-redis/v9"
+)
+
+// 账号 TTFT / 错误率 EWMA
+//
+// - Key: account_ewma:{accountID}
+// - Hash 字段: ttft（毫秒）、err（0-1）、n（样本数）
+// 长时间无流量的账号统计自然过期，重新从默认值开始评估。
+const (
+	accountLatencyStatsKeyPrefix  = "account_ewma:"
+	accountLatencyStatsTTLSeconds = 24 * 60 * 60
+)
+
+// recordAccountOutcomeScript 原子更新 EWMA
+// KEYS[1] = account_ewma:{accountID}
+// ARGV[1] = ttft 毫秒（<=0 不更新）；ARGV[2] = 是否失败（0/1）；ARGV[3] = alpha；ARGV[4] = TTL（秒）
+var recordAccountOutcomeScript = redis.NewScript(`
+	local key = KEYS[1]
+	local ttft = tonumber(ARGV[1])
+	local failed = tonumber(ARGV[2])
+	local alpha = tonumber(ARGV[3])
+
+	local cur = redis.call('HMGET', key, 'ttft', 'err', 'n')
+	local errRate = tonumber(cur[2]) or 0
+	errRate = errRate + alpha * (failed - errRate)
+
+	if ttft > 0 then
+		local latency = tonumber(cur[1])
+		if latency == nil or latency <= 0 then
+			latency = ttft
+		else
+			latency = latency + alpha * (ttft - latency)
+		end
+		redis.call('HSET', key, 'ttft', tostring(latency))
+	end
+
+	redis.call('HSET', key, 'err', tostring(errRate), 'n', (tonumber(cur[3]) or 0) + 1)
+	redis.call('EXPIRE', key, tonumber(ARGV[4]))
+	return 1
+`)
+
+type accountLatencyStatsCache struct {
+	rdb *redis.Client
+}
+
+// NewAccountLatencyStatsCache 创建账号 EWMA 统计缓存
+func NewAccountLatencyStatsCache(rdb *redis.Client) service.AccountLatencyStatsCache {
+	return &accountLatencyStatsCache{rdb: rdb}
+}
+
+func accountLatencyStatsKey(accountID int64) string {
+	return accountLatencyStatsKeyPrefix + strconv.FormatInt(accountID, 10)
+}
+
+func (c *accountLatencyStatsCache) RecordAccountOutcome(ctx context.Context, accountID int64, ttftMs int64, failed bool, alpha float64) error {
+	failedFlag := 0
+	if failed {
+		failedFlag = 1
+	}
+	return recordAccountOutcomeScript.Run(ctx, c.rdb, []string{accountLatencyStatsKey(accountID)},
+		ttftMs, failedFlag, strconv.FormatFloat(alpha, 'f', -1, 64), accountLatencyStatsTTLSeconds).Err()
+}
+
+func (c *accountLatencyStatsCache) GetAccountLatencyStatsBatch(ctx context.Context, accountIDs []int64) (map[int64]service.AccountLatencyStats, error) {
+	result := make(map[int64]service.AccountLatencyStats, len(accountIDs))
+	if len(accountIDs) == 0 {
+		return result, nil
+	}
+
+	pipe := c.rdb.Pipeline()
+	cmds := make([]*redis.SliceCmd, 0, len(accountIDs))
+	for _, id := range accountIDs {
+		cmds = append(cmds, pipe.HMGet(ctx, accountLatencyStatsKey(id), "ttft", "err", "n"))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	for i, cmd := range cmds {
+		values, err := cmd.Result()
+		if err != nil || len(values) != 3 || values[2] == nil {
+			continue
+		}
+		result[accountIDs[i]] = service.AccountLatencyStats{
+			TTFTMs:    parseRedisFloat(values[0]),
+			ErrorRate: parseRedisFloat(values[1]),
+			Samples:   int64(parseRedisFloat(values[2])),
+		}
+	}
+	return result, nil
+}
+
+func parseRedisFloat(v any) float64 {
+	s, ok := v.(string)
+	if !ok {
+		return 0
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return f
+}
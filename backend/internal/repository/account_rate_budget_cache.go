@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/redis/go-redis/v9"
+)
+
+// 账号 RPM/TPM 预算计数
+//
+// 设计说明：
+// 按分钟分桶，每个桶为 Hash：
+// - Key: rate_budget:account:{accountID}:{unixMinute}
+// - Field r: 请求数；Field t: token 数
+//
+// 读取时用滑动窗口近似：当前分钟 + 上一分钟 ×（本分钟剩余比例），避免分钟边界突发。
+// 分桶键与权重由调用方按本机时间计算后传入脚本，脚本内不拼接键名。
+const (
+	// 预算计数键前缀
+	// 格式: rate_budget:account:{accountID}:{unixMinute}
+	rateBudgetKeyPrefix = "rate_budget:account:"
+
+	// 分桶 TTL（秒），覆盖当前与上一分钟
+	rateBudgetBucketTTLSeconds = 180
+)
+
+var (
+	// incrRateBudgetTokensScript 原子累加当前分钟桶的 token 数
+	// KEYS[1] = 当前分钟桶
+	// ARGV[1] = token 增量；ARGV[2] = TTL（秒）
+	incrRateBudgetTokensScript = redis.NewScript(`
+		redis.call('HINCRBY', KEYS[1], 't', tonumber(ARGV[1]))
+		redis.call('EXPIRE', KEYS[1], tonumber(ARGV[2]))
+		return 1
+	`)
+
+	// reserveRateBudgetScript 原子检查滑动窗口用量并预占一次请求
+	// KEYS[1] = 当前分钟桶；KEYS[2] = 上一分钟桶
+	// ARGV[1] = 上一分钟权重；ARGV[2] = RPM 上限（0 表示不限）；ARGV[3] = TPM 上限（0 表示不限）；ARGV[4] = TTL（秒）
+	// 返回: 1 = 已预占，0 = 预算已满
+	reserveRateBudgetScript = redis.NewScript(`
+		local weight = tonumber(ARGV[1])
+		local cur = redis.call('HMGET', KEYS[1], 'r', 't')
+		local prev = redis.call('HMGET', KEYS[2], 'r', 't')
+		local requests = math.ceil((tonumber(cur[1]) or 0) + (tonumber(prev[1]) or 0) * weight)
+		local tokens = math.ceil((tonumber(cur[2]) or 0) + (tonumber(prev[2]) or 0) * weight)
+
+		local rpmLimit = tonumber(ARGV[2])
+		local tpmLimit = tonumber(ARGV[3])
+		if rpmLimit > 0 and requests >= rpmLimit then
+			return 0
+		end
+		if tpmLimit > 0 and tokens >= tpmLimit then
+			return 0
+		end
+
+		redis.call('HINCRBY', KEYS[1], 'r', 1)
+		redis.call('EXPIRE', KEYS[1], tonumber(ARGV[4]))
+		return 1
+	`)
+
+	// getRateBudgetBatchScript 批量读取滑动 1 分钟用量
+	// KEYS[2i-1] = 账号 i 当前分钟桶；KEYS[2i] = 账号 i 上一分钟桶
+	// ARGV[1] = 上一分钟权重
+	// 返回: [requests1, tokens1, requests2, tokens2, ...]（向上取整，偏保守）
+	getRateBudgetBatchScript = redis.NewScript(`
+		local weight = tonumber(ARGV[1])
+		local result = {}
+		for i = 1, #KEYS, 2 do
+			local cur = redis.call('HMGET', KEYS[i], 'r', 't')
+			local prev = redis.call('HMGET', KEYS[i + 1], 'r', 't')
+			local requests = (tonumber(cur[1]) or 0) + (tonumber(prev[1]) or 0) * weight
+			local tokens = (tonumber(cur[2]) or 0) + (tonumber(prev[2]) or 0) * weight
+			table.insert(result, math.ceil(requests))
+			table.insert(result, math.ceil(tokens))
+		end
+		return result
+	`)
+)
+
+type accountRateBudgetCache struct {
+	rdb *redis.Client
+	now func() time.Time
+}
+
+// NewAccountRateBudgetCache 创建账号 RPM/TPM 预算计数缓存
+func NewAccountRateBudgetCache(rdb *redis.Client) service.AccountRateBudgetCache {
+	return &accountRateBudgetCache{rdb: rdb, now: time.Now}
+}
+
+func rateBudgetBucketKey(accountID, minute int64) string {
+	return rateBudgetKeyPrefix + strconv.FormatInt(accountID, 10) + ":" + strconv.FormatInt(minute, 10)
+}
+
+// rateBudgetWindow 返回当前分钟序号与上一分钟桶的权重（本分钟剩余比例）
+func rateBudgetWindow(now time.Time) (minute int64, weight string) {
+	seconds := float64(now.UnixNano()) / float64(time.Second)
+	minute = now.Unix() / 60
+	w := 1 - (seconds-float64(minute*60))/60
+	return minute, strconv.FormatFloat(w, 'f', 6, 64)
+}
+
+func (c *accountRateBudgetCache) IncrAccountRateTokens(ctx context.Context, accountID int64, tokens int64) error {
+	if tokens <= 0 {
+		return nil
+	}
+	minute, _ := rateBudgetWindow(c.now())
+	return incrRateBudgetTokensScript.Run(ctx, c.rdb, []string{rateBudgetBucketKey(accountID, minute)}, tokens, rateBudgetBucketTTLSeconds).Err()
+}
+
+func (c *accountRateBudgetCache) ReserveAccountRequest(ctx context.Context, accountID int64, rpmLimit, tpmLimit int64) (bool, error) {
+	minute, weight := rateBudgetWindow(c.now())
+	keys := []string{rateBudgetBucketKey(accountID, minute), rateBudgetBucketKey(accountID, minute-1)}
+	reserved, err := reserveRateBudgetScript.Run(ctx, c.rdb, keys, weight, rpmLimit, tpmLimit, rateBudgetBucketTTLSeconds).Int()
+	if err != nil {
+		return false, err
+	}
+	return reserved == 1, nil
+}
+
+func (c *accountRateBudgetCache) GetAccountRateUsageBatch(ctx context.Context, accountIDs []int64) (map[int64]service.AccountRateUsage, error) {
+	result := make(map[int64]service.AccountRateUsage, len(accountIDs))
+	if len(accountIDs) == 0 {
+		return result, nil
+	}
+	minute, weight := rateBudgetWindow(c.now())
+	keys := make([]string, 0, 2*len(accountIDs))
+	for _, id := range accountIDs {
+		keys = append(keys, rateBudgetBucketKey(id, minute), rateBudgetBucketKey(id, minute-1))
+	}
+	values, err := getRateBudgetBatchScript.Run(ctx, c.rdb, keys, weight).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+	for i, id := range accountIDs {
+		if 2*i+1 >= len(values) {
+			break
+		}
+		result[id] = service.AccountRateUsage{Requests: values[2*i], Tokens: values[2*i+1]}
+	}
+	return result, nil
+}
@@ -0,0 +1,65 @@
+//go:build integration
+
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type AccountRateBudgetCacheSuite struct {
+	IntegrationRedisSuite
+	cache *accountRateBudgetCache
+	now   time.Time
+}
+
+func (s *AccountRateBudgetCacheSuite) SetupTest() {
+	s.IntegrationRedisSuite.SetupTest()
+	// 固定在分钟起点，上一分钟权重为 1
+	s.now = time.Unix(1_700_000_040, 0)
+	s.cache = &accountRateBudgetCache{rdb: s.rdb, now: func() time.Time { return s.now }}
+}
+
+func (s *AccountRateBudgetCacheSuite) TestReserveAccountRequest_StopsAtLimit() {
+	accountID := int64(7)
+	for i := 0; i < 3; i++ {
+		ok, err := s.cache.ReserveAccountRequest(s.ctx, accountID, 3, 0)
+		require.NoError(s.T(), err)
+		require.True(s.T(), ok, "reserve %d", i+1)
+	}
+	ok, err := s.cache.ReserveAccountRequest(s.ctx, accountID, 3, 0)
+	require.NoError(s.T(), err)
+	require.False(s.T(), ok, "rpm limit reached")
+
+	usage, err := s.cache.GetAccountRateUsageBatch(s.ctx, []int64{accountID})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), int64(3), usage[accountID].Requests, "rejected reservations are not counted")
+
+	ttl, err := s.rdb.TTL(s.ctx, rateBudgetBucketKey(accountID, s.now.Unix()/60)).Result()
+	require.NoError(s.T(), err)
+	require.Greater(s.T(), ttl, time.Duration(0))
+}
+
+func (s *AccountRateBudgetCacheSuite) TestReserveAccountRequest_SlidingWindowAndTokens() {
+	accountID := int64(8)
+	require.NoError(s.T(), s.cache.IncrAccountRateTokens(s.ctx, accountID, 500))
+	ok, err := s.cache.ReserveAccountRequest(s.ctx, accountID, 0, 500)
+	require.NoError(s.T(), err)
+	require.False(s.T(), ok, "tpm limit reached")
+
+	// 下一分钟过半：上一分钟用量按一半计入
+	s.now = s.now.Add(90 * time.Second)
+	usage, err := s.cache.GetAccountRateUsageBatch(s.ctx, []int64{accountID})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), int64(250), usage[accountID].Tokens)
+	ok, err = s.cache.ReserveAccountRequest(s.ctx, accountID, 0, 500)
+	require.NoError(s.T(), err)
+	require.True(s.T(), ok)
+}
+
+func TestAccountRateBudgetCacheSuite(t *testing.T) {
+	suite.Run(t, new(AccountRateBudgetCacheSuite))
+}
@@ -84,7 +84,8 @@ func (r *accountRepository) Create(ctx context.Context, account *service.Account
 		SetStatus(account.Status).
 		SetErrorMessage(account.ErrorMessage).
 		SetSchedulable(account.Schedulable).
-		SetAutoPauseOnExpired(account.AutoPauseOnExpired)
+		SetAutoPauseOnExpired(account.AutoPauseOnExpired).
+		SetTags(pq.StringArray(nonNilStrings(account.Tags)))
 
 	if account.RateMultiplier != nil {
 		builder.SetRateMultiplier(*account.RateMultiplier)
@@ -117,6 +118,14 @@ func (r *accountRepository) Create(ctx context.Context, account *service.Account
 	if account.SessionWindowStatus != "" {
 		builder.SetSessionWindowStatus(account.SessionWindowStatus)
 	}
+	availability, nextTransition, err := encodeAccountAvailability(account, time.Now())
+	if err != nil {
+		return err
+	}
+	if availability != nil {
+		builder.SetAvailabilitySchedule(availability)
+	}
+	builder.SetNillableAvailabilityNextTransitionAt(nextTransition)
 
 	created, err := builder.Save(ctx)
 	if err != nil {
@@ -126,7 +135,7 @@ func (r *accountRepository) Create(ctx context.Context, account *service.Account
 	account.ID = created.ID
 	account.CreatedAt = created.CreatedAt
 	account.UpdatedAt = created.UpdatedAt
-	if err := enqueueSchedulerOutbox(ctx, r.sql, service.SchedulerOutboxEventAccountChanged, &account.ID, nil, buildSchedulerGroupPayload(account.GroupIDs)); err != nil {
+	if err := enqueueSchedulerOutbox(ctx, r.sql, service.SchedulerOutboxEventAccountChanged, &account.ID, nil, buildSchedulerGroupPayload(r.schedulerGroupIDs(ctx, account))); err != nil {
 		log.Printf("[SchedulerOutbox] enqueue account create failed: account=%d err=%v", account.ID, err)
 	}
 	return nil
@@ -198,6 +207,10 @@ func (r *accountRepository) GetByIDs(ctx context.Context, ids []int64) ([]*servi
 	if err != nil {
 		return nil, err
 	}
+	tagGroups, err := r.loadTagSelectorGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	outByID := make(map[int64]*service.Account, len(entAccounts))
 	for _, entAcc := range entAccounts {
@@ -224,6 +237,7 @@ func (r *accountRepository) GetByIDs(ctx context.Context, ids []int64) ([]*servi
 			out.TempUnschedulableUntil = snap.until
 			out.TempUnschedulableReason = snap.reason
 		}
+		out.TagGroupIDs = tagGroups.matchGroupIDs(out)
 		outByID[entAcc.ID] = out
 	}
 
@@ -299,7 +313,8 @@ func (r *accountRepository) Update(ctx context.Context, account *service.Account
 		SetStatus(account.Status).
 		SetErrorMessage(account.ErrorMessage).
 		SetSchedulable(account.Schedulable).
-		SetAutoPauseOnExpired(account.AutoPauseOnExpired)
+		SetAutoPauseOnExpired(account.AutoPauseOnExpired).
+		SetTags(pq.StringArray(nonNilStrings(account.Tags)))
 
 	if account.RateMultiplier != nil {
 		builder.SetRateMultiplier(*account.RateMultiplier)
@@ -353,13 +368,27 @@ func (r *accountRepository) Update(ctx context.Context, account *service.Account
 	if account.Notes == nil {
 		builder.ClearNotes()
 	}
+	availability, nextTransition, err := encodeAccountAvailability(account, time.Now())
+	if err != nil {
+		return err
+	}
+	if availability != nil {
+		builder.SetAvailabilitySchedule(availability)
+	} else {
+		builder.ClearAvailabilitySchedule()
+	}
+	if nextTransition != nil {
+		builder.SetAvailabilityNextTransitionAt(*nextTransition)
+	} else {
+		builder.ClearAvailabilityNextTransitionAt()
+	}
 
 	updated, err := builder.Save(ctx)
 	if err != nil {
 		return translatePersistenceError(err, service.ErrAccountNotFound, nil)
 	}
 	account.UpdatedAt = updated.UpdatedAt
-	if err := enqueueSchedulerOutbox(ctx, r.sql, service.SchedulerOutboxEventAccountChanged, &account.ID, nil, buildSchedulerGroupPayload(account.GroupIDs)); err != nil {
+	if err := enqueueSchedulerOutbox(ctx, r.sql, service.SchedulerOutboxEventAccountChanged, &account.ID, nil, buildSchedulerGroupPayload(r.schedulerGroupIDs(ctx, account))); err != nil {
 		log.Printf("[SchedulerOutbox] enqueue account update failed: account=%d err=%v", account.ID, err)
 	}
 	if account.Status == service.StatusError || account.Status == service.StatusDisabled || !account.Schedulable {
@@ -394,6 +423,10 @@ func (r *accountRepository) Delete(ctx context.Context, id int64) error {
 	if _, err := txClient.Account.Delete().Where(dbaccount.IDEQ(id)).Exec(ctx); err != nil {
 		return err
 	}
+	// 上游文件只存在于该账号中，一并清理 Files API 归属映射
+	if _, err := txClient.ExecContext(ctx, "DELETE FROM gateway_files WHERE account_id = $1", id); err != nil {
+		return err
+	}
 
 	if tx != nil {
 		if err := tx.Commit(); err != nil {
@@ -1140,6 +1173,12 @@ func (r *accountRepository) BulkUpdate(ctx context.Context, ids []int64, updates
 		args = append(args, *updates.Schedulable)
 		idx++
 	}
+	// 标签追加/移除后保持去重有序
+	if len(updates.AddTags) > 0 || len(updates.RemoveTags) > 0 {
+		setClauses = append(setClauses, "tags = ARRAY(SELECT DISTINCT t FROM unnest(tags || $"+itoa(idx)+"::text[]) AS t WHERE t <> ALL($"+itoa(idx+1)+"::text[]) ORDER BY t)")
+		args = append(args, pq.Array(nonNilStrings(updates.AddTags)), pq.Array(nonNilStrings(updates.RemoveTags)))
+		idx += 2
+	}
 	// JSONB 需要合并而非覆盖，使用 raw SQL 保持旧行为。
 	if len(updates.Credentials) > 0 {
 		payload, err := json.Marshal(updates.Credentials)
@@ -1182,6 +1221,9 @@ func (r *accountRepository) BulkUpdate(ctx context.Context, ids []int64, updates
 		if err := enqueueSchedulerOutbox(ctx, r.sql, service.SchedulerOutboxEventAccountBulkChanged, nil, nil, payload); err != nil {
 			log.Printf("[SchedulerOutbox] enqueue bulk update failed: err=%v", err)
 		}
+		if len(updates.AddTags) > 0 || len(updates.RemoveTags) > 0 {
+			r.enqueueTagSelectorGroupsChanged(ctx)
+		}
 		shouldSync := false
 		if updates.Status != nil && (*updates.Status == service.StatusError || *updates.Status == service.StatusDisabled) {
 			shouldSync = true
@@ -1263,6 +1305,13 @@ func (r *accountRepository) queryAccountsByGroup(ctx context.Context, groupID in
 		}
 	}
 
+	// 标签选择器自动纳入的账号排在显式绑定之后，按账号优先级排序
+	tagged, err := r.queryTagMatchedAccounts(ctx, groupID, preds)
+	if err != nil {
+		return nil, err
+	}
+	accounts = append(accounts, tagged...)
+
 	return r.accountsToService(ctx, accounts)
 }
 
@@ -1292,6 +1341,10 @@ func (r *accountRepository) accountsToService(ctx context.Context, accounts []*d
 	if err != nil {
 		return nil, err
 	}
+	tagGroups, err := r.loadTagSelectorGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	outAccounts := make([]service.Account, 0, len(accounts))
 	for _, acc := range accounts {
@@ -1317,6 +1370,7 @@ func (r *accountRepository) accountsToService(ctx context.Context, accounts []*d
 			out.TempUnschedulableUntil = snap.until
 			out.TempUnschedulableReason = snap.reason
 		}
+		out.TagGroupIDs = tagGroups.matchGroupIDs(out)
 		outAccounts = append(outAccounts, *out)
 	}
 
@@ -1493,32 +1547,41 @@ func accountEntityToService(m *dbent.Account) *service.Account {
 	rateMultiplier := m.RateMultiplier
 
 	return &service.Account{
-		ID:                  m.ID,
-		Name:                m.Name,
-		Notes:               m.Notes,
-		Platform:            m.Platform,
-		Type:                m.Type,
-		Credentials:         copyJSONMap(m.Credentials),
-		Extra:               copyJSONMap(m.Extra),
-		ProxyID:             m.ProxyID,
-		Concurrency:         m.Concurrency,
-		Priority:            m.Priority,
-		RateMultiplier:      &rateMultiplier,
-		Status:              m.Status,
-		ErrorMessage:        derefString(m.ErrorMessage),
-		LastUsedAt:          m.LastUsedAt,
-		ExpiresAt:           m.ExpiresAt,
-		AutoPauseOnExpired:  m.AutoPauseOnExpired,
-		CreatedAt:           m.CreatedAt,
-		UpdatedAt:           m.UpdatedAt,
-		Schedulable:         m.Schedulable,
-		RateLimitedAt:       m.RateLimitedAt,
-		RateLimitResetAt:    m.RateLimitResetAt,
-		OverloadUntil:       m.OverloadUntil,
-		SessionWindowStart:  m.SessionWindowStart,
-		SessionWindowEnd:    m.SessionWindowEnd,
-		SessionWindowStatus: derefString(m.SessionWindowStatus),
+		ID:                   m.ID,
+		Name:                 m.Name,
+		Notes:                m.Notes,
+		Platform:             m.Platform,
+		Type:                 m.Type,
+		Credentials:          copyJSONMap(m.Credentials),
+		Extra:                copyJSONMap(m.Extra),
+		ProxyID:              m.ProxyID,
+		Concurrency:          m.Concurrency,
+		Priority:             m.Priority,
+		RateMultiplier:       &rateMultiplier,
+		Status:               m.Status,
+		ErrorMessage:         derefString(m.ErrorMessage),
+		LastUsedAt:           m.LastUsedAt,
+		ExpiresAt:            m.ExpiresAt,
+		AutoPauseOnExpired:   m.AutoPauseOnExpired,
+		CreatedAt:            m.CreatedAt,
+		UpdatedAt:            m.UpdatedAt,
+		Schedulable:          m.Schedulable,
+		RateLimitedAt:        m.RateLimitedAt,
+		RateLimitResetAt:     m.RateLimitResetAt,
+		OverloadUntil:        m.OverloadUntil,
+		SessionWindowStart:   m.SessionWindowStart,
+		SessionWindowEnd:     m.SessionWindowEnd,
+		SessionWindowStatus:  derefString(m.SessionWindowStatus),
+		Tags:                 emptyStringsToNil(m.Tags),
+		AvailabilitySchedule: decodeAvailabilitySchedule(m.AvailabilitySchedule),
+	}
+}
+
+func emptyStringsToNil(items []string) []string {
+	if len(items) == 0 {
+		return nil
 	}
+	return items
 }
 
 func normalizeJSONMap(in map[string]any) map[string]any {
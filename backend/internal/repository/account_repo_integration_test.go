@@ -132,6 +132,41 @@ func (s *AccountRepoSuite) TestUpdate_SyncSchedulerSnapshotOnDisabled() {
 	s.Require().Equal(service.StatusDisabled, cacheRecorder.setAccounts[0].Status)
 }
 
+func (s *AccountRepoSuite) TestAvailabilitySchedule_RoundTripAndAdvance() {
+	account := mustCreateAccount(s.T(), s.client, &service.Account{Name: "windowed"})
+	account.AvailabilitySchedule = &service.AvailabilitySchedule{
+		Timezone: "UTC",
+		Windows:  []service.AvailabilityWindow{{Start: "09:00", End: "18:00"}},
+	}
+	s.Require().NoError(s.repo.Update(s.ctx, account))
+
+	got, err := s.repo.GetByID(s.ctx, account.ID)
+	s.Require().NoError(err)
+	s.Require().Equal(account.AvailabilitySchedule, got.AvailabilitySchedule)
+
+	stored, err := s.client.Account.Get(s.ctx, account.ID)
+	s.Require().NoError(err)
+	s.Require().NotNil(stored.AvailabilityNextTransitionAt)
+
+	// 到期后推进到下一次切换时间
+	due := stored.AvailabilityNextTransitionAt.Add(time.Second)
+	advanced, err := s.repo.AdvanceAvailabilityTransitions(s.ctx, due)
+	s.Require().NoError(err)
+	s.Require().Equal(int64(1), advanced)
+	stored, err = s.client.Account.Get(s.ctx, account.ID)
+	s.Require().NoError(err)
+	s.Require().NotNil(stored.AvailabilityNextTransitionAt)
+	s.Require().True(stored.AvailabilityNextTransitionAt.After(due))
+
+	// 清除时间表后始终可用
+	account.AvailabilitySchedule = nil
+	s.Require().NoError(s.repo.Update(s.ctx, account))
+	stored, err = s.client.Account.Get(s.ctx, account.ID)
+	s.Require().NoError(err)
+	s.Require().Nil(stored.AvailabilitySchedule)
+	s.Require().Nil(stored.AvailabilityNextTransitionAt)
+}
+
 func (s *AccountRepoSuite) TestDelete() {
 	account := mustCreateAccount(s.T(), s.client, &service.Account{Name: "to-delete"})
 
@@ -264,6 +299,32 @@ func (s *AccountRepoSuite) TestListByGroup() {
 	s.Require().Equal(acc2.ID, accounts[0].ID, "expected acc2 first (priority=1)")
 }
 
+func (s *AccountRepoSuite) TestListByGroup_TagSelector() {
+	group := mustCreateGroup(s.T(), s.client, &service.Group{Name: "g-tags", AccountTagSelector: "tier:max,!region:eu"})
+	bound := mustCreateAccount(s.T(), s.client, &service.Account{Name: "bound", Priority: 5, Tags: []string{"tier:max"}})
+	mustBindAccountToGroup(s.T(), s.client, bound.ID, group.ID, 5)
+	matched := mustCreateAccount(s.T(), s.client, &service.Account{Name: "matched", Priority: 1, Tags: []string{"region:us", "tier:max"}})
+	mustCreateAccount(s.T(), s.client, &service.Account{Name: "excluded", Tags: []string{"region:eu", "tier:max"}})
+	mustCreateAccount(s.T(), s.client, &service.Account{Name: "untagged"})
+
+	accounts, err := s.repo.ListByGroup(s.ctx, group.ID)
+	s.Require().NoError(err, "ListByGroup")
+	s.Require().Len(accounts, 2)
+	// 显式绑定的账号在前，标签匹配的账号在后且不重复
+	s.Require().Equal(bound.ID, accounts[0].ID)
+	s.Require().Equal(matched.ID, accounts[1].ID)
+	s.Require().Equal([]string{"region:us", "tier:max"}, accounts[1].Tags)
+	s.Require().Equal([]int64{group.ID}, accounts[1].TagGroupIDs)
+
+	// 标签通过 Update 写回
+	matched.Tags = []string{"region:eu", "tier:max"}
+	s.Require().NoError(s.repo.Update(s.ctx, matched))
+	accounts, err = s.repo.ListByGroup(s.ctx, group.ID)
+	s.Require().NoError(err)
+	s.Require().Len(accounts, 1)
+	s.Require().Equal(bound.ID, accounts[0].ID)
+}
+
 func (s *AccountRepoSuite) TestListActive() {
 	mustCreateAccount(s.T(), s.client, &service.Account{Name: "active1", Status: service.StatusActive})
 	mustCreateAccount(s.T(), s.client, &service.Account{Name: "inactive1", Status: service.StatusDisabled})
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"log"
+
+	dbent "github.com/Wei-Shaw/sub2api/ent"
+	dbaccount "github.com/Wei-Shaw/sub2api/ent/account"
+	dbaccountgroup "github.com/Wei-Shaw/sub2api/ent/accountgroup"
+	dbgroup "github.com/Wei-Shaw/sub2api/ent/group"
+	dbpredicate "github.com/Wei-Shaw/sub2api/ent/predicate"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	entsql "entgo.io/ent/dialect/sql"
+)
+
+// 账号标签（accounts.tags）与分组标签选择器（groups.account_tag_selector）：
+// 分组成员 = 显式绑定 ∪ 标签匹配的账号。
+
+type tagSelectorGroup struct {
+	id       int64
+	selector service.TagSelector
+}
+
+// tagSelectorGroups 配置了标签选择器的分组
+type tagSelectorGroups []tagSelectorGroup
+
+// matchGroupIDs 返回账号通过标签匹配到的分组（不含已显式绑定的分组）
+func (g tagSelectorGroups) matchGroupIDs(account *service.Account) []int64 {
+	if len(g) == 0 || account == nil || len(account.Tags) == 0 {
+		return nil
+	}
+	var out []int64
+	for _, grp := range g {
+		if containsInt64(account.GroupIDs, grp.id) || !grp.selector.Matches(account.Tags) {
+			continue
+		}
+		out = append(out, grp.id)
+	}
+	return out
+}
+
+// loadTagSelectorGroups 加载配置了账号标签选择器的分组（无效表达式忽略）
+func (r *accountRepository) loadTagSelectorGroups(ctx context.Context) (tagSelectorGroups, error) {
+	groups, err := r.client.Group.Query().
+		Where(dbgroup.AccountTagSelectorNEQ("")).
+		Select(dbgroup.FieldID, dbgroup.FieldAccountTagSelector).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var out tagSelectorGroups
+	for _, g := range groups {
+		selector, err := service.ParseTagSelector(g.AccountTagSelector)
+		if err != nil {
+			log.Printf("[AccountTags] ignore invalid selector: group=%d selector=%q err=%v", g.ID, g.AccountTagSelector, err)
+			continue
+		}
+		if selector.IsEmpty() {
+			continue
+		}
+		out = append(out, tagSelectorGroup{id: g.ID, selector: selector})
+	}
+	return out, nil
+}
+
+// loadGroupTagSelector 读取单个分组的账号标签选择器，未配置时返回空选择器
+func (r *accountRepository) loadGroupTagSelector(ctx context.Context, groupID int64) (service.TagSelector, error) {
+	g, err := r.client.Group.Query().
+		Where(dbgroup.IDEQ(groupID)).
+		Select(dbgroup.FieldAccountTagSelector).
+		Only(ctx)
+	if err != nil {
+		if dbent.IsNotFound(err) {
+			return service.TagSelector{}, nil
+		}
+		return service.TagSelector{}, err
+	}
+	return service.ParseTagSelector(g.AccountTagSelector)
+}
+
+// queryTagMatchedAccounts 返回满足分组标签选择器、但未显式绑定到该分组的账号，按账号优先级排序
+func (r *accountRepository) queryTagMatchedAccounts(ctx context.Context, groupID int64, preds []dbpredicate.Account) ([]*dbent.Account, error) {
+	selector, err := r.loadGroupTagSelector(ctx, groupID)
+	if err != nil || selector.IsEmpty() {
+		return nil, err
+	}
+	candidates, err := r.client.Account.Query().
+		Where(append([]dbpredicate.Account{
+			accountHasTags(),
+			dbaccount.Not(dbaccount.HasAccountGroupsWith(dbaccountgroup.GroupIDEQ(groupID))),
+		}, preds...)...).
+		Order(dbent.Asc(dbaccount.FieldPriority), dbent.Asc(dbaccount.FieldID)).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	matched := candidates[:0]
+	for _, acc := range candidates {
+		if selector.Matches(acc.Tags) {
+			matched = append(matched, acc)
+		}
+	}
+	return matched, nil
+}
+
+// accountHasTags 只匹配打了标签的账号
+func accountHasTags() dbpredicate.Account {
+	return dbpredicate.Account(func(s *entsql.Selector) {
+		s.Where(entsql.ExprP("cardinality(" + s.C(dbaccount.FieldTags) + ") > 0"))
+	})
+}
+
+// schedulerGroupIDs 账号变更时需要重建快照的分组：显式绑定、变更前后标签匹配的分组
+func (r *accountRepository) schedulerGroupIDs(ctx context.Context, account *service.Account) []int64 {
+	groupIDs := mergeGroupIDs(account.GroupIDs, account.TagGroupIDs)
+	if len(account.Tags) == 0 {
+		return groupIDs
+	}
+	tagGroups, err := r.loadTagSelectorGroups(ctx)
+	if err != nil {
+		log.Printf("[AccountTags] load tag selector groups failed: account=%d err=%v", account.ID, err)
+		return groupIDs
+	}
+	return mergeGroupIDs(groupIDs, tagGroups.matchGroupIDs(account))
+}
+
+func containsInt64(items []int64, needle int64) bool {
+	for _, item := range items {
+		if item == needle {
+			return true
+		}
+	}
+	return false
+}
+
+// enqueueTagSelectorGroupsChanged 批量修改标签后，重建所有配置了标签选择器的分组快照
+func (r *accountRepository) enqueueTagSelectorGroupsChanged(ctx context.Context) {
+	tagGroups, err := r.loadTagSelectorGroups(ctx)
+	if err != nil {
+		log.Printf("[AccountTags] load tag selector groups failed: err=%v", err)
+		return
+	}
+	for _, grp := range tagGroups {
+		groupID := grp.id
+		if err := enqueueSchedulerOutbox(ctx, r.sql, service.SchedulerOutboxEventGroupChanged, nil, &groupID, nil); err != nil {
+			log.Printf("[SchedulerOutbox] enqueue tag group change failed: group=%d err=%v", groupID, err)
+		}
+	}
+}
+
+func nonNilStrings(items []string) []string {
+	if items == nil {
+		return []string{}
+	}
+	return items
+}
@@ -42,6 +42,22 @@ func (r *apiKeyRepository) Create(ctx context.Context, key *service.APIKey) erro
 		builder.SetIPBlacklist(key.IPBlacklist)
 	}
 
+	// 限额字段
+	l := key.Limits
+	builder.
+		SetNillableExpiresAt(l.ExpiresAt).
+		SetNillableTotalLimitUsd(l.TotalLimitUSD).
+		SetNillableDailyLimitUsd(l.DailyLimitUSD).
+		SetNillableMonthlyLimitUsd(l.MonthlyLimitUSD).
+		SetNillableDailyRequestLimit(l.DailyRequestLimit).
+		SetNillableMonthlyRequestLimit(l.MonthlyRequestLimit)
+	if len(l.AllowedModels) > 0 {
+		builder.SetAllowedModels(l.AllowedModels)
+	}
+	if len(l.AllowedPlatforms) > 0 {
+		builder.SetAllowedPlatforms(l.AllowedPlatforms)
+	}
+
 	created, err := builder.Save(ctx)
 	if err == nil {
 		key.ID = created.ID
@@ -110,6 +126,14 @@ func (r *apiKeyRepository) GetByKeyForAuth(ctx context.Context, key string) (*se
 			apikey.FieldStatus,
 			apikey.FieldIPWhitelist,
 			apikey.FieldIPBlacklist,
+			apikey.FieldExpiresAt,
+			apikey.FieldTotalLimitUsd,
+			apikey.FieldDailyLimitUsd,
+			apikey.FieldMonthlyLimitUsd,
+			apikey.FieldDailyRequestLimit,
+			apikey.FieldMonthlyRequestLimit,
+			apikey.FieldAllowedModels,
+			apikey.FieldAllowedPlatforms,
 		).
 		WithUser(func(q *dbent.UserQuery) {
 			q.Select(
@@ -138,6 +162,12 @@ func (r *apiKeyRepository) GetByKeyForAuth(ctx context.Context, key string) (*se
 				group.FieldFallbackGroupID,
 				group.FieldModelRoutingEnabled,
 				group.FieldModelRouting,
+				group.FieldModelMapping,
+				group.FieldStickyPolicy,
+				group.FieldStickyTTLSeconds,
+				group.FieldAccountTagSelector,
+				group.FieldModelRoutingTags,
+				group.FieldCostRoutingPolicy,
 			)
 		}).
 		Only(ctx)
@@ -180,6 +210,49 @@ func (r *apiKeyRepository) Update(ctx context.Context, key *service.APIKey) erro
 		builder.ClearIPBlacklist()
 	}
 
+	// 限额字段：nil 时清除
+	l := key.Limits
+	if l.ExpiresAt != nil {
+		builder.SetExpiresAt(*l.ExpiresAt)
+	} else {
+		builder.ClearExpiresAt()
+	}
+	if l.TotalLimitUSD != nil {
+		builder.SetTotalLimitUsd(*l.TotalLimitUSD)
+	} else {
+		builder.ClearTotalLimitUsd()
+	}
+	if l.DailyLimitUSD != nil {
+		builder.SetDailyLimitUsd(*l.DailyLimitUSD)
+	} else {
+		builder.ClearDailyLimitUsd()
+	}
+	if l.MonthlyLimitUSD != nil {
+		builder.SetMonthlyLimitUsd(*l.MonthlyLimitUSD)
+	} else {
+		builder.ClearMonthlyLimitUsd()
+	}
+	if l.DailyRequestLimit != nil {
+		builder.SetDailyRequestLimit(*l.DailyRequestLimit)
+	} else {
+		builder.ClearDailyRequestLimit()
+	}
+	if l.MonthlyRequestLimit != nil {
+		builder.SetMonthlyRequestLimit(*l.MonthlyRequestLimit)
+	} else {
+		builder.ClearMonthlyRequestLimit()
+	}
+	if len(l.AllowedModels) > 0 {
+		builder.SetAllowedModels(l.AllowedModels)
+	} else {
+		builder.ClearAllowedModels()
+	}
+	if len(l.AllowedPlatforms) > 0 {
+		builder.SetAllowedPlatforms(l.AllowedPlatforms)
+	} else {
+		builder.ClearAllowedPlatforms()
+	}
+
 	affected, err := builder.Save(ctx)
 	if err != nil {
 		return err
@@ -372,6 +445,16 @@ func apiKeyEntityToService(m *dbent.APIKey) *service.APIKey {
 		CreatedAt:   m.CreatedAt,
 		UpdatedAt:   m.UpdatedAt,
 		GroupID:     m.GroupID,
+		Limits: service.APIKeyLimits{
+			ExpiresAt:           m.ExpiresAt,
+			TotalLimitUSD:       m.TotalLimitUsd,
+			DailyLimitUSD:       m.DailyLimitUsd,
+			MonthlyLimitUSD:     m.MonthlyLimitUsd,
+			DailyRequestLimit:   m.DailyRequestLimit,
+			MonthlyRequestLimit: m.MonthlyRequestLimit,
+			AllowedModels:       emptyStringsToNil(m.AllowedModels),
+			AllowedPlatforms:    emptyStringsToNil(m.AllowedPlatforms),
+		},
 	}
 	if m.Edges.User != nil {
 		out.User = userEntityToService(m.Edges.User)
@@ -429,11 +512,25 @@ func groupEntityToService(g *dbent.Group) *service.Group {
 		FallbackGroupID:     g.FallbackGroupID,
 		ModelRouting:        g.ModelRouting,
 		ModelRoutingEnabled: g.ModelRoutingEnabled,
+		ModelMapping:        emptyStringMapToNil(g.ModelMapping),
+		StickyPolicy:        g.StickyPolicy,
+		StickyTTLSeconds:    g.StickyTTLSeconds,
+		AccountTagSelector:  g.AccountTagSelector,
+		ModelRoutingTags:    emptyStringMapToNil(g.ModelRoutingTags),
+		CostRoutingPolicy:   g.CostRoutingPolicy,
 		CreatedAt:           g.CreatedAt,
 		UpdatedAt:           g.UpdatedAt,
 	}
 }
 
+// emptyStringMapToNil 未配置的映射统一返回 nil
+func emptyStringMapToNil(m map[string]string) map[string]string {
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
+
 func derefString(s *string) string {
 	if s == nil {
 		return ""
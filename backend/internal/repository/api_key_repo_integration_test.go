@@ -5,6 +5,7 @@ package repository
 import (
 	"context"
 	"testing"
+	"time"
 
 	dbent "github.com/Wei-Shaw/sub2api/ent"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
@@ -129,6 +130,46 @@ func (s *APIKeyRepoSuite) TestUpdate_ClearGroupID() {
 	s.Require().Nil(got.GroupID, "expected GroupID to be cleared")
 }
 
+func (s *APIKeyRepoSuite) TestCreateUpdate_Limits() {
+	user := s.mustCreateUser("limits@test.com")
+	expiresAt := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	total := 12.5
+	daily := int64(100)
+	key := &service.APIKey{
+		UserID: user.ID,
+		Key:    "sk-limits",
+		Name:   "Limited",
+		Status: service.StatusActive,
+		Limits: service.APIKeyLimits{
+			ExpiresAt:         &expiresAt,
+			TotalLimitUSD:     &total,
+			DailyRequestLimit: &daily,
+			AllowedModels:     []string{"claude-sonnet-*"},
+			AllowedPlatforms:  []string{service.PlatformAnthropic},
+		},
+	}
+	s.Require().NoError(s.repo.Create(s.ctx, key))
+
+	got, err := s.repo.GetByID(s.ctx, key.ID)
+	s.Require().NoError(err, "GetByID")
+	s.Require().NotNil(got.Limits.ExpiresAt)
+	s.Require().True(expiresAt.Equal(*got.Limits.ExpiresAt))
+	s.Require().NotNil(got.Limits.TotalLimitUSD)
+	s.Require().InDelta(12.5, *got.Limits.TotalLimitUSD, 1e-9)
+	s.Require().Nil(got.Limits.DailyLimitUSD)
+	s.Require().Equal(int64(100), *got.Limits.DailyRequestLimit)
+	s.Require().Equal([]string{"claude-sonnet-*"}, got.Limits.AllowedModels)
+	s.Require().Equal([]string{service.PlatformAnthropic}, got.Limits.AllowedPlatforms)
+
+	key.Limits = service.APIKeyLimits{}
+	s.Require().NoError(s.repo.Update(s.ctx, key))
+
+	keys, _, err := s.repo.ListByUserID(s.ctx, user.ID, pagination.PaginationParams{Page: 1, PageSize: 10})
+	s.Require().NoError(err, "ListByUserID")
+	s.Require().Len(keys, 1)
+	s.Require().True(keys[0].Limits.IsZero(), "expected limits to be cleared")
+}
+
 // --- Delete ---
 
 func (s *APIKeyRepoSuite) TestDelete() {
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type apiKeyRequestCountRepository struct {
+	sql sqlExecutor
+}
+
+func NewAPIKeyRequestCountRepository(sqlDB *sql.DB) service.APIKeyRequestCountRepository {
+	return newAPIKeyRequestCountRepositoryWithSQL(sqlDB)
+}
+
+func newAPIKeyRequestCountRepositoryWithSQL(sqlq sqlExecutor) *apiKeyRequestCountRepository {
+	return &apiKeyRequestCountRepository{sql: sqlq}
+}
+
+// IncrementRequests 在同一语句中原子累加当日与当月计数，并返回累加后的值
+func (r *apiKeyRequestCountRepository) IncrementRequests(ctx context.Context, apiKeyID int64, day, month string, n int64) (daily, monthly int64, err error) {
+	rows, err := r.sql.QueryContext(ctx, `
+		INSERT INTO api_key_request_counts (api_key_id, period, requests, updated_at)
+		VALUES ($1, $2, $4, NOW()), ($1, $3, $4, NOW())
+		ON CONFLICT (api_key_id, period) DO UPDATE
+		SET requests = api_key_request_counts.requests + EXCLUDED.requests,
+			updated_at = NOW()
+		RETURNING period, requests
+	`, apiKeyID, day, month, n)
+	if err != nil {
+		return 0, 0, err
+	}
+	return scanAPIKeyRequestCounts(rows, day, month)
+}
+
+// GetRequests 读取当日与当月已接纳请求数，无记录时为 0
+func (r *apiKeyRequestCountRepository) GetRequests(ctx context.Context, apiKeyID int64, day, month string) (daily, monthly int64, err error) {
+	rows, err := r.sql.QueryContext(ctx, `
+		SELECT period, requests
+		FROM api_key_request_counts
+		WHERE api_key_id = $1 AND period IN ($2, $3)
+	`, apiKeyID, day, month)
+	if err != nil {
+		return 0, 0, err
+	}
+	return scanAPIKeyRequestCounts(rows, day, month)
+}
+
+func scanAPIKeyRequestCounts(rows *sql.Rows, day, month string) (daily, monthly int64, err error) {
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var (
+			period   string
+			requests int64
+		)
+		if err := rows.Scan(&period, &requests); err != nil {
+			return 0, 0, err
+		}
+		switch period {
+		case day:
+			daily = requests
+		case month:
+			monthly = requests
+		}
+	}
+	return daily, monthly, rows.Err()
+}
@@ -0,0 +1,48 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyRequestCountRepository_IncrementAndGet(t *testing.T) {
+	ctx := context.Background()
+	tx := testEntTx(t)
+	client := tx.Client()
+	repo := newAPIKeyRequestCountRepositoryWithSQL(tx)
+
+	user := mustCreateUser(t, client, &service.User{Email: "request-count-" + time.Now().Format(time.RFC3339Nano) + "@example.com"})
+	key := mustCreateApiKey(t, client, &service.APIKey{UserID: user.ID, Key: "sk-request-count-" + time.Now().Format(time.RFC3339Nano), Name: "counted"})
+
+	daily, monthly, err := repo.GetRequests(ctx, key.ID, "2026-01-31", "2026-01")
+	require.NoError(t, err)
+	require.Zero(t, daily)
+	require.Zero(t, monthly)
+
+	daily, monthly, err = repo.IncrementRequests(ctx, key.ID, "2026-01-31", "2026-01", 1)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), daily)
+	require.Equal(t, int64(1), monthly)
+
+	daily, monthly, err = repo.IncrementRequests(ctx, key.ID, "2026-01-31", "2026-01", 2)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), daily)
+	require.Equal(t, int64(3), monthly)
+
+	// 跨日：当日计数重新开始，当月计数继续累加
+	daily, monthly, err = repo.IncrementRequests(ctx, key.ID, "2026-02-01", "2026-02", 1)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), daily)
+	require.Equal(t, int64(1), monthly)
+
+	daily, monthly, err = repo.GetRequests(ctx, key.ID, "2026-01-31", "2026-01")
+	require.NoError(t, err)
+	require.Equal(t, int64(3), daily)
+	require.Equal(t, int64(3), monthly)
+}
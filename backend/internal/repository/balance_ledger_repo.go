@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type balanceLedgerRepository struct {
+	db *sql.DB
+}
+
+func NewBalanceLedgerRepository(db *sql.DB) service.BalanceLedgerRepository {
+	return &balanceLedgerRepository{db: db}
+}
+
+func (r *balanceLedgerRepository) ListByUser(ctx context.Context, userID int64, params pagination.PaginationParams, filters service.BalanceLedgerFilters) ([]service.BalanceLedgerEntry, *pagination.PaginationResult, error) {
+	conditions := []string{"user_id = $1"}
+	args := []any{userID}
+	if filters.EntryType != "" {
+		args = append(args, filters.EntryType)
+		conditions = append(conditions, fmt.Sprintf("entry_type = $%d", len(args)))
+	}
+	if filters.StartTime != nil {
+		args = append(args, *filters.StartTime)
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if filters.EndTime != nil {
+		args = append(args, *filters.EndTime)
+		conditions = append(conditions, fmt.Sprintf("created_at <= $%d", len(args)))
+	}
+	where := strings.Join(conditions, " AND ")
+
+	var total int64
+	if err := scanSingleRow(ctx, r.db, "SELECT COUNT(*) FROM balance_ledger_entries WHERE "+where, args, &total); err != nil {
+		return nil, nil, err
+	}
+	if total == 0 {
+		return []service.BalanceLedgerEntry{}, paginationResultFromTotal(0, params), nil
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, user_id, entry_type, amount, balance_after,
+			usage_log_id, redeem_code_id, promo_code_id, admin_id, notes, created_at, topup_order_id
+		FROM balance_ledger_entries
+		WHERE %s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, where, len(args)+1, len(args)+2)
+	rows, err := r.db.QueryContext(ctx, query, append(args, params.Limit(), params.Offset())...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	entries := make([]service.BalanceLedgerEntry, 0)
+	for rows.Next() {
+		var e service.BalanceLedgerEntry
+		var usageLogID, redeemCodeID, promoCodeID, adminID, topupOrderID sql.NullInt64
+		if err := rows.Scan(
+			&e.ID,
+			&e.UserID,
+			&e.EntryType,
+			&e.Amount,
+			&e.BalanceAfter,
+			&usageLogID,
+			&redeemCodeID,
+			&promoCodeID,
+			&adminID,
+			&e.Notes,
+			&e.CreatedAt,
+			&topupOrderID,
+		); err != nil {
+			return nil, nil, err
+		}
+		e.UsageLogID = nullInt64Ptr(usageLogID)
+		e.RedeemCodeID = nullInt64Ptr(redeemCodeID)
+		e.PromoCodeID = nullInt64Ptr(promoCodeID)
+		e.TopupOrderID = nullInt64Ptr(topupOrderID)
+		e.AdminID = nullInt64Ptr(adminID)
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return entries, paginationResultFromTotal(total, params), nil
+}
+
+func (r *balanceLedgerRepository) ListBalanceSnapshots(ctx context.Context, afterUserID int64, limit int) ([]service.UserBalanceSnapshot, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT u.id, u.balance, COALESCE(l.total, 0), COALESCE(l.entries, 0)
+		FROM users u
+		LEFT JOIN LATERAL (
+			SELECT SUM(amount) AS total, COUNT(*) AS entries
+			FROM balance_ledger_entries
+			WHERE user_id = u.id
+		) l ON TRUE
+		WHERE u.deleted_at IS NULL AND u.id > $1
+		ORDER BY u.id
+		LIMIT $2
+	`, afterUserID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	snapshots := make([]service.UserBalanceSnapshot, 0, limit)
+	for rows.Next() {
+		var s service.UserBalanceSnapshot
+		if err := rows.Scan(&s.UserID, &s.Balance, &s.LedgerSum, &s.EntryCount); err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return snapshots, nil
+}
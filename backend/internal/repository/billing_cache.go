@@ -5,7 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Wei-Shaw/sub2api/internal/service"
@@ -15,6 +17,8 @@ import (
 const (
 	billingBalanceKeyPrefix = "billing:balance:"
 	billingSubKeyPrefix     = "billing:sub:"
+	billingAPIKeyKeyPrefix  = "billing:apikey:"
+	billingHoldKeyPrefix    = "billing:hold:"
 	billingCacheTTL         = 5 * time.Minute
 )
 
@@ -28,6 +32,16 @@ func billingSubKey(userID, groupID int64) string {
 	return fmt.Sprintf("%s%d:%d", billingSubKeyPrefix, userID, groupID)
 }
 
+// billingAPIKeyKey generates the Redis key for API key quota usage cache.
+func billingAPIKeyKey(apiKeyID int64) string {
+	return fmt.Sprintf("%s%d", billingAPIKeyKeyPrefix, apiKeyID)
+}
+
+// billingHoldKey generates the Redis key for user balance holds (hash: holdID -> "amount:expiresAt:createdAt:model").
+func billingHoldKey(userID int64) string {
+	return fmt.Sprintf("%s%d", billingHoldKeyPrefix, userID)
+}
+
 const (
 	subFieldStatus       = "status"
 	subFieldExpiresAt    = "expires_at"
@@ -37,6 +51,16 @@ const (
 	subFieldVersion      = "version"
 )
 
+const (
+	apiKeyFieldTotalCost       = "total_cost"
+	apiKeyFieldDailyCost       = "daily_cost"
+	apiKeyFieldMonthlyCost     = "monthly_cost"
+	apiKeyFieldDailyRequests   = "daily_requests"
+	apiKeyFieldMonthlyRequests = "monthly_requests"
+	apiKeyFieldDay             = "day"
+	apiKeyFieldMonth           = "month"
+)
+
 var (
 	deductBalanceScript = redis.NewScript(`
 		local current = redis.call('GET', KEYS[1])
@@ -61,6 +85,118 @@ var (
 		redis.call('EXPIRE', KEYS[1], ARGV[2])
 		return 1
 	`)
+
+	// incrementAPIKeyUsageScript 周期（日/月）切换时先归零对应计数，再累加消费
+	// ARGV: 消费, 日, 月, TTL 秒
+	incrementAPIKeyUsageScript = redis.NewScript(`
+		local exists = redis.call('EXISTS', KEYS[1])
+		if exists == 0 then
+			return 0
+		end
+		if redis.call('HGET', KEYS[1], 'day') ~= ARGV[2] then
+			redis.call('HSET', KEYS[1], 'day', ARGV[2], 'daily_cost', 0, 'daily_requests', 0)
+		end
+		if redis.call('HGET', KEYS[1], 'month') ~= ARGV[3] then
+			redis.call('HSET', KEYS[1], 'month', ARGV[3], 'monthly_cost', 0, 'monthly_requests', 0)
+		end
+		local cost = tonumber(ARGV[1])
+		redis.call('HINCRBYFLOAT', KEYS[1], 'total_cost', cost)
+		redis.call('HINCRBYFLOAT', KEYS[1], 'daily_cost', cost)
+		redis.call('HINCRBYFLOAT', KEYS[1], 'monthly_cost', cost)
+		redis.call('EXPIRE', KEYS[1], ARGV[4])
+		return 1
+	`)
+
+	// mergeAPIKeyRequestsScript 周期切换时先归零对应计数，再将请求数更新为 max(缓存值, 持久化计数)
+	// ARGV: 日, 月, 日请求数, 月请求数, TTL 秒
+	mergeAPIKeyRequestsScript = redis.NewScript(`
+		local exists = redis.call('EXISTS', KEYS[1])
+		if exists == 0 then
+			return 0
+		end
+		if redis.call('HGET', KEYS[1], 'day') ~= ARGV[1] then
+			redis.call('HSET', KEYS[1], 'day', ARGV[1], 'daily_cost', 0, 'daily_requests', 0)
+		end
+		if redis.call('HGET', KEYS[1], 'month') ~= ARGV[2] then
+			redis.call('HSET', KEYS[1], 'month', ARGV[2], 'monthly_cost', 0, 'monthly_requests', 0)
+		end
+		if tonumber(ARGV[3]) > tonumber(redis.call('HGET', KEYS[1], 'daily_requests') or '0') then
+			redis.call('HSET', KEYS[1], 'daily_requests', ARGV[3])
+		end
+		if tonumber(ARGV[4]) > tonumber(redis.call('HGET', KEYS[1], 'monthly_requests') or '0') then
+			redis.call('HSET', KEYS[1], 'monthly_requests', ARGV[4])
+		end
+		redis.call('EXPIRE', KEYS[1], ARGV[5])
+		return 1
+	`)
+
+	// setAPIKeyUsageScript 回填限额计数：周期切换（或缓存不存在）时先归零对应计数，再逐字段取 max(缓存值, 回填值)
+	// 回填值来自数据库汇总，可能早于并发写入的累加，取较大值避免覆盖
+	// ARGV: 日, 月, TTL 秒, 累计消费, 当日消费, 当月消费, 日请求数, 月请求数
+	setAPIKeyUsageScript = redis.NewScript(`
+		if redis.call('HGET', KEYS[1], 'day') ~= ARGV[1] then
+			redis.call('HSET', KEYS[1], 'day', ARGV[1], 'daily_cost', 0, 'daily_requests', 0)
+		end
+		if redis.call('HGET', KEYS[1], 'month') ~= ARGV[2] then
+			redis.call('HSET', KEYS[1], 'month', ARGV[2], 'monthly_cost', 0, 'monthly_requests', 0)
+		end
+		local fields = {'total_cost', 'daily_cost', 'monthly_cost', 'daily_requests', 'monthly_requests'}
+		for i, field in ipairs(fields) do
+			local value = ARGV[i + 3]
+			if tonumber(value) > tonumber(redis.call('HGET', KEYS[1], field) or '0') then
+				redis.call('HSET', KEYS[1], field, value)
+			end
+		end
+		redis.call('EXPIRE', KEYS[1], ARGV[3])
+		return 1
+	`)
+
+	// reserveBalanceHoldScript 清理过期冻结后按"余额 - 冻结合计"计算可用额度，冻结 min(估算, 可用额度)
+	// KEYS[1]=冻结 hash，KEYS[2]=余额缓存；ARGV: 回退余额, 估算金额, holdID, 当前时间, TTL 秒, "createdAt:model"
+	reserveBalanceHoldScript = redis.NewScript(`
+		local balance = redis.call('GET', KEYS[2])
+		if balance == false then
+			balance = ARGV[1]
+		end
+		balance = tonumber(balance)
+		local now = tonumber(ARGV[4])
+		local held = 0
+		local entries = redis.call('HGETALL', KEYS[1])
+		for i = 1, #entries, 2 do
+			local amount, expiresAt = string.match(entries[i + 1], '^([^:]+):([^:]+):')
+			if amount == nil or tonumber(expiresAt) <= now then
+				redis.call('HDEL', KEYS[1], entries[i])
+			else
+				held = held + tonumber(amount)
+			end
+		end
+		local available = balance - held
+		if available <= 0 then
+			return {0, tostring(available)}
+		end
+		local amount = tonumber(ARGV[2])
+		if amount > available then
+			amount = available
+		end
+		local ttl = tonumber(ARGV[5])
+		redis.call('HSET', KEYS[1], ARGV[3], tostring(amount) .. ':' .. tostring(now + ttl) .. ':' .. ARGV[6])
+		if redis.call('TTL', KEYS[1]) < ttl then
+			redis.call('EXPIRE', KEYS[1], ttl)
+		end
+		return {1, tostring(amount)}
+	`)
+
+	// settleBalanceHoldScript 移除冻结并扣减余额缓存（余额缓存不存在时仅移除冻结）
+	settleBalanceHoldScript = redis.NewScript(`
+		redis.call('HDEL', KEYS[1], ARGV[1])
+		local current = redis.call('GET', KEYS[2])
+		if current == false then
+			return 0
+		end
+		redis.call('SET', KEYS[2], tonumber(current) - tonumber(ARGV[2]))
+		redis.call('EXPIRE', KEYS[2], ARGV[3])
+		return 1
+	`)
 )
 
 type billingCache struct {
@@ -181,3 +317,125 @@ func (c *billingCache) InvalidateSubscriptionCache(ctx context.Context, userID,
 	key := billingSubKey(userID, groupID)
 	return c.rdb.Del(ctx, key).Err()
 }
+
+func (c *billingCache) GetAPIKeyUsage(ctx context.Context, apiKeyID int64) (*service.APIKeyUsage, error) {
+	key := billingAPIKeyKey(apiKeyID)
+	result, err := c.rdb.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, redis.Nil
+	}
+
+	usage := &service.APIKeyUsage{
+		Day:   result[apiKeyFieldDay],
+		Month: result[apiKeyFieldMonth],
+	}
+	usage.TotalCost, _ = strconv.ParseFloat(result[apiKeyFieldTotalCost], 64)
+	usage.DailyCost, _ = strconv.ParseFloat(result[apiKeyFieldDailyCost], 64)
+	usage.MonthlyCost, _ = strconv.ParseFloat(result[apiKeyFieldMonthlyCost], 64)
+	usage.DailyRequests, _ = strconv.ParseInt(result[apiKeyFieldDailyRequests], 10, 64)
+	usage.MonthlyRequests, _ = strconv.ParseInt(result[apiKeyFieldMonthlyRequests], 10, 64)
+	return usage, nil
+}
+
+func (c *billingCache) SetAPIKeyUsage(ctx context.Context, apiKeyID int64, usage *service.APIKeyUsage) error {
+	if usage == nil {
+		return nil
+	}
+
+	key := billingAPIKeyKey(apiKeyID)
+	_, err := setAPIKeyUsageScript.Run(ctx, c.rdb, []string{key},
+		usage.Day, usage.Month, int(billingCacheTTL.Seconds()),
+		usage.TotalCost, usage.DailyCost, usage.MonthlyCost, usage.DailyRequests, usage.MonthlyRequests).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+	return nil
+}
+
+func (c *billingCache) IncrementAPIKeyUsage(ctx context.Context, apiKeyID int64, cost float64, day, month string) error {
+	key := billingAPIKeyKey(apiKeyID)
+	_, err := incrementAPIKeyUsageScript.Run(ctx, c.rdb, []string{key}, cost, day, month, int(billingCacheTTL.Seconds())).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		log.Printf("Warning: increment api key usage cache failed for key %d: %v", apiKeyID, err)
+	}
+	return nil
+}
+
+func (c *billingCache) MergeAPIKeyRequests(ctx context.Context, apiKeyID int64, day, month string, daily, monthly int64) error {
+	key := billingAPIKeyKey(apiKeyID)
+	_, err := mergeAPIKeyRequestsScript.Run(ctx, c.rdb, []string{key}, day, month, daily, monthly, int(billingCacheTTL.Seconds())).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+	return nil
+}
+
+func (c *billingCache) ReserveBalanceHold(ctx context.Context, hold *service.BalanceHold, fallbackBalance float64) (float64, bool, error) {
+	ttl := int64(hold.ExpiresAt.Sub(hold.CreatedAt).Seconds())
+	if ttl <= 0 {
+		ttl = 1
+	}
+	meta := fmt.Sprintf("%d:%s", hold.CreatedAt.Unix(), hold.Model)
+	keys := []string{billingHoldKey(hold.UserID), billingBalanceKey(hold.UserID)}
+	res, err := reserveBalanceHoldScript.Run(ctx, c.rdb, keys,
+		fallbackBalance, hold.Amount, hold.ID, hold.CreatedAt.Unix(), ttl, meta).Slice()
+	if err != nil {
+		return 0, false, err
+	}
+	if len(res) != 2 {
+		return 0, false, fmt.Errorf("unexpected reserve hold result: %v", res)
+	}
+	ok, _ := res[0].(int64)
+	amountStr, _ := res[1].(string)
+	amount, err := strconv.ParseFloat(amountStr, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("parse reserved amount %q: %w", amountStr, err)
+	}
+	return amount, ok == 1, nil
+}
+
+func (c *billingCache) SettleBalanceHold(ctx context.Context, userID int64, holdID string, actualCost float64) error {
+	keys := []string{billingHoldKey(userID), billingBalanceKey(userID)}
+	_, err := settleBalanceHoldScript.Run(ctx, c.rdb, keys, holdID, actualCost, int(billingCacheTTL.Seconds())).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+	return nil
+}
+
+func (c *billingCache) ReleaseBalanceHold(ctx context.Context, userID int64, holdID string) error {
+	return c.rdb.HDel(ctx, billingHoldKey(userID), holdID).Err()
+}
+
+func (c *billingCache) ListBalanceHolds(ctx context.Context, userID int64) ([]service.BalanceHold, error) {
+	result, err := c.rdb.HGetAll(ctx, billingHoldKey(userID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	holds := make([]service.BalanceHold, 0, len(result))
+	for id, raw := range result {
+		parts := strings.SplitN(raw, ":", 4)
+		if len(parts) != 4 {
+			continue
+		}
+		amount, err1 := strconv.ParseFloat(parts[0], 64)
+		expiresAt, err2 := strconv.ParseInt(parts[1], 10, 64)
+		createdAt, err3 := strconv.ParseInt(parts[2], 10, 64)
+		if err1 != nil || err2 != nil || err3 != nil {
+			continue
+		}
+		holds = append(holds, service.BalanceHold{
+			ID:        id,
+			UserID:    userID,
+			Model:     parts[3],
+			Amount:    amount,
+			CreatedAt: time.Unix(createdAt, 0),
+			ExpiresAt: time.Unix(expiresAt, 0),
+		})
+	}
+	sort.Slice(holds, func(i, j int) bool { return holds[i].CreatedAt.Before(holds[j].CreatedAt) })
+	return holds, nil
+}
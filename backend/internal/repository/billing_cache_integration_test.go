@@ -278,6 +278,241 @@ func (s *BillingCacheSuite) TestSubscriptionCache() {
 	}
 }
 
+func (s *BillingCacheSuite) TestAPIKeyUsage() {
+	tests := []struct {
+		name string
+		fn   func(ctx context.Context, rdb *redis.Client, cache service.BillingCache)
+	}{
+		{
+			name: "increment_on_nonexistent_is_noop",
+			fn: func(ctx context.Context, rdb *redis.Client, cache service.BillingCache) {
+				apiKeyID := int64(1)
+				usageKey := fmt.Sprintf("%s%d", billingAPIKeyKeyPrefix, apiKeyID)
+
+				require.NoError(s.T(), cache.IncrementAPIKeyUsage(ctx, apiKeyID, 1, "2026-01-01", "2026-01"), "IncrementAPIKeyUsage")
+
+				exists, err := rdb.Exists(ctx, usageKey).Result()
+				require.NoError(s.T(), err, "Exists")
+				require.Equal(s.T(), int64(0), exists, "expected key to remain missing")
+
+				_, err = cache.GetAPIKeyUsage(ctx, apiKeyID)
+				require.ErrorIs(s.T(), err, redis.Nil, "expected redis.Nil for missing usage key")
+			},
+		},
+		{
+			name: "increment_same_period",
+			fn: func(ctx context.Context, rdb *redis.Client, cache service.BillingCache) {
+				apiKeyID := int64(2)
+				usageKey := fmt.Sprintf("%s%d", billingAPIKeyKeyPrefix, apiKeyID)
+
+				require.NoError(s.T(), cache.SetAPIKeyUsage(ctx, apiKeyID, &service.APIKeyUsage{
+					TotalCost: 5, DailyCost: 1, MonthlyCost: 3, DailyRequests: 2, MonthlyRequests: 6,
+					Day: "2026-01-15", Month: "2026-01",
+				}), "SetAPIKeyUsage")
+				require.NoError(s.T(), cache.IncrementAPIKeyUsage(ctx, apiKeyID, 0.5, "2026-01-15", "2026-01"), "IncrementAPIKeyUsage")
+
+				got, err := cache.GetAPIKeyUsage(ctx, apiKeyID)
+				require.NoError(s.T(), err, "GetAPIKeyUsage")
+				require.InDelta(s.T(), 5.5, got.TotalCost, 1e-9)
+				require.InDelta(s.T(), 1.5, got.DailyCost, 1e-9)
+				require.InDelta(s.T(), 3.5, got.MonthlyCost, 1e-9)
+				require.Equal(s.T(), int64(2), got.DailyRequests)
+				require.Equal(s.T(), int64(6), got.MonthlyRequests)
+
+				ttl, err := rdb.TTL(ctx, usageKey).Result()
+				require.NoError(s.T(), err, "TTL")
+				s.AssertTTLWithin(ttl, 1*time.Second, billingCacheTTL)
+			},
+		},
+		{
+			name: "increment_resets_rolled_periods",
+			fn: func(ctx context.Context, rdb *redis.Client, cache service.BillingCache) {
+				apiKeyID := int64(3)
+
+				require.NoError(s.T(), cache.SetAPIKeyUsage(ctx, apiKeyID, &service.APIKeyUsage{
+					TotalCost: 5, DailyCost: 1, MonthlyCost: 3, DailyRequests: 2, MonthlyRequests: 6,
+					Day: "2026-01-31", Month: "2026-01",
+				}), "SetAPIKeyUsage")
+				require.NoError(s.T(), cache.IncrementAPIKeyUsage(ctx, apiKeyID, 0.25, "2026-02-01", "2026-02"), "IncrementAPIKeyUsage")
+
+				got, err := cache.GetAPIKeyUsage(ctx, apiKeyID)
+				require.NoError(s.T(), err, "GetAPIKeyUsage")
+				require.InDelta(s.T(), 5.25, got.TotalCost, 1e-9)
+				require.InDelta(s.T(), 0.25, got.DailyCost, 1e-9)
+				require.InDelta(s.T(), 0.25, got.MonthlyCost, 1e-9)
+				require.Zero(s.T(), got.DailyRequests)
+				require.Zero(s.T(), got.MonthlyRequests)
+				require.Equal(s.T(), "2026-02-01", got.Day)
+				require.Equal(s.T(), "2026-02", got.Month)
+			},
+		},
+		{
+			name: "merge_requests_keeps_max",
+			fn: func(ctx context.Context, rdb *redis.Client, cache service.BillingCache) {
+				apiKeyID := int64(4)
+				usageKey := fmt.Sprintf("%s%d", billingAPIKeyKeyPrefix, apiKeyID)
+
+				require.NoError(s.T(), cache.MergeAPIKeyRequests(ctx, apiKeyID, "2026-01-15", "2026-01", 3, 3), "MergeAPIKeyRequests on missing key")
+				exists, err := rdb.Exists(ctx, usageKey).Result()
+				require.NoError(s.T(), err, "Exists")
+				require.Equal(s.T(), int64(0), exists, "expected key to remain missing")
+
+				require.NoError(s.T(), cache.SetAPIKeyUsage(ctx, apiKeyID, &service.APIKeyUsage{
+					DailyRequests: 5, MonthlyRequests: 9, Day: "2026-01-15", Month: "2026-01",
+				}), "SetAPIKeyUsage")
+				require.NoError(s.T(), cache.MergeAPIKeyRequests(ctx, apiKeyID, "2026-01-15", "2026-01", 4, 10), "MergeAPIKeyRequests")
+
+				got, err := cache.GetAPIKeyUsage(ctx, apiKeyID)
+				require.NoError(s.T(), err, "GetAPIKeyUsage")
+				require.Equal(s.T(), int64(5), got.DailyRequests)
+				require.Equal(s.T(), int64(10), got.MonthlyRequests)
+
+				require.NoError(s.T(), cache.MergeAPIKeyRequests(ctx, apiKeyID, "2026-01-16", "2026-01", 1, 11), "MergeAPIKeyRequests next day")
+				got, err = cache.GetAPIKeyUsage(ctx, apiKeyID)
+				require.NoError(s.T(), err, "GetAPIKeyUsage")
+				require.Equal(s.T(), "2026-01-16", got.Day)
+				require.Equal(s.T(), int64(1), got.DailyRequests)
+				require.Equal(s.T(), int64(11), got.MonthlyRequests)
+			},
+		},
+		{
+			name: "set_does_not_overwrite_newer_counts",
+			fn: func(ctx context.Context, rdb *redis.Client, cache service.BillingCache) {
+				apiKeyID := int64(5)
+
+				require.NoError(s.T(), cache.SetAPIKeyUsage(ctx, apiKeyID, &service.APIKeyUsage{
+					TotalCost: 5, DailyCost: 1, MonthlyCost: 3, DailyRequests: 2, MonthlyRequests: 6,
+					Day: "2026-01-15", Month: "2026-01",
+				}), "SetAPIKeyUsage")
+				require.NoError(s.T(), cache.IncrementAPIKeyUsage(ctx, apiKeyID, 0.5, "2026-01-15", "2026-01"), "IncrementAPIKeyUsage")
+				// 回填值早于上面的累加
+				require.NoError(s.T(), cache.SetAPIKeyUsage(ctx, apiKeyID, &service.APIKeyUsage{
+					TotalCost: 5, DailyCost: 1, MonthlyCost: 3, DailyRequests: 1, MonthlyRequests: 7,
+					Day: "2026-01-15", Month: "2026-01",
+				}), "SetAPIKeyUsage stale")
+
+				got, err := cache.GetAPIKeyUsage(ctx, apiKeyID)
+				require.NoError(s.T(), err, "GetAPIKeyUsage")
+				require.InDelta(s.T(), 5.5, got.TotalCost, 1e-9)
+				require.InDelta(s.T(), 1.5, got.DailyCost, 1e-9)
+				require.InDelta(s.T(), 3.5, got.MonthlyCost, 1e-9)
+				require.Equal(s.T(), int64(2), got.DailyRequests)
+				require.Equal(s.T(), int64(7), got.MonthlyRequests)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			rdb := testRedis(s.T())
+			cache := NewBillingCache(rdb)
+			ctx := context.Background()
+
+			tt.fn(ctx, rdb, cache)
+		})
+	}
+}
+
 func TestBillingCacheSuite(t *testing.T) {
 	suite.Run(t, new(BillingCacheSuite))
 }
+
+func (s *BillingCacheSuite) TestBalanceHolds() {
+	newHold := func(userID int64, id string, amount float64, createdAt time.Time) *service.BalanceHold {
+		return &service.BalanceHold{
+			ID:        id,
+			UserID:    userID,
+			Model:     "claude-opus-4",
+			Amount:    amount,
+			CreatedAt: createdAt,
+			ExpiresAt: createdAt.Add(30 * time.Minute),
+		}
+	}
+
+	tests := []struct {
+		name string
+		fn   func(ctx context.Context, rdb *redis.Client, cache service.BillingCache)
+	}{
+		{
+			name: "reserve_clamps_to_available_and_rejects_when_fully_held",
+			fn: func(ctx context.Context, rdb *redis.Client, cache service.BillingCache) {
+				userID := int64(1)
+				now := time.Now()
+				require.NoError(s.T(), cache.SetUserBalance(ctx, userID, 1), "SetUserBalance")
+
+				reserved, ok, err := cache.ReserveBalanceHold(ctx, newHold(userID, "a", 0.4, now), 0)
+				require.NoError(s.T(), err, "ReserveBalanceHold a")
+				require.True(s.T(), ok)
+				require.InDelta(s.T(), 0.4, reserved, 1e-9)
+
+				reserved, ok, err = cache.ReserveBalanceHold(ctx, newHold(userID, "b", 5, now), 0)
+				require.NoError(s.T(), err, "ReserveBalanceHold b")
+				require.True(s.T(), ok)
+				require.InDelta(s.T(), 0.6, reserved, 1e-9, "expected hold clamped to available balance")
+
+				_, ok, err = cache.ReserveBalanceHold(ctx, newHold(userID, "c", 0.1, now), 0)
+				require.NoError(s.T(), err, "ReserveBalanceHold c")
+				require.False(s.T(), ok, "expected rejection when balance is fully held")
+
+				holds, err := cache.ListBalanceHolds(ctx, userID)
+				require.NoError(s.T(), err, "ListBalanceHolds")
+				require.Len(s.T(), holds, 2)
+				require.Equal(s.T(), "claude-opus-4", holds[0].Model)
+
+				ttl, err := rdb.TTL(ctx, billingHoldKey(userID)).Result()
+				require.NoError(s.T(), err, "TTL")
+				s.AssertTTLWithin(ttl, 1*time.Second, 30*time.Minute)
+			},
+		},
+		{
+			name: "reserve_uses_fallback_balance_and_prunes_expired",
+			fn: func(ctx context.Context, rdb *redis.Client, cache service.BillingCache) {
+				userID := int64(2)
+				past := time.Now().Add(-time.Hour)
+				require.NoError(s.T(), rdb.HSet(ctx, billingHoldKey(userID), "stale",
+					fmt.Sprintf("2:%d:%d:claude", past.Unix(), past.Unix()-60)).Err(), "HSet stale hold")
+
+				reserved, ok, err := cache.ReserveBalanceHold(ctx, newHold(userID, "a", 1, time.Now()), 2)
+				require.NoError(s.T(), err, "ReserveBalanceHold")
+				require.True(s.T(), ok)
+				require.InDelta(s.T(), 1, reserved, 1e-9)
+
+				exists, err := rdb.HExists(ctx, billingHoldKey(userID), "stale").Result()
+				require.NoError(s.T(), err, "HExists")
+				require.False(s.T(), exists, "expected expired hold to be pruned")
+			},
+		},
+		{
+			name: "settle_deducts_balance_and_release_removes_hold",
+			fn: func(ctx context.Context, rdb *redis.Client, cache service.BillingCache) {
+				userID := int64(3)
+				now := time.Now()
+				require.NoError(s.T(), cache.SetUserBalance(ctx, userID, 10), "SetUserBalance")
+				_, _, err := cache.ReserveBalanceHold(ctx, newHold(userID, "a", 2, now), 0)
+				require.NoError(s.T(), err, "ReserveBalanceHold a")
+				_, _, err = cache.ReserveBalanceHold(ctx, newHold(userID, "b", 3, now), 0)
+				require.NoError(s.T(), err, "ReserveBalanceHold b")
+
+				require.NoError(s.T(), cache.SettleBalanceHold(ctx, userID, "a", 0.5), "SettleBalanceHold")
+				balance, err := cache.GetUserBalance(ctx, userID)
+				require.NoError(s.T(), err, "GetUserBalance")
+				require.InDelta(s.T(), 9.5, balance, 1e-9)
+
+				require.NoError(s.T(), cache.ReleaseBalanceHold(ctx, userID, "b"), "ReleaseBalanceHold")
+				holds, err := cache.ListBalanceHolds(ctx, userID)
+				require.NoError(s.T(), err, "ListBalanceHolds")
+				require.Empty(s.T(), holds)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			rdb := testRedis(s.T())
+			cache := NewBillingCache(rdb)
+			ctx := context.Background()
+
+			tt.fn(ctx, rdb, cache)
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type costRoutingReportRepository struct {
+	db *sql.DB
+}
+
+func NewCostRoutingReportRepository(db *sql.DB) service.CostRoutingReportRepository {
+	return &costRoutingReportRepository{db: db}
+}
+
+// GetGroupCostRoutingStats 汇总分组在 [startTime, endTime) 内按成本策略调度的请求
+// 成本按标准费用（total_cost）× 成本系数计算，默认账号与实际账号的差额即策略转移的花费
+func (r *costRoutingReportRepository) GetGroupCostRoutingStats(ctx context.Context, groupID int64, startTime, endTime time.Time) (*service.GroupCostRoutingReport, error) {
+	query := `
+		SELECT
+			COUNT(*) AS requests,
+			COUNT(*) FILTER (WHERE cost_routing_baseline_factor <> cost_routing_chosen_factor) AS shifted_requests,
+			COALESCE(SUM(total_cost * cost_routing_baseline_factor), 0) AS baseline_cost,
+			COALESCE(SUM(total_cost * cost_routing_chosen_factor), 0) AS actual_cost
+		FROM usage_logs
+		WHERE group_id = $1
+			AND created_at >= $2
+			AND created_at < $3
+			AND cost_routing_baseline_factor IS NOT NULL
+	`
+	report := &service.GroupCostRoutingReport{}
+	if err := scanSingleRow(ctx, r.db, query, []any{groupID, startTime, endTime},
+		&report.Requests,
+		&report.ShiftedRequests,
+		&report.BaselineCost,
+		&report.ActualCost,
+	); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
@@ -0,0 +1,387 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/redis/go-redis/v9"
+)
+
+// 账号槽位加权公平队列
+//
+// 设计说明：
+// - 队列 Key: fairq:group:{groupID}（有序集合，成员为 requestID，分数为 WFQ 完成标签）
+// - 元数据 Key: fairq:group:{groupID}:meta（Hash：requestID -> "accountID|userID|心跳毫秒"）
+// - 虚拟时钟 Key: fairq:group:{groupID}:vt（最近放行请求的标签）
+// - 用户标签 Key: fairq:group:{groupID}:user:{userID}（该用户最近入队请求的标签）
+// - 放行统计 Key: fairq:account:{accountID}:grant（Hash：last 上次放行毫秒、ewma 放行间隔 EWMA）
+// - 账号等待 Key: fairq:account:{accountID}:waiting（有序集合，成员为 requestID，分数为心跳毫秒）
+// 放行时直接写入账号槽位有序集合 concurrency:account:{accountID}，释放沿用 ReleaseAccountSlot。
+// 未排队的请求直接占用槽位时检查账号等待集合，有等待者时让行，避免绕过队列插队。
+// 心跳超时的排队项在轮询时惰性清理。
+const (
+	fairQueueKeyPrefix        = "fairq:group:"
+	fairQueueAccountKeyPrefix = "fairq:account:"
+	// 队列相关 Key 的 TTL（秒），无排队流量后自然过期
+	fairQueueKeyTTLSeconds = 60 * 60
+	// 放行间隔超过该值（毫秒）视为空闲，不计入 EWMA
+	fairQueueMaxGrantIntervalMs = 60 * 1000
+)
+
+var (
+	// enqueueFairWaiterScript 计算 WFQ 标签并入队
+	// KEYS[1] = 队列；KEYS[2] = 元数据；KEYS[3] = 虚拟时钟；KEYS[4] = 用户标签；KEYS[5] = 账号等待集合
+	// ARGV[1] = requestID；ARGV[2] = accountID；ARGV[3] = userID；ARGV[4] = 权重
+	// ARGV[5] = 心跳超时（毫秒）；ARGV[6] = Key TTL（秒）
+	// 返回: 同一账号上排在之前的有效等待者数量
+	enqueueFairWaiterScript = redis.NewScript(`
+		local requestID = ARGV[1]
+		local accountID = ARGV[2]
+		local weight = tonumber(ARGV[4])
+		local staleMs = tonumber(ARGV[5])
+		local ttl = tonumber(ARGV[6])
+		if weight == nil or weight <= 0 then
+			weight = 1
+		end
+
+		local t = redis.call('TIME')
+		local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+		local vt = tonumber(redis.call('GET', KEYS[3])) or 0
+		local last = tonumber(redis.call('GET', KEYS[4])) or 0
+		local start = vt
+		if last > start then
+			start = last
+		end
+		local tag = start + 1 / weight
+
+		redis.call('SET', KEYS[4], tag, 'EX', ttl)
+		redis.call('ZADD', KEYS[1], tag, requestID)
+		redis.call('HSET', KEYS[2], requestID, accountID .. '|' .. ARGV[3] .. '|' .. now)
+		redis.call('ZADD', KEYS[5], now, requestID)
+		redis.call('EXPIRE', KEYS[1], ttl)
+		redis.call('EXPIRE', KEYS[2], ttl)
+		redis.call('EXPIRE', KEYS[5], ttl)
+
+		local ahead = 0
+		local rank = redis.call('ZRANK', KEYS[1], requestID)
+		if rank and rank > 0 then
+			local members = redis.call('ZRANGE', KEYS[1], 0, rank - 1)
+			for _, m in ipairs(members) do
+				local v = redis.call('HGET', KEYS[2], m)
+				if v then
+					local aid, _, hb = string.match(v, '^(%d+)|(%d+)|(%d+)$')
+					if aid == accountID and now - tonumber(hb) <= staleMs then
+						ahead = ahead + 1
+					end
+				end
+			end
+		end
+		return ahead
+	`)
+
+	// tryAcquireFairSlotScript 刷新心跳、清理过期排队项并按公平顺序占用账号槽位
+	// KEYS[1] = 队列；KEYS[2] = 元数据；KEYS[3] = 虚拟时钟；KEYS[4] = 账号槽位；KEYS[5] = 放行统计；KEYS[6] = 账号等待集合
+	// ARGV[1] = requestID；ARGV[2] = accountID；ARGV[3] = userID；ARGV[4] = maxConcurrency
+	// ARGV[5] = 槽位 TTL（秒）；ARGV[6] = 心跳超时（毫秒）；ARGV[7] = Key TTL（秒）；ARGV[8] = 最大放行间隔（毫秒）
+	// 返回: {status, ahead, ewma}（status: 1 已获取，0 继续等待，-1 排队项已失效）
+	tryAcquireFairSlotScript = redis.NewScript(`
+		local requestID = ARGV[1]
+		local accountID = ARGV[2]
+		local maxConcurrency = tonumber(ARGV[4])
+		local slotTTL = tonumber(ARGV[5])
+		local staleMs = tonumber(ARGV[6])
+		local ttl = tonumber(ARGV[7])
+		local maxInterval = tonumber(ARGV[8])
+
+		local t = redis.call('TIME')
+		local nowSec = tonumber(t[1])
+		local now = nowSec * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+		redis.call('ZREMRANGEBYSCORE', KEYS[6], '-inf', now - staleMs)
+		local myTag = redis.call('ZSCORE', KEYS[1], requestID)
+		if not myTag or not redis.call('HEXISTS', KEYS[2], requestID) then
+			redis.call('ZREM', KEYS[1], requestID)
+			redis.call('HDEL', KEYS[2], requestID)
+			redis.call('ZREM', KEYS[6], requestID)
+			return {-1, 0, 0}
+		end
+		redis.call('HSET', KEYS[2], requestID, accountID .. '|' .. ARGV[3] .. '|' .. now)
+		redis.call('ZADD', KEYS[6], now, requestID)
+		redis.call('EXPIRE', KEYS[6], ttl)
+
+		local ahead = 0
+		local rank = redis.call('ZRANK', KEYS[1], requestID)
+		if rank and rank > 0 then
+			local members = redis.call('ZRANGE', KEYS[1], 0, rank - 1)
+			for _, m in ipairs(members) do
+				local v = redis.call('HGET', KEYS[2], m)
+				if not v then
+					redis.call('ZREM', KEYS[1], m)
+				else
+					local aid, _, hb = string.match(v, '^(%d+)|(%d+)|(%d+)$')
+					if hb == nil or now - tonumber(hb) > staleMs then
+						redis.call('ZREM', KEYS[1], m)
+						redis.call('HDEL', KEYS[2], m)
+					elseif aid == accountID then
+						ahead = ahead + 1
+					end
+				end
+			end
+		end
+
+		local g = redis.call('HMGET', KEYS[5], 'last', 'ewma')
+		local ewma = tonumber(g[2]) or 0
+
+		redis.call('ZREMRANGEBYSCORE', KEYS[4], '-inf', nowSec - slotTTL)
+		local used = redis.call('ZCARD', KEYS[4])
+		if ahead >= maxConcurrency - used then
+			return {0, ahead, math.floor(ewma)}
+		end
+
+		redis.call('ZADD', KEYS[4], nowSec, requestID)
+		redis.call('EXPIRE', KEYS[4], slotTTL)
+		redis.call('ZREM', KEYS[1], requestID)
+		redis.call('HDEL', KEYS[2], requestID)
+		redis.call('ZREM', KEYS[6], requestID)
+
+		local tag = tonumber(myTag)
+		local vt = tonumber(redis.call('GET', KEYS[3])) or 0
+		if tag > vt then
+			vt = tag
+		end
+		redis.call('SET', KEYS[3], vt, 'EX', ttl)
+
+		local last = tonumber(g[1])
+		if last then
+			local interval = now - last
+			if interval >= 0 and interval <= maxInterval then
+				if ewma <= 0 then
+					ewma = interval
+				else
+					ewma = ewma * 0.8 + interval * 0.2
+				end
+			end
+		end
+		redis.call('HSET', KEYS[5], 'last', now, 'ewma', math.floor(ewma))
+		redis.call('EXPIRE', KEYS[5], ttl)
+		return {1, ahead, math.floor(ewma)}
+	`)
+
+	// leaveFairQueueScript 移除排队项
+	// KEYS[1] = 队列；KEYS[2] = 元数据；KEYS[3] = 账号等待集合；ARGV[1] = requestID
+	leaveFairQueueScript = redis.NewScript(`
+		redis.call('ZREM', KEYS[1], ARGV[1])
+		redis.call('HDEL', KEYS[2], ARGV[1])
+		redis.call('ZREM', KEYS[3], ARGV[1])
+		return 1
+	`)
+
+	// acquireSlotIfNoWaitersScript 账号没有有效排队者时直接占用槽位（未排队请求使用）
+	// KEYS[1] = 账号等待集合；KEYS[2] = 账号槽位
+	// ARGV[1] = maxConcurrency；ARGV[2] = 槽位 TTL（秒）；ARGV[3] = requestID；ARGV[4] = 心跳超时（毫秒）
+	// 返回: 1 已获取，0 槽位已满或有排队者
+	acquireSlotIfNoWaitersScript = redis.NewScript(`
+		local maxConcurrency = tonumber(ARGV[1])
+		local slotTTL = tonumber(ARGV[2])
+		local requestID = ARGV[3]
+		local staleMs = tonumber(ARGV[4])
+
+		local t = redis.call('TIME')
+		local nowSec = tonumber(t[1])
+		local now = nowSec * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+		redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - staleMs)
+		if redis.call('ZCARD', KEYS[1]) > 0 then
+			return 0
+		end
+
+		redis.call('ZREMRANGEBYSCORE', KEYS[2], '-inf', nowSec - slotTTL)
+		if redis.call('ZCARD', KEYS[2]) >= maxConcurrency then
+			return 0
+		end
+		redis.call('ZADD', KEYS[2], nowSec, requestID)
+		redis.call('EXPIRE', KEYS[2], slotTTL)
+		return 1
+	`)
+
+	// countAccountWaitersScript 统计账号有效排队者数量（只读，过期判定与 acquireSlotIfNoWaitersScript 一致）
+	// KEYS[1] = 账号等待集合；ARGV[1] = 心跳超时（毫秒）
+	countAccountWaitersScript = redis.NewScript(`
+		local t = redis.call('TIME')
+		local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+		return redis.call('ZCOUNT', KEYS[1], '(' .. (now - tonumber(ARGV[1])), '+inf')
+	`)
+
+	// getFairQueueStatsScript 批量统计分组有效排队数与排队用户数（只读）
+	// KEYS[2i-1] = 队列；KEYS[2i] = 元数据；ARGV[1] = 心跳超时（毫秒）
+	// 返回: 每个分组 2 项 {waiting, users}
+	getFairQueueStatsScript = redis.NewScript(`
+		local staleMs = tonumber(ARGV[1])
+		local t = redis.call('TIME')
+		local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+		local result = {}
+		for i = 2, #KEYS, 2 do
+			local entries = redis.call('HVALS', KEYS[i])
+			local waiting = 0
+			local users = {}
+			local userCount = 0
+			for _, v in ipairs(entries) do
+				local _, uid, hb = string.match(v, '^(%d+)|(%d+)|(%d+)$')
+				if hb and now - tonumber(hb) <= staleMs then
+					waiting = waiting + 1
+					if not users[uid] then
+						users[uid] = true
+						userCount = userCount + 1
+					end
+				end
+			end
+			table.insert(result, waiting)
+			table.insert(result, userCount)
+		end
+		return result
+	`)
+)
+
+type fairQueueCache struct {
+	rdb            *redis.Client
+	slotTTLSeconds int
+}
+
+// NewFairQueueCache 创建账号槽位公平队列缓存
+// slotTTLMinutes: 账号槽位过期时间（分钟），需与并发控制缓存保持一致
+func NewFairQueueCache(rdb *redis.Client, slotTTLMinutes int) service.FairQueueCache {
+	if slotTTLMinutes <= 0 {
+		slotTTLMinutes = defaultSlotTTLMinutes
+	}
+	return &fairQueueCache{rdb: rdb, slotTTLSeconds: slotTTLMinutes * 60}
+}
+
+func fairQueueKey(groupID int64) string {
+	return fairQueueKeyPrefix + strconv.FormatInt(groupID, 10)
+}
+
+func fairQueueMetaKey(groupID int64) string {
+	return fairQueueKey(groupID) + ":meta"
+}
+
+func fairQueueVirtualTimeKey(groupID int64) string {
+	return fairQueueKey(groupID) + ":vt"
+}
+
+func fairQueueUserTagKey(groupID, userID int64) string {
+	return fairQueueKey(groupID) + ":user:" + strconv.FormatInt(userID, 10)
+}
+
+func fairQueueGrantKey(accountID int64) string {
+	return fairQueueAccountKeyPrefix + strconv.FormatInt(accountID, 10) + ":grant"
+}
+
+func fairQueueAccountWaitingKey(accountID int64) string {
+	return fairQueueAccountKeyPrefix + strconv.FormatInt(accountID, 10) + ":waiting"
+}
+
+func (c *fairQueueCache) EnqueueFairWaiter(ctx context.Context, waiter service.FairQueueWaiter, staleSeconds int) (int, error) {
+	keys := []string{
+		fairQueueKey(waiter.GroupID),
+		fairQueueMetaKey(waiter.GroupID),
+		fairQueueVirtualTimeKey(waiter.GroupID),
+		fairQueueUserTagKey(waiter.GroupID, waiter.UserID),
+		fairQueueAccountWaitingKey(waiter.AccountID),
+	}
+	ahead, err := enqueueFairWaiterScript.Run(ctx, c.rdb, keys,
+		waiter.RequestID,
+		waiter.AccountID,
+		waiter.UserID,
+		strconv.FormatFloat(waiter.Weight, 'f', -1, 64),
+		staleSeconds*1000,
+		fairQueueKeyTTLSeconds,
+	).Int()
+	if err != nil {
+		return 0, fmt.Errorf("enqueue fair waiter: %w", err)
+	}
+	return ahead, nil
+}
+
+func (c *fairQueueCache) TryAcquireFairSlot(ctx context.Context, waiter service.FairQueueWaiter, maxConcurrency int, staleSeconds int) (*service.FairQueuePoll, error) {
+	keys := []string{
+		fairQueueKey(waiter.GroupID),
+		fairQueueMetaKey(waiter.GroupID),
+		fairQueueVirtualTimeKey(waiter.GroupID),
+		accountSlotKey(waiter.AccountID),
+		fairQueueGrantKey(waiter.AccountID),
+		fairQueueAccountWaitingKey(waiter.AccountID),
+	}
+	values, err := tryAcquireFairSlotScript.Run(ctx, c.rdb, keys,
+		waiter.RequestID,
+		waiter.AccountID,
+		waiter.UserID,
+		maxConcurrency,
+		c.slotTTLSeconds,
+		staleSeconds*1000,
+		fairQueueKeyTTLSeconds,
+		fairQueueMaxGrantIntervalMs,
+	).Int64Slice()
+	if err != nil {
+		return nil, fmt.Errorf("try acquire fair slot: %w", err)
+	}
+	if len(values) != 3 {
+		return nil, fmt.Errorf("try acquire fair slot: unexpected reply length %d", len(values))
+	}
+	return &service.FairQueuePoll{
+		Acquired:           values[0] == 1,
+		Evicted:            values[0] == -1,
+		Ahead:              int(values[1]),
+		AvgGrantIntervalMs: values[2],
+	}, nil
+}
+
+func (c *fairQueueCache) LeaveFairQueue(ctx context.Context, waiter service.FairQueueWaiter) error {
+	keys := []string{
+		fairQueueKey(waiter.GroupID),
+		fairQueueMetaKey(waiter.GroupID),
+		fairQueueAccountWaitingKey(waiter.AccountID),
+	}
+	return leaveFairQueueScript.Run(ctx, c.rdb, keys, waiter.RequestID).Err()
+}
+
+func (c *fairQueueCache) AcquireSlotIfNoWaiters(ctx context.Context, accountID int64, maxConcurrency int, requestID string, staleSeconds int) (bool, error) {
+	keys := []string{fairQueueAccountWaitingKey(accountID), accountSlotKey(accountID)}
+	result, err := acquireSlotIfNoWaitersScript.Run(ctx, c.rdb, keys, maxConcurrency, c.slotTTLSeconds, requestID, staleSeconds*1000).Int()
+	if err != nil {
+		return false, fmt.Errorf("acquire slot if no waiters: %w", err)
+	}
+	return result == 1, nil
+}
+
+func (c *fairQueueCache) CountAccountWaiters(ctx context.Context, accountID int64, staleSeconds int) (int, error) {
+	count, err := countAccountWaitersScript.Run(ctx, c.rdb, []string{fairQueueAccountWaitingKey(accountID)}, staleSeconds*1000).Int()
+	if err != nil {
+		return 0, fmt.Errorf("count account waiters: %w", err)
+	}
+	return count, nil
+}
+
+func (c *fairQueueCache) GetFairQueueStats(ctx context.Context, groupIDs []int64, staleSeconds int) (map[int64]*service.FairQueueGroupStats, error) {
+	result := make(map[int64]*service.FairQueueGroupStats, len(groupIDs))
+	if len(groupIDs) == 0 {
+		return result, nil
+	}
+	keys := make([]string, 0, len(groupIDs)*2)
+	for _, id := range groupIDs {
+		keys = append(keys, fairQueueKey(id), fairQueueMetaKey(id))
+	}
+	values, err := getFairQueueStatsScript.Run(ctx, c.rdb, keys, staleSeconds*1000).Int64Slice()
+	if err != nil {
+		return nil, fmt.Errorf("get fair queue stats: %w", err)
+	}
+	for i, id := range groupIDs {
+		if 2*i+1 >= len(values) {
+			break
+		}
+		result[id] = &service.FairQueueGroupStats{Waiting: values[2*i], Users: values[2*i+1]}
+	}
+	return result, nil
+}
@@ -0,0 +1,129 @@
+//go:build integration
+
+package repository
+
+import (
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type FairQueueCacheSuite struct {
+	IntegrationRedisSuite
+	cache service.FairQueueCache
+	slots service.ConcurrencyCache
+}
+
+func (s *FairQueueCacheSuite) SetupTest() {
+	s.IntegrationRedisSuite.SetupTest()
+	s.cache = NewFairQueueCache(s.rdb, testSlotTTLMinutes)
+	s.slots = NewConcurrencyCache(s.rdb, testSlotTTLMinutes, int(testSlotTTL.Seconds()))
+}
+
+func (s *FairQueueCacheSuite) waiter(userID int64, requestID string) service.FairQueueWaiter {
+	return service.FairQueueWaiter{GroupID: 1, UserID: userID, AccountID: 100, RequestID: requestID, Weight: 1}
+}
+
+func (s *FairQueueCacheSuite) TestRoundRobinAcrossUsers() {
+	const staleSeconds = 10
+	ok, err := s.slots.AcquireAccountSlot(s.ctx, 100, 1, "busy")
+	require.NoError(s.T(), err)
+	require.True(s.T(), ok)
+
+	// 用户 1 先排 3 个请求，用户 2 后到的请求应排在用户 1 的第二个请求之前
+	for _, id := range []string{"a1", "a2", "a3"} {
+		_, err := s.cache.EnqueueFairWaiter(s.ctx, s.waiter(1, id), staleSeconds)
+		require.NoError(s.T(), err)
+	}
+	ahead, err := s.cache.EnqueueFairWaiter(s.ctx, s.waiter(2, "b1"), staleSeconds)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 1, ahead)
+
+	stats, err := s.cache.GetFairQueueStats(s.ctx, []int64{1, 2}, staleSeconds)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), &service.FairQueueGroupStats{Waiting: 4, Users: 2}, stats[1])
+	require.Equal(s.T(), &service.FairQueueGroupStats{}, stats[2])
+
+	poll, err := s.cache.TryAcquireFairSlot(s.ctx, s.waiter(1, "a1"), 1, staleSeconds)
+	require.NoError(s.T(), err)
+	require.False(s.T(), poll.Acquired)
+	require.Equal(s.T(), 0, poll.Ahead)
+
+	require.NoError(s.T(), s.slots.ReleaseAccountSlot(s.ctx, 100, "busy"))
+
+	// 空闲槽位只放行排在最前的请求
+	poll, err = s.cache.TryAcquireFairSlot(s.ctx, s.waiter(2, "b1"), 1, staleSeconds)
+	require.NoError(s.T(), err)
+	require.False(s.T(), poll.Acquired)
+	require.Equal(s.T(), 1, poll.Ahead)
+
+	poll, err = s.cache.TryAcquireFairSlot(s.ctx, s.waiter(1, "a1"), 1, staleSeconds)
+	require.NoError(s.T(), err)
+	require.True(s.T(), poll.Acquired)
+	cur, err := s.slots.GetAccountConcurrency(s.ctx, 100)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 1, cur)
+
+	require.NoError(s.T(), s.slots.ReleaseAccountSlot(s.ctx, 100, "a1"))
+	poll, err = s.cache.TryAcquireFairSlot(s.ctx, s.waiter(2, "b1"), 1, staleSeconds)
+	require.NoError(s.T(), err)
+	require.True(s.T(), poll.Acquired)
+
+	require.NoError(s.T(), s.cache.LeaveFairQueue(s.ctx, s.waiter(1, "a3")))
+	stats, err = s.cache.GetFairQueueStats(s.ctx, []int64{1}, staleSeconds)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), &service.FairQueueGroupStats{Waiting: 1, Users: 1}, stats[1])
+}
+
+func (s *FairQueueCacheSuite) TestDirectAcquireYieldsToWaiters() {
+	const staleSeconds = 10
+	_, err := s.cache.EnqueueFairWaiter(s.ctx, s.waiter(1, "q1"), staleSeconds)
+	require.NoError(s.T(), err)
+
+	waiters, err := s.cache.CountAccountWaiters(s.ctx, 100, staleSeconds)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 1, waiters)
+
+	// 有排队者时未排队请求不能直接占用空闲槽位
+	ok, err := s.cache.AcquireSlotIfNoWaiters(s.ctx, 100, 2, "direct1", staleSeconds)
+	require.NoError(s.T(), err)
+	require.False(s.T(), ok)
+
+	poll, err := s.cache.TryAcquireFairSlot(s.ctx, s.waiter(1, "q1"), 2, staleSeconds)
+	require.NoError(s.T(), err)
+	require.True(s.T(), poll.Acquired)
+
+	waiters, err = s.cache.CountAccountWaiters(s.ctx, 100, staleSeconds)
+	require.NoError(s.T(), err)
+	require.Zero(s.T(), waiters)
+
+	// 队列清空后恢复直接占用，直到槽位用满
+	ok, err = s.cache.AcquireSlotIfNoWaiters(s.ctx, 100, 2, "direct1", staleSeconds)
+	require.NoError(s.T(), err)
+	require.True(s.T(), ok)
+	ok, err = s.cache.AcquireSlotIfNoWaiters(s.ctx, 100, 2, "direct2", staleSeconds)
+	require.NoError(s.T(), err)
+	require.False(s.T(), ok)
+
+	// 放弃排队同样移出账号等待集合
+	_, err = s.cache.EnqueueFairWaiter(s.ctx, s.waiter(2, "q2"), staleSeconds)
+	require.NoError(s.T(), err)
+	require.NoError(s.T(), s.cache.LeaveFairQueue(s.ctx, s.waiter(2, "q2")))
+	require.NoError(s.T(), s.slots.ReleaseAccountSlot(s.ctx, 100, "direct1"))
+	ok, err = s.cache.AcquireSlotIfNoWaiters(s.ctx, 100, 2, "direct3", staleSeconds)
+	require.NoError(s.T(), err)
+	require.True(s.T(), ok)
+}
+
+func (s *FairQueueCacheSuite) TestEvictedWaiter() {
+	poll, err := s.cache.TryAcquireFairSlot(s.ctx, s.waiter(1, "missing"), 1, 10)
+	require.NoError(s.T(), err)
+	require.True(s.T(), poll.Evicted)
+	require.False(s.T(), poll.Acquired)
+}
+
+func TestFairQueueCacheSuite(t *testing.T) {
+	suite.Run(t, new(FairQueueCacheSuite))
+}
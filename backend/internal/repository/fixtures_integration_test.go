@@ -9,6 +9,7 @@ import (
 
 	dbent "github.com/Wei-Shaw/sub2api/ent"
 	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/require"
 )
 
@@ -101,6 +102,9 @@ func mustCreateGroup(t *testing.T, client *dbent.Client, g *service.Group) *serv
 	if g.MonthlyLimitUSD != nil {
 		create.SetMonthlyLimitUsd(*g.MonthlyLimitUSD)
 	}
+	if g.AccountTagSelector != "" {
+		create.SetAccountTagSelector(g.AccountTagSelector)
+	}
 	if !g.CreatedAt.IsZero() {
 		create.SetCreatedAt(g.CreatedAt)
 	}
@@ -227,6 +231,9 @@ func mustCreateAccount(t *testing.T, client *dbent.Client, a *service.Account) *
 	if a.SessionWindowStatus != "" {
 		create.SetSessionWindowStatus(a.SessionWindowStatus)
 	}
+	if len(a.Tags) > 0 {
+		create.SetTags(pq.StringArray(a.Tags))
+	}
 	if !a.CreatedAt.IsZero() {
 		create.SetCreatedAt(a.CreatedAt)
 	}
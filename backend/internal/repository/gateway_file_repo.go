@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/lib/pq"
+)
+
+type gatewayFileRepository struct {
+	db *sql.DB
+}
+
+func NewGatewayFileRepository(db *sql.DB) service.GatewayFileRepository {
+	return &gatewayFileRepository{db: db}
+}
+
+const gatewayFileSelectColumns = `
+	id, file_id, platform, account_id, user_id, api_key_id, filename, mime_type,
+	purpose, size_bytes, expires_at, created_at
+`
+
+func (r *gatewayFileRepository) Create(ctx context.Context, file *service.GatewayFile) error {
+	if file == nil {
+		return nil
+	}
+	var expiresAt sql.NullTime
+	if file.ExpiresAt != nil {
+		expiresAt = sql.NullTime{Time: *file.ExpiresAt, Valid: true}
+	}
+	return r.db.QueryRowContext(ctx, `
+		INSERT INTO gateway_files (file_id, platform, account_id, user_id, api_key_id, filename, mime_type, purpose, size_bytes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (file_id) DO UPDATE SET
+			platform = EXCLUDED.platform,
+			account_id = EXCLUDED.account_id,
+			user_id = EXCLUDED.user_id,
+			api_key_id = EXCLUDED.api_key_id,
+			filename = EXCLUDED.filename,
+			mime_type = EXCLUDED.mime_type,
+			purpose = EXCLUDED.purpose,
+			size_bytes = EXCLUDED.size_bytes,
+			expires_at = EXCLUDED.expires_at
+		RETURNING id, created_at
+	`, file.FileID, file.Platform, file.AccountID, file.UserID, file.APIKeyID, file.Filename, file.MimeType,
+		file.Purpose, file.SizeBytes, expiresAt).Scan(&file.ID, &file.CreatedAt)
+}
+
+func (r *gatewayFileRepository) GetByFileID(ctx context.Context, fileID string) (*service.GatewayFile, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+gatewayFileSelectColumns+" FROM gateway_files WHERE file_id = $1", fileID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	files, err := scanGatewayFiles(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, service.ErrGatewayFileNotFound
+	}
+	return &files[0], nil
+}
+
+func (r *gatewayFileRepository) GetByFileIDs(ctx context.Context, fileIDs []string) ([]service.GatewayFile, error) {
+	if len(fileIDs) == 0 {
+		return nil, nil
+	}
+	rows, err := r.db.QueryContext(ctx, "SELECT "+gatewayFileSelectColumns+" FROM gateway_files WHERE file_id = ANY($1)", pq.Array(fileIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	return scanGatewayFiles(rows)
+}
+
+func (r *gatewayFileRepository) List(ctx context.Context, userID int64, platform string, params service.GatewayFileListParams) ([]service.GatewayFile, bool, error) {
+	limit := params.Limit
+	where := "user_id = $1 AND platform = $2 AND (expires_at IS NULL OR expires_at > NOW())"
+	order := "id DESC"
+	args := []any{userID, platform}
+
+	if params.Purpose != "" {
+		args = append(args, params.Purpose)
+		where += fmt.Sprintf(" AND purpose = $%d", len(args))
+	}
+	switch {
+	case params.AfterID != "":
+		// after_id：列表（新→旧）中位于游标之后，即更早上传的文件
+		args = append(args, params.AfterID)
+		where += fmt.Sprintf(" AND id < (SELECT id FROM gateway_files WHERE file_id = $%d AND user_id = $1)", len(args))
+	case params.BeforeID != "":
+		// before_id：位于游标之前，即更晚上传的文件；先按升序取最近的一页再反转
+		args = append(args, params.BeforeID)
+		where += fmt.Sprintf(" AND id > (SELECT id FROM gateway_files WHERE file_id = $%d AND user_id = $1)", len(args))
+		order = "id ASC"
+	}
+	args = append(args, limit+1)
+
+	query := "SELECT " + gatewayFileSelectColumns + " FROM gateway_files WHERE " + where +
+		" ORDER BY " + order + fmt.Sprintf(" LIMIT $%d", len(args))
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	files, err := scanGatewayFiles(rows)
+	if err != nil {
+		return nil, false, err
+	}
+	hasMore := len(files) > limit
+	if hasMore {
+		files = files[:limit]
+	}
+	if params.BeforeID != "" && params.AfterID == "" {
+		for i, j := 0, len(files)-1; i < j; i, j = i+1, j-1 {
+			files[i], files[j] = files[j], files[i]
+		}
+	}
+	return files, hasMore, nil
+}
+
+func (r *gatewayFileRepository) Delete(ctx context.Context, fileID string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM gateway_files WHERE file_id = $1", fileID)
+	return err
+}
+
+func (r *gatewayFileRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM gateway_files WHERE expires_at IS NOT NULL AND expires_at <= $1", now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func scanGatewayFiles(rows *sql.Rows) ([]service.GatewayFile, error) {
+	files := make([]service.GatewayFile, 0)
+	for rows.Next() {
+		var (
+			f         service.GatewayFile
+			expiresAt sql.NullTime
+		)
+		if err := rows.Scan(
+			&f.ID,
+			&f.FileID,
+			&f.Platform,
+			&f.AccountID,
+			&f.UserID,
+			&f.APIKeyID,
+			&f.Filename,
+			&f.MimeType,
+			&f.Purpose,
+			&f.SizeBytes,
+			&expiresAt,
+			&f.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		if expiresAt.Valid {
+			t := expiresAt.Time
+			f.ExpiresAt = &t
+		}
+		files = append(files, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
@@ -50,7 +50,13 @@ func (r *groupRepository) Create(ctx context.Context, groupIn *service.Group) er
 		SetDefaultValidityDays(groupIn.DefaultValidityDays).
 		SetClaudeCodeOnly(groupIn.ClaudeCodeOnly).
 		SetNillableFallbackGroupID(groupIn.FallbackGroupID).
-		SetModelRoutingEnabled(groupIn.ModelRoutingEnabled)
+		SetModelRoutingEnabled(groupIn.ModelRoutingEnabled).
+		SetModelMapping(nonNilStringMap(groupIn.ModelMapping)).
+		SetStickyPolicy(groupStickyPolicy(groupIn)).
+		SetStickyTTLSeconds(groupIn.StickyTTLSeconds).
+		SetAccountTagSelector(groupIn.AccountTagSelector).
+		SetModelRoutingTags(nonNilStringMap(groupIn.ModelRoutingTags)).
+		SetCostRoutingPolicy(groupIn.CostRoutingPolicy)
 
 	// 设置模型路由配置
 	if groupIn.ModelRouting != nil {
@@ -108,7 +114,13 @@ func (r *groupRepository) Update(ctx context.Context, groupIn *service.Group) er
 		SetNillableImagePrice4k(groupIn.ImagePrice4K).
 		SetDefaultValidityDays(groupIn.DefaultValidityDays).
 		SetClaudeCodeOnly(groupIn.ClaudeCodeOnly).
-		SetModelRoutingEnabled(groupIn.ModelRoutingEnabled)
+		SetModelRoutingEnabled(groupIn.ModelRoutingEnabled).
+		SetModelMapping(nonNilStringMap(groupIn.ModelMapping)).
+		SetStickyPolicy(groupStickyPolicy(groupIn)).
+		SetStickyTTLSeconds(groupIn.StickyTTLSeconds).
+		SetAccountTagSelector(groupIn.AccountTagSelector).
+		SetModelRoutingTags(nonNilStringMap(groupIn.ModelRoutingTags)).
+		SetCostRoutingPolicy(groupIn.CostRoutingPolicy)
 
 	// 处理 FallbackGroupID：nil 时清除，否则设置
 	if groupIn.FallbackGroupID != nil {
@@ -425,3 +437,18 @@ func (r *groupRepository) loadAccountCounts(ctx context.Context, groupIDs []int6
 
 	return counts, nil
 }
+
+func nonNilStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return map[string]string{}
+	}
+	return m
+}
+
+// groupStickyPolicy 未配置粘性会话策略时使用默认的按内容哈希
+func groupStickyPolicy(groupIn *service.Group) string {
+	if groupIn.StickyPolicy == "" {
+		return service.StickyPolicyContentHash
+	}
+	return groupIn.StickyPolicy
+}
@@ -113,6 +113,53 @@ func (s *GroupRepoSuite) TestUpdate() {
 	s.Require().Equal("updated", got.Name)
 }
 
+func (s *GroupRepoSuite) TestRoutingFields_RoundTrip() {
+	group := &service.Group{
+		Name:               "routing",
+		Platform:           service.PlatformAnthropic,
+		RateMultiplier:     1.0,
+		Status:             service.StatusActive,
+		SubscriptionType:   service.SubscriptionTypeStandard,
+		ModelMapping:       map[string]string{"gemini-*": "claude-haiku-4-5"},
+		StickyPolicy:       service.StickyPolicyAPIKey,
+		StickyTTLSeconds:   600,
+		AccountTagSelector: "tier:max,!region:eu",
+		ModelRoutingTags:   map[string]string{"claude-opus-*": "tier:max"},
+		CostRoutingPolicy:  service.CostRoutingPolicyLowestCost,
+	}
+	s.Require().NoError(s.repo.Create(s.ctx, group))
+
+	active, err := s.repo.ListActive(s.ctx)
+	s.Require().NoError(err)
+	var listed *service.Group
+	for i := range active {
+		if active[i].ID == group.ID {
+			listed = &active[i]
+		}
+	}
+	s.Require().NotNil(listed)
+	s.Require().Equal(group.ModelMapping, listed.ModelMapping)
+	s.Require().Equal(service.StickyPolicyAPIKey, listed.StickyPolicy)
+	s.Require().Equal(600, listed.StickyTTLSeconds)
+	s.Require().Equal("tier:max,!region:eu", listed.AccountTagSelector)
+	s.Require().Equal(group.ModelRoutingTags, listed.ModelRoutingTags)
+	s.Require().Equal(service.CostRoutingPolicyLowestCost, listed.CostRoutingPolicy)
+
+	// 清空后恢复默认值
+	group.ModelMapping = nil
+	group.StickyPolicy = ""
+	group.ModelRoutingTags = nil
+	group.CostRoutingPolicy = ""
+	s.Require().NoError(s.repo.Update(s.ctx, group))
+
+	got, err := s.repo.GetByIDLite(s.ctx, group.ID)
+	s.Require().NoError(err)
+	s.Require().Nil(got.ModelMapping)
+	s.Require().Nil(got.ModelRoutingTags)
+	s.Require().Equal(service.StickyPolicyContentHash, got.StickyPolicy)
+	s.Require().Empty(got.CostRoutingPolicy)
+}
+
 func (s *GroupRepoSuite) TestDelete() {
 	group := &service.Group{
 		Name:             "to-delete",
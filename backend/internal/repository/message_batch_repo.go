@@ -0,0 +1,431 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+// messageBatchInsertChunk 批量写入请求时每条 INSERT 的行数
+const messageBatchInsertChunk = 500
+
+type messageBatchRepository struct {
+	db *sql.DB
+}
+
+func NewMessageBatchRepository(db *sql.DB) service.MessageBatchRepository {
+	return &messageBatchRepository{db: db}
+}
+
+func (r *messageBatchRepository) CreateBatch(ctx context.Context, batch *service.MessageBatch, items []service.MessageBatchItem) error {
+	if batch == nil {
+		return nil
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO message_batches (batch_id, user_id, api_key_id, group_id, processing_status, expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+		RETURNING id
+	`, batch.BatchID, batch.UserID, batch.APIKeyID, nullInt64(batch.GroupID), batch.ProcessingStatus, batch.ExpiresAt, batch.CreatedAt).Scan(&batch.ID)
+	if err != nil {
+		return err
+	}
+
+	for start := 0; start < len(items); start += messageBatchInsertChunk {
+		end := start + messageBatchInsertChunk
+		if end > len(items) {
+			end = len(items)
+		}
+		chunk := items[start:end]
+		values := make([]string, 0, len(chunk))
+		args := make([]any, 0, len(chunk)*3+1)
+		args = append(args, batch.ID)
+		for i := range chunk {
+			base := len(args)
+			values = append(values, fmt.Sprintf("($1, $%d, $%d, $%d)", base+1, base+2, base+3))
+			args = append(args, chunk[i].CustomID, []byte(chunk[i].Params), chunk[i].Status)
+		}
+		query := "INSERT INTO message_batch_items (batch_id, custom_id, params, status) VALUES " + strings.Join(values, ", ")
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// messageBatchSelectColumns 查询批次及其各状态请求数
+const messageBatchSelectColumns = `
+	b.id, b.batch_id, b.user_id, b.api_key_id, b.group_id, b.processing_status,
+	b.cancel_initiated_at, b.ended_at, b.expires_at, b.created_at, b.updated_at,
+	COALESCE(c.processing, 0), COALESCE(c.succeeded, 0), COALESCE(c.errored, 0),
+	COALESCE(c.canceled, 0), COALESCE(c.expired, 0)
+`
+
+const messageBatchCountsJoin = `
+	LEFT JOIN LATERAL (
+		SELECT
+			COUNT(*) FILTER (WHERE status IN ('pending', 'running')) AS processing,
+			COUNT(*) FILTER (WHERE status = 'succeeded') AS succeeded,
+			COUNT(*) FILTER (WHERE status = 'errored') AS errored,
+			COUNT(*) FILTER (WHERE status = 'canceled') AS canceled,
+			COUNT(*) FILTER (WHERE status = 'expired') AS expired
+		FROM message_batch_items
+		WHERE batch_id = b.id
+	) c ON TRUE
+`
+
+func (r *messageBatchRepository) GetBatch(ctx context.Context, userID int64, batchID string) (*service.MessageBatch, error) {
+	query := "SELECT " + messageBatchSelectColumns + " FROM message_batches b " + messageBatchCountsJoin +
+		" WHERE b.batch_id = $1 AND b.user_id = $2"
+	rows, err := r.db.QueryContext(ctx, query, batchID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	batches, err := scanMessageBatches(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(batches) == 0 {
+		return nil, service.ErrMessageBatchNotFound
+	}
+	return &batches[0], nil
+}
+
+func (r *messageBatchRepository) ListBatches(ctx context.Context, userID int64, params service.MessageBatchListParams) ([]service.MessageBatch, bool, error) {
+	limit := params.Limit
+	where := "b.user_id = $1"
+	order := "b.id DESC"
+	args := []any{userID}
+
+	switch {
+	case params.AfterID != "":
+		// after_id：列表（新→旧）中位于游标之后，即更早创建的批次
+		where += " AND b.id < (SELECT id FROM message_batches WHERE batch_id = $2 AND user_id = $1)"
+		args = append(args, params.AfterID)
+	case params.BeforeID != "":
+		// before_id：位于游标之前，即更晚创建的批次；先按升序取最近的一页再反转
+		where += " AND b.id > (SELECT id FROM message_batches WHERE batch_id = $2 AND user_id = $1)"
+		order = "b.id ASC"
+		args = append(args, params.BeforeID)
+	}
+	args = append(args, limit+1)
+
+	query := "SELECT " + messageBatchSelectColumns + " FROM message_batches b " + messageBatchCountsJoin +
+		" WHERE " + where + " ORDER BY " + order + fmt.Sprintf(" LIMIT $%d", len(args))
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, false, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	batches, err := scanMessageBatches(rows)
+	if err != nil {
+		return nil, false, err
+	}
+	hasMore := len(batches) > limit
+	if hasMore {
+		batches = batches[:limit]
+	}
+	if params.BeforeID != "" {
+		for i, j := 0, len(batches)-1; i < j; i, j = i+1, j-1 {
+			batches[i], batches[j] = batches[j], batches[i]
+		}
+	}
+	return batches, hasMore, nil
+}
+
+func scanMessageBatches(rows *sql.Rows) ([]service.MessageBatch, error) {
+	batches := make([]service.MessageBatch, 0)
+	for rows.Next() {
+		var (
+			b                 service.MessageBatch
+			groupID           sql.NullInt64
+			cancelInitiatedAt sql.NullTime
+			endedAt           sql.NullTime
+		)
+		if err := rows.Scan(
+			&b.ID,
+			&b.BatchID,
+			&b.UserID,
+			&b.APIKeyID,
+			&groupID,
+			&b.ProcessingStatus,
+			&cancelInitiatedAt,
+			&endedAt,
+			&b.ExpiresAt,
+			&b.CreatedAt,
+			&b.UpdatedAt,
+			&b.RequestCounts.Processing,
+			&b.RequestCounts.Succeeded,
+			&b.RequestCounts.Errored,
+			&b.RequestCounts.Canceled,
+			&b.RequestCounts.Expired,
+		); err != nil {
+			return nil, err
+		}
+		if groupID.Valid {
+			v := groupID.Int64
+			b.GroupID = &v
+		}
+		if cancelInitiatedAt.Valid {
+			t := cancelInitiatedAt.Time
+			b.CancelInitiatedAt = &t
+		}
+		if endedAt.Valid {
+			t := endedAt.Time
+			b.EndedAt = &t
+		}
+		batches = append(batches, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return batches, nil
+}
+
+func (r *messageBatchRepository) CancelBatch(ctx context.Context, batchID int64, canceledResult json.RawMessage) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE message_batches
+		SET processing_status = $1,
+			cancel_initiated_at = NOW(),
+			updated_at = NOW()
+		WHERE id = $2 AND processing_status = $3
+	`, service.MessageBatchStatusCanceling, batchID, service.MessageBatchStatusInProgress)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE message_batch_items
+		SET status = $1,
+			result = $2,
+			finished_at = NOW(),
+			updated_at = NOW()
+		WHERE batch_id = $3 AND status = $4
+	`, service.MessageBatchItemStatusCanceled, []byte(canceledResult), batchID, service.MessageBatchItemStatusPending); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *messageBatchRepository) ListItemResults(ctx context.Context, batchID int64, afterItemID int64, limit int) ([]service.MessageBatchItem, error) {
+	if limit <= 0 {
+		limit = 500
+	}
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, custom_id, status, result
+		FROM message_batch_items
+		WHERE batch_id = $1 AND id > $2
+		ORDER BY id ASC
+		LIMIT $3
+	`, batchID, afterItemID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	items := make([]service.MessageBatchItem, 0, limit)
+	for rows.Next() {
+		item := service.MessageBatchItem{BatchID: batchID}
+		var result []byte
+		if err := rows.Scan(&item.ID, &item.CustomID, &item.Status, &result); err != nil {
+			return nil, err
+		}
+		item.Result = result
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *messageBatchRepository) ClaimPendingItems(ctx context.Context, limit int, staleRunningAfterSeconds int64) ([]service.MessageBatchWorkItem, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+	if staleRunningAfterSeconds <= 0 {
+		staleRunningAfterSeconds = 1200
+	}
+	query := `
+		WITH next AS (
+			SELECT i.id
+			FROM message_batch_items i
+			JOIN message_batches b ON b.id = i.batch_id
+			WHERE b.processing_status = $1
+				AND b.expires_at > NOW()
+				AND (
+					i.status = $2
+					OR (
+						i.status = $3
+						AND i.started_at IS NOT NULL
+						AND i.started_at < NOW() - ($4 * interval '1 second')
+					)
+				)
+			ORDER BY i.id ASC
+			LIMIT $5
+			FOR UPDATE OF i SKIP LOCKED
+		)
+		UPDATE message_batch_items AS items
+		SET status = $3,
+			started_at = NOW(),
+			updated_at = NOW()
+		FROM next, message_batches b
+		WHERE items.id = next.id AND b.id = items.batch_id
+		RETURNING items.id, items.batch_id, items.custom_id, items.params, items.attempts,
+			b.user_id, b.api_key_id, b.group_id
+	`
+	rows, err := r.db.QueryContext(ctx, query,
+		service.MessageBatchStatusInProgress,
+		service.MessageBatchItemStatusPending,
+		service.MessageBatchItemStatusRunning,
+		staleRunningAfterSeconds,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	items := make([]service.MessageBatchWorkItem, 0, limit)
+	for rows.Next() {
+		var (
+			item    service.MessageBatchWorkItem
+			params  []byte
+			groupID sql.NullInt64
+		)
+		if err := rows.Scan(&item.ID, &item.BatchID, &item.CustomID, &params, &item.Attempts, &item.UserID, &item.APIKeyID, &groupID); err != nil {
+			return nil, err
+		}
+		item.Params = params
+		item.Status = service.MessageBatchItemStatusRunning
+		if groupID.Valid {
+			v := groupID.Int64
+			item.GroupID = &v
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *messageBatchRepository) RequeueItem(ctx context.Context, itemID int64, countAttempt bool) error {
+	increment := 0
+	if countAttempt {
+		increment = 1
+	}
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE message_batch_items
+		SET status = $1,
+			attempts = attempts + $2,
+			started_at = NULL,
+			updated_at = NOW()
+		WHERE id = $3 AND status = $4
+	`, service.MessageBatchItemStatusPending, increment, itemID, service.MessageBatchItemStatusRunning)
+	return err
+}
+
+func (r *messageBatchRepository) FinishItem(ctx context.Context, itemID int64, status string, result json.RawMessage) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE message_batch_items
+		SET status = $1,
+			result = $2,
+			finished_at = NOW(),
+			updated_at = NOW()
+		WHERE id = $3 AND status = $4
+	`, status, []byte(result), itemID, service.MessageBatchItemStatusRunning)
+	return err
+}
+
+func (r *messageBatchRepository) ExpireItems(ctx context.Context, result json.RawMessage) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE message_batch_items AS items
+		SET status = $1,
+			result = $2,
+			finished_at = NOW(),
+			updated_at = NOW()
+		FROM message_batches b
+		WHERE b.id = items.batch_id
+			AND b.processing_status = $3
+			AND b.expires_at <= NOW()
+			AND items.status = $4
+	`, service.MessageBatchItemStatusExpired, []byte(result), service.MessageBatchStatusInProgress, service.MessageBatchItemStatusPending)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (r *messageBatchRepository) CancelPendingItems(ctx context.Context, result json.RawMessage) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE message_batch_items AS items
+		SET status = $1,
+			result = $2,
+			finished_at = NOW(),
+			updated_at = NOW()
+		FROM message_batches b
+		WHERE b.id = items.batch_id
+			AND b.processing_status = $3
+			AND items.status = $4
+	`, service.MessageBatchItemStatusCanceled, []byte(result), service.MessageBatchStatusCanceling, service.MessageBatchItemStatusPending)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (r *messageBatchRepository) FinalizeBatches(ctx context.Context) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE message_batches AS b
+		SET processing_status = $1,
+			ended_at = NOW(),
+			updated_at = NOW()
+		WHERE b.processing_status IN ($2, $3)
+			AND NOT EXISTS (
+				SELECT 1 FROM message_batch_items i
+				WHERE i.batch_id = b.id AND i.status IN ($4, $5)
+			)
+	`, service.MessageBatchStatusEnded,
+		service.MessageBatchStatusInProgress,
+		service.MessageBatchStatusCanceling,
+		service.MessageBatchItemStatusPending,
+		service.MessageBatchItemStatusRunning,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (r *messageBatchRepository) DeleteEndedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `
+		DELETE FROM message_batches
+		WHERE processing_status = $1 AND ended_at < $2
+	`, service.MessageBatchStatusEnded, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/redis/go-redis/v9"
+)
+
+const openaiResponseStateKeyPrefix = "openai:response_state:"
+
+type openaiResponseStateCache struct {
+	rdb *redis.Client
+}
+
+func NewOpenAIResponseStateCache(rdb *redis.Client) service.OpenAIResponseStateCache {
+	return &openaiResponseStateCache{rdb: rdb}
+}
+
+func (c *openaiResponseStateCache) GetResponseState(ctx context.Context, responseID string) (*service.OpenAIResponseState, error) {
+	raw, err := c.rdb.Get(ctx, openaiResponseStateKeyPrefix+responseID).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var state service.OpenAIResponseState
+	if err := json.Unmarshal(raw, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func (c *openaiResponseStateCache) SetResponseState(ctx context.Context, state *service.OpenAIResponseState, ttl time.Duration) error {
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return c.rdb.Set(ctx, openaiResponseStateKeyPrefix+state.ResponseID, raw, ttl).Err()
+}
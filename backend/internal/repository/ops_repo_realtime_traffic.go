@@ -42,7 +42,10 @@ WITH usage_buckets AS (
   SELECT
     date_trunc('minute', ul.created_at) AS bucket,
     COALESCE(COUNT(*), 0) AS success_count,
-    COALESCE(SUM(input_tokens + output_tokens + cache_creation_tokens + cache_read_tokens), 0) AS token_sum
+    COALESCE(SUM(input_tokens + output_tokens + cache_creation_tokens + cache_read_tokens), 0) AS token_sum,
+    COALESCE(SUM(cache_read_tokens), 0) AS cache_read_sum,
+    COALESCE(SUM(cache_creation_tokens), 0) AS cache_creation_sum,
+    COALESCE(COUNT(*) FILTER (WHERE ul.session_hash IS NOT NULL), 0) AS sticky_count
   FROM usage_logs ul
   ` + usageJoin + `
   ` + usageWhere + `
@@ -62,6 +65,9 @@ combined AS (
     COALESCE(u.bucket, e.bucket) AS bucket,
     COALESCE(u.success_count, 0) AS success_count,
     COALESCE(u.token_sum, 0) AS token_sum,
+    COALESCE(u.cache_read_sum, 0) AS cache_read_sum,
+    COALESCE(u.cache_creation_sum, 0) AS cache_creation_sum,
+    COALESCE(u.sticky_count, 0) AS sticky_count,
     COALESCE(e.error_count, 0) AS error_count,
     COALESCE(u.success_count, 0) + COALESCE(e.error_count, 0) AS request_total
   FROM usage_buckets u
@@ -72,7 +78,10 @@ SELECT
   COALESCE(SUM(error_count), 0) AS error_total,
   COALESCE(SUM(token_sum), 0) AS token_total,
   COALESCE(MAX(request_total), 0) AS peak_requests_per_min,
-  COALESCE(MAX(token_sum), 0) AS peak_tokens_per_min
+  COALESCE(MAX(token_sum), 0) AS peak_tokens_per_min,
+  COALESCE(SUM(cache_read_sum), 0) AS cache_read_total,
+  COALESCE(SUM(cache_creation_sum), 0) AS cache_creation_total,
+  COALESCE(SUM(sticky_count), 0) AS sticky_total
 FROM combined`
 
 	args := append(usageArgs, errorArgs...)
@@ -81,12 +90,18 @@ FROM combined`
 	var tokenConsumed int64
 	var peakRequestsPerMin int64
 	var peakTokensPerMin int64
+	var cacheReadTotal int64
+	var cacheCreationTotal int64
+	var stickyTotal int64
 	if err := r.db.QueryRowContext(ctx, q, args...).Scan(
 		&successCount,
 		&errorTotal,
 		&tokenConsumed,
 		&peakRequestsPerMin,
 		&peakTokensPerMin,
+		&cacheReadTotal,
+		&cacheCreationTotal,
+		&stickyTotal,
 	); err != nil {
 		return nil, err
 	}
@@ -125,5 +140,12 @@ FROM combined`
 			Peak:    tpsPeak,
 			Avg:     tpsAvg,
 		},
+		PromptCache: service.OpsPromptCacheSummary{
+			CacheReadTokens:     cacheReadTotal,
+			CacheCreationTokens: cacheCreationTotal,
+			HitRatio:            roundTo4DP(service.PromptCacheHitRatio(cacheReadTotal, cacheCreationTotal)),
+			StickyRequests:      stickyTotal,
+			SuccessRequests:     successCount,
+		},
 	}, nil
 }
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+const pricingOverrideSelectColumns = `id, group_id, model_pattern, input_price, output_price, cache_creation_price, cache_read_price, image_price, effective_from, notes, created_at, updated_at`
+
+type pricingOverrideRepository struct {
+	db *sql.DB
+}
+
+func NewPricingOverrideRepository(db *sql.DB) service.PricingOverrideRepository {
+	return &pricingOverrideRepository{db: db}
+}
+
+func (r *pricingOverrideRepository) List(ctx context.Context, filters service.PricingOverrideFilters) ([]service.PricingOverride, error) {
+	conditions := []string{"deleted_at IS NULL"}
+	args := make([]any, 0, 2)
+	if filters.GlobalOnly {
+		conditions = append(conditions, "group_id IS NULL")
+	} else if filters.GroupID != nil {
+		args = append(args, *filters.GroupID)
+		conditions = append(conditions, "group_id = $"+itoa(len(args)))
+	}
+	if model := strings.TrimSpace(filters.Model); model != "" {
+		args = append(args, "%"+strings.ToLower(model)+"%")
+		conditions = append(conditions, "LOWER(model_pattern) LIKE $"+itoa(len(args)))
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+pricingOverrideSelectColumns+`
+		FROM pricing_overrides
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY group_id NULLS FIRST, model_pattern, effective_from DESC, id DESC
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	overrides := make([]service.PricingOverride, 0)
+	for rows.Next() {
+		o, err := scanPricingOverride(rows)
+		if err != nil {
+			return nil, err
+		}
+		overrides = append(overrides, *o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return overrides, nil
+}
+
+func (r *pricingOverrideRepository) GetByID(ctx context.Context, id int64) (*service.PricingOverride, error) {
+	row := r.db.QueryRowContext(ctx, `
+		SELECT `+pricingOverrideSelectColumns+`
+		FROM pricing_overrides
+		WHERE id = $1 AND deleted_at IS NULL
+	`, id)
+	o, err := scanPricingOverride(row)
+	if err != nil {
+		return nil, translatePersistenceError(err, service.ErrPricingOverrideNotFound, nil)
+	}
+	return o, nil
+}
+
+func (r *pricingOverrideRepository) Create(ctx context.Context, override *service.PricingOverride) error {
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO pricing_overrides (group_id, model_pattern, input_price, output_price, cache_creation_price, cache_read_price, image_price, effective_from, notes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, created_at, updated_at
+	`, nullInt64(override.GroupID), override.ModelPattern, override.InputPrice, override.OutputPrice,
+		override.CacheCreationPrice, override.CacheReadPrice, override.ImagePrice, override.EffectiveFrom, override.Notes).
+		Scan(&override.ID, &override.CreatedAt, &override.UpdatedAt)
+	return translatePersistenceError(err, nil, service.ErrPricingOverrideDuplicate)
+}
+
+func (r *pricingOverrideRepository) Update(ctx context.Context, override *service.PricingOverride) error {
+	err := r.db.QueryRowContext(ctx, `
+		UPDATE pricing_overrides
+		SET group_id = $2, model_pattern = $3, input_price = $4, output_price = $5,
+			cache_creation_price = $6, cache_read_price = $7, image_price = $8, effective_from = $9, notes = $10, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING created_at, updated_at
+	`, override.ID, nullInt64(override.GroupID), override.ModelPattern, override.InputPrice, override.OutputPrice,
+		override.CacheCreationPrice, override.CacheReadPrice, override.ImagePrice, override.EffectiveFrom, override.Notes).
+		Scan(&override.CreatedAt, &override.UpdatedAt)
+	return translatePersistenceError(err, service.ErrPricingOverrideNotFound, service.ErrPricingOverrideDuplicate)
+}
+
+func (r *pricingOverrideRepository) Delete(ctx context.Context, id int64) error {
+	result, err := r.db.ExecContext(ctx, "UPDATE pricing_overrides SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL", id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return service.ErrPricingOverrideNotFound
+	}
+	return nil
+}
+
+type pricingOverrideRow interface {
+	Scan(dest ...any) error
+}
+
+func scanPricingOverride(row pricingOverrideRow) (*service.PricingOverride, error) {
+	var (
+		o          service.PricingOverride
+		groupID    sql.NullInt64
+		imagePrice sql.NullFloat64
+	)
+	if err := row.Scan(&o.ID, &groupID, &o.ModelPattern, &o.InputPrice, &o.OutputPrice, &o.CacheCreationPrice,
+		&o.CacheReadPrice, &imagePrice, &o.EffectiveFrom, &o.Notes, &o.CreatedAt, &o.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if groupID.Valid {
+		o.GroupID = &groupID.Int64
+	}
+	o.ImagePrice = nullFloat64Ptr(imagePrice)
+	return &o, nil
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type promptCacheStatsRepository struct {
+	db *sql.DB
+}
+
+func NewPromptCacheStatsRepository(db *sql.DB) service.PromptCacheStatsRepository {
+	return &promptCacheStatsRepository{db: db}
+}
+
+// buildPromptCacheWhere 构建 usage_logs 过滤条件（别名 ul）
+func buildPromptCacheWhere(filter service.PromptCacheStatsFilter, extra ...string) (string, []any) {
+	clauses := []string{"ul.created_at >= $1", "ul.created_at < $2"}
+	args := []any{filter.StartTime, filter.EndTime}
+	if filter.GroupID > 0 {
+		args = append(args, filter.GroupID)
+		clauses = append(clauses, fmt.Sprintf("ul.group_id = $%d", len(args)))
+	}
+	if filter.AccountID > 0 {
+		args = append(args, filter.AccountID)
+		clauses = append(clauses, fmt.Sprintf("ul.account_id = $%d", len(args)))
+	}
+	clauses = append(clauses, extra...)
+	return "WHERE " + strings.Join(clauses, " AND "), args
+}
+
+func (r *promptCacheStatsRepository) GetAccountStats(ctx context.Context, filter service.PromptCacheStatsFilter) (stats []service.PromptCacheAccountStat, err error) {
+	where, args := buildPromptCacheWhere(filter)
+	args = append(args, filter.Limit)
+	query := `
+		SELECT
+			ul.account_id,
+			COALESCE(a.name, '') AS account_name,
+			COUNT(*) AS requests,
+			COALESCE(SUM(ul.input_tokens), 0) AS input_tokens,
+			COALESCE(SUM(ul.cache_read_tokens), 0) AS cache_read_tokens,
+			COALESCE(SUM(ul.cache_creation_tokens), 0) AS cache_creation_tokens,
+			COUNT(*) FILTER (WHERE ul.session_hash IS NOT NULL) AS sticky_requests,
+			COUNT(DISTINCT ul.session_hash) AS sticky_sessions,
+			COALESCE(SUM(ul.cache_read_tokens) FILTER (WHERE ul.session_hash IS NOT NULL), 0) AS sticky_cache_read_tokens,
+			COALESCE(SUM(ul.cache_creation_tokens) FILTER (WHERE ul.session_hash IS NOT NULL), 0) AS sticky_cache_creation_tokens
+		FROM usage_logs ul
+		LEFT JOIN accounts a ON a.id = ul.account_id
+		` + where + `
+		GROUP BY ul.account_id, a.name
+		ORDER BY SUM(ul.cache_read_tokens + ul.cache_creation_tokens) DESC, ul.account_id ASC
+		LIMIT $` + fmt.Sprint(len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = closeErr
+			stats = nil
+		}
+	}()
+
+	stats = make([]service.PromptCacheAccountStat, 0)
+	for rows.Next() {
+		var s service.PromptCacheAccountStat
+		if err = rows.Scan(
+			&s.AccountID,
+			&s.AccountName,
+			&s.Requests,
+			&s.InputTokens,
+			&s.CacheReadTokens,
+			&s.CacheCreationTokens,
+			&s.StickyRequests,
+			&s.StickySessions,
+			&s.StickyCacheReadTokens,
+			&s.StickyCacheCreationTokens,
+		); err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+func (r *promptCacheStatsRepository) GetSessionStats(ctx context.Context, filter service.PromptCacheStatsFilter) (stats []service.PromptCacheSessionStat, err error) {
+	where, args := buildPromptCacheWhere(filter, "ul.session_hash IS NOT NULL")
+	args = append(args, filter.Limit)
+	query := `
+		SELECT
+			ul.session_hash,
+			COUNT(DISTINCT ul.account_id) AS account_count,
+			(ARRAY_AGG(ul.account_id ORDER BY ul.created_at DESC))[1] AS last_account_id,
+			COUNT(*) AS requests,
+			COALESCE(SUM(ul.input_tokens), 0) AS input_tokens,
+			COALESCE(SUM(ul.cache_read_tokens), 0) AS cache_read_tokens,
+			COALESCE(SUM(ul.cache_creation_tokens), 0) AS cache_creation_tokens,
+			MIN(ul.created_at) AS first_seen_at,
+			MAX(ul.created_at) AS last_seen_at
+		FROM usage_logs ul
+		` + where + `
+		GROUP BY ul.session_hash
+		ORDER BY MAX(ul.created_at) DESC
+		LIMIT $` + fmt.Sprint(len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = closeErr
+			stats = nil
+		}
+	}()
+
+	stats = make([]service.PromptCacheSessionStat, 0)
+	for rows.Next() {
+		var s service.PromptCacheSessionStat
+		if err = rows.Scan(
+			&s.SessionHash,
+			&s.AccountCount,
+			&s.LastAccountID,
+			&s.Requests,
+			&s.InputTokens,
+			&s.CacheReadTokens,
+			&s.CacheCreationTokens,
+			&s.FirstSeenAt,
+			&s.LastSeenAt,
+		); err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	tokenCountDriftKeyPrefix = "token_count:drift:"
+	tokenCountDriftTTL       = 8 * 24 * time.Hour
+)
+
+// 每小时一个 hash，字段为 {family}:{samples|estimated|upstream|abs_error}
+func tokenCountDriftKey(hour time.Time) string {
+	return tokenCountDriftKeyPrefix + hour.UTC().Format("2006010215")
+}
+
+type tokenCountDriftCache struct {
+	rdb *redis.Client
+}
+
+func NewTokenCountDriftCache(rdb *redis.Client) service.TokenCountDriftCache {
+	return &tokenCountDriftCache{rdb: rdb}
+}
+
+func (c *tokenCountDriftCache) RecordTokenCountDrift(ctx context.Context, at time.Time, family string, estimated, upstream int) error {
+	key := tokenCountDriftKey(at.UTC().Truncate(time.Hour))
+	absErr := estimated - upstream
+	if absErr < 0 {
+		absErr = -absErr
+	}
+
+	pipe := c.rdb.TxPipeline()
+	pipe.HIncrBy(ctx, key, family+":samples", 1)
+	pipe.HIncrBy(ctx, key, family+":estimated", int64(estimated))
+	pipe.HIncrBy(ctx, key, family+":upstream", int64(upstream))
+	pipe.HIncrBy(ctx, key, family+":abs_error", int64(absErr))
+	pipe.Expire(ctx, key, tokenCountDriftTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (c *tokenCountDriftCache) GetTokenCountDrift(ctx context.Context, hours []time.Time) ([]service.TokenCountDriftBucket, error) {
+	if len(hours) == 0 {
+		return nil, nil
+	}
+
+	pipe := c.rdb.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, 0, len(hours))
+	for _, hour := range hours {
+		cmds = append(cmds, pipe.HGetAll(ctx, tokenCountDriftKey(hour)))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	buckets := make([]service.TokenCountDriftBucket, 0)
+	for i, cmd := range cmds {
+		fields, err := cmd.Result()
+		if err != nil {
+			continue
+		}
+		byFamily := make(map[string]*service.TokenCountDriftBucket)
+		for field, raw := range fields {
+			idx := strings.LastIndex(field, ":")
+			if idx <= 0 {
+				continue
+			}
+			family, metric := field[:idx], field[idx+1:]
+			b, ok := byFamily[family]
+			if !ok {
+				b = &service.TokenCountDriftBucket{Hour: hours[i].UTC().Truncate(time.Hour), Family: family}
+				byFamily[family] = b
+			}
+			value, _ := strconv.ParseInt(raw, 10, 64)
+			switch metric {
+			case "samples":
+				b.Samples = value
+			case "estimated":
+				b.EstimatedTokens = value
+			case "upstream":
+				b.UpstreamTokens = value
+			case "abs_error":
+				b.AbsErrorTokens = value
+			}
+		}
+		for _, b := range byFamily {
+			buckets = append(buckets, *b)
+		}
+	}
+	return buckets, nil
+}
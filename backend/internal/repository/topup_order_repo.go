@@ -0,0 +1,235 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	dbent "github.com/Wei-Shaw/sub2api/ent"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+const topupOrderSelectColumns = `id, order_no, user_id, order_type, amount, currency, credit_amount, plan_id, group_id, validity_days,
+	provider, provider_order_id, pay_url, status, paid_at, expires_at, created_at, updated_at`
+
+type topupOrderRepository struct {
+	sql sqlExecutor
+}
+
+func NewTopupOrderRepository(sqlDB *sql.DB) service.TopupOrderRepository {
+	return newTopupOrderRepositoryWithSQL(sqlDB)
+}
+
+func newTopupOrderRepositoryWithSQL(sqlq sqlExecutor) *topupOrderRepository {
+	return &topupOrderRepository{sql: sqlq}
+}
+
+// executor 处于事务上下文时使用事务执行，保证订单状态与入账一起提交或回滚
+func (r *topupOrderRepository) executor(ctx context.Context) sqlExecutor {
+	if tx := dbent.TxFromContext(ctx); tx != nil {
+		return tx.Client()
+	}
+	return r.sql
+}
+
+func (r *topupOrderRepository) Create(ctx context.Context, order *service.TopupOrder) error {
+	query := `
+		INSERT INTO topup_orders (
+			order_no, user_id, order_type, amount, currency, credit_amount, plan_id, group_id, validity_days,
+			provider, provider_order_id, pay_url, status, expires_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING id, created_at, updated_at
+	`
+	args := []any{
+		order.OrderNo,
+		order.UserID,
+		order.OrderType,
+		order.Amount,
+		order.Currency,
+		order.CreditAmount,
+		order.PlanID,
+		nullInt64(order.GroupID),
+		order.ValidityDays,
+		order.Provider,
+		order.ProviderOrderID,
+		order.PayURL,
+		order.Status,
+		order.ExpiresAt,
+	}
+	return scanSingleRow(ctx, r.executor(ctx), query, args, &order.ID, &order.CreatedAt, &order.UpdatedAt)
+}
+
+func (r *topupOrderRepository) GetByID(ctx context.Context, id int64) (*service.TopupOrder, error) {
+	return r.getOne(ctx, "id = $1", id)
+}
+
+func (r *topupOrderRepository) GetByOrderNo(ctx context.Context, orderNo string) (*service.TopupOrder, error) {
+	return r.getOne(ctx, "order_no = $1", orderNo)
+}
+
+func (r *topupOrderRepository) getOne(ctx context.Context, where string, arg any) (*service.TopupOrder, error) {
+	rows, err := r.executor(ctx).QueryContext(ctx, "SELECT "+topupOrderSelectColumns+" FROM topup_orders WHERE "+where, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, service.ErrTopupOrderNotFound
+	}
+	order, err := scanTopupOrder(rows)
+	if err != nil {
+		return nil, err
+	}
+	return order, rows.Err()
+}
+
+func (r *topupOrderRepository) List(ctx context.Context, params pagination.PaginationParams, filters service.TopupOrderFilters) ([]service.TopupOrder, *pagination.PaginationResult, error) {
+	conditions := []string{"1 = 1"}
+	args := make([]any, 0, 3)
+	if filters.UserID != nil {
+		args = append(args, *filters.UserID)
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", len(args)))
+	}
+	if filters.Status != "" {
+		args = append(args, filters.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if filters.Provider != "" {
+		args = append(args, filters.Provider)
+		conditions = append(conditions, fmt.Sprintf("provider = $%d", len(args)))
+	}
+	where := strings.Join(conditions, " AND ")
+
+	var total int64
+	if err := scanSingleRow(ctx, r.sql, "SELECT COUNT(*) FROM topup_orders WHERE "+where, args, &total); err != nil {
+		return nil, nil, err
+	}
+	if total == 0 {
+		return []service.TopupOrder{}, paginationResultFromTotal(0, params), nil
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM topup_orders
+		WHERE %s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, topupOrderSelectColumns, where, len(args)+1, len(args)+2)
+	rows, err := r.sql.QueryContext(ctx, query, append(args, params.Limit(), params.Offset())...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	orders := make([]service.TopupOrder, 0)
+	for rows.Next() {
+		order, err := scanTopupOrder(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		orders = append(orders, *order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+	return orders, paginationResultFromTotal(total, params), nil
+}
+
+func (r *topupOrderRepository) SetPayment(ctx context.Context, id int64, providerOrderID, payURL string) error {
+	_, err := r.executor(ctx).ExecContext(ctx, `
+		UPDATE topup_orders
+		SET provider_order_id = $2, pay_url = $3, updated_at = NOW()
+		WHERE id = $1
+	`, id, providerOrderID, payURL)
+	return err
+}
+
+func (r *topupOrderRepository) MarkPaid(ctx context.Context, id int64, providerOrderID string, paidAt time.Time) (bool, error) {
+	result, err := r.executor(ctx).ExecContext(ctx, `
+		UPDATE topup_orders
+		SET status = $2,
+			paid_at = $3,
+			provider_order_id = CASE WHEN $4 = '' THEN provider_order_id ELSE $4 END,
+			updated_at = NOW()
+		WHERE id = $1 AND status <> $2
+	`, id, service.TopupStatusPaid, paidAt, providerOrderID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (r *topupOrderRepository) TransitionStatus(ctx context.Context, id int64, from, to string) (bool, error) {
+	result, err := r.executor(ctx).ExecContext(ctx, `
+		UPDATE topup_orders
+		SET status = $3, updated_at = NOW()
+		WHERE id = $1 AND status = $2
+	`, id, from, to)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (r *topupOrderRepository) ExpirePending(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.executor(ctx).ExecContext(ctx, `
+		UPDATE topup_orders
+		SET status = $1, updated_at = NOW()
+		WHERE status = $2 AND expires_at < $3
+	`, service.TopupStatusExpired, service.TopupStatusPending, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func scanTopupOrder(rows *sql.Rows) (*service.TopupOrder, error) {
+	var (
+		o       service.TopupOrder
+		groupID sql.NullInt64
+		paidAt  sql.NullTime
+	)
+	if err := rows.Scan(
+		&o.ID,
+		&o.OrderNo,
+		&o.UserID,
+		&o.OrderType,
+		&o.Amount,
+		&o.Currency,
+		&o.CreditAmount,
+		&o.PlanID,
+		&groupID,
+		&o.ValidityDays,
+		&o.Provider,
+		&o.ProviderOrderID,
+		&o.PayURL,
+		&o.Status,
+		&paidAt,
+		&o.ExpiresAt,
+		&o.CreatedAt,
+		&o.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	o.GroupID = nullInt64Ptr(groupID)
+	if paidAt.Valid {
+		t := paidAt.Time
+		o.PaidAt = &t
+	}
+	return &o, nil
+}
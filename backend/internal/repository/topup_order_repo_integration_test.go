@@ -0,0 +1,88 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopupOrderRepository_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+	tx := testEntTx(t)
+	client := tx.Client()
+	repo := newTopupOrderRepositoryWithSQL(tx)
+
+	user := mustCreateUser(t, client, &service.User{Email: "topup-" + time.Now().Format(time.RFC3339Nano) + "@example.com"})
+
+	newOrder := func(orderNo string, expiresAt time.Time) *service.TopupOrder {
+		order := &service.TopupOrder{
+			OrderNo:      orderNo,
+			UserID:       user.ID,
+			OrderType:    service.TopupOrderTypeBalance,
+			Amount:       10,
+			Currency:     "USD",
+			CreditAmount: 10,
+			Provider:     service.PaymentProviderMock,
+			Status:       service.TopupStatusPending,
+			ExpiresAt:    expiresAt,
+		}
+		require.NoError(t, repo.Create(ctx, order))
+		require.NotZero(t, order.ID)
+		return order
+	}
+
+	order := newOrder("TP-INTEGRATION-1", time.Now().Add(time.Hour))
+	require.NoError(t, repo.SetPayment(ctx, order.ID, "mock_1", "https://pay.example.com/1"))
+
+	got, err := repo.GetByOrderNo(ctx, order.OrderNo)
+	require.NoError(t, err)
+	require.Equal(t, "mock_1", got.ProviderOrderID)
+	require.Equal(t, "https://pay.example.com/1", got.PayURL)
+	require.Nil(t, got.PaidAt)
+
+	_, err = repo.GetByOrderNo(ctx, "missing")
+	require.ErrorIs(t, err, service.ErrTopupOrderNotFound)
+
+	// 重复回调只有第一次完成状态变更
+	changed, err := repo.MarkPaid(ctx, order.ID, "", time.Now())
+	require.NoError(t, err)
+	require.True(t, changed)
+	changed, err = repo.MarkPaid(ctx, order.ID, "ch_other", time.Now())
+	require.NoError(t, err)
+	require.False(t, changed)
+
+	got, err = repo.GetByID(ctx, order.ID)
+	require.NoError(t, err)
+	require.Equal(t, service.TopupStatusPaid, got.Status)
+	require.Equal(t, "mock_1", got.ProviderOrderID, "empty provider order id keeps the existing one")
+	require.NotNil(t, got.PaidAt)
+
+	changed, err = repo.TransitionStatus(ctx, order.ID, service.TopupStatusPending, service.TopupStatusCancelled)
+	require.NoError(t, err)
+	require.False(t, changed, "paid orders cannot be cancelled")
+
+	stale := newOrder("TP-INTEGRATION-2", time.Now().Add(-time.Minute))
+	expired, err := repo.ExpirePending(ctx, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, int64(1), expired)
+	got, err = repo.GetByID(ctx, stale.ID)
+	require.NoError(t, err)
+	require.Equal(t, service.TopupStatusExpired, got.Status)
+
+	orders, result, err := repo.List(ctx, pagination.PaginationParams{Page: 1, PageSize: 10}, service.TopupOrderFilters{UserID: &user.ID})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), result.Total)
+	require.Len(t, orders, 2)
+	require.Equal(t, stale.ID, orders[0].ID)
+
+	orders, _, err = repo.List(ctx, pagination.PaginationParams{Page: 1, PageSize: 10}, service.TopupOrderFilters{UserID: &user.ID, Status: service.TopupStatusPaid})
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	require.Equal(t, order.ID, orders[0].ID)
+}
@@ -114,14 +114,19 @@ func (r *usageLogRepository) Create(ctx context.Context, log *service.UsageLog)
 			ip_address,
 			image_count,
 			image_size,
-			created_at
+			created_at,
+			session_hash,
+			cost_routing_baseline_factor,
+			cost_routing_chosen_factor,
+			pricing_override_id
 		) VALUES (
 			$1, $2, $3, $4, $5,
 			$6, $7,
 			$8, $9, $10, $11,
 			$12, $13,
 			$14, $15, $16, $17, $18, $19,
-			$20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30
+			$20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30,
+			$31, $32, $33, $34
 		)
 		ON CONFLICT (request_id, api_key_id) DO NOTHING
 		RETURNING id, created_at
@@ -134,6 +139,7 @@ func (r *usageLogRepository) Create(ctx context.Context, log *service.UsageLog)
 	userAgent := nullString(log.UserAgent)
 	ipAddress := nullString(log.IPAddress)
 	imageSize := nullString(log.ImageSize)
+	sessionHash := nullString(log.SessionHash)
 
 	var requestIDArg any
 	if requestID != "" {
@@ -171,6 +177,10 @@ func (r *usageLogRepository) Create(ctx context.Context, log *service.UsageLog)
 		log.ImageCount,
 		imageSize,
 		createdAt,
+		sessionHash,
+		log.CostRoutingBaselineFactor,
+		log.CostRoutingChosenFactor,
+		log.PricingOverrideID,
 	}
 	if err := scanSingleRow(ctx, sqlq, query, args, &log.ID, &log.CreatedAt); err != nil {
 		if errors.Is(err, sql.ErrNoRows) && requestID != "" {
@@ -636,6 +646,33 @@ func (r *usageLogRepository) GetAPIKeyStatsAggregated(ctx context.Context, apiKe
 	return &stats, nil
 }
 
+// GetAPIKeyQuotaUsage 汇总 API Key 的累计/当日/当月消费（actual_cost），用于 Key 限额计数回源
+// 请求数不从用量记录统计（已接纳但失败的请求不落库），由 api_key_request_counts 提供
+func (r *usageLogRepository) GetAPIKeyQuotaUsage(ctx context.Context, apiKeyID int64, dayStart, monthStart time.Time) (*service.APIKeyUsage, error) {
+	query := `
+		SELECT
+			COALESCE(SUM(actual_cost), 0),
+			COALESCE(SUM(actual_cost) FILTER (WHERE created_at >= $2), 0),
+			COALESCE(SUM(actual_cost) FILTER (WHERE created_at >= $3), 0)
+		FROM usage_logs
+		WHERE api_key_id = $1
+	`
+
+	var usage service.APIKeyUsage
+	if err := scanSingleRow(
+		ctx,
+		r.sql,
+		query,
+		[]any{apiKeyID, dayStart, monthStart},
+		&usage.TotalCost,
+		&usage.DailyCost,
+		&usage.MonthlyCost,
+	); err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
+
 // GetAccountStatsAggregated 使用 SQL 聚合统计账号使用数据
 //
 // 性能优化说明：
@@ -2263,6 +2300,14 @@ func nullFloat64Ptr(v sql.NullFloat64) *float64 {
 	return &out
 }
 
+func nullInt64Ptr(v sql.NullInt64) *int64 {
+	if !v.Valid {
+		return nil
+	}
+	out := v.Int64
+	return &out
+}
+
 func nullString(v *string) sql.NullString {
 	if v == nil || *v == "" {
 		return sql.NullString{}
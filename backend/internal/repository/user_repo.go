@@ -57,7 +57,6 @@ func (r *userRepository) Create(ctx context.Context, userIn *service.User) error
 		SetNotes(userIn.Notes).
 		SetPasswordHash(userIn.PasswordHash).
 		SetRole(userIn.Role).
-		SetBalance(userIn.Balance).
 		SetConcurrency(userIn.Concurrency).
 		SetStatus(userIn.Status).
 		Save(ctx)
@@ -69,6 +68,16 @@ func (r *userRepository) Create(ctx context.Context, userIn *service.User) error
 		return err
 	}
 
+	// 初始余额（注册赠送 / 管理员创建时指定）记为 initial 流水，保证流水合计与余额一致
+	if created.Balance != 0 {
+		if _, err := txClient.ExecContext(ctx, `
+			INSERT INTO balance_ledger_entries (user_id, entry_type, amount, balance_after)
+			VALUES ($1, $2, $3, $3)
+		`, created.ID, service.BalanceEntryInitial, created.Balance); err != nil {
+			return err
+		}
+	}
+
 	if tx != nil {
 		if err := tx.Commit(); err != nil {
 			return err
@@ -113,6 +122,7 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*service
 	return out, nil
 }
 
+// Update 更新用户资料；余额不在此写回，余额变更必须经 ApplyBalanceEntry 以保证与余额流水一致
 func (r *userRepository) Update(ctx context.Context, userIn *service.User) error {
 	if userIn == nil {
 		return nil
@@ -318,32 +328,68 @@ func (r *userRepository) filterUsersByAttributes(ctx context.Context, attrs map[
 	return result, nil
 }
 
+// UpdateBalance 调整用户余额（记为 adjustment 流水）
 func (r *userRepository) UpdateBalance(ctx context.Context, id int64, amount float64) error {
-	client := clientFromContext(ctx, r.client)
-	n, err := client.User.Update().Where(dbuser.IDEQ(id)).AddBalance(amount).Save(ctx)
-	if err != nil {
-		return translatePersistenceError(err, service.ErrUserNotFound, nil)
-	}
-	if n == 0 {
-		return service.ErrUserNotFound
-	}
-	return nil
+	return r.ApplyBalanceEntry(ctx, &service.BalanceLedgerEntry{
+		UserID:    id,
+		EntryType: service.BalanceEntryAdjustment,
+		Amount:    amount,
+	})
 }
 
-// DeductBalance 扣除用户余额
+// DeductBalance 扣除用户余额（记为 usage 流水）
 // 透支策略：允许余额变为负数，确保当前请求能够完成
 // 中间件会阻止余额 <= 0 的用户发起后续请求
 func (r *userRepository) DeductBalance(ctx context.Context, id int64, amount float64) error {
-	client := clientFromContext(ctx, r.client)
-	n, err := client.User.Update().
-		Where(dbuser.IDEQ(id)).
-		AddBalance(-amount).
-		Save(ctx)
-	if err != nil {
-		return err
+	return r.ApplyBalanceEntry(ctx, &service.BalanceLedgerEntry{
+		UserID:    id,
+		EntryType: service.BalanceEntryUsage,
+		Amount:    -amount,
+	})
+}
+
+// ApplyBalanceEntry 在同一条语句中调整余额并追加流水：balance_after 取自 UPDATE ... RETURNING，
+// 并发变动下流水与余额仍严格一致。处于事务上下文时随事务提交或回滚。
+func (r *userRepository) ApplyBalanceEntry(ctx context.Context, entry *service.BalanceLedgerEntry) error {
+	if entry == nil {
+		return nil
 	}
-	if n == 0 {
-		return service.ErrUserNotFound
+	sqlq := r.sql
+	if tx := dbent.TxFromContext(ctx); tx != nil {
+		sqlq = tx.Client()
+	}
+
+	query := `
+		WITH updated AS (
+			UPDATE users
+			SET balance = balance + $2, updated_at = NOW()
+			WHERE id = $1 AND deleted_at IS NULL
+			RETURNING id, balance
+		)
+		INSERT INTO balance_ledger_entries (
+			user_id, entry_type, amount, balance_after,
+			usage_log_id, redeem_code_id, promo_code_id, admin_id, notes, topup_order_id
+		)
+		SELECT id, $3, $2, balance, $4, $5, $6, $7, $8, $9
+		FROM updated
+		RETURNING id, balance_after, created_at
+	`
+	args := []any{
+		entry.UserID,
+		entry.Amount,
+		entry.EntryType,
+		nullInt64(entry.UsageLogID),
+		nullInt64(entry.RedeemCodeID),
+		nullInt64(entry.PromoCodeID),
+		nullInt64(entry.AdminID),
+		entry.Notes,
+		nullInt64(entry.TopupOrderID),
+	}
+	if err := scanSingleRow(ctx, sqlq, query, args, &entry.ID, &entry.BalanceAfter, &entry.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return service.ErrUserNotFound
+		}
+		return err
 	}
 	return nil
 }
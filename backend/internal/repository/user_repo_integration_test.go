@@ -140,6 +140,23 @@ func (s *UserRepoSuite) TestUpdate() {
 	s.Require().Equal("updated", updated.Username)
 }
 
+func (s *UserRepoSuite) TestUpdate_DoesNotWriteBackBalance() {
+	user := s.mustCreateUser(&service.User{Email: "update-balance@test.com", Balance: 10})
+
+	stale, err := s.repo.GetByID(s.ctx, user.ID)
+	s.Require().NoError(err)
+	s.Require().NoError(s.repo.DeductBalance(s.ctx, user.ID, 4), "concurrent deduction")
+
+	stale.Username = "edited"
+	stale.Balance = 100
+	s.Require().NoError(s.repo.Update(s.ctx, stale), "Update")
+
+	got, err := s.repo.GetByID(s.ctx, user.ID)
+	s.Require().NoError(err)
+	s.Require().Equal("edited", got.Username)
+	s.Require().InDelta(6.0, got.Balance, 1e-6, "profile edits must not overwrite the balance")
+}
+
 func (s *UserRepoSuite) TestDelete() {
 	user := s.mustCreateUser(&service.User{Email: "delete@test.com"})
 
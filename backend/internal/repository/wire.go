@@ -25,6 +25,11 @@ func ProvideConcurrencyCache(rdb *redis.Client, cfg *config.Config) service.Conc
 	return NewConcurrencyCache(rdb, cfg.Gateway.ConcurrencySlotTTLMinutes, waitTTLSeconds)
 }
 
+// ProvideFairQueueCache 创建账号槽位公平队列缓存，槽位 TTL 与并发控制缓存一致
+func ProvideFairQueueCache(rdb *redis.Client, cfg *config.Config) service.FairQueueCache {
+	return NewFairQueueCache(rdb, cfg.Gateway.ConcurrencySlotTTLMinutes)
+}
+
 // ProvideGitHubReleaseClient 创建 GitHub Release 客户端
 // 从配置中读取代理设置，支持国内服务器通过代理访问 GitHub
 func ProvideGitHubReleaseClient(cfg *config.Config) service.GitHubReleaseClient {
@@ -58,6 +63,15 @@ var ProviderSet = wire.NewSet(
 	NewPromoCodeRepository,
 	NewUsageLogRepository,
 	NewUsageCleanupRepository,
+	NewMessageBatchRepository,
+	NewGatewayFileRepository,
+	NewAccountHealthCheckRepository,
+	NewBalanceLedgerRepository,
+	NewAPIKeyRequestCountRepository,
+	NewPromptCacheStatsRepository,
+	NewCostRoutingReportRepository,
+	NewPricingOverrideRepository,
+	NewTopupOrderRepository,
 	NewDashboardAggregationRepository,
 	NewSettingRepository,
 	NewOpsRepository,
@@ -71,7 +85,11 @@ var ProviderSet = wire.NewSet(
 	NewAPIKeyCache,
 	NewTempUnschedCache,
 	NewTimeoutCounterCache,
+	NewAccountRateBudgetCache,
+	NewAccountLatencyStatsCache,
+	NewAccountCircuitBreakerCache,
 	ProvideConcurrencyCache,
+	ProvideFairQueueCache,
 	ProvideSessionLimitCache,
 	NewDashboardCache,
 	NewEmailCache,
@@ -82,6 +100,8 @@ var ProviderSet = wire.NewSet(
 	NewSchedulerCache,
 	NewSchedulerOutboxRepository,
 	NewProxyLatencyCache,
+	NewTokenCountDriftCache,
+	NewOpenAIResponseStateCache,
 	NewTotpCache,
 
 	// Encryptors
@@ -64,6 +64,52 @@ func RegisterAdminRoutes(
 
 		// 用户属性管理
 		registerUserAttributeRoutes(admin, h)
+
+		// 调度诊断
+		registerSchedulerRoutes(admin, h)
+
+		// 余额流水对账
+		registerBalanceLedgerRoutes(admin, h)
+
+		// 自定义价格
+		registerPricingOverrideRoutes(admin, h)
+
+		// 充值订单
+		registerTopupRoutes(admin, h)
+	}
+}
+
+func registerTopupRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	orders := admin.Group("/topup-orders")
+	{
+		orders.GET("", h.Admin.Topup.List)
+	}
+}
+
+func registerPricingOverrideRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	overrides := admin.Group("/pricing-overrides")
+	{
+		overrides.GET("", h.Admin.PricingOverride.List)
+		overrides.GET("/:id", h.Admin.PricingOverride.GetByID)
+		overrides.POST("", h.Admin.PricingOverride.Create)
+		overrides.PUT("/:id", h.Admin.PricingOverride.Update)
+		overrides.DELETE("/:id", h.Admin.PricingOverride.Delete)
+	}
+}
+
+func registerBalanceLedgerRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	ledger := admin.Group("/balance-ledger")
+	{
+		ledger.GET("/reconciliation", h.Admin.BalanceLedger.GetReconciliation)
+		ledger.POST("/reconciliation/run", h.Admin.BalanceLedger.RunReconciliation)
+	}
+}
+
+func registerSchedulerRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	scheduler := admin.Group("/scheduler")
+	{
+		scheduler.POST("/explain", h.Admin.Scheduler.Explain)
+		scheduler.GET("/cost-routing/report", h.Admin.Scheduler.GetCostRoutingReport)
 	}
 }
 
@@ -143,6 +189,9 @@ func registerOpsRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 		ops.GET("/dashboard/latency-histogram", h.Admin.Ops.GetDashboardLatencyHistogram)
 		ops.GET("/dashboard/error-trend", h.Admin.Ops.GetDashboardErrorTrend)
 		ops.GET("/dashboard/error-distribution", h.Admin.Ops.GetDashboardErrorDistribution)
+
+		// count_tokens 本地估算偏差
+		ops.GET("/token-count-drift", h.Admin.Ops.GetTokenCountDrift)
 	}
 }
 
@@ -153,6 +202,8 @@ func registerDashboardRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 		dashboard.GET("/realtime", h.Admin.Dashboard.GetRealtimeMetrics)
 		dashboard.GET("/trend", h.Admin.Dashboard.GetUsageTrend)
 		dashboard.GET("/models", h.Admin.Dashboard.GetModelStats)
+		dashboard.GET("/cache-stats", h.Admin.Dashboard.GetPromptCacheStats)
+		dashboard.GET("/cache-stats/sessions", h.Admin.Dashboard.GetPromptCacheSessionStats)
 		dashboard.GET("/api-keys-trend", h.Admin.Dashboard.GetAPIKeyUsageTrend)
 		dashboard.GET("/users-trend", h.Admin.Dashboard.GetUserUsageTrend)
 		dashboard.POST("/users-usage", h.Admin.Dashboard.GetBatchUsersUsage)
@@ -170,6 +221,7 @@ func registerUserManagementRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 		users.PUT("/:id", h.Admin.User.Update)
 		users.DELETE("/:id", h.Admin.User.Delete)
 		users.POST("/:id/balance", h.Admin.User.UpdateBalance)
+		users.GET("/:id/balance-ledger", h.Admin.BalanceLedger.ListUserEntries)
 		users.GET("/:id/api-keys", h.Admin.User.GetUserAPIKeys)
 		users.GET("/:id/usage", h.Admin.User.GetUserUsage)
 
@@ -189,6 +241,7 @@ func registerGroupRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 		groups.PUT("/:id", h.Admin.Group.Update)
 		groups.DELETE("/:id", h.Admin.Group.Delete)
 		groups.GET("/:id/stats", h.Admin.Group.GetStats)
+		groups.GET("/:id/models", h.Admin.Group.GetModels)
 		groups.GET("/:id/api-keys", h.Admin.Group.GetGroupAPIKeys)
 	}
 }
@@ -206,6 +259,7 @@ func registerAccountRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 		accounts.POST("/:id/refresh", h.Admin.Account.Refresh)
 		accounts.POST("/:id/refresh-tier", h.Admin.Account.RefreshTier)
 		accounts.GET("/:id/stats", h.Admin.Account.GetStats)
+		accounts.GET("/:id/health-checks", h.Admin.Account.GetHealthChecks)
 		accounts.POST("/:id/clear-error", h.Admin.Account.ClearError)
 		accounts.GET("/:id/usage", h.Admin.Account.GetUsage)
 		accounts.GET("/:id/today-stats", h.Admin.Account.GetTodayStats)
@@ -32,10 +32,38 @@ func RegisterGatewayRoutes(
 	{
 		gateway.POST("/messages", h.Gateway.Messages)
 		gateway.POST("/messages/count_tokens", h.Gateway.CountTokens)
+		// Message Batches API（网关侧持久化并由后台执行器低优先级执行）
+		gateway.POST("/messages/batches", h.MessageBatch.Create)
+		gateway.GET("/messages/batches", h.MessageBatch.List)
+		gateway.GET("/messages/batches/:batch_id", h.MessageBatch.Get)
+		gateway.POST("/messages/batches/:batch_id/cancel", h.MessageBatch.Cancel)
+		gateway.GET("/messages/batches/:batch_id/results", h.MessageBatch.Results)
+		// Files API（透传到上传账号，按分组平台使用 Anthropic 或 OpenAI 格式）
+		gateway.POST("/files", h.Files.Upload)
+		gateway.GET("/files", h.Files.List)
+		gateway.GET("/files/:file_id", h.Files.Get)
+		gateway.DELETE("/files/:file_id", h.Files.Delete)
+		gateway.GET("/files/:file_id/content", h.Files.Content)
 		gateway.GET("/models", h.Gateway.Models)
 		gateway.GET("/usage", h.Gateway.Usage)
 		// OpenAI Responses API
 		gateway.POST("/responses", h.OpenAIGateway.Responses)
+		// OpenAI Chat Completions API（按分组平台转换到 Responses 或 Claude Messages）
+		gateway.POST("/chat/completions", func(c *gin.Context) {
+			if apiKey, ok := middleware.GetAPIKeyFromContext(c); ok && apiKey.Group != nil && apiKey.Group.Platform == service.PlatformOpenAI {
+				h.OpenAIGateway.ChatCompletions(c)
+				return
+			}
+			h.Gateway.ChatCompletions(c)
+		})
+		// OpenAI Embeddings API（OpenAI 分组透传，Gemini 分组转换为 batchEmbedContents）
+		gateway.POST("/embeddings", func(c *gin.Context) {
+			if apiKey, ok := middleware.GetAPIKeyFromContext(c); ok && apiKey.Group != nil && apiKey.Group.Platform == service.PlatformOpenAI {
+				h.OpenAIGateway.Embeddings(c)
+				return
+			}
+			h.Gateway.Embeddings(c)
+		})
 	}
 
 	// Gemini 原生 API 兼容层（Gemini SDK/CLI 直连）
@@ -67,6 +95,7 @@ func RegisterGatewayRoutes(
 	{
 		antigravityV1.POST("/messages", h.Gateway.Messages)
 		antigravityV1.POST("/messages/count_tokens", h.Gateway.CountTokens)
+		antigravityV1.POST("/chat/completions", h.Gateway.ChatCompletions)
 		antigravityV1.GET("/models", h.Gateway.AntigravityModels)
 		antigravityV1.GET("/usage", h.Gateway.Usage)
 	}
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/Wei-Shaw/sub2api/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterPaymentRoutes 注册支付回调路由（公开接口，由各支付渠道自行校验签名）
+func RegisterPaymentRoutes(v1 *gin.RouterGroup, h *handler.Handlers) {
+	payment := v1.Group("/payment")
+	{
+		payment.POST("/webhook/:provider", h.Topup.HandleWebhook)
+	}
+}
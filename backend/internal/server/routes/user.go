@@ -22,6 +22,8 @@ func RegisterUserRoutes(
 			user.GET("/profile", h.User.GetProfile)
 			user.PUT("/password", h.User.ChangePassword)
 			user.PUT("", h.User.UpdateProfile)
+			user.GET("/balance-ledger", h.User.GetBalanceLedger)
+			user.GET("/balance-holds", h.User.GetBalanceHolds)
 
 			// TOTP 双因素认证
 			totp := user.Group("/totp")
@@ -79,5 +81,15 @@ func RegisterUserRoutes(
 			subscriptions.GET("/progress", h.Subscription.GetProgress)
 			subscriptions.GET("/summary", h.Subscription.GetSummary)
 		}
+
+		// 充值订单
+		topup := authenticated.Group("/topup")
+		{
+			topup.GET("/options", h.Topup.GetOptions)
+			topup.GET("/orders", h.Topup.ListOrders)
+			topup.POST("/orders", h.Topup.CreateOrder)
+			topup.GET("/orders/:order_no", h.Topup.GetOrder)
+			topup.POST("/orders/:order_no/cancel", h.Topup.CancelOrder)
+		}
 	}
 }
@@ -43,10 +43,18 @@ type Account struct {
 	SessionWindowEnd    *time.Time
 	SessionWindowStatus string
 
+	// Tags 账号标签（小写、去重排序），供分组与模型路由的标签选择器匹配
+	Tags []string
+
+	// AvailabilitySchedule 可用时间窗口，nil 表示始终可用
+	AvailabilitySchedule *AvailabilitySchedule
+
 	Proxy         *Proxy
 	AccountGroups []AccountGroup
 	GroupIDs      []int64
 	Groups        []*Group
+	// TagGroupIDs 通过分组标签选择器自动加入的分组（不含显式绑定）
+	TagGroupIDs []int64
 }
 
 type TempUnschedulableRule struct {
@@ -91,6 +99,9 @@ func (a *Account) IsSchedulable() bool {
 	if a.TempUnschedulableUntil != nil && now.Before(*a.TempUnschedulableUntil) {
 		return false
 	}
+	if !a.IsWithinAvailabilitySchedule(now) {
+		return false
+	}
 	return true
 }
 
@@ -486,6 +497,15 @@ func (a *Account) IsOpenAIApiKey() bool {
 	return a.IsOpenAI() && a.Type == AccountTypeAPIKey
 }
 
+// SupportsEmbeddings 仅 OpenAI / Gemini 的 API Key 账号可转发 embeddings 请求
+// （ChatGPT OAuth、Code Assist、Antigravity 上游均无 embeddings 接口）
+func (a *Account) SupportsEmbeddings() bool {
+	if a.Type != AccountTypeAPIKey {
+		return false
+	}
+	return a.Platform == PlatformOpenAI || a.Platform == PlatformGemini
+}
+
 func (a *Account) GetOpenAIBaseURL() string {
 	if !a.IsOpenAI() {
 		return ""
@@ -570,10 +590,11 @@ func (a *Account) IsOpenAITokenExpired() bool {
 	return time.Now().Add(60 * time.Second).After(*expiresAt)
 }
 
-// IsMixedSchedulingEnabled 检查 antigravity 账户是否启用混合调度
-// 启用后可参与 anthropic/gemini 分组的账户调度
+// IsMixedSchedulingEnabled 检查账户是否启用混合调度
+// antigravity 账户启用后可参与 anthropic/gemini 分组的账户调度；
+// openai 账户启用后可参与 anthropic 分组的账户调度（Messages 请求转换为 Responses 请求）
 func (a *Account) IsMixedSchedulingEnabled() bool {
-	if a.Platform != PlatformAntigravity {
+	if a.Platform != PlatformAntigravity && a.Platform != PlatformOpenAI {
 		return false
 	}
 	if a.Extra == nil {
@@ -587,6 +608,33 @@ func (a *Account) IsMixedSchedulingEnabled() bool {
 	return false
 }
 
+// IsAllowedInMixedScheduling 检查账户在混合调度模式下能否参与原生平台的调度
+// 原生平台直接通过；antigravity 需启用混合调度；openai 需启用混合调度且仅限 anthropic 分组
+func (a *Account) IsAllowedInMixedScheduling(nativePlatform string) bool {
+	if a.Platform == nativePlatform {
+		return true
+	}
+	if !a.IsMixedSchedulingEnabled() {
+		return false
+	}
+	switch a.Platform {
+	case PlatformAntigravity:
+		return true
+	case PlatformOpenAI:
+		return nativePlatform == PlatformAnthropic
+	default:
+		return false
+	}
+}
+
+// MixedSchedulingPlatforms 返回混合调度模式下需要查询的账户平台列表
+func MixedSchedulingPlatforms(nativePlatform string) []string {
+	if nativePlatform == PlatformAnthropic {
+		return []string{nativePlatform, PlatformAntigravity, PlatformOpenAI}
+	}
+	return []string{nativePlatform, PlatformAntigravity}
+}
+
 // WindowCostSchedulability 窗口费用调度状态
 type WindowCostSchedulability int
 
@@ -682,6 +730,35 @@ func (a *Account) GetMaxSessions() int {
 	return 0
 }
 
+// GetRPMLimit 获取每分钟请求数预算（extra.rpm_limit）
+// 返回 0 表示未启用
+func (a *Account) GetRPMLimit() int {
+	if a.Extra == nil {
+		return 0
+	}
+	if v, ok := a.Extra["rpm_limit"]; ok {
+		return parseExtraInt(v)
+	}
+	return 0
+}
+
+// GetTPMLimit 获取每分钟 token 预算（extra.tpm_limit）
+// 返回 0 表示未启用
+func (a *Account) GetTPMLimit() int {
+	if a.Extra == nil {
+		return 0
+	}
+	if v, ok := a.Extra["tpm_limit"]; ok {
+		return parseExtraInt(v)
+	}
+	return 0
+}
+
+// HasRateBudget 是否配置了 RPM/TPM 预算
+func (a *Account) HasRateBudget() bool {
+	return a.GetRPMLimit() > 0 || a.GetTPMLimit() > 0
+}
+
 // GetSessionIdleTimeoutMinutes 获取会话空闲超时分钟数
 // 默认值为 5 分钟
 func (a *Account) GetSessionIdleTimeoutMinutes() int {
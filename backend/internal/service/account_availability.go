@@ -0,0 +1,240 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
+)
+
+// 账号可用时间窗口
+//
+// 部分账号在工作时间与人工共用、只在夜间供网关使用，或只在周末借用。
+// 配置了时间窗口的账号只在窗口内参与调度；未配置表示始终可用。
+// 窗口按周配置：days 为星期（mon..sun，空表示每天），start/end 为 HH:MM，
+// end <= start 表示跨零点（如 19:00-08:00），end 可写 24:00 表示当天结束。
+// 时区为空时使用服务器时区（pkg/timezone）。
+
+const maxAvailabilityWindows = 32
+
+// availabilityLookahead 计算下一次切换时间的最大向后查找范围（覆盖完整一周及跨零点窗口）
+const availabilityLookahead = 8 * 24 * time.Hour
+
+var availabilityWeekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// AvailabilitySchedule 账号可用时间表
+type AvailabilitySchedule struct {
+	Timezone string               `json:"timezone,omitempty"`
+	Windows  []AvailabilityWindow `json:"windows"`
+}
+
+// AvailabilityWindow 每周重复的可用时间窗口
+type AvailabilityWindow struct {
+	Days  []string `json:"days,omitempty"`
+	Start string   `json:"start"`
+	End   string   `json:"end"`
+}
+
+// NormalizeAvailabilitySchedule 校验并规范化时间表，没有窗口时返回 nil（始终可用）
+func NormalizeAvailabilitySchedule(schedule *AvailabilitySchedule) (*AvailabilitySchedule, error) {
+	if schedule == nil || len(schedule.Windows) == 0 {
+		return nil, nil
+	}
+	if len(schedule.Windows) > maxAvailabilityWindows {
+		return nil, fmt.Errorf("too many availability windows: max %d", maxAvailabilityWindows)
+	}
+	out := &AvailabilitySchedule{Timezone: strings.TrimSpace(schedule.Timezone)}
+	if out.Timezone != "" {
+		if _, err := time.LoadLocation(out.Timezone); err != nil {
+			return nil, fmt.Errorf("invalid timezone %q", out.Timezone)
+		}
+	}
+	for i, w := range schedule.Windows {
+		nw := AvailabilityWindow{
+			Start: strings.TrimSpace(w.Start),
+			End:   strings.TrimSpace(w.End),
+		}
+		if _, err := parseClock(nw.Start, false); err != nil {
+			return nil, fmt.Errorf("windows[%d].start: %w", i, err)
+		}
+		if _, err := parseClock(nw.End, true); err != nil {
+			return nil, fmt.Errorf("windows[%d].end: %w", i, err)
+		}
+		seen := make(map[time.Weekday]struct{}, len(w.Days))
+		for _, raw := range w.Days {
+			day := strings.ToLower(strings.TrimSpace(raw))
+			weekday, ok := availabilityWeekdays[day]
+			if !ok {
+				return nil, fmt.Errorf("windows[%d].days: invalid day %q", i, raw)
+			}
+			if _, dup := seen[weekday]; dup {
+				continue
+			}
+			seen[weekday] = struct{}{}
+			nw.Days = append(nw.Days, day)
+		}
+		sort.Slice(nw.Days, func(a, b int) bool {
+			return availabilityWeekdays[nw.Days[a]] < availabilityWeekdays[nw.Days[b]]
+		})
+		out.Windows = append(out.Windows, nw)
+	}
+	return out, nil
+}
+
+// parseClock 解析 HH:MM，返回距零点的分钟数；allowEndOfDay 时允许 24:00
+func parseClock(value string, allowEndOfDay bool) (int, error) {
+	if len(value) != 5 || value[2] != ':' {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	for _, i := range []int{0, 1, 3, 4} {
+		if value[i] < '0' || value[i] > '9' {
+			return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+		}
+	}
+	hour := int(value[0]-'0')*10 + int(value[1]-'0')
+	minute := int(value[3]-'0')*10 + int(value[4]-'0')
+	if allowEndOfDay && hour == 24 && minute == 0 {
+		return 24 * 60, nil
+	}
+	if hour > 23 || minute > 59 {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	return hour*60 + minute, nil
+}
+
+var availabilityLocations sync.Map // map[string]*time.Location
+
+// location 返回时间表时区，未配置或无效时使用服务器时区
+func (s *AvailabilitySchedule) location() *time.Location {
+	if s.Timezone == "" {
+		return timezone.Location()
+	}
+	if cached, ok := availabilityLocations.Load(s.Timezone); ok {
+		return cached.(*time.Location)
+	}
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return timezone.Location()
+	}
+	availabilityLocations.Store(s.Timezone, loc)
+	return loc
+}
+
+type availabilityInterval struct {
+	start time.Time
+	end   time.Time
+}
+
+// intervals 返回 [from-1天, from+days天] 内所有窗口的具体起止时间
+func (s *AvailabilitySchedule) intervals(from time.Time, days int) []availabilityInterval {
+	loc := s.location()
+	local := from.In(loc)
+	var out []availabilityInterval
+	for offset := -1; offset <= days; offset++ {
+		y, m, d := local.AddDate(0, 0, offset).Date()
+		weekday := time.Date(y, m, d, 12, 0, 0, 0, loc).Weekday()
+		for _, w := range s.Windows {
+			if !w.includes(weekday) {
+				continue
+			}
+			startMin, err := parseClock(w.Start, false)
+			if err != nil {
+				continue
+			}
+			endMin, err := parseClock(w.End, true)
+			if err != nil {
+				continue
+			}
+			start := time.Date(y, m, d, startMin/60, startMin%60, 0, 0, loc)
+			endDay := d
+			if endMin <= startMin {
+				endDay++
+			}
+			end := time.Date(y, m, endDay, endMin/60, endMin%60, 0, 0, loc)
+			out = append(out, availabilityInterval{start: start, end: end})
+		}
+	}
+	return out
+}
+
+func (w AvailabilityWindow) includes(weekday time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, day := range w.Days {
+		if availabilityWeekdays[strings.ToLower(day)] == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAvailableAt 判断给定时间是否落在任一可用窗口内（无窗口视为始终可用）
+func (s *AvailabilitySchedule) IsAvailableAt(t time.Time) bool {
+	if s == nil || len(s.Windows) == 0 {
+		return true
+	}
+	for _, iv := range s.intervals(t, 0) {
+		if !t.Before(iv.start) && t.Before(iv.end) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextTransition 返回 t 之后可用状态发生切换的最近时间，始终可用或始终不可用时返回 nil
+func (s *AvailabilitySchedule) NextTransition(t time.Time) *time.Time {
+	if s == nil || len(s.Windows) == 0 {
+		return nil
+	}
+	days := int(availabilityLookahead / (24 * time.Hour))
+	var boundaries []time.Time
+	for _, iv := range s.intervals(t, days) {
+		if iv.start.After(t) {
+			boundaries = append(boundaries, iv.start)
+		}
+		if iv.end.After(t) {
+			boundaries = append(boundaries, iv.end)
+		}
+	}
+	sort.Slice(boundaries, func(i, j int) bool { return boundaries[i].Before(boundaries[j]) })
+
+	current := s.IsAvailableAt(t)
+	for _, b := range boundaries {
+		if s.IsAvailableAt(b) != current {
+			next := b
+			return &next
+		}
+	}
+	return nil
+}
+
+// IsWithinAvailabilitySchedule 账号当前是否处于可用时间窗口内
+func (a *Account) IsWithinAvailabilitySchedule(now time.Time) bool {
+	if a == nil || a.AvailabilitySchedule == nil {
+		return true
+	}
+	return a.AvailabilitySchedule.IsAvailableAt(now)
+}
+
+// filterAvailableAccounts 过滤掉当前不在可用时间窗口内的账号
+func filterAvailableAccounts(accounts []Account, now time.Time) []Account {
+	filtered := make([]Account, 0, len(accounts))
+	for i := range accounts {
+		if accounts[i].IsWithinAvailabilitySchedule(now) {
+			filtered = append(filtered, accounts[i])
+		}
+	}
+	return filtered
+}
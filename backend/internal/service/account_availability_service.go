@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// AccountAvailabilityService periodically advances due availability window transitions,
+// emitting scheduler outbox events so snapshots flip accounts in and out on time.
+type AccountAvailabilityService struct {
+	accountRepo AccountRepository
+	interval    time.Duration
+	stopCh      chan struct{}
+	stopOnce    sync.Once
+	wg          sync.WaitGroup
+}
+
+func NewAccountAvailabilityService(accountRepo AccountRepository, interval time.Duration) *AccountAvailabilityService {
+	return &AccountAvailabilityService{
+		accountRepo: accountRepo,
+		interval:    interval,
+		stopCh:      make(chan struct{}),
+	}
+}
+
+func (s *AccountAvailabilityService) Start() {
+	if s == nil || s.accountRepo == nil || s.interval <= 0 {
+		return
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		s.runOnce()
+		for {
+			select {
+			case <-ticker.C:
+				s.runOnce()
+			case <-s.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+func (s *AccountAvailabilityService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+	s.wg.Wait()
+}
+
+func (s *AccountAvailabilityService) runOnce() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	advanced, err := s.accountRepo.AdvanceAvailabilityTransitions(ctx, time.Now())
+	if err != nil {
+		log.Printf("[AccountAvailability] Advance availability transitions failed: %v", err)
+		return
+	}
+	if advanced > 0 {
+		log.Printf("[AccountAvailability] Advanced %d availability transitions", advanced)
+	}
+}
@@ -0,0 +1,124 @@
+//go:build unit
+
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	require.NoError(t, err)
+	return loc
+}
+
+func TestNormalizeAvailabilitySchedule(t *testing.T) {
+	schedule, err := NormalizeAvailabilitySchedule(nil)
+	require.NoError(t, err)
+	require.Nil(t, schedule)
+
+	schedule, err = NormalizeAvailabilitySchedule(&AvailabilitySchedule{Windows: []AvailabilityWindow{}})
+	require.NoError(t, err)
+	require.Nil(t, schedule)
+
+	schedule, err = NormalizeAvailabilitySchedule(&AvailabilitySchedule{
+		Timezone: " Asia/Shanghai ",
+		Windows: []AvailabilityWindow{
+			{Days: []string{"FRI", "mon", "fri"}, Start: "19:00", End: "08:00"},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, &AvailabilitySchedule{
+		Timezone: "Asia/Shanghai",
+		Windows:  []AvailabilityWindow{{Days: []string{"mon", "fri"}, Start: "19:00", End: "08:00"}},
+	}, schedule)
+
+	invalid := []*AvailabilitySchedule{
+		{Timezone: "Mars/Base", Windows: []AvailabilityWindow{{Start: "00:00", End: "24:00"}}},
+		{Windows: []AvailabilityWindow{{Days: []string{"someday"}, Start: "00:00", End: "01:00"}}},
+		{Windows: []AvailabilityWindow{{Start: "24:00", End: "01:00"}}},
+		{Windows: []AvailabilityWindow{{Start: "9:00", End: "10:00"}}},
+		{Windows: []AvailabilityWindow{{Start: "09:60", End: "10:00"}}},
+	}
+	for _, s := range invalid {
+		_, err := NormalizeAvailabilitySchedule(s)
+		require.Error(t, err)
+	}
+}
+
+func TestAvailabilitySchedule_NightWindow(t *testing.T) {
+	loc := mustLoadLocation(t, "Asia/Shanghai")
+	schedule := &AvailabilitySchedule{
+		Timezone: "Asia/Shanghai",
+		Windows:  []AvailabilityWindow{{Days: []string{"mon", "tue", "wed", "thu", "fri"}, Start: "19:00", End: "08:00"}},
+	}
+
+	// 2026-10-12 是周一
+	require.False(t, schedule.IsAvailableAt(time.Date(2026, 10, 12, 12, 0, 0, 0, loc)))
+	require.True(t, schedule.IsAvailableAt(time.Date(2026, 10, 12, 19, 0, 0, 0, loc)))
+	require.True(t, schedule.IsAvailableAt(time.Date(2026, 10, 13, 7, 59, 0, 0, loc)))
+	require.False(t, schedule.IsAvailableAt(time.Date(2026, 10, 13, 8, 0, 0, 0, loc)))
+	// 周五晚上的窗口延续到周六早上，周六晚上不可用
+	require.True(t, schedule.IsAvailableAt(time.Date(2026, 10, 17, 3, 0, 0, 0, loc)))
+	require.False(t, schedule.IsAvailableAt(time.Date(2026, 10, 17, 20, 0, 0, 0, loc)))
+	// 周一早上不属于周日的窗口
+	require.False(t, schedule.IsAvailableAt(time.Date(2026, 10, 12, 3, 0, 0, 0, loc)))
+
+	// 同一时刻在 UTC 下判断结果一致
+	require.True(t, schedule.IsAvailableAt(time.Date(2026, 10, 12, 11, 30, 0, 0, time.UTC)))
+
+	next := schedule.NextTransition(time.Date(2026, 10, 12, 12, 0, 0, 0, loc))
+	require.NotNil(t, next)
+	require.True(t, next.Equal(time.Date(2026, 10, 12, 19, 0, 0, 0, loc)))
+
+	next = schedule.NextTransition(time.Date(2026, 10, 17, 3, 0, 0, 0, loc))
+	require.NotNil(t, next)
+	require.True(t, next.Equal(time.Date(2026, 10, 17, 8, 0, 0, 0, loc)))
+
+	// 周六白天之后的下一次切换是周一晚上
+	next = schedule.NextTransition(time.Date(2026, 10, 17, 9, 0, 0, 0, loc))
+	require.NotNil(t, next)
+	require.True(t, next.Equal(time.Date(2026, 10, 19, 19, 0, 0, 0, loc)))
+}
+
+func TestAvailabilitySchedule_AdjacentWindowsMerge(t *testing.T) {
+	schedule := &AvailabilitySchedule{
+		Timezone: "UTC",
+		Windows: []AvailabilityWindow{
+			{Days: []string{"sat"}, Start: "00:00", End: "24:00"},
+			{Days: []string{"sun"}, Start: "00:00", End: "24:00"},
+		},
+	}
+	// 2026-10-17 是周六；周六 24:00 与周日 00:00 相接，不算切换
+	next := schedule.NextTransition(time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC))
+	require.NotNil(t, next)
+	require.True(t, next.Equal(time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)))
+
+	always := &AvailabilitySchedule{Timezone: "UTC", Windows: []AvailabilityWindow{{Start: "00:00", End: "00:00"}}}
+	require.True(t, always.IsAvailableAt(time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)))
+	require.Nil(t, always.NextTransition(time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)))
+}
+
+func TestAccountIsSchedulable_AvailabilityWindow(t *testing.T) {
+	now := time.Now().UTC()
+	closed := &Account{
+		Status:      StatusActive,
+		Schedulable: true,
+		AvailabilitySchedule: &AvailabilitySchedule{
+			Timezone: "UTC",
+			Windows:  []AvailabilityWindow{{Start: now.Add(2 * time.Hour).Format("15:04"), End: now.Add(3 * time.Hour).Format("15:04")}},
+		},
+	}
+	require.False(t, closed.IsSchedulable())
+
+	open := &Account{ID: 2, Status: StatusActive, Schedulable: true}
+	require.True(t, open.IsSchedulable())
+
+	filtered := filterAvailableAccounts([]Account{*closed, *open}, now)
+	require.Len(t, filtered, 1)
+	require.Equal(t, int64(2), filtered[0].ID)
+}
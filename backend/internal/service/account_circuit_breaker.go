@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/usagestats"
+)
+
+// 熔断状态
+const (
+	CircuitStateClosed   = "closed"
+	CircuitStateOpen     = "open"
+	CircuitStateHalfOpen = "half_open"
+)
+
+// circuitBreakerRuleIndex 熔断触发的临时不可调度记录使用的规则索引（区别于用户配置的规则）
+const circuitBreakerRuleIndex = -1
+
+// CircuitBreakerSettings 熔断参数（由配置转换，传给缓存脚本）
+type CircuitBreakerSettings struct {
+	WindowSeconds        int
+	MinRequests          int
+	FailureRatio         float64
+	OpenSeconds          int
+	ProbeIntervalSeconds int
+	HalfOpenSuccesses    int
+}
+
+// CircuitBreakerState 账号熔断状态快照
+type CircuitBreakerState struct {
+	State          string
+	Requests       int64 // 滚动窗口内请求数（仅 closed 状态计数）
+	Failures       int64 // 滚动窗口内失败数
+	OpenedAtUnix   int64
+	OpenUntilUnix  int64
+	ProbeSuccesses int64 // 半开状态下已连续成功的探测数
+	Trips          int64 // 累计熔断次数
+}
+
+// AccountCircuitBreakerCache 账号熔断状态（Redis）
+type AccountCircuitBreakerCache interface {
+	// RecordCircuitResult 记录一次请求结果并推进状态机，返回记录后的状态与是否发生状态切换
+	RecordCircuitResult(ctx context.Context, accountID int64, failed bool, settings CircuitBreakerSettings) (*CircuitBreakerState, bool, error)
+	// PeekCircuitPermits 批量只读判断账号是否可调度：open 或半开且探测名额已被领取时拒绝，不领取名额
+	PeekCircuitPermits(ctx context.Context, accountIDs []int64) (map[int64]bool, error)
+	// AcquireCircuitPermit 为选中的账号领取放行名额：open 拒绝；half_open 按间隔放行一个探测请求
+	AcquireCircuitPermit(ctx context.Context, accountID int64, settings CircuitBreakerSettings) (bool, error)
+	// GetCircuitStatesBatch 批量读取熔断状态（只读，不放行探测）
+	GetCircuitStatesBatch(ctx context.Context, accountIDs []int64, settings CircuitBreakerSettings) (map[int64]*CircuitBreakerState, error)
+	// ResetCircuit 清除熔断状态与窗口计数
+	ResetCircuit(ctx context.Context, accountID int64) error
+}
+
+// AccountCircuitBreakerService 账号级滚动窗口熔断器。
+//
+// RateLimitService 只对单次响应做出反应，持续少量 5xx/超时的账号仍会接到流量。
+// 熔断器在窗口内失败比例超过阈值时将账号标记为临时不可调度（经 outbox 同步到调度快照），
+// 到期后进入半开状态，按间隔放行探测请求，连续成功后恢复。
+type AccountCircuitBreakerService struct {
+	cache            AccountCircuitBreakerCache
+	accountRepo      AccountRepository
+	tempUnschedCache TempUnschedCache
+	cfg              *config.Config
+}
+
+// NewAccountCircuitBreakerService creates a new AccountCircuitBreakerService
+func NewAccountCircuitBreakerService(cache AccountCircuitBreakerCache, accountRepo AccountRepository, tempUnschedCache TempUnschedCache, cfg *config.Config) *AccountCircuitBreakerService {
+	return &AccountCircuitBreakerService{
+		cache:            cache,
+		accountRepo:      accountRepo,
+		tempUnschedCache: tempUnschedCache,
+		cfg:              cfg,
+	}
+}
+
+func (s *AccountCircuitBreakerService) enabled() bool {
+	return s != nil && s.cache != nil && s.cfg != nil && s.cfg.Gateway.CircuitBreaker.Enabled
+}
+
+func (s *AccountCircuitBreakerService) settings() CircuitBreakerSettings {
+	c := s.cfg.Gateway.CircuitBreaker
+	return CircuitBreakerSettings{
+		WindowSeconds:        c.WindowSeconds,
+		MinRequests:          c.MinRequests,
+		FailureRatio:         c.FailureRatio,
+		OpenSeconds:          c.OpenSeconds,
+		ProbeIntervalSeconds: c.ProbeIntervalSeconds,
+		HalfOpenSuccesses:    c.HalfOpenSuccesses,
+	}
+}
+
+// RecordSuccess 记录一次成功请求
+func (s *AccountCircuitBreakerService) RecordSuccess(ctx context.Context, account *Account) {
+	s.record(ctx, account, false, "")
+}
+
+// RecordFailure 记录一次上游失败（5xx、超时、请求失败）
+func (s *AccountCircuitBreakerService) RecordFailure(ctx context.Context, account *Account, reason string) {
+	s.record(ctx, account, true, reason)
+}
+
+func (s *AccountCircuitBreakerService) record(ctx context.Context, account *Account, failed bool, reason string) {
+	if !s.enabled() || account == nil || account.ID <= 0 {
+		return
+	}
+	state, changed, err := s.cache.RecordCircuitResult(ctx, account.ID, failed, s.settings())
+	if err != nil {
+		slog.Warn("circuit_breaker_record_failed", "account_id", account.ID, "error", err)
+		return
+	}
+	if !changed || state == nil {
+		return
+	}
+	switch state.State {
+	case CircuitStateOpen:
+		s.markOpen(ctx, account, state, reason)
+	case CircuitStateClosed:
+		slog.Info("circuit_breaker_closed", "account_id", account.ID)
+	}
+}
+
+// markOpen 熔断时写入临时不可调度（账号仓储会写入调度 outbox 事件，快照随之剔除该账号）
+func (s *AccountCircuitBreakerService) markOpen(ctx context.Context, account *Account, state *CircuitBreakerState, reason string) {
+	until := time.Unix(state.OpenUntilUnix, 0)
+	tempState := &TempUnschedState{
+		UntilUnix:       state.OpenUntilUnix,
+		TriggeredAtUnix: state.OpenedAtUnix,
+		RuleIndex:       circuitBreakerRuleIndex,
+		ErrorMessage:    fmt.Sprintf("circuit breaker open: %d/%d requests failed", state.Failures, state.Requests),
+	}
+	if state.Requests == 0 {
+		tempState.ErrorMessage = "circuit breaker open: half-open probe failed"
+	}
+	if reason != "" {
+		tempState.ErrorMessage += " (last: " + reason + ")"
+	}
+
+	raw, _ := json.Marshal(tempState)
+	if err := s.accountRepo.SetTempUnschedulable(ctx, account.ID, until, string(raw)); err != nil {
+		slog.Warn("circuit_breaker_set_temp_unsched_failed", "account_id", account.ID, "error", err)
+	}
+	if s.tempUnschedCache != nil {
+		if err := s.tempUnschedCache.SetTempUnsched(ctx, account.ID, tempState); err != nil {
+			slog.Warn("temp_unsched_cache_set_failed", "account_id", account.ID, "error", err)
+		}
+	}
+	slog.Info("circuit_breaker_open", "account_id", account.ID, "until", until, "requests", state.Requests, "failures", state.Failures)
+}
+
+// BlockedAccounts 返回熔断中（或半开且本轮探测名额已被领取）的账号 ID 集合；只读，不领取探测名额。缓存异常时失败开放
+func (s *AccountCircuitBreakerService) BlockedAccounts(ctx context.Context, accounts []Account) map[int64]struct{} {
+	if !s.enabled() || len(accounts) == 0 {
+		return nil
+	}
+	ids := make([]int64, 0, len(accounts))
+	for i := range accounts {
+		ids = append(ids, accounts[i].ID)
+	}
+	permits, err := s.cache.PeekCircuitPermits(ctx, ids)
+	if err != nil {
+		slog.Warn("circuit_breaker_permits_failed", "error", err)
+		return nil
+	}
+	blocked := make(map[int64]struct{})
+	for _, id := range ids {
+		if allowed, ok := permits[id]; ok && !allowed {
+			blocked[id] = struct{}{}
+		}
+	}
+	return blocked
+}
+
+// IsBlocked 单账号只读熔断检查（非负载感知路径与粘性预检使用）
+func (s *AccountCircuitBreakerService) IsBlocked(ctx context.Context, account *Account) bool {
+	if account == nil {
+		return false
+	}
+	_, blocked := s.BlockedAccounts(ctx, []Account{*account})[account.ID]
+	return blocked
+}
+
+// AcquirePermit 为最终选中并获取到槽位的账号领取放行名额（半开状态下即领取探测名额）
+// 返回 false 表示熔断中或探测名额已被其他请求领取，调用方应释放槽位并改选账号；缓存异常时失败开放。
+func (s *AccountCircuitBreakerService) AcquirePermit(ctx context.Context, account *Account) bool {
+	if !s.enabled() || account == nil {
+		return true
+	}
+	allowed, err := s.cache.AcquireCircuitPermit(ctx, account.ID, s.settings())
+	if err != nil {
+		slog.Warn("circuit_breaker_permit_failed", "account_id", account.ID, "error", err)
+		return true
+	}
+	return allowed
+}
+
+// GetStates 批量读取熔断状态；未启用时返回 nil
+func (s *AccountCircuitBreakerService) GetStates(ctx context.Context, accountIDs []int64) (map[int64]*CircuitBreakerState, error) {
+	if !s.enabled() || len(accountIDs) == 0 {
+		return nil, nil
+	}
+	return s.cache.GetCircuitStatesBatch(ctx, accountIDs, s.settings())
+}
+
+// GetStatus 返回账号熔断状态（账号统计接口使用）
+func (s *AccountCircuitBreakerService) GetStatus(ctx context.Context, accountID int64) (*usagestats.AccountCircuitBreaker, error) {
+	states, err := s.GetStates(ctx, []int64{accountID})
+	if err != nil || states == nil {
+		return nil, err
+	}
+	state := states[accountID]
+	if state == nil {
+		state = &CircuitBreakerState{State: CircuitStateClosed}
+	}
+	status := &usagestats.AccountCircuitBreaker{
+		State:          state.State,
+		WindowRequests: state.Requests,
+		WindowFailures: state.Failures,
+		ProbeSuccesses: state.ProbeSuccesses,
+		Trips:          state.Trips,
+	}
+	if state.Requests > 0 {
+		status.FailureRatio = float64(state.Failures) / float64(state.Requests)
+	}
+	if state.State == CircuitStateOpen && state.OpenUntilUnix > 0 {
+		until := time.Unix(state.OpenUntilUnix, 0)
+		status.OpenUntil = &until
+	}
+	return status, nil
+}
+
+// Reset 清除熔断状态（管理员手动恢复账号时调用）
+func (s *AccountCircuitBreakerService) Reset(ctx context.Context, accountID int64) {
+	if s == nil || s.cache == nil {
+		return
+	}
+	if err := s.cache.ResetCircuit(ctx, accountID); err != nil {
+		slog.Warn("circuit_breaker_reset_failed", "account_id", accountID, "error", err)
+	}
+}
+
+// isCircuitBreakerFailure 判断上游状态码是否计入熔断失败（529 过载由 RateLimitService 单独处理）
+func isCircuitBreakerFailure(statusCode int) bool {
+	return statusCode >= 500 && statusCode != 529
+}
@@ -0,0 +1,197 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+type stubCircuitBreakerCache struct {
+	results  []bool // 记录的结果（true 为失败）
+	next     *CircuitBreakerState
+	changed  bool
+	permits  map[int64]bool
+	probes   map[int64]bool // 半开账号：true 表示探测名额尚未领取
+	states   map[int64]*CircuitBreakerState
+	resetIDs []int64
+}
+
+func (c *stubCircuitBreakerCache) RecordCircuitResult(ctx context.Context, accountID int64, failed bool, settings CircuitBreakerSettings) (*CircuitBreakerState, bool, error) {
+	c.results = append(c.results, failed)
+	if c.next == nil {
+		return &CircuitBreakerState{State: CircuitStateClosed}, false, nil
+	}
+	return c.next, c.changed, nil
+}
+
+func (c *stubCircuitBreakerCache) PeekCircuitPermits(ctx context.Context, accountIDs []int64) (map[int64]bool, error) {
+	out := make(map[int64]bool, len(accountIDs))
+	for _, id := range accountIDs {
+		if available, ok := c.probes[id]; ok {
+			out[id] = available
+		} else if allowed, ok := c.permits[id]; ok {
+			out[id] = allowed
+		}
+	}
+	return out, nil
+}
+
+func (c *stubCircuitBreakerCache) AcquireCircuitPermit(ctx context.Context, accountID int64, settings CircuitBreakerSettings) (bool, error) {
+	if available, ok := c.probes[accountID]; ok {
+		c.probes[accountID] = false
+		return available, nil
+	}
+	allowed, ok := c.permits[accountID]
+	return !ok || allowed, nil
+}
+
+func (c *stubCircuitBreakerCache) GetCircuitStatesBatch(ctx context.Context, accountIDs []int64, settings CircuitBreakerSettings) (map[int64]*CircuitBreakerState, error) {
+	return c.states, nil
+}
+
+func (c *stubCircuitBreakerCache) ResetCircuit(ctx context.Context, accountID int64) error {
+	c.resetIDs = append(c.resetIDs, accountID)
+	return nil
+}
+
+func circuitBreakerTestConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.Gateway.CircuitBreaker = config.GatewayCircuitBreakerConfig{
+		Enabled:              true,
+		WindowSeconds:        60,
+		MinRequests:          20,
+		FailureRatio:         0.5,
+		OpenSeconds:          60,
+		ProbeIntervalSeconds: 5,
+		HalfOpenSuccesses:    3,
+	}
+	return cfg
+}
+
+func TestAccountCircuitBreaker_TripMarksTempUnschedulable(t *testing.T) {
+	repo := &rateLimitAccountRepoStub{}
+	cache := &stubCircuitBreakerCache{
+		next:    &CircuitBreakerState{State: CircuitStateOpen, Requests: 20, Failures: 12, OpenedAtUnix: 1000, OpenUntilUnix: 1060},
+		changed: true,
+	}
+	breaker := NewAccountCircuitBreakerService(cache, repo, nil, circuitBreakerTestConfig())
+
+	breaker.RecordFailure(context.Background(), &Account{ID: 7}, "upstream 500")
+	require.Equal(t, []bool{true}, cache.results)
+	require.Equal(t, 1, repo.tempCalls)
+
+	// 未发生状态切换时不重复写入
+	cache.changed = false
+	breaker.RecordFailure(context.Background(), &Account{ID: 7}, "upstream 500")
+	require.Equal(t, 1, repo.tempCalls)
+}
+
+func TestAccountCircuitBreaker_Disabled(t *testing.T) {
+	cache := &stubCircuitBreakerCache{permits: map[int64]bool{1: false}}
+	cfg := circuitBreakerTestConfig()
+	cfg.Gateway.CircuitBreaker.Enabled = false
+	breaker := NewAccountCircuitBreakerService(cache, &rateLimitAccountRepoStub{}, nil, cfg)
+
+	breaker.RecordFailure(context.Background(), &Account{ID: 1}, "")
+	require.Empty(t, cache.results)
+	require.Nil(t, breaker.BlockedAccounts(context.Background(), []Account{{ID: 1}}))
+
+	var nilBreaker *AccountCircuitBreakerService
+	require.False(t, nilBreaker.IsBlocked(context.Background(), &Account{ID: 1}))
+	nilBreaker.RecordSuccess(context.Background(), &Account{ID: 1})
+	require.True(t, nilBreaker.AcquirePermit(context.Background(), &Account{ID: 1}))
+}
+
+func TestAccountCircuitBreaker_ChecksDoNotConsumeProbe(t *testing.T) {
+	cache := &stubCircuitBreakerCache{probes: map[int64]bool{5: true}}
+	breaker := NewAccountCircuitBreakerService(cache, &rateLimitAccountRepoStub{}, nil, circuitBreakerTestConfig())
+	account := &Account{ID: 5}
+
+	// 粘性预检与候选筛选都只读，不领取半开探测名额
+	require.False(t, breaker.IsBlocked(context.Background(), account))
+	require.Empty(t, breaker.BlockedAccounts(context.Background(), []Account{*account}))
+
+	// 仅最终选中的请求领取名额，之后其他请求视为被拦截
+	require.True(t, breaker.AcquirePermit(context.Background(), account))
+	require.False(t, breaker.AcquirePermit(context.Background(), account))
+	require.True(t, breaker.IsBlocked(context.Background(), account))
+}
+
+func TestAccountCircuitBreaker_BlockedAccountsAndStatus(t *testing.T) {
+	cache := &stubCircuitBreakerCache{
+		permits: map[int64]bool{1: true, 2: false},
+		states: map[int64]*CircuitBreakerState{
+			2: {State: CircuitStateOpen, OpenUntilUnix: 2000, Trips: 1},
+			3: {State: CircuitStateClosed, Requests: 10, Failures: 4},
+		},
+	}
+	breaker := NewAccountCircuitBreakerService(cache, &rateLimitAccountRepoStub{}, nil, circuitBreakerTestConfig())
+
+	blocked := breaker.BlockedAccounts(context.Background(), []Account{{ID: 1}, {ID: 2}})
+	require.Equal(t, map[int64]struct{}{2: {}}, blocked)
+	require.True(t, breaker.IsBlocked(context.Background(), &Account{ID: 2}))
+
+	status, err := breaker.GetStatus(context.Background(), 2)
+	require.NoError(t, err)
+	require.Equal(t, CircuitStateOpen, status.State)
+	require.NotNil(t, status.OpenUntil)
+
+	status, err = breaker.GetStatus(context.Background(), 3)
+	require.NoError(t, err)
+	require.InDelta(t, 0.4, status.FailureRatio, 1e-9)
+
+	// 无记录视为 closed
+	status, err = breaker.GetStatus(context.Background(), 4)
+	require.NoError(t, err)
+	require.Equal(t, CircuitStateClosed, status.State)
+}
+
+func TestRateLimitService_FeedsCircuitBreaker(t *testing.T) {
+	repo := &rateLimitAccountRepoStub{}
+	cache := &stubCircuitBreakerCache{}
+	svc := NewRateLimitService(repo, nil, &config.Config{}, nil, nil)
+	svc.SetCircuitBreakerService(NewAccountCircuitBreakerService(cache, repo, nil, circuitBreakerTestConfig()))
+	account := &Account{ID: 9, Platform: PlatformAnthropic, Type: AccountTypeAPIKey}
+
+	svc.HandleUpstreamError(context.Background(), account, http.StatusBadGateway, http.Header{}, []byte("bad gateway"))
+	svc.HandleUpstreamError(context.Background(), account, http.StatusBadRequest, http.Header{}, []byte("bad request"))
+	svc.HandleUpstreamRequestError(context.Background(), account, errors.New("dial tcp: i/o timeout"))
+	svc.HandleUpstreamSuccess(context.Background(), account)
+	require.Equal(t, []bool{true, true, false}, cache.results)
+
+	require.NoError(t, svc.ClearTempUnschedulable(context.Background(), account.ID))
+	require.Equal(t, []int64{9}, cache.resetIDs)
+}
+
+func TestOpenAISelectAccountWithLoadAwareness_HalfOpenProbeClaimedOnce(t *testing.T) {
+	groupID := int64(1)
+	repo := stubOpenAIAccountRepo{
+		accounts: []Account{
+			{ID: 1, Platform: PlatformOpenAI, Status: StatusActive, Schedulable: true, Concurrency: 1, Priority: 1},
+			{ID: 2, Platform: PlatformOpenAI, Status: StatusActive, Schedulable: true, Concurrency: 1, Priority: 2},
+		},
+	}
+	breakerCache := &stubCircuitBreakerCache{probes: map[int64]bool{1: true}}
+	svc := &OpenAIGatewayService{
+		accountRepo:        repo,
+		cache:              &stubGatewayCache{sessionBindings: map[string]int64{"openai:sticky": 1}},
+		concurrencyService: NewConcurrencyService(stubConcurrencyCache{}),
+		circuitBreaker:     NewAccountCircuitBreakerService(breakerCache, &rateLimitAccountRepoStub{}, nil, circuitBreakerTestConfig()),
+	}
+
+	// 粘性预检与候选筛选不消耗探测名额，半开账号仍能作为探测请求被选中
+	selection, err := svc.SelectAccountWithLoadAwareness(context.Background(), &groupID, "sticky", "gpt-4", nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), selection.Account.ID)
+
+	// 名额已被领取，后续请求改选其他账号
+	selection, err = svc.SelectAccountWithLoadAwareness(context.Background(), &groupID, "sticky", "gpt-4", nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), selection.Account.ID)
+}
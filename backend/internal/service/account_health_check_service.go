@@ -0,0 +1,479 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	accountHealthCheckJobName = "account_health_check"
+
+	accountHealthCheckLeaderLockKey    = "account_health_check:leader"
+	accountHealthCheckLeaderLockTTLMin = 5 * time.Minute
+
+	// accountHealthCheckErrorPrefix 巡检标记错误时写入的错误信息前缀，自动恢复只作用于带此前缀的账号，
+	// 不会覆盖管理员或 RateLimitService 标记的错误。
+	accountHealthCheckErrorPrefix = "Health check failed: "
+
+	// accountHealthCheckErrorListLimit 单轮最多拉取的错误账号数（用于自动恢复）
+	accountHealthCheckErrorListLimit = 1000
+
+	accountHealthCheckMaxErrorLen = 1024
+)
+
+// 巡检触发方式
+const (
+	AccountHealthCheckTriggerScheduled = "scheduled"
+)
+
+var accountHealthCheckReleaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+  return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// AccountHealthCheck 单次巡检记录
+type AccountHealthCheck struct {
+	ID           int64     `json:"id"`
+	AccountID    int64     `json:"account_id"`
+	Platform     string    `json:"platform"`
+	Success      bool      `json:"success"`
+	LatencyMs    int64     `json:"latency_ms"`
+	ErrorMessage string    `json:"error_message,omitempty"`
+	Trigger      string    `json:"trigger"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// AccountHealthTimeline 账号健康时间线（按时间倒序）
+type AccountHealthTimeline struct {
+	AccountID     int64                `json:"account_id"`
+	Total         int                  `json:"total"`
+	Failures      int                  `json:"failures"`
+	SuccessRate   float64              `json:"success_rate"`
+	AvgLatencyMs  int64                `json:"avg_latency_ms"`
+	LastCheckedAt *time.Time           `json:"last_checked_at,omitempty"`
+	Checks        []AccountHealthCheck `json:"checks"`
+}
+
+// AccountHealthCheckRepository 巡检记录存储
+type AccountHealthCheckRepository interface {
+	Create(ctx context.Context, check *AccountHealthCheck) error
+	// ListByAccount 按时间倒序返回 since 之后的记录
+	ListByAccount(ctx context.Context, accountID int64, since time.Time, limit int) ([]AccountHealthCheck, error)
+	// ListRecentResults 按时间倒序返回最近 limit 次巡检是否成功
+	ListRecentResults(ctx context.Context, accountID int64, limit int) ([]bool, error)
+	DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+// accountConnectionTester 发送最小测试请求（由 AccountTestService 实现）
+type accountConnectionTester interface {
+	RunHeadlessTest(ctx context.Context, accountID int64, modelID string) error
+}
+
+// AccountHealthCheckService 账号定时健康巡检。
+//
+// 空闲账号长时间没有真实流量，过期的 OAuth token 或被吊销的 API Key 往往要等到用户请求失败才会暴露。
+// 巡检按间隔对空闲账号发送最小测试请求，记录结果与延迟；连续失败达到阈值时将账号标记为错误，
+// 之后巡检成功则自动恢复。多实例部署时通过 Redis 选主，仅一个实例执行。
+type AccountHealthCheckService struct {
+	accountRepo AccountRepository
+	checkRepo   AccountHealthCheckRepository
+	tester      accountConnectionTester
+	opsService  *OpsService
+	redisClient *redis.Client
+	cfg         *config.Config
+
+	instanceID string
+
+	distributedLockOn bool
+	warnNoRedisOnce   sync.Once
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stopCtx   context.Context
+	stop      context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+// NewAccountHealthCheckService creates a new AccountHealthCheckService
+func NewAccountHealthCheckService(
+	accountRepo AccountRepository,
+	checkRepo AccountHealthCheckRepository,
+	accountTestService *AccountTestService,
+	opsService *OpsService,
+	redisClient *redis.Client,
+	cfg *config.Config,
+) *AccountHealthCheckService {
+	lockOn := cfg == nil || strings.TrimSpace(cfg.RunMode) != config.RunModeSimple
+	svc := &AccountHealthCheckService{
+		accountRepo:       accountRepo,
+		checkRepo:         checkRepo,
+		opsService:        opsService,
+		redisClient:       redisClient,
+		cfg:               cfg,
+		instanceID:        uuid.NewString(),
+		distributedLockOn: lockOn,
+	}
+	if accountTestService != nil {
+		svc.tester = accountTestService
+	}
+	return svc
+}
+
+func (s *AccountHealthCheckService) enabled() bool {
+	return s != nil && s.cfg != nil && s.cfg.HealthCheck.Enabled &&
+		s.accountRepo != nil && s.checkRepo != nil && s.tester != nil
+}
+
+func (s *AccountHealthCheckService) interval() time.Duration {
+	return time.Duration(s.cfg.HealthCheck.IntervalMinutes) * time.Minute
+}
+
+func (s *AccountHealthCheckService) Start() {
+	s.StartWithContext(context.Background())
+}
+
+func (s *AccountHealthCheckService) StartWithContext(ctx context.Context) {
+	if !s.enabled() {
+		return
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	s.startOnce.Do(func() {
+		s.stopCtx, s.stop = context.WithCancel(ctx)
+		s.wg.Add(1)
+		go s.run()
+		log.Printf("[AccountHealthCheck] Service started (interval: %v, idle: %dm, concurrency: %d)",
+			s.interval(), s.cfg.HealthCheck.IdleMinutes, s.cfg.HealthCheck.Concurrency)
+	})
+}
+
+func (s *AccountHealthCheckService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		if s.stop != nil {
+			s.stop()
+		}
+	})
+	s.wg.Wait()
+}
+
+func (s *AccountHealthCheckService) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.interval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.runOnce()
+		case <-s.stopCtx.Done():
+			return
+		}
+	}
+}
+
+// accountHealthCheckRunStats 单轮巡检统计
+type accountHealthCheckRunStats struct {
+	checked   atomic.Int64
+	failed    atomic.Int64
+	marked    atomic.Int64
+	recovered atomic.Int64
+}
+
+func (s *AccountHealthCheckService) runOnce() {
+	// 单轮不超过一个巡检间隔，避免与下一轮（或其他实例）重叠
+	ctx, cancel := context.WithTimeout(s.stopCtx, s.interval())
+	defer cancel()
+
+	release, ok := s.tryAcquireLeaderLock(ctx)
+	if !ok {
+		return
+	}
+	if release != nil {
+		defer release()
+	}
+
+	startedAt := time.Now().UTC()
+	candidates, err := s.collectCandidates(ctx, time.Now())
+	if err != nil {
+		log.Printf("[AccountHealthCheck] list accounts failed: %v", err)
+		s.recordHeartbeatError(startedAt, time.Since(startedAt), err)
+		return
+	}
+
+	stats := &accountHealthCheckRunStats{}
+	sem := make(chan struct{}, s.cfg.HealthCheck.Concurrency)
+	var wg sync.WaitGroup
+	for i := range candidates {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(account *Account) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			s.checkAccount(ctx, account, stats)
+		}(&candidates[i])
+	}
+	wg.Wait()
+
+	s.pruneHistory(ctx)
+
+	result := fmt.Sprintf("checked=%d failed=%d marked_error=%d recovered=%d",
+		stats.checked.Load(), stats.failed.Load(), stats.marked.Load(), stats.recovered.Load())
+	if stats.checked.Load() > 0 {
+		log.Printf("[AccountHealthCheck] Cycle complete: %s", result)
+	}
+	s.recordHeartbeatSuccess(startedAt, time.Since(startedAt), result)
+}
+
+// collectCandidates 选出本轮巡检账号：可调度的空闲账号，以及（开启自动恢复时）由巡检标记为错误的账号
+func (s *AccountHealthCheckService) collectCandidates(ctx context.Context, now time.Time) ([]Account, error) {
+	active, err := s.accountRepo.ListActive(ctx)
+	if err != nil {
+		return nil, err
+	}
+	idle := time.Duration(s.cfg.HealthCheck.IdleMinutes) * time.Minute
+
+	candidates := make([]Account, 0, len(active))
+	for i := range active {
+		account := &active[i]
+		// 限流/过载/临时不可调度的账号状态已知，无需额外消耗额度巡检
+		if !account.IsSchedulable() {
+			continue
+		}
+		if idle > 0 && account.LastUsedAt != nil && now.Sub(*account.LastUsedAt) < idle {
+			continue
+		}
+		candidates = append(candidates, *account)
+	}
+
+	if !s.cfg.HealthCheck.AutoRecover {
+		return candidates, nil
+	}
+	params := pagination.PaginationParams{Page: 1, PageSize: accountHealthCheckErrorListLimit}
+	errored, _, err := s.accountRepo.ListWithFilters(ctx, params, "", "", StatusError, "")
+	if err != nil {
+		return nil, err
+	}
+	for i := range errored {
+		if errored[i].Schedulable && isHealthCheckError(errored[i].ErrorMessage) {
+			candidates = append(candidates, errored[i])
+		}
+	}
+	return candidates, nil
+}
+
+// checkAccount 巡检单个账号并根据结果标记错误或恢复
+func (s *AccountHealthCheckService) checkAccount(ctx context.Context, account *Account, stats *accountHealthCheckRunStats) {
+	checkCtx, cancel := context.WithTimeout(ctx, time.Duration(s.cfg.HealthCheck.TimeoutSeconds)*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	testErr := s.tester.RunHeadlessTest(checkCtx, account.ID, "")
+	latency := time.Since(start)
+	if ctx.Err() != nil {
+		// 服务停止或本轮超时，结果不可信，不记录
+		return
+	}
+
+	check := &AccountHealthCheck{
+		AccountID: account.ID,
+		Platform:  account.Platform,
+		Success:   testErr == nil,
+		LatencyMs: latency.Milliseconds(),
+		Trigger:   AccountHealthCheckTriggerScheduled,
+	}
+	if testErr != nil {
+		check.ErrorMessage = truncateString(testErr.Error(), accountHealthCheckMaxErrorLen)
+	}
+	stats.checked.Add(1)
+	if err := s.checkRepo.Create(ctx, check); err != nil {
+		log.Printf("[AccountHealthCheck] save result failed: account=%d err=%v", account.ID, err)
+		return
+	}
+
+	switch s.applyResult(ctx, account, check) {
+	case accountHealthOutcomeMarkedError:
+		stats.marked.Add(1)
+	case accountHealthOutcomeRecovered:
+		stats.recovered.Add(1)
+	}
+	if !check.Success {
+		stats.failed.Add(1)
+	}
+}
+
+type accountHealthOutcome int
+
+const (
+	accountHealthOutcomeNone accountHealthOutcome = iota
+	accountHealthOutcomeMarkedError
+	accountHealthOutcomeRecovered
+)
+
+// applyResult 连续失败达到阈值时标记账号错误；巡检标记的错误账号巡检成功后自动恢复
+func (s *AccountHealthCheckService) applyResult(ctx context.Context, account *Account, check *AccountHealthCheck) accountHealthOutcome {
+	if check.Success {
+		if account.Status != StatusError || !isHealthCheckError(account.ErrorMessage) || !s.cfg.HealthCheck.AutoRecover {
+			return accountHealthOutcomeNone
+		}
+		if err := s.accountRepo.ClearError(ctx, account.ID); err != nil {
+			log.Printf("[AccountHealthCheck] clear error failed: account=%d err=%v", account.ID, err)
+			return accountHealthOutcomeNone
+		}
+		log.Printf("[AccountHealthCheck] Account %d (%s) recovered", account.ID, account.Name)
+		return accountHealthOutcomeRecovered
+	}
+
+	if account.Status != StatusActive {
+		return accountHealthOutcomeNone
+	}
+	threshold := s.cfg.HealthCheck.FailureThreshold
+	recent, err := s.checkRepo.ListRecentResults(ctx, account.ID, threshold)
+	if err != nil {
+		log.Printf("[AccountHealthCheck] load recent results failed: account=%d err=%v", account.ID, err)
+		return accountHealthOutcomeNone
+	}
+	if len(recent) < threshold {
+		return accountHealthOutcomeNone
+	}
+	for _, ok := range recent {
+		if ok {
+			return accountHealthOutcomeNone
+		}
+	}
+	msg := truncateString(accountHealthCheckErrorPrefix+check.ErrorMessage, accountHealthCheckMaxErrorLen)
+	if err := s.accountRepo.SetError(ctx, account.ID, msg); err != nil {
+		log.Printf("[AccountHealthCheck] set error failed: account=%d err=%v", account.ID, err)
+		return accountHealthOutcomeNone
+	}
+	log.Printf("[AccountHealthCheck] Account %d (%s) marked as error after %d consecutive failures", account.ID, account.Name, threshold)
+	return accountHealthOutcomeMarkedError
+}
+
+func (s *AccountHealthCheckService) pruneHistory(ctx context.Context) {
+	cutoff := time.Now().Add(-time.Duration(s.cfg.HealthCheck.RetentionDays) * 24 * time.Hour)
+	if _, err := s.checkRepo.DeleteBefore(ctx, cutoff); err != nil {
+		log.Printf("[AccountHealthCheck] prune history failed: %v", err)
+	}
+}
+
+// GetTimeline 返回账号在 since 之后的巡检时间线（管理接口使用）
+func (s *AccountHealthCheckService) GetTimeline(ctx context.Context, accountID int64, since time.Time, limit int) (*AccountHealthTimeline, error) {
+	timeline := &AccountHealthTimeline{AccountID: accountID, Checks: []AccountHealthCheck{}}
+	if s == nil || s.checkRepo == nil {
+		return timeline, nil
+	}
+	checks, err := s.checkRepo.ListByAccount(ctx, accountID, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	var latencySum int64
+	for i := range checks {
+		if !checks[i].Success {
+			timeline.Failures++
+		}
+		latencySum += checks[i].LatencyMs
+	}
+	timeline.Total = len(checks)
+	if timeline.Total > 0 {
+		timeline.Checks = checks
+		timeline.SuccessRate = float64(timeline.Total-timeline.Failures) / float64(timeline.Total)
+		timeline.AvgLatencyMs = latencySum / int64(timeline.Total)
+		last := checks[0].CreatedAt
+		timeline.LastCheckedAt = &last
+	}
+	return timeline, nil
+}
+
+func isHealthCheckError(msg string) bool {
+	return strings.HasPrefix(msg, accountHealthCheckErrorPrefix)
+}
+
+func (s *AccountHealthCheckService) tryAcquireLeaderLock(ctx context.Context) (func(), bool) {
+	if !s.distributedLockOn {
+		return nil, true
+	}
+	if s.redisClient == nil {
+		s.warnNoRedisOnce.Do(func() {
+			log.Printf("[AccountHealthCheck] redis not configured; running without distributed lock")
+		})
+		return nil, true
+	}
+
+	ttl := s.interval()
+	if ttl < accountHealthCheckLeaderLockTTLMin {
+		ttl = accountHealthCheckLeaderLockTTLMin
+	}
+	ok, err := s.redisClient.SetNX(ctx, accountHealthCheckLeaderLockKey, s.instanceID, ttl).Result()
+	if err != nil {
+		// Fail closed: duplicate probes would double-count consecutive failures.
+		log.Printf("[AccountHealthCheck] leader lock SetNX failed; skipping this cycle: %v", err)
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+	return func() {
+		releaseCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		_, _ = accountHealthCheckReleaseScript.Run(releaseCtx, s.redisClient, []string{accountHealthCheckLeaderLockKey}, s.instanceID).Result()
+	}, true
+}
+
+func (s *AccountHealthCheckService) recordHeartbeatSuccess(runAt time.Time, duration time.Duration, result string) {
+	if s.opsService == nil || s.opsService.opsRepo == nil {
+		return
+	}
+	now := time.Now().UTC()
+	durMs := duration.Milliseconds()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	msg := truncateString(result, 2048)
+	_ = s.opsService.opsRepo.UpsertJobHeartbeat(ctx, &OpsUpsertJobHeartbeatInput{
+		JobName:        accountHealthCheckJobName,
+		LastRunAt:      &runAt,
+		LastSuccessAt:  &now,
+		LastDurationMs: &durMs,
+		LastResult:     &msg,
+	})
+}
+
+func (s *AccountHealthCheckService) recordHeartbeatError(runAt time.Time, duration time.Duration, err error) {
+	if s.opsService == nil || s.opsService.opsRepo == nil || err == nil {
+		return
+	}
+	now := time.Now().UTC()
+	durMs := duration.Milliseconds()
+	msg := truncateString(err.Error(), 2048)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_ = s.opsService.opsRepo.UpsertJobHeartbeat(ctx, &OpsUpsertJobHeartbeatInput{
+		JobName:        accountHealthCheckJobName,
+		LastRunAt:      &runAt,
+		LastErrorAt:    &now,
+		LastError:      &msg,
+		LastDurationMs: &durMs,
+	})
+}
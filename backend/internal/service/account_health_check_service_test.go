@@ -0,0 +1,190 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/stretchr/testify/require"
+)
+
+type healthCheckAccountRepoStub struct {
+	rateLimitAccountRepoStub
+	active       []Account
+	errored      []Account
+	clearedIDs   []int64
+	errorStatus  string
+	errorListErr error
+}
+
+func (r *healthCheckAccountRepoStub) ListActive(ctx context.Context) ([]Account, error) {
+	return r.active, nil
+}
+
+func (r *healthCheckAccountRepoStub) ListWithFilters(ctx context.Context, params pagination.PaginationParams, platform, accountType, status, search string) ([]Account, *pagination.PaginationResult, error) {
+	r.errorStatus = status
+	return r.errored, nil, r.errorListErr
+}
+
+func (r *healthCheckAccountRepoStub) ClearError(ctx context.Context, id int64) error {
+	r.clearedIDs = append(r.clearedIDs, id)
+	return nil
+}
+
+type stubHealthCheckRepo struct {
+	checks []AccountHealthCheck // 按时间倒序
+}
+
+func (r *stubHealthCheckRepo) Create(ctx context.Context, check *AccountHealthCheck) error {
+	check.ID = int64(len(r.checks) + 1)
+	check.CreatedAt = time.Now()
+	r.checks = append([]AccountHealthCheck{*check}, r.checks...)
+	return nil
+}
+
+func (r *stubHealthCheckRepo) ListByAccount(ctx context.Context, accountID int64, since time.Time, limit int) ([]AccountHealthCheck, error) {
+	var out []AccountHealthCheck
+	for _, c := range r.checks {
+		if c.AccountID == accountID && len(out) < limit {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func (r *stubHealthCheckRepo) ListRecentResults(ctx context.Context, accountID int64, limit int) ([]bool, error) {
+	var out []bool
+	for _, c := range r.checks {
+		if c.AccountID == accountID && len(out) < limit {
+			out = append(out, c.Success)
+		}
+	}
+	return out, nil
+}
+
+func (r *stubHealthCheckRepo) DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	return 0, nil
+}
+
+type stubConnectionTester struct {
+	err error
+}
+
+func (t *stubConnectionTester) RunHeadlessTest(ctx context.Context, accountID int64, modelID string) error {
+	return t.err
+}
+
+func healthCheckTestService(repo *healthCheckAccountRepoStub, checks *stubHealthCheckRepo, tester *stubConnectionTester) *AccountHealthCheckService {
+	cfg := &config.Config{RunMode: config.RunModeSimple}
+	cfg.HealthCheck = config.AccountHealthCheckConfig{
+		Enabled:          true,
+		IntervalMinutes:  30,
+		IdleMinutes:      30,
+		Concurrency:      2,
+		TimeoutSeconds:   10,
+		FailureThreshold: 2,
+		AutoRecover:      true,
+		RetentionDays:    7,
+	}
+	svc := NewAccountHealthCheckService(repo, checks, nil, nil, nil, cfg)
+	svc.tester = tester
+	return svc
+}
+
+func TestAccountHealthCheck_MarksErrorAfterConsecutiveFailures(t *testing.T) {
+	repo := &healthCheckAccountRepoStub{}
+	checks := &stubHealthCheckRepo{}
+	tester := &stubConnectionTester{err: errors.New("API returned 401: invalid token")}
+	svc := healthCheckTestService(repo, checks, tester)
+	account := &Account{ID: 1, Platform: PlatformAnthropic, Status: StatusActive, Schedulable: true}
+	stats := &accountHealthCheckRunStats{}
+
+	svc.checkAccount(context.Background(), account, stats)
+	require.Equal(t, 0, repo.setErrorCalls)
+
+	svc.checkAccount(context.Background(), account, stats)
+	require.Equal(t, 1, repo.setErrorCalls)
+	require.True(t, isHealthCheckError(repo.lastErrorMsg))
+	require.Contains(t, repo.lastErrorMsg, "invalid token")
+	require.EqualValues(t, 2, stats.failed.Load())
+	require.EqualValues(t, 1, stats.marked.Load())
+
+	// 中间有一次成功则重新计数
+	tester.err = nil
+	svc.checkAccount(context.Background(), account, stats)
+	tester.err = errors.New("timeout")
+	svc.checkAccount(context.Background(), account, stats)
+	require.Equal(t, 1, repo.setErrorCalls)
+}
+
+func TestAccountHealthCheck_AutoRecover(t *testing.T) {
+	repo := &healthCheckAccountRepoStub{}
+	svc := healthCheckTestService(repo, &stubHealthCheckRepo{}, &stubConnectionTester{})
+	stats := &accountHealthCheckRunStats{}
+
+	svc.checkAccount(context.Background(), &Account{ID: 1, Status: StatusError, ErrorMessage: accountHealthCheckErrorPrefix + "x"}, stats)
+	// 非巡检标记的错误不自动恢复
+	svc.checkAccount(context.Background(), &Account{ID: 2, Status: StatusError, ErrorMessage: "Authentication failed (401)"}, stats)
+	require.Equal(t, []int64{1}, repo.clearedIDs)
+	require.EqualValues(t, 1, stats.recovered.Load())
+
+	svc.cfg.HealthCheck.AutoRecover = false
+	svc.checkAccount(context.Background(), &Account{ID: 3, Status: StatusError, ErrorMessage: accountHealthCheckErrorPrefix + "x"}, stats)
+	require.Equal(t, []int64{1}, repo.clearedIDs)
+}
+
+func TestAccountHealthCheck_CollectCandidates(t *testing.T) {
+	now := time.Now()
+	recent := now.Add(-5 * time.Minute)
+	idle := now.Add(-2 * time.Hour)
+	limited := now.Add(time.Hour)
+	repo := &healthCheckAccountRepoStub{
+		active: []Account{
+			{ID: 1, Status: StatusActive, Schedulable: true, LastUsedAt: &idle},
+			{ID: 2, Status: StatusActive, Schedulable: true, LastUsedAt: &recent},
+			{ID: 3, Status: StatusActive, Schedulable: true},
+			{ID: 4, Status: StatusActive, Schedulable: true, RateLimitResetAt: &limited},
+			{ID: 5, Status: StatusActive, Schedulable: false},
+		},
+		errored: []Account{
+			{ID: 6, Status: StatusError, Schedulable: true, ErrorMessage: accountHealthCheckErrorPrefix + "x"},
+			{ID: 7, Status: StatusError, Schedulable: true, ErrorMessage: "manual"},
+		},
+	}
+	svc := healthCheckTestService(repo, &stubHealthCheckRepo{}, &stubConnectionTester{})
+
+	candidates, err := svc.collectCandidates(context.Background(), now)
+	require.NoError(t, err)
+	ids := make([]int64, 0, len(candidates))
+	for _, a := range candidates {
+		ids = append(ids, a.ID)
+	}
+	require.Equal(t, []int64{1, 3, 6}, ids)
+	require.Equal(t, StatusError, repo.errorStatus)
+}
+
+func TestAccountHealthCheck_Timeline(t *testing.T) {
+	checks := &stubHealthCheckRepo{}
+	svc := healthCheckTestService(&healthCheckAccountRepoStub{}, checks, &stubConnectionTester{})
+	require.NoError(t, checks.Create(context.Background(), &AccountHealthCheck{AccountID: 1, Success: true, LatencyMs: 100}))
+	require.NoError(t, checks.Create(context.Background(), &AccountHealthCheck{AccountID: 1, Success: false, LatencyMs: 300}))
+	require.NoError(t, checks.Create(context.Background(), &AccountHealthCheck{AccountID: 2, Success: true, LatencyMs: 50}))
+
+	timeline, err := svc.GetTimeline(context.Background(), 1, time.Now().Add(-time.Hour), 100)
+	require.NoError(t, err)
+	require.Equal(t, 2, timeline.Total)
+	require.Equal(t, 1, timeline.Failures)
+	require.InDelta(t, 0.5, timeline.SuccessRate, 1e-9)
+	require.EqualValues(t, 200, timeline.AvgLatencyMs)
+	require.NotNil(t, timeline.LastCheckedAt)
+
+	var nilSvc *AccountHealthCheckService
+	empty, err := nilSvc.GetTimeline(context.Background(), 1, time.Now(), 10)
+	require.NoError(t, err)
+	require.Empty(t, empty.Checks)
+}
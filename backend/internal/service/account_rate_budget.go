@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/usagestats"
+)
+
+// AccountRateUsage 账号滑动 1 分钟窗口内的用量估算
+type AccountRateUsage struct {
+	Requests int64
+	Tokens   int64
+}
+
+// AccountRateBudgetCache 账号 RPM/TPM 计数（Redis，按分钟分桶）
+type AccountRateBudgetCache interface {
+	// IncrAccountRateTokens 原子累加当前分钟的 token 数
+	IncrAccountRateTokens(ctx context.Context, accountID int64, tokens int64) error
+	// ReserveAccountRequest 原子检查滑动窗口用量未达预算（上限 <= 0 表示不限）并计入一次请求；预算已满时返回 false
+	ReserveAccountRequest(ctx context.Context, accountID int64, rpmLimit, tpmLimit int64) (bool, error)
+	// GetAccountRateUsageBatch 批量读取滑动 1 分钟窗口用量（当前分钟 + 上一分钟按剩余时间加权）
+	GetAccountRateUsageBatch(ctx context.Context, accountIDs []int64) (map[int64]AccountRateUsage, error)
+}
+
+// AccountRateBudgetService 按账号 extra.rpm_limit / extra.tpm_limit 主动跳过已打满预算的账号，
+// 避免突发流量把 API Key 账号推过上游 RPM/TPM 档位后才收到 429。
+//
+// 请求数在实际获取到账号并发槽位时原子地检查并预占，token 数在记录用量时计入；缓存异常时失败开放。
+type AccountRateBudgetService struct {
+	cache AccountRateBudgetCache
+}
+
+// NewAccountRateBudgetService creates a new AccountRateBudgetService
+func NewAccountRateBudgetService(cache AccountRateBudgetCache) *AccountRateBudgetService {
+	return &AccountRateBudgetService{cache: cache}
+}
+
+// IsRateBudgetSaturated 判断用量是否已达到账号任一预算
+func (a *Account) IsRateBudgetSaturated(usage AccountRateUsage) bool {
+	if limit := a.GetRPMLimit(); limit > 0 && usage.Requests >= int64(limit) {
+		return true
+	}
+	if limit := a.GetTPMLimit(); limit > 0 && usage.Tokens >= int64(limit) {
+		return true
+	}
+	return false
+}
+
+// SaturatedAccounts 返回已打满 RPM/TPM 预算的账号 ID 集合（仅查询配置了预算的账号）
+func (s *AccountRateBudgetService) SaturatedAccounts(ctx context.Context, accounts []Account) map[int64]struct{} {
+	if s == nil || s.cache == nil {
+		return nil
+	}
+	budgeted := make(map[int64]*Account)
+	ids := make([]int64, 0)
+	for i := range accounts {
+		if accounts[i].HasRateBudget() {
+			budgeted[accounts[i].ID] = &accounts[i]
+			ids = append(ids, accounts[i].ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	usage, err := s.cache.GetAccountRateUsageBatch(ctx, ids)
+	if err != nil {
+		log.Printf("[RateBudget] load usage failed: %v", err)
+		return nil
+	}
+	saturated := make(map[int64]struct{})
+	for id, account := range budgeted {
+		if account.IsRateBudgetSaturated(usage[id]) {
+			saturated[id] = struct{}{}
+		}
+	}
+	return saturated
+}
+
+// TryReserveRequest 获取到账号槽位后原子地检查预算并计入一次请求
+// 返回 false 表示预算已被并发请求占满，调用方应释放槽位并改选其他账号；未配置预算或缓存异常时返回 true。
+func (s *AccountRateBudgetService) TryReserveRequest(ctx context.Context, account *Account) bool {
+	if s == nil || s.cache == nil || account == nil || !account.HasRateBudget() {
+		return true
+	}
+	reserved, err := s.cache.ReserveAccountRequest(ctx, account.ID, int64(account.GetRPMLimit()), int64(account.GetTPMLimit()))
+	if err != nil {
+		log.Printf("[RateBudget] reserve request failed: account=%d err=%v", account.ID, err)
+		return true
+	}
+	return reserved
+}
+
+// RecordTokens 请求完成后计入 token 用量
+func (s *AccountRateBudgetService) RecordTokens(ctx context.Context, account *Account, tokens int) {
+	if s == nil || s.cache == nil || account == nil || !account.HasRateBudget() || tokens <= 0 {
+		return
+	}
+	if err := s.cache.IncrAccountRateTokens(ctx, account.ID, int64(tokens)); err != nil {
+		log.Printf("[RateBudget] record usage failed: account=%d err=%v", account.ID, err)
+	}
+}
+
+// GetStatus 返回账号当前 RPM/TPM 预算与用量；未配置预算时返回 nil
+func (s *AccountRateBudgetService) GetStatus(ctx context.Context, account *Account) (*usagestats.AccountRateBudget, error) {
+	if s == nil || s.cache == nil || account == nil || !account.HasRateBudget() {
+		return nil, nil
+	}
+	usage, err := s.cache.GetAccountRateUsageBatch(ctx, []int64{account.ID})
+	if err != nil {
+		return nil, err
+	}
+	current := usage[account.ID]
+	return &usagestats.AccountRateBudget{
+		RPMLimit:  account.GetRPMLimit(),
+		RPMUsed:   current.Requests,
+		TPMLimit:  account.GetTPMLimit(),
+		TPMUsed:   current.Tokens,
+		Saturated: account.IsRateBudgetSaturated(current),
+	}, nil
+}
+
+// IsSaturated 单账号预算检查（非负载感知路径使用）
+func (s *AccountRateBudgetService) IsSaturated(ctx context.Context, account *Account) bool {
+	if account == nil || !account.HasRateBudget() {
+		return false
+	}
+	_, saturated := s.SaturatedAccounts(ctx, []Account{*account})[account.ID]
+	return saturated
+}
+
+// withExcludedAccount 返回追加了 accountID 的排除集合副本，不修改调用方的集合
+func withExcludedAccount(excludedIDs map[int64]struct{}, accountID int64) map[int64]struct{} {
+	out := make(map[int64]struct{}, len(excludedIDs)+1)
+	for id := range excludedIDs {
+		out[id] = struct{}{}
+	}
+	out[accountID] = struct{}{}
+	return out
+}
@@ -0,0 +1,124 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type stubRateBudgetCache struct {
+	usage map[int64]AccountRateUsage
+	// denyReserve 模拟筛选之后被并发请求占满预算的账号
+	denyReserve map[int64]bool
+}
+
+func (c *stubRateBudgetCache) IncrAccountRateTokens(ctx context.Context, accountID int64, tokens int64) error {
+	u := c.usage[accountID]
+	u.Tokens += tokens
+	c.usage[accountID] = u
+	return nil
+}
+
+func (c *stubRateBudgetCache) ReserveAccountRequest(ctx context.Context, accountID int64, rpmLimit, tpmLimit int64) (bool, error) {
+	u := c.usage[accountID]
+	if c.denyReserve[accountID] || (rpmLimit > 0 && u.Requests >= rpmLimit) || (tpmLimit > 0 && u.Tokens >= tpmLimit) {
+		return false, nil
+	}
+	u.Requests++
+	c.usage[accountID] = u
+	return true, nil
+}
+
+func (c *stubRateBudgetCache) GetAccountRateUsageBatch(ctx context.Context, accountIDs []int64) (map[int64]AccountRateUsage, error) {
+	out := make(map[int64]AccountRateUsage, len(accountIDs))
+	for _, id := range accountIDs {
+		out[id] = c.usage[id]
+	}
+	return out, nil
+}
+
+func TestAccountRateBudgetService_SaturatedAccounts(t *testing.T) {
+	cache := &stubRateBudgetCache{usage: map[int64]AccountRateUsage{
+		1: {Requests: 10},
+		2: {Requests: 3, Tokens: 5000},
+		3: {Requests: 100, Tokens: 100000},
+	}}
+	svc := NewAccountRateBudgetService(cache)
+	accounts := []Account{
+		{ID: 1, Extra: map[string]any{"rpm_limit": float64(10)}},
+		{ID: 2, Extra: map[string]any{"rpm_limit": "10", "tpm_limit": float64(4000)}},
+		{ID: 3}, // 未配置预算
+	}
+
+	saturated := svc.SaturatedAccounts(context.Background(), accounts)
+	require.Contains(t, saturated, int64(1))
+	require.Contains(t, saturated, int64(2))
+	require.NotContains(t, saturated, int64(3))
+
+	// 未配置预算的账号不计数
+	require.True(t, svc.TryReserveRequest(context.Background(), &accounts[2]))
+	require.Equal(t, int64(100), cache.usage[3].Requests)
+	// 预算已满时拒绝预占
+	require.False(t, svc.TryReserveRequest(context.Background(), &accounts[0]))
+	require.Equal(t, int64(10), cache.usage[1].Requests)
+	svc.RecordTokens(context.Background(), &accounts[0], 42)
+	require.Equal(t, int64(42), cache.usage[1].Tokens)
+
+	status, err := svc.GetStatus(context.Background(), &accounts[1])
+	require.NoError(t, err)
+	require.Equal(t, 10, status.RPMLimit)
+	require.Equal(t, int64(5000), status.TPMUsed)
+	require.True(t, status.Saturated)
+
+	// nil 服务失败开放
+	var nilSvc *AccountRateBudgetService
+	require.Nil(t, nilSvc.SaturatedAccounts(context.Background(), accounts))
+	require.False(t, nilSvc.IsSaturated(context.Background(), &accounts[0]))
+	require.True(t, nilSvc.TryReserveRequest(context.Background(), &accounts[0]))
+}
+
+func TestOpenAISelectAccountWithLoadAwareness_SkipsRateSaturated(t *testing.T) {
+	groupID := int64(1)
+	repo := stubOpenAIAccountRepo{
+		accounts: []Account{
+			{ID: 1, Platform: PlatformOpenAI, Status: StatusActive, Schedulable: true, Concurrency: 1, Priority: 1,
+				Extra: map[string]any{"rpm_limit": float64(5)}},
+			{ID: 2, Platform: PlatformOpenAI, Status: StatusActive, Schedulable: true, Concurrency: 1, Priority: 2,
+				Extra: map[string]any{"rpm_limit": float64(5)}},
+		},
+	}
+	budgetCache := &stubRateBudgetCache{usage: map[int64]AccountRateUsage{1: {Requests: 5}}}
+	cache := &stubGatewayCache{sessionBindings: map[string]int64{"openai:sticky": 1}}
+	svc := &OpenAIGatewayService{
+		accountRepo:        repo,
+		cache:              cache,
+		concurrencyService: NewConcurrencyService(stubConcurrencyCache{}),
+		rateBudgetService:  NewAccountRateBudgetService(budgetCache),
+	}
+
+	// 粘性账号 1 已打满 RPM，改选账号 2 并计入请求
+	selection, err := svc.SelectAccountWithLoadAwareness(context.Background(), &groupID, "sticky", "gpt-4", nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), selection.Account.ID)
+	require.Equal(t, int64(1), budgetCache.usage[2].Requests)
+
+	// 筛选通过但预占时已被并发请求占满：释放槽位并改选，不修改调用方的排除集合
+	budgetCache.usage[1] = AccountRateUsage{}
+	budgetCache.denyReserve = map[int64]bool{1: true}
+	excluded := map[int64]struct{}{}
+	selection, err = svc.SelectAccountWithLoadAwareness(context.Background(), &groupID, "", "gpt-4", excluded)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), selection.Account.ID)
+	require.Equal(t, int64(2), budgetCache.usage[2].Requests)
+	require.Empty(t, excluded)
+
+	// 全部打满时不再调度
+	budgetCache.denyReserve = nil
+	budgetCache.usage[1] = AccountRateUsage{Requests: 5}
+	budgetCache.usage[2] = AccountRateUsage{Requests: 5}
+	_, err = svc.SelectAccountWithLoadAwareness(context.Background(), &groupID, "", "gpt-4", nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"log"
+	mathrand "math/rand"
+	"sort"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+)
+
+// 账号选择策略
+const (
+	SelectionModeLastUsed     = "last_used"
+	SelectionModeRandom       = "random"
+	SelectionModeLeastLatency = "least_latency"
+	SelectionModeP2C          = "p2c"
+)
+
+const (
+	defaultScoringEWMAAlpha       = 0.2
+	defaultScoringErrorRateWeight = 4.0
+	// 尚无 TTFT 样本时的默认延迟（毫秒），有其他账号样本时取其均值
+	defaultScoringLatencyMs = 1000.0
+)
+
+// AccountLatencyStats 账号近期 TTFT 与错误率（EWMA）
+type AccountLatencyStats struct {
+	TTFTMs    float64 // 首字延迟 EWMA（毫秒），0 表示尚无样本
+	ErrorRate float64 // 错误率 EWMA（0-1）
+	Samples   int64
+}
+
+// AccountLatencyStatsCache 账号 EWMA 统计（Redis）
+type AccountLatencyStatsCache interface {
+	// RecordAccountOutcome 原子更新 EWMA；ttftMs <= 0 时只更新错误率
+	RecordAccountOutcome(ctx context.Context, accountID int64, ttftMs int64, failed bool, alpha float64) error
+	// GetAccountLatencyStatsBatch 批量读取账号 EWMA 统计，无记录的账号不出现在结果中
+	GetAccountLatencyStatsBatch(ctx context.Context, accountIDs []int64) (map[int64]AccountLatencyStats, error)
+}
+
+// AccountScoringService 按 TTFT、错误率与负载为账号评分，供 least_latency / p2c 选择策略使用，
+// 让慢或不稳定的账号自然获得更少流量，无需管理员手动调低优先级。
+type AccountScoringService struct {
+	cache AccountLatencyStatsCache
+	cfg   *config.Config
+}
+
+// NewAccountScoringService creates a new AccountScoringService
+func NewAccountScoringService(cache AccountLatencyStatsCache, cfg *config.Config) *AccountScoringService {
+	return &AccountScoringService{cache: cache, cfg: cfg}
+}
+
+// IsScoringSelectionMode 是否为评分类选择策略
+func IsScoringSelectionMode(mode string) bool {
+	return mode == SelectionModeLeastLatency || mode == SelectionModeP2C
+}
+
+// Enabled 当前策略是否需要评分
+func (s *AccountScoringService) Enabled(mode string) bool {
+	return s != nil && s.cache != nil && IsScoringSelectionMode(mode)
+}
+
+func (s *AccountScoringService) alpha() float64 {
+	if s.cfg != nil && s.cfg.Gateway.Scheduling.ScoringEWMAAlpha > 0 {
+		return s.cfg.Gateway.Scheduling.ScoringEWMAAlpha
+	}
+	return defaultScoringEWMAAlpha
+}
+
+func (s *AccountScoringService) errorRateWeight() float64 {
+	if s.cfg != nil && s.cfg.Gateway.Scheduling.ScoringErrorRateWeight > 0 {
+		return s.cfg.Gateway.Scheduling.ScoringErrorRateWeight
+	}
+	return defaultScoringErrorRateWeight
+}
+
+// RecordSuccess 记录一次成功请求；firstTokenMs 为空（非流式）时只计入错误率
+func (s *AccountScoringService) RecordSuccess(ctx context.Context, accountID int64, firstTokenMs *int) {
+	var ttft int64
+	if firstTokenMs != nil {
+		ttft = int64(*firstTokenMs)
+	}
+	s.record(ctx, accountID, ttft, false)
+}
+
+// RecordFailure 记录一次上游失败
+func (s *AccountScoringService) RecordFailure(ctx context.Context, accountID int64) {
+	s.record(ctx, accountID, 0, true)
+}
+
+// isAccountScoringFailure 判断上游状态码是否计入账号错误率
+func isAccountScoringFailure(statusCode int) bool {
+	switch statusCode {
+	case 401, 403, 429:
+		return true
+	}
+	return statusCode >= 500
+}
+
+func (s *AccountScoringService) record(ctx context.Context, accountID int64, ttftMs int64, failed bool) {
+	if s == nil || s.cache == nil || accountID <= 0 {
+		return
+	}
+	if err := s.cache.RecordAccountOutcome(ctx, accountID, ttftMs, failed, s.alpha()); err != nil {
+		log.Printf("[AccountScoring] record outcome failed: account=%d err=%v", accountID, err)
+	}
+}
+
+// ScoreAccounts 计算账号评分（越低越好）：TTFT × (1 + 负载率) × (1 + 权重 × 错误率)。
+// 尚无 TTFT 样本的账号按其他账号均值估算，既不被饿死也不会独占流量。
+func (s *AccountScoringService) ScoreAccounts(ctx context.Context, accounts []*Account, loadMap map[int64]*AccountLoadInfo) map[int64]float64 {
+	ids := make([]int64, 0, len(accounts))
+	for _, acc := range accounts {
+		ids = append(ids, acc.ID)
+	}
+	stats, err := s.cache.GetAccountLatencyStatsBatch(ctx, ids)
+	if err != nil {
+		log.Printf("[AccountScoring] load stats failed: %v", err)
+		stats = nil
+	}
+	return scoreAccounts(accounts, stats, loadMap, s.errorRateWeight())
+}
+
+func scoreAccounts(accounts []*Account, stats map[int64]AccountLatencyStats, loadMap map[int64]*AccountLoadInfo, errorRateWeight float64) map[int64]float64 {
+	defaultLatency := defaultScoringLatencyMs
+	var sum float64
+	var known int
+	for _, acc := range accounts {
+		if st, ok := stats[acc.ID]; ok && st.TTFTMs > 0 {
+			sum += st.TTFTMs
+			known++
+		}
+	}
+	if known > 0 {
+		defaultLatency = sum / float64(known)
+	}
+
+	scores := make(map[int64]float64, len(accounts))
+	for _, acc := range accounts {
+		st := stats[acc.ID]
+		latency := st.TTFTMs
+		if latency <= 0 {
+			latency = defaultLatency
+		}
+		loadRate := 0
+		if info := loadMap[acc.ID]; info != nil {
+			loadRate = info.LoadRate
+		}
+		scores[acc.ID] = latency * (1 + float64(loadRate)/100) * (1 + errorRateWeight*st.ErrorRate)
+	}
+	return scores
+}
+
+// Order 按评分返回候选账号的尝试顺序（下标排列）。
+// 优先级仍然优先：只在同优先级内按评分排序；
+// least_latency 严格按评分升序，p2c 每次随机取两个候选择优，避免所有实例同时涌向同一个最优账号。
+func (s *AccountScoringService) Order(ctx context.Context, mode string, accounts []*Account, loadMap map[int64]*AccountLoadInfo) []int {
+	scores := s.ScoreAccounts(ctx, accounts, loadMap)
+	r := mathrand.New(mathrand.NewSource(time.Now().UnixNano()))
+	return orderByScore(accounts, scores, mode, r)
+}
+
+func orderByScore(accounts []*Account, scores map[int64]float64, mode string, r *mathrand.Rand) []int {
+	indexes := make([]int, len(accounts))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(i, j int) bool {
+		a, b := accounts[indexes[i]], accounts[indexes[j]]
+		if a.Priority != b.Priority {
+			return a.Priority < b.Priority
+		}
+		if mode == SelectionModeP2C {
+			return false
+		}
+		return scores[a.ID] < scores[b.ID]
+	})
+	if mode != SelectionModeP2C {
+		return indexes
+	}
+
+	ordered := make([]int, 0, len(indexes))
+	start := 0
+	for start < len(indexes) {
+		priority := accounts[indexes[start]].Priority
+		end := start + 1
+		for end < len(indexes) && accounts[indexes[end]].Priority == priority {
+			end++
+		}
+		remaining := append([]int(nil), indexes[start:end]...)
+		for len(remaining) > 1 {
+			i := r.Intn(len(remaining))
+			j := r.Intn(len(remaining) - 1)
+			if j >= i {
+				j++
+			}
+			winner := i
+			if scores[accounts[remaining[j]].ID] < scores[accounts[remaining[i]].ID] {
+				winner = j
+			}
+			ordered = append(ordered, remaining[winner])
+			remaining = append(remaining[:winner], remaining[winner+1:]...)
+		}
+		ordered = append(ordered, remaining...)
+		start = end
+	}
+	return ordered
+}
@@ -0,0 +1,118 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	mathrand "math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type stubLatencyStatsCache struct {
+	stats    map[int64]AccountLatencyStats
+	recorded []int64
+	failures int
+}
+
+func (c *stubLatencyStatsCache) RecordAccountOutcome(ctx context.Context, accountID int64, ttftMs int64, failed bool, alpha float64) error {
+	c.recorded = append(c.recorded, accountID)
+	if failed {
+		c.failures++
+	}
+	return nil
+}
+
+func (c *stubLatencyStatsCache) GetAccountLatencyStatsBatch(ctx context.Context, accountIDs []int64) (map[int64]AccountLatencyStats, error) {
+	return c.stats, nil
+}
+
+func TestScoreAccounts(t *testing.T) {
+	accounts := []*Account{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}}
+	stats := map[int64]AccountLatencyStats{
+		1: {TTFTMs: 500, Samples: 10},
+		2: {TTFTMs: 1500, Samples: 10},
+		3: {TTFTMs: 500, ErrorRate: 0.5, Samples: 10},
+	}
+	loadMap := map[int64]*AccountLoadInfo{1: {AccountID: 1, LoadRate: 50}}
+
+	scores := scoreAccounts(accounts, stats, loadMap, 4)
+	require.InDelta(t, 750, scores[1], 1e-9)  // 500 × 1.5
+	require.InDelta(t, 1500, scores[2], 1e-9) // 无负载
+	require.InDelta(t, 1500, scores[3], 1e-9) // 500 × (1 + 4×0.5)
+	// 无样本按已知均值估算
+	require.InDelta(t, (500.0+1500+500)/3, scores[4], 1e-9)
+}
+
+func TestOrderByScore(t *testing.T) {
+	accounts := []*Account{
+		{ID: 1, Priority: 2},
+		{ID: 2, Priority: 1},
+		{ID: 3, Priority: 1},
+		{ID: 4, Priority: 1},
+	}
+	scores := map[int64]float64{1: 1, 2: 300, 3: 100, 4: 200}
+
+	// least_latency：优先级优先，同优先级按评分升序
+	order := orderByScore(accounts, scores, SelectionModeLeastLatency, mathrand.New(mathrand.NewSource(1)))
+	require.Equal(t, []int{2, 3, 1, 0}, order)
+
+	// p2c：最差账号不会排在首位，低优先级账号始终最后
+	r := mathrand.New(mathrand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		order = orderByScore(accounts, scores, SelectionModeP2C, r)
+		require.Len(t, order, 4)
+		require.NotEqual(t, 1, order[0])
+		require.Equal(t, 0, order[3])
+	}
+}
+
+func TestAccountScoringService_Record(t *testing.T) {
+	cache := &stubLatencyStatsCache{}
+	svc := NewAccountScoringService(cache, nil)
+	require.True(t, svc.Enabled(SelectionModeP2C))
+	require.False(t, svc.Enabled(SelectionModeLastUsed))
+
+	ttft := 120
+	svc.RecordSuccess(context.Background(), 1, &ttft)
+	svc.RecordFailure(context.Background(), 2)
+	require.Equal(t, []int64{1, 2}, cache.recorded)
+	require.Equal(t, 1, cache.failures)
+
+	require.True(t, isAccountScoringFailure(529))
+	require.True(t, isAccountScoringFailure(429))
+	require.False(t, isAccountScoringFailure(400))
+
+	var nilSvc *AccountScoringService
+	require.False(t, nilSvc.Enabled(SelectionModeLeastLatency))
+	nilSvc.RecordFailure(context.Background(), 1)
+}
+
+func TestOpenAISelectAccountWithLoadAwareness_LeastLatency(t *testing.T) {
+	groupID := int64(1)
+	repo := stubOpenAIAccountRepo{
+		accounts: []Account{
+			{ID: 1, Platform: PlatformOpenAI, Status: StatusActive, Schedulable: true, Concurrency: 5, Priority: 1},
+			{ID: 2, Platform: PlatformOpenAI, Status: StatusActive, Schedulable: true, Concurrency: 5, Priority: 1},
+		},
+	}
+	cfg := testConfig()
+	cfg.Gateway.Scheduling.LoadBatchEnabled = true
+	cfg.Gateway.Scheduling.FallbackSelectionMode = SelectionModeLeastLatency
+	latency := &stubLatencyStatsCache{stats: map[int64]AccountLatencyStats{
+		1: {TTFTMs: 3000, Samples: 20},
+		2: {TTFTMs: 400, Samples: 20},
+	}}
+	svc := &OpenAIGatewayService{
+		accountRepo:        repo,
+		cache:              &stubGatewayCache{},
+		cfg:                cfg,
+		concurrencyService: NewConcurrencyService(stubConcurrencyCache{}),
+		scoringService:     NewAccountScoringService(latency, cfg),
+	}
+
+	selection, err := svc.SelectAccountWithLoadAwareness(context.Background(), &groupID, "", "gpt-4", nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), selection.Account.ID)
+}
@@ -40,6 +40,8 @@ type AccountRepository interface {
 	ClearError(ctx context.Context, id int64) error
 	SetSchedulable(ctx context.Context, id int64, schedulable bool) error
 	AutoPauseExpiredAccounts(ctx context.Context, now time.Time) (int64, error)
+	// AdvanceAvailabilityTransitions 处理已到期的可用时间窗口切换，返回处理的账号数
+	AdvanceAvailabilityTransitions(ctx context.Context, now time.Time) (int64, error)
 	BindGroups(ctx context.Context, accountID int64, groupIDs []int64) error
 
 	ListSchedulable(ctx context.Context) ([]Account, error)
@@ -73,6 +75,8 @@ type AccountBulkUpdate struct {
 	RateMultiplier *float64
 	Status         *string
 	Schedulable    *bool
+	AddTags        []string
+	RemoveTags     []string
 	Credentials    map[string]any
 	Extra          map[string]any
 }
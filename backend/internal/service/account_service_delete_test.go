@@ -111,6 +111,10 @@ func (s *accountRepoStub) AutoPauseExpiredAccounts(ctx context.Context, now time
 	panic("unexpected AutoPauseExpiredAccounts call")
 }
 
+func (s *accountRepoStub) AdvanceAvailabilityTransitions(ctx context.Context, now time.Time) (int64, error) {
+	panic("unexpected AdvanceAvailabilityTransitions call")
+}
+
 func (s *accountRepoStub) BindGroups(ctx context.Context, accountID int64, groupIDs []int64) error {
 	panic("unexpected BindGroups call")
 }
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// 账号标签与标签选择器
+//
+// 标签为自由文本（建议 key:value 形式，如 tier:max、region:us、owner:teamA），统一小写、去重并排序。
+// 选择器以逗号分隔多个条件，所有条件都满足才算匹配：
+//   - "tier:max"     账号带有该标签
+//   - "region:*"     账号带有以 "region:" 开头的标签（末尾 * 通配）
+//   - "!owner:teamA" 账号不带有该标签
+// 只包含排除条件的选择器视为无效，避免误把全部账号纳入。
+
+const (
+	maxAccountTags       = 32
+	maxAccountTagLength  = 64
+	maxTagSelectorLength = 512
+)
+
+// NormalizeAccountTags 校验并规范化账号标签（小写、去重、排序）
+func NormalizeAccountTags(tags []string) ([]string, error) {
+	seen := make(map[string]struct{}, len(tags))
+	out := make([]string, 0, len(tags))
+	for _, raw := range tags {
+		tag := strings.ToLower(strings.TrimSpace(raw))
+		if tag == "" {
+			continue
+		}
+		if err := validateTagToken(tag); err != nil {
+			return nil, err
+		}
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		out = append(out, tag)
+	}
+	if len(out) > maxAccountTags {
+		return nil, fmt.Errorf("too many tags: max %d", maxAccountTags)
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+func validateTagToken(tag string) error {
+	if len(tag) > maxAccountTagLength {
+		return fmt.Errorf("tag %q too long: max %d characters", tag, maxAccountTagLength)
+	}
+	if strings.HasPrefix(tag, "!") || strings.ContainsAny(tag, ", \t*") {
+		return fmt.Errorf("invalid tag %q", tag)
+	}
+	return nil
+}
+
+type tagCondition struct {
+	value  string
+	prefix bool
+	negate bool
+}
+
+func (c tagCondition) matchTag(tag string) bool {
+	if c.prefix {
+		return strings.HasPrefix(tag, c.value)
+	}
+	return tag == c.value
+}
+
+// TagSelector 解析后的标签选择器
+type TagSelector struct {
+	conditions []tagCondition
+}
+
+// ParseTagSelector 解析标签选择器表达式，空表达式返回空选择器（不匹配任何账号）
+func ParseTagSelector(expr string) (TagSelector, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return TagSelector{}, nil
+	}
+	if len(expr) > maxTagSelectorLength {
+		return TagSelector{}, fmt.Errorf("tag selector too long: max %d characters", maxTagSelectorLength)
+	}
+	var sel TagSelector
+	positive := false
+	for _, part := range strings.Split(expr, ",") {
+		token := strings.ToLower(strings.TrimSpace(part))
+		if token == "" {
+			continue
+		}
+		cond := tagCondition{}
+		if strings.HasPrefix(token, "!") {
+			cond.negate = true
+			token = strings.TrimSpace(token[1:])
+		}
+		if strings.HasSuffix(token, "*") {
+			cond.prefix = true
+			token = strings.TrimSuffix(token, "*")
+		}
+		if token == "" {
+			return TagSelector{}, fmt.Errorf("invalid tag selector %q", expr)
+		}
+		if err := validateTagToken(token); err != nil {
+			return TagSelector{}, err
+		}
+		cond.value = token
+		if !cond.negate {
+			positive = true
+		}
+		sel.conditions = append(sel.conditions, cond)
+	}
+	if len(sel.conditions) > 0 && !positive {
+		return TagSelector{}, fmt.Errorf("tag selector %q needs at least one non-negated condition", expr)
+	}
+	return sel, nil
+}
+
+// NormalizeTagSelector 校验选择器并返回规范化表达式
+func NormalizeTagSelector(expr string) (string, error) {
+	sel, err := ParseTagSelector(expr)
+	if err != nil {
+		return "", err
+	}
+	return sel.String(), nil
+}
+
+// IsEmpty 选择器没有任何条件
+func (s TagSelector) IsEmpty() bool {
+	return len(s.conditions) == 0
+}
+
+// Matches 判断标签集合是否满足选择器的全部条件，空选择器不匹配
+func (s TagSelector) Matches(tags []string) bool {
+	if s.IsEmpty() {
+		return false
+	}
+	for _, cond := range s.conditions {
+		found := false
+		for _, tag := range tags {
+			if cond.matchTag(tag) {
+				found = true
+				break
+			}
+		}
+		if found == cond.negate {
+			return false
+		}
+	}
+	return true
+}
+
+func (s TagSelector) String() string {
+	parts := make([]string, 0, len(s.conditions))
+	for _, cond := range s.conditions {
+		token := cond.value
+		if cond.prefix {
+			token += "*"
+		}
+		if cond.negate {
+			token = "!" + token
+		}
+		parts = append(parts, token)
+	}
+	return strings.Join(parts, ",")
+}
+
+// MatchesTagSelector 判断账号是否满足标签选择器表达式（表达式无效时不匹配）
+func (a *Account) MatchesTagSelector(expr string) bool {
+	if a == nil || len(a.Tags) == 0 {
+		return false
+	}
+	sel, err := ParseTagSelector(expr)
+	if err != nil {
+		return false
+	}
+	return sel.Matches(a.Tags)
+}
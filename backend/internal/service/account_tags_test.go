@@ -0,0 +1,93 @@
+//go:build unit
+
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeAccountTags(t *testing.T) {
+	tags, err := NormalizeAccountTags([]string{" Region:US ", "tier:max", "region:us", ""})
+	require.NoError(t, err)
+	require.Equal(t, []string{"region:us", "tier:max"}, tags)
+
+	_, err = NormalizeAccountTags([]string{"bad tag"})
+	require.Error(t, err)
+	_, err = NormalizeAccountTags([]string{"!neg"})
+	require.Error(t, err)
+	_, err = NormalizeAccountTags([]string{"tier:*"})
+	require.Error(t, err)
+}
+
+func TestParseTagSelector(t *testing.T) {
+	sel, err := ParseTagSelector(" Tier:Max , region:* ,!owner:teamb ")
+	require.NoError(t, err)
+	require.Equal(t, "tier:max,region:*,!owner:teamb", sel.String())
+
+	require.True(t, sel.Matches([]string{"region:us", "tier:max"}))
+	require.False(t, sel.Matches([]string{"tier:max"}))
+	require.False(t, sel.Matches([]string{"owner:teamb", "region:eu", "tier:max"}))
+
+	empty, err := ParseTagSelector("  ")
+	require.NoError(t, err)
+	require.True(t, empty.IsEmpty())
+	require.False(t, empty.Matches([]string{"tier:max"}))
+
+	// 只有排除条件会匹配几乎所有账号，视为无效
+	_, err = ParseTagSelector("!owner:teama")
+	require.Error(t, err)
+	_, err = ParseTagSelector("tier:max,*")
+	require.Error(t, err)
+}
+
+func TestGroupResolveRoutingAccountIDs(t *testing.T) {
+	group := &Group{
+		ModelRoutingEnabled: true,
+		ModelRouting: map[string][]int64{
+			"claude-opus-*": {9},
+		},
+		ModelRoutingTags: map[string]string{
+			"claude-opus-*":   "tier:max",
+			"claude-sonnet-*": "region:us",
+		},
+	}
+	accounts := []Account{
+		{ID: 1, Tags: []string{"region:us", "tier:max"}},
+		{ID: 2, Tags: []string{"region:eu", "tier:max"}},
+		{ID: 9, Tags: []string{"tier:max"}},
+		{ID: 3},
+	}
+
+	require.Equal(t, []int64{9}, group.GetRoutingAccountIDs("claude-opus-4"))
+	require.Equal(t, []int64{9, 1, 2}, group.ResolveRoutingAccountIDs("claude-opus-4", accounts))
+	require.Equal(t, []int64{1}, group.ResolveRoutingAccountIDs("claude-sonnet-4", accounts))
+	require.True(t, group.RoutingRequiresAccounts("claude-sonnet-4"))
+	require.True(t, group.HasRoutingRule("claude-sonnet-4"))
+	require.False(t, group.HasRoutingRule("claude-haiku-4"))
+	require.Nil(t, group.ResolveRoutingAccountIDs("claude-haiku-4", accounts))
+
+	group.ModelRoutingEnabled = false
+	require.False(t, group.HasRoutingRule("claude-sonnet-4"))
+}
+
+func TestGroupMatchesAccountTags(t *testing.T) {
+	group := &Group{AccountTagSelector: "tier:max,region:*"}
+	require.True(t, group.MatchesAccountTags(&Account{Tags: []string{"region:us", "tier:max"}}))
+	require.False(t, group.MatchesAccountTags(&Account{Tags: []string{"tier:max"}}))
+	require.False(t, (&Group{}).MatchesAccountTags(&Account{Tags: []string{"tier:max"}}))
+}
+
+func TestNormalizeModelRoutingTags(t *testing.T) {
+	out, err := normalizeModelRoutingTags(map[string]string{
+		" claude-opus-* ": " Tier:Max ",
+		"claude-haiku-*":  " ",
+		"":                "tier:max",
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"claude-opus-*": "tier:max"}, out)
+
+	_, err = normalizeModelRoutingTags(map[string]string{"claude-*": "!tier:max"})
+	require.Error(t, err)
+}
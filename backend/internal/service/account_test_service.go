@@ -166,6 +166,21 @@ func (s *AccountTestService) TestAccountConnection(c *gin.Context, accountID int
 	return s.testClaudeAccountConnection(c, account, modelID)
 }
 
+// accountTestCaptureLimit 无头测试时响应捕获上限（仅用于丢弃 SSE 输出）
+const accountTestCaptureLimit = 64 << 10
+
+// RunHeadlessTest runs the same connection test without an HTTP client attached
+// (used by the scheduled health checker). Returns nil when the test succeeds.
+func (s *AccountTestService) RunHeadlessTest(ctx context.Context, accountID int64, modelID string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "/internal/account-health-check", nil)
+	if err != nil {
+		return err
+	}
+	c, _ := gin.CreateTestContext(newLimitedResponseWriter(accountTestCaptureLimit))
+	c.Request = req
+	return s.TestAccountConnection(c, accountID, modelID)
+}
+
 // testClaudeAccountConnection tests an Anthropic Claude account's connection
 func (s *AccountTestService) testClaudeAccountConnection(c *gin.Context, account *Account, modelID string) error {
 	ctx := c.Request.Context()
@@ -58,6 +58,9 @@ type UsageLogRepository interface {
 	GetAccountStatsAggregated(ctx context.Context, accountID int64, startTime, endTime time.Time) (*usagestats.UsageStats, error)
 	GetModelStatsAggregated(ctx context.Context, modelName string, startTime, endTime time.Time) (*usagestats.UsageStats, error)
 	GetDailyStatsAggregated(ctx context.Context, userID int64, startTime, endTime time.Time) ([]map[string]any, error)
+
+	// GetAPIKeyQuotaUsage 汇总 API Key 的累计、dayStart 起、monthStart 起的消费（Key 限额计数回源，不含请求数）
+	GetAPIKeyQuotaUsage(ctx context.Context, apiKeyID int64, dayStart, monthStart time.Time) (*APIKeyUsage, error)
 }
 
 // apiUsageCache 缓存从 Anthropic API 获取的使用率数据（utilization, resets_at）
@@ -182,6 +185,8 @@ type AccountUsageService struct {
 	antigravityQuotaFetcher *AntigravityQuotaFetcher
 	cache                   *UsageCache
 	identityCache           IdentityCache
+	rateBudgetService       *AccountRateBudgetService
+	circuitBreaker          *AccountCircuitBreakerService
 }
 
 // NewAccountUsageService 创建AccountUsageService实例
@@ -193,6 +198,8 @@ func NewAccountUsageService(
 	antigravityQuotaFetcher *AntigravityQuotaFetcher,
 	cache *UsageCache,
 	identityCache IdentityCache,
+	rateBudgetService *AccountRateBudgetService,
+	circuitBreaker *AccountCircuitBreakerService,
 ) *AccountUsageService {
 	return &AccountUsageService{
 		accountRepo:             accountRepo,
@@ -202,6 +209,8 @@ func NewAccountUsageService(
 		antigravityQuotaFetcher: antigravityQuotaFetcher,
 		cache:                   cache,
 		identityCache:           identityCache,
+		rateBudgetService:       rateBudgetService,
+		circuitBreaker:          circuitBreaker,
 	}
 }
 
@@ -434,6 +443,24 @@ func (s *AccountUsageService) GetAccountUsageStats(ctx context.Context, accountI
 	if err != nil {
 		return nil, fmt.Errorf("get account usage stats failed: %w", err)
 	}
+
+	// 附带当前 RPM/TPM 预算用量（失败不影响统计结果）
+	if s.rateBudgetService != nil {
+		if account, err := s.accountRepo.GetByID(ctx, accountID); err == nil {
+			if budget, err := s.rateBudgetService.GetStatus(ctx, account); err == nil {
+				stats.RateBudget = budget
+			} else {
+				log.Printf("[RateBudget] load status failed: account=%d err=%v", accountID, err)
+			}
+		}
+	}
+
+	// 附带熔断器状态
+	if breaker, err := s.circuitBreaker.GetStatus(ctx, accountID); err == nil {
+		stats.CircuitBreaker = breaker
+	} else {
+		log.Printf("[CircuitBreaker] load status failed: account=%d err=%v", accountID, err)
+	}
 	return stats, nil
 }
 
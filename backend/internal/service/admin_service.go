@@ -19,7 +19,8 @@ type AdminService interface {
 	CreateUser(ctx context.Context, input *CreateUserInput) (*User, error)
 	UpdateUser(ctx context.Context, id int64, input *UpdateUserInput) (*User, error)
 	DeleteUser(ctx context.Context, id int64) error
-	UpdateUserBalance(ctx context.Context, userID int64, balance float64, operation string, notes string) (*User, error)
+	// UpdateUserBalance 调整用户余额；adminID 为操作人（记入余额流水，0 表示未知）
+	UpdateUserBalance(ctx context.Context, userID int64, balance float64, operation string, notes string, adminID int64) (*User, error)
 	GetUserAPIKeys(ctx context.Context, userID int64, page, pageSize int) ([]APIKey, int64, error)
 	GetUserUsageStats(ctx context.Context, userID int64, period string) (any, error)
 
@@ -110,6 +111,17 @@ type CreateGroupInput struct {
 	// 模型路由配置（仅 anthropic 平台使用）
 	ModelRouting        map[string][]int64
 	ModelRoutingEnabled bool // 是否启用模型路由
+	// 模型路由标签选择器（模型模式 -> 账号标签选择器）
+	ModelRoutingTags map[string]string
+	// 账号标签选择器：匹配的账号自动加入该分组
+	AccountTagSelector string
+	// 成本优先路由策略（空表示关闭）
+	CostRoutingPolicy string
+	// 模型映射配置（跨协议转发时使用，如 Gemini 原生 API 转发到 anthropic/openai 平台）
+	ModelMapping map[string]string
+	// 粘性会话策略与 TTL（秒，0 表示默认值）
+	StickyPolicy     string
+	StickyTTLSeconds int
 }
 
 type UpdateGroupInput struct {
@@ -132,22 +144,36 @@ type UpdateGroupInput struct {
 	// 模型路由配置（仅 anthropic 平台使用）
 	ModelRouting        map[string][]int64
 	ModelRoutingEnabled *bool // 是否启用模型路由
+	// 模型路由标签选择器（nil 表示不修改，空 map 表示清除）
+	ModelRoutingTags map[string]string
+	// 账号标签选择器（nil 表示不修改，空字符串表示清除）
+	AccountTagSelector *string
+	// 成本优先路由策略（nil 表示不修改，空字符串表示关闭）
+	CostRoutingPolicy *string
+	// 模型映射配置（nil 表示不修改，空 map 表示清除）
+	ModelMapping map[string]string
+	// 粘性会话策略（空值表示不修改）与 TTL（nil 表示不修改，0 表示默认值）
+	StickyPolicy     string
+	StickyTTLSeconds *int
 }
 
 type CreateAccountInput struct {
-	Name               string
-	Notes              *string
-	Platform           string
-	Type               string
-	Credentials        map[string]any
-	Extra              map[string]any
-	ProxyID            *int64
-	Concurrency        int
-	Priority           int
-	RateMultiplier     *float64 // 账号计费倍率（>=0，允许 0）
-	GroupIDs           []int64
-	ExpiresAt          *int64
-	AutoPauseOnExpired *bool
+	Name           string
+	Notes          *string
+	Platform       string
+	Type           string
+	Credentials    map[string]any
+	Extra          map[string]any
+	ProxyID        *int64
+	Concurrency    int
+	Priority       int
+	RateMultiplier *float64 // 账号计费倍率（>=0，允许 0）
+	GroupIDs       []int64
+	Tags           []string
+	// 可用时间窗口，nil 表示始终可用
+	AvailabilitySchedule *AvailabilitySchedule
+	ExpiresAt            *int64
+	AutoPauseOnExpired   *bool
 	// SkipMixedChannelCheck skips the mixed channel risk check when binding groups.
 	// This should only be set when the caller has explicitly confirmed the risk.
 	SkipMixedChannelCheck bool
@@ -165,6 +191,8 @@ type UpdateAccountInput struct {
 	RateMultiplier        *float64 // 账号计费倍率（>=0，允许 0）
 	Status                string
 	GroupIDs              *[]int64
+	Tags                  *[]string             // nil 表示不修改，空数组表示清除
+	AvailabilitySchedule  *AvailabilitySchedule // nil 表示不修改，windows 为空表示清除（始终可用）
 	ExpiresAt             *int64
 	AutoPauseOnExpired    *bool
 	SkipMixedChannelCheck bool // 跳过混合渠道检查（用户已确认风险）
@@ -181,6 +209,8 @@ type BulkUpdateAccountsInput struct {
 	Status         string
 	Schedulable    *bool
 	GroupIDs       *[]int64
+	AddTags        []string // 追加的标签
+	RemoveTags     []string // 移除的标签
 	Credentials    map[string]any
 	Extra          map[string]any
 	// SkipMixedChannelCheck skips the mixed channel risk check when binding groups.
@@ -437,31 +467,45 @@ func (s *adminServiceImpl) DeleteUser(ctx context.Context, id int64) error {
 	return nil
 }
 
-func (s *adminServiceImpl) UpdateUserBalance(ctx context.Context, userID int64, balance float64, operation string, notes string) (*User, error) {
+func (s *adminServiceImpl) UpdateUserBalance(ctx context.Context, userID int64, balance float64, operation string, notes string, adminID int64) (*User, error) {
 	user, err := s.userRepo.GetByID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 
 	oldBalance := user.Balance
+	newBalance := oldBalance
 
 	switch operation {
 	case "set":
-		user.Balance = balance
+		newBalance = balance
 	case "add":
-		user.Balance += balance
+		newBalance += balance
 	case "subtract":
-		user.Balance -= balance
+		newBalance -= balance
 	}
 
-	if user.Balance < 0 {
-		return nil, fmt.Errorf("balance cannot be negative, current balance: %.2f, requested operation would result in: %.2f", oldBalance, user.Balance)
+	if newBalance < 0 {
+		return nil, fmt.Errorf("balance cannot be negative, current balance: %.2f, requested operation would result in: %.2f", oldBalance, newBalance)
 	}
 
-	if err := s.userRepo.Update(ctx, user); err != nil {
-		return nil, err
+	// 以差额入账（而非覆盖余额），并发扣费不会丢失，且每次调整都有对应的余额流水
+	balanceDiff := newBalance - oldBalance
+	if balanceDiff != 0 {
+		entry := &BalanceLedgerEntry{
+			UserID:    userID,
+			EntryType: BalanceEntryAdminAdjustment,
+			Amount:    balanceDiff,
+			Notes:     notes,
+		}
+		if adminID > 0 {
+			entry.AdminID = &adminID
+		}
+		if err := s.userRepo.ApplyBalanceEntry(ctx, entry); err != nil {
+			return nil, err
+		}
+		user.Balance = entry.BalanceAfter
 	}
-	balanceDiff := user.Balance - oldBalance
 	if s.authCacheInvalidator != nil && balanceDiff != 0 {
 		s.authCacheInvalidator.InvalidateAuthCacheByUserID(ctx, userID)
 	}
@@ -572,23 +616,42 @@ func (s *adminServiceImpl) CreateGroup(ctx context.Context, input *CreateGroupIn
 		}
 	}
 
+	// 校验账号标签选择器
+	accountTagSelector, err := NormalizeTagSelector(input.AccountTagSelector)
+	if err != nil {
+		return nil, err
+	}
+	modelRoutingTags, err := normalizeModelRoutingTags(input.ModelRoutingTags)
+	if err != nil {
+		return nil, err
+	}
+	if !IsValidCostRoutingPolicy(input.CostRoutingPolicy) {
+		return nil, fmt.Errorf("invalid cost_routing_policy %q", input.CostRoutingPolicy)
+	}
+
 	group := &Group{
-		Name:             input.Name,
-		Description:      input.Description,
-		Platform:         platform,
-		RateMultiplier:   input.RateMultiplier,
-		IsExclusive:      input.IsExclusive,
-		Status:           StatusActive,
-		SubscriptionType: subscriptionType,
-		DailyLimitUSD:    dailyLimit,
-		WeeklyLimitUSD:   weeklyLimit,
-		MonthlyLimitUSD:  monthlyLimit,
-		ImagePrice1K:     imagePrice1K,
-		ImagePrice2K:     imagePrice2K,
-		ImagePrice4K:     imagePrice4K,
-		ClaudeCodeOnly:   input.ClaudeCodeOnly,
-		FallbackGroupID:  input.FallbackGroupID,
-		ModelRouting:     input.ModelRouting,
+		Name:               input.Name,
+		Description:        input.Description,
+		Platform:           platform,
+		RateMultiplier:     input.RateMultiplier,
+		IsExclusive:        input.IsExclusive,
+		Status:             StatusActive,
+		SubscriptionType:   subscriptionType,
+		DailyLimitUSD:      dailyLimit,
+		WeeklyLimitUSD:     weeklyLimit,
+		MonthlyLimitUSD:    monthlyLimit,
+		ImagePrice1K:       imagePrice1K,
+		ImagePrice2K:       imagePrice2K,
+		ImagePrice4K:       imagePrice4K,
+		ClaudeCodeOnly:     input.ClaudeCodeOnly,
+		FallbackGroupID:    input.FallbackGroupID,
+		ModelRouting:       input.ModelRouting,
+		ModelRoutingTags:   modelRoutingTags,
+		AccountTagSelector: accountTagSelector,
+		CostRoutingPolicy:  input.CostRoutingPolicy,
+		ModelMapping:       normalizeModelMapping(input.ModelMapping),
+		StickyPolicy:       input.StickyPolicy,
+		StickyTTLSeconds:   input.StickyTTLSeconds,
 	}
 	if err := s.groupRepo.Create(ctx, group); err != nil {
 		return nil, err
@@ -596,6 +659,52 @@ func (s *adminServiceImpl) CreateGroup(ctx context.Context, input *CreateGroupIn
 	return group, nil
 }
 
+// normalizeModelRoutingTags 校验模型路由标签选择器并去除空条目
+func normalizeModelRoutingTags(rules map[string]string) (map[string]string, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	out := make(map[string]string, len(rules))
+	for pattern, expr := range rules {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		selector, err := NormalizeTagSelector(expr)
+		if err != nil {
+			return nil, fmt.Errorf("model_routing_tags[%s]: %w", pattern, err)
+		}
+		if selector == "" {
+			continue
+		}
+		out[pattern] = selector
+	}
+	if len(out) == 0 {
+		return nil, nil
+	}
+	return out, nil
+}
+
+// normalizeModelMapping 去除模型映射中的空白与空条目
+func normalizeModelMapping(mapping map[string]string) map[string]string {
+	if len(mapping) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(mapping))
+	for pattern, target := range mapping {
+		pattern = strings.TrimSpace(pattern)
+		target = strings.TrimSpace(target)
+		if pattern == "" || target == "" {
+			continue
+		}
+		out[pattern] = target
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
 // normalizeLimit 将 0 或负数转换为 nil（表示无限制）
 func normalizeLimit(limit *float64) *float64 {
 	if limit == nil || *limit <= 0 {
@@ -724,6 +833,37 @@ func (s *adminServiceImpl) UpdateGroup(ctx context.Context, id int64, input *Upd
 	if input.ModelRoutingEnabled != nil {
 		group.ModelRoutingEnabled = *input.ModelRoutingEnabled
 	}
+	if input.ModelRoutingTags != nil {
+		modelRoutingTags, err := normalizeModelRoutingTags(input.ModelRoutingTags)
+		if err != nil {
+			return nil, err
+		}
+		group.ModelRoutingTags = modelRoutingTags
+	}
+	if input.AccountTagSelector != nil {
+		accountTagSelector, err := NormalizeTagSelector(*input.AccountTagSelector)
+		if err != nil {
+			return nil, err
+		}
+		group.AccountTagSelector = accountTagSelector
+	}
+	if input.CostRoutingPolicy != nil {
+		if !IsValidCostRoutingPolicy(*input.CostRoutingPolicy) {
+			return nil, fmt.Errorf("invalid cost_routing_policy %q", *input.CostRoutingPolicy)
+		}
+		group.CostRoutingPolicy = *input.CostRoutingPolicy
+	}
+	if input.ModelMapping != nil {
+		group.ModelMapping = normalizeModelMapping(input.ModelMapping)
+	}
+
+	// 粘性会话策略
+	if input.StickyPolicy != "" {
+		group.StickyPolicy = input.StickyPolicy
+	}
+	if input.StickyTTLSeconds != nil {
+		group.StickyTTLSeconds = *input.StickyTTLSeconds
+	}
 
 	if err := s.groupRepo.Update(ctx, group); err != nil {
 		return nil, err
@@ -858,6 +998,16 @@ func (s *adminServiceImpl) CreateAccount(ctx context.Context, input *CreateAccou
 		}
 		account.RateMultiplier = input.RateMultiplier
 	}
+	tags, err := NormalizeAccountTags(input.Tags)
+	if err != nil {
+		return nil, err
+	}
+	account.Tags = tags
+	schedule, err := NormalizeAvailabilitySchedule(input.AvailabilitySchedule)
+	if err != nil {
+		return nil, err
+	}
+	account.AvailabilitySchedule = schedule
 	if err := s.accountRepo.Create(ctx, account); err != nil {
 		return nil, err
 	}
@@ -930,6 +1080,20 @@ func (s *adminServiceImpl) UpdateAccount(ctx context.Context, id int64, input *U
 	if input.AutoPauseOnExpired != nil {
 		account.AutoPauseOnExpired = *input.AutoPauseOnExpired
 	}
+	if input.Tags != nil {
+		tags, err := NormalizeAccountTags(*input.Tags)
+		if err != nil {
+			return nil, err
+		}
+		account.Tags = tags
+	}
+	if input.AvailabilitySchedule != nil {
+		schedule, err := NormalizeAvailabilitySchedule(input.AvailabilitySchedule)
+		if err != nil {
+			return nil, err
+		}
+		account.AvailabilitySchedule = schedule
+	}
 
 	// 先验证分组是否存在（在任何写操作之前）
 	if input.GroupIDs != nil {
@@ -1021,6 +1185,18 @@ func (s *adminServiceImpl) BulkUpdateAccounts(ctx context.Context, input *BulkUp
 	if input.Schedulable != nil {
 		repoUpdates.Schedulable = input.Schedulable
 	}
+	if len(input.AddTags) > 0 || len(input.RemoveTags) > 0 {
+		addTags, err := NormalizeAccountTags(input.AddTags)
+		if err != nil {
+			return nil, err
+		}
+		removeTags, err := NormalizeAccountTags(input.RemoveTags)
+		if err != nil {
+			return nil, err
+		}
+		repoUpdates.AddTags = addTags
+		repoUpdates.RemoveTags = removeTags
+	}
 
 	// Run bulk update for column/jsonb fields first.
 	if _, err := s.accountRepo.BulkUpdate(ctx, input.AccountIDs, repoUpdates); err != nil {
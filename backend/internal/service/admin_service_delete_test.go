@@ -78,6 +78,10 @@ func (s *userRepoStub) DeductBalance(ctx context.Context, id int64, amount float
 	panic("unexpected DeductBalance call")
 }
 
+func (s *userRepoStub) ApplyBalanceEntry(ctx context.Context, entry *BalanceLedgerEntry) error {
+	panic("unexpected ApplyBalanceEntry call")
+}
+
 func (s *userRepoStub) UpdateConcurrency(ctx context.Context, id int64, amount int) error {
 	panic("unexpected UpdateConcurrency call")
 }
@@ -320,6 +324,38 @@ func (s *billingCacheStub) InvalidateSubscriptionCache(ctx context.Context, user
 	return nil
 }
 
+func (s *billingCacheStub) GetAPIKeyUsage(ctx context.Context, apiKeyID int64) (*APIKeyUsage, error) {
+	panic("unexpected GetAPIKeyUsage call")
+}
+
+func (s *billingCacheStub) SetAPIKeyUsage(ctx context.Context, apiKeyID int64, usage *APIKeyUsage) error {
+	panic("unexpected SetAPIKeyUsage call")
+}
+
+func (s *billingCacheStub) IncrementAPIKeyUsage(ctx context.Context, apiKeyID int64, cost float64, day, month string) error {
+	panic("unexpected IncrementAPIKeyUsage call")
+}
+
+func (s *billingCacheStub) MergeAPIKeyRequests(ctx context.Context, apiKeyID int64, day, month string, daily, monthly int64) error {
+	panic("unexpected MergeAPIKeyRequests call")
+}
+
+func (s *billingCacheStub) ReserveBalanceHold(ctx context.Context, hold *BalanceHold, fallbackBalance float64) (float64, bool, error) {
+	panic("unexpected ReserveBalanceHold call")
+}
+
+func (s *billingCacheStub) SettleBalanceHold(ctx context.Context, userID int64, holdID string, actualCost float64) error {
+	panic("unexpected SettleBalanceHold call")
+}
+
+func (s *billingCacheStub) ReleaseBalanceHold(ctx context.Context, userID int64, holdID string) error {
+	panic("unexpected ReleaseBalanceHold call")
+}
+
+func (s *billingCacheStub) ListBalanceHolds(ctx context.Context, userID int64) ([]BalanceHold, error) {
+	panic("unexpected ListBalanceHolds call")
+}
+
 func waitForInvalidations(t *testing.T, ch <-chan subscriptionInvalidateCall, expected int) []subscriptionInvalidateCall {
 	t.Helper()
 	calls := make([]subscriptionInvalidateCall, 0, expected)
@@ -11,22 +11,23 @@ import (
 
 type balanceUserRepoStub struct {
 	*userRepoStub
-	updateErr error
-	updated   []*User
+	applyErr error
+	applied  []*BalanceLedgerEntry
 }
 
-func (s *balanceUserRepoStub) Update(ctx context.Context, user *User) error {
-	if s.updateErr != nil {
-		return s.updateErr
+func (s *balanceUserRepoStub) ApplyBalanceEntry(ctx context.Context, entry *BalanceLedgerEntry) error {
+	if s.applyErr != nil {
+		return s.applyErr
 	}
-	if user == nil {
+	if entry == nil {
 		return nil
 	}
-	clone := *user
-	s.updated = append(s.updated, &clone)
-	if s.userRepoStub != nil {
-		s.userRepoStub.user = &clone
+	if s.userRepoStub != nil && s.userRepoStub.user != nil {
+		s.userRepoStub.user.Balance += entry.Amount
+		entry.BalanceAfter = s.userRepoStub.user.Balance
 	}
+	clone := *entry
+	s.applied = append(s.applied, &clone)
 	return nil
 }
 
@@ -73,10 +74,33 @@ func TestAdminService_UpdateUserBalance_InvalidatesAuthCache(t *testing.T) {
 		authCacheInvalidator: invalidator,
 	}
 
-	_, err := svc.UpdateUserBalance(context.Background(), 7, 5, "add", "")
+	user, err := svc.UpdateUserBalance(context.Background(), 7, 5, "add", "", 1)
 	require.NoError(t, err)
 	require.Equal(t, []int64{7}, invalidator.userIDs)
 	require.Len(t, redeemRepo.created, 1)
+	require.Equal(t, 15.0, user.Balance)
+	require.Len(t, repo.applied, 1)
+	require.Equal(t, BalanceEntryAdminAdjustment, repo.applied[0].EntryType)
+	require.Equal(t, 5.0, repo.applied[0].Amount)
+	require.NotNil(t, repo.applied[0].AdminID)
+	require.Equal(t, int64(1), *repo.applied[0].AdminID)
+}
+
+func TestAdminService_UpdateUserBalance_SetAppliesDifference(t *testing.T) {
+	baseRepo := &userRepoStub{user: &User{ID: 7, Balance: 10}}
+	repo := &balanceUserRepoStub{userRepoStub: baseRepo}
+	svc := &adminServiceImpl{
+		userRepo:       repo,
+		redeemCodeRepo: &balanceRedeemRepoStub{redeemRepoStub: &redeemRepoStub{}},
+	}
+
+	user, err := svc.UpdateUserBalance(context.Background(), 7, 4, "set", "refund", 0)
+	require.NoError(t, err)
+	require.Equal(t, 4.0, user.Balance)
+	require.Len(t, repo.applied, 1)
+	require.Equal(t, -6.0, repo.applied[0].Amount)
+	require.Equal(t, "refund", repo.applied[0].Notes)
+	require.Nil(t, repo.applied[0].AdminID)
 }
 
 func TestAdminService_UpdateUserBalance_NoChangeNoInvalidate(t *testing.T) {
@@ -90,8 +114,9 @@ func TestAdminService_UpdateUserBalance_NoChangeNoInvalidate(t *testing.T) {
 		authCacheInvalidator: invalidator,
 	}
 
-	_, err := svc.UpdateUserBalance(context.Background(), 7, 10, "set", "")
+	_, err := svc.UpdateUserBalance(context.Background(), 7, 10, "set", "", 1)
 	require.NoError(t, err)
 	require.Empty(t, invalidator.userIDs)
 	require.Empty(t, redeemRepo.created)
+	require.Empty(t, repo.applied)
 }
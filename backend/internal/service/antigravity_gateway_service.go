@@ -309,11 +309,12 @@ var antigravityPrefixMapping = []struct {
 
 // AntigravityGatewayService 处理 Antigravity 平台的 API 转发
 type AntigravityGatewayService struct {
-	accountRepo      AccountRepository
-	tokenProvider    *AntigravityTokenProvider
-	rateLimitService *RateLimitService
-	httpUpstream     HTTPUpstream
-	settingService   *SettingService
+	accountRepo         AccountRepository
+	tokenProvider       *AntigravityTokenProvider
+	rateLimitService    *RateLimitService
+	httpUpstream        HTTPUpstream
+	settingService      *SettingService
+	tokenCountEstimator *TokenCountEstimator
 }
 
 func NewAntigravityGatewayService(
@@ -323,13 +324,15 @@ func NewAntigravityGatewayService(
 	rateLimitService *RateLimitService,
 	httpUpstream HTTPUpstream,
 	settingService *SettingService,
+	tokenCountEstimator *TokenCountEstimator,
 ) *AntigravityGatewayService {
 	return &AntigravityGatewayService{
-		accountRepo:      accountRepo,
-		tokenProvider:    tokenProvider,
-		rateLimitService: rateLimitService,
-		httpUpstream:     httpUpstream,
-		settingService:   settingService,
+		accountRepo:         accountRepo,
+		tokenProvider:       tokenProvider,
+		rateLimitService:    rateLimitService,
+		httpUpstream:        httpUpstream,
+		settingService:      settingService,
+		tokenCountEstimator: tokenCountEstimator,
 	}
 }
 
@@ -1265,8 +1268,12 @@ func (s *AntigravityGatewayService) ForwardGemini(ctx context.Context, c *gin.Co
 	case "generateContent", "streamGenerateContent":
 		// ok
 	case "countTokens":
-		// 直接返回空值，不透传上游
-		c.JSON(http.StatusOK, map[string]any{"totalTokens": 0})
+		// 不透传上游：启用本地估算时返回估算值，否则返回空值
+		totalTokens := 0
+		if s.tokenCountEstimator.FallbackEnabled() {
+			totalTokens = s.tokenCountEstimator.EstimateGeminiRequest(originalModel, body)
+		}
+		c.JSON(http.StatusOK, map[string]any{"totalTokens": totalTokens})
 		return &ForwardResult{
 			RequestID:    "",
 			Usage:        ClaudeUsage{},
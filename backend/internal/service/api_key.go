@@ -11,6 +11,7 @@ type APIKey struct {
 	Status      string
 	IPWhitelist []string
 	IPBlacklist []string
+	Limits      APIKeyLimits
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 	User        *User
@@ -20,3 +21,8 @@ type APIKey struct {
 func (k *APIKey) IsActive() bool {
 	return k.Status == StatusActive
 }
+
+// IsExpired 检查 Key 是否已过有效期
+func (k *APIKey) IsExpired(now time.Time) bool {
+	return k.Limits.IsExpired(now)
+}
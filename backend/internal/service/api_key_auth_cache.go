@@ -1,5 +1,7 @@
 package service
 
+import "time"
+
 // APIKeyAuthSnapshot API Key 认证缓存快照（仅包含认证所需字段）
 type APIKeyAuthSnapshot struct {
 	APIKeyID    int64                    `json:"api_key_id"`
@@ -10,6 +12,21 @@ type APIKeyAuthSnapshot struct {
 	IPBlacklist []string                 `json:"ip_blacklist,omitempty"`
 	User        APIKeyAuthUserSnapshot   `json:"user"`
 	Group       *APIKeyAuthGroupSnapshot `json:"group,omitempty"`
+
+	// Key-level limits are enforced by billing eligibility checks on every request.
+	Limits *APIKeyAuthLimitsSnapshot `json:"limits,omitempty"`
+}
+
+// APIKeyAuthLimitsSnapshot API Key 限额快照
+type APIKeyAuthLimitsSnapshot struct {
+	ExpiresAt           *time.Time `json:"expires_at,omitempty"`
+	TotalLimitUSD       *float64   `json:"total_limit_usd,omitempty"`
+	DailyLimitUSD       *float64   `json:"daily_limit_usd,omitempty"`
+	MonthlyLimitUSD     *float64   `json:"monthly_limit_usd,omitempty"`
+	DailyRequestLimit   *int64     `json:"daily_request_limit,omitempty"`
+	MonthlyRequestLimit *int64     `json:"monthly_request_limit,omitempty"`
+	AllowedModels       []string   `json:"allowed_models,omitempty"`
+	AllowedPlatforms    []string   `json:"allowed_platforms,omitempty"`
 }
 
 // APIKeyAuthUserSnapshot 用户快照
@@ -42,6 +59,17 @@ type APIKeyAuthGroupSnapshot struct {
 	// Only anthropic groups use these fields; others may leave them empty.
 	ModelRouting        map[string][]int64 `json:"model_routing,omitempty"`
 	ModelRoutingEnabled bool               `json:"model_routing_enabled"`
+	ModelRoutingTags    map[string]string  `json:"model_routing_tags,omitempty"`
+
+	// Cost routing policy reorders load-aware candidates by effective account cost.
+	CostRoutingPolicy string `json:"cost_routing_policy,omitempty"`
+
+	// Model mapping is used when forwarding across protocols (e.g. Gemini native API on Claude accounts).
+	ModelMapping map[string]string `json:"model_mapping,omitempty"`
+
+	// Sticky session policy/TTL drive session hashing and binding TTL on the gateway hot path.
+	StickyPolicy     string `json:"sticky_policy,omitempty"`
+	StickyTTLSeconds int    `json:"sticky_ttl_seconds,omitempty"`
 }
 
 // APIKeyAuthCacheEntry 缓存条目，支持负缓存
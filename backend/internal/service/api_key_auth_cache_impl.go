@@ -221,6 +221,18 @@ func (s *APIKeyService) snapshotFromAPIKey(apiKey *APIKey) *APIKeyAuthSnapshot {
 			Concurrency: apiKey.User.Concurrency,
 		},
 	}
+	if l := apiKey.Limits; !l.IsZero() {
+		snapshot.Limits = &APIKeyAuthLimitsSnapshot{
+			ExpiresAt:           l.ExpiresAt,
+			TotalLimitUSD:       l.TotalLimitUSD,
+			DailyLimitUSD:       l.DailyLimitUSD,
+			MonthlyLimitUSD:     l.MonthlyLimitUSD,
+			DailyRequestLimit:   l.DailyRequestLimit,
+			MonthlyRequestLimit: l.MonthlyRequestLimit,
+			AllowedModels:       l.AllowedModels,
+			AllowedPlatforms:    l.AllowedPlatforms,
+		}
+	}
 	if apiKey.Group != nil {
 		snapshot.Group = &APIKeyAuthGroupSnapshot{
 			ID:                  apiKey.Group.ID,
@@ -239,6 +251,11 @@ func (s *APIKeyService) snapshotFromAPIKey(apiKey *APIKey) *APIKeyAuthSnapshot {
 			FallbackGroupID:     apiKey.Group.FallbackGroupID,
 			ModelRouting:        apiKey.Group.ModelRouting,
 			ModelRoutingEnabled: apiKey.Group.ModelRoutingEnabled,
+			ModelRoutingTags:    apiKey.Group.ModelRoutingTags,
+			CostRoutingPolicy:   apiKey.Group.CostRoutingPolicy,
+			ModelMapping:        apiKey.Group.ModelMapping,
+			StickyPolicy:        apiKey.Group.StickyPolicy,
+			StickyTTLSeconds:    apiKey.Group.StickyTTLSeconds,
 		}
 	}
 	return snapshot
@@ -264,6 +281,18 @@ func (s *APIKeyService) snapshotToAPIKey(key string, snapshot *APIKeyAuthSnapsho
 			Concurrency: snapshot.User.Concurrency,
 		},
 	}
+	if snapshot.Limits != nil {
+		apiKey.Limits = APIKeyLimits{
+			ExpiresAt:           snapshot.Limits.ExpiresAt,
+			TotalLimitUSD:       snapshot.Limits.TotalLimitUSD,
+			DailyLimitUSD:       snapshot.Limits.DailyLimitUSD,
+			MonthlyLimitUSD:     snapshot.Limits.MonthlyLimitUSD,
+			DailyRequestLimit:   snapshot.Limits.DailyRequestLimit,
+			MonthlyRequestLimit: snapshot.Limits.MonthlyRequestLimit,
+			AllowedModels:       snapshot.Limits.AllowedModels,
+			AllowedPlatforms:    snapshot.Limits.AllowedPlatforms,
+		}
+	}
 	if snapshot.Group != nil {
 		apiKey.Group = &Group{
 			ID:                  snapshot.Group.ID,
@@ -283,6 +312,11 @@ func (s *APIKeyService) snapshotToAPIKey(key string, snapshot *APIKeyAuthSnapsho
 			FallbackGroupID:     snapshot.Group.FallbackGroupID,
 			ModelRouting:        snapshot.Group.ModelRouting,
 			ModelRoutingEnabled: snapshot.Group.ModelRoutingEnabled,
+			ModelRoutingTags:    snapshot.Group.ModelRoutingTags,
+			CostRoutingPolicy:   snapshot.Group.CostRoutingPolicy,
+			ModelMapping:        snapshot.Group.ModelMapping,
+			StickyPolicy:        snapshot.Group.StickyPolicy,
+			StickyTTLSeconds:    snapshot.Group.StickyTTLSeconds,
 		}
 	}
 	return apiKey
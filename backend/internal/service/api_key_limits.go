@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
+)
+
+var (
+	ErrAPIKeyExpired                     = infraerrors.Unauthorized("API_KEY_EXPIRED", "api key has expired")
+	ErrAPIKeyModelNotAllowed             = infraerrors.Forbidden("API_KEY_MODEL_NOT_ALLOWED", "model is not allowed for this api key")
+	ErrAPIKeyPlatformNotAllowed          = infraerrors.Forbidden("API_KEY_PLATFORM_NOT_ALLOWED", "platform is not allowed for this api key")
+	ErrAPIKeyTotalLimitExceeded          = infraerrors.TooManyRequests("API_KEY_TOTAL_LIMIT_EXCEEDED", "api key total spend limit exceeded")
+	ErrAPIKeyDailyLimitExceeded          = infraerrors.TooManyRequests("API_KEY_DAILY_LIMIT_EXCEEDED", "api key daily spend limit exceeded")
+	ErrAPIKeyMonthlyLimitExceeded        = infraerrors.TooManyRequests("API_KEY_MONTHLY_LIMIT_EXCEEDED", "api key monthly spend limit exceeded")
+	ErrAPIKeyDailyRequestLimitExceeded   = infraerrors.TooManyRequests("API_KEY_DAILY_REQUEST_LIMIT_EXCEEDED", "api key daily request limit exceeded")
+	ErrAPIKeyMonthlyRequestLimitExceeded = infraerrors.TooManyRequests("API_KEY_MONTHLY_REQUEST_LIMIT_EXCEEDED", "api key monthly request limit exceeded")
+	ErrInvalidAPIKeyLimits               = infraerrors.BadRequest("INVALID_API_KEY_LIMITS", "invalid api key limits")
+)
+
+// APIKeyLimits API Key 级别的限制；字段为空表示不限制。
+// 消费额度按 actual_cost（用户实际扣费）计，日/月按系统时区的自然日/自然月统计。
+type APIKeyLimits struct {
+	ExpiresAt           *time.Time
+	TotalLimitUSD       *float64
+	DailyLimitUSD       *float64
+	MonthlyLimitUSD     *float64
+	DailyRequestLimit   *int64
+	MonthlyRequestLimit *int64
+	// AllowedModels 允许的模型（支持末尾 * 通配），按客户端请求的模型名匹配
+	AllowedModels []string
+	// AllowedPlatforms 允许的平台，按 Key 绑定分组的平台匹配
+	AllowedPlatforms []string
+}
+
+// IsZero 是否未配置任何限制
+func (l APIKeyLimits) IsZero() bool {
+	return !l.HasQuota() && l.ExpiresAt == nil && len(l.AllowedModels) == 0 && len(l.AllowedPlatforms) == 0
+}
+
+// HasQuota 是否配置了需要用量计数的限制（消费额度或请求数）
+func (l APIKeyLimits) HasQuota() bool {
+	return l.TotalLimitUSD != nil || l.DailyLimitUSD != nil || l.MonthlyLimitUSD != nil ||
+		l.DailyRequestLimit != nil || l.MonthlyRequestLimit != nil
+}
+
+// IsExpired 检查 Key 是否已过期
+func (l APIKeyLimits) IsExpired(now time.Time) bool {
+	return l.ExpiresAt != nil && !now.Before(*l.ExpiresAt)
+}
+
+// AllowsModel 检查模型是否在白名单内；未配置白名单或模型未知时放行
+func (l APIKeyLimits) AllowsModel(model string) bool {
+	if len(l.AllowedModels) == 0 || model == "" {
+		return true
+	}
+	for _, pattern := range l.AllowedModels {
+		if matchModelPattern(pattern, model) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsPlatform 检查平台是否在白名单内；未配置白名单时放行
+func (l APIKeyLimits) AllowsPlatform(platform string) bool {
+	if len(l.AllowedPlatforms) == 0 {
+		return true
+	}
+	for _, p := range l.AllowedPlatforms {
+		if p == platform {
+			return true
+		}
+	}
+	return false
+}
+
+// checkUsage 检查当前周期用量是否已达到上限
+func (l APIKeyLimits) checkUsage(usage *APIKeyUsage) error {
+	if usage == nil {
+		return nil
+	}
+	if l.TotalLimitUSD != nil && usage.TotalCost >= *l.TotalLimitUSD {
+		return ErrAPIKeyTotalLimitExceeded
+	}
+	if l.DailyLimitUSD != nil && usage.DailyCost >= *l.DailyLimitUSD {
+		return ErrAPIKeyDailyLimitExceeded
+	}
+	if l.MonthlyLimitUSD != nil && usage.MonthlyCost >= *l.MonthlyLimitUSD {
+		return ErrAPIKeyMonthlyLimitExceeded
+	}
+	if l.DailyRequestLimit != nil && usage.DailyRequests >= *l.DailyRequestLimit {
+		return ErrAPIKeyDailyRequestLimitExceeded
+	}
+	if l.MonthlyRequestLimit != nil && usage.MonthlyRequests >= *l.MonthlyRequestLimit {
+		return ErrAPIKeyMonthlyRequestLimitExceeded
+	}
+	return nil
+}
+
+// normalizeAPIKeyLimits 校验限额配置，并去除白名单中的空白与重复项
+func normalizeAPIKeyLimits(l APIKeyLimits) (APIKeyLimits, error) {
+	for _, v := range []*float64{l.TotalLimitUSD, l.DailyLimitUSD, l.MonthlyLimitUSD} {
+		if v != nil && *v < 0 {
+			return l, fmt.Errorf("%w: spend limit must be >= 0", ErrInvalidAPIKeyLimits)
+		}
+	}
+	for _, v := range []*int64{l.DailyRequestLimit, l.MonthlyRequestLimit} {
+		if v != nil && *v < 0 {
+			return l, fmt.Errorf("%w: request limit must be >= 0", ErrInvalidAPIKeyLimits)
+		}
+	}
+	l.AllowedModels = normalizeStringList(l.AllowedModels)
+	l.AllowedPlatforms = normalizeStringList(l.AllowedPlatforms)
+	for _, p := range l.AllowedPlatforms {
+		switch p {
+		case PlatformAnthropic, PlatformOpenAI, PlatformGemini, PlatformAntigravity:
+		default:
+			return l, fmt.Errorf("%w: unknown platform %q", ErrInvalidAPIKeyLimits, p)
+		}
+	}
+	return l, nil
+}
+
+func normalizeStringList(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(values))
+	seen := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		out = append(out, v)
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// APIKeyUsage API Key 限额计数（累计 + 当日/当月）
+type APIKeyUsage struct {
+	TotalCost       float64
+	DailyCost       float64
+	MonthlyCost     float64
+	DailyRequests   int64
+	MonthlyRequests int64
+	// Day/Month 计数所属周期（系统时区，YYYY-MM-DD / YYYY-MM）
+	Day   string
+	Month string
+}
+
+// APIKeyRequestCountRepository API Key 已接纳请求数的持久化计数（按自然日/自然月）
+// 请求数以此为准，Redis 缓存仅保存热计数；已接纳但失败的请求不会写入 usage_logs，因此不能从用量记录回源。
+type APIKeyRequestCountRepository interface {
+	// IncrementRequests 原子累加当日与当月请求数，返回累加后的计数
+	IncrementRequests(ctx context.Context, apiKeyID int64, day, month string, n int64) (daily, monthly int64, err error)
+	// GetRequests 读取当日与当月请求数
+	GetRequests(ctx context.Context, apiKeyID int64, day, month string) (daily, monthly int64, err error)
+}
+
+// apiKeyUsagePeriods 返回 now 所在的自然日/自然月标识
+func apiKeyUsagePeriods(now time.Time) (day, month string) {
+	local := now.In(timezone.Location())
+	return local.Format("2006-01-02"), local.Format("2006-01")
+}
+
+// rollTo 将计数滚动到指定周期：周期已切换时对应计数归零
+func (u *APIKeyUsage) rollTo(day, month string) {
+	if u.Day != day {
+		u.Day = day
+		u.DailyCost = 0
+		u.DailyRequests = 0
+	}
+	if u.Month != month {
+		u.Month = month
+		u.MonthlyCost = 0
+		u.MonthlyRequests = 0
+	}
+}
@@ -0,0 +1,183 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+type apiKeyQuotaCacheStub struct {
+	BillingCache
+	usage map[int64]*APIKeyUsage
+	sets  chan int64
+}
+
+func (s *apiKeyQuotaCacheStub) GetUserBalance(ctx context.Context, userID int64) (float64, error) {
+	return 10, nil
+}
+
+func (s *apiKeyQuotaCacheStub) GetAPIKeyUsage(ctx context.Context, apiKeyID int64) (*APIKeyUsage, error) {
+	if u, ok := s.usage[apiKeyID]; ok {
+		cp := *u
+		return &cp, nil
+	}
+	return nil, errors.New("cache miss")
+}
+
+func (s *apiKeyQuotaCacheStub) SetAPIKeyUsage(ctx context.Context, apiKeyID int64, usage *APIKeyUsage) error {
+	s.sets <- apiKeyID
+	return nil
+}
+
+func (s *apiKeyQuotaCacheStub) MergeAPIKeyRequests(ctx context.Context, apiKeyID int64, day, month string, daily, monthly int64) error {
+	// 缓存不存在时不处理，与 Redis 实现一致
+	if u, ok := s.usage[apiKeyID]; ok {
+		u.rollTo(day, month)
+		u.DailyRequests = max(u.DailyRequests, daily)
+		u.MonthlyRequests = max(u.MonthlyRequests, monthly)
+	}
+	return nil
+}
+
+type apiKeyQuotaUsageRepoStub struct {
+	UsageLogRepository
+	usage *APIKeyUsage
+	calls int
+}
+
+func (s *apiKeyQuotaUsageRepoStub) GetAPIKeyQuotaUsage(ctx context.Context, apiKeyID int64, dayStart, monthStart time.Time) (*APIKeyUsage, error) {
+	s.calls++
+	cp := *s.usage
+	return &cp, nil
+}
+
+func int64Ptr(v int64) *int64 { return &v }
+
+func TestCheckBillingEligibility_APIKeyAccess(t *testing.T) {
+	svc := NewBillingCacheService(&apiKeyQuotaCacheStub{}, nil, nil, nil, nil, nil, &config.Config{RunMode: config.RunModeSimple})
+	t.Cleanup(svc.Stop)
+
+	user := &User{ID: 1}
+	group := &Group{ID: 2, Platform: PlatformOpenAI}
+	past := time.Now().Add(-time.Minute)
+
+	cases := []struct {
+		name   string
+		limits APIKeyLimits
+		model  string
+		want   error
+	}{
+		{name: "no_limits", model: "gpt-5"},
+		{name: "expired", limits: APIKeyLimits{ExpiresAt: &past}, model: "gpt-5", want: ErrAPIKeyExpired},
+		{name: "platform_denied", limits: APIKeyLimits{AllowedPlatforms: []string{PlatformAnthropic}}, model: "gpt-5", want: ErrAPIKeyPlatformNotAllowed},
+		{name: "model_wildcard_allowed", limits: APIKeyLimits{AllowedModels: []string{"gpt-5*"}}, model: "gpt-5-codex"},
+		{name: "model_denied", limits: APIKeyLimits{AllowedModels: []string{"gpt-4o"}}, model: "gpt-5", want: ErrAPIKeyModelNotAllowed},
+		{name: "unknown_model_skips_allowlist", limits: APIKeyLimits{AllowedModels: []string{"gpt-4o"}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			apiKey := &APIKey{ID: 3, Limits: tc.limits}
+			err := svc.CheckBillingEligibility(context.Background(), user, apiKey, group, nil, tc.model)
+			if tc.want == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.want)
+			}
+		})
+	}
+}
+
+func TestCheckBillingEligibility_APIKeyQuota(t *testing.T) {
+	day, month := apiKeyUsagePeriods(time.Now())
+	cache := &apiKeyQuotaCacheStub{
+		usage: map[int64]*APIKeyUsage{
+			// 缓存中的日计数属于前一天，应按当前周期归零
+			1: {TotalCost: 4, DailyCost: 3, MonthlyCost: 3, DailyRequests: 9, MonthlyRequests: 9, Day: "2000-01-01", Month: month},
+		},
+		sets: make(chan int64, 4),
+	}
+	usageRepo := &apiKeyQuotaUsageRepoStub{usage: &APIKeyUsage{TotalCost: 1, DailyCost: 1, MonthlyCost: 1}}
+	counter := &apiKeyRequestCounterStub{}
+	_, _, err := counter.IncrementRequests(context.Background(), 2, day, month, 5)
+	require.NoError(t, err)
+	svc := NewBillingCacheService(cache, nil, nil, usageRepo, counter, nil, &config.Config{})
+	t.Cleanup(svc.Stop)
+
+	user := &User{ID: 1}
+	ctx := context.Background()
+
+	cached := &APIKey{ID: 1, Limits: APIKeyLimits{DailyLimitUSD: float64Ptr(2), DailyRequestLimit: int64Ptr(5)}}
+	require.NoError(t, svc.CheckBillingEligibility(ctx, user, cached, nil, nil, ""))
+	usage, err := svc.GetAPIKeyUsage(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, day, usage.Day)
+	require.Zero(t, usage.DailyRequests)
+	require.InDelta(t, 3.0, usage.MonthlyCost, 1e-9)
+
+	cached.Limits.TotalLimitUSD = float64Ptr(4)
+	require.ErrorIs(t, svc.CheckBillingEligibility(ctx, user, cached, nil, nil, ""), ErrAPIKeyTotalLimitExceeded)
+	require.Zero(t, usageRepo.calls)
+
+	// 缓存未命中：消费从 usage_logs、请求数从持久化计数回源，并同步回填缓存
+	fromDB := &APIKey{ID: 2, Limits: APIKeyLimits{DailyRequestLimit: int64Ptr(5)}}
+	require.ErrorIs(t, svc.CheckBillingEligibility(ctx, user, fromDB, nil, nil, ""), ErrAPIKeyDailyRequestLimitExceeded)
+	require.Equal(t, 1, usageRepo.calls)
+	select {
+	case id := <-cache.sets:
+		require.Equal(t, int64(2), id)
+	default:
+		t.Fatal("expected api key usage cache to be populated")
+	}
+}
+
+func TestCountAPIKeyRequest_LimitHoldsAcrossCacheExpiry(t *testing.T) {
+	cache := &apiKeyQuotaCacheStub{usage: map[int64]*APIKeyUsage{}, sets: make(chan int64, 8)}
+	// 已接纳的请求全部失败，usage_logs 中没有记录
+	usageRepo := &apiKeyQuotaUsageRepoStub{usage: &APIKeyUsage{}}
+	counter := &apiKeyRequestCounterStub{}
+	svc := NewBillingCacheService(cache, nil, nil, usageRepo, counter, nil, &config.Config{})
+	t.Cleanup(svc.Stop)
+
+	user := &User{ID: 1}
+	ctx := context.Background()
+	apiKey := &APIKey{ID: 7, Limits: APIKeyLimits{DailyRequestLimit: int64Ptr(3)}}
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, svc.CheckBillingEligibility(ctx, user, apiKey, nil, nil, ""))
+		svc.CountAPIKeyRequest(ctx, apiKey)
+	}
+
+	// 模拟缓存过期：回源时请求数仍来自持久化计数
+	delete(cache.usage, apiKey.ID)
+	require.ErrorIs(t, svc.CheckBillingEligibility(ctx, user, apiKey, nil, nil, ""), ErrAPIKeyDailyRequestLimitExceeded)
+
+	// 计数写入失败时不阻断请求
+	counter.err = errors.New("db down")
+	svc.CountAPIKeyRequest(ctx, &APIKey{ID: 8, Limits: apiKey.Limits})
+}
+
+func TestNormalizeAPIKeyLimits(t *testing.T) {
+	limits, err := normalizeAPIKeyLimits(APIKeyLimits{
+		AllowedModels:    []string{" claude-* ", "", "claude-*", "gpt-5"},
+		AllowedPlatforms: []string{PlatformAnthropic, " "},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"claude-*", "gpt-5"}, limits.AllowedModels)
+	require.Equal(t, []string{PlatformAnthropic}, limits.AllowedPlatforms)
+
+	_, err = normalizeAPIKeyLimits(APIKeyLimits{AllowedPlatforms: []string{"azure"}})
+	require.ErrorIs(t, err, ErrInvalidAPIKeyLimits)
+
+	_, err = normalizeAPIKeyLimits(APIKeyLimits{DailyLimitUSD: float64Ptr(-1)})
+	require.ErrorIs(t, err, ErrInvalidAPIKeyLimits)
+
+	limits, err = normalizeAPIKeyLimits(APIKeyLimits{AllowedModels: []string{" "}})
+	require.NoError(t, err)
+	require.True(t, limits.IsZero())
+}
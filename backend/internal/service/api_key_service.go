@@ -80,20 +80,22 @@ type APIKeyAuthCacheInvalidator interface {
 
 // CreateAPIKeyRequest 创建API Key请求
 type CreateAPIKeyRequest struct {
-	Name        string   `json:"name"`
-	GroupID     *int64   `json:"group_id"`
-	CustomKey   *string  `json:"custom_key"`   // 可选的自定义key
-	IPWhitelist []string `json:"ip_whitelist"` // IP 白名单
-	IPBlacklist []string `json:"ip_blacklist"` // IP 黑名单
+	Name        string       `json:"name"`
+	GroupID     *int64       `json:"group_id"`
+	CustomKey   *string      `json:"custom_key"`   // 可选的自定义key
+	IPWhitelist []string     `json:"ip_whitelist"` // IP 白名单
+	IPBlacklist []string     `json:"ip_blacklist"` // IP 黑名单
+	Limits      APIKeyLimits `json:"limits"`       // Key 级限额（零值表示不限制）
 }
 
 // UpdateAPIKeyRequest 更新API Key请求
 type UpdateAPIKeyRequest struct {
-	Name        *string  `json:"name"`
-	GroupID     *int64   `json:"group_id"`
-	Status      *string  `json:"status"`
-	IPWhitelist []string `json:"ip_whitelist"` // IP 白名单（空数组清空）
-	IPBlacklist []string `json:"ip_blacklist"` // IP 黑名单（空数组清空）
+	Name        *string       `json:"name"`
+	GroupID     *int64        `json:"group_id"`
+	Status      *string       `json:"status"`
+	IPWhitelist []string      `json:"ip_whitelist"` // IP 白名单（空数组清空）
+	IPBlacklist []string      `json:"ip_blacklist"` // IP 黑名单（空数组清空）
+	Limits      *APIKeyLimits `json:"limits"`       // Key 级限额（nil 表示不修改，零值清空）
 }
 
 // APIKeyService API Key服务
@@ -232,6 +234,11 @@ func (s *APIKeyService) Create(ctx context.Context, userID int64, req CreateAPIK
 		}
 	}
 
+	limits, err := normalizeAPIKeyLimits(req.Limits)
+	if err != nil {
+		return nil, err
+	}
+
 	// 验证分组权限（如果指定了分组）
 	if req.GroupID != nil {
 		group, err := s.groupRepo.GetByID(ctx, *req.GroupID)
@@ -289,6 +296,7 @@ func (s *APIKeyService) Create(ctx context.Context, userID int64, req CreateAPIK
 		Status:      StatusActive,
 		IPWhitelist: req.IPWhitelist,
 		IPBlacklist: req.IPBlacklist,
+		Limits:      limits,
 	}
 
 	if err := s.apiKeyRepo.Create(ctx, apiKey); err != nil {
@@ -440,6 +448,15 @@ func (s *APIKeyService) Update(ctx context.Context, id int64, userID int64, req
 	apiKey.IPWhitelist = req.IPWhitelist
 	apiKey.IPBlacklist = req.IPBlacklist
 
+	// 更新 Key 级限额（整体替换）
+	if req.Limits != nil {
+		limits, err := normalizeAPIKeyLimits(*req.Limits)
+		if err != nil {
+			return nil, err
+		}
+		apiKey.Limits = limits
+	}
+
 	if err := s.apiKeyRepo.Update(ctx, apiKey); err != nil {
 		return nil, fmt.Errorf("update api key: %w", err)
 	}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/google/uuid"
+	"github.com/tidwall/gjson"
+)
+
+// ErrBalanceFullyHeld 余额已被进行中的请求全部冻结
+var ErrBalanceFullyHeld = infraerrors.Forbidden("BALANCE_FULLY_HELD", "available balance is fully held by in-flight requests, please wait for them to finish")
+
+// BalanceHold 余额模式下的预授权冻结
+//
+// 请求开始时按估算费用（输入 token + max_tokens）从可用余额（余额 - 其他冻结）中冻结，
+// 可用余额不足估算时仅冻结剩余部分；可用余额为 0 时拒绝请求，防止并发长请求把余额扣成负数。
+// 请求完成时在 RecordUsage 中按实际费用结算，失败时释放；冻结带过期时间，进程异常退出时自动失效。
+type BalanceHold struct {
+	ID        string
+	UserID    int64
+	Model     string
+	Amount    float64
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// ReserveBalanceHold 余额模式下按估算费用预授权冻结
+// 不适用（简易模式、订阅模式、未启用、无 Redis、无法估价）时返回 nil；缓存异常时放行并记录告警。
+func (s *BillingCacheService) ReserveBalanceHold(ctx context.Context, user *User, apiKey *APIKey, group *Group, subscription *UserSubscription, model string, body []byte) (*BalanceHold, error) {
+	holdCfg := s.cfg.Billing.Holds
+	if s.cfg.RunMode == config.RunModeSimple || !holdCfg.Enabled || s.cache == nil || s.billingService == nil || user == nil || model == "" {
+		return nil, nil
+	}
+	if group != nil && group.IsSubscriptionType() && subscription != nil {
+		return nil, nil
+	}
+
+	multiplier := s.cfg.Default.RateMultiplier
+	var groupID *int64
+	if apiKey != nil && apiKey.GroupID != nil && apiKey.Group != nil {
+		multiplier = apiKey.Group.RateMultiplier
+		groupID = apiKey.GroupID
+	}
+	inputTokens, outputTokens := estimateHoldTokens(model, body, holdCfg.DefaultOutputTokens)
+	estimate, err := s.billingService.GetEstimatedCost(model, groupID, inputTokens, outputTokens, multiplier)
+	if err != nil || estimate <= 0 {
+		return nil, nil
+	}
+
+	balance, err := s.GetUserBalance(ctx, user.ID)
+	if err != nil {
+		log.Printf("Warning: balance hold skipped for user %d: %v", user.ID, err)
+		return nil, nil
+	}
+
+	now := time.Now()
+	hold := &BalanceHold{
+		ID:        uuid.NewString(),
+		UserID:    user.ID,
+		Model:     model,
+		Amount:    estimate,
+		CreatedAt: now,
+		ExpiresAt: now.Add(time.Duration(holdCfg.TTLSeconds) * time.Second),
+	}
+	reserved, ok, err := s.cache.ReserveBalanceHold(ctx, hold, balance)
+	if err != nil {
+		log.Printf("Warning: reserve balance hold failed for user %d: %v", user.ID, err)
+		return nil, nil
+	}
+	if !ok {
+		return nil, ErrBalanceFullyHeld
+	}
+	hold.Amount = reserved
+	return hold, nil
+}
+
+// SettleBalanceHold 按实际费用结算冻结：原子地移除冻结并扣减余额缓存（替代 QueueDeductBalance）
+func (s *BillingCacheService) SettleBalanceHold(hold *BalanceHold, actualCost float64) {
+	if hold == nil || s.cache == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cacheWriteTimeout)
+	defer cancel()
+	if err := s.cache.SettleBalanceHold(ctx, hold.UserID, hold.ID, actualCost); err != nil {
+		log.Printf("Warning: settle balance hold %s failed for user %d: %v", hold.ID, hold.UserID, err)
+	}
+}
+
+// ReleaseBalanceHold 释放未结算的冻结（请求失败或无需扣费时）
+func (s *BillingCacheService) ReleaseBalanceHold(hold *BalanceHold) {
+	if hold == nil || s.cache == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cacheWriteTimeout)
+	defer cancel()
+	if err := s.cache.ReleaseBalanceHold(ctx, hold.UserID, hold.ID); err != nil {
+		log.Printf("Warning: release balance hold %s failed for user %d: %v", hold.ID, hold.UserID, err)
+	}
+}
+
+// ListBalanceHolds 列出用户当前未过期的冻结
+func (s *BillingCacheService) ListBalanceHolds(ctx context.Context, userID int64) ([]BalanceHold, error) {
+	if s.cache == nil {
+		return nil, nil
+	}
+	holds, err := s.cache.ListBalanceHolds(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	active := holds[:0]
+	for _, h := range holds {
+		if now.Before(h.ExpiresAt) {
+			active = append(active, h)
+		}
+	}
+	return active, nil
+}
+
+// estimateHoldTokens 从请求体估算输入 token 与最大输出 token
+// 输出优先取 max_tokens / max_output_tokens / max_completion_tokens / generationConfig.maxOutputTokens，
+// 均未提供时使用 defaultOutput；输入与本地 count_tokens 一致，只统计消息、系统提示与工具定义的内容，
+// 不计 model/role/type 等结构字段，图片/文件按 tokenizer 的固定 token 数计。
+func estimateHoldTokens(model string, body []byte, defaultOutput int) (input, output int) {
+	output = defaultOutput
+	for _, path := range []string{"max_tokens", "max_output_tokens", "max_completion_tokens", "generationConfig.maxOutputTokens"} {
+		if v := gjson.GetBytes(body, path); v.Exists() && v.Int() > 0 {
+			output = int(v.Int())
+			break
+		}
+	}
+	if !gjson.ValidBytes(body) {
+		return 0, output
+	}
+
+	root := gjson.ParseBytes(body)
+	switch {
+	case root.Get("contents").Exists(), root.Get("generateContentRequest").Exists(), root.Get("request").Exists():
+		input = estimateGeminiRequestTokens(model, body)
+	case root.Get("input").Exists(), root.Get("instructions").Exists():
+		input = estimateOpenAIResponsesRequestTokens(model, body)
+	default:
+		input = estimateClaudeRequestTokens(model, body)
+	}
+	return input, output
+}
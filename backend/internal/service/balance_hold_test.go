@@ -0,0 +1,172 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/tokenizer"
+	"github.com/stretchr/testify/require"
+)
+
+type balanceHoldCacheStub struct {
+	BillingCache
+	balance  float64
+	held     float64
+	reserved []*BalanceHold
+	settled  map[string]float64
+	released []string
+}
+
+func (s *balanceHoldCacheStub) GetUserBalance(ctx context.Context, userID int64) (float64, error) {
+	return s.balance, nil
+}
+
+func (s *balanceHoldCacheStub) ReserveBalanceHold(ctx context.Context, hold *BalanceHold, fallbackBalance float64) (float64, bool, error) {
+	available := fallbackBalance - s.held
+	if available <= 0 {
+		return 0, false, nil
+	}
+	amount := min(hold.Amount, available)
+	s.held += amount
+	s.reserved = append(s.reserved, hold)
+	return amount, true, nil
+}
+
+func (s *balanceHoldCacheStub) SettleBalanceHold(ctx context.Context, userID int64, holdID string, actualCost float64) error {
+	s.settled[holdID] = actualCost
+	return nil
+}
+
+func (s *balanceHoldCacheStub) ReleaseBalanceHold(ctx context.Context, userID int64, holdID string) error {
+	s.released = append(s.released, holdID)
+	return nil
+}
+
+func newBalanceHoldTestService(cache BillingCache) *BillingCacheService {
+	cfg := &config.Config{}
+	cfg.Default.RateMultiplier = 1
+	cfg.Billing.Holds = config.BalanceHoldConfig{Enabled: true, DefaultOutputTokens: 1000, TTLSeconds: 600}
+	return NewBillingCacheService(cache, nil, nil, nil, nil, NewBillingService(cfg, nil, nil), cfg)
+}
+
+func TestReserveBalanceHold_ClampsAndRejectsWhenFullyHeld(t *testing.T) {
+	cache := &balanceHoldCacheStub{balance: 0.1, settled: map[string]float64{}}
+	svc := newBalanceHoldTestService(cache)
+	t.Cleanup(svc.Stop)
+
+	user := &User{ID: 1}
+	body := []byte(`{"model":"claude-3-opus","max_tokens":32000,"messages":[{"role":"user","content":"hi"}]}`)
+
+	hold, err := svc.ReserveBalanceHold(context.Background(), user, &APIKey{ID: 2}, nil, nil, "claude-3-opus", body)
+	require.NoError(t, err)
+	require.NotNil(t, hold)
+	require.InDelta(t, 0.1, hold.Amount, 1e-9, "estimate exceeds balance, hold is clamped to what is available")
+	require.Equal(t, int64(1), hold.UserID)
+	require.True(t, hold.ExpiresAt.After(hold.CreatedAt))
+
+	_, err = svc.ReserveBalanceHold(context.Background(), user, &APIKey{ID: 2}, nil, nil, "claude-3-opus", body)
+	require.ErrorIs(t, err, ErrBalanceFullyHeld)
+
+	svc.SettleBalanceHold(hold, 0.02)
+	require.InDelta(t, 0.02, cache.settled[hold.ID], 1e-9)
+	svc.ReleaseBalanceHold(hold)
+	require.Equal(t, []string{hold.ID}, cache.released)
+}
+
+func TestReserveBalanceHold_SkipsWhenNotApplicable(t *testing.T) {
+	cache := &balanceHoldCacheStub{balance: 10, settled: map[string]float64{}}
+	svc := newBalanceHoldTestService(cache)
+	t.Cleanup(svc.Stop)
+
+	user := &User{ID: 1}
+	body := []byte(`{"model":"claude-3-opus","messages":[]}`)
+	subGroup := &Group{ID: 3, SubscriptionType: SubscriptionTypeSubscription}
+
+	hold, err := svc.ReserveBalanceHold(context.Background(), user, &APIKey{ID: 2}, subGroup, &UserSubscription{ID: 4}, "claude-3-opus", body)
+	require.NoError(t, err)
+	require.Nil(t, hold, "subscription billing does not hold balance")
+
+	hold, err = svc.ReserveBalanceHold(context.Background(), user, &APIKey{ID: 2}, nil, nil, "", body)
+	require.NoError(t, err)
+	require.Nil(t, hold, "request without model is not held")
+
+	svc.cfg.Billing.Holds.Enabled = false
+	hold, err = svc.ReserveBalanceHold(context.Background(), user, &APIKey{ID: 2}, nil, nil, "claude-3-opus", body)
+	require.NoError(t, err)
+	require.Nil(t, hold)
+	require.Empty(t, cache.reserved)
+
+	// nil hold is a no-op
+	svc.SettleBalanceHold(nil, 1)
+	svc.ReleaseBalanceHold(nil)
+	require.Empty(t, cache.released)
+}
+
+func TestEstimateHoldTokens(t *testing.T) {
+	const model = "claude-3-opus"
+	imageTokens := tokenizer.ForModel(model).Profile().ImageTokens
+	body := []byte(`{
+		"model": "claude-3-opus",
+		"max_tokens": 2048,
+		"messages": [{"role": "user", "content": [
+			{"type": "text", "text": "abcdefgh"},
+			{"type": "image", "source": {"type": "base64", "media_type": "image/png", "data": "AAAA"}}
+		]}]
+	}`)
+	input, output := estimateHoldTokens(model, body, 100)
+	require.Equal(t, 2048, output)
+	require.Equal(t, estimateClaudeRequestTokens(model, body), input)
+	require.GreaterOrEqual(t, input, imageTokens+2)
+	require.Less(t, input, imageTokens+50)
+
+	_, output = estimateHoldTokens(model, []byte(`{"input":"hi","max_output_tokens":512}`), 100)
+	require.Equal(t, 512, output)
+	_, output = estimateHoldTokens(model, []byte(`{"contents":[],"generationConfig":{"maxOutputTokens":64}}`), 100)
+	require.Equal(t, 64, output)
+	_, output = estimateHoldTokens(model, []byte(`{"messages":[]}`), 100)
+	require.Equal(t, 100, output)
+}
+
+func TestEstimateHoldTokens_CountsContentOnly(t *testing.T) {
+	const model = "claude-3-opus"
+	imageTokens := tokenizer.ForModel(model).Profile().ImageTokens
+
+	// model/role/type/metadata 等结构字段不计入，data: 文本也不按图片计
+	plain, _ := estimateHoldTokens(model, []byte(`{"messages":[{"role":"user","content":"hello"}]}`), 0)
+	noisy, _ := estimateHoldTokens(model, []byte(`{
+		"model": "claude-3-opus-20240229-with-a-very-long-model-name",
+		"metadata": {"user_id": "data:image/png;base64,AAAAAAAAAAAAAAAA"},
+		"stream": true,
+		"messages": [{"role": "user", "content": "hello"}]
+	}`), 0)
+	require.Equal(t, plain, noisy)
+	require.Less(t, plain, imageTokens)
+
+	withDataText, _ := estimateHoldTokens(model, []byte(`{"messages":[{"role":"user","content":"data:hello"}]}`), 0)
+	require.Less(t, withDataText, imageTokens)
+
+	// OpenAI Responses：只计 instructions、输入内容与工具定义，图片按固定 token 数计
+	responses := []byte(`{
+		"model": "gpt-4o",
+		"instructions": "be brief",
+		"input": [
+			{"type": "message", "role": "user", "content": [
+				{"type": "input_text", "text": "describe"},
+				{"type": "input_image", "image_url": "data:image/png;base64,AAAA"}
+			]},
+			{"type": "function_call", "call_id": "call_1", "name": "lookup", "arguments": "{}"}
+		],
+		"tools": [{"type": "function", "name": "lookup", "parameters": {"type": "object"}}]
+	}`)
+	input, _ := estimateHoldTokens("gpt-4o", responses, 0)
+	require.Equal(t, estimateOpenAIResponsesRequestTokens("gpt-4o", responses), input)
+	require.GreaterOrEqual(t, input, tokenizer.ForModel("gpt-4o").Profile().ImageTokens)
+
+	// Gemini 请求按 contents 统计
+	gemini := []byte(`{"contents":[{"role":"user","parts":[{"text":"hello"}]}]}`)
+	input, _ = estimateHoldTokens("gemini-2.5-pro", gemini, 0)
+	require.Equal(t, estimateGeminiRequestTokens("gemini-2.5-pro", gemini), input)
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+// 余额流水类型
+const (
+	// BalanceEntryInitial 初始余额（注册赠送、管理员创建用户或迁移时的期初余额）
+	BalanceEntryInitial = "initial"
+	// BalanceEntryUsage 用量扣费
+	BalanceEntryUsage = "usage"
+	// BalanceEntryRedeem 兑换码充值
+	BalanceEntryRedeem = "redeem"
+	// BalanceEntryPromo 优惠码赠送
+	BalanceEntryPromo = "promo"
+	// BalanceEntryTopup 充值订单入账
+	BalanceEntryTopup = "topup"
+	// BalanceEntryAdminAdjustment 管理员调整
+	BalanceEntryAdminAdjustment = "admin_adjustment"
+	// BalanceEntryAdjustment 其他系统调整
+	BalanceEntryAdjustment = "adjustment"
+)
+
+// BalanceLedgerEntry 余额流水（只追加）
+// Amount 为正表示入账、为负表示扣减；BalanceAfter 为本次变动后的用户余额。
+// 来源引用按类型填写：用量扣费关联 usage log，兑换码/优惠码/充值订单关联对应记录，管理员调整记录操作人。
+type BalanceLedgerEntry struct {
+	ID           int64
+	UserID       int64
+	EntryType    string
+	Amount       float64
+	BalanceAfter float64
+	UsageLogID   *int64
+	RedeemCodeID *int64
+	PromoCodeID  *int64
+	TopupOrderID *int64
+	AdminID      *int64
+	Notes        string
+	CreatedAt    time.Time
+}
+
+// BalanceLedgerFilters 账单查询过滤条件
+type BalanceLedgerFilters struct {
+	EntryType string
+	StartTime *time.Time
+	EndTime   *time.Time
+}
+
+// UserBalanceSnapshot 对账快照：用户当前余额与流水合计
+type UserBalanceSnapshot struct {
+	UserID     int64
+	Balance    float64
+	LedgerSum  float64
+	EntryCount int64
+}
+
+// BalanceLedgerRepository 余额流水查询
+// 写入由 UserRepository.ApplyBalanceEntry 与余额变动在同一条语句中完成，保证流水与余额一致。
+type BalanceLedgerRepository interface {
+	ListByUser(ctx context.Context, userID int64, params pagination.PaginationParams, filters BalanceLedgerFilters) ([]BalanceLedgerEntry, *pagination.PaginationResult, error)
+	// ListBalanceSnapshots 按用户 ID 升序返回 afterUserID 之后的 limit 个用户的余额与流水合计
+	ListBalanceSnapshots(ctx context.Context, afterUserID int64, limit int) ([]UserBalanceSnapshot, error)
+}
+
+// usageBalanceEntry 构造用量扣费流水；usage log 未写入（写入失败）时不关联
+func usageBalanceEntry(userID int64, actualCost float64, usageLog *UsageLog, inserted bool) *BalanceLedgerEntry {
+	entry := &BalanceLedgerEntry{
+		UserID:    userID,
+		EntryType: BalanceEntryUsage,
+		Amount:    -actualCost,
+	}
+	if inserted && usageLog != nil && usageLog.ID > 0 {
+		id := usageLog.ID
+		entry.UsageLogID = &id
+	}
+	return entry
+}
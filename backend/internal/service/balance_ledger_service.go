@@ -0,0 +1,366 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	balanceReconcileJobName = "balance_ledger_reconcile"
+
+	// balanceReconcileTimeout 单轮对账超时
+	balanceReconcileTimeout = 10 * time.Minute
+
+	balanceReconcileDefaultBatchSize = 500
+
+	balanceReconcileLeaderLockKey = "balance_ledger_reconcile:leader"
+)
+
+var balanceReconcileReleaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+  return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// BalanceDrift 单个用户的对账差异
+type BalanceDrift struct {
+	UserID    int64   `json:"user_id"`
+	Balance   float64 `json:"balance"`
+	LedgerSum float64 `json:"ledger_sum"`
+	// LedgerDrift = Balance - LedgerSum（绕过流水修改余额时非零）
+	LedgerDrift float64 `json:"ledger_drift"`
+	// CacheBalance/CacheDrift 仅在 Redis 缓存存在且与数据库余额不一致时填写
+	CacheBalance *float64 `json:"cache_balance,omitempty"`
+	CacheDrift   *float64 `json:"cache_drift,omitempty"`
+}
+
+// BalanceReconciliationReport 一轮对账结果
+type BalanceReconciliationReport struct {
+	StartedAt        time.Time      `json:"started_at"`
+	FinishedAt       time.Time      `json:"finished_at"`
+	DurationMs       int64          `json:"duration_ms"`
+	UsersChecked     int64          `json:"users_checked"`
+	CacheChecked     int64          `json:"cache_checked"`
+	LedgerDriftCount int64          `json:"ledger_drift_count"`
+	CacheDriftCount  int64          `json:"cache_drift_count"`
+	TotalLedgerDrift float64        `json:"total_ledger_drift"`
+	Drifts           []BalanceDrift `json:"drifts"`
+	// Truncated 漂移明细超过 max_reported_drifts 时为 true（计数仍为全量）
+	Truncated bool   `json:"truncated"`
+	Error     string `json:"error,omitempty"`
+}
+
+// BalanceLedgerService 余额流水服务：用户账单查询与定时对账。
+//
+// 所有余额变动都通过 UserRepository.ApplyBalanceEntry 追加流水，因此流水合计应始终等于 users.balance。
+// 对账任务定期逐批比对两者，并比对 Redis 余额缓存（缓存扣减为异步，允许配置误差），
+// 只报告漂移不自动修正，由管理员排查。多实例部署时定时对账通过 Redis 选主，仅一个实例执行。
+type BalanceLedgerService struct {
+	ledgerRepo   BalanceLedgerRepository
+	billingCache BillingCache
+	opsService   *OpsService
+	redisClient  *redis.Client
+	cfg          *config.Config
+
+	instanceID string
+
+	distributedLockOn bool
+	warnNoRedisOnce   sync.Once
+
+	runMu      sync.Mutex
+	reportMu   sync.RWMutex
+	lastReport *BalanceReconciliationReport
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stopCtx   context.Context
+	stop      context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+// NewBalanceLedgerService creates a new BalanceLedgerService
+func NewBalanceLedgerService(
+	ledgerRepo BalanceLedgerRepository,
+	billingCache BillingCache,
+	opsService *OpsService,
+	redisClient *redis.Client,
+	cfg *config.Config,
+) *BalanceLedgerService {
+	return &BalanceLedgerService{
+		ledgerRepo:        ledgerRepo,
+		billingCache:      billingCache,
+		opsService:        opsService,
+		redisClient:       redisClient,
+		cfg:               cfg,
+		instanceID:        uuid.NewString(),
+		distributedLockOn: cfg == nil || strings.TrimSpace(cfg.RunMode) != config.RunModeSimple,
+	}
+}
+
+// ListStatement 返回用户的余额流水（按时间倒序）
+func (s *BalanceLedgerService) ListStatement(ctx context.Context, userID int64, params pagination.PaginationParams, filters BalanceLedgerFilters) ([]BalanceLedgerEntry, *pagination.PaginationResult, error) {
+	entries, result, err := s.ledgerRepo.ListByUser(ctx, userID, params, filters)
+	if err != nil {
+		return nil, nil, fmt.Errorf("list balance ledger: %w", err)
+	}
+	return entries, result, nil
+}
+
+// LastReport 返回本实例最近一次对账结果（未运行过时为 nil）
+func (s *BalanceLedgerService) LastReport() *BalanceReconciliationReport {
+	s.reportMu.RLock()
+	defer s.reportMu.RUnlock()
+	return s.lastReport
+}
+
+func (s *BalanceLedgerService) enabled() bool {
+	return s != nil && s.cfg != nil && s.cfg.Ledger.ReconcileEnabled && s.ledgerRepo != nil
+}
+
+func (s *BalanceLedgerService) interval() time.Duration {
+	return time.Duration(s.cfg.Ledger.ReconcileIntervalMinutes) * time.Minute
+}
+
+func (s *BalanceLedgerService) Start() {
+	s.StartWithContext(context.Background())
+}
+
+func (s *BalanceLedgerService) StartWithContext(ctx context.Context) {
+	if !s.enabled() {
+		return
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	s.startOnce.Do(func() {
+		s.stopCtx, s.stop = context.WithCancel(ctx)
+		s.wg.Add(1)
+		go s.run()
+		log.Printf("[BalanceLedger] Reconciliation started (interval: %v)", s.interval())
+	})
+}
+
+func (s *BalanceLedgerService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		if s.stop != nil {
+			s.stop()
+		}
+	})
+	s.wg.Wait()
+}
+
+func (s *BalanceLedgerService) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.interval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.runOnce()
+		case <-s.stopCtx.Done():
+			return
+		}
+	}
+}
+
+func (s *BalanceLedgerService) runOnce() {
+	ctx, cancel := context.WithTimeout(s.stopCtx, balanceReconcileTimeout)
+	defer cancel()
+
+	release, ok := s.tryAcquireLeaderLock(ctx)
+	if !ok {
+		return
+	}
+	if release != nil {
+		defer release()
+	}
+	_, _ = s.Reconcile(ctx)
+}
+
+func (s *BalanceLedgerService) tryAcquireLeaderLock(ctx context.Context) (func(), bool) {
+	if !s.distributedLockOn {
+		return nil, true
+	}
+	if s.redisClient == nil {
+		s.warnNoRedisOnce.Do(func() {
+			log.Printf("[BalanceLedger] redis not configured; running without distributed lock")
+		})
+		return nil, true
+	}
+
+	// 锁有效期覆盖单轮对账超时，避免慢对账期间被其他实例抢占
+	ok, err := s.redisClient.SetNX(ctx, balanceReconcileLeaderLockKey, s.instanceID, balanceReconcileTimeout).Result()
+	if err != nil {
+		log.Printf("[BalanceLedger] leader lock SetNX failed; skipping this cycle: %v", err)
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+	return func() {
+		releaseCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		_, _ = balanceReconcileReleaseScript.Run(releaseCtx, s.redisClient, []string{balanceReconcileLeaderLockKey}, s.instanceID).Result()
+	}, true
+}
+
+// Reconcile 执行一轮对账：逐批比对流水合计、users.balance 与 Redis 余额缓存。
+// 同一实例上的并发调用会串行执行。
+func (s *BalanceLedgerService) Reconcile(ctx context.Context) (*BalanceReconciliationReport, error) {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	report := &BalanceReconciliationReport{StartedAt: time.Now().UTC(), Drifts: []BalanceDrift{}}
+	err := s.reconcile(ctx, report)
+	report.FinishedAt = time.Now().UTC()
+	report.DurationMs = report.FinishedAt.Sub(report.StartedAt).Milliseconds()
+	sort.SliceStable(report.Drifts, func(i, j int) bool {
+		return math.Abs(report.Drifts[i].LedgerDrift) > math.Abs(report.Drifts[j].LedgerDrift)
+	})
+
+	if err != nil {
+		report.Error = err.Error()
+		log.Printf("[BalanceLedger] Reconciliation failed: %v", err)
+		s.recordHeartbeatError(report.StartedAt, time.Duration(report.DurationMs)*time.Millisecond, err)
+	} else {
+		if report.LedgerDriftCount > 0 || report.CacheDriftCount > 0 {
+			log.Printf("[BalanceLedger] Reconciliation found drift: users=%d ledger_drift=%d (total %.8f) cache_drift=%d",
+				report.UsersChecked, report.LedgerDriftCount, report.TotalLedgerDrift, report.CacheDriftCount)
+		}
+		s.recordHeartbeatSuccess(report.StartedAt, time.Duration(report.DurationMs)*time.Millisecond, fmt.Sprintf(
+			"users=%d ledger_drift=%d cache_drift=%d", report.UsersChecked, report.LedgerDriftCount, report.CacheDriftCount))
+	}
+
+	s.reportMu.Lock()
+	s.lastReport = report
+	s.reportMu.Unlock()
+	return report, err
+}
+
+func (s *BalanceLedgerService) reconcile(ctx context.Context, report *BalanceReconciliationReport) error {
+	if s == nil || s.ledgerRepo == nil {
+		return fmt.Errorf("balance ledger repository not configured")
+	}
+	batchSize := balanceReconcileDefaultBatchSize
+	tolerance := 0.0
+	maxDrifts := 0
+	checkCache := false
+	if s.cfg != nil {
+		if s.cfg.Ledger.BatchSize > 0 {
+			batchSize = s.cfg.Ledger.BatchSize
+		}
+		tolerance = s.cfg.Ledger.DriftTolerance
+		maxDrifts = s.cfg.Ledger.MaxReportedDrifts
+		checkCache = s.cfg.Ledger.CheckCache
+	}
+	checkCache = checkCache && s.billingCache != nil
+
+	var afterID int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		snapshots, err := s.ledgerRepo.ListBalanceSnapshots(ctx, afterID, batchSize)
+		if err != nil {
+			return fmt.Errorf("list balance snapshots: %w", err)
+		}
+		for i := range snapshots {
+			snap := &snapshots[i]
+			report.UsersChecked++
+
+			drift := BalanceDrift{
+				UserID:      snap.UserID,
+				Balance:     snap.Balance,
+				LedgerSum:   snap.LedgerSum,
+				LedgerDrift: roundBalance(snap.Balance - snap.LedgerSum),
+			}
+			drifted := false
+			if math.Abs(drift.LedgerDrift) > tolerance {
+				report.LedgerDriftCount++
+				report.TotalLedgerDrift = roundBalance(report.TotalLedgerDrift + drift.LedgerDrift)
+				drifted = true
+			}
+			if checkCache {
+				// 缓存未命中（未建立或已过期）不视为漂移
+				if cached, err := s.billingCache.GetUserBalance(ctx, snap.UserID); err == nil {
+					report.CacheChecked++
+					if cacheDrift := roundBalance(cached - snap.Balance); math.Abs(cacheDrift) > tolerance {
+						report.CacheDriftCount++
+						drift.CacheBalance = &cached
+						drift.CacheDrift = &cacheDrift
+						drifted = true
+					}
+				}
+			}
+			if drifted {
+				if len(report.Drifts) < maxDrifts {
+					report.Drifts = append(report.Drifts, drift)
+				} else {
+					report.Truncated = true
+				}
+			}
+		}
+		if len(snapshots) < batchSize {
+			return nil
+		}
+		afterID = snapshots[len(snapshots)-1].UserID
+	}
+}
+
+// roundBalance 按数据库精度（8 位小数）取整，避免浮点误差被误报为漂移
+func roundBalance(v float64) float64 {
+	return math.Round(v*1e8) / 1e8
+}
+
+func (s *BalanceLedgerService) recordHeartbeatSuccess(runAt time.Time, duration time.Duration, result string) {
+	if s.opsService == nil || s.opsService.opsRepo == nil {
+		return
+	}
+	now := time.Now().UTC()
+	durMs := duration.Milliseconds()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	msg := truncateString(result, 2048)
+	_ = s.opsService.opsRepo.UpsertJobHeartbeat(ctx, &OpsUpsertJobHeartbeatInput{
+		JobName:        balanceReconcileJobName,
+		LastRunAt:      &runAt,
+		LastSuccessAt:  &now,
+		LastDurationMs: &durMs,
+		LastResult:     &msg,
+	})
+}
+
+func (s *BalanceLedgerService) recordHeartbeatError(runAt time.Time, duration time.Duration, err error) {
+	if s.opsService == nil || s.opsService.opsRepo == nil || err == nil {
+		return
+	}
+	now := time.Now().UTC()
+	durMs := duration.Milliseconds()
+	msg := truncateString(err.Error(), 2048)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_ = s.opsService.opsRepo.UpsertJobHeartbeat(ctx, &OpsUpsertJobHeartbeatInput{
+		JobName:        balanceReconcileJobName,
+		LastRunAt:      &runAt,
+		LastErrorAt:    &now,
+		LastError:      &msg,
+		LastDurationMs: &durMs,
+	})
+}
@@ -0,0 +1,114 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/stretchr/testify/require"
+)
+
+type ledgerRepoStub struct {
+	snapshots []UserBalanceSnapshot
+	calls     []int64
+}
+
+func (s *ledgerRepoStub) ListByUser(ctx context.Context, userID int64, params pagination.PaginationParams, filters BalanceLedgerFilters) ([]BalanceLedgerEntry, *pagination.PaginationResult, error) {
+	panic("unexpected ListByUser call")
+}
+
+func (s *ledgerRepoStub) ListBalanceSnapshots(ctx context.Context, afterUserID int64, limit int) ([]UserBalanceSnapshot, error) {
+	s.calls = append(s.calls, afterUserID)
+	out := make([]UserBalanceSnapshot, 0, limit)
+	for _, snap := range s.snapshots {
+		if snap.UserID > afterUserID && len(out) < limit {
+			out = append(out, snap)
+		}
+	}
+	return out, nil
+}
+
+type ledgerBillingCacheStub struct {
+	BillingCache
+	balances map[int64]float64
+}
+
+func (s *ledgerBillingCacheStub) GetUserBalance(ctx context.Context, userID int64) (float64, error) {
+	if v, ok := s.balances[userID]; ok {
+		return v, nil
+	}
+	return 0, errors.New("cache miss")
+}
+
+func newLedgerTestConfig() *config.Config {
+	return &config.Config{Ledger: config.BalanceLedgerConfig{
+		DriftTolerance:    0.0001,
+		CheckCache:        true,
+		BatchSize:         2,
+		MaxReportedDrifts: 10,
+	}}
+}
+
+func TestBalanceLedgerService_Reconcile_ReportsLedgerAndCacheDrift(t *testing.T) {
+	repo := &ledgerRepoStub{snapshots: []UserBalanceSnapshot{
+		{UserID: 1, Balance: 10, LedgerSum: 10},
+		{UserID: 2, Balance: 5, LedgerSum: 7.5},
+		{UserID: 3, Balance: 0.3, LedgerSum: 0.1 + 0.2},
+		{UserID: 4, Balance: 8, LedgerSum: 8},
+	}}
+	cache := &ledgerBillingCacheStub{balances: map[int64]float64{1: 10, 4: 6}}
+	svc := NewBalanceLedgerService(repo, cache, nil, nil, newLedgerTestConfig())
+
+	report, err := svc.Reconcile(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(4), report.UsersChecked)
+	require.Equal(t, int64(2), report.CacheChecked)
+	require.Equal(t, int64(1), report.LedgerDriftCount)
+	require.Equal(t, int64(1), report.CacheDriftCount)
+	require.InDelta(t, -2.5, report.TotalLedgerDrift, 1e-9)
+	require.Equal(t, []int64{0, 2, 4}, repo.calls)
+
+	require.Len(t, report.Drifts, 2)
+	require.Equal(t, int64(2), report.Drifts[0].UserID)
+	require.InDelta(t, -2.5, report.Drifts[0].LedgerDrift, 1e-9)
+	require.Nil(t, report.Drifts[0].CacheDrift)
+	require.Equal(t, int64(4), report.Drifts[1].UserID)
+	require.NotNil(t, report.Drifts[1].CacheDrift)
+	require.InDelta(t, -2.0, *report.Drifts[1].CacheDrift, 1e-9)
+
+	require.Same(t, report, svc.LastReport())
+}
+
+func TestBalanceLedgerService_Reconcile_TruncatesDrifts(t *testing.T) {
+	repo := &ledgerRepoStub{snapshots: []UserBalanceSnapshot{
+		{UserID: 1, Balance: 1, LedgerSum: 0},
+		{UserID: 2, Balance: 2, LedgerSum: 0},
+		{UserID: 3, Balance: 3, LedgerSum: 0},
+	}}
+	cfg := newLedgerTestConfig()
+	cfg.Ledger.MaxReportedDrifts = 1
+	cfg.Ledger.CheckCache = false
+	svc := NewBalanceLedgerService(repo, nil, nil, nil, cfg)
+
+	report, err := svc.Reconcile(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(3), report.LedgerDriftCount)
+	require.Len(t, report.Drifts, 1)
+	require.True(t, report.Truncated)
+	require.InDelta(t, 6.0, report.TotalLedgerDrift, 1e-9)
+}
+
+func TestUsageBalanceEntry_LinksInsertedUsageLog(t *testing.T) {
+	entry := usageBalanceEntry(7, 0.25, &UsageLog{ID: 42}, true)
+	require.Equal(t, BalanceEntryUsage, entry.EntryType)
+	require.Equal(t, -0.25, entry.Amount)
+	require.NotNil(t, entry.UsageLogID)
+	require.Equal(t, int64(42), *entry.UsageLogID)
+
+	entry = usageBalanceEntry(7, 0.25, &UsageLog{}, false)
+	require.Nil(t, entry.UsageLogID)
+}
@@ -10,11 +10,13 @@ import (
 
 	"github.com/Wei-Shaw/sub2api/internal/config"
 	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/timezone"
 )
 
 // 错误定义
 // 注：ErrInsufficientBalance在redeem_service.go中定义
 // 注：ErrDailyLimitExceeded/ErrWeeklyLimitExceeded/ErrMonthlyLimitExceeded在subscription_service.go中定义
+// 注：API Key 限额相关错误在api_key_limits.go中定义
 var (
 	ErrSubscriptionInvalid       = infraerrors.Forbidden("SUBSCRIPTION_INVALID", "subscription is invalid or expired")
 	ErrBillingServiceUnavailable = infraerrors.ServiceUnavailable("BILLING_SERVICE_ERROR", "Billing service temporarily unavailable. Please retry later.")
@@ -38,6 +40,8 @@ const (
 	cacheWriteSetSubscription
 	cacheWriteUpdateSubscriptionUsage
 	cacheWriteDeductBalance
+	cacheWriteIncrementAPIKeyUsage
+	cacheWriteMergeAPIKeyRequests
 )
 
 // 异步缓存写入工作池配置
@@ -68,14 +72,19 @@ type cacheWriteTask struct {
 	balance          float64
 	amount           float64
 	subscriptionData *subscriptionCacheData
+	apiKeyID         int64
+	apiKeyUsage      *APIKeyUsage
 }
 
 // BillingCacheService 计费缓存服务
-// 负责余额和订阅数据的缓存管理，提供高性能的计费资格检查
+// 负责余额、订阅与 API Key 限额计数的缓存管理及余额预授权冻结，提供高性能的计费资格检查
 type BillingCacheService struct {
 	cache          BillingCache
 	userRepo       UserRepository
 	subRepo        UserSubscriptionRepository
+	usageLogRepo   UsageLogRepository
+	requestCounter APIKeyRequestCountRepository
+	billingService *BillingService
 	cfg            *config.Config
 	circuitBreaker *billingCircuitBreaker
 
@@ -90,12 +99,15 @@ type BillingCacheService struct {
 }
 
 // NewBillingCacheService 创建计费缓存服务
-func NewBillingCacheService(cache BillingCache, userRepo UserRepository, subRepo UserSubscriptionRepository, cfg *config.Config) *BillingCacheService {
+func NewBillingCacheService(cache BillingCache, userRepo UserRepository, subRepo UserSubscriptionRepository, usageLogRepo UsageLogRepository, requestCounter APIKeyRequestCountRepository, billingService *BillingService, cfg *config.Config) *BillingCacheService {
 	svc := &BillingCacheService{
-		cache:    cache,
-		userRepo: userRepo,
-		subRepo:  subRepo,
-		cfg:      cfg,
+		cache:          cache,
+		userRepo:       userRepo,
+		subRepo:        subRepo,
+		usageLogRepo:   usageLogRepo,
+		requestCounter: requestCounter,
+		billingService: billingService,
+		cfg:            cfg,
 	}
 	svc.circuitBreaker = newBillingCircuitBreaker(cfg.Billing.CircuitBreaker)
 	svc.startCacheWriteWorkers()
@@ -165,6 +177,10 @@ func (s *BillingCacheService) cacheWriteWorker() {
 					log.Printf("Warning: deduct balance cache failed for user %d: %v", task.userID, err)
 				}
 			}
+		case cacheWriteIncrementAPIKeyUsage:
+			s.incrementAPIKeyUsageCache(ctx, task.apiKeyID, task.amount)
+		case cacheWriteMergeAPIKeyRequests:
+			s.mergeAPIKeyRequestsCache(ctx, task.apiKeyID, task.apiKeyUsage)
 		}
 		cancel()
 	}
@@ -181,6 +197,10 @@ func cacheWriteKindName(kind cacheWriteKind) string {
 		return "update_subscription_usage"
 	case cacheWriteDeductBalance:
 		return "deduct_balance"
+	case cacheWriteIncrementAPIKeyUsage:
+		return "increment_api_key_usage"
+	case cacheWriteMergeAPIKeyRequests:
+		return "merge_api_key_requests"
 	default:
 		return "unknown"
 	}
@@ -441,14 +461,144 @@ func (s *BillingCacheService) InvalidateSubscription(ctx context.Context, userID
 	return nil
 }
 
+// ============================================
+// API Key 限额计数方法
+// ============================================
+
+// GetAPIKeyUsage 获取 API Key 当前周期的限额计数
+// 优先从缓存读取；未命中时消费按 usage_logs 汇总、请求数按持久化计数读取，并同步回填缓存
+func (s *BillingCacheService) GetAPIKeyUsage(ctx context.Context, apiKeyID int64) (*APIKeyUsage, error) {
+	now := timezone.Now()
+	day, month := apiKeyUsagePeriods(now)
+
+	if s.cache != nil {
+		usage, err := s.cache.GetAPIKeyUsage(ctx, apiKeyID)
+		if err == nil && usage != nil {
+			usage.rollTo(day, month)
+			return usage, nil
+		}
+	}
+
+	usage, err := s.getAPIKeyUsageFromDB(ctx, apiKeyID, now)
+	if err != nil {
+		return nil, err
+	}
+	usage.Day, usage.Month = day, month
+
+	// 同步回填：缓存按字段取较大值合并，不会覆盖并发写入的更新计数
+	s.setAPIKeyUsageCache(ctx, apiKeyID, usage)
+
+	return usage, nil
+}
+
+// getAPIKeyUsageFromDB 从 usage_logs 汇总累计/当日/当月消费，从持久化计数读取当日/当月请求数
+func (s *BillingCacheService) getAPIKeyUsageFromDB(ctx context.Context, apiKeyID int64, now time.Time) (*APIKeyUsage, error) {
+	if s.usageLogRepo == nil {
+		return nil, fmt.Errorf("get api key usage: usage log repository not configured")
+	}
+	usage, err := s.usageLogRepo.GetAPIKeyQuotaUsage(ctx, apiKeyID, timezone.StartOfDay(now), timezone.StartOfMonth(now))
+	if err != nil {
+		return nil, fmt.Errorf("get api key usage: %w", err)
+	}
+	if s.requestCounter != nil {
+		day, month := apiKeyUsagePeriods(now)
+		usage.DailyRequests, usage.MonthlyRequests, err = s.requestCounter.GetRequests(ctx, apiKeyID, day, month)
+		if err != nil {
+			return nil, fmt.Errorf("get api key request counts: %w", err)
+		}
+	}
+	return usage, nil
+}
+
+// setAPIKeyUsageCache 设置 API Key 限额计数缓存
+func (s *BillingCacheService) setAPIKeyUsageCache(ctx context.Context, apiKeyID int64, usage *APIKeyUsage) {
+	if s.cache == nil || usage == nil {
+		return
+	}
+	if err := s.cache.SetAPIKeyUsage(ctx, apiKeyID, usage); err != nil {
+		log.Printf("Warning: set api key usage cache failed for key %d: %v", apiKeyID, err)
+	}
+}
+
+// incrementAPIKeyUsageCache 累加消费计数（缓存不存在时由下次检查回源）
+func (s *BillingCacheService) incrementAPIKeyUsageCache(ctx context.Context, apiKeyID int64, costUSD float64) {
+	if s.cache == nil {
+		return
+	}
+	day, month := apiKeyUsagePeriods(timezone.Now())
+	if err := s.cache.IncrementAPIKeyUsage(ctx, apiKeyID, costUSD, day, month); err != nil {
+		log.Printf("Warning: increment api key usage cache failed for key %d: %v", apiKeyID, err)
+	}
+}
+
+// mergeAPIKeyRequestsCache 将持久化计数返回的请求数合并进缓存（缓存不存在时由下次检查回源）
+func (s *BillingCacheService) mergeAPIKeyRequestsCache(ctx context.Context, apiKeyID int64, usage *APIKeyUsage) {
+	if s.cache == nil || usage == nil {
+		return
+	}
+	if err := s.cache.MergeAPIKeyRequests(ctx, apiKeyID, usage.Day, usage.Month, usage.DailyRequests, usage.MonthlyRequests); err != nil {
+		log.Printf("Warning: merge api key requests cache failed for key %d: %v", apiKeyID, err)
+	}
+}
+
+// QueueAPIKeyUsage 异步累加 API Key 消费限额计数（实际消费，请求数在准入时由 CountAPIKeyRequest 计入）
+func (s *BillingCacheService) QueueAPIKeyUsage(apiKeyID int64, costUSD float64) {
+	if s.cache == nil {
+		return
+	}
+	// 队列满时同步回退，避免限额计数被静默丢弃。
+	if s.enqueueCacheWrite(cacheWriteTask{
+		kind:     cacheWriteIncrementAPIKeyUsage,
+		apiKeyID: apiKeyID,
+		amount:   costUSD,
+	}) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cacheWriteTimeout)
+	defer cancel()
+	s.incrementAPIKeyUsageCache(ctx, apiKeyID, costUSD)
+}
+
+// CountAPIKeyRequest 请求通过计费检查被接纳后计入一次 API Key 请求数
+// 无论后续是否成功或计费，已接纳的请求都占用 daily_request_limit/monthly_request_limit。
+// 计数同步写入数据库（缓存过期后仍以此为准），再将最新计数合并进缓存。
+func (s *BillingCacheService) CountAPIKeyRequest(ctx context.Context, apiKey *APIKey) {
+	if apiKey == nil || !apiKey.Limits.HasQuota() || s.cfg.RunMode == config.RunModeSimple || s.requestCounter == nil {
+		return
+	}
+	day, month := apiKeyUsagePeriods(timezone.Now())
+	daily, monthly, err := s.requestCounter.IncrementRequests(ctx, apiKey.ID, day, month, 1)
+	if err != nil {
+		log.Printf("Warning: count api key request failed for key %d: %v", apiKey.ID, err)
+		return
+	}
+	usage := &APIKeyUsage{DailyRequests: daily, MonthlyRequests: monthly, Day: day, Month: month}
+	if s.cache == nil || s.enqueueCacheWrite(cacheWriteTask{
+		kind:        cacheWriteMergeAPIKeyRequests,
+		apiKeyID:    apiKey.ID,
+		apiKeyUsage: usage,
+	}) {
+		return
+	}
+	mergeCtx, cancel := context.WithTimeout(context.Background(), cacheWriteTimeout)
+	defer cancel()
+	s.mergeAPIKeyRequestsCache(mergeCtx, apiKey.ID, usage)
+}
+
 // ============================================
 // 统一检查方法
 // ============================================
 
 // CheckBillingEligibility 检查用户是否有资格发起请求
+// API Key：检查有效期、模型/平台白名单（简易模式同样生效），以及 Key 级消费/请求数限额
 // 余额模式：检查缓存余额 > 0
 // 订阅模式：检查缓存用量未超过限额（Group限额从参数传入）
-func (s *BillingCacheService) CheckBillingEligibility(ctx context.Context, user *User, apiKey *APIKey, group *Group, subscription *UserSubscription) error {
+// model 为客户端请求的模型名（未知时传空字符串，跳过模型白名单检查）
+func (s *BillingCacheService) CheckBillingEligibility(ctx context.Context, user *User, apiKey *APIKey, group *Group, subscription *UserSubscription, model string) error {
+	if err := checkAPIKeyAccess(apiKey, group, model, time.Now()); err != nil {
+		return err
+	}
+
 	// 简易模式：跳过所有计费检查
 	if s.cfg.RunMode == config.RunModeSimple {
 		return nil
@@ -457,6 +607,12 @@ func (s *BillingCacheService) CheckBillingEligibility(ctx context.Context, user
 		return ErrBillingServiceUnavailable
 	}
 
+	if apiKey != nil && apiKey.Limits.HasQuota() {
+		if err := s.checkAPIKeyQuota(ctx, apiKey); err != nil {
+			return err
+		}
+	}
+
 	// 判断计费模式
 	isSubscriptionMode := group != nil && group.IsSubscriptionType() && subscription != nil
 
@@ -467,6 +623,36 @@ func (s *BillingCacheService) CheckBillingEligibility(ctx context.Context, user
 	return s.checkBalanceEligibility(ctx, user.ID)
 }
 
+// checkAPIKeyAccess 检查 API Key 有效期与模型/平台白名单
+func checkAPIKeyAccess(apiKey *APIKey, group *Group, model string, now time.Time) error {
+	if apiKey == nil {
+		return nil
+	}
+	if apiKey.IsExpired(now) {
+		return ErrAPIKeyExpired
+	}
+	if group != nil && !apiKey.Limits.AllowsPlatform(group.Platform) {
+		return ErrAPIKeyPlatformNotAllowed
+	}
+	if !apiKey.Limits.AllowsModel(model) {
+		return ErrAPIKeyModelNotAllowed
+	}
+	return nil
+}
+
+// checkAPIKeyQuota 检查 API Key 消费/请求数限额
+func (s *BillingCacheService) checkAPIKeyQuota(ctx context.Context, apiKey *APIKey) error {
+	usage, err := s.GetAPIKeyUsage(ctx, apiKey.ID)
+	if err != nil {
+		if s.circuitBreaker != nil {
+			s.circuitBreaker.OnFailure(err)
+		}
+		log.Printf("ALERT: billing api key quota check failed for key %d: %v", apiKey.ID, err)
+		return ErrBillingServiceUnavailable.WithCause(err)
+	}
+	return apiKey.Limits.checkUsage(usage)
+}
+
 // checkBalanceEligibility 检查余额模式资格
 func (s *BillingCacheService) checkBalanceEligibility(ctx context.Context, userID int64) error {
 	balance, err := s.GetUserBalance(ctx, userID)
@@ -3,6 +3,8 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -14,6 +16,8 @@ import (
 type billingCacheWorkerStub struct {
 	balanceUpdates      int64
 	subscriptionUpdates int64
+	apiKeyUpdates       int64
+	apiKeyRequests      int64
 }
 
 func (b *billingCacheWorkerStub) GetUserBalance(ctx context.Context, userID int64) (float64, error) {
@@ -52,9 +56,71 @@ func (b *billingCacheWorkerStub) InvalidateSubscriptionCache(ctx context.Context
 	return nil
 }
 
+func (b *billingCacheWorkerStub) GetAPIKeyUsage(ctx context.Context, apiKeyID int64) (*APIKeyUsage, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (b *billingCacheWorkerStub) SetAPIKeyUsage(ctx context.Context, apiKeyID int64, usage *APIKeyUsage) error {
+	atomic.AddInt64(&b.apiKeyUpdates, 1)
+	return nil
+}
+
+func (b *billingCacheWorkerStub) IncrementAPIKeyUsage(ctx context.Context, apiKeyID int64, cost float64, day, month string) error {
+	atomic.AddInt64(&b.apiKeyUpdates, 1)
+	return nil
+}
+
+func (b *billingCacheWorkerStub) MergeAPIKeyRequests(ctx context.Context, apiKeyID int64, day, month string, daily, monthly int64) error {
+	atomic.AddInt64(&b.apiKeyUpdates, 1)
+	atomic.StoreInt64(&b.apiKeyRequests, daily)
+	return nil
+}
+
+type apiKeyRequestCounterStub struct {
+	mu     sync.Mutex
+	counts map[string]int64
+	err    error
+}
+
+func (c *apiKeyRequestCounterStub) IncrementRequests(ctx context.Context, apiKeyID int64, day, month string, n int64) (int64, int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return 0, 0, c.err
+	}
+	if c.counts == nil {
+		c.counts = make(map[string]int64)
+	}
+	c.counts[fmt.Sprintf("%d:%s", apiKeyID, day)] += n
+	c.counts[fmt.Sprintf("%d:%s", apiKeyID, month)] += n
+	return c.counts[fmt.Sprintf("%d:%s", apiKeyID, day)], c.counts[fmt.Sprintf("%d:%s", apiKeyID, month)], nil
+}
+
+func (c *apiKeyRequestCounterStub) GetRequests(ctx context.Context, apiKeyID int64, day, month string) (int64, int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[fmt.Sprintf("%d:%s", apiKeyID, day)], c.counts[fmt.Sprintf("%d:%s", apiKeyID, month)], c.err
+}
+
+func (b *billingCacheWorkerStub) ReserveBalanceHold(ctx context.Context, hold *BalanceHold, fallbackBalance float64) (float64, bool, error) {
+	return 0, false, errors.New("not implemented")
+}
+
+func (b *billingCacheWorkerStub) SettleBalanceHold(ctx context.Context, userID int64, holdID string, actualCost float64) error {
+	return nil
+}
+
+func (b *billingCacheWorkerStub) ReleaseBalanceHold(ctx context.Context, userID int64, holdID string) error {
+	return nil
+}
+
+func (b *billingCacheWorkerStub) ListBalanceHolds(ctx context.Context, userID int64) ([]BalanceHold, error) {
+	return nil, nil
+}
+
 func TestBillingCacheServiceQueueHighLoad(t *testing.T) {
 	cache := &billingCacheWorkerStub{}
-	svc := NewBillingCacheService(cache, nil, nil, &config.Config{})
+	svc := NewBillingCacheService(cache, nil, nil, nil, nil, nil, &config.Config{})
 	t.Cleanup(svc.Stop)
 
 	start := time.Now()
@@ -64,6 +130,7 @@ func TestBillingCacheServiceQueueHighLoad(t *testing.T) {
 	require.Less(t, time.Since(start), 2*time.Second)
 
 	svc.QueueUpdateSubscriptionUsage(1, 2, 1.5)
+	svc.QueueAPIKeyUsage(3, 0.5)
 
 	require.Eventually(t, func() bool {
 		return atomic.LoadInt64(&cache.balanceUpdates) > 0
@@ -72,4 +139,35 @@ func TestBillingCacheServiceQueueHighLoad(t *testing.T) {
 	require.Eventually(t, func() bool {
 		return atomic.LoadInt64(&cache.subscriptionUpdates) > 0
 	}, 2*time.Second, 10*time.Millisecond)
+
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt64(&cache.apiKeyUpdates) > 0
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestBillingCacheServiceCountAPIKeyRequest(t *testing.T) {
+	cache := &billingCacheWorkerStub{}
+	counter := &apiKeyRequestCounterStub{}
+	svc := NewBillingCacheService(cache, nil, nil, nil, counter, nil, &config.Config{})
+	t.Cleanup(svc.Stop)
+
+	ctx := context.Background()
+	limit := int64(10)
+	svc.CountAPIKeyRequest(ctx, &APIKey{ID: 1})
+	svc.CountAPIKeyRequest(ctx, &APIKey{ID: 2, Limits: APIKeyLimits{DailyRequestLimit: &limit}})
+	svc.CountAPIKeyRequest(ctx, &APIKey{ID: 2, Limits: APIKeyLimits{DailyRequestLimit: &limit}})
+	svc.QueueAPIKeyUsage(2, 0.5)
+
+	day, month := apiKeyUsagePeriods(time.Now())
+	daily, monthly, err := counter.GetRequests(ctx, 2, day, month)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), daily, "admission is counted synchronously in the durable counter")
+	require.Equal(t, int64(2), monthly)
+	daily, _, _ = counter.GetRequests(ctx, 1, day, month)
+	require.Zero(t, daily, "keys without quota are skipped")
+
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt64(&cache.apiKeyUpdates) == 3
+	}, 2*time.Second, 10*time.Millisecond)
+	require.Equal(t, int64(2), atomic.LoadInt64(&cache.apiKeyRequests), "cache receives the durable count")
 }
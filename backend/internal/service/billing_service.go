@@ -6,6 +6,7 @@ import (
 
 	"log"
 	"strings"
+	"time"
 
 	"github.com/Wei-Shaw/sub2api/internal/config"
 )
@@ -23,6 +24,24 @@ type BillingCache interface {
 	SetSubscriptionCache(ctx context.Context, userID, groupID int64, data *SubscriptionCacheData) error
 	UpdateSubscriptionUsage(ctx context.Context, userID, groupID int64, cost float64) error
 	InvalidateSubscriptionCache(ctx context.Context, userID, groupID int64) error
+
+	// API key quota operations
+	GetAPIKeyUsage(ctx context.Context, apiKeyID int64) (*APIKeyUsage, error)
+	// SetAPIKeyUsage 回填限额计数：缓存不存在时写入，已存在时同一周期内按字段取较大值，避免覆盖并发累加
+	SetAPIKeyUsage(ctx context.Context, apiKeyID int64, usage *APIKeyUsage) error
+	// IncrementAPIKeyUsage 累加消费；缓存不存在时不处理，周期切换时先归零对应计数
+	IncrementAPIKeyUsage(ctx context.Context, apiKeyID int64, cost float64, day, month string) error
+	// MergeAPIKeyRequests 以持久化计数更新请求数（取较大值）；缓存不存在时不处理，周期切换时先归零对应计数
+	MergeAPIKeyRequests(ctx context.Context, apiKeyID int64, day, month string, daily, monthly int64) error
+
+	// Balance hold operations
+	// ReserveBalanceHold 原子地按"余额 - 未过期冻结"计算可用额度并冻结 min(hold.Amount, 可用额度)；
+	// 可用额度 <= 0 时返回 ok=false。余额缓存不存在时以 fallbackBalance 作为余额。
+	ReserveBalanceHold(ctx context.Context, hold *BalanceHold, fallbackBalance float64) (reserved float64, ok bool, err error)
+	// SettleBalanceHold 移除冻结并按实际费用扣减余额缓存（余额缓存不存在时仅移除冻结）
+	SettleBalanceHold(ctx context.Context, userID int64, holdID string, actualCost float64) error
+	ReleaseBalanceHold(ctx context.Context, userID int64, holdID string) error
+	ListBalanceHolds(ctx context.Context, userID int64) ([]BalanceHold, error)
 }
 
 // ModelPricing 模型价格配置（per-token价格，与LiteLLM格式一致）
@@ -34,6 +53,7 @@ type ModelPricing struct {
 	CacheCreation5mPrice       float64 // 5分钟缓存创建价格（每百万token）- 仅用于硬编码回退
 	CacheCreation1hPrice       float64 // 1小时缓存创建价格（每百万token）- 仅用于硬编码回退
 	SupportsCacheBreakdown     bool    // 是否支持详细的缓存分类
+	PricingOverrideID          *int64  // 命中的管理员自定义价格（nil 表示使用默认价格）
 }
 
 // UsageTokens 使用的token数量
@@ -54,20 +74,23 @@ type CostBreakdown struct {
 	CacheReadCost     float64
 	TotalCost         float64
 	ActualCost        float64 // 应用倍率后的实际费用
+	PricingOverrideID *int64  // 计费使用的管理员自定义价格（nil 表示默认价格）
 }
 
 // BillingService 计费服务
 type BillingService struct {
 	cfg            *config.Config
 	pricingService *PricingService
+	overrides      *PricingOverrideService
 	fallbackPrices map[string]*ModelPricing // 硬编码回退价格
 }
 
 // NewBillingService 创建计费服务实例
-func NewBillingService(cfg *config.Config, pricingService *PricingService) *BillingService {
+func NewBillingService(cfg *config.Config, pricingService *PricingService, overrides *PricingOverrideService) *BillingService {
 	s := &BillingService{
 		cfg:            cfg,
 		pricingService: pricingService,
+		overrides:      overrides,
 		fallbackPrices: make(map[string]*ModelPricing),
 	}
 
@@ -163,12 +186,23 @@ func (s *BillingService) getFallbackPricing(model string) *ModelPricing {
 	return s.fallbackPrices["claude-sonnet-4"]
 }
 
-// GetModelPricing 获取模型价格配置
+// GetModelPricing 获取模型价格配置（含全局自定义价格）
 func (s *BillingService) GetModelPricing(model string) (*ModelPricing, error) {
+	return s.GetModelPricingForGroup(model, nil)
+}
+
+// GetModelPricingForGroup 获取模型在指定分组下的价格配置
+// 优先级：管理员自定义价格（分组 > 全局）> 动态价格服务 > 硬编码回退价格
+func (s *BillingService) GetModelPricingForGroup(model string, groupID *int64) (*ModelPricing, error) {
 	// 标准化模型名称（转小写）
 	model = strings.ToLower(model)
 
-	// 1. 优先从动态价格服务获取
+	// 0. 管理员自定义价格
+	if o := s.resolveOverride(model, groupID); o != nil {
+		return o.toModelPricing(), nil
+	}
+
+	// 1. 从动态价格服务获取
 	if s.pricingService != nil {
 		litellmPricing := s.pricingService.GetModelPricing(model)
 		if litellmPricing != nil {
@@ -192,14 +226,25 @@ func (s *BillingService) GetModelPricing(model string) (*ModelPricing, error) {
 	return nil, fmt.Errorf("pricing not found for model: %s", model)
 }
 
-// CalculateCost 计算使用费用
+// resolveOverride 返回当前对 (groupID, model) 生效的管理员自定义价格，未配置时返回 nil
+// token、图片与 embedding 计费共用该查找，保证自定义价格覆盖所有计费路径。
+func (s *BillingService) resolveOverride(model string, groupID *int64) *PricingOverride {
+	return s.overrides.Resolve(groupID, model, time.Now())
+}
+
+// CalculateCost 计算使用费用（全局价格）
 func (s *BillingService) CalculateCost(model string, tokens UsageTokens, rateMultiplier float64) (*CostBreakdown, error) {
-	pricing, err := s.GetModelPricing(model)
+	return s.CalculateCostForGroup(model, nil, tokens, rateMultiplier)
+}
+
+// CalculateCostForGroup 按分组价格计算使用费用（分组未配置自定义价格时使用全局价格）
+func (s *BillingService) CalculateCostForGroup(model string, groupID *int64, tokens UsageTokens, rateMultiplier float64) (*CostBreakdown, error) {
+	pricing, err := s.GetModelPricingForGroup(model, groupID)
 	if err != nil {
 		return nil, err
 	}
 
-	breakdown := &CostBreakdown{}
+	breakdown := &CostBreakdown{PricingOverrideID: pricing.PricingOverrideID}
 
 	// 计算输入token费用（使用per-token价格）
 	breakdown.InputCost = float64(tokens.InputTokens) * pricing.InputPricePerToken
@@ -261,14 +306,14 @@ func (s *BillingService) IsModelSupported(model string) bool {
 		strings.Contains(modelLower, "haiku")
 }
 
-// GetEstimatedCost 估算费用（用于前端展示）
-func (s *BillingService) GetEstimatedCost(model string, estimatedInputTokens, estimatedOutputTokens int) (float64, error) {
+// GetEstimatedCost 按分组价格与倍率估算费用（用于余额预授权冻结）
+func (s *BillingService) GetEstimatedCost(model string, groupID *int64, estimatedInputTokens, estimatedOutputTokens int, rateMultiplier float64) (float64, error) {
 	tokens := UsageTokens{
 		InputTokens:  estimatedInputTokens,
 		OutputTokens: estimatedOutputTokens,
 	}
 
-	breakdown, err := s.CalculateCostWithConfig(model, tokens)
+	breakdown, err := s.CalculateCostForGroup(model, groupID, tokens, rateMultiplier)
 	if err != nil {
 		return 0, err
 	}
@@ -303,19 +348,25 @@ type ImagePriceConfig struct {
 	Price4K *float64 // 4K 尺寸价格（nil 表示使用默认值）
 }
 
-// CalculateImageCost 计算图片生成费用
+// CalculateImageCost 计算图片生成费用（全局价格）
 // model: 请求的模型名称（用于获取 LiteLLM 默认价格）
 // imageSize: 图片尺寸 "1K", "2K", "4K"
 // imageCount: 生成的图片数量
 // groupConfig: 分组配置的价格（可能为 nil，表示使用默认值）
 // rateMultiplier: 费率倍数
 func (s *BillingService) CalculateImageCost(model string, imageSize string, imageCount int, groupConfig *ImagePriceConfig, rateMultiplier float64) *CostBreakdown {
+	return s.CalculateImageCostForGroup(model, nil, imageSize, imageCount, groupConfig, rateMultiplier)
+}
+
+// CalculateImageCostForGroup 按分组价格计算图片生成费用
+// 单价优先级：分组自定义价格 > 分组图片价格配置 > 全局自定义价格 > LiteLLM 默认价格
+func (s *BillingService) CalculateImageCostForGroup(model string, groupID *int64, imageSize string, imageCount int, groupConfig *ImagePriceConfig, rateMultiplier float64) *CostBreakdown {
 	if imageCount <= 0 {
 		return &CostBreakdown{}
 	}
 
 	// 获取单价
-	unitPrice := s.getImageUnitPrice(model, imageSize, groupConfig)
+	unitPrice, overrideID := s.getImageUnitPrice(model, groupID, imageSize, groupConfig)
 
 	// 计算总费用
 	totalCost := unitPrice * float64(imageCount)
@@ -327,33 +378,118 @@ func (s *BillingService) CalculateImageCost(model string, imageSize string, imag
 	actualCost := totalCost * rateMultiplier
 
 	return &CostBreakdown{
-		TotalCost:  totalCost,
-		ActualCost: actualCost,
+		TotalCost:         totalCost,
+		ActualCost:        actualCost,
+		PricingOverrideID: overrideID,
 	}
 }
 
-// getImageUnitPrice 获取图片单价
-func (s *BillingService) getImageUnitPrice(model string, imageSize string, groupConfig *ImagePriceConfig) float64 {
-	// 优先使用分组配置的价格
+// embeddingFallbackPrices embedding 模型硬编码回退价格（USD per token）
+var embeddingFallbackPrices = map[string]float64{
+	"text-embedding-3-small": 0.02e-6, // $0.02 per MTok
+	"text-embedding-3-large": 0.13e-6, // $0.13 per MTok
+	"text-embedding-ada-002": 0.1e-6,  // $0.10 per MTok
+	"gemini-embedding-001":   0.15e-6, // $0.15 per MTok
+}
+
+// defaultEmbeddingPricePerToken 未知 embedding 模型的默认价格（与 text-embedding-3-large 一致）
+const defaultEmbeddingPricePerToken = 0.13e-6
+
+// CalculateEmbeddingCost 计算 embedding 请求费用（仅输入 token，全局价格）
+func (s *BillingService) CalculateEmbeddingCost(model string, inputTokens int, rateMultiplier float64) *CostBreakdown {
+	return s.CalculateEmbeddingCostForGroup(model, nil, inputTokens, rateMultiplier)
+}
+
+// CalculateEmbeddingCostForGroup 按分组价格计算 embedding 请求费用（仅输入 token）
+// 优先使用管理员自定义价格的输入价格，其次 PricingService 中 mode=embedding 的价格，否则按模型名回退到硬编码价格。
+func (s *BillingService) CalculateEmbeddingCostForGroup(model string, groupID *int64, inputTokens int, rateMultiplier float64) *CostBreakdown {
+	if inputTokens <= 0 {
+		return &CostBreakdown{}
+	}
+
+	unitPrice := 0.0
+	found := false
+	var overrideID *int64
+	if o := s.resolveOverride(strings.TrimPrefix(strings.TrimSpace(model), "models/"), groupID); o != nil {
+		unitPrice = o.InputPrice / 1_000_000
+		overrideID = &o.ID
+		found = true
+	}
+	if !found && s.pricingService != nil {
+		if pricing := s.pricingService.GetEmbeddingPricing(model); pricing != nil {
+			unitPrice = pricing.InputCostPerToken
+			found = true
+		}
+	}
+	if !found {
+		modelLower := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(model), "models/"))
+		unitPrice = defaultEmbeddingPricePerToken
+		for name, price := range embeddingFallbackPrices {
+			if strings.HasPrefix(modelLower, name) {
+				unitPrice = price
+				break
+			}
+		}
+		log.Printf("[Billing] Using fallback embedding pricing for model: %s", model)
+	}
+
+	inputCost := float64(inputTokens) * unitPrice
+	if rateMultiplier <= 0 {
+		rateMultiplier = 1.0
+	}
+	return &CostBreakdown{
+		InputCost:         inputCost,
+		TotalCost:         inputCost,
+		ActualCost:        inputCost * rateMultiplier,
+		PricingOverrideID: overrideID,
+	}
+}
+
+// getImageUnitPrice 获取图片单价及命中的自定义价格 ID
+func (s *BillingService) getImageUnitPrice(model string, groupID *int64, imageSize string, groupConfig *ImagePriceConfig) (float64, *int64) {
+	var override *PricingOverride
+	if o := s.resolveOverride(strings.ToLower(model), groupID); o != nil && o.ImagePrice != nil {
+		override = o
+	}
+
+	// 分组级自定义价格最优先
+	if override != nil && override.GroupID != nil {
+		return overrideImagePrice(override, imageSize), &override.ID
+	}
+
+	// 其次使用分组配置的价格
 	if groupConfig != nil {
 		switch imageSize {
 		case "1K":
 			if groupConfig.Price1K != nil {
-				return *groupConfig.Price1K
+				return *groupConfig.Price1K, nil
 			}
 		case "2K":
 			if groupConfig.Price2K != nil {
-				return *groupConfig.Price2K
+				return *groupConfig.Price2K, nil
 			}
 		case "4K":
 			if groupConfig.Price4K != nil {
-				return *groupConfig.Price4K
+				return *groupConfig.Price4K, nil
 			}
 		}
 	}
 
+	// 全局自定义价格
+	if override != nil {
+		return overrideImagePrice(override, imageSize), &override.ID
+	}
+
 	// 回退到 LiteLLM 默认价格
-	return s.getDefaultImagePrice(model, imageSize)
+	return s.getDefaultImagePrice(model, imageSize), nil
+}
+
+// overrideImagePrice 自定义价格的图片单价（与默认价格一致，4K 尺寸翻倍）
+func overrideImagePrice(o *PricingOverride, imageSize string) float64 {
+	if imageSize == "4K" {
+		return *o.ImagePrice * 2
+	}
+	return *o.ImagePrice
 }
 
 // getDefaultImagePrice 获取 LiteLLM 默认图片价格
@@ -0,0 +1,65 @@
+//go:build unit
+
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestCalculateEmbeddingCost_PricingService 测试优先使用 LiteLLM 中 mode=embedding 的价格
+func TestCalculateEmbeddingCost_PricingService(t *testing.T) {
+	pricing := &PricingService{pricingData: map[string]*LiteLLMModelPricing{
+		"text-embedding-3-small":      {InputCostPerToken: 0.02e-6, Mode: "embedding"},
+		"gemini/gemini-embedding-001": {InputCostPerToken: 0.15e-6, Mode: "embedding"},
+	}}
+	svc := &BillingService{pricingService: pricing}
+
+	cost := svc.CalculateEmbeddingCost("text-embedding-3-small", 1_000_000, 1.5)
+	require.InDelta(t, 0.02, cost.InputCost, 1e-9)
+	require.InDelta(t, 0.02, cost.TotalCost, 1e-9)
+	require.InDelta(t, 0.03, cost.ActualCost, 1e-9)
+	require.Zero(t, cost.OutputCost)
+
+	// provider 前缀 + models/ 前缀
+	cost = svc.CalculateEmbeddingCost("models/gemini-embedding-001", 1_000_000, 1.0)
+	require.InDelta(t, 0.15, cost.TotalCost, 1e-9)
+}
+
+// TestCalculateEmbeddingCost_IgnoresChatPricing 测试不会误用对话模型价格
+func TestCalculateEmbeddingCost_IgnoresChatPricing(t *testing.T) {
+	pricing := &PricingService{pricingData: map[string]*LiteLLMModelPricing{
+		"text-embedding-3-large": {InputCostPerToken: 3e-6, Mode: "chat"},
+	}}
+	svc := &BillingService{pricingService: pricing}
+
+	cost := svc.CalculateEmbeddingCost("text-embedding-3-large", 1_000_000, 1.0)
+	require.InDelta(t, 0.13, cost.TotalCost, 1e-9)
+}
+
+// TestCalculateEmbeddingCost_Fallback 测试无价格数据时的硬编码回退
+func TestCalculateEmbeddingCost_Fallback(t *testing.T) {
+	svc := &BillingService{}
+
+	cost := svc.CalculateEmbeddingCost("text-embedding-ada-002", 1_000_000, 1.0)
+	require.InDelta(t, 0.10, cost.TotalCost, 1e-9)
+
+	cost = svc.CalculateEmbeddingCost("unknown-embedding", 1_000_000, 0)
+	require.InDelta(t, 0.13, cost.ActualCost, 1e-9)
+
+	cost = svc.CalculateEmbeddingCost("text-embedding-3-small", 0, 1.0)
+	require.Zero(t, cost.ActualCost)
+}
+
+func TestEstimateGeminiEmbedTokens(t *testing.T) {
+	single := []byte(`{"content":{"parts":[{"text":"hello world, this is a test"}]}}`)
+	require.Equal(t, 7, estimateGeminiEmbedTokens(single))
+
+	batch := []byte(`{"requests":[
+		{"model":"models/gemini-embedding-001","content":{"parts":[{"text":"abcd"}]}},
+		{"model":"models/gemini-embedding-001","content":{"parts":[{"text":"abcdefgh"}]}}
+	]}`)
+	require.Equal(t, 3, estimateGeminiEmbedTokens(batch))
+	require.Zero(t, estimateGeminiEmbedTokens([]byte(`{}`)))
+}
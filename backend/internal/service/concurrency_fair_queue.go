@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"log"
+	"math"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+)
+
+// 账号槽位加权公平排队（Weighted Fair Queuing）
+//
+// 原有等待逻辑中，排队请求以退避轮询的方式竞争账号槽位，并发上限高的用户排队请求多、
+// 抢到槽位的概率也更高，同组其他用户容易饥饿。公平队列按分组维护虚拟时钟，
+// 以用户为流计算完成标签 tag = max(V, lastTag(user)) + 1/weight，排队请求按标签升序放行：
+// 同一账号上排在自己之前的等待者数量小于空闲槽位时才允许占用槽位。
+// 权重默认取用户并发上限（可通过订阅分组倍数调整），因此同组用户按权重轮转获得槽位。
+
+// FairQueueWaiter 排队中的请求
+type FairQueueWaiter struct {
+	GroupID   int64
+	UserID    int64
+	AccountID int64
+	RequestID string
+	Weight    float64
+}
+
+// FairQueuePoll 一次公平队列轮询的结果
+type FairQueuePoll struct {
+	Acquired bool
+	// Evicted 排队项已不存在（心跳超时被清理），调用方需重新入队
+	Evicted bool
+	// Ahead 同一账号上排在当前请求之前的等待者数量
+	Ahead int
+	// AvgGrantIntervalMs 账号最近槽位放行间隔的 EWMA（毫秒），0 表示暂无数据
+	AvgGrantIntervalMs int64
+}
+
+// FairQueueGroupStats 分组公平队列统计
+type FairQueueGroupStats struct {
+	Waiting int64
+	Users   int64
+}
+
+// FairQueueCache 公平队列存储（Redis 实现需保证入队与放行的原子性）
+type FairQueueCache interface {
+	// EnqueueFairWaiter 入队并返回同一账号上排在之前的等待者数量
+	EnqueueFairWaiter(ctx context.Context, waiter FairQueueWaiter, staleSeconds int) (int, error)
+	// TryAcquireFairSlot 刷新心跳并按公平顺序尝试占用账号槽位（槽位成员为 waiter.RequestID）
+	TryAcquireFairSlot(ctx context.Context, waiter FairQueueWaiter, maxConcurrency int, staleSeconds int) (*FairQueuePoll, error)
+	// LeaveFairQueue 放弃排队（超时、客户端断开）
+	LeaveFairQueue(ctx context.Context, waiter FairQueueWaiter) error
+	// AcquireSlotIfNoWaiters 账号没有有效排队者时原子地直接占用槽位（槽位成员为 requestID），有排队者时让行
+	AcquireSlotIfNoWaiters(ctx context.Context, accountID int64, maxConcurrency int, requestID string, staleSeconds int) (bool, error)
+	// GetFairQueueStats 批量读取分组排队统计（只读）
+	GetFairQueueStats(ctx context.Context, groupIDs []int64, staleSeconds int) (map[int64]*FairQueueGroupStats, error)
+	// CountAccountWaiters 统计账号有效排队者数量（只读，调度说明预测让行使用）
+	CountAccountWaiters(ctx context.Context, accountID int64, staleSeconds int) (int, error)
+}
+
+// FairQueueTicket 公平队列排队凭证
+type FairQueueTicket struct {
+	waiter FairQueueWaiter
+	// Position 当前排队位置（从 1 开始）
+	Position int
+	// ETA 预计等待时间，0 表示无法估算
+	ETA time.Duration
+}
+
+// SetFairQueue 启用账号槽位公平排队
+func (s *ConcurrencyService) SetFairQueue(cache FairQueueCache, cfg config.GatewayFairQueueConfig) {
+	if s == nil {
+		return
+	}
+	s.fairQueueCache = cache
+	s.fairQueueCfg = cfg
+}
+
+// FairQueueEnabled 是否启用公平排队
+func (s *ConcurrencyService) FairQueueEnabled() bool {
+	return s != nil && s.fairQueueCache != nil && s.fairQueueCfg.Enabled
+}
+
+// FairQueueWeight 计算用户权重：默认取用户并发上限，订阅分组按倍数放大，并受上限约束
+func (s *ConcurrencyService) FairQueueWeight(userConcurrency int, subscription bool) float64 {
+	weight := float64(userConcurrency)
+	if weight < 1 {
+		weight = 1
+	}
+	if subscription && s.fairQueueCfg.SubscriptionWeightMultiplier > 0 {
+		weight *= s.fairQueueCfg.SubscriptionWeightMultiplier
+	}
+	if maxWeight := float64(s.fairQueueCfg.MaxWeight); maxWeight > 0 && weight > maxWeight {
+		weight = maxWeight
+	}
+	return weight
+}
+
+// EnqueueAccountWaiter 将等待账号槽位的请求加入分组公平队列
+func (s *ConcurrencyService) EnqueueAccountWaiter(ctx context.Context, groupID, userID, accountID int64, weight float64) (*FairQueueTicket, error) {
+	ticket := &FairQueueTicket{
+		waiter: FairQueueWaiter{
+			GroupID:   groupID,
+			UserID:    userID,
+			AccountID: accountID,
+			RequestID: generateRequestID(),
+			Weight:    weight,
+		},
+	}
+	ahead, err := s.fairQueueCache.EnqueueFairWaiter(ctx, ticket.waiter, s.fairQueueCfg.StaleSeconds)
+	if err != nil {
+		return nil, err
+	}
+	ticket.Position = ahead + 1
+	return ticket, nil
+}
+
+// TryAcquireAccountSlotFair 按公平顺序尝试获取账号槽位，并刷新排队位置与预计等待时间
+func (s *ConcurrencyService) TryAcquireAccountSlotFair(ctx context.Context, ticket *FairQueueTicket, maxConcurrency int) (*AcquireResult, error) {
+	poll, err := s.fairQueueCache.TryAcquireFairSlot(ctx, ticket.waiter, maxConcurrency, s.fairQueueCfg.StaleSeconds)
+	if err != nil {
+		return nil, err
+	}
+	if poll.Evicted {
+		// 心跳超时被清理（例如轮询间隔过长），以新的标签重新入队
+		ahead, err := s.fairQueueCache.EnqueueFairWaiter(ctx, ticket.waiter, s.fairQueueCfg.StaleSeconds)
+		if err != nil {
+			return nil, err
+		}
+		ticket.Position = ahead + 1
+		return &AcquireResult{Acquired: false}, nil
+	}
+	if poll.Acquired {
+		accountID, requestID := ticket.waiter.AccountID, ticket.waiter.RequestID
+		return &AcquireResult{
+			Acquired: true,
+			ReleaseFunc: func() {
+				bgCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				if err := s.cache.ReleaseAccountSlot(bgCtx, accountID, requestID); err != nil {
+					log.Printf("Warning: failed to release account slot for %d (req=%s): %v", accountID, requestID, err)
+				}
+			},
+		}, nil
+	}
+	ticket.Position = poll.Ahead + 1
+	ticket.ETA = estimateFairQueueETA(ticket.Position, poll.AvgGrantIntervalMs)
+	return &AcquireResult{Acquired: false}, nil
+}
+
+// acquireAccountSlotUnqueued 未排队的请求直接占用账号槽位
+// 启用公平排队时账号有排队者则让行（由队列按公平顺序放行），公平队列异常时退化为竞争式占用。
+func (s *ConcurrencyService) acquireAccountSlotUnqueued(ctx context.Context, accountID int64, maxConcurrency int, requestID string) (bool, error) {
+	if s.FairQueueEnabled() {
+		acquired, err := s.fairQueueCache.AcquireSlotIfNoWaiters(ctx, accountID, maxConcurrency, requestID, s.fairQueueCfg.StaleSeconds)
+		if err == nil {
+			return acquired, nil
+		}
+		if ctx.Err() != nil {
+			return false, err
+		}
+		log.Printf("Warning: fair queue unavailable for account %d, acquiring directly: %v", accountID, err)
+	}
+	return s.cache.AcquireAccountSlot(ctx, accountID, maxConcurrency, requestID)
+}
+
+// LeaveAccountQueue 放弃排队，失败仅记录日志（排队项会在心跳超时后被清理）
+func (s *ConcurrencyService) LeaveAccountQueue(ctx context.Context, ticket *FairQueueTicket) {
+	if ticket == nil || !s.FairQueueEnabled() {
+		return
+	}
+	if err := s.fairQueueCache.LeaveFairQueue(ctx, ticket.waiter); err != nil {
+		log.Printf("Warning: leave fair queue failed for account %d (req=%s): %v", ticket.waiter.AccountID, ticket.waiter.RequestID, err)
+	}
+}
+
+// GetFairQueueStats 批量读取分组公平队列统计
+func (s *ConcurrencyService) GetFairQueueStats(ctx context.Context, groupIDs []int64) (map[int64]*FairQueueGroupStats, error) {
+	if !s.FairQueueEnabled() || len(groupIDs) == 0 {
+		return map[int64]*FairQueueGroupStats{}, nil
+	}
+	return s.fairQueueCache.GetFairQueueStats(ctx, groupIDs, s.fairQueueCfg.StaleSeconds)
+}
+
+// estimateFairQueueETA 以放行间隔 EWMA 估算等待时间（向上取整到秒）
+func estimateFairQueueETA(position int, avgGrantIntervalMs int64) time.Duration {
+	if position <= 0 || avgGrantIntervalMs <= 0 {
+		return 0
+	}
+	seconds := math.Ceil(float64(position) * float64(avgGrantIntervalMs) / 1000)
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,166 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+type stubFairQueueCache struct {
+	enqueued []FairQueueWaiter
+	polls    []*FairQueuePoll
+	left     []string
+	stats    map[int64]*FairQueueGroupStats
+	waiting  map[int64]int // 账号有效排队者数量
+	direct   []string      // 直接占用槽位的 requestID
+}
+
+func (c *stubFairQueueCache) EnqueueFairWaiter(ctx context.Context, waiter FairQueueWaiter, staleSeconds int) (int, error) {
+	c.enqueued = append(c.enqueued, waiter)
+	return 2, nil
+}
+
+func (c *stubFairQueueCache) TryAcquireFairSlot(ctx context.Context, waiter FairQueueWaiter, maxConcurrency int, staleSeconds int) (*FairQueuePoll, error) {
+	poll := c.polls[0]
+	c.polls = c.polls[1:]
+	return poll, nil
+}
+
+func (c *stubFairQueueCache) LeaveFairQueue(ctx context.Context, waiter FairQueueWaiter) error {
+	c.left = append(c.left, waiter.RequestID)
+	return nil
+}
+
+func (c *stubFairQueueCache) AcquireSlotIfNoWaiters(ctx context.Context, accountID int64, maxConcurrency int, requestID string, staleSeconds int) (bool, error) {
+	if c.waiting[accountID] > 0 {
+		return false, nil
+	}
+	c.direct = append(c.direct, requestID)
+	return true, nil
+}
+
+func (c *stubFairQueueCache) CountAccountWaiters(ctx context.Context, accountID int64, staleSeconds int) (int, error) {
+	return c.waiting[accountID], nil
+}
+
+func (c *stubFairQueueCache) GetFairQueueStats(ctx context.Context, groupIDs []int64, staleSeconds int) (map[int64]*FairQueueGroupStats, error) {
+	return c.stats, nil
+}
+
+type releaseRecordingConcurrencyCache struct {
+	ConcurrencyCache
+	released []string
+}
+
+func (c *releaseRecordingConcurrencyCache) ReleaseAccountSlot(ctx context.Context, accountID int64, requestID string) error {
+	c.released = append(c.released, requestID)
+	return nil
+}
+
+func fairQueueTestConfig() config.GatewayFairQueueConfig {
+	return config.GatewayFairQueueConfig{
+		Enabled:                      true,
+		StaleSeconds:                 10,
+		MaxWeight:                    20,
+		SubscriptionWeightMultiplier: 2,
+	}
+}
+
+func TestConcurrencyService_FairQueueWeight(t *testing.T) {
+	svc := NewConcurrencyService(&releaseRecordingConcurrencyCache{})
+	svc.SetFairQueue(&stubFairQueueCache{}, fairQueueTestConfig())
+
+	require.Equal(t, 1.0, svc.FairQueueWeight(0, false))
+	require.Equal(t, 5.0, svc.FairQueueWeight(5, false))
+	require.Equal(t, 10.0, svc.FairQueueWeight(5, true))
+	// 权重受上限约束，避免高并发用户独占队列
+	require.Equal(t, 20.0, svc.FairQueueWeight(50, false))
+}
+
+func TestConcurrencyService_FairQueueAcquireFlow(t *testing.T) {
+	slots := &releaseRecordingConcurrencyCache{}
+	cache := &stubFairQueueCache{
+		polls: []*FairQueuePoll{
+			{Ahead: 3, AvgGrantIntervalMs: 1500},
+			{Evicted: true},
+			{Acquired: true},
+		},
+	}
+	svc := NewConcurrencyService(slots)
+	svc.SetFairQueue(cache, fairQueueTestConfig())
+	require.True(t, svc.FairQueueEnabled())
+
+	ticket, err := svc.EnqueueAccountWaiter(context.Background(), 3, 7, 100, 4)
+	require.NoError(t, err)
+	require.Equal(t, 3, ticket.Position)
+	require.Len(t, cache.enqueued, 1)
+	require.Equal(t, FairQueueWaiter{GroupID: 3, UserID: 7, AccountID: 100, RequestID: ticket.waiter.RequestID, Weight: 4}, cache.enqueued[0])
+
+	result, err := svc.TryAcquireAccountSlotFair(context.Background(), ticket, 2)
+	require.NoError(t, err)
+	require.False(t, result.Acquired)
+	require.Equal(t, 4, ticket.Position)
+	require.Equal(t, 6*time.Second, ticket.ETA)
+
+	// 排队项失效后重新入队，沿用同一 requestID
+	result, err = svc.TryAcquireAccountSlotFair(context.Background(), ticket, 2)
+	require.NoError(t, err)
+	require.False(t, result.Acquired)
+	require.Len(t, cache.enqueued, 2)
+	require.Equal(t, ticket.waiter.RequestID, cache.enqueued[1].RequestID)
+
+	result, err = svc.TryAcquireAccountSlotFair(context.Background(), ticket, 2)
+	require.NoError(t, err)
+	require.True(t, result.Acquired)
+	result.ReleaseFunc()
+	require.Equal(t, []string{ticket.waiter.RequestID}, slots.released)
+
+	svc.LeaveAccountQueue(context.Background(), ticket)
+	require.Equal(t, []string{ticket.waiter.RequestID}, cache.left)
+}
+
+func TestConcurrencyService_FairQueueDisabled(t *testing.T) {
+	cache := &stubFairQueueCache{stats: map[int64]*FairQueueGroupStats{1: {Waiting: 3, Users: 2}}}
+	cfg := fairQueueTestConfig()
+	cfg.Enabled = false
+	svc := NewConcurrencyService(&releaseRecordingConcurrencyCache{})
+	svc.SetFairQueue(cache, cfg)
+
+	require.False(t, svc.FairQueueEnabled())
+	stats, err := svc.GetFairQueueStats(context.Background(), []int64{1})
+	require.NoError(t, err)
+	require.Empty(t, stats)
+	svc.LeaveAccountQueue(context.Background(), &FairQueueTicket{})
+	require.Empty(t, cache.left)
+
+	var nilSvc *ConcurrencyService
+	require.False(t, nilSvc.FairQueueEnabled())
+}
+
+func TestEstimateFairQueueETA(t *testing.T) {
+	require.Equal(t, time.Duration(0), estimateFairQueueETA(3, 0))
+	require.Equal(t, 2*time.Second, estimateFairQueueETA(3, 400))
+	require.Equal(t, 10*time.Second, estimateFairQueueETA(5, 2000))
+}
+
+func TestConcurrencyService_DirectAcquireYieldsToFairWaiters(t *testing.T) {
+	cache := &stubFairQueueCache{waiting: map[int64]int{100: 1}}
+	svc := NewConcurrencyService(&releaseRecordingConcurrencyCache{})
+	svc.SetFairQueue(cache, fairQueueTestConfig())
+
+	// 账号有排队者时未排队的新请求让行
+	result, err := svc.AcquireAccountSlot(context.Background(), 100, 2)
+	require.NoError(t, err)
+	require.False(t, result.Acquired)
+	require.Empty(t, cache.direct)
+
+	result, err = svc.AcquireAccountSlot(context.Background(), 101, 2)
+	require.NoError(t, err)
+	require.True(t, result.Acquired)
+	require.Len(t, cache.direct, 1)
+}
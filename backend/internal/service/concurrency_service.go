@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"log"
 	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
 )
 
 // ConcurrencyCache 定义并发控制的缓存接口
@@ -59,6 +61,10 @@ const (
 // ConcurrencyService manages concurrent request limiting for accounts and users
 type ConcurrencyService struct {
 	cache ConcurrencyCache
+
+	// 账号槽位公平排队（可选）
+	fairQueueCache FairQueueCache
+	fairQueueCfg   config.GatewayFairQueueConfig
 }
 
 // NewConcurrencyService creates a new ConcurrencyService
@@ -99,7 +105,7 @@ func (s *ConcurrencyService) AcquireAccountSlot(ctx context.Context, accountID i
 	// Generate unique request ID for this slot
 	requestID := generateRequestID()
 
-	acquired, err := s.cache.AcquireAccountSlot(ctx, accountID, maxConcurrency, requestID)
+	acquired, err := s.acquireAccountSlotUnqueued(ctx, accountID, maxConcurrency, requestID)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+)
+
+// 成本优先路由（分组可选策略）
+//
+// 默认调度只按优先级/负载挑选账号，账号计费倍率仅影响记账。
+// 分组开启 lowest_cost 策略后，负载感知层在可用账号中优先选择有效成本最低的账号，
+// 低成本账号饱和（负载满或槽位获取失败）后才依次溢出到更贵的账号：
+//   - 有效成本 = 账号计费倍率 × 映射后模型单价 / 请求模型单价
+//   - 配置了窗口费用上限且窗口额度未用完的预付费账号视为 0 成本（额度过期即作废）
+//
+// 每次按策略选中账号时记录“默认顺序会选中的账号”的成本系数与实际成本系数，
+// 写入 usage_logs 后按分组汇总即可得到策略转移的花费。
+
+const (
+	// CostRoutingPolicyLowestCost 优先选择有效成本最低的账号
+	CostRoutingPolicyLowestCost = "lowest_cost"
+)
+
+// IsValidCostRoutingPolicy 空字符串表示关闭
+func IsValidCostRoutingPolicy(policy string) bool {
+	return policy == "" || policy == CostRoutingPolicyLowestCost
+}
+
+// CostRoutingEnabled 分组是否开启成本优先路由
+func (g *Group) CostRoutingEnabled() bool {
+	return g != nil && g.CostRoutingPolicy == CostRoutingPolicyLowestCost
+}
+
+// CostRoutingDecision 单次按成本策略调度的决策记录
+type CostRoutingDecision struct {
+	// BaselineAccountID 默认顺序（优先级/负载/评分）下会选中的账号
+	BaselineAccountID int64
+	BaselineFactor    float64
+	ChosenFactor      float64
+}
+
+// costRouter 单次调度内的账号有效成本表
+type costRouter struct {
+	factors map[int64]float64
+}
+
+func newCostRouter(accounts []*Account, factorOf func(*Account) float64) *costRouter {
+	r := &costRouter{factors: make(map[int64]float64, len(accounts))}
+	for _, acc := range accounts {
+		if acc == nil {
+			continue
+		}
+		if _, ok := r.factors[acc.ID]; ok {
+			continue
+		}
+		r.factors[acc.ID] = factorOf(acc)
+	}
+	return r
+}
+
+// order 返回按有效成本升序的下标，成本相同时保持原有顺序
+func (r *costRouter) order(accounts []*Account) []int {
+	idx := make([]int, len(accounts))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		return r.factors[accounts[idx[i]].ID] < r.factors[accounts[idx[j]].ID]
+	})
+	return idx
+}
+
+// decision 生成决策记录，router 为 nil 时返回 nil
+func (r *costRouter) decision(baseline, chosen *Account) *CostRoutingDecision {
+	if r == nil || baseline == nil || chosen == nil {
+		return nil
+	}
+	return &CostRoutingDecision{
+		BaselineAccountID: baseline.ID,
+		BaselineFactor:    r.factors[baseline.ID],
+		ChosenFactor:      r.factors[chosen.ID],
+	}
+}
+
+// costRoutingGroup 从请求上下文获取开启了成本优先路由的分组
+func costRoutingGroup(ctx context.Context, groupID *int64) *Group {
+	if groupID == nil {
+		return nil
+	}
+	group, ok := ctx.Value(ctxkey.Group).(*Group)
+	if !ok || !IsGroupContextValid(group) || group.ID != *groupID || !group.CostRoutingEnabled() {
+		return nil
+	}
+	return group
+}
+
+// modelPriceRatio 账号映射后模型与请求模型的单价比（无映射或缺少价格时为 1）
+func modelPriceRatio(billing *BillingService, account *Account, requestedModel string) float64 {
+	if billing == nil || requestedModel == "" {
+		return 1
+	}
+	mapped := account.GetMappedModel(requestedModel)
+	if mapped == "" || mapped == requestedModel {
+		return 1
+	}
+	requested, err := billing.GetModelPricing(requestedModel)
+	if err != nil || requested == nil {
+		return 1
+	}
+	target, err := billing.GetModelPricing(mapped)
+	if err != nil || target == nil {
+		return 1
+	}
+	base := requested.InputPricePerToken + requested.OutputPricePerToken
+	if base <= 0 {
+		return 1
+	}
+	return (target.InputPricePerToken + target.OutputPricePerToken) / base
+}
+
+// accountCostFactor 账号有效成本系数；prepaidAvailable 表示预付费窗口额度尚未用完
+func accountCostFactor(billing *BillingService, account *Account, requestedModel string, prepaidAvailable bool) float64 {
+	if prepaidAvailable {
+		return 0
+	}
+	return account.BillingRateMultiplier() * modelPriceRatio(billing, account, requestedModel)
+}
+
+// GroupCostRoutingReport 分组成本优先路由报告
+type GroupCostRoutingReport struct {
+	GroupID   int64     `json:"group_id"`
+	Policy    string    `json:"policy"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	// Requests 按策略调度的请求数；ShiftedRequests 其中选中账号与默认顺序不同的请求数
+	Requests        int64 `json:"requests"`
+	ShiftedRequests int64 `json:"shifted_requests"`
+	// BaselineCost 按默认顺序选中账号的成本；ActualCost 实际选中账号的成本（标准费用 × 成本系数）
+	BaselineCost float64 `json:"baseline_cost"`
+	ActualCost   float64 `json:"actual_cost"`
+	// SavedCost = BaselineCost - ActualCost，为负表示策略导致花费上升
+	SavedCost float64 `json:"saved_cost"`
+}
+
+// CostRoutingReportRepository 成本优先路由报告数据源
+type CostRoutingReportRepository interface {
+	GetGroupCostRoutingStats(ctx context.Context, groupID int64, startTime, endTime time.Time) (*GroupCostRoutingReport, error)
+}
+
+// CostRoutingReportService 生成分组成本优先路由报告
+type CostRoutingReportService struct {
+	reportRepo CostRoutingReportRepository
+	groupRepo  GroupRepository
+}
+
+// NewCostRoutingReportService 创建成本优先路由报告服务
+func NewCostRoutingReportService(reportRepo CostRoutingReportRepository, groupRepo GroupRepository) *CostRoutingReportService {
+	return &CostRoutingReportService{reportRepo: reportRepo, groupRepo: groupRepo}
+}
+
+// GetGroupReport 汇总分组在时间范围内按成本策略转移的花费
+func (s *CostRoutingReportService) GetGroupReport(ctx context.Context, groupID int64, startTime, endTime time.Time) (*GroupCostRoutingReport, error) {
+	group, err := s.groupRepo.GetByID(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+	report, err := s.reportRepo.GetGroupCostRoutingStats(ctx, groupID, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	report.GroupID = groupID
+	report.Policy = group.CostRoutingPolicy
+	report.StartTime = startTime
+	report.EndTime = endTime
+	report.SavedCost = report.BaselineCost - report.ActualCost
+	return report, nil
+}
@@ -0,0 +1,65 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCostRouterOrder(t *testing.T) {
+	accounts := []*Account{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}}
+	factors := map[int64]float64{1: 1.5, 2: 0.8, 3: 1.5, 4: 0}
+	router := newCostRouter(accounts, func(acc *Account) float64 { return factors[acc.ID] })
+
+	// 成本相同的账号保持默认顺序
+	require.Equal(t, []int{3, 1, 0, 2}, router.order(accounts))
+
+	decision := router.decision(accounts[0], accounts[3])
+	require.Equal(t, &CostRoutingDecision{BaselineAccountID: 1, BaselineFactor: 1.5, ChosenFactor: 0}, decision)
+
+	var nilRouter *costRouter
+	require.Nil(t, nilRouter.decision(accounts[0], accounts[1]))
+}
+
+func TestAccountCostFactor(t *testing.T) {
+	billing := NewBillingService(nil, nil, nil)
+	multiplier := 2.0
+	account := &Account{
+		RateMultiplier: &multiplier,
+		Credentials: map[string]any{
+			"model_mapping": map[string]any{"claude-sonnet-4-5": "claude-haiku-4-5"},
+		},
+	}
+
+	require.Equal(t, 0.0, accountCostFactor(billing, account, "claude-sonnet-4-5", true))
+	// 未映射或无价格的模型按倍率计算
+	require.Equal(t, 2.0, accountCostFactor(billing, account, "claude-opus-4-5", false))
+	require.Equal(t, 2.0, accountCostFactor(nil, account, "claude-sonnet-4-5", false))
+
+	ratio := modelPriceRatio(billing, account, "claude-sonnet-4-5")
+	require.Greater(t, ratio, 0.0)
+	require.Less(t, ratio, 1.0)
+	require.InDelta(t, 2*ratio, accountCostFactor(billing, account, "claude-sonnet-4-5", false), 1e-9)
+}
+
+func TestCostRoutingGroup(t *testing.T) {
+	groupID := int64(7)
+	group := &Group{ID: groupID, Platform: PlatformAnthropic, Status: StatusActive, Hydrated: true, CostRoutingPolicy: CostRoutingPolicyLowestCost}
+	ctx := context.WithValue(context.Background(), ctxkey.Group, group)
+
+	require.Equal(t, group, costRoutingGroup(ctx, &groupID))
+	require.Nil(t, costRoutingGroup(ctx, nil))
+	other := int64(8)
+	require.Nil(t, costRoutingGroup(ctx, &other))
+
+	group.CostRoutingPolicy = ""
+	require.Nil(t, costRoutingGroup(ctx, &groupID))
+
+	require.True(t, IsValidCostRoutingPolicy(""))
+	require.True(t, IsValidCostRoutingPolicy(CostRoutingPolicyLowestCost))
+	require.False(t, IsValidCostRoutingPolicy("cheapest"))
+}
@@ -0,0 +1,500 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/util/responseheaders"
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// anthropicFilesAPIBeta Anthropic Files API 所需的 beta 标识
+	anthropicFilesAPIBeta = "files-api-2025-04-14"
+	// openaiFilesAPIURL OpenAI Platform Files API（未配置 base_url 的 API Key 账号）
+	openaiFilesAPIURL = "https://api.openai.com/v1/files"
+
+	gatewayFileDefaultLimit     = 20
+	gatewayFileMaxLimit         = 1000
+	gatewayFileMaxSwitches      = 3
+	gatewayFileMaxReferencedIDs = 32
+	gatewayFileCleanupInterval  = 10 * time.Minute
+	gatewayFileSessionPrefix    = "file:"
+)
+
+var (
+	ErrGatewayFileNotFound     = infraerrors.NotFound("GATEWAY_FILE_NOT_FOUND", "file not found")
+	ErrGatewayFileNoAccount    = infraerrors.ServiceUnavailable("GATEWAY_FILE_NO_ACCOUNT", "no available account supports the Files API")
+	ErrGatewayFileUploadFailed = infraerrors.New(http.StatusBadGateway, "GATEWAY_FILE_UPLOAD_FAILED", "upstream file upload failed")
+)
+
+// GatewayFile 通过网关上传的文件及其归属账号
+type GatewayFile struct {
+	ID        int64
+	FileID    string
+	Platform  string
+	AccountID int64
+	UserID    int64
+	APIKeyID  int64
+	Filename  string
+	MimeType  string
+	Purpose   string
+	SizeBytes int64
+	ExpiresAt *time.Time
+	CreatedAt time.Time
+}
+
+// IsExpired 文件是否已过期（未设置过期时间视为永不过期）
+func (f *GatewayFile) IsExpired(now time.Time) bool {
+	return f.ExpiresAt != nil && !f.ExpiresAt.After(now)
+}
+
+// GatewayFileListParams 文件列表分页参数（按创建时间新→旧）
+type GatewayFileListParams struct {
+	Limit    int
+	BeforeID string
+	AfterID  string
+	Purpose  string
+}
+
+// GatewayFileRepository 文件归属映射存储
+type GatewayFileRepository interface {
+	Create(ctx context.Context, file *GatewayFile) error
+	GetByFileID(ctx context.Context, fileID string) (*GatewayFile, error)
+	GetByFileIDs(ctx context.Context, fileIDs []string) ([]GatewayFile, error)
+	List(ctx context.Context, userID int64, platform string, params GatewayFileListParams) ([]GatewayFile, bool, error)
+	Delete(ctx context.Context, fileID string) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// GatewayFileService Files API 透传
+//
+// 上游文件只存在于上传时使用的账号中，因此上传时记录 file_id -> 账号 映射：
+// 查询/下载/删除直接转发到归属账号；Messages/Responses 请求引用 file_id 时通过粘性会话固定到该账号。
+// 仅 API Key 账号支持 Files API（OAuth 账号的上游不提供文件接口）。
+type GatewayFileService struct {
+	repo                 GatewayFileRepository
+	accountRepo          AccountRepository
+	gatewayService       *GatewayService
+	openAIGatewayService *OpenAIGatewayService
+	httpUpstream         HTTPUpstream
+	cfg                  *config.Config
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+func NewGatewayFileService(
+	repo GatewayFileRepository,
+	accountRepo AccountRepository,
+	gatewayService *GatewayService,
+	openAIGatewayService *OpenAIGatewayService,
+	httpUpstream HTTPUpstream,
+	cfg *config.Config,
+) *GatewayFileService {
+	return &GatewayFileService{
+		repo:                 repo,
+		accountRepo:          accountRepo,
+		gatewayService:       gatewayService,
+		openAIGatewayService: openAIGatewayService,
+		httpUpstream:         httpUpstream,
+		cfg:                  cfg,
+		stopCh:               make(chan struct{}),
+	}
+}
+
+// Start 启动过期映射清理
+func (s *GatewayFileService) Start() {
+	if s == nil || s.repo == nil {
+		return
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(gatewayFileCleanupInterval)
+		defer ticker.Stop()
+
+		s.purgeExpired()
+		for {
+			select {
+			case <-ticker.C:
+				s.purgeExpired()
+			case <-s.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+func (s *GatewayFileService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+	s.wg.Wait()
+}
+
+func (s *GatewayFileService) purgeExpired() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	deleted, err := s.repo.DeleteExpired(ctx, time.Now())
+	if err != nil {
+		log.Printf("[GatewayFile] Purge expired file mappings failed: %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("[GatewayFile] Purged %d expired file mappings", deleted)
+	}
+}
+
+// Upload 选择 API Key 账号上传文件（multipart 原样透传），成功后记录 file_id 归属并写回上游响应
+func (s *GatewayFileService) Upload(ctx context.Context, c *gin.Context, apiKey *APIKey, platform string, body []byte) error {
+	excluded := make(map[int64]struct{})
+	for attempt := 0; attempt <= gatewayFileMaxSwitches; attempt++ {
+		account, err := s.selectUploadAccount(ctx, apiKey.GroupID, platform, excluded)
+		if err != nil {
+			if attempt > 0 {
+				return ErrGatewayFileUploadFailed
+			}
+			return ErrGatewayFileNoAccount.WithCause(err)
+		}
+
+		resp, err := s.doUpstream(ctx, c, account, http.MethodPost, "", nil, bytes.NewReader(body))
+		if err != nil {
+			log.Printf("[GatewayFile] Upload via account %d failed: %v", account.ID, err)
+			excluded[account.ID] = struct{}{}
+			continue
+		}
+		if resp.StatusCode >= 400 && s.shouldFailover(platform, resp.StatusCode) {
+			s.handleFailoverSideEffects(ctx, platform, resp, account)
+			_ = resp.Body.Close()
+			excluded[account.ID] = struct{}{}
+			continue
+		}
+
+		respBody, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("read upstream response: %w", err)
+		}
+		if resp.StatusCode < 400 {
+			if file := parseUploadedFile(platform, respBody); file != nil {
+				file.AccountID = account.ID
+				file.UserID = apiKey.UserID
+				file.APIKeyID = apiKey.ID
+				if err := s.repo.Create(ctx, file); err != nil {
+					log.Printf("[GatewayFile] Record file %s (account=%d) failed: %v", file.FileID, account.ID, err)
+				}
+			}
+		}
+		s.writeResponse(c, resp, respBody)
+		return nil
+	}
+	return ErrGatewayFileUploadFailed
+}
+
+// List 列出用户通过网关上传的文件（来自本地映射）
+func (s *GatewayFileService) List(ctx context.Context, userID int64, platform string, params GatewayFileListParams) ([]GatewayFile, bool, error) {
+	if params.Limit <= 0 {
+		params.Limit = gatewayFileDefaultLimit
+	}
+	if params.Limit > gatewayFileMaxLimit {
+		params.Limit = gatewayFileMaxLimit
+	}
+	return s.repo.List(ctx, userID, platform, params)
+}
+
+// Forward 将单个文件的查询（subPath 为空）/下载（subPath 为 "content"）/删除请求转发到归属账号
+func (s *GatewayFileService) Forward(ctx context.Context, c *gin.Context, userID int64, platform, fileID, method, subPath string) error {
+	file, err := s.getOwnedFile(ctx, userID, platform, fileID)
+	if err != nil {
+		return err
+	}
+	account, err := s.accountRepo.GetByID(ctx, file.AccountID)
+	if err != nil {
+		if errors.Is(err, ErrAccountNotFound) {
+			// 归属账号已不存在，上游文件不可再访问
+			s.dropMapping(ctx, fileID)
+			return ErrGatewayFileNotFound
+		}
+		return err
+	}
+
+	path := "/" + url.PathEscape(fileID)
+	if subPath != "" {
+		path += "/" + subPath
+	}
+	resp, err := s.doUpstream(ctx, c, account, method, path, c.Request.URL.Query(), nil)
+	if err != nil {
+		return infraerrors.New(http.StatusBadGateway, "GATEWAY_FILE_UPSTREAM_FAILED", "upstream request failed").WithCause(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusNotFound || (method == http.MethodDelete && resp.StatusCode < 400) {
+		s.dropMapping(ctx, fileID)
+	}
+
+	responseheaders.WriteFilteredHeaders(c.Writer.Header(), resp.Header, s.cfg.Security.ResponseHeaders)
+	if ct := resp.Header.Get("Content-Type"); ct != "" {
+		c.Header("Content-Type", ct)
+	}
+	if cd := resp.Header.Get("Content-Disposition"); cd != "" {
+		c.Header("Content-Disposition", cd)
+	}
+	c.Status(resp.StatusCode)
+	if _, err := io.Copy(c.Writer, resp.Body); err != nil {
+		log.Printf("[GatewayFile] Copy upstream response for %s failed: %v", fileID, err)
+	}
+	return nil
+}
+
+// BindFileAffinity 请求引用了用户通过网关上传的文件时，将粘性会话绑定到文件归属账号并返回会话键；
+// 未引用或映射不可用时返回空串。多个文件归属不同账号时以首个可用文件为准。
+func (s *GatewayFileService) BindFileAffinity(ctx context.Context, apiKey *APIKey, platform string, body []byte) string {
+	if s == nil || apiKey == nil {
+		return ""
+	}
+	fileIDs := ExtractReferencedFileIDs(body)
+	if len(fileIDs) == 0 {
+		return ""
+	}
+	files, err := s.repo.GetByFileIDs(ctx, fileIDs)
+	if err != nil {
+		log.Printf("[GatewayFile] Lookup referenced files failed: %v", err)
+		return ""
+	}
+	byID := make(map[string]*GatewayFile, len(files))
+	for i := range files {
+		byID[files[i].FileID] = &files[i]
+	}
+
+	now := time.Now()
+	for _, id := range fileIDs {
+		file := byID[id]
+		if file == nil || file.UserID != apiKey.UserID || file.Platform != platform || file.IsExpired(now) {
+			continue
+		}
+		sessionKey := gatewayFileSessionPrefix + file.FileID
+		switch platform {
+		case PlatformOpenAI:
+			err = s.openAIGatewayService.BindStickySession(ctx, apiKey.GroupID, sessionKey, file.AccountID)
+		default:
+			err = s.gatewayService.BindStickySession(ctx, apiKey.GroupID, sessionKey, file.AccountID)
+		}
+		if err != nil {
+			log.Printf("[GatewayFile] Bind file affinity failed: file=%s account=%d err=%v", file.FileID, file.AccountID, err)
+			return ""
+		}
+		return sessionKey
+	}
+	return ""
+}
+
+// ExtractReferencedFileIDs 提取请求体中引用的 file_id（Claude source.file_id、OpenAI input_file/input_image.file_id 等），
+// 按出现顺序去重
+func ExtractReferencedFileIDs(body []byte) []string {
+	if !bytes.Contains(body, []byte(`"file_id"`)) {
+		return nil
+	}
+	var ids []string
+	seen := make(map[string]struct{})
+	var walk func(value gjson.Result, depth int)
+	walk = func(value gjson.Result, depth int) {
+		if depth > 32 || len(ids) >= gatewayFileMaxReferencedIDs {
+			return
+		}
+		value.ForEach(func(key, item gjson.Result) bool {
+			if key.Str == "file_id" && item.Type == gjson.String {
+				if id := strings.TrimSpace(item.Str); id != "" {
+					if _, ok := seen[id]; !ok {
+						seen[id] = struct{}{}
+						ids = append(ids, id)
+					}
+				}
+			} else if item.IsObject() || item.IsArray() {
+				walk(item, depth+1)
+			}
+			return len(ids) < gatewayFileMaxReferencedIDs
+		})
+	}
+	walk(gjson.ParseBytes(body), 0)
+	return ids
+}
+
+func (s *GatewayFileService) getOwnedFile(ctx context.Context, userID int64, platform, fileID string) (*GatewayFile, error) {
+	file, err := s.repo.GetByFileID(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+	if file.UserID != userID || file.Platform != platform || file.IsExpired(time.Now()) {
+		return nil, ErrGatewayFileNotFound
+	}
+	return file, nil
+}
+
+func (s *GatewayFileService) dropMapping(ctx context.Context, fileID string) {
+	if err := s.repo.Delete(ctx, fileID); err != nil && !errors.Is(err, ErrGatewayFileNotFound) {
+		log.Printf("[GatewayFile] Delete file mapping %s failed: %v", fileID, err)
+	}
+}
+
+// selectUploadAccount 按分组调度选择支持 Files API 的账号（跳过 OAuth 与混合调度的其他平台账号）
+func (s *GatewayFileService) selectUploadAccount(ctx context.Context, groupID *int64, platform string, excluded map[int64]struct{}) (*Account, error) {
+	for {
+		var (
+			account *Account
+			err     error
+		)
+		if platform == PlatformOpenAI {
+			account, err = s.openAIGatewayService.SelectAccountForModelWithExclusions(ctx, groupID, "", "", excluded)
+		} else {
+			account, err = s.gatewayService.SelectAccountForModelWithExclusions(ctx, groupID, "", "", excluded)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if account.Platform == platform && account.Type == AccountTypeAPIKey {
+			return account, nil
+		}
+		excluded[account.ID] = struct{}{}
+	}
+}
+
+// doUpstream 构建并发送 Files API 上游请求；path 为 /v1/files 之后的部分
+func (s *GatewayFileService) doUpstream(ctx context.Context, c *gin.Context, account *Account, method, path string, query url.Values, body io.Reader) (*http.Response, error) {
+	if account.Type != AccountTypeAPIKey {
+		return nil, fmt.Errorf("account %d does not support the Files API", account.ID)
+	}
+	apiKey := account.GetCredential("api_key")
+	if apiKey == "" {
+		return nil, errors.New("api_key not found in credentials")
+	}
+
+	var targetURL string
+	switch account.Platform {
+	case PlatformOpenAI:
+		targetURL = openaiFilesAPIURL
+		if baseURL := strings.TrimSpace(account.GetCredential("base_url")); baseURL != "" {
+			validatedURL, err := s.openAIGatewayService.validateUpstreamBaseURL(baseURL)
+			if err != nil {
+				return nil, err
+			}
+			targetURL = validatedURL + "/files"
+		}
+	case PlatformAnthropic:
+		validatedURL, err := s.gatewayService.validateUpstreamBaseURL(account.GetBaseURL())
+		if err != nil {
+			return nil, err
+		}
+		targetURL = validatedURL + "/v1/files"
+	default:
+		return nil, fmt.Errorf("platform %s does not support the Files API", account.Platform)
+	}
+	targetURL += path
+	if len(query) > 0 {
+		targetURL += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, targetURL, body)
+	if err != nil {
+		return nil, err
+	}
+	if ct := c.GetHeader("Content-Type"); ct != "" && body != nil {
+		req.Header.Set("content-type", ct)
+	}
+
+	proxyURL := ""
+	if account.ProxyID != nil && account.Proxy != nil {
+		proxyURL = account.Proxy.URL()
+	}
+
+	if account.Platform == PlatformOpenAI {
+		req.Header.Set("authorization", "Bearer "+apiKey)
+		if customUA := account.GetOpenAIUserAgent(); customUA != "" {
+			req.Header.Set("user-agent", customUA)
+		}
+		return s.httpUpstream.Do(req, proxyURL, account.ID, account.Concurrency)
+	}
+
+	req.Header.Set("x-api-key", apiKey)
+	version := c.GetHeader("anthropic-version")
+	if version == "" {
+		version = "2023-06-01"
+	}
+	req.Header.Set("anthropic-version", version)
+	beta := c.GetHeader("anthropic-beta")
+	if !strings.Contains(beta, anthropicFilesAPIBeta) {
+		if beta != "" {
+			beta += ","
+		}
+		beta += anthropicFilesAPIBeta
+	}
+	req.Header.Set("anthropic-beta", beta)
+	return s.httpUpstream.DoWithTLS(req, proxyURL, account.ID, account.Concurrency, account.IsTLSFingerprintEnabled())
+}
+
+func (s *GatewayFileService) shouldFailover(platform string, statusCode int) bool {
+	if platform == PlatformOpenAI {
+		return s.openAIGatewayService.shouldFailoverUpstreamError(statusCode)
+	}
+	return s.gatewayService.shouldFailoverUpstreamError(statusCode)
+}
+
+func (s *GatewayFileService) handleFailoverSideEffects(ctx context.Context, platform string, resp *http.Response, account *Account) {
+	if platform == PlatformOpenAI {
+		s.openAIGatewayService.handleFailoverSideEffects(ctx, resp, account)
+		return
+	}
+	s.gatewayService.handleFailoverSideEffects(ctx, resp, account)
+}
+
+func (s *GatewayFileService) writeResponse(c *gin.Context, resp *http.Response, body []byte) {
+	responseheaders.WriteFilteredHeaders(c.Writer.Header(), resp.Header, s.cfg.Security.ResponseHeaders)
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	c.Data(resp.StatusCode, contentType, body)
+}
+
+// parseUploadedFile 从上游上传响应解析文件元数据（Anthropic file 对象 / OpenAI file 对象）
+func parseUploadedFile(platform string, body []byte) *GatewayFile {
+	parsed := gjson.ParseBytes(body)
+	fileID := parsed.Get("id").String()
+	if fileID == "" {
+		return nil
+	}
+	file := &GatewayFile{
+		FileID:   fileID,
+		Platform: platform,
+		Filename: parsed.Get("filename").String(),
+	}
+	if platform == PlatformOpenAI {
+		file.SizeBytes = parsed.Get("bytes").Int()
+		file.Purpose = parsed.Get("purpose").String()
+		if expiresAt := parsed.Get("expires_at").Int(); expiresAt > 0 {
+			t := time.Unix(expiresAt, 0)
+			file.ExpiresAt = &t
+		}
+		return file
+	}
+	file.SizeBytes = parsed.Get("size_bytes").Int()
+	file.MimeType = parsed.Get("mime_type").String()
+	return file
+}
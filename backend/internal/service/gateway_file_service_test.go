@@ -0,0 +1,113 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type stubGatewayFileRepo struct {
+	files   map[string]GatewayFile
+	deleted []string
+}
+
+func (r *stubGatewayFileRepo) Create(ctx context.Context, file *GatewayFile) error {
+	r.files[file.FileID] = *file
+	return nil
+}
+
+func (r *stubGatewayFileRepo) GetByFileID(ctx context.Context, fileID string) (*GatewayFile, error) {
+	f, ok := r.files[fileID]
+	if !ok {
+		return nil, ErrGatewayFileNotFound
+	}
+	return &f, nil
+}
+
+func (r *stubGatewayFileRepo) GetByFileIDs(ctx context.Context, fileIDs []string) ([]GatewayFile, error) {
+	out := make([]GatewayFile, 0, len(fileIDs))
+	for _, id := range fileIDs {
+		if f, ok := r.files[id]; ok {
+			out = append(out, f)
+		}
+	}
+	return out, nil
+}
+
+func (r *stubGatewayFileRepo) List(ctx context.Context, userID int64, platform string, params GatewayFileListParams) ([]GatewayFile, bool, error) {
+	return nil, false, nil
+}
+
+func (r *stubGatewayFileRepo) Delete(ctx context.Context, fileID string) error {
+	r.deleted = append(r.deleted, fileID)
+	delete(r.files, fileID)
+	return nil
+}
+
+func (r *stubGatewayFileRepo) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestExtractReferencedFileIDs(t *testing.T) {
+	claudeBody := []byte(`{"model":"claude-sonnet-4-5","messages":[{"role":"user","content":[
+		{"type":"document","source":{"type":"file","file_id":"file_011"}},
+		{"type":"image","source":{"type":"file","file_id":"file_022"}},
+		{"type":"document","source":{"type":"file","file_id":"file_011"}},
+		{"type":"text","text":"summarize"}]}]}`)
+	require.Equal(t, []string{"file_011", "file_022"}, ExtractReferencedFileIDs(claudeBody))
+
+	responsesBody := []byte(`{"model":"gpt-4.1","input":[{"role":"user","content":[
+		{"type":"input_file","file_id":"file-abc"},
+		{"type":"input_text","text":"what is in this file?"}]}]}`)
+	require.Equal(t, []string{"file-abc"}, ExtractReferencedFileIDs(responsesBody))
+
+	require.Nil(t, ExtractReferencedFileIDs([]byte(`{"messages":[{"role":"user","content":"hi"}]}`)))
+}
+
+func TestParseUploadedFile(t *testing.T) {
+	anthropic := parseUploadedFile(PlatformAnthropic, []byte(`{"id":"file_011","type":"file","filename":"a.pdf","mime_type":"application/pdf","size_bytes":1024}`))
+	require.NotNil(t, anthropic)
+	require.Equal(t, "file_011", anthropic.FileID)
+	require.Equal(t, "application/pdf", anthropic.MimeType)
+	require.Equal(t, int64(1024), anthropic.SizeBytes)
+	require.Nil(t, anthropic.ExpiresAt)
+
+	openai := parseUploadedFile(PlatformOpenAI, []byte(`{"id":"file-abc","object":"file","bytes":2048,"filename":"b.jsonl","purpose":"user_data","expires_at":1893456000}`))
+	require.NotNil(t, openai)
+	require.Equal(t, "user_data", openai.Purpose)
+	require.Equal(t, int64(2048), openai.SizeBytes)
+	require.NotNil(t, openai.ExpiresAt)
+	require.Equal(t, int64(1893456000), openai.ExpiresAt.Unix())
+
+	require.Nil(t, parseUploadedFile(PlatformAnthropic, []byte(`{"type":"error"}`)))
+}
+
+func TestGatewayFileService_BindFileAffinity(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	repo := &stubGatewayFileRepo{files: map[string]GatewayFile{
+		"file_other":   {FileID: "file_other", Platform: PlatformAnthropic, AccountID: 7, UserID: 2},
+		"file_expired": {FileID: "file_expired", Platform: PlatformAnthropic, AccountID: 8, UserID: 1, ExpiresAt: &past},
+		"file_owned":   {FileID: "file_owned", Platform: PlatformAnthropic, AccountID: 9, UserID: 1},
+	}}
+	cache := &mockGatewayCacheForPlatform{}
+	svc := NewGatewayFileService(repo, nil, &GatewayService{cache: cache}, nil, nil, testConfig())
+	groupID := int64(3)
+	apiKey := &APIKey{ID: 5, UserID: 1, GroupID: &groupID}
+
+	body := []byte(`{"messages":[{"role":"user","content":[
+		{"type":"document","source":{"type":"file","file_id":"file_other"}},
+		{"type":"document","source":{"type":"file","file_id":"file_expired"}},
+		{"type":"document","source":{"type":"file","file_id":"file_owned"}}]}]}`)
+
+	key := svc.BindFileAffinity(context.Background(), apiKey, PlatformAnthropic, body)
+	require.Equal(t, "file:file_owned", key)
+	require.Equal(t, int64(9), cache.sessionBindings[key])
+
+	// 平台不匹配或未引用文件时不绑定
+	require.Empty(t, svc.BindFileAffinity(context.Background(), apiKey, PlatformOpenAI, body))
+	require.Empty(t, svc.BindFileAffinity(context.Background(), apiKey, PlatformAnthropic, []byte(`{"messages":[]}`)))
+}
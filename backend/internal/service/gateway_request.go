@@ -22,6 +22,7 @@ type ParsedRequest struct {
 	Body           []byte // 原始请求体（保留用于转发）
 	Model          string // 请求的模型名称
 	Stream         bool   // 是否为流式请求
+	MaxTokens      int    // max_tokens（未提供时为 0）
 	MetadataUserID string // metadata.user_id（用于会话亲和）
 	System         any    // system 字段内容
 	Messages       []any  // messages 数组
@@ -54,6 +55,9 @@ func ParseGatewayRequest(body []byte) (*ParsedRequest, error) {
 		}
 		parsed.Stream = stream
 	}
+	if maxTokens, ok := req["max_tokens"].(float64); ok && maxTokens > 0 {
+		parsed.MaxTokens = int(maxTokens)
+	}
 	if metadata, ok := req["metadata"].(map[string]any); ok {
 		if userID, ok := metadata["user_id"].(string); ok {
 			parsed.MetadataUserID = userID
@@ -72,6 +76,11 @@ func ParseGatewayRequest(body []byte) (*ParsedRequest, error) {
 	return parsed, nil
 }
 
+// Clone 深拷贝解析结果（复制请求体并重新解析），供并发转发（如请求对冲）的各个尝试独立使用
+func (p *ParsedRequest) Clone() (*ParsedRequest, error) {
+	return ParseGatewayRequest(bytes.Clone(p.Body))
+}
+
 // FilterThinkingBlocks removes thinking blocks from request body
 // Returns filtered body or original body if filtering fails (fail-safe)
 // This prevents 400 errors from invalid thinking block signatures
@@ -19,6 +19,22 @@ func TestParseGatewayRequest(t *testing.T) {
 	require.Len(t, parsed.Messages, 1)
 }
 
+func TestParsedRequestClone(t *testing.T) {
+	parsed, err := ParseGatewayRequest([]byte(`{"model":"claude-haiku-4-5","max_tokens":64,"messages":[{"role":"user","content":"hi"}]}`))
+	require.NoError(t, err)
+
+	clone, err := parsed.Clone()
+	require.NoError(t, err)
+	require.Equal(t, parsed.Model, clone.Model)
+	require.Equal(t, parsed.MaxTokens, clone.MaxTokens)
+	require.Equal(t, parsed.Body, clone.Body)
+
+	clone.Body[0] = ' '
+	clone.Messages[0].(map[string]any)["content"] = "changed"
+	require.Equal(t, byte('{'), parsed.Body[0])
+	require.Equal(t, "hi", parsed.Messages[0].(map[string]any)["content"])
+}
+
 func TestParseGatewayRequest_SystemNull(t *testing.T) {
 	body := []byte(`{"model":"claude-3","system":null}`)
 	parsed, err := ParseGatewayRequest(body)
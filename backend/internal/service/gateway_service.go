@@ -161,6 +161,8 @@ type AccountSelectionResult struct {
 	Acquired    bool
 	ReleaseFunc func()
 	WaitPlan    *AccountWaitPlan // nil means no wait allowed
+	// CostRouting 按分组成本优先策略调度时的决策记录（未启用时为 nil）
+	CostRouting *CostRoutingDecision
 }
 
 // ClaudeUsage 表示Claude API返回的usage信息
@@ -184,6 +186,9 @@ type ForwardResult struct {
 	// 图片生成计费字段（仅 gemini-3-pro-image 使用）
 	ImageCount int    // 生成的图片数量
 	ImageSize  string // 图片尺寸 "1K", "2K", "4K"
+
+	// Embedding 请求按 embedding 价格仅对输入 token 计费
+	Embedding bool
 }
 
 // UpstreamFailoverError indicates an upstream error that should trigger account failover.
@@ -214,6 +219,10 @@ type GatewayService struct {
 	concurrencyService  *ConcurrencyService
 	claudeTokenProvider *ClaudeTokenProvider
 	sessionLimitCache   SessionLimitCache // 会话数量限制缓存（仅 Anthropic OAuth/SetupToken）
+	tokenCountEstimator *TokenCountEstimator
+	rateBudgetService   *AccountRateBudgetService // 账号 RPM/TPM 预算
+	scoringService      *AccountScoringService    // 账号延迟/错误率评分
+	circuitBreaker      *AccountCircuitBreakerService
 }
 
 // NewGatewayService creates a new GatewayService
@@ -235,6 +244,10 @@ func NewGatewayService(
 	deferredService *DeferredService,
 	claudeTokenProvider *ClaudeTokenProvider,
 	sessionLimitCache SessionLimitCache,
+	tokenCountEstimator *TokenCountEstimator,
+	rateBudgetService *AccountRateBudgetService,
+	scoringService *AccountScoringService,
+	circuitBreaker *AccountCircuitBreakerService,
 ) *GatewayService {
 	return &GatewayService{
 		accountRepo:         accountRepo,
@@ -254,6 +267,10 @@ func NewGatewayService(
 		deferredService:     deferredService,
 		claudeTokenProvider: claudeTokenProvider,
 		sessionLimitCache:   sessionLimitCache,
+		tokenCountEstimator: tokenCountEstimator,
+		rateBudgetService:   rateBudgetService,
+		scoringService:      scoringService,
+		circuitBreaker:      circuitBreaker,
 	}
 }
 
@@ -297,12 +314,12 @@ func (s *GatewayService) GenerateSessionHash(parsed *ParsedRequest) string {
 	return ""
 }
 
-// BindStickySession sets session -> account binding with standard TTL.
+// BindStickySession sets session -> account binding with the group TTL (standard TTL by default).
 func (s *GatewayService) BindStickySession(ctx context.Context, groupID *int64, sessionHash string, accountID int64) error {
 	if sessionHash == "" || accountID <= 0 || s.cache == nil {
 		return nil
 	}
-	return s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), sessionHash, accountID, stickySessionTTL)
+	return s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), sessionHash, accountID, groupStickySessionTTL(ctx, stickySessionTTL))
 }
 
 // GetCachedSessionAccountID retrieves the account ID bound to a sticky session.
@@ -462,6 +479,30 @@ func (s *GatewayService) SelectAccountForModelWithExclusions(ctx context.Context
 // SelectAccountWithLoadAwareness selects account with load-awareness and wait plan.
 // metadataUserID: 已废弃参数，会话限制现在统一使用 sessionHash
 func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, groupID *int64, sessionHash string, requestedModel string, excludedIDs map[int64]struct{}, metadataUserID string) (*AccountSelectionResult, error) {
+	for {
+		result, err := s.selectAccountWithLoadAwareness(ctx, groupID, sessionHash, requestedModel, excludedIDs)
+		if err != nil || result == nil || !result.Acquired {
+			// 等待计划此时不领取名额，由调用方获取到槽位后通过 ReserveAccountRequest 领取
+			return result, err
+		}
+		if s.ReserveAccountRequest(ctx, result.Account) {
+			return result, nil
+		}
+		// 筛选后探测名额被领取或预算被并发请求占满：释放槽位并改选其他账号
+		if result.ReleaseFunc != nil {
+			result.ReleaseFunc()
+		}
+		excludedIDs = withExcludedAccount(excludedIDs, result.Account.ID)
+	}
+}
+
+// ReserveAccountRequest 获取到账号槽位后领取熔断放行名额（半开探测）并原子地计入 RPM 预算
+// 返回 false 表示账号熔断中、探测名额已被领取或预算已满，调用方应释放槽位并改选其他账号。
+func (s *GatewayService) ReserveAccountRequest(ctx context.Context, account *Account) bool {
+	return s.circuitBreaker.AcquirePermit(ctx, account) && s.rateBudgetService.TryReserveRequest(ctx, account)
+}
+
+func (s *GatewayService) selectAccountWithLoadAwareness(ctx context.Context, groupID *int64, sessionHash string, requestedModel string, excludedIDs map[int64]struct{}) (*AccountSelectionResult, error) {
 	// 调试日志：记录调度入口参数
 	excludedIDsList := make([]int64, 0, len(excludedIDs))
 	for id := range excludedIDs {
@@ -474,6 +515,7 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 		"excluded_ids", excludedIDsList)
 
 	cfg := s.schedulingConfig()
+	trace := selectionTraceFrom(ctx)
 
 	var stickyAccountID int64
 	if sessionHash != "" && s.cache != nil {
@@ -481,6 +523,7 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 			stickyAccountID = accountID
 		}
 	}
+	trace.sticky(stickyAccountID)
 
 	// 检查 Claude Code 客户端限制（可能会替换 groupID 为降级分组）
 	group, groupID, err := s.checkClaudeCodeRestriction(ctx, groupID)
@@ -499,6 +542,13 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 	}
 
 	if s.concurrencyService == nil || !cfg.LoadBatchEnabled {
+		trace.enter(SchedulerLayerLegacy)
+		if trace != nil {
+			if err := s.traceLegacyCandidates(ctx, trace, groupID, group, requestedModel, excludedIDs); err != nil {
+				return nil, err
+			}
+		}
+
 		// 复制排除列表，用于会话限制拒绝时的重试
 		localExcluded := make(map[int64]struct{})
 		for k, v := range excludedIDs {
@@ -510,6 +560,11 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 			if err != nil {
 				return nil, err
 			}
+			// RPM/TPM 预算已满或熔断中时排除并重新选择
+			if s.rateBudgetService.IsSaturated(ctx, account) || s.circuitBreaker.IsBlocked(ctx, account) {
+				localExcluded[account.ID] = struct{}{}
+				continue
+			}
 
 			result, err := s.tryAcquireAccountSlot(ctx, account.ID, account.Concurrency)
 			if err == nil && result.Acquired {
@@ -571,17 +626,17 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 	if err != nil {
 		return nil, err
 	}
+	trace.scope(groupID, platform, useMixed)
 	if len(accounts) == 0 {
 		return nil, errors.New("no available accounts")
 	}
 
+	// RPM/TPM 预算已满、熔断中的账号与显式排除的账号一样跳过（路由、粘性与负载感知各层均生效）
+	exclusion, rejection := s.accountFilter(ctx, accounts, excludedIDs, platform, useMixed, requestedModel)
 	isExcluded := func(accountID int64) bool {
-		if excludedIDs == nil {
-			return false
-		}
-		_, excluded := excludedIDs[accountID]
-		return excluded
+		return exclusion(accountID) != ""
 	}
+	trace.evaluate(accounts, rejection)
 
 	// 提前构建 accountByID（供 Layer 1 和 Layer 1.5 使用）
 	accountByID := make(map[int64]*Account, len(accounts))
@@ -592,7 +647,8 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 	// 获取模型路由配置（仅 anthropic 平台）
 	var routingAccountIDs []int64
 	if group != nil && requestedModel != "" && group.Platform == PlatformAnthropic {
-		routingAccountIDs = group.GetRoutingAccountIDs(requestedModel)
+		routingAccountIDs = group.ResolveRoutingAccountIDs(requestedModel, accounts)
+		trace.routing(routingAccountIDs)
 		if s.debugModelRoutingEnabled() {
 			log.Printf("[ModelRoutingDebug] context group routing: group_id=%d model=%s enabled=%v rules=%d matched_ids=%v session=%s sticky_account=%d",
 				group.ID, requestedModel, group.ModelRoutingEnabled, len(group.ModelRouting), routingAccountIDs, shortSessionHash(sessionHash), stickyAccountID)
@@ -613,41 +669,36 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 
 	// ============ Layer 1: 模型路由优先选择（优先级高于粘性会话） ============
 	if len(routingAccountIDs) > 0 && s.concurrencyService != nil {
+		trace.enter(SchedulerLayerModelRouting)
 		// 1. 过滤出路由列表中可调度的账号
 		var routingCandidates []*Account
 		var filteredExcluded, filteredMissing, filteredUnsched, filteredPlatform, filteredModelScope, filteredModelMapping, filteredWindowCost int
 		for _, routingAccountID := range routingAccountIDs {
-			if isExcluded(routingAccountID) {
-				filteredExcluded++
-				continue
-			}
 			account, ok := accountByID[routingAccountID]
-			if !ok || !account.IsSchedulable() {
-				if !ok {
-					filteredMissing++
+			if !ok {
+				if isExcluded(routingAccountID) {
+					filteredExcluded++
 				} else {
-					filteredUnsched++
+					filteredMissing++
 				}
 				continue
 			}
-			if !s.isAccountAllowedForPlatform(account, platform, useMixed) {
+			switch rejection(account) {
+			case "":
+				routingCandidates = append(routingCandidates, account)
+			case SchedulerReasonExcluded, SchedulerReasonRateBudget, SchedulerReasonCircuitOpen:
+				filteredExcluded++
+			case SchedulerReasonUnschedulable:
+				filteredUnsched++
+			case SchedulerReasonPlatform:
 				filteredPlatform++
-				continue
-			}
-			if !account.IsSchedulableForModel(requestedModel) {
+			case SchedulerReasonModelRateLimited:
 				filteredModelScope++
-				continue
-			}
-			if requestedModel != "" && !s.isModelSupportedByAccount(account, requestedModel) {
+			case SchedulerReasonModelUnsupported:
 				filteredModelMapping++
-				continue
-			}
-			// 窗口费用检查（非粘性会话路径）
-			if !s.isAccountSchedulableForWindowCost(ctx, account, false) {
+			case SchedulerReasonWindowCost:
 				filteredWindowCost++
-				continue
 			}
-			routingCandidates = append(routingCandidates, account)
 		}
 
 		if s.debugModelRoutingEnabled() {
@@ -675,7 +726,7 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 									result.ReleaseFunc() // 释放槽位
 									// 继续到负载感知选择
 								} else {
-									_ = s.cache.RefreshSessionTTL(ctx, derefGroupID(groupID), sessionHash, stickySessionTTL)
+									_ = s.cache.RefreshSessionTTL(ctx, derefGroupID(groupID), sessionHash, groupStickySessionTTL(ctx, stickySessionTTL))
 									if s.debugModelRoutingEnabled() {
 										log.Printf("[ModelRoutingDebug] routed sticky hit: group_id=%v model=%s session=%s account=%d", derefGroupID(groupID), requestedModel, shortSessionHash(sessionHash), stickyAccountID)
 									}
@@ -760,7 +811,39 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 					}
 				})
 
+				// 评分类策略：同优先级内按 TTFT/错误率/负载评分重排
+				if s.scoringService.Enabled(cfg.FallbackSelectionMode) {
+					accs := make([]*Account, 0, len(routingAvailable))
+					for _, item := range routingAvailable {
+						accs = append(accs, item.account)
+					}
+					reordered := make([]accountWithLoad, 0, len(routingAvailable))
+					for _, idx := range s.scoringService.Order(ctx, cfg.FallbackSelectionMode, accs, routingLoadMap) {
+						reordered = append(reordered, routingAvailable[idx])
+					}
+					routingAvailable = reordered
+				}
+
+				// 成本优先路由：以默认顺序的首选账号为基线，按有效成本稳定重排（低成本账号饱和后再溢出）
+				var router *costRouter
+				var baseline *Account
+				if costRoutingGroup(ctx, groupID) != nil {
+					accs := make([]*Account, 0, len(routingAvailable))
+					for _, item := range routingAvailable {
+						accs = append(accs, item.account)
+					}
+					if router = s.newCostRouter(ctx, groupID, accs, requestedModel); router != nil {
+						baseline = accs[0]
+						reordered := make([]accountWithLoad, 0, len(routingAvailable))
+						for _, idx := range router.order(accs) {
+							reordered = append(reordered, routingAvailable[idx])
+						}
+						routingAvailable = reordered
+					}
+				}
+
 				// 4. 尝试获取槽位
+				trace.rank(len(routingAvailable), func(i int) *Account { return routingAvailable[i].account })
 				for _, item := range routingAvailable {
 					result, err := s.tryAcquireAccountSlot(ctx, item.account.ID, item.account.Concurrency)
 					if err == nil && result.Acquired {
@@ -770,7 +853,7 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 							continue
 						}
 						if sessionHash != "" && s.cache != nil {
-							_ = s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), sessionHash, item.account.ID, stickySessionTTL)
+							_ = s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), sessionHash, item.account.ID, groupStickySessionTTL(ctx, stickySessionTTL))
 						}
 						if s.debugModelRoutingEnabled() {
 							log.Printf("[ModelRoutingDebug] routed select: group_id=%v model=%s session=%s account=%d", derefGroupID(groupID), requestedModel, shortSessionHash(sessionHash), item.account.ID)
@@ -779,6 +862,7 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 							Account:     item.account,
 							Acquired:    true,
 							ReleaseFunc: result.ReleaseFunc,
+							CostRouting: router.decision(baseline, item.account),
 						}, nil
 					}
 				}
@@ -811,6 +895,7 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 
 	// ============ Layer 1.5: 粘性会话（仅在无模型路由配置时生效） ============
 	if len(routingAccountIDs) == 0 && sessionHash != "" && s.cache != nil {
+		trace.enter(SchedulerLayerSticky)
 		accountID, err := s.cache.GetSessionAccountID(ctx, derefGroupID(groupID), sessionHash)
 		if err == nil && accountID > 0 && !isExcluded(accountID) {
 			account, ok := accountByID[accountID]
@@ -833,7 +918,7 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 						if !s.checkAndRegisterSession(ctx, account, sessionHash) {
 							result.ReleaseFunc() // 释放槽位，继续到 Layer 2
 						} else {
-							_ = s.cache.RefreshSessionTTL(ctx, derefGroupID(groupID), sessionHash, stickySessionTTL)
+							_ = s.cache.RefreshSessionTTL(ctx, derefGroupID(groupID), sessionHash, groupStickySessionTTL(ctx, stickySessionTTL))
 							return &AccountSelectionResult{
 								Account:     account,
 								Acquired:    true,
@@ -867,32 +952,13 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 	}
 
 	// ============ Layer 2: 负载感知选择 ============
+	trace.enter(SchedulerLayerLoadBalance)
 	candidates := make([]*Account, 0, len(accounts))
 	for i := range accounts {
 		acc := &accounts[i]
-		if isExcluded(acc.ID) {
-			continue
+		if rejection(acc) == "" {
+			candidates = append(candidates, acc)
 		}
-		// Scheduler snapshots can be temporarily stale (bucket rebuild is throttled);
-		// re-check schedulability here so recently rate-limited/overloaded accounts
-		// are not selected again before the bucket is rebuilt.
-		if !acc.IsSchedulable() {
-			continue
-		}
-		if !s.isAccountAllowedForPlatform(acc, platform, useMixed) {
-			continue
-		}
-		if !acc.IsSchedulableForModel(requestedModel) {
-			continue
-		}
-		if requestedModel != "" && !s.isModelSupportedByAccount(acc, requestedModel) {
-			continue
-		}
-		// 窗口费用检查（非粘性会话路径）
-		if !s.isAccountSchedulableForWindowCost(ctx, acc, false) {
-			continue
-		}
-		candidates = append(candidates, acc)
 	}
 
 	if len(candidates) == 0 {
@@ -955,6 +1021,38 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 				}
 			})
 
+			// 评分类策略：同优先级内按 TTFT/错误率/负载评分重排
+			if s.scoringService.Enabled(cfg.FallbackSelectionMode) {
+				accs := make([]*Account, 0, len(available))
+				for _, item := range available {
+					accs = append(accs, item.account)
+				}
+				reordered := make([]accountWithLoad, 0, len(available))
+				for _, idx := range s.scoringService.Order(ctx, cfg.FallbackSelectionMode, accs, loadMap) {
+					reordered = append(reordered, available[idx])
+				}
+				available = reordered
+			}
+
+			// 成本优先路由：以默认顺序的首选账号为基线，按有效成本稳定重排（低成本账号饱和后再溢出）
+			var router *costRouter
+			var baseline *Account
+			if costRoutingGroup(ctx, groupID) != nil {
+				accs := make([]*Account, 0, len(available))
+				for _, item := range available {
+					accs = append(accs, item.account)
+				}
+				if router = s.newCostRouter(ctx, groupID, accs, requestedModel); router != nil {
+					baseline = accs[0]
+					reordered := make([]accountWithLoad, 0, len(available))
+					for _, idx := range router.order(accs) {
+						reordered = append(reordered, available[idx])
+					}
+					available = reordered
+				}
+			}
+
+			trace.rank(len(available), func(i int) *Account { return available[i].account })
 			for _, item := range available {
 				result, err := s.tryAcquireAccountSlot(ctx, item.account.ID, item.account.Concurrency)
 				if err == nil && result.Acquired {
@@ -964,12 +1062,13 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 						continue
 					}
 					if sessionHash != "" && s.cache != nil {
-						_ = s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), sessionHash, item.account.ID, stickySessionTTL)
+						_ = s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), sessionHash, item.account.ID, groupStickySessionTTL(ctx, stickySessionTTL))
 					}
 					return &AccountSelectionResult{
 						Account:     item.account,
 						Acquired:    true,
 						ReleaseFunc: result.ReleaseFunc,
+						CostRouting: router.decision(baseline, item.account),
 					}, nil
 				}
 			}
@@ -977,7 +1076,9 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 	}
 
 	// ============ Layer 3: 兜底排队 ============
-	s.sortCandidatesForFallback(candidates, preferOAuth, cfg.FallbackSelectionMode)
+	trace.enter(SchedulerLayerFallbackWait)
+	candidates = s.orderCandidatesForFallback(ctx, candidates, preferOAuth, cfg.FallbackSelectionMode, loadMap)
+	trace.rank(len(candidates), func(i int) *Account { return candidates[i] })
 	for _, acc := range candidates {
 		// 会话数量限制检查（等待计划也需要占用会话配额）
 		if !s.checkAndRegisterSession(ctx, acc, sessionHash) {
@@ -999,6 +1100,7 @@ func (s *GatewayService) SelectAccountWithLoadAwareness(ctx context.Context, gro
 func (s *GatewayService) tryAcquireByLegacyOrder(ctx context.Context, candidates []*Account, groupID *int64, sessionHash string, preferOAuth bool) (*AccountSelectionResult, bool) {
 	ordered := append([]*Account(nil), candidates...)
 	sortAccountsByPriorityAndLastUsed(ordered, preferOAuth)
+	selectionTraceFrom(ctx).rank(len(ordered), func(i int) *Account { return ordered[i] })
 
 	for _, acc := range ordered {
 		result, err := s.tryAcquireAccountSlot(ctx, acc.ID, acc.Concurrency)
@@ -1009,7 +1111,7 @@ func (s *GatewayService) tryAcquireByLegacyOrder(ctx context.Context, candidates
 				continue
 			}
 			if sessionHash != "" && s.cache != nil {
-				_ = s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), sessionHash, acc.ID, stickySessionTTL)
+				_ = s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), sessionHash, acc.ID, groupStickySessionTTL(ctx, stickySessionTTL))
 			}
 			return &AccountSelectionResult{
 				Account:     acc,
@@ -1083,6 +1185,19 @@ func (s *GatewayService) routingAccountIDsForRequest(ctx context.Context, groupI
 		return nil
 	}
 	ids := group.GetRoutingAccountIDs(requestedModel)
+	if group.RoutingRequiresAccounts(requestedModel) {
+		// 标签选择器规则需结合分组调度快照解析
+		forcePlatform, hasForcePlatform := ctx.Value(ctxkey.ForcePlatform).(string)
+		if hasForcePlatform && forcePlatform == "" {
+			hasForcePlatform = false
+		}
+		accounts, _, err := s.listSchedulableAccounts(ctx, groupID, platform, hasForcePlatform)
+		if err != nil {
+			log.Printf("[ModelRouting] resolve tag selector failed: group_id=%d model=%s err=%v", group.ID, requestedModel, err)
+		} else {
+			ids = group.ResolveRoutingAccountIDs(requestedModel, accounts)
+		}
+	}
 	if s.debugModelRoutingEnabled() {
 		log.Printf("[ModelRoutingDebug] routing lookup: group_id=%d model=%s enabled=%v rules=%d matched_ids=%v",
 			group.ID, requestedModel, group.ModelRoutingEnabled, len(group.ModelRouting), ids)
@@ -1182,7 +1297,7 @@ func (s *GatewayService) listSchedulableAccounts(ctx context.Context, groupID *i
 	}
 	useMixed := (platform == PlatformAnthropic || platform == PlatformGemini) && !hasForcePlatform
 	if useMixed {
-		platforms := []string{platform, PlatformAntigravity}
+		platforms := MixedSchedulingPlatforms(platform)
 		var accounts []Account
 		var err error
 		if groupID != nil {
@@ -1199,7 +1314,7 @@ func (s *GatewayService) listSchedulableAccounts(ctx context.Context, groupID *i
 		}
 		filtered := make([]Account, 0, len(accounts))
 		for _, acc := range accounts {
-			if acc.Platform == PlatformAntigravity && !acc.IsMixedSchedulingEnabled() {
+			if !acc.IsAllowedInMixedScheduling(platform) {
 				continue
 			}
 			filtered = append(filtered, acc)
@@ -1259,16 +1374,55 @@ func (s *GatewayService) isAccountAllowedForPlatform(account *Account, platform
 		return false
 	}
 	if useMixed {
-		if account.Platform == platform {
-			return true
-		}
-		return account.Platform == PlatformAntigravity && account.IsMixedSchedulingEnabled()
+		return account.IsAllowedInMixedScheduling(platform)
 	}
 	return account.Platform == platform
 }
 
 // isAccountInGroup checks if the account belongs to the specified group.
 // Returns true if groupID is nil (no group restriction) or account belongs to the group.
+// accountFilter 负载感知调度的逐账号过滤条件（模型路由候选、负载感知候选与调度说明共用）
+// exclusion 只检查 RPM/TPM 预算、熔断与显式排除，rejection 依次检查全部条件；均返回拒绝原因，空字符串表示通过
+func (s *GatewayService) accountFilter(ctx context.Context, accounts []Account, excludedIDs map[int64]struct{}, platform string, useMixed bool, requestedModel string) (exclusion func(int64) string, rejection func(*Account) string) {
+	rateSaturated := s.rateBudgetService.SaturatedAccounts(ctx, accounts)
+	circuitBlocked := s.circuitBreaker.BlockedAccounts(ctx, accounts)
+	exclusion = func(accountID int64) string {
+		if _, saturated := rateSaturated[accountID]; saturated {
+			return SchedulerReasonRateBudget
+		}
+		if _, blocked := circuitBlocked[accountID]; blocked {
+			return SchedulerReasonCircuitOpen
+		}
+		if _, excluded := excludedIDs[accountID]; excluded {
+			return SchedulerReasonExcluded
+		}
+		return ""
+	}
+	rejection = func(acc *Account) string {
+		if reason := exclusion(acc.ID); reason != "" {
+			return reason
+		}
+		switch {
+		// Scheduler snapshots can be temporarily stale (bucket rebuild is throttled);
+		// re-check schedulability here so recently rate-limited/overloaded accounts
+		// are not selected again before the bucket is rebuilt.
+		case !acc.IsSchedulable():
+			return SchedulerReasonUnschedulable
+		case !s.isAccountAllowedForPlatform(acc, platform, useMixed):
+			return SchedulerReasonPlatform
+		case !acc.IsSchedulableForModel(requestedModel):
+			return SchedulerReasonModelRateLimited
+		case requestedModel != "" && !s.isModelSupportedByAccount(acc, requestedModel):
+			return SchedulerReasonModelUnsupported
+		case !s.isAccountSchedulableForWindowCost(ctx, acc, false):
+			// 窗口费用检查（非粘性会话路径）
+			return SchedulerReasonWindowCost
+		}
+		return ""
+	}
+	return exclusion, rejection
+}
+
 func (s *GatewayService) isAccountInGroup(account *Account, groupID *int64) bool {
 	if groupID == nil {
 		return true // 无分组限制
@@ -1281,7 +1435,8 @@ func (s *GatewayService) isAccountInGroup(account *Account, groupID *int64) bool
 			return true
 		}
 	}
-	return false
+	// 通过分组标签选择器自动加入的分组
+	return containsInt64(account.TagGroupIDs, *groupID)
 }
 
 func (s *GatewayService) tryAcquireAccountSlot(ctx context.Context, accountID int64, maxConcurrency int) (*AcquireResult, error) {
@@ -1305,36 +1460,12 @@ func (s *GatewayService) isAccountSchedulableForWindowCost(ctx context.Context,
 		return true // 未启用窗口费用限制
 	}
 
-	// 尝试从缓存获取窗口费用
-	var currentCost float64
-	if s.sessionLimitCache != nil {
-		if cost, hit, err := s.sessionLimitCache.GetWindowCost(ctx, account.ID); err == nil && hit {
-			currentCost = cost
-			goto checkSchedulability
-		}
-	}
-
-	// 缓存未命中，从数据库查询
-	{
-		// 使用统一的窗口开始时间计算逻辑（考虑窗口过期情况）
-		startTime := account.GetCurrentWindowStartTime()
-
-		stats, err := s.usageLogRepo.GetAccountWindowStats(ctx, account.ID, startTime)
-		if err != nil {
-			// 失败开放：查询失败时允许调度
-			return true
-		}
-
-		// 使用标准费用（不含账号倍率）
-		currentCost = stats.StandardCost
-
-		// 设置缓存（忽略错误）
-		if s.sessionLimitCache != nil {
-			_ = s.sessionLimitCache.SetWindowCost(ctx, account.ID, currentCost)
-		}
+	currentCost, ok := s.getAccountWindowCost(ctx, account)
+	if !ok {
+		// 失败开放：查询失败时允许调度
+		return true
 	}
 
-checkSchedulability:
 	schedulability := account.CheckWindowCostSchedulability(currentCost)
 
 	switch schedulability {
@@ -1348,6 +1479,49 @@ checkSchedulability:
 	return true
 }
 
+// getAccountWindowCost 获取账号当前窗口的标准费用（不含账号倍率），优先读缓存
+// 查询失败时返回 ok=false
+func (s *GatewayService) getAccountWindowCost(ctx context.Context, account *Account) (float64, bool) {
+	if s.sessionLimitCache != nil {
+		if cost, hit, err := s.sessionLimitCache.GetWindowCost(ctx, account.ID); err == nil && hit {
+			return cost, true
+		}
+	}
+
+	// 缓存未命中，从数据库查询（使用统一的窗口开始时间计算逻辑，考虑窗口过期情况）
+	startTime := account.GetCurrentWindowStartTime()
+	stats, err := s.usageLogRepo.GetAccountWindowStats(ctx, account.ID, startTime)
+	if err != nil {
+		return 0, false
+	}
+	currentCost := stats.StandardCost
+
+	// 设置缓存（忽略错误）
+	if s.sessionLimitCache != nil {
+		_ = s.sessionLimitCache.SetWindowCost(ctx, account.ID, currentCost)
+	}
+	return currentCost, true
+}
+
+// newCostRouter 为开启成本优先路由的分组构建本次调度的账号成本表，未开启时返回 nil
+// 配置了窗口费用上限且当前窗口额度未用完的 Anthropic OAuth/SetupToken 账号视为预付费额度可用
+func (s *GatewayService) newCostRouter(ctx context.Context, groupID *int64, accounts []*Account, requestedModel string) *costRouter {
+	if costRoutingGroup(ctx, groupID) == nil || len(accounts) == 0 {
+		return nil
+	}
+	return newCostRouter(accounts, func(acc *Account) float64 {
+		prepaid := false
+		if acc.IsAnthropicOAuthOrSetupToken() {
+			if limit := acc.GetWindowCostLimit(); limit > 0 {
+				if cost, ok := s.getAccountWindowCost(ctx, acc); ok && cost < limit {
+					prepaid = true
+				}
+			}
+		}
+		return accountCostFactor(s.billingService, acc, requestedModel, prepaid)
+	})
+}
+
 // checkAndRegisterSession 检查并注册会话，用于会话数量限制
 // 仅适用于 Anthropic OAuth/SetupToken 账号
 // sessionID: 会话标识符（使用粘性会话的 hash）
@@ -1406,6 +1580,19 @@ func sortAccountsByPriorityAndLastUsed(accounts []*Account, preferOAuth bool) {
 	})
 }
 
+// orderCandidatesForFallback 兜底层排序：评分类策略按评分排序，否则沿用 sortCandidatesForFallback
+func (s *GatewayService) orderCandidatesForFallback(ctx context.Context, candidates []*Account, preferOAuth bool, mode string, loadMap map[int64]*AccountLoadInfo) []*Account {
+	if !s.scoringService.Enabled(mode) {
+		s.sortCandidatesForFallback(candidates, preferOAuth, mode)
+		return candidates
+	}
+	ordered := make([]*Account, 0, len(candidates))
+	for _, idx := range s.scoringService.Order(ctx, mode, candidates, loadMap) {
+		ordered = append(ordered, candidates[idx])
+	}
+	return ordered
+}
+
 // sortCandidatesForFallback 根据配置选择排序策略
 // mode: "last_used"(按最后使用时间) 或 "random"(随机)
 func (s *GatewayService) sortCandidatesForFallback(accounts []*Account, preferOAuth bool, mode string) {
@@ -1485,7 +1672,7 @@ func (s *GatewayService) selectAccountForModelWithPlatform(ctx context.Context,
 							_ = s.cache.DeleteSessionAccountID(ctx, derefGroupID(groupID), sessionHash)
 						}
 						if !clearSticky && s.isAccountInGroup(account, groupID) && account.Platform == platform && account.IsSchedulableForModel(requestedModel) && (requestedModel == "" || s.isModelSupportedByAccount(account, requestedModel)) {
-							if err := s.cache.RefreshSessionTTL(ctx, derefGroupID(groupID), sessionHash, stickySessionTTL); err != nil {
+							if err := s.cache.RefreshSessionTTL(ctx, derefGroupID(groupID), sessionHash, groupStickySessionTTL(ctx, stickySessionTTL)); err != nil {
 								log.Printf("refresh session ttl failed: session=%s err=%v", sessionHash, err)
 							}
 							if s.debugModelRoutingEnabled() {
@@ -1563,7 +1750,7 @@ func (s *GatewayService) selectAccountForModelWithPlatform(ctx context.Context,
 
 		if selected != nil {
 			if sessionHash != "" && s.cache != nil {
-				if err := s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), sessionHash, selected.ID, stickySessionTTL); err != nil {
+				if err := s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), sessionHash, selected.ID, groupStickySessionTTL(ctx, stickySessionTTL)); err != nil {
 					log.Printf("set session account failed: session=%s account_id=%d err=%v", sessionHash, selected.ID, err)
 				}
 			}
@@ -1588,7 +1775,7 @@ func (s *GatewayService) selectAccountForModelWithPlatform(ctx context.Context,
 						_ = s.cache.DeleteSessionAccountID(ctx, derefGroupID(groupID), sessionHash)
 					}
 					if !clearSticky && s.isAccountInGroup(account, groupID) && account.Platform == platform && account.IsSchedulableForModel(requestedModel) && (requestedModel == "" || s.isModelSupportedByAccount(account, requestedModel)) {
-						if err := s.cache.RefreshSessionTTL(ctx, derefGroupID(groupID), sessionHash, stickySessionTTL); err != nil {
+						if err := s.cache.RefreshSessionTTL(ctx, derefGroupID(groupID), sessionHash, groupStickySessionTTL(ctx, stickySessionTTL)); err != nil {
 							log.Printf("refresh session ttl failed: session=%s err=%v", sessionHash, err)
 						}
 						return account, nil
@@ -1662,7 +1849,7 @@ func (s *GatewayService) selectAccountForModelWithPlatform(ctx context.Context,
 
 	// 4. 建立粘性绑定
 	if sessionHash != "" && s.cache != nil {
-		if err := s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), sessionHash, selected.ID, stickySessionTTL); err != nil {
+		if err := s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), sessionHash, selected.ID, groupStickySessionTTL(ctx, stickySessionTTL)); err != nil {
 			log.Printf("set session account failed: session=%s account_id=%d err=%v", sessionHash, selected.ID, err)
 		}
 	}
@@ -1691,15 +1878,15 @@ func (s *GatewayService) selectAccountWithMixedScheduling(ctx context.Context, g
 			if err == nil && accountID > 0 && containsInt64(routingAccountIDs, accountID) {
 				if _, excluded := excludedIDs[accountID]; !excluded {
 					account, err := s.getSchedulableAccount(ctx, accountID)
-					// 检查账号分组归属和有效性：原生平台直接匹配，antigravity 需要启用混合调度
+					// 检查账号分组归属和有效性：原生平台直接匹配，antigravity/openai 需要启用混合调度
 					if err == nil {
 						clearSticky := shouldClearStickySession(account)
 						if clearSticky {
 							_ = s.cache.DeleteSessionAccountID(ctx, derefGroupID(groupID), sessionHash)
 						}
 						if !clearSticky && s.isAccountInGroup(account, groupID) && account.IsSchedulableForModel(requestedModel) && (requestedModel == "" || s.isModelSupportedByAccount(account, requestedModel)) {
-							if account.Platform == nativePlatform || (account.Platform == PlatformAntigravity && account.IsMixedSchedulingEnabled()) {
-								if err := s.cache.RefreshSessionTTL(ctx, derefGroupID(groupID), sessionHash, stickySessionTTL); err != nil {
+							if account.IsAllowedInMixedScheduling(nativePlatform) {
+								if err := s.cache.RefreshSessionTTL(ctx, derefGroupID(groupID), sessionHash, groupStickySessionTTL(ctx, stickySessionTTL)); err != nil {
 									log.Printf("refresh session ttl failed: session=%s err=%v", sessionHash, err)
 								}
 								if s.debugModelRoutingEnabled() {
@@ -1742,8 +1929,8 @@ func (s *GatewayService) selectAccountWithMixedScheduling(ctx context.Context, g
 			if !acc.IsSchedulable() {
 				continue
 			}
-			// 过滤：原生平台直接通过，antigravity 需要启用混合调度
-			if acc.Platform == PlatformAntigravity && !acc.IsMixedSchedulingEnabled() {
+			// 过滤：原生平台直接通过，antigravity/openai 需要启用混合调度
+			if !acc.IsAllowedInMixedScheduling(nativePlatform) {
 				continue
 			}
 			if !acc.IsSchedulableForModel(requestedModel) {
@@ -1778,7 +1965,7 @@ func (s *GatewayService) selectAccountWithMixedScheduling(ctx context.Context, g
 
 		if selected != nil {
 			if sessionHash != "" && s.cache != nil {
-				if err := s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), sessionHash, selected.ID, stickySessionTTL); err != nil {
+				if err := s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), sessionHash, selected.ID, groupStickySessionTTL(ctx, stickySessionTTL)); err != nil {
 					log.Printf("set session account failed: session=%s account_id=%d err=%v", sessionHash, selected.ID, err)
 				}
 			}
@@ -1796,15 +1983,15 @@ func (s *GatewayService) selectAccountWithMixedScheduling(ctx context.Context, g
 		if err == nil && accountID > 0 {
 			if _, excluded := excludedIDs[accountID]; !excluded {
 				account, err := s.getSchedulableAccount(ctx, accountID)
-				// 检查账号分组归属和有效性：原生平台直接匹配，antigravity 需要启用混合调度
+				// 检查账号分组归属和有效性：原生平台直接匹配，antigravity/openai 需要启用混合调度
 				if err == nil {
 					clearSticky := shouldClearStickySession(account)
 					if clearSticky {
 						_ = s.cache.DeleteSessionAccountID(ctx, derefGroupID(groupID), sessionHash)
 					}
 					if !clearSticky && s.isAccountInGroup(account, groupID) && account.IsSchedulableForModel(requestedModel) && (requestedModel == "" || s.isModelSupportedByAccount(account, requestedModel)) {
-						if account.Platform == nativePlatform || (account.Platform == PlatformAntigravity && account.IsMixedSchedulingEnabled()) {
-							if err := s.cache.RefreshSessionTTL(ctx, derefGroupID(groupID), sessionHash, stickySessionTTL); err != nil {
+						if account.IsAllowedInMixedScheduling(nativePlatform) {
+							if err := s.cache.RefreshSessionTTL(ctx, derefGroupID(groupID), sessionHash, groupStickySessionTTL(ctx, stickySessionTTL)); err != nil {
 								log.Printf("refresh session ttl failed: session=%s err=%v", sessionHash, err)
 							}
 							return account, nil
@@ -1836,8 +2023,8 @@ func (s *GatewayService) selectAccountWithMixedScheduling(ctx context.Context, g
 		if !acc.IsSchedulable() {
 			continue
 		}
-		// 过滤：原生平台直接通过，antigravity 需要启用混合调度
-		if acc.Platform == PlatformAntigravity && !acc.IsMixedSchedulingEnabled() {
+		// 过滤：原生平台直接通过，antigravity/openai 需要启用混合调度
+		if !acc.IsAllowedInMixedScheduling(nativePlatform) {
 			continue
 		}
 		if !acc.IsSchedulableForModel(requestedModel) {
@@ -1879,7 +2066,7 @@ func (s *GatewayService) selectAccountWithMixedScheduling(ctx context.Context, g
 
 	// 4. 建立粘性绑定
 	if sessionHash != "" && s.cache != nil {
-		if err := s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), sessionHash, selected.ID, stickySessionTTL); err != nil {
+		if err := s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), sessionHash, selected.ID, groupStickySessionTTL(ctx, stickySessionTTL)); err != nil {
 			log.Printf("set session account failed: session=%s account_id=%d err=%v", sessionHash, selected.ID, err)
 		}
 	}
@@ -2333,6 +2520,7 @@ func (s *GatewayService) Forward(ctx context.Context, c *gin.Context, account *A
 		// 发送请求
 		resp, err = s.httpUpstream.DoWithTLS(upstreamReq, proxyURL, account.ID, account.Concurrency, account.IsTLSFingerprintEnabled())
 		if err != nil {
+			s.rateLimitService.HandleUpstreamRequestError(ctx, account, err)
 			if resp != nil && resp.Body != nil {
 				_ = resp.Body.Close()
 			}
@@ -3454,9 +3642,12 @@ type RecordUsageInput struct {
 	APIKey       *APIKey
 	User         *User
 	Account      *Account
-	Subscription *UserSubscription // 可选：订阅信息
-	UserAgent    string            // 请求的 User-Agent
-	IPAddress    string            // 请求的客户端 IP 地址
+	Subscription *UserSubscription    // 可选：订阅信息
+	UserAgent    string               // 请求的 User-Agent
+	IPAddress    string               // 请求的客户端 IP 地址
+	SessionHash  string               // 粘性会话哈希（用于统计 prompt cache 命中率）
+	CostRouting  *CostRoutingDecision // 成本优先路由决策（用于统计策略转移的花费）
+	BalanceHold  *BalanceHold         // 可选：请求开始时的余额预授权冻结，扣费时按实际费用结算
 }
 
 // RecordUsage 记录使用量并扣费（或更新订阅用量）
@@ -3467,6 +3658,14 @@ func (s *GatewayService) RecordUsage(ctx context.Context, input *RecordUsageInpu
 	account := input.Account
 	subscription := input.Subscription
 
+	// 余额预授权冻结：扣费时按实际费用结算，未扣费（简易模式/不计费/零费用）时返回前释放
+	holdSettled := false
+	defer func() {
+		if !holdSettled {
+			s.billingCacheService.ReleaseBalanceHold(input.BalanceHold)
+		}
+	}()
+
 	// 获取费率倍数
 	multiplier := s.cfg.Default.RateMultiplier
 	if apiKey.GroupID != nil && apiKey.Group != nil {
@@ -3486,7 +3685,10 @@ func (s *GatewayService) RecordUsage(ctx context.Context, input *RecordUsageInpu
 				Price4K: apiKey.Group.ImagePrice4K,
 			}
 		}
-		cost = s.billingService.CalculateImageCost(result.Model, result.ImageSize, result.ImageCount, groupConfig, multiplier)
+		cost = s.billingService.CalculateImageCostForGroup(result.Model, apiKey.GroupID, result.ImageSize, result.ImageCount, groupConfig, multiplier)
+	} else if result.Embedding {
+		// Embedding 计费（仅输入 token）
+		cost = s.billingService.CalculateEmbeddingCostForGroup(result.Model, apiKey.GroupID, result.Usage.InputTokens, multiplier)
 	} else {
 		// Token 计费
 		tokens := UsageTokens{
@@ -3496,7 +3698,7 @@ func (s *GatewayService) RecordUsage(ctx context.Context, input *RecordUsageInpu
 			CacheReadTokens:     result.Usage.CacheReadInputTokens,
 		}
 		var err error
-		cost, err = s.billingService.CalculateCost(result.Model, tokens, multiplier)
+		cost, err = s.billingService.CalculateCostForGroup(result.Model, apiKey.GroupID, tokens, multiplier)
 		if err != nil {
 			log.Printf("Calculate cost failed: %v", err)
 			cost = &CostBreakdown{ActualCost: 0}
@@ -3541,6 +3743,7 @@ func (s *GatewayService) RecordUsage(ctx context.Context, input *RecordUsageInpu
 		FirstTokenMs:          result.FirstTokenMs,
 		ImageCount:            result.ImageCount,
 		ImageSize:             imageSize,
+		PricingOverrideID:     cost.PricingOverrideID,
 		CreatedAt:             time.Now(),
 	}
 
@@ -3554,6 +3757,16 @@ func (s *GatewayService) RecordUsage(ctx context.Context, input *RecordUsageInpu
 		usageLog.IPAddress = &input.IPAddress
 	}
 
+	// 添加粘性会话哈希
+	usageLog.SessionHash = usageSessionHash(input.SessionHash)
+	usageLog.applyCostRouting(input.CostRouting)
+
+	// 计入账号 TPM 预算
+	s.rateBudgetService.RecordTokens(ctx, account, usageLog.TotalTokens())
+	// 成功请求计入账号延迟/错误率评分与熔断统计
+	s.scoringService.RecordSuccess(ctx, account.ID, result.FirstTokenMs)
+	s.rateLimitService.HandleUpstreamSuccess(ctx, account)
+
 	// 添加分组和订阅关联
 	if apiKey.GroupID != nil {
 		usageLog.GroupID = apiKey.GroupID
@@ -3588,14 +3801,24 @@ func (s *GatewayService) RecordUsage(ctx context.Context, input *RecordUsageInpu
 	} else {
 		// 余额模式：扣除用户余额（使用 ActualCost 考虑倍率后的费用）
 		if shouldBill && cost.ActualCost > 0 {
-			if err := s.userRepo.DeductBalance(ctx, user.ID, cost.ActualCost); err != nil {
+			if err := s.userRepo.ApplyBalanceEntry(ctx, usageBalanceEntry(user.ID, cost.ActualCost, usageLog, inserted)); err != nil {
 				log.Printf("Deduct balance failed: %v", err)
 			}
-			// 异步更新余额缓存
-			s.billingCacheService.QueueDeductBalance(user.ID, cost.ActualCost)
+			// 更新余额缓存：有预授权冻结时原子结算冻结，否则异步扣减
+			if input.BalanceHold != nil {
+				s.billingCacheService.SettleBalanceHold(input.BalanceHold, cost.ActualCost)
+				holdSettled = true
+			} else {
+				s.billingCacheService.QueueDeductBalance(user.ID, cost.ActualCost)
+			}
 		}
 	}
 
+	// API Key 消费限额计数（按实际扣费金额累计；请求数已在准入时计入）
+	if shouldBill && apiKey.Limits.HasQuota() {
+		s.billingCacheService.QueueAPIKeyUsage(apiKey.ID, cost.ActualCost)
+	}
+
 	// Schedule batch update for account last_used_at
 	s.deferredService.ScheduleLastUsedUpdate(account.ID)
 
@@ -3613,12 +3836,21 @@ func (s *GatewayService) ForwardCountTokens(ctx context.Context, c *gin.Context,
 	body := parsed.Body
 	reqModel := parsed.Model
 
-	// Antigravity 账户不支持 count_tokens 转发，直接返回空值
+	// Antigravity 账户不支持 count_tokens 转发：启用本地估算时返回估算值，否则返回空值
 	if account.Platform == PlatformAntigravity {
+		if s.CountTokensFallback(c, parsed) {
+			return nil
+		}
 		c.JSON(http.StatusOK, gin.H{"input_tokens": 0})
 		return nil
 	}
 
+	// OpenAI（混合调度）账户没有 Anthropic count_tokens 接口，不论 count_tokens 模式均返回本地估算
+	if account.Platform == PlatformOpenAI {
+		c.JSON(http.StatusOK, gin.H{"input_tokens": s.tokenCountEstimator.EstimateClaudeRequest(parsed.Model, body)})
+		return nil
+	}
+
 	// 应用模型映射（仅对 apikey 类型账号）
 	if account.Type == AccountTypeAPIKey {
 		if reqModel != "" {
@@ -3634,6 +3866,10 @@ func (s *GatewayService) ForwardCountTokens(ctx context.Context, c *gin.Context,
 	// 获取凭证
 	token, tokenType, err := s.GetAccessToken(ctx, account)
 	if err != nil {
+		if s.CountTokensFallback(c, parsed) {
+			log.Printf("count_tokens fallback to local estimate (account=%d): get access token failed: %v", account.ID, err)
+			return nil
+		}
 		s.countTokensError(c, http.StatusBadGateway, "upstream_error", "Failed to get access token")
 		return err
 	}
@@ -3655,6 +3891,10 @@ func (s *GatewayService) ForwardCountTokens(ctx context.Context, c *gin.Context,
 	resp, err := s.httpUpstream.DoWithTLS(upstreamReq, proxyURL, account.ID, account.Concurrency, account.IsTLSFingerprintEnabled())
 	if err != nil {
 		setOpsUpstreamError(c, 0, sanitizeUpstreamErrorMessage(err.Error()), "")
+		if s.CountTokensFallback(c, parsed) {
+			log.Printf("count_tokens fallback to local estimate (account=%d): upstream request failed: %v", account.ID, err)
+			return nil
+		}
 		s.countTokensError(c, http.StatusBadGateway, "upstream_error", "Request failed")
 		return fmt.Errorf("upstream request failed: %w", err)
 	}
@@ -3716,6 +3956,12 @@ func (s *GatewayService) ForwardCountTokens(ctx context.Context, c *gin.Context,
 			)
 		}
 
+		// 鉴权/限流/服务端错误时回退本地估算（请求本身无效的 4xx 仍返回错误）
+		if isCountTokensFallbackStatus(resp.StatusCode) && s.CountTokensFallback(c, parsed) {
+			log.Printf("count_tokens fallback to local estimate (account=%d): upstream status %d", account.ID, resp.StatusCode)
+			return nil
+		}
+
 		// 返回简化的错误响应
 		errMsg := "Upstream request failed"
 		switch resp.StatusCode {
@@ -3731,11 +3977,38 @@ func (s *GatewayService) ForwardCountTokens(ctx context.Context, c *gin.Context,
 		return fmt.Errorf("upstream error: %d message=%s", resp.StatusCode, upstreamMsg)
 	}
 
+	// 按采样率记录本地估算偏差
+	s.tokenCountEstimator.ObserveUpstream(parsed.Model, int(gjson.GetBytes(respBody, "input_tokens").Int()), func() int {
+		return s.tokenCountEstimator.EstimateClaudeRequest(parsed.Model, parsed.Body)
+	})
+
 	// 透传成功响应
 	c.Data(resp.StatusCode, "application/json", respBody)
 	return nil
 }
 
+// CountTokensLocalOnly 是否配置为始终本地估算 count_tokens
+func (s *GatewayService) CountTokensLocalOnly() bool {
+	return s.tokenCountEstimator.LocalOnly()
+}
+
+// CountTokensFallback 启用本地估算（fallback/local 模式）时写入估算结果并返回 true
+func (s *GatewayService) CountTokensFallback(c *gin.Context, parsed *ParsedRequest) bool {
+	if !s.tokenCountEstimator.FallbackEnabled() || parsed == nil {
+		return false
+	}
+	c.JSON(http.StatusOK, gin.H{"input_tokens": s.tokenCountEstimator.EstimateClaudeRequest(parsed.Model, parsed.Body)})
+	return true
+}
+
+func isCountTokensFallbackStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusTooManyRequests:
+		return true
+	}
+	return statusCode >= 500
+}
+
 // buildCountTokensRequest 构建 count_tokens 上游请求
 func (s *GatewayService) buildCountTokensRequest(ctx context.Context, c *gin.Context, account *Account, body []byte, token, tokenType, modelID string) (*http.Request, error) {
 	// 确定目标 URL
@@ -3847,58 +4120,3 @@ func (s *GatewayService) validateUpstreamBaseURL(raw string) (string, error) {
 	}
 	return normalized, nil
 }
-
-// GetAvailableModels returns the list of models available for a group
-// It aggregates model_mapping keys from all schedulable accounts in the group
-func (s *GatewayService) GetAvailableModels(ctx context.Context, groupID *int64, platform string) []string {
-	var accounts []Account
-	var err error
-
-	if groupID != nil {
-		accounts, err = s.accountRepo.ListSchedulableByGroupID(ctx, *groupID)
-	} else {
-		accounts, err = s.accountRepo.ListSchedulable(ctx)
-	}
-
-	if err != nil || len(accounts) == 0 {
-		return nil
-	}
-
-	// Filter by platform if specified
-	if platform != "" {
-		filtered := make([]Account, 0)
-		for _, acc := range accounts {
-			if acc.Platform == platform {
-				filtered = append(filtered, acc)
-			}
-		}
-		accounts = filtered
-	}
-
-	// Collect unique models from all accounts
-	modelSet := make(map[string]struct{})
-	hasAnyMapping := false
-
-	for _, acc := range accounts {
-		mapping := acc.GetModelMapping()
-		if len(mapping) > 0 {
-			hasAnyMapping = true
-			for model := range mapping {
-				modelSet[model] = struct{}{}
-			}
-		}
-	}
-
-	// If no account has model_mapping, return nil (use default)
-	if !hasAnyMapping {
-		return nil
-	}
-
-	// Convert to slice
-	models := make([]string, 0, len(modelSet))
-	for model := range modelSet {
-		models = append(models, model)
-	}
-
-	return models
-}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/geminicli"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/openai"
+	"github.com/Wei-Shaw/sub2api/internal/util/responseheaders"
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+)
+
+// IsGeminiEmbeddingAction 判断 Gemini 原生动作是否为 embedding（embedContent / batchEmbedContents）
+func IsGeminiEmbeddingAction(action string) bool {
+	return action == "embedContent" || action == "batchEmbedContents"
+}
+
+// estimateGeminiEmbedTokens 估算 embedContent / batchEmbedContents 请求的输入 token
+// Gemini embedding 接口不返回 usageMetadata，只能按文本长度估算。
+func estimateGeminiEmbedTokens(reqBody []byte) int {
+	total := 0
+	countParts := func(content gjson.Result) {
+		for _, part := range content.Get("parts").Array() {
+			total += estimateTokensForText(part.Get("text").String())
+		}
+	}
+	countParts(gjson.GetBytes(reqBody, "content"))
+	for _, req := range gjson.GetBytes(reqBody, "requests").Array() {
+		countParts(req.Get("content"))
+	}
+	return total
+}
+
+// ForwardEmbeddings 将 OpenAI Embeddings 请求转换为 Gemini batchEmbedContents 转发到 Gemini API Key 账号，
+// 并将响应转换回 OpenAI 格式。用于 gemini 分组调用 /v1/embeddings 的场景。
+func (s *GeminiMessagesCompatService) ForwardEmbeddings(ctx context.Context, c *gin.Context, account *Account, body []byte) (*ForwardResult, error) {
+	startTime := time.Now()
+
+	if !account.SupportsEmbeddings() || account.Platform != PlatformGemini {
+		return nil, fmt.Errorf("account %d does not support embeddings", account.ID)
+	}
+
+	var req openai.EmbeddingRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, s.writeOpenAIError(c, http.StatusBadRequest, "invalid_request_error", "Failed to parse request body")
+	}
+	originalModel := req.Model
+	mappedModel := account.GetMappedModel(originalModel)
+	geminiBody, err := openai.EmbeddingToGeminiBatchRequest(&req, mappedModel)
+	if err != nil {
+		return nil, s.writeOpenAIError(c, http.StatusBadRequest, "invalid_request_error", err.Error())
+	}
+
+	apiKey := strings.TrimSpace(account.GetCredential("api_key"))
+	if apiKey == "" {
+		return nil, s.writeOpenAIError(c, http.StatusBadGateway, "upstream_error", "gemini api_key not configured")
+	}
+	baseURL := strings.TrimSpace(account.GetCredential("base_url"))
+	if baseURL == "" {
+		baseURL = geminicli.AIStudioBaseURL
+	}
+	normalizedBaseURL, err := s.validateUpstreamBaseURL(baseURL)
+	if err != nil {
+		return nil, s.writeOpenAIError(c, http.StatusBadGateway, "upstream_error", err.Error())
+	}
+	fullURL := fmt.Sprintf("%s/v1beta/models/%s:batchEmbedContents", strings.TrimRight(normalizedBaseURL, "/"), strings.TrimPrefix(mappedModel, "models/"))
+
+	upstreamReq, err := http.NewRequestWithContext(ctx, http.MethodPost, fullURL, bytes.NewReader(geminiBody))
+	if err != nil {
+		return nil, err
+	}
+	upstreamReq.Header.Set("Content-Type", "application/json")
+	upstreamReq.Header.Set("x-goog-api-key", apiKey)
+
+	proxyURL := ""
+	if account.ProxyID != nil && account.Proxy != nil {
+		proxyURL = account.Proxy.URL()
+	}
+
+	// Capture upstream request body for ops retry of this attempt.
+	c.Set(OpsUpstreamRequestBodyKey, string(geminiBody))
+
+	resp, err := s.httpUpstream.Do(upstreamReq, proxyURL, account.ID, account.Concurrency)
+	if err != nil {
+		safeErr := sanitizeUpstreamErrorMessage(err.Error())
+		setOpsUpstreamError(c, 0, safeErr, "")
+		appendOpsUpstreamError(c, OpsUpstreamErrorEvent{
+			Platform:           account.Platform,
+			AccountID:          account.ID,
+			AccountName:        account.Name,
+			UpstreamStatusCode: 0,
+			Kind:               "request_error",
+			Message:            safeErr,
+		})
+		return nil, s.writeOpenAIError(c, http.StatusBadGateway, "upstream_error", "Upstream request failed")
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	requestID := resp.Header.Get("x-request-id")
+	if requestID == "" {
+		requestID = resp.Header.Get("x-goog-request-id")
+	}
+
+	if resp.StatusCode >= 400 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 2<<20))
+		s.handleGeminiUpstreamError(ctx, account, resp.StatusCode, resp.Header, respBody)
+
+		upstreamMsg := strings.TrimSpace(extractUpstreamErrorMessage(respBody))
+		upstreamMsg = sanitizeUpstreamErrorMessage(upstreamMsg)
+		upstreamDetail := ""
+		if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
+			maxBytes := s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes
+			if maxBytes <= 0 {
+				maxBytes = 2048
+			}
+			upstreamDetail = truncateString(string(respBody), maxBytes)
+		}
+
+		if s.shouldFailoverGeminiUpstreamError(resp.StatusCode) {
+			appendOpsUpstreamError(c, OpsUpstreamErrorEvent{
+				Platform:           account.Platform,
+				AccountID:          account.ID,
+				AccountName:        account.Name,
+				UpstreamStatusCode: resp.StatusCode,
+				UpstreamRequestID:  requestID,
+				Kind:               "failover",
+				Message:            upstreamMsg,
+				Detail:             upstreamDetail,
+			})
+			return nil, &UpstreamFailoverError{StatusCode: resp.StatusCode}
+		}
+
+		setOpsUpstreamError(c, resp.StatusCode, upstreamMsg, upstreamDetail)
+		appendOpsUpstreamError(c, OpsUpstreamErrorEvent{
+			Platform:           account.Platform,
+			AccountID:          account.ID,
+			AccountName:        account.Name,
+			UpstreamStatusCode: resp.StatusCode,
+			UpstreamRequestID:  requestID,
+			Kind:               "http_error",
+			Message:            upstreamMsg,
+			Detail:             upstreamDetail,
+		})
+		if upstreamMsg == "" {
+			upstreamMsg = "Upstream request failed"
+		}
+		return nil, s.writeOpenAIError(c, resp.StatusCode, "invalid_request_error", upstreamMsg)
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, s.writeOpenAIError(c, http.StatusBadGateway, "upstream_error", "Failed to read upstream response")
+	}
+
+	promptTokens := estimateGeminiEmbedTokens(geminiBody)
+	out, err := openai.GeminiBatchEmbedToEmbeddingResponse(respBody, originalModel, req.EncodingFormat, promptTokens)
+	if err != nil {
+		log.Printf("[Gemini] embeddings response conversion failed: %v", err)
+		return nil, s.writeOpenAIError(c, http.StatusBadGateway, "upstream_error", "Failed to parse upstream response")
+	}
+
+	responseheaders.WriteFilteredHeaders(c.Writer.Header(), resp.Header, s.cfg.Security.ResponseHeaders)
+	if requestID != "" {
+		c.Header("x-request-id", requestID)
+	}
+	c.Data(http.StatusOK, "application/json", out)
+
+	return &ForwardResult{
+		RequestID: requestID,
+		Usage:     ClaudeUsage{InputTokens: promptTokens},
+		Model:     originalModel,
+		Duration:  time.Since(startTime),
+		Embedding: true,
+	}, nil
+}
+
+// writeOpenAIError 写出 OpenAI 格式错误响应
+func (s *GeminiMessagesCompatService) writeOpenAIError(c *gin.Context, status int, errType, message string) error {
+	c.JSON(status, gin.H{
+		"error": gin.H{"type": errType, "message": message},
+	})
+	return fmt.Errorf("%s", message)
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/Wei-Shaw/sub2api/internal/util/urlvalidator"
 
 	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
 )
 
 const geminiStickySessionTTL = time.Hour
@@ -46,6 +47,7 @@ type GeminiMessagesCompatService struct {
 	httpUpstream              HTTPUpstream
 	antigravityGatewayService *AntigravityGatewayService
 	cfg                       *config.Config
+	tokenCountEstimator       *TokenCountEstimator
 }
 
 func NewGeminiMessagesCompatService(
@@ -58,6 +60,7 @@ func NewGeminiMessagesCompatService(
 	httpUpstream HTTPUpstream,
 	antigravityGatewayService *AntigravityGatewayService,
 	cfg *config.Config,
+	tokenCountEstimator *TokenCountEstimator,
 ) *GeminiMessagesCompatService {
 	return &GeminiMessagesCompatService{
 		accountRepo:               accountRepo,
@@ -69,6 +72,7 @@ func NewGeminiMessagesCompatService(
 		httpUpstream:              httpUpstream,
 		antigravityGatewayService: antigravityGatewayService,
 		cfg:                       cfg,
+		tokenCountEstimator:       tokenCountEstimator,
 	}
 }
 
@@ -125,7 +129,7 @@ func (s *GeminiMessagesCompatService) SelectAccountForModelWithExclusions(ctx co
 	// 5. 设置粘性会话绑定
 	// Set sticky session binding
 	if sessionHash != "" {
-		_ = s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), cacheKey, selected.ID, geminiStickySessionTTL)
+		_ = s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), cacheKey, selected.ID, groupStickySessionTTL(ctx, geminiStickySessionTTL))
 	}
 
 	return selected, nil
@@ -208,7 +212,7 @@ func (s *GeminiMessagesCompatService) tryStickySessionHit(
 
 	// 刷新会话 TTL 并返回账号
 	// Refresh session TTL and return account
-	_ = s.cache.RefreshSessionTTL(ctx, derefGroupID(groupID), cacheKey, geminiStickySessionTTL)
+	_ = s.cache.RefreshSessionTTL(ctx, derefGroupID(groupID), cacheKey, groupStickySessionTTL(ctx, geminiStickySessionTTL))
 	return account
 }
 
@@ -684,6 +688,7 @@ func (s *GeminiMessagesCompatService) Forward(ctx context.Context, c *gin.Contex
 
 		resp, err = s.httpUpstream.Do(upstreamReq, proxyURL, account.ID, account.Concurrency)
 		if err != nil {
+			s.rateLimitService.HandleUpstreamRequestError(ctx, account, err)
 			safeErr := sanitizeUpstreamErrorMessage(err.Error())
 			appendOpsUpstreamError(c, OpsUpstreamErrorEvent{
 				Platform:           account.Platform,
@@ -974,6 +979,10 @@ func (s *GeminiMessagesCompatService) ForwardNative(ctx context.Context, c *gin.
 	switch action {
 	case "generateContent", "streamGenerateContent", "countTokens":
 		// ok
+	case "embedContent", "batchEmbedContents":
+		if !account.SupportsEmbeddings() {
+			return nil, s.writeGoogleError(c, http.StatusBadRequest, "Embedding actions require a Gemini API key account")
+		}
 	default:
 		return nil, s.writeGoogleError(c, http.StatusNotFound, "Unsupported action: "+action)
 	}
@@ -1131,6 +1140,7 @@ func (s *GeminiMessagesCompatService) ForwardNative(ctx context.Context, c *gin.
 
 		resp, err = s.httpUpstream.Do(upstreamReq, proxyURL, account.ID, account.Concurrency)
 		if err != nil {
+			s.rateLimitService.HandleUpstreamRequestError(ctx, account, err)
 			safeErr := sanitizeUpstreamErrorMessage(err.Error())
 			appendOpsUpstreamError(c, OpsUpstreamErrorEvent{
 				Platform:           account.Platform,
@@ -1146,7 +1156,7 @@ func (s *GeminiMessagesCompatService) ForwardNative(ctx context.Context, c *gin.
 				continue
 			}
 			if action == "countTokens" {
-				estimated := estimateGeminiCountTokens(body)
+				estimated := s.tokenCountEstimator.EstimateGeminiRequest(originalModel, body)
 				c.JSON(http.StatusOK, map[string]any{"totalTokens": estimated})
 				return &ForwardResult{
 					RequestID:    "",
@@ -1207,7 +1217,7 @@ func (s *GeminiMessagesCompatService) ForwardNative(ctx context.Context, c *gin.
 				continue
 			}
 			if action == "countTokens" {
-				estimated := estimateGeminiCountTokens(body)
+				estimated := s.tokenCountEstimator.EstimateGeminiRequest(originalModel, body)
 				c.JSON(http.StatusOK, map[string]any{"totalTokens": estimated})
 				return &ForwardResult{
 					RequestID:    "",
@@ -1252,7 +1262,7 @@ func (s *GeminiMessagesCompatService) ForwardNative(ctx context.Context, c *gin.
 		// Best-effort fallback for OAuth tokens missing AI Studio scopes when calling countTokens.
 		// This avoids Gemini SDKs failing hard during preflight token counting.
 		if action == "countTokens" && isOAuth && isGeminiInsufficientScope(resp.Header, respBody) {
-			estimated := estimateGeminiCountTokens(body)
+			estimated := s.tokenCountEstimator.EstimateGeminiRequest(originalModel, body)
 			c.JSON(http.StatusOK, map[string]any{"totalTokens": estimated})
 			return &ForwardResult{
 				RequestID:    requestID,
@@ -1348,6 +1358,10 @@ func (s *GeminiMessagesCompatService) ForwardNative(ctx context.Context, c *gin.
 		return nil, fmt.Errorf("gemini upstream error: %d message=%s", resp.StatusCode, upstreamMsg)
 	}
 
+	if action == "countTokens" {
+		return s.handleNativeCountTokensResponse(c, resp, originalModel, body, requestID, startTime, isOAuth)
+	}
+
 	var usage *ClaudeUsage
 	var firstTokenMs *int
 
@@ -1380,6 +1394,17 @@ func (s *GeminiMessagesCompatService) ForwardNative(ctx context.Context, c *gin.
 		usage = &ClaudeUsage{}
 	}
 
+	// Embedding 响应不含 usageMetadata，按请求文本估算输入 token
+	if IsGeminiEmbeddingAction(action) {
+		return &ForwardResult{
+			RequestID: requestID,
+			Usage:     ClaudeUsage{InputTokens: estimateGeminiEmbedTokens(body)},
+			Model:     originalModel,
+			Duration:  time.Since(startTime),
+			Embedding: true,
+		}, nil
+	}
+
 	// 图片生成计费
 	imageCount := 0
 	imageSize := s.extractImageSize(body)
@@ -2144,60 +2169,6 @@ func isGeminiInsufficientScope(headers http.Header, body []byte) bool {
 	return strings.Contains(lower, "insufficient authentication scopes") || strings.Contains(lower, "access_token_scope_insufficient")
 }
 
-func estimateGeminiCountTokens(reqBody []byte) int {
-	var obj map[string]any
-	if err := json.Unmarshal(reqBody, &obj); err != nil {
-		return 0
-	}
-
-	var texts []string
-
-	// systemInstruction.parts[].text
-	if si, ok := obj["systemInstruction"].(map[string]any); ok {
-		if parts, ok := si["parts"].([]any); ok {
-			for _, p := range parts {
-				if pm, ok := p.(map[string]any); ok {
-					if t, ok := pm["text"].(string); ok && strings.TrimSpace(t) != "" {
-						texts = append(texts, t)
-					}
-				}
-			}
-		}
-	}
-
-	// contents[].parts[].text
-	if contents, ok := obj["contents"].([]any); ok {
-		for _, c := range contents {
-			cm, ok := c.(map[string]any)
-			if !ok {
-				continue
-			}
-			parts, ok := cm["parts"].([]any)
-			if !ok {
-				continue
-			}
-			for _, p := range parts {
-				pm, ok := p.(map[string]any)
-				if !ok {
-					continue
-				}
-				if t, ok := pm["text"].(string); ok && strings.TrimSpace(t) != "" {
-					texts = append(texts, t)
-				}
-			}
-		}
-	}
-
-	total := 0
-	for _, t := range texts {
-		total += estimateTokensForText(t)
-	}
-	if total < 0 {
-		return 0
-	}
-	return total
-}
-
 func estimateTokensForText(s string) int {
 	s = strings.TrimSpace(s)
 	if s == "" {
@@ -2269,6 +2240,42 @@ func (s *GeminiMessagesCompatService) handleNativeNonStreamingResponse(c *gin.Co
 	return &ClaudeUsage{}, nil
 }
 
+// handleNativeCountTokensResponse 透传 countTokens 结果，并按采样率记录本地估算偏差
+func (s *GeminiMessagesCompatService) handleNativeCountTokensResponse(c *gin.Context, resp *http.Response, originalModel string, reqBody []byte, requestID string, startTime time.Time, isOAuth bool) (*ForwardResult, error) {
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, s.writeGoogleError(c, http.StatusBadGateway, "Failed to read upstream response")
+	}
+	respBody = unwrapIfNeeded(isOAuth, respBody)
+
+	responseheaders.WriteFilteredHeaders(c.Writer.Header(), resp.Header, s.cfg.Security.ResponseHeaders)
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	c.Data(resp.StatusCode, contentType, respBody)
+
+	s.tokenCountEstimator.ObserveUpstream(originalModel, int(gjson.GetBytes(respBody, "totalTokens").Int()), func() int {
+		return s.tokenCountEstimator.EstimateGeminiRequest(originalModel, reqBody)
+	})
+
+	return &ForwardResult{
+		RequestID: requestID,
+		Model:     originalModel,
+		Duration:  time.Since(startTime),
+	}, nil
+}
+
+// CountTokensLocalOnly 是否配置为始终本地估算 countTokens
+func (s *GeminiMessagesCompatService) CountTokensLocalOnly() bool {
+	return s.tokenCountEstimator.LocalOnly()
+}
+
+// WriteLocalCountTokens 写入本地估算的 countTokens 响应
+func (s *GeminiMessagesCompatService) WriteLocalCountTokens(c *gin.Context, model string, body []byte) {
+	c.JSON(http.StatusOK, map[string]any{"totalTokens": s.tokenCountEstimator.EstimateGeminiRequest(model, body)})
+}
+
 func (s *GeminiMessagesCompatService) handleNativeStreamingResponse(c *gin.Context, resp *http.Response, startTime time.Time, isOAuth bool) (*geminiNativeStreamResult, error) {
 	// Log response headers for debugging
 	log.Printf("[GeminiAPI] ========== Streaming Response Headers ==========")
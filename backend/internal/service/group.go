@@ -35,6 +35,24 @@ type Group struct {
 	// value: 优先账号 ID 列表
 	ModelRouting        map[string][]int64
 	ModelRoutingEnabled bool
+	// ModelRoutingTags 模型路由标签选择器（key: 模型匹配模式，value: 标签选择器表达式）
+	// 与 ModelRouting 共用匹配规则，命中的账号在调度快照内按标签解析，新账号打上标签即自动加入路由
+	ModelRoutingTags map[string]string
+
+	// AccountTagSelector 账号标签选择器，匹配的同平台账号自动加入分组（与显式绑定取并集）
+	AccountTagSelector string
+
+	// CostRoutingPolicy 成本优先路由策略（空表示关闭，lowest_cost 优先选择有效成本最低的账号）
+	CostRoutingPolicy string
+
+	// 模型映射配置（跨协议转发时使用，如 Gemini 原生 API 转发到 Claude/OpenAI 账号）
+	// key: 客户端请求的模型匹配模式（支持 * 通配符）
+	// value: 转发到上游的模型名
+	ModelMapping map[string]string
+
+	// 粘性会话策略（见 StickyPolicy* 常量，空值等同 content_hash）与 TTL（秒，0 表示平台默认值）
+	StickyPolicy     string
+	StickyTTLSeconds int
 
 	CreatedAt time.Time
 	UpdatedAt time.Time
@@ -102,24 +120,118 @@ func IsGroupContextValid(group *Group) bool {
 
 // GetRoutingAccountIDs 根据请求模型获取路由账号 ID 列表
 // 返回匹配的优先账号 ID 列表，如果没有匹配规则则返回 nil
+// 仅返回显式配置的账号 ID；标签选择器规则需通过 ResolveRoutingAccountIDs 结合账号列表解析
 func (g *Group) GetRoutingAccountIDs(requestedModel string) []int64 {
-	if !g.ModelRoutingEnabled || len(g.ModelRouting) == 0 || requestedModel == "" {
-		return nil
+	accountIDs, _ := g.matchRoutingRule(requestedModel)
+	return accountIDs
+}
+
+// HasRoutingRule 请求模型是否命中模型路由规则（账号 ID 或标签选择器）
+func (g *Group) HasRoutingRule(requestedModel string) bool {
+	accountIDs, selector := g.matchRoutingRule(requestedModel)
+	return len(accountIDs) > 0 || selector != ""
+}
+
+// RoutingRequiresAccounts 命中的路由规则是否包含标签选择器（需要账号列表才能解析）
+func (g *Group) RoutingRequiresAccounts(requestedModel string) bool {
+	_, selector := g.matchRoutingRule(requestedModel)
+	return selector != ""
+}
+
+// ResolveRoutingAccountIDs 根据请求模型解析路由账号 ID 列表
+// 显式账号 ID 在前，随后追加 accounts 中满足标签选择器的账号（保持 accounts 原有顺序）
+func (g *Group) ResolveRoutingAccountIDs(requestedModel string, accounts []Account) []int64 {
+	accountIDs, selectorExpr := g.matchRoutingRule(requestedModel)
+	if selectorExpr == "" {
+		return accountIDs
+	}
+	selector, err := ParseTagSelector(selectorExpr)
+	if err != nil || selector.IsEmpty() {
+		return accountIDs
+	}
+	out := make([]int64, 0, len(accountIDs)+len(accounts))
+	seen := make(map[int64]struct{}, len(accountIDs)+len(accounts))
+	for _, id := range accountIDs {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		out = append(out, id)
+	}
+	for i := range accounts {
+		id := accounts[i].ID
+		if _, ok := seen[id]; ok || !selector.Matches(accounts[i].Tags) {
+			continue
+		}
+		seen[id] = struct{}{}
+		out = append(out, id)
+	}
+	return out
+}
+
+// matchRoutingRule 查找命中的路由规则：精确匹配优先，其次通配符匹配
+// 同一模式同时配置了账号 ID 与标签选择器时两者都返回
+func (g *Group) matchRoutingRule(requestedModel string) ([]int64, string) {
+	if g == nil || !g.ModelRoutingEnabled || requestedModel == "" {
+		return nil, ""
+	}
+	if len(g.ModelRouting) == 0 && len(g.ModelRoutingTags) == 0 {
+		return nil, ""
 	}
 
 	// 1. 精确匹配优先
-	if accountIDs, ok := g.ModelRouting[requestedModel]; ok && len(accountIDs) > 0 {
-		return accountIDs
+	accountIDs := g.ModelRouting[requestedModel]
+	selector := strings.TrimSpace(g.ModelRoutingTags[requestedModel])
+	if len(accountIDs) > 0 || selector != "" {
+		return accountIDs, selector
 	}
 
 	// 2. 通配符匹配（前缀匹配）
-	for pattern, accountIDs := range g.ModelRouting {
-		if matchModelPattern(pattern, requestedModel) && len(accountIDs) > 0 {
-			return accountIDs
+	for pattern, ids := range g.ModelRouting {
+		if matchModelPattern(pattern, requestedModel) && len(ids) > 0 {
+			return ids, strings.TrimSpace(g.ModelRoutingTags[pattern])
+		}
+	}
+	for pattern, expr := range g.ModelRoutingTags {
+		if strings.TrimSpace(expr) != "" && matchModelPattern(pattern, requestedModel) {
+			return nil, strings.TrimSpace(expr)
 		}
 	}
 
-	return nil
+	return nil, ""
+}
+
+// MatchesAccountTags 账号是否通过标签选择器属于该分组
+func (g *Group) MatchesAccountTags(account *Account) bool {
+	if g == nil || account == nil || strings.TrimSpace(g.AccountTagSelector) == "" {
+		return false
+	}
+	return account.MatchesTagSelector(g.AccountTagSelector)
+}
+
+// MapModel 根据分组模型映射返回上游模型名
+// 精确匹配优先，其次为最长前缀的通配符匹配；未命中时原样返回
+func (g *Group) MapModel(requestedModel string) string {
+	if g == nil || len(g.ModelMapping) == 0 || requestedModel == "" {
+		return requestedModel
+	}
+	if mapped, ok := g.ModelMapping[requestedModel]; ok && mapped != "" {
+		return mapped
+	}
+
+	best, bestLen := "", -1
+	for pattern, mapped := range g.ModelMapping {
+		if mapped == "" || !strings.HasSuffix(pattern, "*") || !matchModelPattern(pattern, requestedModel) {
+			continue
+		}
+		if len(pattern) > bestLen {
+			best, bestLen = mapped, len(pattern)
+		}
+	}
+	if bestLen >= 0 {
+		return best
+	}
+	return requestedModel
 }
 
 // matchModelPattern 检查模型是否匹配模式
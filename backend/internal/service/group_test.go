@@ -90,3 +90,20 @@ func TestGroup_GetImagePrice_PartialConfig(t *testing.T) {
 	require.Nil(t, group.GetImagePrice("2K"))
 	require.Nil(t, group.GetImagePrice("4K"))
 }
+
+// TestGroup_MapModel 测试分组模型映射：精确匹配优先，其次最长通配符
+func TestGroup_MapModel(t *testing.T) {
+	group := &Group{ModelMapping: map[string]string{
+		"gemini-2.5-pro": "claude-opus-4-5",
+		"gemini-*":       "claude-haiku-4-5",
+		"gemini-2.5-*":   "claude-sonnet-4-5",
+	}}
+
+	require.Equal(t, "claude-opus-4-5", group.MapModel("gemini-2.5-pro"))
+	require.Equal(t, "claude-sonnet-4-5", group.MapModel("gemini-2.5-flash"))
+	require.Equal(t, "claude-haiku-4-5", group.MapModel("gemini-3-pro-preview"))
+	require.Equal(t, "claude-sonnet-4-5", group.MapModel("claude-sonnet-4-5"))
+
+	var nilGroup *Group
+	require.Equal(t, "gemini-2.5-pro", nilGroup.MapModel("gemini-2.5-pro"))
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Message Batch 处理状态（与 Anthropic Message Batches API 保持一致）
+const (
+	MessageBatchStatusInProgress = "in_progress"
+	MessageBatchStatusCanceling  = "canceling"
+	MessageBatchStatusEnded      = "ended"
+)
+
+// Message Batch 单条请求状态
+const (
+	MessageBatchItemStatusPending   = "pending"
+	MessageBatchItemStatusRunning   = "running"
+	MessageBatchItemStatusSucceeded = "succeeded"
+	MessageBatchItemStatusErrored   = "errored"
+	MessageBatchItemStatusCanceled  = "canceled"
+	MessageBatchItemStatusExpired   = "expired"
+)
+
+// MessageBatchRequestCounts 批次内各状态请求数
+// Processing 包含 pending 与 running。
+type MessageBatchRequestCounts struct {
+	Processing int `json:"processing"`
+	Succeeded  int `json:"succeeded"`
+	Errored    int `json:"errored"`
+	Canceled   int `json:"canceled"`
+	Expired    int `json:"expired"`
+}
+
+// MessageBatch 网关侧批处理任务
+// BatchID 为对外暴露的 msgbatch_ 前缀 ID，ID 为内部自增主键（用于分页与关联）。
+type MessageBatch struct {
+	ID                int64
+	BatchID           string
+	UserID            int64
+	APIKeyID          int64
+	GroupID           *int64
+	ProcessingStatus  string
+	RequestCounts     MessageBatchRequestCounts
+	CancelInitiatedAt *time.Time
+	EndedAt           *time.Time
+	ExpiresAt         time.Time
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+// MessageBatchItem 批次内单条 Messages 请求
+// Result 保存 Anthropic 批处理结果中的 result 对象（succeeded/errored/canceled/expired）。
+type MessageBatchItem struct {
+	ID         int64
+	BatchID    int64
+	CustomID   string
+	Params     json.RawMessage
+	Status     string
+	Result     json.RawMessage
+	Attempts   int
+	StartedAt  *time.Time
+	FinishedAt *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// MessageBatchWorkItem 执行器抢占到的待执行请求（附带所属批次的归属信息）
+type MessageBatchWorkItem struct {
+	MessageBatchItem
+	UserID   int64
+	APIKeyID int64
+	GroupID  *int64
+}
+
+// MessageBatchListParams Anthropic 风格的游标分页参数
+// BeforeID/AfterID 为对外 batch_id，二者最多设置一个。
+type MessageBatchListParams struct {
+	Limit    int
+	BeforeID string
+	AfterID  string
+}
+
+// MessageBatchRepository 定义批处理任务持久层接口
+type MessageBatchRepository interface {
+	// CreateBatch 在同一事务中写入批次及其全部请求
+	CreateBatch(ctx context.Context, batch *MessageBatch, items []MessageBatchItem) error
+	// GetBatch 按对外 batch_id 查询用户的批次（含 request_counts）；不存在返回 ErrMessageBatchNotFound
+	GetBatch(ctx context.Context, userID int64, batchID string) (*MessageBatch, error)
+	// ListBatches 按创建时间倒序列出用户批次，返回 hasMore
+	ListBatches(ctx context.Context, userID int64, params MessageBatchListParams) ([]MessageBatch, bool, error)
+	// CancelBatch 将 in_progress 批次置为 canceling，并将其 pending 请求标记为 canceled
+	CancelBatch(ctx context.Context, batchID int64, canceledResult json.RawMessage) error
+	// ListItemResults 按 ID 顺序分页读取批次内请求结果（afterItemID 为上一页最后一条的内部 ID）
+	ListItemResults(ctx context.Context, batchID int64, afterItemID int64, limit int) ([]MessageBatchItem, error)
+
+	// ClaimPendingItems 抢占最多 limit 条可执行请求（pending，或 running 超过 staleRunningAfterSeconds）
+	// 仅抢占 in_progress 且未过期批次中的请求。
+	ClaimPendingItems(ctx context.Context, limit int, staleRunningAfterSeconds int64) ([]MessageBatchWorkItem, error)
+	// RequeueItem 将 running 请求放回 pending；countAttempt 为 true 时累加 attempts
+	RequeueItem(ctx context.Context, itemID int64, countAttempt bool) error
+	// FinishItem 写入请求最终状态与结果
+	FinishItem(ctx context.Context, itemID int64, status string, result json.RawMessage) error
+	// ExpireItems 将已过期批次中的 pending 请求标记为 expired，返回影响行数
+	ExpireItems(ctx context.Context, result json.RawMessage) (int64, error)
+	// CancelPendingItems 将 canceling 批次中（被重新放回队列的）pending 请求标记为 canceled
+	CancelPendingItems(ctx context.Context, result json.RawMessage) (int64, error)
+	// FinalizeBatches 将不再有 pending/running 请求的 in_progress/canceling 批次置为 ended
+	FinalizeBatches(ctx context.Context) (int64, error)
+	// DeleteEndedBefore 删除结束时间早于 cutoff 的批次（请求随外键级联删除）
+	DeleteEndedBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
@@ -0,0 +1,674 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	messageBatchWorkerName = "message_batch_worker"
+
+	messageBatchIDPrefix        = "msgbatch_"
+	messageBatchDefaultLimit    = 20
+	messageBatchMaxLimit        = 1000
+	messageBatchResultPageSize  = 500
+	messageBatchCaptureLimit    = 16 << 20
+	messageBatchPurgeInterval   = time.Hour
+	messageBatchUsageTimeout    = 10 * time.Second
+	messageBatchRequestUA       = "sub2api-message-batch"
+	messageBatchDefaultSwitches = 10
+)
+
+var (
+	ErrMessageBatchNotFound = infraerrors.NotFound("MESSAGE_BATCH_NOT_FOUND", "message batch not found")
+	ErrMessageBatchNotEnded = infraerrors.BadRequest("MESSAGE_BATCH_NOT_ENDED", "batch results are available once processing_status is ended")
+
+	messageBatchCustomIDPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+	messageBatchCanceledResult = json.RawMessage(`{"type":"canceled"}`)
+	messageBatchExpiredResult  = json.RawMessage(`{"type":"expired"}`)
+)
+
+// MessageBatchService 网关侧 Message Batches 实现
+//
+// 上游 OAuth/Setup Token 账号无法直接使用 Anthropic 批处理后端，因此批次请求持久化到数据库，
+// 由后台执行器逐条通过正常调度链路（SelectAccountWithLoadAwareness + Forward）执行并计费。
+// 执行器以低优先级运行：只使用可立即获取的并发槽位，账号存在交互请求排队时主动让出。
+type MessageBatchService struct {
+	repo                      MessageBatchRepository
+	apiKeyRepo                APIKeyRepository
+	subscriptionService       *SubscriptionService
+	billingCacheService       *BillingCacheService
+	concurrencyService        *ConcurrencyService
+	gatewayService            *GatewayService
+	openAIGatewayService      *OpenAIGatewayService
+	geminiCompatService       *GeminiMessagesCompatService
+	antigravityGatewayService *AntigravityGatewayService
+	timingWheel               *TimingWheelService
+	cfg                       *config.Config
+
+	running   int32
+	lastPurge time.Time
+	startOnce sync.Once
+	stopOnce  sync.Once
+
+	workerCtx    context.Context
+	workerCancel context.CancelFunc
+}
+
+func NewMessageBatchService(
+	repo MessageBatchRepository,
+	apiKeyRepo APIKeyRepository,
+	subscriptionService *SubscriptionService,
+	billingCacheService *BillingCacheService,
+	concurrencyService *ConcurrencyService,
+	gatewayService *GatewayService,
+	openAIGatewayService *OpenAIGatewayService,
+	geminiCompatService *GeminiMessagesCompatService,
+	antigravityGatewayService *AntigravityGatewayService,
+	timingWheel *TimingWheelService,
+	cfg *config.Config,
+) *MessageBatchService {
+	workerCtx, workerCancel := context.WithCancel(context.Background())
+	return &MessageBatchService{
+		repo:                      repo,
+		apiKeyRepo:                apiKeyRepo,
+		subscriptionService:       subscriptionService,
+		billingCacheService:       billingCacheService,
+		concurrencyService:        concurrencyService,
+		gatewayService:            gatewayService,
+		openAIGatewayService:      openAIGatewayService,
+		geminiCompatService:       geminiCompatService,
+		antigravityGatewayService: antigravityGatewayService,
+		timingWheel:               timingWheel,
+		cfg:                       cfg,
+		workerCtx:                 workerCtx,
+		workerCancel:              workerCancel,
+	}
+}
+
+func (s *MessageBatchService) Start() {
+	if s == nil {
+		return
+	}
+	if s.cfg != nil && !s.cfg.MessageBatch.Enabled {
+		log.Printf("[MessageBatch] not started (disabled)")
+		return
+	}
+	if s.repo == nil || s.timingWheel == nil || s.gatewayService == nil {
+		log.Printf("[MessageBatch] not started (missing deps)")
+		return
+	}
+
+	interval := s.workerInterval()
+	s.startOnce.Do(func() {
+		s.timingWheel.ScheduleRecurring(messageBatchWorkerName, interval, s.runOnce)
+		log.Printf("[MessageBatch] started (interval=%s concurrency=%d)", interval, s.workerConcurrency())
+	})
+}
+
+func (s *MessageBatchService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		if s.workerCancel != nil {
+			s.workerCancel()
+		}
+		if s.timingWheel != nil {
+			s.timingWheel.Cancel(messageBatchWorkerName)
+		}
+		log.Printf("[MessageBatch] stopped")
+	})
+}
+
+// messageBatchCreateRequest POST /v1/messages/batches 请求体
+type messageBatchCreateRequest struct {
+	Requests []messageBatchCreateItem `json:"requests"`
+}
+
+type messageBatchCreateItem struct {
+	CustomID string          `json:"custom_id"`
+	Params   json.RawMessage `json:"params"`
+}
+
+// CreateBatch 校验并持久化批次，请求随后由后台执行器异步执行
+func (s *MessageBatchService) CreateBatch(ctx context.Context, apiKey *APIKey, body []byte) (*MessageBatch, error) {
+	if apiKey == nil {
+		return nil, infraerrors.Unauthorized("INVALID_API_KEY", "invalid api key")
+	}
+	items, err := s.parseCreateRequest(body)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	batch := &MessageBatch{
+		BatchID:          newMessageBatchID(),
+		UserID:           apiKey.UserID,
+		APIKeyID:         apiKey.ID,
+		GroupID:          apiKey.GroupID,
+		ProcessingStatus: MessageBatchStatusInProgress,
+		RequestCounts:    MessageBatchRequestCounts{Processing: len(items)},
+		ExpiresAt:        now.Add(s.expireAfter()),
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+	if err := s.repo.CreateBatch(ctx, batch, items); err != nil {
+		return nil, fmt.Errorf("create message batch: %w", err)
+	}
+	log.Printf("[MessageBatch] batch created: batch=%s user=%d api_key=%d requests=%d", batch.BatchID, batch.UserID, batch.APIKeyID, len(items))
+	return batch, nil
+}
+
+func (s *MessageBatchService) parseCreateRequest(body []byte) ([]MessageBatchItem, error) {
+	var req messageBatchCreateRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, infraerrors.BadRequest("INVALID_MESSAGE_BATCH", "Failed to parse request body")
+	}
+	if len(req.Requests) == 0 {
+		return nil, infraerrors.BadRequest("INVALID_MESSAGE_BATCH", "requests: must contain at least one request")
+	}
+	if maxRequests := s.maxRequestsPerBatch(); len(req.Requests) > maxRequests {
+		return nil, infraerrors.BadRequest("INVALID_MESSAGE_BATCH", fmt.Sprintf("requests: a batch may contain at most %d requests", maxRequests))
+	}
+
+	seen := make(map[string]struct{}, len(req.Requests))
+	items := make([]MessageBatchItem, 0, len(req.Requests))
+	for i, r := range req.Requests {
+		if err := validateMessageBatchCreateItem(r); err != nil {
+			return nil, infraerrors.BadRequest("INVALID_MESSAGE_BATCH", fmt.Sprintf("requests.%d.%s", i, err.Error()))
+		}
+		if _, dup := seen[r.CustomID]; dup {
+			return nil, infraerrors.BadRequest("INVALID_MESSAGE_BATCH", fmt.Sprintf("requests.%d.custom_id: duplicate custom_id %q", i, r.CustomID))
+		}
+		seen[r.CustomID] = struct{}{}
+		items = append(items, MessageBatchItem{
+			CustomID: r.CustomID,
+			Params:   r.Params,
+			Status:   MessageBatchItemStatusPending,
+		})
+	}
+	return items, nil
+}
+
+func validateMessageBatchCreateItem(r messageBatchCreateItem) error {
+	if !messageBatchCustomIDPattern.MatchString(r.CustomID) {
+		return errors.New("custom_id: must be 1-64 characters of letters, digits, '_' or '-'")
+	}
+	params := gjson.ParseBytes(r.Params)
+	if !params.IsObject() {
+		return errors.New("params: must be an object")
+	}
+	if strings.TrimSpace(params.Get("model").String()) == "" {
+		return errors.New("params.model: field required")
+	}
+	if !params.Get("messages").IsArray() {
+		return errors.New("params.messages: field required")
+	}
+	if params.Get("max_tokens").Int() <= 0 {
+		return errors.New("params.max_tokens: must be a positive integer")
+	}
+	if params.Get("stream").Bool() {
+		return errors.New("params.stream: streaming is not supported in batches")
+	}
+	return nil
+}
+
+func newMessageBatchID() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%s%x", messageBatchIDPrefix, time.Now().UnixNano())
+	}
+	return messageBatchIDPrefix + hex.EncodeToString(b)
+}
+
+// GetBatch 查询用户批次
+func (s *MessageBatchService) GetBatch(ctx context.Context, userID int64, batchID string) (*MessageBatch, error) {
+	if !strings.HasPrefix(batchID, messageBatchIDPrefix) {
+		return nil, ErrMessageBatchNotFound
+	}
+	return s.repo.GetBatch(ctx, userID, batchID)
+}
+
+// ListBatches 按创建时间倒序列出用户批次
+func (s *MessageBatchService) ListBatches(ctx context.Context, userID int64, params MessageBatchListParams) ([]MessageBatch, bool, error) {
+	if params.BeforeID != "" && params.AfterID != "" {
+		return nil, false, infraerrors.BadRequest("INVALID_MESSAGE_BATCH_CURSOR", "before_id and after_id cannot both be set")
+	}
+	if params.Limit <= 0 {
+		params.Limit = messageBatchDefaultLimit
+	}
+	if params.Limit > messageBatchMaxLimit {
+		params.Limit = messageBatchMaxLimit
+	}
+	return s.repo.ListBatches(ctx, userID, params)
+}
+
+// CancelBatch 取消批次：未开始的请求立即标记为 canceled，执行中的请求完成后批次结束
+func (s *MessageBatchService) CancelBatch(ctx context.Context, userID int64, batchID string) (*MessageBatch, error) {
+	batch, err := s.GetBatch(ctx, userID, batchID)
+	if err != nil {
+		return nil, err
+	}
+	if batch.ProcessingStatus != MessageBatchStatusInProgress {
+		return batch, nil
+	}
+	if err := s.repo.CancelBatch(ctx, batch.ID, messageBatchCanceledResult); err != nil {
+		return nil, fmt.Errorf("cancel message batch: %w", err)
+	}
+	log.Printf("[MessageBatch] batch cancel initiated: batch=%s user=%d", batch.BatchID, userID)
+	return s.repo.GetBatch(ctx, userID, batchID)
+}
+
+// messageBatchResultLine 结果 JSONL 中的一行
+type messageBatchResultLine struct {
+	CustomID string          `json:"custom_id"`
+	Result   json.RawMessage `json:"result"`
+}
+
+// StreamResults 逐行输出已结束批次的结果（Anthropic JSONL 格式），emit 返回错误时中止
+func (s *MessageBatchService) StreamResults(ctx context.Context, userID int64, batchID string, emit func(line []byte) error) error {
+	batch, err := s.GetBatch(ctx, userID, batchID)
+	if err != nil {
+		return err
+	}
+	if batch.ProcessingStatus != MessageBatchStatusEnded {
+		return ErrMessageBatchNotEnded
+	}
+
+	afterID := int64(0)
+	for {
+		items, err := s.repo.ListItemResults(ctx, batch.ID, afterID, messageBatchResultPageSize)
+		if err != nil {
+			return fmt.Errorf("list message batch results: %w", err)
+		}
+		for i := range items {
+			line, err := buildMessageBatchResultLine(&items[i])
+			if err != nil {
+				return err
+			}
+			if err := emit(line); err != nil {
+				return err
+			}
+			afterID = items[i].ID
+		}
+		if len(items) < messageBatchResultPageSize {
+			return nil
+		}
+	}
+}
+
+func buildMessageBatchResultLine(item *MessageBatchItem) ([]byte, error) {
+	result := item.Result
+	if len(result) == 0 {
+		// 批次已结束但请求无结果（理论上不会出现），按过期处理
+		result = messageBatchExpiredResult
+	}
+	return json.Marshal(messageBatchResultLine{CustomID: item.CustomID, Result: result})
+}
+
+func (s *MessageBatchService) runOnce() {
+	svc := s
+	if svc == nil {
+		return
+	}
+	if !atomic.CompareAndSwapInt32(&svc.running, 0, 1) {
+		return
+	}
+	defer atomic.StoreInt32(&svc.running, 0)
+
+	ctx := context.Background()
+	if svc.workerCtx != nil {
+		ctx = svc.workerCtx
+	}
+
+	svc.sweep(ctx)
+
+	items, err := svc.repo.ClaimPendingItems(ctx, svc.workerConcurrency(), int64(svc.itemTimeout().Seconds())*2)
+	if err != nil {
+		log.Printf("[MessageBatch] claim pending items failed: %v", err)
+		return
+	}
+	if len(items) == 0 {
+		return
+	}
+
+	apiKeys := make(map[int64]*APIKey)
+	var wg sync.WaitGroup
+	for i := range items {
+		item := items[i]
+		apiKey, ok := apiKeys[item.APIKeyID]
+		if !ok {
+			apiKey, err = svc.apiKeyRepo.GetByID(ctx, item.APIKeyID)
+			if err != nil && !errors.Is(err, ErrAPIKeyNotFound) {
+				log.Printf("[MessageBatch] load api key failed: api_key=%d err=%v", item.APIKeyID, err)
+				svc.requeue(ctx, &item, false)
+				continue
+			}
+			apiKeys[item.APIKeyID] = apiKey
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			svc.executeItem(ctx, &item, apiKey)
+		}()
+	}
+	wg.Wait()
+
+	// 尽快结束已处理完的批次
+	svc.sweep(ctx)
+}
+
+// sweep 过期/取消剩余请求、结束已完成批次、清理超出保留期的批次
+func (s *MessageBatchService) sweep(ctx context.Context) {
+	if n, err := s.repo.ExpireItems(ctx, messageBatchExpiredResult); err != nil {
+		log.Printf("[MessageBatch] expire items failed: %v", err)
+	} else if n > 0 {
+		log.Printf("[MessageBatch] expired %d pending requests", n)
+	}
+	if _, err := s.repo.CancelPendingItems(ctx, messageBatchCanceledResult); err != nil {
+		log.Printf("[MessageBatch] cancel pending items failed: %v", err)
+	}
+	if n, err := s.repo.FinalizeBatches(ctx); err != nil {
+		log.Printf("[MessageBatch] finalize batches failed: %v", err)
+	} else if n > 0 {
+		log.Printf("[MessageBatch] %d batches ended", n)
+	}
+
+	retentionDays := 0
+	if s.cfg != nil {
+		retentionDays = s.cfg.MessageBatch.ResultRetentionDays
+	}
+	if retentionDays > 0 && time.Since(s.lastPurge) >= messageBatchPurgeInterval {
+		s.lastPurge = time.Now()
+		cutoff := time.Now().AddDate(0, 0, -retentionDays)
+		if n, err := s.repo.DeleteEndedBefore(ctx, cutoff); err != nil {
+			log.Printf("[MessageBatch] purge ended batches failed: %v", err)
+		} else if n > 0 {
+			log.Printf("[MessageBatch] purged %d ended batches older than %d days", n, retentionDays)
+		}
+	}
+}
+
+// messageBatchOutcome 单条请求的执行结果
+// requeue 为 true 时放回队列稍后重试（countAttempt 表示是否计入重试次数），否则写入最终状态。
+type messageBatchOutcome struct {
+	requeue      bool
+	countAttempt bool
+	status       string
+	result       json.RawMessage
+}
+
+func (s *MessageBatchService) executeItem(ctx context.Context, item *MessageBatchWorkItem, apiKey *APIKey) {
+	itemCtx, cancel := context.WithTimeout(ctx, s.itemTimeout())
+	outcome := s.runItem(itemCtx, item, apiKey)
+	cancel()
+
+	if outcome.requeue {
+		s.requeue(ctx, item, outcome.countAttempt)
+		return
+	}
+	if err := s.repo.FinishItem(ctx, item.ID, outcome.status, outcome.result); err != nil {
+		log.Printf("[MessageBatch] finish item failed: item=%d err=%v", item.ID, err)
+	}
+}
+
+func (s *MessageBatchService) requeue(ctx context.Context, item *MessageBatchWorkItem, countAttempt bool) {
+	if err := s.repo.RequeueItem(ctx, item.ID, countAttempt); err != nil {
+		log.Printf("[MessageBatch] requeue item failed: item=%d err=%v", item.ID, err)
+	}
+}
+
+func (s *MessageBatchService) runItem(ctx context.Context, item *MessageBatchWorkItem, apiKey *APIKey) messageBatchOutcome {
+	if apiKey == nil || apiKey.UserID != item.UserID {
+		return messageBatchErrored("authentication_error", "API key not found")
+	}
+	user := apiKey.User
+	if !apiKey.IsActive() || user == nil || !user.IsActive() {
+		return messageBatchErrored("permission_error", "API key or user is disabled")
+	}
+	group := apiKey.Group
+
+	var subscription *UserSubscription
+	if group != nil && group.IsSubscriptionType() {
+		sub, err := s.subscriptionService.GetActiveSubscription(ctx, user.ID, group.ID)
+		if err != nil {
+			return messageBatchErrored("permission_error", "No active subscription found for this group")
+		}
+		subscription = sub
+	}
+	model := gjson.GetBytes(item.Params, "model").String()
+	if err := s.billingCacheService.CheckBillingEligibility(ctx, user, apiKey, group, subscription, model); err != nil {
+		if errors.Is(err, ErrBillingServiceUnavailable) {
+			return messageBatchOutcome{requeue: true}
+		}
+		msg := infraerrors.Message(err)
+		if msg == "" {
+			msg = err.Error()
+		}
+		return messageBatchErrored("billing_error", msg)
+	}
+
+	// 用户并发槽位：只尝试立即获取，拿不到时让出给交互请求
+	userSlot, err := s.concurrencyService.AcquireUserSlot(ctx, user.ID, user.Concurrency)
+	if err != nil || !userSlot.Acquired {
+		return messageBatchOutcome{requeue: true}
+	}
+	defer userSlot.ReleaseFunc()
+
+	maxSwitches := messageBatchDefaultSwitches
+	if s.cfg != nil && s.cfg.Gateway.MaxAccountSwitches > 0 {
+		maxSwitches = s.cfg.Gateway.MaxAccountSwitches
+	}
+	excluded := make(map[int64]struct{})
+	switches := 0
+	counted := false
+
+	for {
+		selection, err := s.gatewayService.SelectAccountWithLoadAwareness(ctx, item.GroupID, "", model, excluded, "") // 批处理不使用粘性会话
+		if err != nil || selection == nil || selection.Account == nil {
+			return s.retryOrFail(item, "overloaded_error", "No available accounts")
+		}
+		account := selection.Account
+		if !selection.Acquired || selection.ReleaseFunc == nil {
+			// 账号已满：不排队等待，下一轮再试
+			return messageBatchOutcome{requeue: true}
+		}
+		if waiting, err := s.concurrencyService.GetAccountWaitingCount(ctx, account.ID); err == nil && waiting > 0 {
+			// 账号有交互请求在排队，让出槽位
+			selection.ReleaseFunc()
+			return messageBatchOutcome{requeue: true}
+		}
+
+		// 首次转发时计入 API Key 请求数限额（重新入队的条目此前未被接纳，不计数）
+		if !counted {
+			s.billingCacheService.CountAPIKeyRequest(ctx, apiKey)
+			counted = true
+		}
+		result, statusCode, respBody, err := s.forward(ctx, account, item.Params)
+		selection.ReleaseFunc()
+
+		if err != nil {
+			var failoverErr *UpstreamFailoverError
+			if errors.As(err, &failoverErr) {
+				excluded[account.ID] = struct{}{}
+				if switches >= maxSwitches {
+					return s.retryOrFail(item, "api_error", fmt.Sprintf("Upstream request failed with status %d", failoverErr.StatusCode))
+				}
+				switches++
+				continue
+			}
+		}
+		if err != nil || statusCode >= 400 || result == nil {
+			errType, msg := extractMessageBatchUpstreamError(respBody)
+			if statusCode == http.StatusTooManyRequests || statusCode >= 500 || ctx.Err() != nil {
+				return s.retryOrFail(item, errType, msg)
+			}
+			return messageBatchErrored(errType, msg)
+		}
+		if !json.Valid(respBody) {
+			return messageBatchErrored("api_error", "Upstream returned an invalid response")
+		}
+
+		s.recordUsage(result, apiKey, account, subscription)
+
+		payload, err := json.Marshal(struct {
+			Type    string          `json:"type"`
+			Message json.RawMessage `json:"message"`
+		}{Type: "succeeded", Message: respBody})
+		if err != nil {
+			return messageBatchErrored("api_error", "Failed to encode result")
+		}
+		return messageBatchOutcome{status: MessageBatchItemStatusSucceeded, result: payload}
+	}
+}
+
+// forward 在合成的 gin.Context 中按账号平台转发单条 Messages 请求，返回状态码与响应体
+func (s *MessageBatchService) forward(ctx context.Context, account *Account, params []byte) (*ForwardResult, int, []byte, error) {
+	w := newLimitedResponseWriter(messageBatchCaptureLimit)
+	c, _ := gin.CreateTestContext(w)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "http://localhost/v1/messages", bytes.NewReader(params))
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("anthropic-version", "2023-06-01")
+	req.Header.Set("user-agent", messageBatchRequestUA)
+	c.Request = req
+
+	var (
+		result *ForwardResult
+		err    error
+	)
+	switch account.Platform {
+	case PlatformAntigravity:
+		result, err = s.antigravityGatewayService.Forward(ctx, c, account, params)
+	case PlatformGemini:
+		result, err = s.geminiCompatService.Forward(ctx, c, account, params)
+	case PlatformOpenAI:
+		result, err = s.openAIGatewayService.ForwardAsClaude(ctx, c, account, params)
+	default:
+		parsedReq, parseErr := ParseGatewayRequest(params)
+		if parseErr != nil {
+			return nil, http.StatusBadRequest, []byte(`{"type":"error","error":{"type":"invalid_request_error","message":"Failed to parse request body"}}`), nil
+		}
+		result, err = s.gatewayService.Forward(ctx, c, account, parsedReq)
+	}
+
+	statusCode := c.Writer.Status()
+	if err != nil && !c.Writer.Written() {
+		// 未写出任何响应（如连接失败、超时），按上游网关错误处理以便重试
+		statusCode = http.StatusBadGateway
+	}
+	if w.truncated() {
+		return nil, http.StatusBadGateway, []byte(`{"type":"error","error":{"type":"api_error","message":"Upstream response too large"}}`), err
+	}
+	return result, statusCode, w.bodyBytes(), err
+}
+
+func (s *MessageBatchService) recordUsage(result *ForwardResult, apiKey *APIKey, account *Account, subscription *UserSubscription) {
+	ctx, cancel := context.WithTimeout(context.Background(), messageBatchUsageTimeout)
+	defer cancel()
+	if err := s.gatewayService.RecordUsage(ctx, &RecordUsageInput{
+		Result:       result,
+		APIKey:       apiKey,
+		User:         apiKey.User,
+		Account:      account,
+		Subscription: subscription,
+		UserAgent:    messageBatchRequestUA,
+	}); err != nil {
+		log.Printf("[MessageBatch] record usage failed: api_key=%d account=%d err=%v", apiKey.ID, account.ID, err)
+	}
+}
+
+// retryOrFail 可重试错误：未达最大次数时放回队列，否则标记为 errored
+func (s *MessageBatchService) retryOrFail(item *MessageBatchWorkItem, errType, message string) messageBatchOutcome {
+	if item.Attempts+1 < s.maxAttempts() {
+		return messageBatchOutcome{requeue: true, countAttempt: true}
+	}
+	return messageBatchErrored(errType, message)
+}
+
+func messageBatchErrored(errType, message string) messageBatchOutcome {
+	payload, _ := json.Marshal(map[string]any{
+		"type": "errored",
+		"error": map[string]any{
+			"type": "error",
+			"error": map[string]string{
+				"type":    errType,
+				"message": message,
+			},
+		},
+	})
+	return messageBatchOutcome{status: MessageBatchItemStatusErrored, result: payload}
+}
+
+// extractMessageBatchUpstreamError 从 Anthropic/OpenAI 风格错误响应中提取错误类型与消息
+func extractMessageBatchUpstreamError(body []byte) (errType, message string) {
+	errType = strings.TrimSpace(gjson.GetBytes(body, "error.type").String())
+	message = strings.TrimSpace(gjson.GetBytes(body, "error.message").String())
+	if errType == "" {
+		errType = "api_error"
+	}
+	if message == "" {
+		message = "Upstream request failed"
+	}
+	return errType, message
+}
+
+func (s *MessageBatchService) workerInterval() time.Duration {
+	if s.cfg == nil || s.cfg.MessageBatch.WorkerIntervalSeconds <= 0 {
+		return 5 * time.Second
+	}
+	return time.Duration(s.cfg.MessageBatch.WorkerIntervalSeconds) * time.Second
+}
+
+func (s *MessageBatchService) workerConcurrency() int {
+	if s.cfg == nil || s.cfg.MessageBatch.WorkerConcurrency <= 0 {
+		return 4
+	}
+	return s.cfg.MessageBatch.WorkerConcurrency
+}
+
+func (s *MessageBatchService) maxRequestsPerBatch() int {
+	if s.cfg == nil || s.cfg.MessageBatch.MaxRequestsPerBatch <= 0 {
+		return 10000
+	}
+	return s.cfg.MessageBatch.MaxRequestsPerBatch
+}
+
+func (s *MessageBatchService) expireAfter() time.Duration {
+	if s.cfg == nil || s.cfg.MessageBatch.ExpireHours <= 0 {
+		return 24 * time.Hour
+	}
+	return time.Duration(s.cfg.MessageBatch.ExpireHours) * time.Hour
+}
+
+func (s *MessageBatchService) itemTimeout() time.Duration {
+	if s.cfg == nil || s.cfg.MessageBatch.ItemTimeoutSeconds <= 0 {
+		return 10 * time.Minute
+	}
+	return time.Duration(s.cfg.MessageBatch.ItemTimeoutSeconds) * time.Second
+}
+
+func (s *MessageBatchService) maxAttempts() int {
+	if s.cfg == nil || s.cfg.MessageBatch.MaxAttempts <= 0 {
+		return 3
+	}
+	return s.cfg.MessageBatch.MaxAttempts
+}
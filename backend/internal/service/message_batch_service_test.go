@@ -0,0 +1,101 @@
+//go:build unit
+
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func newMessageBatchTestService(maxRequests, maxAttempts int) *MessageBatchService {
+	cfg := &config.Config{}
+	cfg.MessageBatch.MaxRequestsPerBatch = maxRequests
+	cfg.MessageBatch.MaxAttempts = maxAttempts
+	return &MessageBatchService{cfg: cfg}
+}
+
+func TestMessageBatchParseCreateRequest(t *testing.T) {
+	svc := newMessageBatchTestService(2, 3)
+
+	items, err := svc.parseCreateRequest([]byte(`{"requests":[
+		{"custom_id":"req-1","params":{"model":"claude-sonnet-4-5","max_tokens":16,"messages":[{"role":"user","content":"hi"}]}},
+		{"custom_id":"req_2","params":{"model":"claude-sonnet-4-5","max_tokens":16,"messages":[]}}
+	]}`))
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	require.Equal(t, "req-1", items[0].CustomID)
+	require.Equal(t, MessageBatchItemStatusPending, items[0].Status)
+	require.Equal(t, "claude-sonnet-4-5", gjson.GetBytes(items[1].Params, "model").String())
+}
+
+func TestMessageBatchParseCreateRequestRejectsInvalid(t *testing.T) {
+	svc := newMessageBatchTestService(2, 3)
+	valid := `{"model":"m","max_tokens":1,"messages":[]}`
+
+	cases := map[string]string{
+		"empty":          `{"requests":[]}`,
+		"too_many":       `{"requests":[{"custom_id":"a","params":` + valid + `},{"custom_id":"b","params":` + valid + `},{"custom_id":"c","params":` + valid + `}]}`,
+		"bad_custom_id":  `{"requests":[{"custom_id":"has space","params":` + valid + `}]}`,
+		"duplicate":      `{"requests":[{"custom_id":"a","params":` + valid + `},{"custom_id":"a","params":` + valid + `}]}`,
+		"missing_model":  `{"requests":[{"custom_id":"a","params":{"max_tokens":1,"messages":[]}}]}`,
+		"missing_tokens": `{"requests":[{"custom_id":"a","params":{"model":"m","messages":[]}}]}`,
+		"stream":         `{"requests":[{"custom_id":"a","params":{"model":"m","max_tokens":1,"messages":[],"stream":true}}]}`,
+		"params_array":   `{"requests":[{"custom_id":"a","params":[]}]}`,
+		"not_json":       `{`,
+	}
+	for name, body := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := svc.parseCreateRequest([]byte(body))
+			require.Error(t, err)
+			require.Equal(t, http.StatusBadRequest, infraerrors.Code(err))
+		})
+	}
+}
+
+func TestBuildMessageBatchResultLine(t *testing.T) {
+	line, err := buildMessageBatchResultLine(&MessageBatchItem{
+		CustomID: "req-1",
+		Result:   json.RawMessage(`{"type":"succeeded","message":{"id":"msg_1"}}`),
+	})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"custom_id":"req-1","result":{"type":"succeeded","message":{"id":"msg_1"}}}`, string(line))
+
+	line, err = buildMessageBatchResultLine(&MessageBatchItem{CustomID: "req-2"})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"custom_id":"req-2","result":{"type":"expired"}}`, string(line))
+}
+
+func TestMessageBatchErroredResultFormat(t *testing.T) {
+	outcome := messageBatchErrored("invalid_request_error", "bad model")
+	require.False(t, outcome.requeue)
+	require.Equal(t, MessageBatchItemStatusErrored, outcome.status)
+	require.JSONEq(t, `{"type":"errored","error":{"type":"error","error":{"type":"invalid_request_error","message":"bad model"}}}`, string(outcome.result))
+}
+
+func TestMessageBatchRetryOrFail(t *testing.T) {
+	svc := newMessageBatchTestService(10, 3)
+
+	outcome := svc.retryOrFail(&MessageBatchWorkItem{MessageBatchItem: MessageBatchItem{Attempts: 1}}, "overloaded_error", "busy")
+	require.True(t, outcome.requeue)
+	require.True(t, outcome.countAttempt)
+
+	outcome = svc.retryOrFail(&MessageBatchWorkItem{MessageBatchItem: MessageBatchItem{Attempts: 2}}, "overloaded_error", "busy")
+	require.False(t, outcome.requeue)
+	require.Equal(t, MessageBatchItemStatusErrored, outcome.status)
+}
+
+func TestExtractMessageBatchUpstreamError(t *testing.T) {
+	errType, msg := extractMessageBatchUpstreamError([]byte(`{"type":"error","error":{"type":"invalid_request_error","message":"max_tokens too large"}}`))
+	require.Equal(t, "invalid_request_error", errType)
+	require.Equal(t, "max_tokens too large", msg)
+
+	errType, msg = extractMessageBatchUpstreamError([]byte(`not json`))
+	require.Equal(t, "api_error", errType)
+	require.Equal(t, "Upstream request failed", msg)
+}
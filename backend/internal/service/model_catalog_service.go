@@ -0,0 +1,391 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/antigravity"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/claude"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/gemini"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/openai"
+)
+
+// 模型目录来源
+const (
+	ModelCatalogSourceAccountMapping  = "account_mapping"
+	ModelCatalogSourceModelRouting    = "model_routing"
+	ModelCatalogSourceGroupMapping    = "group_mapping"
+	ModelCatalogSourcePlatformDefault = "platform_default"
+)
+
+// ModelCatalogPricing 模型价格（每百万 token，USD）
+// Base* 为基础价格，其余为乘以分组倍率后的实际计费价格
+type ModelCatalogPricing struct {
+	Currency          string  `json:"currency"`
+	RateMultiplier    float64 `json:"rate_multiplier"`
+	InputPerMTok      float64 `json:"input_per_mtok"`
+	OutputPerMTok     float64 `json:"output_per_mtok"`
+	CacheWritePerMTok float64 `json:"cache_write_per_mtok"`
+	CacheReadPerMTok  float64 `json:"cache_read_per_mtok"`
+
+	BaseInputPerMTok      float64 `json:"base_input_per_mtok"`
+	BaseOutputPerMTok     float64 `json:"base_output_per_mtok"`
+	BaseCacheWritePerMTok float64 `json:"base_cache_write_per_mtok"`
+	BaseCacheReadPerMTok  float64 `json:"base_cache_read_per_mtok"`
+}
+
+// ModelCatalogEntry 某分组下可路由的模型及其能力、价格元数据
+type ModelCatalogEntry struct {
+	ID                string               `json:"id"`
+	DisplayName       string               `json:"display_name"`
+	OwnedBy           string               `json:"owned_by"`
+	CreatedAt         time.Time            `json:"created_at"`
+	ContextWindow     int                  `json:"context_window"`
+	MaxOutputTokens   int                  `json:"max_output_tokens"`
+	InputModalities   []string             `json:"input_modalities"`
+	OutputModalities  []string             `json:"output_modalities"`
+	SupportsTools     bool                 `json:"supports_tools"`
+	SupportsReasoning bool                 `json:"supports_reasoning"`
+	Pricing           *ModelCatalogPricing `json:"pricing,omitempty"`
+
+	// 以下字段仅用于管理员视图
+	Sources    []string `json:"sources"`
+	AccountIDs []int64  `json:"account_ids"`
+}
+
+// ModelCatalogService 按 API Key 所属分组计算实际可路由的模型目录
+type ModelCatalogService struct {
+	accountRepo    AccountRepository
+	pricingService *PricingService
+	billingService *BillingService
+	cfg            *config.Config
+}
+
+// NewModelCatalogService creates a new ModelCatalogService
+func NewModelCatalogService(
+	accountRepo AccountRepository,
+	pricingService *PricingService,
+	billingService *BillingService,
+	cfg *config.Config,
+) *ModelCatalogService {
+	return &ModelCatalogService{
+		accountRepo:    accountRepo,
+		pricingService: pricingService,
+		billingService: billingService,
+		cfg:            cfg,
+	}
+}
+
+// catalogDefaultModel 平台默认模型
+type catalogDefaultModel struct {
+	ID          string
+	DisplayName string
+	CreatedAt   time.Time
+}
+
+// ListModels 返回分组内实际可路由的模型（group 为 nil 时统计所有可调度账号）。
+// platform 非空时覆盖分组平台（如 /antigravity 路由强制 antigravity 平台）。
+//
+// 来源：
+//   - 账号 model_mapping 的精确键（未配置映射的账号支持平台默认模型）
+//   - 分组 model_routing 的精确键（至少一个路由账号可调度时）
+//   - 分组 model_mapping 的精确键（跨协议转发，如 Gemini 原生 API）
+func (s *ModelCatalogService) ListModels(ctx context.Context, group *Group, platform string) ([]ModelCatalogEntry, error) {
+	if platform == "" && group != nil {
+		platform = group.Platform
+	}
+
+	var (
+		accounts []Account
+		err      error
+	)
+	if group != nil {
+		accounts, err = s.accountRepo.ListSchedulableByGroupID(ctx, group.ID)
+	} else {
+		accounts, err = s.accountRepo.ListSchedulable(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]*ModelCatalogEntry)
+	add := func(model, source string, accountID int64) {
+		model = strings.TrimSpace(model)
+		if model == "" || strings.Contains(model, "*") {
+			return
+		}
+		entry, ok := entries[model]
+		if !ok {
+			entry = &ModelCatalogEntry{ID: model, Sources: []string{}, AccountIDs: []int64{}}
+			entries[model] = entry
+		}
+		if !slices.Contains(entry.Sources, source) {
+			entry.Sources = append(entry.Sources, source)
+		}
+		if accountID > 0 && !containsInt64(entry.AccountIDs, accountID) {
+			entry.AccountIDs = append(entry.AccountIDs, accountID)
+		}
+	}
+
+	schedulable := make(map[int64]struct{}, len(accounts))
+	for i := range accounts {
+		account := &accounts[i]
+		if platform != "" && !account.IsAllowedInMixedScheduling(platform) {
+			continue
+		}
+		schedulable[account.ID] = struct{}{}
+
+		mapping := account.GetModelMapping()
+		if len(mapping) > 0 {
+			for model := range mapping {
+				add(model, ModelCatalogSourceAccountMapping, account.ID)
+			}
+			continue
+		}
+		defaultsPlatform := platform
+		if defaultsPlatform == "" {
+			defaultsPlatform = account.Platform
+		}
+		for _, m := range platformDefaultCatalogModels(defaultsPlatform) {
+			add(m.ID, ModelCatalogSourcePlatformDefault, account.ID)
+		}
+	}
+
+	if group != nil {
+		if group.ModelRoutingEnabled {
+			for model, accountIDs := range group.ModelRouting {
+				for _, id := range accountIDs {
+					if _, ok := schedulable[id]; ok {
+						add(model, ModelCatalogSourceModelRouting, id)
+					}
+				}
+			}
+			for model, expr := range group.ModelRoutingTags {
+				for i := range accounts {
+					if _, ok := schedulable[accounts[i].ID]; ok && accounts[i].MatchesTagSelector(expr) {
+						add(model, ModelCatalogSourceModelRouting, accounts[i].ID)
+					}
+				}
+			}
+		}
+		if len(schedulable) > 0 {
+			for model := range group.ModelMapping {
+				add(model, ModelCatalogSourceGroupMapping, 0)
+			}
+		}
+	}
+
+	multiplier := s.rateMultiplier(group)
+	out := make([]ModelCatalogEntry, 0, len(entries))
+	for _, entry := range entries {
+		s.enrich(entry, platform, group, multiplier)
+		sort.Slice(entry.AccountIDs, func(i, j int) bool { return entry.AccountIDs[i] < entry.AccountIDs[j] })
+		out = append(out, *entry)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+func (s *ModelCatalogService) rateMultiplier(group *Group) float64 {
+	if group != nil {
+		return group.RateMultiplier
+	}
+	if s.cfg != nil {
+		return s.cfg.Default.RateMultiplier
+	}
+	return 1
+}
+
+// enrich 填充展示名、上下文长度、模态与分组倍率后的价格
+func (s *ModelCatalogService) enrich(entry *ModelCatalogEntry, platform string, group *Group, multiplier float64) {
+	entry.DisplayName = entry.ID
+	entry.OwnedBy = catalogModelOwner(entry.ID, platform)
+	if def, ok := findCatalogDefaultModel(entry.ID); ok {
+		if def.DisplayName != "" {
+			entry.DisplayName = def.DisplayName
+		}
+		entry.CreatedAt = def.CreatedAt
+	}
+
+	// 跨协议转发时按映射后的上游模型计价与查询能力
+	pricedModel := entry.ID
+	if group != nil {
+		pricedModel = group.MapModel(entry.ID)
+	}
+
+	caps := defaultCatalogCapabilities(pricedModel)
+	if s.pricingService != nil {
+		if lp := s.pricingService.GetModelPricing(pricedModel); lp != nil {
+			applyLiteLLMCapabilities(&caps, lp)
+		}
+	}
+	entry.ContextWindow = caps.contextWindow
+	entry.MaxOutputTokens = caps.maxOutputTokens
+	entry.InputModalities = caps.inputModalities()
+	entry.OutputModalities = []string{"text"}
+	entry.SupportsTools = caps.tools
+	entry.SupportsReasoning = caps.reasoning
+
+	if s.billingService == nil {
+		return
+	}
+	pricing, err := s.billingService.GetModelPricing(pricedModel)
+	if err != nil || pricing == nil {
+		return
+	}
+	const perMillion = 1_000_000
+	entry.Pricing = &ModelCatalogPricing{
+		Currency:              "USD",
+		RateMultiplier:        multiplier,
+		BaseInputPerMTok:      roundCatalogPrice(pricing.InputPricePerToken * perMillion),
+		BaseOutputPerMTok:     roundCatalogPrice(pricing.OutputPricePerToken * perMillion),
+		BaseCacheWritePerMTok: roundCatalogPrice(pricing.CacheCreationPricePerToken * perMillion),
+		BaseCacheReadPerMTok:  roundCatalogPrice(pricing.CacheReadPricePerToken * perMillion),
+		InputPerMTok:          roundCatalogPrice(pricing.InputPricePerToken * perMillion * multiplier),
+		OutputPerMTok:         roundCatalogPrice(pricing.OutputPricePerToken * perMillion * multiplier),
+		CacheWritePerMTok:     roundCatalogPrice(pricing.CacheCreationPricePerToken * perMillion * multiplier),
+		CacheReadPerMTok:      roundCatalogPrice(pricing.CacheReadPricePerToken * perMillion * multiplier),
+	}
+}
+
+// roundCatalogPrice 保留 6 位小数，避免浮点误差
+func roundCatalogPrice(v float64) float64 {
+	const scale = 1e6
+	if v >= 0 {
+		return float64(int64(v*scale+0.5)) / scale
+	}
+	return float64(int64(v*scale-0.5)) / scale
+}
+
+// catalogCapabilities 模型能力（LiteLLM 数据缺失时按模型系列给出默认值）
+type catalogCapabilities struct {
+	contextWindow   int
+	maxOutputTokens int
+	vision          bool
+	pdf             bool
+	audio           bool
+	tools           bool
+	reasoning       bool
+}
+
+func (c catalogCapabilities) inputModalities() []string {
+	modalities := []string{"text"}
+	if c.vision {
+		modalities = append(modalities, "image")
+	}
+	if c.pdf {
+		modalities = append(modalities, "pdf")
+	}
+	if c.audio {
+		modalities = append(modalities, "audio")
+	}
+	return modalities
+}
+
+func defaultCatalogCapabilities(model string) catalogCapabilities {
+	lower := strings.ToLower(model)
+	switch {
+	case strings.Contains(lower, "claude"):
+		return catalogCapabilities{contextWindow: 200000, maxOutputTokens: 64000, vision: true, pdf: true, tools: true, reasoning: true}
+	case strings.Contains(lower, "gemini"):
+		return catalogCapabilities{contextWindow: 1048576, maxOutputTokens: 65536, vision: true, pdf: true, audio: true, tools: true, reasoning: true}
+	case strings.HasPrefix(lower, "gpt-5"):
+		return catalogCapabilities{contextWindow: 400000, maxOutputTokens: 128000, vision: true, tools: true, reasoning: true}
+	case strings.HasPrefix(lower, "gpt-"), strings.HasPrefix(lower, "o1"), strings.HasPrefix(lower, "o3"), strings.HasPrefix(lower, "o4"):
+		return catalogCapabilities{contextWindow: 128000, maxOutputTokens: 16384, vision: true, tools: true}
+	default:
+		return catalogCapabilities{tools: true}
+	}
+}
+
+func applyLiteLLMCapabilities(caps *catalogCapabilities, lp *LiteLLMModelPricing) {
+	if lp.MaxInputTokens > 0 {
+		caps.contextWindow = lp.MaxInputTokens
+	}
+	if lp.MaxOutputTokens > 0 {
+		caps.maxOutputTokens = lp.MaxOutputTokens
+	}
+	caps.vision = caps.vision || lp.SupportsVision
+	caps.pdf = caps.pdf || lp.SupportsPDFInput
+	caps.audio = caps.audio || lp.SupportsAudioInput
+	caps.tools = caps.tools || lp.SupportsFunctionCalling
+	caps.reasoning = caps.reasoning || lp.SupportsReasoning
+}
+
+// catalogModelOwner 推断模型所属厂商
+func catalogModelOwner(model, platform string) string {
+	lower := strings.ToLower(model)
+	switch {
+	case strings.HasPrefix(lower, "claude"):
+		return "anthropic"
+	case strings.HasPrefix(lower, "gemini"):
+		return "google"
+	case strings.HasPrefix(lower, "gpt-"), strings.HasPrefix(lower, "codex"):
+		return "openai"
+	}
+	switch platform {
+	case PlatformAnthropic:
+		return "anthropic"
+	case PlatformOpenAI:
+		return "openai"
+	case PlatformGemini, PlatformAntigravity:
+		return "google"
+	default:
+		return "sub2api"
+	}
+}
+
+// platformDefaultCatalogModels 未配置模型映射的账号默认支持的模型
+func platformDefaultCatalogModels(platform string) []catalogDefaultModel {
+	switch platform {
+	case PlatformOpenAI:
+		out := make([]catalogDefaultModel, 0, len(openai.DefaultModels))
+		for _, m := range openai.DefaultModels {
+			out = append(out, catalogDefaultModel{ID: m.ID, DisplayName: m.DisplayName, CreatedAt: time.Unix(m.Created, 0).UTC()})
+		}
+		return out
+	case PlatformGemini:
+		models := gemini.DefaultModels()
+		out := make([]catalogDefaultModel, 0, len(models))
+		for _, m := range models {
+			out = append(out, catalogDefaultModel{ID: strings.TrimPrefix(m.Name, "models/"), DisplayName: m.DisplayName})
+		}
+		return out
+	case PlatformAntigravity:
+		models := antigravity.DefaultModels()
+		out := make([]catalogDefaultModel, 0, len(models))
+		for _, m := range models {
+			out = append(out, catalogDefaultModel{ID: m.ID, DisplayName: m.DisplayName, CreatedAt: parseCatalogTime(m.CreatedAt)})
+		}
+		return out
+	default:
+		out := make([]catalogDefaultModel, 0, len(claude.DefaultModels))
+		for _, m := range claude.DefaultModels {
+			out = append(out, catalogDefaultModel{ID: m.ID, DisplayName: m.DisplayName, CreatedAt: parseCatalogTime(m.CreatedAt)})
+		}
+		return out
+	}
+}
+
+func findCatalogDefaultModel(model string) (catalogDefaultModel, bool) {
+	for _, platform := range []string{PlatformAnthropic, PlatformOpenAI, PlatformGemini, PlatformAntigravity} {
+		for _, m := range platformDefaultCatalogModels(platform) {
+			if m.ID == model {
+				return m, true
+			}
+		}
+	}
+	return catalogDefaultModel{}, false
+}
+
+func parseCatalogTime(value string) time.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
@@ -0,0 +1,91 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type modelCatalogAccountRepoStub struct {
+	mockAccountRepoForPlatform
+}
+
+func (m *modelCatalogAccountRepoStub) ListSchedulableByGroupID(ctx context.Context, groupID int64) ([]Account, error) {
+	return m.accounts, nil
+}
+
+func TestModelCatalogService_ListModels(t *testing.T) {
+	repo := &modelCatalogAccountRepoStub{mockAccountRepoForPlatform{accounts: []Account{
+		{ID: 1, Platform: PlatformAnthropic, Credentials: map[string]any{"model_mapping": map[string]any{
+			"claude-sonnet-4-5": "claude-sonnet-4-5-20250929",
+			"claude-*":          "claude-sonnet-4-5-20250929",
+		}}},
+		{ID: 2, Platform: PlatformAnthropic},
+		{ID: 3, Platform: PlatformGemini},
+	}}}
+	svc := NewModelCatalogService(repo, nil, NewBillingService(testConfig(), nil, nil), testConfig())
+
+	group := &Group{
+		ID:                  10,
+		Platform:            PlatformAnthropic,
+		RateMultiplier:      2,
+		ModelRoutingEnabled: true,
+		ModelRouting: map[string][]int64{
+			"claude-opus-4-5-20251101": {2},
+			"claude-private":           {99},
+		},
+		ModelMapping: map[string]string{"gemini-2.5-pro": "claude-sonnet-4-5"},
+	}
+	entries, err := svc.ListModels(context.Background(), group, "")
+	require.NoError(t, err)
+
+	byID := make(map[string]ModelCatalogEntry, len(entries))
+	for _, e := range entries {
+		byID[e.ID] = e
+	}
+	require.NotContains(t, byID, "claude-*", "wildcard mapping keys are not listed")
+	require.NotContains(t, byID, "claude-private", "routing rules without schedulable accounts are not listed")
+	require.NotContains(t, byID, "gemini-2.5-flash", "accounts of other platforms are skipped")
+
+	sonnet := byID["claude-sonnet-4-5"]
+	require.Equal(t, []string{ModelCatalogSourceAccountMapping}, sonnet.Sources)
+	require.Equal(t, []int64{1}, sonnet.AccountIDs)
+	require.Equal(t, 200000, sonnet.ContextWindow)
+	require.Contains(t, sonnet.InputModalities, "image")
+	require.NotNil(t, sonnet.Pricing)
+	require.InDelta(t, sonnet.Pricing.BaseInputPerMTok*2, sonnet.Pricing.InputPerMTok, 1e-9)
+
+	opus := byID["claude-opus-4-5-20251101"]
+	require.ElementsMatch(t, []string{ModelCatalogSourcePlatformDefault, ModelCatalogSourceModelRouting}, opus.Sources)
+	require.Equal(t, "Claude Opus 4.5", opus.DisplayName)
+	require.Equal(t, "anthropic", opus.OwnedBy)
+
+	bridged := byID["gemini-2.5-pro"]
+	require.Equal(t, []string{ModelCatalogSourceGroupMapping}, bridged.Sources)
+	require.Equal(t, 200000, bridged.ContextWindow, "capabilities follow the mapped upstream model")
+}
+
+func TestModelCatalogService_ListModels_MixedScheduling(t *testing.T) {
+	mixed := map[string]any{"mixed_scheduling": true}
+	repo := &modelCatalogAccountRepoStub{mockAccountRepoForPlatform{accounts: []Account{
+		{ID: 1, Platform: PlatformGemini},
+		{ID: 2, Platform: PlatformAntigravity, Extra: mixed},
+		{ID: 3, Platform: PlatformOpenAI, Extra: mixed},
+	}}}
+	svc := NewModelCatalogService(repo, nil, NewBillingService(testConfig(), nil, nil), testConfig())
+
+	entries, err := svc.ListModels(context.Background(), &Group{ID: 10, Platform: PlatformGemini}, "")
+	require.NoError(t, err)
+
+	accountIDs := map[int64]struct{}{}
+	for _, e := range entries {
+		for _, id := range e.AccountIDs {
+			accountIDs[id] = struct{}{}
+		}
+	}
+	require.Contains(t, accountIDs, int64(2), "mixed antigravity accounts serve gemini groups")
+	require.NotContains(t, accountIDs, int64(3), "mixed openai accounts only join anthropic groups")
+}
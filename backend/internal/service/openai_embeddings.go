@@ -0,0 +1,153 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/util/responseheaders"
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// openaiEmbeddingsAPIURL OpenAI Platform Embeddings API（未配置 base_url 的 API Key 账号）
+const openaiEmbeddingsAPIURL = "https://api.openai.com/v1/embeddings"
+
+// ForwardEmbeddings 透传 Embeddings 请求到 OpenAI API Key 账号
+// 仅对模型名做映射，用量取自响应 usage.prompt_tokens。
+func (s *OpenAIGatewayService) ForwardEmbeddings(ctx context.Context, c *gin.Context, account *Account, body []byte) (*OpenAIForwardResult, error) {
+	startTime := time.Now()
+
+	if !account.IsOpenAIApiKey() {
+		return nil, fmt.Errorf("account %d does not support embeddings", account.ID)
+	}
+
+	originalModel := gjson.GetBytes(body, "model").String()
+	mappedModel := account.GetMappedModel(originalModel)
+	if mappedModel != originalModel {
+		log.Printf("[OpenAI] Embeddings model mapping applied: %s -> %s (account: %s)", originalModel, mappedModel, account.Name)
+		newBody, err := sjson.SetBytes(body, "model", mappedModel)
+		if err != nil {
+			return nil, fmt.Errorf("serialize request body: %w", err)
+		}
+		body = newBody
+	}
+
+	token, _, err := s.GetAccessToken(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+
+	targetURL := openaiEmbeddingsAPIURL
+	if baseURL := strings.TrimSpace(account.GetCredential("base_url")); baseURL != "" {
+		validatedURL, err := s.validateUpstreamBaseURL(baseURL)
+		if err != nil {
+			return nil, err
+		}
+		targetURL = validatedURL + "/embeddings"
+	}
+
+	upstreamReq, err := http.NewRequestWithContext(ctx, http.MethodPost, targetURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	upstreamReq.Header.Set("authorization", "Bearer "+token)
+	for key, values := range c.Request.Header {
+		if openaiAllowedHeaders[strings.ToLower(key)] {
+			for _, v := range values {
+				upstreamReq.Header.Add(key, v)
+			}
+		}
+	}
+	if customUA := account.GetOpenAIUserAgent(); customUA != "" {
+		upstreamReq.Header.Set("user-agent", customUA)
+	}
+	if upstreamReq.Header.Get("content-type") == "" {
+		upstreamReq.Header.Set("content-type", "application/json")
+	}
+
+	proxyURL := ""
+	if account.ProxyID != nil && account.Proxy != nil {
+		proxyURL = account.Proxy.URL()
+	}
+
+	// Capture upstream request body for ops retry of this attempt.
+	c.Set(OpsUpstreamRequestBodyKey, string(body))
+
+	resp, err := s.httpUpstream.Do(upstreamReq, proxyURL, account.ID, account.Concurrency)
+	if err != nil {
+		safeErr := sanitizeUpstreamErrorMessage(err.Error())
+		setOpsUpstreamError(c, 0, safeErr, "")
+		appendOpsUpstreamError(c, OpsUpstreamErrorEvent{
+			Platform:           account.Platform,
+			AccountID:          account.ID,
+			AccountName:        account.Name,
+			UpstreamStatusCode: 0,
+			Kind:               "request_error",
+			Message:            safeErr,
+		})
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error": gin.H{
+				"type":    "upstream_error",
+				"message": "Upstream request failed",
+			},
+		})
+		return nil, fmt.Errorf("upstream request failed: %s", safeErr)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= 400 {
+		if s.shouldFailoverUpstreamError(resp.StatusCode) {
+			respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 2<<20))
+			_ = resp.Body.Close()
+			resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+			upstreamMsg := strings.TrimSpace(extractUpstreamErrorMessage(respBody))
+			upstreamMsg = sanitizeUpstreamErrorMessage(upstreamMsg)
+			appendOpsUpstreamError(c, OpsUpstreamErrorEvent{
+				Platform:           account.Platform,
+				AccountID:          account.ID,
+				AccountName:        account.Name,
+				UpstreamStatusCode: resp.StatusCode,
+				UpstreamRequestID:  resp.Header.Get("x-request-id"),
+				Kind:               "failover",
+				Message:            upstreamMsg,
+				Detail:             s.upstreamErrorDetail(respBody),
+			})
+
+			s.handleFailoverSideEffects(ctx, resp, account)
+			return nil, &UpstreamFailoverError{StatusCode: resp.StatusCode}
+		}
+		return s.handleErrorResponse(ctx, resp, c, account)
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	promptTokens := int(gjson.GetBytes(respBody, "usage.prompt_tokens").Int())
+
+	// 响应中的 model 还原为客户端请求的模型名
+	if originalModel != mappedModel && gjson.GetBytes(respBody, "model").String() == mappedModel {
+		if newBody, err := sjson.SetBytes(respBody, "model", originalModel); err == nil {
+			respBody = newBody
+		}
+	}
+
+	responseheaders.WriteFilteredHeaders(c.Writer.Header(), resp.Header, s.cfg.Security.ResponseHeaders)
+	c.Data(resp.StatusCode, "application/json", respBody)
+
+	return &OpenAIForwardResult{
+		RequestID: resp.Header.Get("x-request-id"),
+		Usage:     OpenAIUsage{InputTokens: promptTokens},
+		Model:     originalModel,
+		Duration:  time.Since(startTime),
+		Embedding: true,
+	}, nil
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/Wei-Shaw/sub2api/internal/util/responseheaders"
 	"github.com/Wei-Shaw/sub2api/internal/util/urlvalidator"
 	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
 )
 
 const (
@@ -162,6 +163,7 @@ type OpenAIForwardResult struct {
 	Stream       bool
 	Duration     time.Duration
 	FirstTokenMs *int
+	Embedding    bool // Embedding 请求按 embedding 价格仅对输入 token 计费
 }
 
 // OpenAIGatewayService handles OpenAI API gateway operations
@@ -180,6 +182,10 @@ type OpenAIGatewayService struct {
 	httpUpstream        HTTPUpstream
 	deferredService     *DeferredService
 	openAITokenProvider *OpenAITokenProvider
+	responseStateCache  OpenAIResponseStateCache
+	rateBudgetService   *AccountRateBudgetService // 账号 RPM/TPM 预算
+	scoringService      *AccountScoringService    // 账号延迟/错误率评分
+	circuitBreaker      *AccountCircuitBreakerService
 	toolCorrector       *CodexToolCorrector
 }
 
@@ -199,6 +205,10 @@ func NewOpenAIGatewayService(
 	httpUpstream HTTPUpstream,
 	deferredService *DeferredService,
 	openAITokenProvider *OpenAITokenProvider,
+	responseStateCache OpenAIResponseStateCache,
+	rateBudgetService *AccountRateBudgetService,
+	scoringService *AccountScoringService,
+	circuitBreaker *AccountCircuitBreakerService,
 ) *OpenAIGatewayService {
 	return &OpenAIGatewayService{
 		accountRepo:         accountRepo,
@@ -215,6 +225,10 @@ func NewOpenAIGatewayService(
 		httpUpstream:        httpUpstream,
 		deferredService:     deferredService,
 		openAITokenProvider: openAITokenProvider,
+		responseStateCache:  responseStateCache,
+		rateBudgetService:   rateBudgetService,
+		scoringService:      scoringService,
+		circuitBreaker:      circuitBreaker,
 		toolCorrector:       NewCodexToolCorrector(),
 	}
 }
@@ -247,12 +261,12 @@ func (s *OpenAIGatewayService) GenerateSessionHash(c *gin.Context, reqBody map[s
 	return hex.EncodeToString(hash[:])
 }
 
-// BindStickySession sets session -> account binding with standard TTL.
+// BindStickySession sets session -> account binding with the group TTL (standard TTL by default).
 func (s *OpenAIGatewayService) BindStickySession(ctx context.Context, groupID *int64, sessionHash string, accountID int64) error {
 	if sessionHash == "" || accountID <= 0 {
 		return nil
 	}
-	return s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), "openai:"+sessionHash, accountID, openaiStickySessionTTL)
+	return s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), "openai:"+sessionHash, accountID, groupStickySessionTTL(ctx, openaiStickySessionTTL))
 }
 
 // SelectAccount selects an OpenAI account with sticky session support
@@ -297,7 +311,7 @@ func (s *OpenAIGatewayService) SelectAccountForModelWithExclusions(ctx context.C
 	// 4. 设置粘性会话绑定
 	// Set sticky session binding
 	if sessionHash != "" {
-		_ = s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), cacheKey, selected.ID, openaiStickySessionTTL)
+		_ = s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), cacheKey, selected.ID, groupStickySessionTTL(ctx, openaiStickySessionTTL))
 	}
 
 	return selected, nil
@@ -345,7 +359,7 @@ func (s *OpenAIGatewayService) tryStickySessionHit(ctx context.Context, groupID
 
 	// 刷新会话 TTL 并返回账号
 	// Refresh session TTL and return account
-	_ = s.cache.RefreshSessionTTL(ctx, derefGroupID(groupID), cacheKey, openaiStickySessionTTL)
+	_ = s.cache.RefreshSessionTTL(ctx, derefGroupID(groupID), cacheKey, groupStickySessionTTL(ctx, openaiStickySessionTTL))
 	return account
 }
 
@@ -428,15 +442,47 @@ func (s *OpenAIGatewayService) isBetterAccount(candidate, current *Account) bool
 
 // SelectAccountWithLoadAwareness selects an account with load-awareness and wait plan.
 func (s *OpenAIGatewayService) SelectAccountWithLoadAwareness(ctx context.Context, groupID *int64, sessionHash string, requestedModel string, excludedIDs map[int64]struct{}) (*AccountSelectionResult, error) {
+	for {
+		result, err := s.selectAccountWithLoadAwareness(ctx, groupID, sessionHash, requestedModel, excludedIDs)
+		if err != nil || result == nil || !result.Acquired {
+			// 等待计划此时不领取名额，由调用方获取到槽位后通过 ReserveAccountRequest 领取
+			return result, err
+		}
+		if s.ReserveAccountRequest(ctx, result.Account) {
+			return result, nil
+		}
+		// 筛选后探测名额被领取或预算被并发请求占满：释放槽位并改选其他账号
+		if result.ReleaseFunc != nil {
+			result.ReleaseFunc()
+		}
+		excludedIDs = withExcludedAccount(excludedIDs, result.Account.ID)
+	}
+}
+
+// ReserveAccountRequest 获取到账号槽位后领取熔断放行名额（半开探测）并原子地计入 RPM 预算
+// 返回 false 表示账号熔断中、探测名额已被领取或预算已满，调用方应释放槽位并改选其他账号。
+func (s *OpenAIGatewayService) ReserveAccountRequest(ctx context.Context, account *Account) bool {
+	return s.circuitBreaker.AcquirePermit(ctx, account) && s.rateBudgetService.TryReserveRequest(ctx, account)
+}
+
+func (s *OpenAIGatewayService) selectAccountWithLoadAwareness(ctx context.Context, groupID *int64, sessionHash string, requestedModel string, excludedIDs map[int64]struct{}) (*AccountSelectionResult, error) {
 	cfg := s.schedulingConfig()
+	trace := selectionTraceFrom(ctx)
 	var stickyAccountID int64
 	if sessionHash != "" && s.cache != nil {
 		if accountID, err := s.cache.GetSessionAccountID(ctx, derefGroupID(groupID), "openai:"+sessionHash); err == nil {
 			stickyAccountID = accountID
 		}
 	}
+	trace.sticky(stickyAccountID)
 	if s.concurrencyService == nil || !cfg.LoadBatchEnabled {
-		account, err := s.SelectAccountForModelWithExclusions(ctx, groupID, sessionHash, requestedModel, excludedIDs)
+		trace.enter(SchedulerLayerLegacy)
+		if trace != nil {
+			if err := s.traceLegacyCandidates(ctx, trace, groupID, requestedModel, excludedIDs); err != nil {
+				return nil, err
+			}
+		}
+		account, err := s.selectAccountWithinLimits(ctx, groupID, sessionHash, requestedModel, excludedIDs)
 		if err != nil {
 			return nil, err
 		}
@@ -477,20 +523,21 @@ func (s *OpenAIGatewayService) SelectAccountWithLoadAwareness(ctx context.Contex
 	if err != nil {
 		return nil, err
 	}
+	trace.scope(groupID, PlatformOpenAI, false)
 	if len(accounts) == 0 {
 		return nil, errors.New("no available accounts")
 	}
 
+	// Accounts whose RPM/TPM budget is saturated or whose circuit is open are skipped like excluded ones (sticky and load-aware layers).
+	exclusion, rejection := s.accountFilter(ctx, accounts, excludedIDs, requestedModel)
 	isExcluded := func(accountID int64) bool {
-		if excludedIDs == nil {
-			return false
-		}
-		_, excluded := excludedIDs[accountID]
-		return excluded
+		return exclusion(accountID) != ""
 	}
+	trace.evaluate(accounts, rejection)
 
 	// ============ Layer 1: Sticky session ============
 	if sessionHash != "" {
+		trace.enter(SchedulerLayerSticky)
 		accountID, err := s.cache.GetSessionAccountID(ctx, derefGroupID(groupID), "openai:"+sessionHash)
 		if err == nil && accountID > 0 && !isExcluded(accountID) {
 			account, err := s.getSchedulableAccount(ctx, accountID)
@@ -503,7 +550,7 @@ func (s *OpenAIGatewayService) SelectAccountWithLoadAwareness(ctx context.Contex
 					(requestedModel == "" || account.IsModelSupported(requestedModel)) {
 					result, err := s.tryAcquireAccountSlot(ctx, accountID, account.Concurrency)
 					if err == nil && result.Acquired {
-						_ = s.cache.RefreshSessionTTL(ctx, derefGroupID(groupID), "openai:"+sessionHash, openaiStickySessionTTL)
+						_ = s.cache.RefreshSessionTTL(ctx, derefGroupID(groupID), "openai:"+sessionHash, groupStickySessionTTL(ctx, openaiStickySessionTTL))
 						return &AccountSelectionResult{
 							Account:     account,
 							Acquired:    true,
@@ -529,22 +576,13 @@ func (s *OpenAIGatewayService) SelectAccountWithLoadAwareness(ctx context.Contex
 	}
 
 	// ============ Layer 2: Load-aware selection ============
+	trace.enter(SchedulerLayerLoadBalance)
 	candidates := make([]*Account, 0, len(accounts))
 	for i := range accounts {
 		acc := &accounts[i]
-		if isExcluded(acc.ID) {
-			continue
-		}
-		// Scheduler snapshots can be temporarily stale (bucket rebuild is throttled);
-		// re-check schedulability here so recently rate-limited/overloaded accounts
-		// are not selected again before the bucket is rebuilt.
-		if !acc.IsSchedulable() {
-			continue
+		if rejection(acc) == "" {
+			candidates = append(candidates, acc)
 		}
-		if requestedModel != "" && !acc.IsModelSupported(requestedModel) {
-			continue
-		}
-		candidates = append(candidates, acc)
 	}
 
 	if len(candidates) == 0 {
@@ -563,11 +601,12 @@ func (s *OpenAIGatewayService) SelectAccountWithLoadAwareness(ctx context.Contex
 	if err != nil {
 		ordered := append([]*Account(nil), candidates...)
 		sortAccountsByPriorityAndLastUsed(ordered, false)
+		trace.rank(len(ordered), func(i int) *Account { return ordered[i] })
 		for _, acc := range ordered {
 			result, err := s.tryAcquireAccountSlot(ctx, acc.ID, acc.Concurrency)
 			if err == nil && result.Acquired {
 				if sessionHash != "" {
-					_ = s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), "openai:"+sessionHash, acc.ID, openaiStickySessionTTL)
+					_ = s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), "openai:"+sessionHash, acc.ID, groupStickySessionTTL(ctx, openaiStickySessionTTL))
 				}
 				return &AccountSelectionResult{
 					Account:     acc,
@@ -616,16 +655,51 @@ func (s *OpenAIGatewayService) SelectAccountWithLoadAwareness(ctx context.Contex
 				}
 			})
 
+			// Scoring modes reorder each priority tier by TTFT / error rate / load.
+			if s.scoringService.Enabled(cfg.FallbackSelectionMode) {
+				accs := make([]*Account, 0, len(available))
+				for _, item := range available {
+					accs = append(accs, item.account)
+				}
+				reordered := make([]accountWithLoad, 0, len(available))
+				for _, idx := range s.scoringService.Order(ctx, cfg.FallbackSelectionMode, accs, loadMap) {
+					reordered = append(reordered, available[idx])
+				}
+				available = reordered
+			}
+
+			// Cost-aware routing: the head of the default order is the baseline; stable-reorder
+			// by effective cost so pricier accounts are used only once cheaper ones are saturated.
+			var router *costRouter
+			var baseline *Account
+			if costRoutingGroup(ctx, groupID) != nil {
+				accs := make([]*Account, 0, len(available))
+				for _, item := range available {
+					accs = append(accs, item.account)
+				}
+				router = newCostRouter(accs, func(acc *Account) float64 {
+					return accountCostFactor(s.billingService, acc, requestedModel, false)
+				})
+				baseline = accs[0]
+				reordered := make([]accountWithLoad, 0, len(available))
+				for _, idx := range router.order(accs) {
+					reordered = append(reordered, available[idx])
+				}
+				available = reordered
+			}
+
+			trace.rank(len(available), func(i int) *Account { return available[i].account })
 			for _, item := range available {
 				result, err := s.tryAcquireAccountSlot(ctx, item.account.ID, item.account.Concurrency)
 				if err == nil && result.Acquired {
 					if sessionHash != "" {
-						_ = s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), "openai:"+sessionHash, item.account.ID, openaiStickySessionTTL)
+						_ = s.cache.SetSessionAccountID(ctx, derefGroupID(groupID), "openai:"+sessionHash, item.account.ID, groupStickySessionTTL(ctx, openaiStickySessionTTL))
 					}
 					return &AccountSelectionResult{
 						Account:     item.account,
 						Acquired:    true,
 						ReleaseFunc: result.ReleaseFunc,
+						CostRouting: router.decision(baseline, item.account),
 					}, nil
 				}
 			}
@@ -633,7 +707,17 @@ func (s *OpenAIGatewayService) SelectAccountWithLoadAwareness(ctx context.Contex
 	}
 
 	// ============ Layer 3: Fallback wait ============
-	sortAccountsByPriorityAndLastUsed(candidates, false)
+	trace.enter(SchedulerLayerFallbackWait)
+	if s.scoringService.Enabled(cfg.FallbackSelectionMode) {
+		ordered := make([]*Account, 0, len(candidates))
+		for _, idx := range s.scoringService.Order(ctx, cfg.FallbackSelectionMode, candidates, loadMap) {
+			ordered = append(ordered, candidates[idx])
+		}
+		candidates = ordered
+	} else {
+		sortAccountsByPriorityAndLastUsed(candidates, false)
+	}
+	trace.rank(len(candidates), func(i int) *Account { return candidates[i] })
 	for _, acc := range candidates {
 		return &AccountSelectionResult{
 			Account: acc,
@@ -649,6 +733,24 @@ func (s *OpenAIGatewayService) SelectAccountWithLoadAwareness(ctx context.Contex
 	return nil, errors.New("no available accounts")
 }
 
+// selectAccountWithinLimits selects via SelectAccountForModelWithExclusions, re-selecting while the chosen account's RPM/TPM budget is saturated or its circuit is open.
+func (s *OpenAIGatewayService) selectAccountWithinLimits(ctx context.Context, groupID *int64, sessionHash string, requestedModel string, excludedIDs map[int64]struct{}) (*Account, error) {
+	localExcluded := make(map[int64]struct{}, len(excludedIDs))
+	for k, v := range excludedIDs {
+		localExcluded[k] = v
+	}
+	for {
+		account, err := s.SelectAccountForModelWithExclusions(ctx, groupID, sessionHash, requestedModel, localExcluded)
+		if err != nil {
+			return nil, err
+		}
+		if !s.rateBudgetService.IsSaturated(ctx, account) && !s.circuitBreaker.IsBlocked(ctx, account) {
+			return account, nil
+		}
+		localExcluded[account.ID] = struct{}{}
+	}
+}
+
 func (s *OpenAIGatewayService) listSchedulableAccounts(ctx context.Context, groupID *int64) ([]Account, error) {
 	if s.schedulerSnapshot != nil {
 		accounts, _, err := s.schedulerSnapshot.ListSchedulableAccounts(ctx, groupID, PlatformOpenAI, false)
@@ -669,6 +771,42 @@ func (s *OpenAIGatewayService) listSchedulableAccounts(ctx context.Context, grou
 	return accounts, nil
 }
 
+// accountFilter per-account filters of load-aware selection (shared with the scheduler explanation).
+// exclusion checks only RPM/TPM budget, circuit breaker and explicit exclusion; rejection checks everything.
+// Both return the rejection reason, empty when the account passes.
+func (s *OpenAIGatewayService) accountFilter(ctx context.Context, accounts []Account, excludedIDs map[int64]struct{}, requestedModel string) (exclusion func(int64) string, rejection func(*Account) string) {
+	rateSaturated := s.rateBudgetService.SaturatedAccounts(ctx, accounts)
+	circuitBlocked := s.circuitBreaker.BlockedAccounts(ctx, accounts)
+	exclusion = func(accountID int64) string {
+		if _, saturated := rateSaturated[accountID]; saturated {
+			return SchedulerReasonRateBudget
+		}
+		if _, blocked := circuitBlocked[accountID]; blocked {
+			return SchedulerReasonCircuitOpen
+		}
+		if _, excluded := excludedIDs[accountID]; excluded {
+			return SchedulerReasonExcluded
+		}
+		return ""
+	}
+	rejection = func(acc *Account) string {
+		if reason := exclusion(acc.ID); reason != "" {
+			return reason
+		}
+		switch {
+		// Scheduler snapshots can be temporarily stale (bucket rebuild is throttled);
+		// re-check schedulability here so recently rate-limited/overloaded accounts
+		// are not selected again before the bucket is rebuilt.
+		case !acc.IsSchedulable():
+			return SchedulerReasonUnschedulable
+		case requestedModel != "" && !acc.IsModelSupported(requestedModel):
+			return SchedulerReasonModelUnsupported
+		}
+		return ""
+	}
+	return exclusion, rejection
+}
+
 func (s *OpenAIGatewayService) tryAcquireAccountSlot(ctx context.Context, accountID int64, maxConcurrency int) (*AcquireResult, error) {
 	if s.concurrencyService == nil {
 		return &AcquireResult{Acquired: true, ReleaseFunc: func() {}}, nil
@@ -762,6 +900,12 @@ func (s *OpenAIGatewayService) Forward(ctx context.Context, c *gin.Context, acco
 	bodyModified := false
 	originalModel := reqModel
 
+	// previous_response_id 不属于当前账号时在本地还原完整输入链（见 openai_response_state.go）
+	stateTurn := s.newResponseStateTurn(ctx, reqBody)
+	if s.rehydrateResponseInput(ctx, stateTurn, account, reqBody) {
+		bodyModified = true
+	}
+
 	isCodexCLI := openai.IsCodexCLIRequest(c.GetHeader("User-Agent"))
 
 	// 对所有请求执行模型映射（包含 Codex CLI）。
@@ -879,6 +1023,7 @@ func (s *OpenAIGatewayService) Forward(ctx context.Context, c *gin.Context, acco
 	// Send request
 	resp, err := s.httpUpstream.Do(upstreamReq, proxyURL, account.ID, account.Concurrency)
 	if err != nil {
+		s.rateLimitService.HandleUpstreamRequestError(ctx, account, err)
 		// Ensure the client receives an error response (handlers assume Forward writes on non-failover errors).
 		safeErr := sanitizeUpstreamErrorMessage(err.Error())
 		setOpsUpstreamError(c, 0, safeErr, "")
@@ -937,6 +1082,7 @@ func (s *OpenAIGatewayService) Forward(ctx context.Context, c *gin.Context, acco
 	// Handle normal response
 	var usage *OpenAIUsage
 	var firstTokenMs *int
+	var finalResponse []byte
 	if reqStream {
 		streamResult, err := s.handleStreamingResponse(ctx, resp, c, account, startTime, originalModel, mappedModel)
 		if err != nil {
@@ -944,12 +1090,16 @@ func (s *OpenAIGatewayService) Forward(ctx context.Context, c *gin.Context, acco
 		}
 		usage = streamResult.usage
 		firstTokenMs = streamResult.firstTokenMs
+		finalResponse = streamResult.finalResponse
 	} else {
-		usage, err = s.handleNonStreamingResponse(ctx, resp, c, account, originalModel, mappedModel)
+		nonStreamResult, err := s.handleNonStreamingResponse(ctx, resp, c, account, originalModel, mappedModel)
 		if err != nil {
 			return nil, err
 		}
+		usage = nonStreamResult.usage
+		finalResponse = nonStreamResult.finalResponse
 	}
+	s.saveResponseState(ctx, stateTurn, account, finalResponse)
 
 	// Extract and save Codex usage snapshot from response headers (for OAuth accounts)
 	if account.Type == AccountTypeOAuth {
@@ -1164,6 +1314,15 @@ func (s *OpenAIGatewayService) handleErrorResponse(ctx context.Context, resp *ht
 type openaiStreamingResult struct {
 	usage        *OpenAIUsage
 	firstTokenMs *int
+	// finalResponse response.completed 事件中的 response 对象（用于保存续链状态）
+	finalResponse []byte
+}
+
+// openaiNonStreamingResult non-streaming response result
+type openaiNonStreamingResult struct {
+	usage *OpenAIUsage
+	// finalResponse 最终 response 对象（用于保存续链状态）
+	finalResponse []byte
 }
 
 func (s *OpenAIGatewayService) handleStreamingResponse(ctx context.Context, resp *http.Response, c *gin.Context, account *Account, startTime time.Time, originalModel, mappedModel string) (*openaiStreamingResult, error) {
@@ -1190,6 +1349,7 @@ func (s *OpenAIGatewayService) handleStreamingResponse(ctx context.Context, resp
 
 	usage := &OpenAIUsage{}
 	var firstTokenMs *int
+	var finalResponse []byte
 	scanner := bufio.NewScanner(resp.Body)
 	maxLineSize := defaultMaxLineSize
 	if s.cfg != nil && s.cfg.Gateway.MaxLineSize > 0 {
@@ -1277,7 +1437,7 @@ func (s *OpenAIGatewayService) handleStreamingResponse(ctx context.Context, resp
 		select {
 		case ev, ok := <-events:
 			if !ok {
-				return &openaiStreamingResult{usage: usage, firstTokenMs: firstTokenMs}, nil
+				return &openaiStreamingResult{usage: usage, firstTokenMs: firstTokenMs, finalResponse: finalResponse}, nil
 			}
 			if ev.err != nil {
 				if errors.Is(ev.err, bufio.ErrTooLong) {
@@ -1319,6 +1479,9 @@ func (s *OpenAIGatewayService) handleStreamingResponse(ctx context.Context, resp
 					firstTokenMs = &ms
 				}
 				s.parseSSEUsage(data, usage)
+				if finalResponse == nil && gjson.Get(data, "type").String() == "response.completed" {
+					finalResponse = []byte(gjson.Get(data, "response").Raw)
+				}
 			} else {
 				// Forward non-data lines as-is
 				if _, err := fmt.Fprintf(w, "%s\n", line); err != nil {
@@ -1429,7 +1592,7 @@ func (s *OpenAIGatewayService) parseSSEUsage(data string, usage *OpenAIUsage) {
 	}
 }
 
-func (s *OpenAIGatewayService) handleNonStreamingResponse(ctx context.Context, resp *http.Response, c *gin.Context, account *Account, originalModel, mappedModel string) (*OpenAIUsage, error) {
+func (s *OpenAIGatewayService) handleNonStreamingResponse(ctx context.Context, resp *http.Response, c *gin.Context, account *Account, originalModel, mappedModel string) (*openaiNonStreamingResult, error) {
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
@@ -1478,7 +1641,7 @@ func (s *OpenAIGatewayService) handleNonStreamingResponse(ctx context.Context, r
 
 	c.Data(resp.StatusCode, contentType, body)
 
-	return usage, nil
+	return &openaiNonStreamingResult{usage: usage, finalResponse: body}, nil
 }
 
 func isEventStreamResponse(header http.Header) bool {
@@ -1486,7 +1649,7 @@ func isEventStreamResponse(header http.Header) bool {
 	return strings.Contains(contentType, "text/event-stream")
 }
 
-func (s *OpenAIGatewayService) handleOAuthSSEToJSON(resp *http.Response, c *gin.Context, body []byte, originalModel, mappedModel string) (*OpenAIUsage, error) {
+func (s *OpenAIGatewayService) handleOAuthSSEToJSON(resp *http.Response, c *gin.Context, body []byte, originalModel, mappedModel string) (*openaiNonStreamingResult, error) {
 	bodyText := string(body)
 	finalResponse, ok := extractCodexFinalResponse(bodyText)
 
@@ -1531,7 +1694,11 @@ func (s *OpenAIGatewayService) handleOAuthSSEToJSON(resp *http.Response, c *gin.
 	}
 	c.Data(resp.StatusCode, contentType, body)
 
-	return usage, nil
+	result := &openaiNonStreamingResult{usage: usage}
+	if ok {
+		result.finalResponse = body
+	}
+	return result, nil
 }
 
 func extractCodexFinalResponse(body string) ([]byte, bool) {
@@ -1633,8 +1800,11 @@ type OpenAIRecordUsageInput struct {
 	User         *User
 	Account      *Account
 	Subscription *UserSubscription
-	UserAgent    string // 请求的 User-Agent
-	IPAddress    string // 请求的客户端 IP 地址
+	UserAgent    string               // 请求的 User-Agent
+	IPAddress    string               // 请求的客户端 IP 地址
+	SessionHash  string               // 粘性会话哈希（用于统计 prompt cache 命中率）
+	CostRouting  *CostRoutingDecision // 成本优先路由决策（用于统计策略转移的花费）
+	BalanceHold  *BalanceHold         // 可选：请求开始时的余额预授权冻结，扣费时按实际费用结算
 }
 
 // RecordUsage records usage and deducts balance
@@ -1645,6 +1815,14 @@ func (s *OpenAIGatewayService) RecordUsage(ctx context.Context, input *OpenAIRec
 	account := input.Account
 	subscription := input.Subscription
 
+	// 余额预授权冻结：扣费时按实际费用结算，未扣费（简易模式/不计费/零费用）时返回前释放
+	holdSettled := false
+	defer func() {
+		if !holdSettled {
+			s.billingCacheService.ReleaseBalanceHold(input.BalanceHold)
+		}
+	}()
+
 	// 计算实际的新输入token（减去缓存读取的token）
 	// 因为 input_tokens 包含了 cache_read_tokens，而缓存读取的token不应按输入价格计费
 	actualInputTokens := result.Usage.InputTokens - result.Usage.CacheReadInputTokens
@@ -1666,9 +1844,15 @@ func (s *OpenAIGatewayService) RecordUsage(ctx context.Context, input *OpenAIRec
 		multiplier = apiKey.Group.RateMultiplier
 	}
 
-	cost, err := s.billingService.CalculateCost(result.Model, tokens, multiplier)
-	if err != nil {
-		cost = &CostBreakdown{ActualCost: 0}
+	var cost *CostBreakdown
+	if result.Embedding {
+		cost = s.billingService.CalculateEmbeddingCostForGroup(result.Model, apiKey.GroupID, actualInputTokens, multiplier)
+	} else {
+		var err error
+		cost, err = s.billingService.CalculateCostForGroup(result.Model, apiKey.GroupID, tokens, multiplier)
+		if err != nil {
+			cost = &CostBreakdown{ActualCost: 0}
+		}
 	}
 
 	// Determine billing type
@@ -1703,6 +1887,7 @@ func (s *OpenAIGatewayService) RecordUsage(ctx context.Context, input *OpenAIRec
 		Stream:                result.Stream,
 		DurationMs:            &durationMs,
 		FirstTokenMs:          result.FirstTokenMs,
+		PricingOverrideID:     cost.PricingOverrideID,
 		CreatedAt:             time.Now(),
 	}
 
@@ -1716,6 +1901,16 @@ func (s *OpenAIGatewayService) RecordUsage(ctx context.Context, input *OpenAIRec
 		usageLog.IPAddress = &input.IPAddress
 	}
 
+	// 添加粘性会话哈希
+	usageLog.SessionHash = usageSessionHash(input.SessionHash)
+	usageLog.applyCostRouting(input.CostRouting)
+
+	// 计入账号 TPM 预算
+	s.rateBudgetService.RecordTokens(ctx, account, usageLog.TotalTokens())
+	// 成功请求计入账号延迟/错误率评分与熔断统计
+	s.scoringService.RecordSuccess(ctx, account.ID, result.FirstTokenMs)
+	s.rateLimitService.HandleUpstreamSuccess(ctx, account)
+
 	if apiKey.GroupID != nil {
 		usageLog.GroupID = apiKey.GroupID
 	}
@@ -1740,11 +1935,21 @@ func (s *OpenAIGatewayService) RecordUsage(ctx context.Context, input *OpenAIRec
 		}
 	} else {
 		if shouldBill && cost.ActualCost > 0 {
-			_ = s.userRepo.DeductBalance(ctx, user.ID, cost.ActualCost)
-			s.billingCacheService.QueueDeductBalance(user.ID, cost.ActualCost)
+			_ = s.userRepo.ApplyBalanceEntry(ctx, usageBalanceEntry(user.ID, cost.ActualCost, usageLog, inserted))
+			if input.BalanceHold != nil {
+				s.billingCacheService.SettleBalanceHold(input.BalanceHold, cost.ActualCost)
+				holdSettled = true
+			} else {
+				s.billingCacheService.QueueDeductBalance(user.ID, cost.ActualCost)
+			}
 		}
 	}
 
+	// API Key 消费限额计数（按实际扣费金额累计；请求数已在准入时计入）
+	if shouldBill && apiKey.Limits.HasQuota() {
+		s.billingCacheService.QueueAPIKeyUsage(apiKey.ID, cost.ActualCost)
+	}
+
 	// Schedule batch update for account last_used_at
 	s.deferredService.ScheduleLastUsedUpdate(account.ID)
 
@@ -0,0 +1,476 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/antigravity"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/openai"
+	"github.com/Wei-Shaw/sub2api/internal/util/responseheaders"
+	"github.com/gin-gonic/gin"
+)
+
+// claudeCompatStreamResult Claude 兼容转发的流处理结果
+type claudeCompatStreamResult struct {
+	usage        *ClaudeUsage
+	firstTokenMs *int
+}
+
+// ForwardAsClaude 将 Claude Messages 请求转换为 Responses 请求后转发到 OpenAI 账号，
+// 并将响应转换回 Claude 格式。用于 anthropic 分组混合调度 OpenAI 账号的场景。
+// 上游统一使用流式请求，客户端非流式请求在服务端收集完整响应后返回。
+func (s *OpenAIGatewayService) ForwardAsClaude(ctx context.Context, c *gin.Context, account *Account, body []byte) (*ForwardResult, error) {
+	startTime := time.Now()
+
+	var claudeReq antigravity.ClaudeRequest
+	if err := json.Unmarshal(body, &claudeReq); err != nil {
+		return nil, s.writeClaudeCompatError(c, http.StatusBadRequest, "invalid_request_error", "Failed to parse request body")
+	}
+	if strings.TrimSpace(claudeReq.Model) == "" {
+		return nil, s.writeClaudeCompatError(c, http.StatusBadRequest, "invalid_request_error", "model is required")
+	}
+
+	originalModel := claudeReq.Model
+	mappedModel := normalizeCodexModel(account.GetMappedModel(originalModel))
+	if mappedModel != originalModel {
+		log.Printf("[OpenAI] Claude compat model mapping applied: %s -> %s (account: %s)", originalModel, mappedModel, account.Name)
+	}
+
+	responsesBody, err := openai.TransformClaudeToResponses(&claudeReq, mappedModel)
+	if err != nil {
+		return nil, s.writeClaudeCompatError(c, http.StatusBadRequest, "invalid_request_error", err.Error())
+	}
+
+	var reqBody map[string]any
+	if err := json.Unmarshal(responsesBody, &reqBody); err != nil {
+		return nil, fmt.Errorf("parse transformed request: %w", err)
+	}
+	promptCacheKey := ""
+	if account.Type == AccountTypeOAuth {
+		codexResult := applyCodexOAuthTransform(reqBody)
+		if codexResult.NormalizedModel != "" {
+			mappedModel = codexResult.NormalizedModel
+		}
+		promptCacheKey = codexResult.PromptCacheKey
+	} else {
+		// 与 Forward 保持一致：API Key 账号不透传 max_output_tokens
+		delete(reqBody, "max_output_tokens")
+	}
+	upstreamBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("serialize request body: %w", err)
+	}
+
+	token, _, err := s.GetAccessToken(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+
+	upstreamReq, err := s.buildUpstreamRequest(ctx, c, account, upstreamBody, token, true, promptCacheKey, false)
+	if err != nil {
+		return nil, err
+	}
+	upstreamReq.Header.Set("accept", "text/event-stream")
+
+	proxyURL := ""
+	if account.ProxyID != nil && account.Proxy != nil {
+		proxyURL = account.Proxy.URL()
+	}
+
+	// Capture upstream request body for ops retry of this attempt.
+	c.Set(OpsUpstreamRequestBodyKey, string(upstreamBody))
+
+	resp, err := s.httpUpstream.Do(upstreamReq, proxyURL, account.ID, account.Concurrency)
+	if err != nil {
+		s.rateLimitService.HandleUpstreamRequestError(ctx, account, err)
+		safeErr := sanitizeUpstreamErrorMessage(err.Error())
+		setOpsUpstreamError(c, 0, safeErr, "")
+		appendOpsUpstreamError(c, OpsUpstreamErrorEvent{
+			Platform:           account.Platform,
+			AccountID:          account.ID,
+			AccountName:        account.Name,
+			UpstreamStatusCode: 0,
+			Kind:               "request_error",
+			Message:            safeErr,
+		})
+		_ = s.writeClaudeCompatError(c, http.StatusBadGateway, "upstream_error", "Upstream request failed")
+		return nil, fmt.Errorf("upstream request failed: %s", safeErr)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= 400 {
+		if s.shouldFailoverUpstreamError(resp.StatusCode) {
+			respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 2<<20))
+			_ = resp.Body.Close()
+			resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+			upstreamMsg := strings.TrimSpace(extractUpstreamErrorMessage(respBody))
+			upstreamMsg = sanitizeUpstreamErrorMessage(upstreamMsg)
+			appendOpsUpstreamError(c, OpsUpstreamErrorEvent{
+				Platform:           account.Platform,
+				AccountID:          account.ID,
+				AccountName:        account.Name,
+				UpstreamStatusCode: resp.StatusCode,
+				UpstreamRequestID:  resp.Header.Get("x-request-id"),
+				Kind:               "failover",
+				Message:            upstreamMsg,
+				Detail:             s.upstreamErrorDetail(respBody),
+			})
+
+			s.handleFailoverSideEffects(ctx, resp, account)
+			return nil, &UpstreamFailoverError{StatusCode: resp.StatusCode}
+		}
+		return nil, s.handleClaudeCompatErrorResponse(ctx, resp, c, account)
+	}
+
+	if s.cfg != nil {
+		responseheaders.WriteFilteredHeaders(c.Writer.Header(), resp.Header, s.cfg.Security.ResponseHeaders)
+	}
+	requestID := resp.Header.Get("x-request-id")
+	if requestID != "" {
+		c.Header("x-request-id", requestID)
+	}
+
+	var streamResult *claudeCompatStreamResult
+	if claudeReq.Stream {
+		streamResult, err = s.handleClaudeCompatStreamingResponse(ctx, resp, c, account, startTime, originalModel)
+	} else {
+		streamResult, err = s.handleClaudeCompatNonStreamingResponse(resp, c, startTime, originalModel)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if account.Type == AccountTypeOAuth {
+		if snapshot := ParseCodexRateLimitHeaders(resp.Header); snapshot != nil {
+			s.updateCodexUsageSnapshot(ctx, account.ID, snapshot)
+		}
+	}
+
+	return &ForwardResult{
+		RequestID:    requestID,
+		Usage:        *streamResult.usage,
+		Model:        originalModel, // 使用原始模型用于计费和日志
+		Stream:       claudeReq.Stream,
+		Duration:     time.Since(startTime),
+		FirstTokenMs: streamResult.firstTokenMs,
+	}, nil
+}
+
+// upstreamErrorDetail 按配置截取上游错误响应体用于 ops 记录
+func (s *OpenAIGatewayService) upstreamErrorDetail(body []byte) string {
+	if s.cfg == nil || !s.cfg.Gateway.LogUpstreamErrorBody {
+		return ""
+	}
+	maxBytes := s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes
+	if maxBytes <= 0 {
+		maxBytes = 2048
+	}
+	return truncateString(string(body), maxBytes)
+}
+
+// handleClaudeCompatErrorResponse 处理非 failover 的上游错误，返回 Claude 格式错误响应
+func (s *OpenAIGatewayService) handleClaudeCompatErrorResponse(ctx context.Context, resp *http.Response, c *gin.Context, account *Account) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 2<<20))
+
+	upstreamMsg := strings.TrimSpace(extractUpstreamErrorMessage(body))
+	upstreamMsg = sanitizeUpstreamErrorMessage(upstreamMsg)
+	upstreamDetail := s.upstreamErrorDetail(body)
+	setOpsUpstreamError(c, resp.StatusCode, upstreamMsg, upstreamDetail)
+
+	if !account.ShouldHandleErrorCode(resp.StatusCode) {
+		appendOpsUpstreamError(c, OpsUpstreamErrorEvent{
+			Platform:           account.Platform,
+			AccountID:          account.ID,
+			AccountName:        account.Name,
+			UpstreamStatusCode: resp.StatusCode,
+			UpstreamRequestID:  resp.Header.Get("x-request-id"),
+			Kind:               "http_error",
+			Message:            upstreamMsg,
+			Detail:             upstreamDetail,
+		})
+		_ = s.writeClaudeCompatError(c, http.StatusInternalServerError, "upstream_error", "Upstream gateway error")
+		return fmt.Errorf("upstream error: %d (not in custom error codes)", resp.StatusCode)
+	}
+
+	shouldDisable := false
+	if s.rateLimitService != nil {
+		shouldDisable = s.rateLimitService.HandleUpstreamError(ctx, account, resp.StatusCode, resp.Header, body)
+	}
+	kind := "http_error"
+	if shouldDisable {
+		kind = "failover"
+	}
+	appendOpsUpstreamError(c, OpsUpstreamErrorEvent{
+		Platform:           account.Platform,
+		AccountID:          account.ID,
+		AccountName:        account.Name,
+		UpstreamStatusCode: resp.StatusCode,
+		UpstreamRequestID:  resp.Header.Get("x-request-id"),
+		Kind:               kind,
+		Message:            upstreamMsg,
+		Detail:             upstreamDetail,
+	})
+	if shouldDisable {
+		return &UpstreamFailoverError{StatusCode: resp.StatusCode}
+	}
+
+	var statusCode int
+	var errType, errMsg string
+	switch resp.StatusCode {
+	case 400:
+		statusCode = http.StatusBadRequest
+		errType = "invalid_request_error"
+		errMsg = "Invalid request"
+		if upstreamMsg != "" {
+			errMsg = upstreamMsg
+		}
+	case 404:
+		statusCode = http.StatusNotFound
+		errType = "not_found_error"
+		errMsg = "Upstream model not found"
+	case 429:
+		statusCode = http.StatusTooManyRequests
+		errType = "rate_limit_error"
+		errMsg = "Upstream rate limit exceeded, please retry later"
+	default:
+		statusCode = http.StatusBadGateway
+		errType = "upstream_error"
+		errMsg = "Upstream request failed"
+	}
+	_ = s.writeClaudeCompatError(c, statusCode, errType, errMsg)
+
+	if upstreamMsg == "" {
+		return fmt.Errorf("upstream error: %d", resp.StatusCode)
+	}
+	return fmt.Errorf("upstream error: %d message=%s", resp.StatusCode, upstreamMsg)
+}
+
+// writeClaudeCompatError 写出 Claude 格式错误响应
+func (s *OpenAIGatewayService) writeClaudeCompatError(c *gin.Context, status int, errType, message string) error {
+	c.JSON(status, gin.H{
+		"type":  "error",
+		"error": gin.H{"type": errType, "message": message},
+	})
+	return fmt.Errorf("%s", message)
+}
+
+func claudeUsageFromAntigravity(usage *antigravity.ClaudeUsage) *ClaudeUsage {
+	if usage == nil {
+		return &ClaudeUsage{}
+	}
+	return &ClaudeUsage{
+		InputTokens:              usage.InputTokens,
+		OutputTokens:             usage.OutputTokens,
+		CacheCreationInputTokens: usage.CacheCreationInputTokens,
+		CacheReadInputTokens:     usage.CacheReadInputTokens,
+	}
+}
+
+// handleClaudeCompatNonStreamingResponse 收集上游流式响应，转换为 Claude 非流式响应
+func (s *OpenAIGatewayService) handleClaudeCompatNonStreamingResponse(resp *http.Response, c *gin.Context, startTime time.Time, originalModel string) (*claudeCompatStreamResult, error) {
+	scanner := bufio.NewScanner(resp.Body)
+	maxLineSize := defaultMaxLineSize
+	if s.cfg != nil && s.cfg.Gateway.MaxLineSize > 0 {
+		maxLineSize = s.cfg.Gateway.MaxLineSize
+	}
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+
+	processor := openai.NewClaudeStreamingProcessor(originalModel)
+	var firstTokenMs *int
+	for scanner.Scan() {
+		if out := processor.ProcessLine(scanner.Text()); len(out) > 0 && firstTokenMs == nil {
+			ms := int(time.Since(startTime).Milliseconds())
+			firstTokenMs = &ms
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			log.Printf("SSE line too long (openai claude compat non-stream): max_size=%d error=%v", maxLineSize, err)
+		}
+		_ = s.writeClaudeCompatError(c, http.StatusBadGateway, "upstream_error", "Failed to read upstream response")
+		return nil, fmt.Errorf("stream read error: %w", err)
+	}
+	_, agUsage := processor.Finish()
+
+	if err := processor.Err(); err != nil {
+		_ = s.writeClaudeCompatError(c, http.StatusBadGateway, "upstream_error", "Upstream response failed")
+		return nil, err
+	}
+	if !processor.HasContent() {
+		log.Printf("[OpenAI] claude compat warning: empty stream response, no valid events received")
+		_ = s.writeClaudeCompatError(c, http.StatusBadGateway, "upstream_error", "Empty response from upstream")
+		return nil, errors.New("empty response from upstream")
+	}
+
+	c.JSON(http.StatusOK, processor.BuildResponse())
+	return &claudeCompatStreamResult{usage: claudeUsageFromAntigravity(agUsage), firstTokenMs: firstTokenMs}, nil
+}
+
+// handleClaudeCompatStreamingResponse 处理流式响应（Responses SSE → Claude SSE 转换）
+func (s *OpenAIGatewayService) handleClaudeCompatStreamingResponse(ctx context.Context, resp *http.Response, c *gin.Context, account *Account, startTime time.Time, originalModel string) (*claudeCompatStreamResult, error) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	w := c.Writer
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, errors.New("streaming not supported")
+	}
+
+	processor := openai.NewClaudeStreamingProcessor(originalModel)
+	var firstTokenMs *int
+	scanner := bufio.NewScanner(resp.Body)
+	maxLineSize := defaultMaxLineSize
+	if s.cfg != nil && s.cfg.Gateway.MaxLineSize > 0 {
+		maxLineSize = s.cfg.Gateway.MaxLineSize
+	}
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+
+	type scanEvent struct {
+		line string
+		err  error
+	}
+	// 独立 goroutine 读取上游，避免读取阻塞影响 keepalive/超时处理
+	events := make(chan scanEvent, 16)
+	done := make(chan struct{})
+	sendEvent := func(ev scanEvent) bool {
+		select {
+		case events <- ev:
+			return true
+		case <-done:
+			return false
+		}
+	}
+	var lastReadAt int64
+	atomic.StoreInt64(&lastReadAt, time.Now().UnixNano())
+	go func() {
+		defer close(events)
+		for scanner.Scan() {
+			atomic.StoreInt64(&lastReadAt, time.Now().UnixNano())
+			if !sendEvent(scanEvent{line: scanner.Text()}) {
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			_ = sendEvent(scanEvent{err: err})
+		}
+	}()
+	defer close(done)
+
+	streamInterval := time.Duration(0)
+	if s.cfg != nil && s.cfg.Gateway.StreamDataIntervalTimeout > 0 {
+		streamInterval = time.Duration(s.cfg.Gateway.StreamDataIntervalTimeout) * time.Second
+	}
+	var intervalTicker *time.Ticker
+	if streamInterval > 0 {
+		intervalTicker = time.NewTicker(streamInterval)
+		defer intervalTicker.Stop()
+	}
+	var intervalCh <-chan time.Time
+	if intervalTicker != nil {
+		intervalCh = intervalTicker.C
+	}
+
+	keepaliveInterval := time.Duration(0)
+	if s.cfg != nil && s.cfg.Gateway.StreamKeepaliveInterval > 0 {
+		keepaliveInterval = time.Duration(s.cfg.Gateway.StreamKeepaliveInterval) * time.Second
+	}
+	var keepaliveTicker *time.Ticker
+	if keepaliveInterval > 0 {
+		keepaliveTicker = time.NewTicker(keepaliveInterval)
+		defer keepaliveTicker.Stop()
+	}
+	var keepaliveCh <-chan time.Time
+	if keepaliveTicker != nil {
+		keepaliveCh = keepaliveTicker.C
+	}
+	lastDataAt := time.Now()
+
+	// 仅发送一次错误事件，避免多次写入导致协议混乱
+	errorEventSent := false
+	sendErrorEvent := func(reason string) {
+		if errorEventSent {
+			return
+		}
+		errorEventSent = true
+		_, _ = fmt.Fprintf(w, "event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"api_error\",\"message\":\"%s\"}}\n\n", reason)
+		flusher.Flush()
+	}
+
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				finalEvents, agUsage := processor.Finish()
+				if len(finalEvents) > 0 {
+					_, _ = w.Write(finalEvents)
+					flusher.Flush()
+				}
+				return &claudeCompatStreamResult{usage: claudeUsageFromAntigravity(agUsage), firstTokenMs: firstTokenMs}, nil
+			}
+			if ev.err != nil {
+				_, agUsage := processor.Finish()
+				if errors.Is(ev.err, bufio.ErrTooLong) {
+					log.Printf("SSE line too long (openai claude compat): account=%d max_size=%d error=%v", account.ID, maxLineSize, ev.err)
+					sendErrorEvent("response_too_large")
+					return &claudeCompatStreamResult{usage: claudeUsageFromAntigravity(agUsage), firstTokenMs: firstTokenMs}, ev.err
+				}
+				sendErrorEvent("stream_read_error")
+				return &claudeCompatStreamResult{usage: claudeUsageFromAntigravity(agUsage), firstTokenMs: firstTokenMs}, fmt.Errorf("stream read error: %w", ev.err)
+			}
+
+			lastDataAt = time.Now()
+			claudeEvents := processor.ProcessLine(ev.line)
+			if len(claudeEvents) == 0 {
+				continue
+			}
+			if firstTokenMs == nil {
+				ms := int(time.Since(startTime).Milliseconds())
+				firstTokenMs = &ms
+			}
+			if _, err := w.Write(claudeEvents); err != nil {
+				_, agUsage := processor.Finish()
+				return &claudeCompatStreamResult{usage: claudeUsageFromAntigravity(agUsage), firstTokenMs: firstTokenMs}, err
+			}
+			flusher.Flush()
+
+		case <-intervalCh:
+			lastRead := time.Unix(0, atomic.LoadInt64(&lastReadAt))
+			if time.Since(lastRead) < streamInterval {
+				continue
+			}
+			log.Printf("Stream data interval timeout (openai claude compat): account=%d model=%s interval=%s", account.ID, originalModel, streamInterval)
+			if s.rateLimitService != nil {
+				s.rateLimitService.HandleStreamTimeout(ctx, account, originalModel)
+			}
+			sendErrorEvent("stream_timeout")
+			_, agUsage := processor.Finish()
+			return &claudeCompatStreamResult{usage: claudeUsageFromAntigravity(agUsage), firstTokenMs: firstTokenMs}, fmt.Errorf("stream data interval timeout")
+
+		case <-keepaliveCh:
+			if time.Since(lastDataAt) < keepaliveInterval {
+				continue
+			}
+			// Claude 客户端可识别 ping 事件
+			if _, err := fmt.Fprint(w, "event: ping\ndata: {\"type\":\"ping\"}\n\n"); err != nil {
+				_, agUsage := processor.Finish()
+				return &claudeCompatStreamResult{usage: claudeUsageFromAntigravity(agUsage), firstTokenMs: firstTokenMs}, err
+			}
+			flusher.Flush()
+		}
+	}
+}
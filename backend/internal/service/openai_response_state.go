@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	"github.com/tidwall/gjson"
+)
+
+// openaiResponseSessionPrefix previous_response_id 粘性会话键前缀
+const openaiResponseSessionPrefix = "resp:"
+
+// OpenAIResponseState 网关保存的单轮 Responses 状态
+//
+// 只保存本轮输入与输出（而非完整上下文），还原时沿 PreviousResponseID 回溯拼接，避免存储随对话长度平方增长。
+type OpenAIResponseState struct {
+	ResponseID         string          `json:"response_id"`
+	PreviousResponseID string          `json:"previous_response_id,omitempty"`
+	UserID             int64           `json:"user_id"`
+	AccountID          int64           `json:"account_id"`
+	Input              json.RawMessage `json:"input"`
+	Output             json.RawMessage `json:"output"`
+	CreatedAt          time.Time       `json:"created_at"`
+}
+
+// OpenAIResponseStateCache 响应状态存储（按 response id，带 TTL）
+type OpenAIResponseStateCache interface {
+	// GetResponseState 不存在时返回 nil, nil
+	GetResponseState(ctx context.Context, responseID string) (*OpenAIResponseState, error)
+	SetResponseState(ctx context.Context, state *OpenAIResponseState, ttl time.Duration) error
+}
+
+// responseStateTurn 本轮续链信息，Forward 在改写请求前提取
+type responseStateTurn struct {
+	userID             int64
+	previousResponseID string
+	input              json.RawMessage
+	store              bool
+}
+
+func (s *OpenAIGatewayService) responseStateEnabled() bool {
+	return s.responseStateCache != nil && s.cfg != nil && s.cfg.Gateway.OpenAIResponseState.Enabled
+}
+
+// newResponseStateTurn 提取本轮输入（规范化为数组）与 previous_response_id；未启用或无法识别用户时返回 nil
+func (s *OpenAIGatewayService) newResponseStateTurn(ctx context.Context, reqBody map[string]any) *responseStateTurn {
+	if !s.responseStateEnabled() {
+		return nil
+	}
+	userID, _ := ctx.Value(ctxkey.UserID).(int64)
+	if userID <= 0 {
+		return nil
+	}
+	turn := &responseStateTurn{userID: userID, store: true}
+	if v, ok := reqBody["store"].(bool); ok && !v {
+		turn.store = false
+	}
+	turn.previousResponseID, _ = reqBody["previous_response_id"].(string)
+	turn.previousResponseID = strings.TrimSpace(turn.previousResponseID)
+	if input := normalizeResponsesInput(reqBody["input"]); input != nil {
+		if raw, err := json.Marshal(input); err == nil {
+			turn.input = raw
+		}
+	}
+	return turn
+}
+
+// rehydrateResponseInput 当 previous_response_id 由其他账号生成（或账号为不保存状态的 OAuth 账号）时，
+// 在本地还原完整输入链并移除 previous_response_id。无法还原（状态缺失/过期/越权）时保持原样转发。
+func (s *OpenAIGatewayService) rehydrateResponseInput(ctx context.Context, turn *responseStateTurn, account *Account, reqBody map[string]any) bool {
+	if turn == nil || turn.previousResponseID == "" {
+		return false
+	}
+	head, err := s.responseStateCache.GetResponseState(ctx, turn.previousResponseID)
+	if err != nil {
+		log.Printf("[OpenAI] Load response state %s failed: %v", turn.previousResponseID, err)
+		return false
+	}
+	if head == nil || head.UserID != turn.userID {
+		return false
+	}
+	if head.AccountID == account.ID && account.Type != AccountTypeOAuth {
+		// 上游账号持有该响应，直接续链
+		return false
+	}
+
+	chain := []*OpenAIResponseState{head}
+	maxDepth := s.cfg.Gateway.OpenAIResponseState.MaxChainDepth
+	for prev := head.PreviousResponseID; prev != ""; {
+		if len(chain) >= maxDepth {
+			log.Printf("[OpenAI] Response chain of %s exceeds max depth %d, forwarding as-is", turn.previousResponseID, maxDepth)
+			return false
+		}
+		state, err := s.responseStateCache.GetResponseState(ctx, prev)
+		if err != nil || state == nil || state.UserID != turn.userID {
+			log.Printf("[OpenAI] Response chain of %s broken at %s, forwarding as-is", turn.previousResponseID, prev)
+			return false
+		}
+		chain = append(chain, state)
+		prev = state.PreviousResponseID
+	}
+
+	items := make([]any, 0)
+	for i := len(chain) - 1; i >= 0; i-- {
+		items = appendRehydratedItems(items, chain[i].Input)
+		items = appendRehydratedItems(items, chain[i].Output)
+	}
+	if current := normalizeResponsesInput(reqBody["input"]); current != nil {
+		items = append(items, current...)
+	}
+	reqBody["input"] = items
+	delete(reqBody, "previous_response_id")
+	log.Printf("[OpenAI] Rehydrated previous_response_id=%s (%d turns, %d items) for account %d", turn.previousResponseID, len(chain), len(items), account.ID)
+	return true
+}
+
+// saveResponseState 保存本轮输入与上游最终 response 的 output
+func (s *OpenAIGatewayService) saveResponseState(ctx context.Context, turn *responseStateTurn, account *Account, response []byte) {
+	if turn == nil || !turn.store || len(response) == 0 {
+		return
+	}
+	parsed := gjson.ParseBytes(response)
+	responseID := parsed.Get("id").String()
+	output := parsed.Get("output")
+	if responseID == "" || !output.IsArray() {
+		return
+	}
+	cfg := s.cfg.Gateway.OpenAIResponseState
+	if len(turn.input)+len(output.Raw) > cfg.MaxBytes {
+		log.Printf("[OpenAI] Response state %s exceeds max_bytes, not stored", responseID)
+		return
+	}
+	state := &OpenAIResponseState{
+		ResponseID:         responseID,
+		PreviousResponseID: turn.previousResponseID,
+		UserID:             turn.userID,
+		AccountID:          account.ID,
+		Input:              turn.input,
+		Output:             json.RawMessage(output.Raw),
+		CreatedAt:          time.Now(),
+	}
+	if err := s.responseStateCache.SetResponseState(ctx, state, time.Duration(cfg.TTLSeconds)*time.Second); err != nil {
+		log.Printf("[OpenAI] Save response state %s failed: %v", responseID, err)
+	}
+}
+
+// BindResponseAffinity 请求携带 previous_response_id 时将粘性会话绑定到生成该响应的账号并返回会话键，
+// 优先保持在同一账号（命中上游缓存且无需还原）；未找到状态时返回空串。
+func (s *OpenAIGatewayService) BindResponseAffinity(ctx context.Context, groupID *int64, userID int64, reqBody map[string]any) string {
+	if !s.responseStateEnabled() {
+		return ""
+	}
+	prevID, _ := reqBody["previous_response_id"].(string)
+	prevID = strings.TrimSpace(prevID)
+	if prevID == "" {
+		return ""
+	}
+	state, err := s.responseStateCache.GetResponseState(ctx, prevID)
+	if err != nil || state == nil || state.UserID != userID {
+		return ""
+	}
+	sessionKey := openaiResponseSessionPrefix + prevID
+	if err := s.BindStickySession(ctx, groupID, sessionKey, state.AccountID); err != nil {
+		log.Printf("[OpenAI] Bind response affinity failed: response=%s account=%d err=%v", prevID, state.AccountID, err)
+		return ""
+	}
+	return sessionKey
+}
+
+// normalizeResponsesInput 将 input 规范化为 item 数组（字符串输入视为单条 user 消息）
+func normalizeResponsesInput(input any) []any {
+	switch v := input.(type) {
+	case string:
+		return []any{map[string]any{"type": "message", "role": "user", "content": v}}
+	case []any:
+		return v
+	default:
+		return nil
+	}
+}
+
+// appendRehydratedItems 追加历史 item：移除仅在原账号有效的 item id 与 item_reference，
+// 丢弃无 encrypted_content 的 reasoning（其他账号无法引用）
+func appendRehydratedItems(items []any, raw json.RawMessage) []any {
+	if len(raw) == 0 {
+		return items
+	}
+	var list []map[string]any
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return items
+	}
+	for _, item := range list {
+		typ, _ := item["type"].(string)
+		switch typ {
+		case "item_reference":
+			continue
+		case "reasoning":
+			if enc, _ := item["encrypted_content"].(string); enc == "" {
+				continue
+			}
+		}
+		delete(item, "id")
+		delete(item, "status")
+		items = append(items, item)
+	}
+	return items
+}
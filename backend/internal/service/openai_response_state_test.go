@@ -0,0 +1,137 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	"github.com/stretchr/testify/require"
+)
+
+type stubResponseStateCache struct {
+	states map[string]*OpenAIResponseState
+}
+
+func (c *stubResponseStateCache) GetResponseState(ctx context.Context, responseID string) (*OpenAIResponseState, error) {
+	return c.states[responseID], nil
+}
+
+func (c *stubResponseStateCache) SetResponseState(ctx context.Context, state *OpenAIResponseState, ttl time.Duration) error {
+	c.states[state.ResponseID] = state
+	return nil
+}
+
+func newResponseStateTestService() (*OpenAIGatewayService, *stubResponseStateCache) {
+	cfg := &config.Config{}
+	cfg.Gateway.OpenAIResponseState = config.GatewayOpenAIResponseStateConfig{
+		Enabled:       true,
+		TTLSeconds:    3600,
+		MaxBytes:      1 << 20,
+		MaxChainDepth: 10,
+	}
+	cache := &stubResponseStateCache{states: map[string]*OpenAIResponseState{}}
+	return &OpenAIGatewayService{cfg: cfg, responseStateCache: cache}, cache
+}
+
+func parseReqBody(t *testing.T, raw string) map[string]any {
+	var body map[string]any
+	require.NoError(t, json.Unmarshal([]byte(raw), &body))
+	return body
+}
+
+func TestOpenAIResponseState_SaveAndRehydrateAcrossAccounts(t *testing.T) {
+	svc, cache := newResponseStateTestService()
+	ctx := context.WithValue(context.Background(), ctxkey.UserID, int64(42))
+	accountA := &Account{ID: 1, Type: AccountTypeAPIKey}
+	accountB := &Account{ID: 2, Type: AccountTypeAPIKey}
+
+	// 第一轮：字符串输入，账号 A 生成 resp_1
+	first := parseReqBody(t, `{"model":"gpt-5","input":"hello"}`)
+	turn1 := svc.newResponseStateTurn(ctx, first)
+	require.False(t, svc.rehydrateResponseInput(ctx, turn1, accountA, first))
+	svc.saveResponseState(ctx, turn1, accountA, []byte(`{"id":"resp_1","output":[
+		{"type":"reasoning","id":"rs_1","summary":[]},
+		{"type":"message","id":"msg_1","status":"completed","role":"assistant","content":[{"type":"output_text","text":"hi"}]}]}`))
+	require.Contains(t, cache.states, "resp_1")
+
+	// 第二轮：账号 A 续链，原样转发
+	second := parseReqBody(t, `{"model":"gpt-5","previous_response_id":"resp_1","input":[{"role":"user","content":"how are you"}]}`)
+	turn2 := svc.newResponseStateTurn(ctx, second)
+	require.False(t, svc.rehydrateResponseInput(ctx, turn2, accountA, second))
+	require.Equal(t, "resp_1", second["previous_response_id"])
+	svc.saveResponseState(ctx, turn2, accountA, []byte(`{"id":"resp_2","output":[{"type":"message","id":"msg_2","role":"assistant","content":[{"type":"output_text","text":"fine"}]}]}`))
+
+	// 第三轮：调度到账号 B，本地还原完整输入链
+	third := parseReqBody(t, `{"model":"gpt-5","previous_response_id":"resp_2","input":"bye"}`)
+	turn3 := svc.newResponseStateTurn(ctx, third)
+	require.True(t, svc.rehydrateResponseInput(ctx, turn3, accountB, third))
+	require.NotContains(t, third, "previous_response_id")
+
+	items := third["input"].([]any)
+	require.Len(t, items, 5)
+	roles := make([]string, 0, len(items))
+	for _, item := range items {
+		m := item.(map[string]any)
+		require.NotContains(t, m, "id")
+		roles = append(roles, m["role"].(string))
+	}
+	// 无 encrypted_content 的 reasoning 被丢弃
+	require.Equal(t, []string{"user", "assistant", "user", "assistant", "user"}, roles)
+
+	// 还原后的请求保存时仍记录逻辑上的 previous_response_id
+	svc.saveResponseState(ctx, turn3, accountB, []byte(`{"id":"resp_3","output":[]}`))
+	require.Equal(t, "resp_2", cache.states["resp_3"].PreviousResponseID)
+	require.Equal(t, int64(2), cache.states["resp_3"].AccountID)
+}
+
+func TestOpenAIResponseState_OAuthAccountAlwaysRehydrates(t *testing.T) {
+	svc, cache := newResponseStateTestService()
+	ctx := context.WithValue(context.Background(), ctxkey.UserID, int64(42))
+	cache.states["resp_1"] = &OpenAIResponseState{ResponseID: "resp_1", UserID: 42, AccountID: 1,
+		Input: json.RawMessage(`[{"role":"user","content":"hello"}]`), Output: json.RawMessage(`[]`)}
+
+	body := parseReqBody(t, `{"previous_response_id":"resp_1","input":"again"}`)
+	turn := svc.newResponseStateTurn(ctx, body)
+	require.True(t, svc.rehydrateResponseInput(ctx, turn, &Account{ID: 1, Type: AccountTypeOAuth}, body))
+	require.Len(t, body["input"].([]any), 2)
+}
+
+func TestOpenAIResponseState_ForwardsAsIsWhenChainUnavailable(t *testing.T) {
+	svc, cache := newResponseStateTestService()
+	ctx := context.WithValue(context.Background(), ctxkey.UserID, int64(42))
+	other := &Account{ID: 9, Type: AccountTypeAPIKey}
+
+	// 其他用户的响应不可还原
+	cache.states["resp_foreign"] = &OpenAIResponseState{ResponseID: "resp_foreign", UserID: 7, AccountID: 1}
+	body := parseReqBody(t, `{"previous_response_id":"resp_foreign","input":"x"}`)
+	require.False(t, svc.rehydrateResponseInput(ctx, svc.newResponseStateTurn(ctx, body), other, body))
+	require.Equal(t, "resp_foreign", body["previous_response_id"])
+
+	// 链中间状态已过期
+	cache.states["resp_tail"] = &OpenAIResponseState{ResponseID: "resp_tail", PreviousResponseID: "resp_gone", UserID: 42, AccountID: 1}
+	body = parseReqBody(t, `{"previous_response_id":"resp_tail","input":"x"}`)
+	require.False(t, svc.rehydrateResponseInput(ctx, svc.newResponseStateTurn(ctx, body), other, body))
+
+	// 缺少用户上下文时不处理
+	require.Nil(t, svc.newResponseStateTurn(context.Background(), body))
+}
+
+func TestOpenAIResponseState_SkipsStoreFalseAndOversized(t *testing.T) {
+	svc, cache := newResponseStateTestService()
+	ctx := context.WithValue(context.Background(), ctxkey.UserID, int64(42))
+	account := &Account{ID: 1, Type: AccountTypeAPIKey}
+
+	body := parseReqBody(t, `{"store":false,"input":"hello"}`)
+	svc.saveResponseState(ctx, svc.newResponseStateTurn(ctx, body), account, []byte(`{"id":"resp_1","output":[]}`))
+	require.NotContains(t, cache.states, "resp_1")
+
+	svc.cfg.Gateway.OpenAIResponseState.MaxBytes = 10
+	body = parseReqBody(t, `{"input":"a long enough input"}`)
+	svc.saveResponseState(ctx, svc.newResponseStateTurn(ctx, body), account, []byte(`{"id":"resp_2","output":[]}`))
+	require.NotContains(t, cache.states, "resp_2")
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"log"
 	"time"
 )
 
@@ -41,6 +42,16 @@ func (s *OpsService) GetAccountAvailabilityStats(ctx context.Context, platformFi
 	now := time.Now()
 	collectedAt := now
 
+	accountIDs := make([]int64, 0, len(accounts))
+	for _, acc := range accounts {
+		accountIDs = append(accountIDs, acc.ID)
+	}
+	circuitStates, err := s.circuitBreaker.GetStates(ctx, accountIDs)
+	if err != nil {
+		log.Printf("[Ops] load circuit breaker states failed: %v", err)
+		circuitStates = nil
+	}
+
 	platform := make(map[string]*PlatformAvailability)
 	group := make(map[int64]*GroupAvailability)
 	account := make(map[int64]*AccountAvailability)
@@ -151,6 +162,17 @@ func (s *OpsService) GetAccountAvailabilityStats(ctx context.Context, platformFi
 			item.TempUnschedulableUntil = acc.TempUnschedulableUntil
 		}
 
+		if circuitStates != nil {
+			item.CircuitState = CircuitStateClosed
+			if state := circuitStates[acc.ID]; state != nil {
+				item.CircuitState = state.State
+				if state.State == CircuitStateOpen && state.OpenUntilUnix > 0 {
+					openUntil := time.Unix(state.OpenUntilUnix, 0)
+					item.CircuitOpenUntil = &openUntil
+				}
+			}
+		}
+
 		account[acc.ID] = item
 	}
 
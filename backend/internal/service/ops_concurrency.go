@@ -252,6 +252,29 @@ func (s *OpsService) GetConcurrencyStats(
 			info.LoadPercentage = float64(info.CurrentInUse) / float64(info.MaxCapacity) * 100
 		}
 	}
+	s.fillFairQueueStatsBestEffort(ctx, group)
 
 	return platform, group, account, &collectedAt, nil
 }
+
+func (s *OpsService) fillFairQueueStatsBestEffort(ctx context.Context, group map[int64]*GroupConcurrencyInfo) {
+	if s == nil || s.concurrencyService == nil || len(group) == 0 {
+		return
+	}
+	groupIDs := make([]int64, 0, len(group))
+	for id := range group {
+		groupIDs = append(groupIDs, id)
+	}
+	stats, err := s.concurrencyService.GetFairQueueStats(ctx, groupIDs)
+	if err != nil {
+		// Best-effort: keep zeros rather than failing the ops UI.
+		log.Printf("[Ops] GetFairQueueStats failed: %v", err)
+		return
+	}
+	for id, st := range stats {
+		if info, ok := group[id]; ok && st != nil {
+			info.FairQueueWaiting = st.Waiting
+			info.FairQueueUsers = st.Users
+		}
+	}
+}
@@ -22,6 +22,9 @@ type GroupConcurrencyInfo struct {
 	MaxCapacity    int64   `json:"max_capacity"`
 	LoadPercentage float64 `json:"load_percentage"`
 	WaitingInQueue int64   `json:"waiting_in_queue"`
+	// 公平队列中等待账号槽位的请求数与用户数
+	FairQueueWaiting int64 `json:"fair_queue_waiting"`
+	FairQueueUsers   int64 `json:"fair_queue_users"`
 }
 
 // AccountConcurrencyInfo represents real-time concurrency usage for a single account.
@@ -78,4 +81,8 @@ type AccountAvailability struct {
 	OverloadRemainingSec   *int64     `json:"overload_remaining_sec"`
 	ErrorMessage           string     `json:"error_message"`
 	TempUnschedulableUntil *time.Time `json:"temp_unschedulable_until,omitempty"`
+
+	// CircuitState: closed / open / half_open (empty when the circuit breaker is disabled).
+	CircuitState     string     `json:"circuit_state,omitempty"`
+	CircuitOpenUntil *time.Time `json:"circuit_open_until,omitempty"`
 }
@@ -16,4 +16,16 @@ type OpsRealtimeTrafficSummary struct {
 
 	QPS OpsRateSummary `json:"qps"`
 	TPS OpsRateSummary `json:"tps"`
+
+	PromptCache OpsPromptCacheSummary `json:"prompt_cache"`
+}
+
+// OpsPromptCacheSummary reports prompt cache effectiveness for the window.
+// HitRatio = cache_read / (cache_read + cache_creation); StickyRequests counts successful requests routed with a sticky session.
+type OpsPromptCacheSummary struct {
+	CacheReadTokens     int64   `json:"cache_read_tokens"`
+	CacheCreationTokens int64   `json:"cache_creation_tokens"`
+	HitRatio            float64 `json:"hit_ratio"`
+	StickyRequests      int64   `json:"sticky_requests"`
+	SuccessRequests     int64   `json:"success_requests"`
 }
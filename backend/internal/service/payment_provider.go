@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+)
+
+// 内置支付渠道名称
+const (
+	PaymentProviderWebhook = "webhook"
+	PaymentProviderMock    = "mock"
+)
+
+// 支付回调事件状态
+const (
+	PaymentEventPaid   = "paid"
+	PaymentEventFailed = "failed"
+)
+
+// 通用 HMAC 回调渠道的签名请求头
+const (
+	PaymentSignatureHeader = "X-Payment-Signature"
+	PaymentTimestampHeader = "X-Payment-Timestamp"
+)
+
+// PaymentProvider 支付渠道
+// 新渠道实现该接口并通过 TopupService.RegisterProvider 注册，回调地址为 /api/v1/payment/webhook/{Name()}。
+type PaymentProvider interface {
+	// Name 渠道名称（订单与回调路由使用）
+	Name() string
+	// CreatePayment 为订单发起支付，返回渠道订单号与支付链接
+	CreatePayment(ctx context.Context, order *TopupOrder) (*PaymentSession, error)
+	// ParseWebhook 校验回调签名并解析支付结果；签名无效时返回 ErrInvalidPaymentSignature
+	ParseWebhook(header http.Header, body []byte) (*PaymentEvent, error)
+}
+
+// PaymentSession 发起支付的结果
+type PaymentSession struct {
+	ProviderOrderID string
+	PayURL          string
+}
+
+// PaymentEvent 支付回调事件
+// 支付成功事件必须携带 Amount；Currency 为空时不校验币种。
+type PaymentEvent struct {
+	OrderNo         string  `json:"order_no"`
+	ProviderOrderID string  `json:"provider_order_id"`
+	Status          string  `json:"status"`
+	Amount          float64 `json:"amount"`
+	Currency        string  `json:"currency"`
+}
+
+func parsePaymentEvent(body []byte) (*PaymentEvent, error) {
+	var event PaymentEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPaymentEvent, err)
+	}
+	event.OrderNo = strings.TrimSpace(event.OrderNo)
+	event.Status = strings.ToLower(strings.TrimSpace(event.Status))
+	if event.OrderNo == "" {
+		return nil, fmt.Errorf("%w: order_no is required", ErrInvalidPaymentEvent)
+	}
+	if event.Status != PaymentEventPaid && event.Status != PaymentEventFailed {
+		return nil, fmt.Errorf("%w: unsupported status %q", ErrInvalidPaymentEvent, event.Status)
+	}
+	return &event, nil
+}
+
+// hmacWebhookProvider 通用 HMAC 签名回调渠道
+//
+// 下单时跳转到配置的收银台地址；收银台（或对接的支付网关）在支付完成后以 JSON 回调，
+// 并在请求头中携带 Unix 时间戳与 hex(HMAC-SHA256(secret, timestamp + "." + body)) 签名。
+type hmacWebhookProvider struct {
+	secret      []byte
+	checkoutURL string
+	tolerance   time.Duration
+	now         func() time.Time
+}
+
+func newHMACWebhookProvider(cfg config.PaymentWebhookProviderConfig) *hmacWebhookProvider {
+	return &hmacWebhookProvider{
+		secret:      []byte(cfg.Secret),
+		checkoutURL: cfg.CheckoutURL,
+		tolerance:   time.Duration(cfg.SignatureToleranceSeconds) * time.Second,
+		now:         time.Now,
+	}
+}
+
+func (p *hmacWebhookProvider) Name() string {
+	return PaymentProviderWebhook
+}
+
+func (p *hmacWebhookProvider) CreatePayment(ctx context.Context, order *TopupOrder) (*PaymentSession, error) {
+	payURL := strings.NewReplacer(
+		"{order_no}", url.QueryEscape(order.OrderNo),
+		"{amount}", strconv.FormatFloat(order.Amount, 'f', 2, 64),
+		"{currency}", url.QueryEscape(order.Currency),
+	).Replace(p.checkoutURL)
+	return &PaymentSession{PayURL: payURL}, nil
+}
+
+func (p *hmacWebhookProvider) ParseWebhook(header http.Header, body []byte) (*PaymentEvent, error) {
+	timestamp := strings.TrimSpace(header.Get(PaymentTimestampHeader))
+	signature := strings.TrimPrefix(strings.TrimSpace(header.Get(PaymentSignatureHeader)), "sha256=")
+	if timestamp == "" || signature == "" {
+		return nil, ErrInvalidPaymentSignature
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidPaymentSignature
+	}
+	if skew := p.now().Sub(time.Unix(ts, 0)); math.Abs(float64(skew)) > float64(p.tolerance) {
+		return nil, ErrInvalidPaymentSignature
+	}
+	got, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(got, p.sign(timestamp, body)) {
+		return nil, ErrInvalidPaymentSignature
+	}
+	return parsePaymentEvent(body)
+}
+
+func (p *hmacWebhookProvider) sign(timestamp string, body []byte) []byte {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// mockPaymentProvider 本地模拟支付渠道（仅用于测试）
+// 不发起真实支付，回调无签名：向 /api/v1/payment/webhook/mock 提交 {"order_no": "...", "status": "paid", "amount": 10} 即可完成支付。
+type mockPaymentProvider struct{}
+
+func (mockPaymentProvider) Name() string {
+	return PaymentProviderMock
+}
+
+func (mockPaymentProvider) CreatePayment(ctx context.Context, order *TopupOrder) (*PaymentSession, error) {
+	return &PaymentSession{ProviderOrderID: "mock_" + order.OrderNo}, nil
+}
+
+func (mockPaymentProvider) ParseWebhook(header http.Header, body []byte) (*PaymentEvent, error) {
+	return parsePaymentEvent(body)
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+var (
+	ErrPricingOverrideNotFound  = infraerrors.NotFound("PRICING_OVERRIDE_NOT_FOUND", "pricing override not found")
+	ErrPricingOverrideInEffect  = infraerrors.Conflict("PRICING_OVERRIDE_IN_EFFECT", "pricing override is already in effect; create a new version with a later effective_from instead")
+	ErrInvalidPricingOverride   = infraerrors.BadRequest("INVALID_PRICING_OVERRIDE", "invalid pricing override")
+	ErrPricingOverrideDuplicate = infraerrors.Conflict("PRICING_OVERRIDE_DUPLICATE", "a pricing override with the same scope, model pattern and effective_from already exists")
+)
+
+// PricingOverride 管理员自定义价格（token 价格为 USD / 百万 token，图片价格为 USD / 张）
+//
+// 作用域：GroupID 为空表示全局，否则仅对该分组生效；ModelPattern 支持精确模型名或末尾 * 通配。
+// 版本：同一作用域与模型模式可有多条记录，EffectiveFrom 不晚于计费时间的最新一条生效；
+// 已生效的记录不可修改（调价需新增版本），删除为软删除，usage_logs 通过 pricing_override_id 追溯计费价格。
+type PricingOverride struct {
+	ID                 int64
+	GroupID            *int64
+	ModelPattern       string
+	InputPrice         float64
+	OutputPrice        float64
+	CacheCreationPrice float64
+	CacheReadPrice     float64
+	ImagePrice         *float64 // 图片生成单价（1K/2K 尺寸，4K 翻倍），nil 表示不覆盖图片价格
+	EffectiveFrom      time.Time
+	Notes              string
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+}
+
+// IsEffective 是否已在 at 时刻生效
+func (o *PricingOverride) IsEffective(at time.Time) bool {
+	return !o.EffectiveFrom.After(at)
+}
+
+// toModelPricing 转换为 per-token 价格
+func (o *PricingOverride) toModelPricing() *ModelPricing {
+	id := o.ID
+	return &ModelPricing{
+		InputPricePerToken:         o.InputPrice / 1_000_000,
+		OutputPricePerToken:        o.OutputPrice / 1_000_000,
+		CacheCreationPricePerToken: o.CacheCreationPrice / 1_000_000,
+		CacheReadPricePerToken:     o.CacheReadPrice / 1_000_000,
+		PricingOverrideID:          &id,
+	}
+}
+
+// PricingOverrideFilters 自定义价格查询条件
+type PricingOverrideFilters struct {
+	// GroupID 为空时不按分组过滤；GlobalOnly 为 true 时仅返回全局价格
+	GroupID    *int64
+	GlobalOnly bool
+	Model      string
+}
+
+// PricingOverrideRepository 自定义价格存储（仅返回未删除的记录）
+type PricingOverrideRepository interface {
+	List(ctx context.Context, filters PricingOverrideFilters) ([]PricingOverride, error)
+	GetByID(ctx context.Context, id int64) (*PricingOverride, error)
+	Create(ctx context.Context, override *PricingOverride) error
+	Update(ctx context.Context, override *PricingOverride) error
+	Delete(ctx context.Context, id int64) error
+}
+
+// resolvePricingOverride 在 overrides 中为 (groupID, model) 选出 at 时刻生效的价格
+// 优先级：分组价格优先于全局价格；精确匹配优先于通配，较长的通配前缀优先；同一模式取最新生效的版本。
+func resolvePricingOverride(overrides []PricingOverride, groupID *int64, model string, at time.Time) *PricingOverride {
+	model = strings.ToLower(model)
+	var best *PricingOverride
+	bestRank := -1
+	for i := range overrides {
+		o := &overrides[i]
+		if !o.IsEffective(at) {
+			continue
+		}
+		scope := 0
+		if o.GroupID != nil {
+			if groupID == nil || *o.GroupID != *groupID {
+				continue
+			}
+			scope = 1
+		}
+		pattern := strings.ToLower(o.ModelPattern)
+		if !matchModelPattern(pattern, model) {
+			continue
+		}
+		specificity := len(pattern)
+		if !strings.HasSuffix(pattern, "*") {
+			specificity = len(pattern) + 1<<16
+		}
+		rank := scope<<24 | specificity
+		if rank > bestRank || (rank == bestRank && o.EffectiveFrom.After(best.EffectiveFrom)) {
+			best, bestRank = o, rank
+		}
+	}
+	return best
+}
+
+// normalizePricingOverride 校验并规范化自定义价格
+func normalizePricingOverride(o *PricingOverride) error {
+	o.ModelPattern = strings.TrimSpace(o.ModelPattern)
+	if o.ModelPattern == "" {
+		return fmt.Errorf("%w: model_pattern is required", ErrInvalidPricingOverride)
+	}
+	if idx := strings.Index(o.ModelPattern, "*"); idx >= 0 && idx != len(o.ModelPattern)-1 {
+		return fmt.Errorf("%w: only a trailing * wildcard is supported in model_pattern", ErrInvalidPricingOverride)
+	}
+	for _, p := range []float64{o.InputPrice, o.OutputPrice, o.CacheCreationPrice, o.CacheReadPrice} {
+		if p < 0 {
+			return fmt.Errorf("%w: prices must be >= 0", ErrInvalidPricingOverride)
+		}
+	}
+	if o.ImagePrice != nil && *o.ImagePrice < 0 {
+		return fmt.Errorf("%w: prices must be >= 0", ErrInvalidPricingOverride)
+	}
+	if o.EffectiveFrom.IsZero() {
+		return fmt.Errorf("%w: effective_from is required", ErrInvalidPricingOverride)
+	}
+	o.Notes = strings.TrimSpace(o.Notes)
+	return nil
+}
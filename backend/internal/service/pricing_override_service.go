@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+)
+
+// pricingOverrideClockSkew 新建/修改价格时允许的生效时间回拨容差
+const pricingOverrideClockSkew = time.Minute
+
+// PricingOverrideService 管理员自定义价格
+// 计费热路径只读内存快照；快照在管理操作后立即刷新，并定时刷新以同步其他实例的修改。
+type PricingOverrideService struct {
+	repo     PricingOverrideRepository
+	interval time.Duration
+
+	mu        sync.RWMutex
+	overrides []PricingOverride
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewPricingOverrideService 创建自定义价格服务
+func NewPricingOverrideService(repo PricingOverrideRepository, cfg *config.Config) *PricingOverrideService {
+	interval := time.Minute
+	if cfg != nil && cfg.Pricing.OverrideRefreshSeconds > 0 {
+		interval = time.Duration(cfg.Pricing.OverrideRefreshSeconds) * time.Second
+	}
+	return &PricingOverrideService{
+		repo:     repo,
+		interval: interval,
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start 同步加载一次快照并启动定时刷新
+func (s *PricingOverrideService) Start() {
+	if s == nil || s.repo == nil {
+		return
+	}
+	s.refresh()
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.refresh()
+			case <-s.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止定时刷新
+func (s *PricingOverrideService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+	s.wg.Wait()
+}
+
+func (s *PricingOverrideService) refresh() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := s.reload(ctx); err != nil {
+		log.Printf("[PricingOverride] Reload overrides failed: %v", err)
+	}
+}
+
+func (s *PricingOverrideService) reload(ctx context.Context) error {
+	overrides, err := s.repo.List(ctx, PricingOverrideFilters{})
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.overrides = overrides
+	s.mu.Unlock()
+	return nil
+}
+
+// Resolve 返回 at 时刻对 (groupID, model) 生效的自定义价格，未配置时返回 nil
+func (s *PricingOverrideService) Resolve(groupID *int64, model string, at time.Time) *PricingOverride {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if o := resolvePricingOverride(s.overrides, groupID, model, at); o != nil {
+		cp := *o
+		return &cp
+	}
+	return nil
+}
+
+// List 列出自定义价格（含尚未生效的版本）
+func (s *PricingOverrideService) List(ctx context.Context, filters PricingOverrideFilters) ([]PricingOverride, error) {
+	return s.repo.List(ctx, filters)
+}
+
+// GetByID 获取自定义价格
+func (s *PricingOverrideService) GetByID(ctx context.Context, id int64) (*PricingOverride, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// Create 新建自定义价格版本；EffectiveFrom 为空时立即生效，不允许早于当前时间
+func (s *PricingOverrideService) Create(ctx context.Context, override *PricingOverride) error {
+	now := time.Now()
+	if override.EffectiveFrom.IsZero() {
+		override.EffectiveFrom = now
+	}
+	if err := s.validate(override, now); err != nil {
+		return err
+	}
+	if err := s.repo.Create(ctx, override); err != nil {
+		return err
+	}
+	s.afterMutation(ctx)
+	return nil
+}
+
+// Update 修改尚未生效的自定义价格；已生效的价格不可修改（需新建更晚生效的版本）
+func (s *PricingOverrideService) Update(ctx context.Context, override *PricingOverride) error {
+	existing, err := s.repo.GetByID(ctx, override.ID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if existing.IsEffective(now) {
+		return ErrPricingOverrideInEffect
+	}
+	if override.EffectiveFrom.IsZero() {
+		override.EffectiveFrom = existing.EffectiveFrom
+	}
+	if err := s.validate(override, now); err != nil {
+		return err
+	}
+	if err := s.repo.Update(ctx, override); err != nil {
+		return err
+	}
+	s.afterMutation(ctx)
+	return nil
+}
+
+// Delete 删除自定义价格（软删除，立即停止生效，历史用量仍可追溯）
+func (s *PricingOverrideService) Delete(ctx context.Context, id int64) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.afterMutation(ctx)
+	return nil
+}
+
+func (s *PricingOverrideService) validate(override *PricingOverride, now time.Time) error {
+	if err := normalizePricingOverride(override); err != nil {
+		return err
+	}
+	if override.EffectiveFrom.Before(now.Add(-pricingOverrideClockSkew)) {
+		return fmt.Errorf("%w: effective_from cannot be in the past", ErrInvalidPricingOverride)
+	}
+	return nil
+}
+
+func (s *PricingOverrideService) afterMutation(ctx context.Context) {
+	if err := s.reload(ctx); err != nil {
+		log.Printf("[PricingOverride] Reload overrides after update failed: %v", err)
+	}
+}
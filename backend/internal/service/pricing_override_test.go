@@ -0,0 +1,192 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type pricingOverrideRepoStub struct {
+	PricingOverrideRepository
+	overrides []PricingOverride
+	nextID    int64
+}
+
+func (r *pricingOverrideRepoStub) List(ctx context.Context, filters PricingOverrideFilters) ([]PricingOverride, error) {
+	return append([]PricingOverride(nil), r.overrides...), nil
+}
+
+func (r *pricingOverrideRepoStub) GetByID(ctx context.Context, id int64) (*PricingOverride, error) {
+	for i := range r.overrides {
+		if r.overrides[i].ID == id {
+			o := r.overrides[i]
+			return &o, nil
+		}
+	}
+	return nil, ErrPricingOverrideNotFound
+}
+
+func (r *pricingOverrideRepoStub) Create(ctx context.Context, override *PricingOverride) error {
+	r.nextID++
+	override.ID = r.nextID
+	r.overrides = append(r.overrides, *override)
+	return nil
+}
+
+func (r *pricingOverrideRepoStub) Update(ctx context.Context, override *PricingOverride) error {
+	for i := range r.overrides {
+		if r.overrides[i].ID == override.ID {
+			r.overrides[i] = *override
+			return nil
+		}
+	}
+	return ErrPricingOverrideNotFound
+}
+
+func TestResolvePricingOverride_Precedence(t *testing.T) {
+	now := time.Now()
+	groupID := int64(7)
+	otherGroup := int64(8)
+	overrides := []PricingOverride{
+		{ID: 1, ModelPattern: "claude-*", InputPrice: 1, EffectiveFrom: now.Add(-time.Hour)},
+		{ID: 2, ModelPattern: "claude-opus-*", InputPrice: 2, EffectiveFrom: now.Add(-time.Hour)},
+		{ID: 3, ModelPattern: "claude-opus-4", InputPrice: 3, EffectiveFrom: now.Add(-time.Hour)},
+		{ID: 4, ModelPattern: "claude-opus-4", InputPrice: 4, EffectiveFrom: now.Add(-time.Minute)},
+		{ID: 5, ModelPattern: "claude-opus-4", InputPrice: 5, EffectiveFrom: now.Add(time.Hour)},
+		{ID: 6, GroupID: &groupID, ModelPattern: "claude-*", InputPrice: 6, EffectiveFrom: now.Add(-time.Hour)},
+		{ID: 7, GroupID: &otherGroup, ModelPattern: "claude-opus-4", InputPrice: 7, EffectiveFrom: now.Add(-time.Hour)},
+	}
+
+	cases := []struct {
+		name    string
+		groupID *int64
+		model   string
+		at      time.Time
+		want    int64
+	}{
+		{"exact match wins over wildcards, latest effective version", nil, "claude-opus-4", now, 4},
+		{"future version not yet effective", nil, "claude-opus-4", now.Add(-30 * time.Minute), 3},
+		{"future version once effective", nil, "Claude-Opus-4", now.Add(2 * time.Hour), 5},
+		{"longer wildcard prefix wins", nil, "claude-opus-3", now, 2},
+		{"generic wildcard", nil, "claude-sonnet-4", now, 1},
+		{"group override wins over global exact match", &groupID, "claude-opus-4", now, 6},
+		{"other group falls back to its own override", &otherGroup, "claude-opus-4", now, 7},
+		{"other group falls back to global", &otherGroup, "claude-sonnet-4", now, 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := resolvePricingOverride(overrides, tc.groupID, tc.model, tc.at)
+			require.NotNil(t, got)
+			require.Equal(t, tc.want, got.ID)
+		})
+	}
+
+	require.Nil(t, resolvePricingOverride(overrides, nil, "gpt-4o", now))
+}
+
+func TestNormalizePricingOverride(t *testing.T) {
+	now := time.Now()
+	valid := PricingOverride{ModelPattern: " claude-* ", InputPrice: 1, EffectiveFrom: now, Notes: " promo "}
+	require.NoError(t, normalizePricingOverride(&valid))
+	require.Equal(t, "claude-*", valid.ModelPattern)
+	require.Equal(t, "promo", valid.Notes)
+
+	for _, o := range []PricingOverride{
+		{ModelPattern: "", EffectiveFrom: now},
+		{ModelPattern: "claude-*-opus", EffectiveFrom: now},
+		{ModelPattern: "claude", OutputPrice: -1, EffectiveFrom: now},
+		{ModelPattern: "claude"},
+	} {
+		require.ErrorIs(t, normalizePricingOverride(&o), ErrInvalidPricingOverride)
+	}
+}
+
+func TestPricingOverrideService_VersioningAndBilling(t *testing.T) {
+	repo := &pricingOverrideRepoStub{}
+	svc := NewPricingOverrideService(repo, nil)
+	ctx := context.Background()
+
+	current := &PricingOverride{ModelPattern: "claude-opus-4", InputPrice: 10, OutputPrice: 20}
+	require.NoError(t, svc.Create(ctx, current))
+	require.False(t, current.EffectiveFrom.IsZero(), "effective_from defaults to now")
+
+	groupID := int64(3)
+	billing := NewBillingService(nil, nil, svc)
+	cost, err := billing.CalculateCostForGroup("claude-opus-4", &groupID, UsageTokens{InputTokens: 1_000_000, OutputTokens: 500_000}, 2)
+	require.NoError(t, err)
+	require.InDelta(t, 10, cost.InputCost, 1e-9)
+	require.InDelta(t, 10, cost.OutputCost, 1e-9)
+	require.InDelta(t, 40, cost.ActualCost, 1e-9)
+	require.NotNil(t, cost.PricingOverrideID)
+	require.Equal(t, current.ID, *cost.PricingOverrideID)
+
+	// 已生效的价格不可修改，需新增版本
+	current.InputPrice = 1
+	require.ErrorIs(t, svc.Update(ctx, current), ErrPricingOverrideInEffect)
+
+	// 生效时间不能早于当前
+	past := &PricingOverride{ModelPattern: "claude-opus-4", InputPrice: 1, EffectiveFrom: time.Now().Add(-time.Hour)}
+	require.ErrorIs(t, svc.Create(ctx, past), ErrInvalidPricingOverride)
+
+	// 未生效的版本可以修改，且不影响当前计费
+	scheduled := &PricingOverride{ModelPattern: "claude-opus-4", InputPrice: 5, EffectiveFrom: time.Now().Add(time.Hour)}
+	require.NoError(t, svc.Create(ctx, scheduled))
+	scheduled.InputPrice = 6
+	require.NoError(t, svc.Update(ctx, scheduled))
+
+	cost, err = billing.CalculateCost("claude-opus-4", UsageTokens{InputTokens: 1_000_000}, 1)
+	require.NoError(t, err)
+	require.InDelta(t, 10, cost.InputCost, 1e-9)
+	require.Equal(t, current.ID, *cost.PricingOverrideID)
+
+	resolved := svc.Resolve(nil, "claude-opus-4", time.Now().Add(2*time.Hour))
+	require.NotNil(t, resolved)
+	require.Equal(t, scheduled.ID, resolved.ID)
+	require.InDelta(t, 6, resolved.InputPrice, 1e-9)
+
+	// 未配置自定义价格的模型使用默认价格
+	cost, err = billing.CalculateCost("claude-sonnet-4", UsageTokens{InputTokens: 1_000_000}, 1)
+	require.NoError(t, err)
+	require.Nil(t, cost.PricingOverrideID)
+}
+
+func TestPricingOverride_ImageAndEmbeddingBilling(t *testing.T) {
+	groupID := int64(3)
+	imagePrice := 0.05
+	groupImagePrice := 0.02
+	svc := NewPricingOverrideService(&pricingOverrideRepoStub{}, nil)
+	svc.overrides = []PricingOverride{
+		{ID: 1, ModelPattern: "gemini-3-pro-image*", ImagePrice: &imagePrice, EffectiveFrom: time.Now().Add(-time.Hour)},
+		{ID: 2, GroupID: &groupID, ModelPattern: "gemini-3-pro-image*", ImagePrice: &groupImagePrice, EffectiveFrom: time.Now().Add(-time.Hour)},
+		{ID: 3, ModelPattern: "text-embedding-3-small", InputPrice: 1, EffectiveFrom: time.Now().Add(-time.Hour)},
+	}
+	billing := NewBillingService(nil, nil, svc)
+
+	// 全局自定义价格覆盖默认图片价格，4K 翻倍
+	cost := billing.CalculateImageCost("gemini-3-pro-image", "4K", 2, nil, 1)
+	require.InDelta(t, 0.2, cost.TotalCost, 1e-9)
+	require.Equal(t, int64(1), *cost.PricingOverrideID)
+
+	// 分组图片价格配置优先于全局自定义价格
+	configured := 0.3
+	cost = billing.CalculateImageCostForGroup("gemini-3-pro-image", nil, "1K", 1, &ImagePriceConfig{Price1K: &configured}, 1)
+	require.InDelta(t, 0.3, cost.TotalCost, 1e-9)
+	require.Nil(t, cost.PricingOverrideID)
+
+	// 分组自定义价格优先于分组图片价格配置
+	cost = billing.CalculateImageCostForGroup("gemini-3-pro-image", &groupID, "1K", 1, &ImagePriceConfig{Price1K: &configured}, 1)
+	require.InDelta(t, 0.02, cost.TotalCost, 1e-9)
+	require.Equal(t, int64(2), *cost.PricingOverrideID)
+
+	cost = billing.CalculateEmbeddingCostForGroup("models/text-embedding-3-small", &groupID, 1_000_000, 2)
+	require.InDelta(t, 1, cost.TotalCost, 1e-9)
+	require.InDelta(t, 2, cost.ActualCost, 1e-9)
+	require.Equal(t, int64(3), *cost.PricingOverrideID)
+
+	cost = billing.CalculateEmbeddingCost("text-embedding-3-large", 1_000_000, 1)
+	require.Nil(t, cost.PricingOverrideID)
+}
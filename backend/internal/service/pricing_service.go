@@ -35,6 +35,15 @@ type LiteLLMModelPricing struct {
 	Mode                        string  `json:"mode"`
 	SupportsPromptCaching       bool    `json:"supports_prompt_caching"`
 	OutputCostPerImage          float64 `json:"output_cost_per_image"` // 图片生成模型每张图片价格
+
+	// 能力元数据（模型目录使用）
+	MaxInputTokens          int  `json:"max_input_tokens"`
+	MaxOutputTokens         int  `json:"max_output_tokens"`
+	SupportsVision          bool `json:"supports_vision"`
+	SupportsPDFInput        bool `json:"supports_pdf_input"`
+	SupportsAudioInput      bool `json:"supports_audio_input"`
+	SupportsFunctionCalling bool `json:"supports_function_calling"`
+	SupportsReasoning       bool `json:"supports_reasoning"`
 }
 
 // PricingRemoteClient 远程价格数据获取接口
@@ -53,6 +62,13 @@ type LiteLLMRawEntry struct {
 	Mode                        string   `json:"mode"`
 	SupportsPromptCaching       bool     `json:"supports_prompt_caching"`
 	OutputCostPerImage          *float64 `json:"output_cost_per_image"`
+	MaxInputTokens              *int     `json:"max_input_tokens"`
+	MaxOutputTokens             *int     `json:"max_output_tokens"`
+	SupportsVision              bool     `json:"supports_vision"`
+	SupportsPDFInput            bool     `json:"supports_pdf_input"`
+	SupportsAudioInput          bool     `json:"supports_audio_input"`
+	SupportsFunctionCalling     bool     `json:"supports_function_calling"`
+	SupportsReasoning           bool     `json:"supports_reasoning"`
 }
 
 // PricingService 动态价格服务
@@ -304,9 +320,20 @@ func (s *PricingService) parsePricingData(body []byte) (map[string]*LiteLLMModel
 		}
 
 		pricing := &LiteLLMModelPricing{
-			LiteLLMProvider:       entry.LiteLLMProvider,
-			Mode:                  entry.Mode,
-			SupportsPromptCaching: entry.SupportsPromptCaching,
+			LiteLLMProvider:         entry.LiteLLMProvider,
+			Mode:                    entry.Mode,
+			SupportsPromptCaching:   entry.SupportsPromptCaching,
+			SupportsVision:          entry.SupportsVision,
+			SupportsPDFInput:        entry.SupportsPDFInput,
+			SupportsAudioInput:      entry.SupportsAudioInput,
+			SupportsFunctionCalling: entry.SupportsFunctionCalling,
+			SupportsReasoning:       entry.SupportsReasoning,
+		}
+		if entry.MaxInputTokens != nil {
+			pricing.MaxInputTokens = *entry.MaxInputTokens
+		}
+		if entry.MaxOutputTokens != nil {
+			pricing.MaxOutputTokens = *entry.MaxOutputTokens
 		}
 
 		if entry.InputCostPerToken != nil {
@@ -497,6 +524,31 @@ func (s *PricingService) GetModelPricing(modelName string) *LiteLLMModelPricing
 	return nil
 }
 
+// embeddingProviderPrefixes LiteLLM 中部分 embedding 模型带有 provider 前缀（如 gemini/gemini-embedding-001）
+var embeddingProviderPrefixes = []string{"", "gemini/", "openai/", "vertex_ai/"}
+
+// GetEmbeddingPricing 获取 embedding 模型价格（仅精确匹配 mode=embedding 的条目）
+// 不走 GetModelPricing 的模糊匹配，避免 embedding 模型误匹配到对话模型价格。
+func (s *PricingService) GetEmbeddingPricing(modelName string) *LiteLLMModelPricing {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if modelName == "" {
+		return nil
+	}
+
+	modelLower := strings.ToLower(strings.TrimSpace(modelName))
+	for _, candidate := range s.buildModelLookupCandidates(modelLower) {
+		for _, prefix := range embeddingProviderPrefixes {
+			pricing, ok := s.pricingData[prefix+candidate]
+			if ok && pricing.Mode == "embedding" {
+				return pricing
+			}
+		}
+	}
+	return nil
+}
+
 func (s *PricingService) buildModelLookupCandidates(modelLower string) []string {
 	// Prefer canonical model name first (this also improves billing compatibility with "models/xxx").
 	candidates := []string{
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	defaultPromptCacheStatsLimit = 50
+	maxPromptCacheStatsLimit     = 500
+)
+
+// PromptCacheStatsFilter prompt cache 统计查询条件
+type PromptCacheStatsFilter struct {
+	StartTime time.Time
+	EndTime   time.Time
+	GroupID   int64 // 0 表示不过滤
+	AccountID int64 // 0 表示不过滤
+	Limit     int
+}
+
+// PromptCacheAccountStat 账号维度的 prompt cache 统计
+//
+// Sticky* 字段仅统计携带粘性会话的请求，用于对比粘性路由是否带来缓存命中。
+type PromptCacheAccountStat struct {
+	AccountID           int64   `json:"account_id"`
+	AccountName         string  `json:"account_name"`
+	Requests            int64   `json:"requests"`
+	InputTokens         int64   `json:"input_tokens"`
+	CacheReadTokens     int64   `json:"cache_read_tokens"`
+	CacheCreationTokens int64   `json:"cache_creation_tokens"`
+	HitRatio            float64 `json:"hit_ratio"`
+
+	StickyRequests            int64   `json:"sticky_requests"`
+	StickySessions            int64   `json:"sticky_sessions"`
+	StickyCacheReadTokens     int64   `json:"sticky_cache_read_tokens"`
+	StickyCacheCreationTokens int64   `json:"sticky_cache_creation_tokens"`
+	StickyHitRatio            float64 `json:"sticky_hit_ratio"`
+}
+
+// PromptCacheSessionStat 粘性会话维度的 prompt cache 统计
+//
+// AccountCount > 1 表示会话在窗口内被调度到多个账号（粘性被打断，缓存需重新创建）。
+type PromptCacheSessionStat struct {
+	SessionHash         string    `json:"session_hash"`
+	AccountCount        int64     `json:"account_count"`
+	LastAccountID       int64     `json:"last_account_id"`
+	Requests            int64     `json:"requests"`
+	InputTokens         int64     `json:"input_tokens"`
+	CacheReadTokens     int64     `json:"cache_read_tokens"`
+	CacheCreationTokens int64     `json:"cache_creation_tokens"`
+	HitRatio            float64   `json:"hit_ratio"`
+	FirstSeenAt         time.Time `json:"first_seen_at"`
+	LastSeenAt          time.Time `json:"last_seen_at"`
+}
+
+// PromptCacheStatsRepository 基于 usage_logs 聚合 prompt cache 统计
+type PromptCacheStatsRepository interface {
+	// GetAccountStats 按账号聚合，按缓存 token 总量降序
+	GetAccountStats(ctx context.Context, filter PromptCacheStatsFilter) ([]PromptCacheAccountStat, error)
+	// GetSessionStats 按粘性会话聚合，按最近请求时间降序
+	GetSessionStats(ctx context.Context, filter PromptCacheStatsFilter) ([]PromptCacheSessionStat, error)
+}
+
+// PromptCacheHitRatio 缓存命中率：cache_read / (cache_read + cache_creation)，无缓存活动时为 0
+func PromptCacheHitRatio(cacheRead, cacheCreation int64) float64 {
+	total := cacheRead + cacheCreation
+	if total <= 0 {
+		return 0
+	}
+	return float64(cacheRead) / float64(total)
+}
+
+// PromptCacheStatsService 提供粘性路由的 prompt cache 命中统计
+type PromptCacheStatsService struct {
+	repo PromptCacheStatsRepository
+}
+
+// NewPromptCacheStatsService creates a new PromptCacheStatsService
+func NewPromptCacheStatsService(repo PromptCacheStatsRepository) *PromptCacheStatsService {
+	return &PromptCacheStatsService{repo: repo}
+}
+
+// GetAccountStats 返回账号维度的缓存命中统计
+func (s *PromptCacheStatsService) GetAccountStats(ctx context.Context, filter PromptCacheStatsFilter) ([]PromptCacheAccountStat, error) {
+	filter.Limit = normalizePromptCacheStatsLimit(filter.Limit)
+	stats, err := s.repo.GetAccountStats(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	for i := range stats {
+		stats[i].HitRatio = PromptCacheHitRatio(stats[i].CacheReadTokens, stats[i].CacheCreationTokens)
+		stats[i].StickyHitRatio = PromptCacheHitRatio(stats[i].StickyCacheReadTokens, stats[i].StickyCacheCreationTokens)
+	}
+	return stats, nil
+}
+
+// GetSessionStats 返回粘性会话维度的缓存命中统计
+func (s *PromptCacheStatsService) GetSessionStats(ctx context.Context, filter PromptCacheStatsFilter) ([]PromptCacheSessionStat, error) {
+	filter.Limit = normalizePromptCacheStatsLimit(filter.Limit)
+	stats, err := s.repo.GetSessionStats(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	for i := range stats {
+		stats[i].HitRatio = PromptCacheHitRatio(stats[i].CacheReadTokens, stats[i].CacheCreationTokens)
+	}
+	return stats, nil
+}
+
+func normalizePromptCacheStatsLimit(limit int) int {
+	if limit <= 0 {
+		return defaultPromptCacheStatsLimit
+	}
+	if limit > maxPromptCacheStatsLimit {
+		return maxPromptCacheStatsLimit
+	}
+	return limit
+}
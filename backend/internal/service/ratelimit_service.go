@@ -3,6 +3,8 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"strconv"
@@ -23,6 +25,8 @@ type RateLimitService struct {
 	timeoutCounterCache   TimeoutCounterCache
 	settingService        *SettingService
 	tokenCacheInvalidator TokenCacheInvalidator
+	scoringService        *AccountScoringService
+	circuitBreaker        *AccountCircuitBreakerService
 	usageCacheMu          sync.RWMutex
 	usageCache            map[int64]*geminiUsageCacheEntry
 }
@@ -62,9 +66,53 @@ func (s *RateLimitService) SetTokenCacheInvalidator(invalidator TokenCacheInvali
 	s.tokenCacheInvalidator = invalidator
 }
 
+// SetAccountScoringService 设置账号评分服务（可选依赖），上游错误计入账号错误率
+func (s *RateLimitService) SetAccountScoringService(scoringService *AccountScoringService) {
+	s.scoringService = scoringService
+}
+
+// SetCircuitBreakerService 设置账号熔断服务（可选依赖）
+func (s *RateLimitService) SetCircuitBreakerService(circuitBreaker *AccountCircuitBreakerService) {
+	s.circuitBreaker = circuitBreaker
+}
+
+// HandleUpstreamSuccess 记录一次上游成功响应（熔断器半开探测与窗口统计）
+func (s *RateLimitService) HandleUpstreamSuccess(ctx context.Context, account *Account) {
+	if s == nil {
+		return
+	}
+	s.circuitBreaker.RecordSuccess(ctx, account)
+}
+
+// HandleUpstreamRequestError 处理未拿到上游响应的请求失败（连接失败、超时等）
+func (s *RateLimitService) HandleUpstreamRequestError(ctx context.Context, account *Account, err error) {
+	if s == nil || account == nil {
+		return
+	}
+	// 客户端断开或对冲落败被取消的请求不计入账号失败
+	if errors.Is(err, context.Canceled) || errors.Is(ctx.Err(), context.Canceled) {
+		return
+	}
+	s.scoringService.RecordFailure(ctx, account.ID)
+	reason := ""
+	if err != nil {
+		reason = sanitizeUpstreamErrorMessage(err.Error())
+	}
+	s.circuitBreaker.RecordFailure(ctx, account, reason)
+}
+
 // HandleUpstreamError 处理上游错误响应，标记账号状态
 // 返回是否应该停止该账号的调度
 func (s *RateLimitService) HandleUpstreamError(ctx context.Context, account *Account, statusCode int, headers http.Header, responseBody []byte) (shouldDisable bool) {
+	// 客户端错误（4xx，认证/限流除外）不计入账号错误率
+	if isAccountScoringFailure(statusCode) {
+		s.scoringService.RecordFailure(ctx, account.ID)
+	}
+	// 持续的 5xx 由熔断器处理（单次响应不足以停止调度）
+	if isCircuitBreakerFailure(statusCode) {
+		s.circuitBreaker.RecordFailure(ctx, account, fmt.Sprintf("upstream %d", statusCode))
+	}
+
 	// apikey 类型账号：检查自定义错误码配置
 	// 如果启用且错误码不在列表中，则不处理（不停止调度、不标记限流/过载）
 	customErrorCodesEnabled := account.IsCustomErrorCodesEnabled()
@@ -628,6 +676,8 @@ func (s *RateLimitService) ClearTempUnschedulable(ctx context.Context, accountID
 	if err := s.accountRepo.ClearTempUnschedulable(ctx, accountID); err != nil {
 		return err
 	}
+	// 手动恢复同时重置熔断器，避免到期后仍处于半开限流
+	s.circuitBreaker.Reset(ctx, accountID)
 	if s.tempUnschedCache != nil {
 		if err := s.tempUnschedCache.DeleteTempUnsched(ctx, accountID); err != nil {
 			slog.Warn("temp_unsched_cache_delete_failed", "account_id", accountID, "error", err)
@@ -811,6 +861,7 @@ func (s *RateLimitService) HandleStreamTimeout(ctx context.Context, account *Acc
 	if account == nil {
 		return false
 	}
+	s.circuitBreaker.RecordFailure(ctx, account, "stream timeout")
 
 	// 获取系统设置
 	if s.settingService == nil {
@@ -278,7 +278,12 @@ func (s *RedeemService) Redeem(ctx context.Context, userID int64, code string) (
 	switch redeemCode.Type {
 	case RedeemTypeBalance:
 		// 增加用户余额
-		if err := s.userRepo.UpdateBalance(txCtx, userID, redeemCode.Value); err != nil {
+		if err := s.userRepo.ApplyBalanceEntry(txCtx, &BalanceLedgerEntry{
+			UserID:       userID,
+			EntryType:    BalanceEntryRedeem,
+			Amount:       redeemCode.Value,
+			RedeemCodeID: &redeemCode.ID,
+		}); err != nil {
 			return nil, fmt.Errorf("update user balance: %w", err)
 		}
 
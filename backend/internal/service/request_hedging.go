@@ -0,0 +1,143 @@
+package service
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+)
+
+// 非流式请求对冲（hedging）
+//
+// 延迟敏感的短请求（分类、count_tokens、短补全）在首个尝试超过近期延迟的指定分位仍未收到上游响应头时，
+// 由网关在另一个账号上发起第二个尝试，先完成者返回给客户端、另一个被取消，只对胜出者计费。
+// 对冲延迟按请求类型与模型分别统计近期成功请求收到响应头的耗时（进程内滑动窗口）。
+
+const (
+	// HedgeKindMessages Messages 非流式请求
+	HedgeKindMessages = "messages"
+	// HedgeKindCountTokens count_tokens 请求
+	HedgeKindCountTokens = "count_tokens"
+)
+
+// RequestHedgingService 维护近期延迟样本并计算对冲延迟
+type RequestHedgingService struct {
+	cfg *config.Config
+
+	mu      sync.Mutex
+	windows map[string]*latencyWindow
+}
+
+// latencyWindow 固定容量的耗时环形缓冲
+type latencyWindow struct {
+	samples []time.Duration
+	next    int
+	full    bool
+}
+
+func (w *latencyWindow) add(d time.Duration) {
+	w.samples[w.next] = d
+	w.next++
+	if w.next == len(w.samples) {
+		w.next = 0
+		w.full = true
+	}
+}
+
+func (w *latencyWindow) values() []time.Duration {
+	n := w.next
+	if w.full {
+		n = len(w.samples)
+	}
+	out := make([]time.Duration, n)
+	copy(out, w.samples[:n])
+	return out
+}
+
+// NewRequestHedgingService creates a new RequestHedgingService
+func NewRequestHedgingService(cfg *config.Config) *RequestHedgingService {
+	return &RequestHedgingService{cfg: cfg, windows: make(map[string]*latencyWindow)}
+}
+
+func (s *RequestHedgingService) enabled() bool {
+	return s != nil && s.cfg != nil && s.cfg.Gateway.Hedging.Enabled
+}
+
+// ShouldHedgeMessages 判断 Messages 请求是否可对冲：仅非流式且 max_tokens 不超过阈值
+func (s *RequestHedgingService) ShouldHedgeMessages(parsed *ParsedRequest) bool {
+	if !s.enabled() || parsed == nil || parsed.Stream {
+		return false
+	}
+	limit := s.cfg.Gateway.Hedging.MaxOutputTokens
+	return limit <= 0 || (parsed.MaxTokens > 0 && parsed.MaxTokens <= limit)
+}
+
+// ShouldHedgeCountTokens 判断 count_tokens 请求是否可对冲
+func (s *RequestHedgingService) ShouldHedgeCountTokens() bool {
+	return s.enabled() && s.cfg.Gateway.Hedging.CountTokens
+}
+
+func hedgeLatencyKey(kind, model string) string {
+	return kind + ":" + model
+}
+
+// Delay 返回发起对冲尝试前的等待时间：近期耗时的指定分位，限制在 [min, max] 内；样本不足时取 max
+func (s *RequestHedgingService) Delay(kind, model string) time.Duration {
+	cfg := s.cfg.Gateway.Hedging
+	minDelay := time.Duration(cfg.MinDelayMs) * time.Millisecond
+	maxDelay := time.Duration(cfg.MaxDelayMs) * time.Millisecond
+
+	s.mu.Lock()
+	var samples []time.Duration
+	if w := s.windows[hedgeLatencyKey(kind, model)]; w != nil {
+		samples = w.values()
+	}
+	s.mu.Unlock()
+
+	if len(samples) < cfg.MinSamples {
+		return maxDelay
+	}
+	delay := latencyPercentile(samples, cfg.Percentile)
+	if delay < minDelay {
+		return minDelay
+	}
+	if delay > maxDelay {
+		return maxDelay
+	}
+	return delay
+}
+
+// RecordLatency 记录一次成功请求收到上游响应头的耗时
+func (s *RequestHedgingService) RecordLatency(kind, model string, d time.Duration) {
+	if !s.enabled() || d <= 0 {
+		return
+	}
+	key := hedgeLatencyKey(kind, model)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w := s.windows[key]
+	if w == nil {
+		w = &latencyWindow{samples: make([]time.Duration, s.cfg.Gateway.Hedging.WindowSize)}
+		s.windows[key] = w
+	}
+	w.add(d)
+}
+
+// latencyPercentile 最近秩法计算分位数（p 取值 0-100）
+func latencyPercentile(samples []time.Duration, p float64) time.Duration {
+	if len(samples) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
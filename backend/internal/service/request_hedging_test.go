@@ -0,0 +1,68 @@
+//go:build unit
+
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestHedgingService() *RequestHedgingService {
+	cfg := &config.Config{}
+	cfg.Gateway.Hedging = config.GatewayHedgingConfig{
+		Enabled:         true,
+		Percentile:      90,
+		MinDelayMs:      100,
+		MaxDelayMs:      5000,
+		WindowSize:      10,
+		MinSamples:      5,
+		MaxOutputTokens: 1024,
+	}
+	return NewRequestHedgingService(cfg)
+}
+
+func TestRequestHedgingService_ShouldHedgeMessages(t *testing.T) {
+	svc := newTestHedgingService()
+	require.True(t, svc.ShouldHedgeMessages(&ParsedRequest{MaxTokens: 256}))
+	require.False(t, svc.ShouldHedgeMessages(&ParsedRequest{MaxTokens: 256, Stream: true}))
+	require.False(t, svc.ShouldHedgeMessages(&ParsedRequest{MaxTokens: 4096}))
+	require.False(t, svc.ShouldHedgeMessages(&ParsedRequest{}))
+	require.False(t, svc.ShouldHedgeCountTokens())
+
+	svc.cfg.Gateway.Hedging.MaxOutputTokens = 0
+	require.True(t, svc.ShouldHedgeMessages(&ParsedRequest{}))
+
+	var disabled *RequestHedgingService
+	require.False(t, disabled.ShouldHedgeMessages(&ParsedRequest{MaxTokens: 1}))
+}
+
+func TestRequestHedgingService_Delay(t *testing.T) {
+	svc := newTestHedgingService()
+
+	// 样本不足时使用上限
+	require.Equal(t, 5*time.Second, svc.Delay(HedgeKindMessages, "claude-haiku-4-5"))
+
+	for i := 1; i <= 10; i++ {
+		svc.RecordLatency(HedgeKindMessages, "claude-haiku-4-5", time.Duration(i)*100*time.Millisecond)
+	}
+	require.Equal(t, 900*time.Millisecond, svc.Delay(HedgeKindMessages, "claude-haiku-4-5"))
+	// 不同模型/类型分别统计
+	require.Equal(t, 5*time.Second, svc.Delay(HedgeKindCountTokens, "claude-haiku-4-5"))
+
+	// 窗口满后覆盖最旧样本，并限制在下限之上
+	for i := 0; i < 10; i++ {
+		svc.RecordLatency(HedgeKindMessages, "claude-haiku-4-5", 10*time.Millisecond)
+	}
+	require.Equal(t, 100*time.Millisecond, svc.Delay(HedgeKindMessages, "claude-haiku-4-5"))
+}
+
+func TestLatencyPercentile(t *testing.T) {
+	samples := []time.Duration{5, 1, 4, 2, 3}
+	require.Equal(t, time.Duration(1), latencyPercentile(samples, 1))
+	require.Equal(t, time.Duration(3), latencyPercentile(samples, 50))
+	require.Equal(t, time.Duration(5), latencyPercentile(samples, 99))
+	require.Equal(t, time.Duration(0), latencyPercentile(nil, 50))
+}
@@ -0,0 +1,319 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// 调度 dry-run：调度说明以只读副本运行真实的 selectAccountWithLoadAwareness，决策路径与线上调度完全一致。
+//   - 副本的粘性绑定写入、会话注册与槽位占用均替换为只读预测（见下方 dryRun* 包装）
+//   - 选号流程在各层入口、候选过滤与排序处回调 selectionTrace 记录判定过程
+//
+// 获取槽位后的熔断探测名额与 RPM 预算领取（ReserveAccountRequest）不执行：二者已由候选过滤中的只读检查覆盖。
+
+type selectionTraceKey struct{}
+
+// selectionTrace 调度说明的追踪记录，未挂到 ctx 上时所有方法均为空操作
+type selectionTrace struct {
+	exp      *SchedulerExplanation
+	accounts []Account
+	index    map[int64]int // 账号 ID -> exp.Candidates 下标
+	ranked   []int64       // 最近一次进入的层的尝试顺序
+}
+
+func newSelectionTrace(exp *SchedulerExplanation) *selectionTrace {
+	return &selectionTrace{exp: exp, index: map[int64]int{}}
+}
+
+func withSelectionTrace(ctx context.Context, trace *selectionTrace) context.Context {
+	return context.WithValue(ctx, selectionTraceKey{}, trace)
+}
+
+func selectionTraceFrom(ctx context.Context) *selectionTrace {
+	trace, _ := ctx.Value(selectionTraceKey{}).(*selectionTrace)
+	return trace
+}
+
+// sticky 记录会话当前绑定的粘性账号
+func (t *selectionTrace) sticky(accountID int64) {
+	if t == nil || accountID <= 0 {
+		return
+	}
+	t.exp.StickyAccountID = accountID
+}
+
+// scope 记录实际生效的分组（可能已降级）与调度平台
+func (t *selectionTrace) scope(groupID *int64, platform string, useMixed bool) {
+	if t == nil {
+		return
+	}
+	t.exp.GroupID = groupID
+	t.exp.Platform = platform
+	t.exp.UseMixed = useMixed
+}
+
+// routing 记录模型路由命中的账号
+func (t *selectionTrace) routing(accountIDs []int64) {
+	if t == nil {
+		return
+	}
+	t.exp.RoutingAccountIDs = accountIDs
+}
+
+// enter 记录进入的决策层，选号返回时最后进入的层即为决策层
+func (t *selectionTrace) enter(layer string) {
+	if t == nil {
+		return
+	}
+	t.exp.Layer = layer
+}
+
+// evaluate 按选号流程使用的过滤条件逐个评估调度池中的账号
+func (t *selectionTrace) evaluate(accounts []Account, rejection func(*Account) string) {
+	if t == nil {
+		return
+	}
+	now := time.Now()
+	t.accounts = accounts
+	t.index = make(map[int64]int, len(accounts))
+	t.exp.Candidates = make([]SchedulerCandidate, 0, len(accounts))
+	for i := range accounts {
+		acc := &accounts[i]
+		c := newSchedulerCandidate(acc)
+		c.Reason = rejection(acc)
+		switch c.Reason {
+		case "":
+			c.Accepted, c.Reason = true, SchedulerReasonEligible
+		case SchedulerReasonUnschedulable:
+			c.Detail = accountUnschedulableDetail(acc, now)
+		case SchedulerReasonPlatform:
+			c.Detail = "account platform " + acc.Platform
+		}
+		t.index[acc.ID] = len(t.exp.Candidates)
+		t.exp.Candidates = append(t.exp.Candidates, c)
+	}
+}
+
+// rank 记录当前层尝试获取槽位（或排队）的账号顺序
+func (t *selectionTrace) rank(n int, at func(int) *Account) {
+	if t == nil {
+		return
+	}
+	t.ranked = make([]int64, 0, n)
+	for i := 0; i < n; i++ {
+		t.ranked = append(t.ranked, at(i).ID)
+	}
+}
+
+// note 记录选号过程中的运行时判定；reason 非空时将通过过滤的候选标记为拒绝
+func (t *selectionTrace) note(accountID int64, reason, detail string) {
+	if t == nil {
+		return
+	}
+	i, ok := t.index[accountID]
+	if !ok {
+		return
+	}
+	c := &t.exp.Candidates[i]
+	if reason != "" && c.Accepted {
+		c.Accepted, c.Reason = false, reason
+	}
+	c.Detail = detail
+}
+
+// finish 根据选号结果补全决策、排序与候选标记
+func (t *selectionTrace) finish(result *AccountSelectionResult, err error) {
+	exp := t.exp
+	routed := make(map[int64]struct{}, len(exp.RoutingAccountIDs))
+	for _, id := range exp.RoutingAccountIDs {
+		routed[id] = struct{}{}
+	}
+	for _, id := range exp.RoutingAccountIDs {
+		if _, ok := t.index[id]; !ok {
+			// 路由规则中引用但不在调度池中的账号（未绑定分组、状态异常或已删除）
+			t.index[id] = len(exp.Candidates)
+			exp.Candidates = append(exp.Candidates, SchedulerCandidate{AccountID: id, Reason: SchedulerReasonNotInPool})
+		}
+	}
+
+	if err != nil || result == nil || result.Account == nil {
+		exp.Decision = SchedulerDecisionNone
+		exp.Layer = ""
+		exp.Error = "no available accounts"
+		if err != nil {
+			exp.Error = err.Error()
+		}
+		t.ranked = nil
+	} else {
+		exp.SelectedAccountID = result.Account.ID
+		exp.Decision = SchedulerDecisionWait
+		if result.Acquired {
+			exp.Decision = SchedulerDecisionAcquire
+		}
+		if !containsInt64(t.ranked, exp.SelectedAccountID) {
+			// 粘性会话与非负载感知路径直接命中单个账号
+			t.ranked = []int64{exp.SelectedAccountID}
+		}
+	}
+
+	rank := make(map[int64]int, len(t.ranked))
+	for i, id := range t.ranked {
+		if _, seen := rank[id]; !seen {
+			rank[id] = i + 1
+		}
+	}
+	for i := range exp.Candidates {
+		c := &exp.Candidates[i]
+		c.Rank = rank[c.AccountID]
+		_, c.Routed = routed[c.AccountID]
+		c.Sticky = exp.StickyAccountID > 0 && c.AccountID == exp.StickyAccountID
+		if c.AccountID == exp.SelectedAccountID && c.Sticky && c.Reason == SchedulerReasonWindowCost {
+			// 窗口费用达到上限的账号仍可服务已绑定的粘性会话
+			c.Accepted, c.Reason, c.Detail = true, SchedulerReasonEligible, "window cost limit reached: sticky session only"
+		}
+	}
+}
+
+// traceLegacyCandidates 非负载感知路径在 SelectAccountForModelWithExclusions 内部选号，按相同分组与平台评估候选账号
+func (s *GatewayService) traceLegacyCandidates(ctx context.Context, trace *selectionTrace, groupID *int64, group *Group, requestedModel string, excludedIDs map[int64]struct{}) error {
+	platform, hasForcePlatform, err := s.resolvePlatform(ctx, groupID, group)
+	if err != nil {
+		return err
+	}
+	accounts, useMixed, err := s.listSchedulableAccounts(ctx, groupID, platform, hasForcePlatform)
+	if err != nil {
+		return err
+	}
+	trace.scope(groupID, platform, useMixed)
+	_, rejection := s.accountFilter(ctx, accounts, excludedIDs, platform, useMixed, requestedModel)
+	trace.evaluate(accounts, rejection)
+	return nil
+}
+
+// traceLegacyCandidates 非负载感知路径在 selectAccountWithinLimits 内部选号，按相同分组评估候选账号
+func (s *OpenAIGatewayService) traceLegacyCandidates(ctx context.Context, trace *selectionTrace, groupID *int64, requestedModel string, excludedIDs map[int64]struct{}) error {
+	accounts, err := s.listSchedulableAccounts(ctx, groupID)
+	if err != nil {
+		return err
+	}
+	trace.scope(groupID, PlatformOpenAI, false)
+	_, rejection := s.accountFilter(ctx, accounts, excludedIDs, requestedModel)
+	trace.evaluate(accounts, rejection)
+	return nil
+}
+
+// dryRun 返回调度 dry-run 使用的只读副本
+func (s *GatewayService) dryRun() *GatewayService {
+	dry := *s
+	if s.cache != nil {
+		dry.cache = dryRunGatewayCache{GatewayCache: s.cache}
+	}
+	if s.sessionLimitCache != nil {
+		dry.sessionLimitCache = dryRunSessionLimitCache{SessionLimitCache: s.sessionLimitCache}
+	}
+	dry.concurrencyService = s.concurrencyService.dryRun()
+	return &dry
+}
+
+// dryRun 返回调度 dry-run 使用的只读副本
+func (s *OpenAIGatewayService) dryRun() *OpenAIGatewayService {
+	dry := *s
+	if s.cache != nil {
+		dry.cache = dryRunGatewayCache{GatewayCache: s.cache}
+	}
+	dry.concurrencyService = s.concurrencyService.dryRun()
+	return &dry
+}
+
+// dryRun 返回只预测、不占用槽位的副本（公平排队的让行判定与线上一致）
+func (s *ConcurrencyService) dryRun() *ConcurrencyService {
+	if s == nil {
+		return nil
+	}
+	dry := *s
+	if s.cache != nil {
+		dry.cache = dryRunConcurrencyCache{ConcurrencyCache: s.cache}
+	}
+	if s.fairQueueCache != nil {
+		dry.fairQueueCache = dryRunFairQueueCache{FairQueueCache: s.fairQueueCache, slots: dry.cache}
+	}
+	return &dry
+}
+
+// dryRunGatewayCache 读取粘性绑定，但不写入、不续期、不删除
+type dryRunGatewayCache struct {
+	GatewayCache
+}
+
+func (dryRunGatewayCache) SetSessionAccountID(ctx context.Context, groupID int64, sessionHash string, accountID int64, ttl time.Duration) error {
+	return nil
+}
+
+func (dryRunGatewayCache) RefreshSessionTTL(ctx context.Context, groupID int64, sessionHash string, ttl time.Duration) error {
+	return nil
+}
+
+func (dryRunGatewayCache) DeleteSessionAccountID(ctx context.Context, groupID int64, sessionHash string) error {
+	return nil
+}
+
+// dryRunSessionLimitCache 只检查会话数量限制，不注册会话
+type dryRunSessionLimitCache struct {
+	SessionLimitCache
+}
+
+func (c dryRunSessionLimitCache) RegisterSession(ctx context.Context, accountID int64, sessionUUID string, maxSessions int, idleTimeout time.Duration) (bool, error) {
+	if active, err := c.IsSessionActive(ctx, accountID, sessionUUID); err != nil || active {
+		return true, err
+	}
+	count, err := c.GetActiveSessionCount(ctx, accountID)
+	if err != nil {
+		return true, err
+	}
+	if count >= maxSessions {
+		selectionTraceFrom(ctx).note(accountID, SchedulerReasonSessionLimit, fmt.Sprintf("%d/%d active sessions", count, maxSessions))
+		return false, nil
+	}
+	return true, nil
+}
+
+func (dryRunSessionLimitCache) RefreshSession(ctx context.Context, accountID int64, sessionUUID string, idleTimeout time.Duration) error {
+	return nil
+}
+
+// dryRunConcurrencyCache 槽位未满即视为可获取，不占用也不释放槽位
+type dryRunConcurrencyCache struct {
+	ConcurrencyCache
+}
+
+func (c dryRunConcurrencyCache) AcquireAccountSlot(ctx context.Context, accountID int64, maxConcurrency int, requestID string) (bool, error) {
+	loads, err := c.GetAccountsLoadBatch(ctx, []AccountWithConcurrency{{ID: accountID, MaxConcurrency: maxConcurrency}})
+	if err != nil {
+		return false, err
+	}
+	info := loads[accountID]
+	return info == nil || info.CurrentConcurrency < maxConcurrency, nil
+}
+
+func (dryRunConcurrencyCache) ReleaseAccountSlot(ctx context.Context, accountID int64, requestID string) error {
+	return nil
+}
+
+// dryRunFairQueueCache 账号有排队者时与线上一样让行，否则按槽位是否未满预测
+type dryRunFairQueueCache struct {
+	FairQueueCache
+	slots ConcurrencyCache
+}
+
+func (c dryRunFairQueueCache) AcquireSlotIfNoWaiters(ctx context.Context, accountID int64, maxConcurrency int, requestID string, staleSeconds int) (bool, error) {
+	waiters, err := c.CountAccountWaiters(ctx, accountID, staleSeconds)
+	if err != nil {
+		return false, err
+	}
+	if waiters > 0 {
+		selectionTraceFrom(ctx).note(accountID, "", fmt.Sprintf("yields to %d fair queue waiter(s)", waiters))
+		return false, nil
+	}
+	return c.slots.AcquireAccountSlot(ctx, accountID, maxConcurrency, requestID)
+}
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+)
+
+// 调度说明（dry-run）：以只读副本运行真实的选号流程（见 scheduler_dry_run.go）并记录每个候选账号的判定，
+// 不获取并发槽位、不注册会话、不写入粘性绑定，也不消耗熔断半开探测名额。
+// 结果为预测值：真实请求时并发槽位可能已被其他请求占用。
+
+// 候选账号拒绝原因
+const (
+	SchedulerReasonEligible         = "eligible"
+	SchedulerReasonExcluded         = "excluded"
+	SchedulerReasonRateBudget       = "rate_budget_saturated"
+	SchedulerReasonCircuitOpen      = "circuit_open"
+	SchedulerReasonUnschedulable    = "unschedulable"
+	SchedulerReasonPlatform         = "platform_mismatch"
+	SchedulerReasonModelRateLimited = "model_rate_limited"
+	SchedulerReasonModelUnsupported = "model_unsupported"
+	SchedulerReasonWindowCost       = "window_cost_exceeded"
+	SchedulerReasonSessionLimit     = "session_limit"
+	SchedulerReasonNotInPool        = "not_in_pool"
+)
+
+// 调度决策结果
+const (
+	SchedulerDecisionAcquire = "acquire" // 直接获取槽位
+	SchedulerDecisionWait    = "wait"    // 进入等待队列
+	SchedulerDecisionNone    = "none"    // 无可用账号
+)
+
+// 调度决策所在层
+const (
+	SchedulerLayerLegacy       = "legacy"
+	SchedulerLayerModelRouting = "model_routing"
+	SchedulerLayerSticky       = "sticky_session"
+	SchedulerLayerLoadBalance  = "load_balance"
+	SchedulerLayerFallbackWait = "fallback_wait"
+)
+
+// SchedulerExplainInput 调度说明请求
+type SchedulerExplainInput struct {
+	GroupID     *int64
+	APIKeyID    *int64 // 指定时使用该 Key 绑定的分组（覆盖 GroupID）
+	Platform    string // 为空时按分组平台；非空时等同强制平台路由（如 /antigravity）
+	Model       string
+	SessionHash string
+	ExcludedIDs []int64
+	// ClaudeCodeClient 模拟 Claude Code 客户端（影响 claude_code_only 分组的降级判断）
+	ClaudeCodeClient bool
+}
+
+// SchedulerCandidate 单个候选账号的评估结果
+type SchedulerCandidate struct {
+	AccountID          int64  `json:"account_id"`
+	Name               string `json:"name"`
+	Platform           string `json:"platform"`
+	Type               string `json:"type"`
+	Priority           int    `json:"priority"`
+	Concurrency        int    `json:"concurrency"`
+	Accepted           bool   `json:"accepted"`
+	Reason             string `json:"reason"`
+	Detail             string `json:"detail,omitempty"`
+	Routed             bool   `json:"routed,omitempty"`
+	Sticky             bool   `json:"sticky,omitempty"`
+	LoadRate           *int   `json:"load_rate,omitempty"`
+	CurrentConcurrency int    `json:"current_concurrency"`
+	WaitingCount       int    `json:"waiting_count"`
+	// Rank 在决策层最终排序中的位置（从 1 开始，0 表示未参与该层排序）
+	Rank int `json:"rank,omitempty"`
+}
+
+// SchedulerExplanation 调度说明结果
+type SchedulerExplanation struct {
+	GroupID           *int64               `json:"group_id"`
+	Platform          string               `json:"platform"`
+	UseMixed          bool                 `json:"use_mixed"`
+	Model             string               `json:"model"`
+	SessionHash       string               `json:"session_hash,omitempty"`
+	SelectionMode     string               `json:"selection_mode"`
+	LoadBatchEnabled  bool                 `json:"load_batch_enabled"`
+	StickyAccountID   int64                `json:"sticky_account_id,omitempty"`
+	RoutingAccountIDs []int64              `json:"routing_account_ids,omitempty"`
+	Decision          string               `json:"decision"`
+	Layer             string               `json:"layer,omitempty"`
+	SelectedAccountID int64                `json:"selected_account_id,omitempty"`
+	Error             string               `json:"error,omitempty"`
+	Candidates        []SchedulerCandidate `json:"candidates"`
+}
+
+// SchedulerExplainService 调度说明服务（管理接口使用）
+type SchedulerExplainService struct {
+	gatewayService       *GatewayService
+	openAIGatewayService *OpenAIGatewayService
+	apiKeyRepo           APIKeyRepository
+}
+
+// NewSchedulerExplainService creates a new SchedulerExplainService
+func NewSchedulerExplainService(gatewayService *GatewayService, openAIGatewayService *OpenAIGatewayService, apiKeyRepo APIKeyRepository) *SchedulerExplainService {
+	return &SchedulerExplainService{
+		gatewayService:       gatewayService,
+		openAIGatewayService: openAIGatewayService,
+		apiKeyRepo:           apiKeyRepo,
+	}
+}
+
+// Explain 说明给定分组/API Key、模型与会话的调度决策
+func (s *SchedulerExplainService) Explain(ctx context.Context, input SchedulerExplainInput) (*SchedulerExplanation, error) {
+	groupID := input.GroupID
+	if input.APIKeyID != nil {
+		apiKey, err := s.apiKeyRepo.GetByID(ctx, *input.APIKeyID)
+		if err != nil {
+			return nil, err
+		}
+		groupID = apiKey.GroupID
+	}
+
+	excluded := make(map[int64]struct{}, len(input.ExcludedIDs))
+	for _, id := range input.ExcludedIDs {
+		excluded[id] = struct{}{}
+	}
+	sessionHash := strings.TrimSpace(input.SessionHash)
+	model := strings.TrimSpace(input.Model)
+
+	platform := strings.TrimSpace(input.Platform)
+	if groupID != nil {
+		group, err := s.gatewayService.resolveGroupByID(ctx, *groupID)
+		if err != nil {
+			return nil, err
+		}
+		// 与 API Key 鉴权中间件一致：分组写入上下文（粘性 TTL、成本优先路由等按上下文分组生效）
+		ctx = s.gatewayService.withGroupContext(ctx, group)
+		if platform == "" {
+			platform = group.Platform
+		}
+	}
+	if platform == PlatformOpenAI {
+		return s.openAIGatewayService.ExplainAccountSelection(ctx, groupID, sessionHash, model, excluded)
+	}
+
+	ctx = SetClaudeCodeClient(ctx, input.ClaudeCodeClient)
+	if strings.TrimSpace(input.Platform) != "" {
+		ctx = context.WithValue(ctx, ctxkey.ForcePlatform, platform)
+	}
+	return s.gatewayService.ExplainAccountSelection(ctx, groupID, sessionHash, model, excluded)
+}
+
+// ExplainAccountSelection 以 dry-run 方式运行 selectAccountWithLoadAwareness，说明真实调度会做出的决策
+func (s *GatewayService) ExplainAccountSelection(ctx context.Context, groupID *int64, sessionHash string, requestedModel string, excludedIDs map[int64]struct{}) (*SchedulerExplanation, error) {
+	exp := newSchedulerExplanation(s.schedulingConfig(), requestedModel, sessionHash)
+	exp.GroupID = groupID
+	trace := newSelectionTrace(exp)
+	result, err := s.dryRun().selectAccountWithLoadAwareness(withSelectionTrace(ctx, trace), groupID, sessionHash, requestedModel, excludedIDs)
+	trace.finish(result, err)
+	explainFillState(ctx, exp, trace.accounts, s.concurrencyService, s.circuitBreaker)
+	return exp, nil
+}
+
+// ExplainAccountSelection 以 dry-run 方式运行 OpenAI 的 selectAccountWithLoadAwareness，说明真实调度会做出的决策
+func (s *OpenAIGatewayService) ExplainAccountSelection(ctx context.Context, groupID *int64, sessionHash string, requestedModel string, excludedIDs map[int64]struct{}) (*SchedulerExplanation, error) {
+	exp := newSchedulerExplanation(s.schedulingConfig(), requestedModel, sessionHash)
+	exp.GroupID = groupID
+	exp.Platform = PlatformOpenAI
+	trace := newSelectionTrace(exp)
+	result, err := s.dryRun().selectAccountWithLoadAwareness(withSelectionTrace(ctx, trace), groupID, sessionHash, requestedModel, excludedIDs)
+	trace.finish(result, err)
+	explainFillState(ctx, exp, trace.accounts, s.concurrencyService, s.circuitBreaker)
+	return exp, nil
+}
+
+func newSchedulerExplanation(cfg config.GatewaySchedulingConfig, model, sessionHash string) *SchedulerExplanation {
+	mode := cfg.FallbackSelectionMode
+	if mode == "" {
+		mode = SelectionModeLastUsed
+	}
+	return &SchedulerExplanation{
+		Model:            model,
+		SessionHash:      sessionHash,
+		SelectionMode:    mode,
+		LoadBatchEnabled: cfg.LoadBatchEnabled,
+		Decision:         SchedulerDecisionNone,
+		Candidates:       []SchedulerCandidate{},
+	}
+}
+
+func newSchedulerCandidate(acc *Account) SchedulerCandidate {
+	return SchedulerCandidate{
+		AccountID:   acc.ID,
+		Name:        acc.Name,
+		Platform:    acc.Platform,
+		Type:        acc.Type,
+		Priority:    acc.Priority,
+		Concurrency: acc.Concurrency,
+	}
+}
+
+// accountUnschedulableDetail 说明 IsSchedulable 返回 false 的具体原因
+func accountUnschedulableDetail(a *Account, now time.Time) string {
+	switch {
+	case !a.IsActive():
+		return "status: " + a.Status
+	case !a.Schedulable:
+		return "scheduling disabled"
+	case a.AutoPauseOnExpired && a.ExpiresAt != nil && !now.Before(*a.ExpiresAt):
+		return "account expired"
+	case a.OverloadUntil != nil && now.Before(*a.OverloadUntil):
+		return "overloaded until " + a.OverloadUntil.Format(time.RFC3339)
+	case a.RateLimitResetAt != nil && now.Before(*a.RateLimitResetAt):
+		return "rate limited until " + a.RateLimitResetAt.Format(time.RFC3339)
+	case a.TempUnschedulableUntil != nil && now.Before(*a.TempUnschedulableUntil):
+		return "temporarily unschedulable until " + a.TempUnschedulableUntil.Format(time.RFC3339)
+	case !a.IsWithinAvailabilitySchedule(now):
+		if next := a.AvailabilitySchedule.NextTransition(now); next != nil {
+			return "outside availability window until " + next.Format(time.RFC3339)
+		}
+		return "outside availability window"
+	}
+	return ""
+}
+
+// explainFillState 只读补充候选账号的当前负载与熔断恢复时间
+func explainFillState(ctx context.Context, exp *SchedulerExplanation, accounts []Account, concurrencyService *ConcurrencyService, breaker *AccountCircuitBreakerService) {
+	if len(accounts) == 0 {
+		return
+	}
+	ids := make([]int64, 0, len(accounts))
+	loads := make([]AccountWithConcurrency, 0, len(accounts))
+	for i := range accounts {
+		ids = append(ids, accounts[i].ID)
+		loads = append(loads, AccountWithConcurrency{ID: accounts[i].ID, MaxConcurrency: accounts[i].Concurrency})
+	}
+	var loadMap map[int64]*AccountLoadInfo
+	if concurrencyService != nil {
+		loadMap, _ = concurrencyService.GetAccountsLoadBatch(ctx, loads)
+	}
+	states, _ := breaker.GetStates(ctx, ids)
+
+	for i := range exp.Candidates {
+		c := &exp.Candidates[i]
+		if info := loadMap[c.AccountID]; info != nil {
+			rate := info.LoadRate
+			c.LoadRate = &rate
+			c.CurrentConcurrency = info.CurrentConcurrency
+			c.WaitingCount = info.WaitingCount
+		}
+		if state := states[c.AccountID]; c.Reason == SchedulerReasonCircuitOpen && state != nil && state.State == CircuitStateOpen {
+			c.Detail = "open until " + time.Unix(state.OpenUntilUnix, 0).Format(time.RFC3339)
+		}
+	}
+}
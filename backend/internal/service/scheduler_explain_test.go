@@ -0,0 +1,236 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func explainCandidate(t *testing.T, exp *SchedulerExplanation, accountID int64) SchedulerCandidate {
+	t.Helper()
+	for _, c := range exp.Candidates {
+		if c.AccountID == accountID {
+			return c
+		}
+	}
+	t.Fatalf("candidate %d not found", accountID)
+	return SchedulerCandidate{}
+}
+
+func TestGatewayExplainAccountSelection(t *testing.T) {
+	ctx := context.Background()
+	resetAt := time.Now().Add(time.Hour)
+	repo := &mockAccountRepoForPlatform{
+		accounts: []Account{
+			{ID: 1, Platform: PlatformAnthropic, Priority: 1, Status: StatusActive, Schedulable: true, Concurrency: 5,
+				Extra: map[string]any{"model_rate_limits": map[string]any{
+					"claude_sonnet": map[string]any{"rate_limit_reset_at": resetAt.Format(time.RFC3339)},
+				}}},
+			{ID: 2, Platform: PlatformAnthropic, Priority: 1, Status: StatusActive, Schedulable: true, Concurrency: 5},
+			{ID: 3, Platform: PlatformAnthropic, Priority: 1, Status: StatusActive, Schedulable: true, Concurrency: 5},
+			{ID: 4, Platform: PlatformAnthropic, Priority: 2, Status: StatusActive, Schedulable: true, Concurrency: 5},
+		},
+		accountsByID: map[int64]*Account{},
+	}
+	for i := range repo.accounts {
+		repo.accountsByID[repo.accounts[i].ID] = &repo.accounts[i]
+	}
+	cfg := testConfig()
+	cfg.Gateway.Scheduling.LoadBatchEnabled = true
+	cfg.Gateway.Scheduling.StickySessionMaxWaiting = 3
+	concurrencyCache := &mockConcurrencyCache{loadMap: map[int64]*AccountLoadInfo{
+		3: {AccountID: 3, CurrentConcurrency: 5, LoadRate: 100},
+	}}
+	svc := &GatewayService{
+		accountRepo:        repo,
+		cache:              &mockGatewayCacheForPlatform{sessionBindings: map[string]int64{"sticky": 3}},
+		cfg:                cfg,
+		concurrencyService: NewConcurrencyService(concurrencyCache),
+	}
+
+	exp, err := svc.ExplainAccountSelection(ctx, nil, "", "claude-3-5-sonnet-20241022", map[int64]struct{}{2: {}})
+	require.NoError(t, err)
+	require.Equal(t, SchedulerDecisionAcquire, exp.Decision)
+	require.Equal(t, SchedulerLayerLoadBalance, exp.Layer)
+	require.Equal(t, int64(4), exp.SelectedAccountID)
+	require.Equal(t, SchedulerReasonModelRateLimited, explainCandidate(t, exp, 1).Reason)
+	require.Equal(t, SchedulerReasonExcluded, explainCandidate(t, exp, 2).Reason)
+	full := explainCandidate(t, exp, 3)
+	require.True(t, full.Accepted)
+	require.Equal(t, 100, *full.LoadRate)
+	require.Equal(t, 1, explainCandidate(t, exp, 4).Rank)
+	require.Equal(t, 0, concurrencyCache.acquireAccountCalls, "dry-run 不应获取槽位")
+
+	// 粘性账号负载已满但等待队列未满：排队等待粘性账号
+	exp, err = svc.ExplainAccountSelection(ctx, nil, "sticky", "claude-3-5-sonnet-20241022", nil)
+	require.NoError(t, err)
+	require.Equal(t, SchedulerDecisionWait, exp.Decision)
+	require.Equal(t, SchedulerLayerSticky, exp.Layer)
+	require.Equal(t, int64(3), exp.SelectedAccountID)
+	require.True(t, explainCandidate(t, exp, 3).Sticky)
+
+	// 全部不可用
+	exp, err = svc.ExplainAccountSelection(ctx, nil, "", "claude-3-5-sonnet-20241022", map[int64]struct{}{2: {}, 3: {}, 4: {}})
+	require.NoError(t, err)
+	require.Equal(t, SchedulerDecisionNone, exp.Decision)
+	require.Equal(t, "no available accounts", exp.Error)
+}
+
+func TestOpenAIExplainAccountSelection(t *testing.T) {
+	groupID := int64(1)
+	repo := stubOpenAIAccountRepo{
+		accounts: []Account{
+			{ID: 1, Platform: PlatformOpenAI, Status: StatusActive, Schedulable: true, Concurrency: 1, Priority: 1,
+				Credentials: map[string]any{"model_mapping": map[string]any{"gpt-4o": "gpt-4o"}}},
+			{ID: 2, Platform: PlatformOpenAI, Status: StatusActive, Schedulable: true, Concurrency: 1, Priority: 2,
+				Extra: map[string]any{"rpm_limit": float64(5)}},
+			{ID: 3, Platform: PlatformOpenAI, Status: StatusActive, Schedulable: true, Concurrency: 1, Priority: 3},
+		},
+	}
+	budgetCache := &stubRateBudgetCache{usage: map[int64]AccountRateUsage{2: {Requests: 5}}}
+	svc := &OpenAIGatewayService{
+		accountRepo:        repo,
+		cache:              &stubGatewayCache{},
+		cfg:                testConfig(),
+		concurrencyService: NewConcurrencyService(stubConcurrencyCache{}),
+		rateBudgetService:  NewAccountRateBudgetService(budgetCache),
+	}
+	svc.cfg.Gateway.Scheduling.LoadBatchEnabled = true
+
+	exp, err := svc.ExplainAccountSelection(context.Background(), &groupID, "", "gpt-4", nil)
+	require.NoError(t, err)
+	require.Equal(t, PlatformOpenAI, exp.Platform)
+	require.Equal(t, int64(3), exp.SelectedAccountID)
+	require.Equal(t, SchedulerReasonModelUnsupported, explainCandidate(t, exp, 1).Reason)
+	require.Equal(t, SchedulerReasonRateBudget, explainCandidate(t, exp, 2).Reason)
+	// dry-run 不计入 RPM
+	require.Equal(t, int64(0), budgetCache.usage[3].Requests)
+}
+
+func TestExplainAccountSelection_CostRouting(t *testing.T) {
+	ctx := context.Background()
+	groupID := int64(7)
+	group := &Group{ID: groupID, Platform: PlatformAnthropic, Status: StatusActive, Hydrated: true, CostRoutingPolicy: CostRoutingPolicyLowestCost}
+	recently := time.Now().Add(-time.Minute)
+	repo := &mockAccountRepoForPlatform{
+		accounts: []Account{
+			// 默认顺序首选（从未使用），但倍率最高
+			{ID: 1, Platform: PlatformAnthropic, Priority: 1, Status: StatusActive, Schedulable: true, Concurrency: 5, RateMultiplier: ptr(2.0)},
+			{ID: 2, Platform: PlatformAnthropic, Priority: 1, Status: StatusActive, Schedulable: true, Concurrency: 5, RateMultiplier: ptr(0.5), LastUsedAt: &recently},
+			// 成本最低但负载已满，溢出到次低成本账号
+			{ID: 3, Platform: PlatformAnthropic, Priority: 1, Status: StatusActive, Schedulable: true, Concurrency: 5, RateMultiplier: ptr(0.1)},
+		},
+		accountsByID: map[int64]*Account{},
+	}
+	for i := range repo.accounts {
+		repo.accountsByID[repo.accounts[i].ID] = &repo.accounts[i]
+	}
+	cfg := testConfig()
+	cfg.Gateway.Scheduling.LoadBatchEnabled = true
+	concurrencyCache := &mockConcurrencyCache{loadMap: map[int64]*AccountLoadInfo{
+		3: {AccountID: 3, CurrentConcurrency: 5, LoadRate: 100},
+	}}
+	gateway := &GatewayService{
+		accountRepo:        repo,
+		groupRepo:          &mockGroupRepoForGateway{groups: map[int64]*Group{groupID: group}},
+		cache:              &mockGatewayCacheForPlatform{},
+		cfg:                cfg,
+		concurrencyService: NewConcurrencyService(concurrencyCache),
+	}
+	svc := NewSchedulerExplainService(gateway, nil, nil)
+
+	exp, err := svc.Explain(ctx, SchedulerExplainInput{GroupID: &groupID, Model: "claude-3-5-sonnet-20241022"})
+	require.NoError(t, err)
+	require.Equal(t, SchedulerLayerLoadBalance, exp.Layer)
+	require.Equal(t, int64(2), exp.SelectedAccountID)
+	require.Equal(t, 1, explainCandidate(t, exp, 2).Rank)
+	require.Equal(t, 2, explainCandidate(t, exp, 1).Rank)
+	require.Equal(t, 0, explainCandidate(t, exp, 3).Rank)
+
+	// 与真实调度选中同一账号
+	result, err := gateway.SelectAccountWithLoadAwareness(ctx, &groupID, "", "claude-3-5-sonnet-20241022", nil, "")
+	require.NoError(t, err)
+	require.True(t, result.Acquired)
+	require.Equal(t, exp.SelectedAccountID, result.Account.ID)
+	require.Equal(t, int64(1), result.CostRouting.BaselineAccountID)
+}
+
+func TestOpenAIExplainAccountSelection_CostRouting(t *testing.T) {
+	ctx := context.Background()
+	groupID := int64(8)
+	group := &Group{ID: groupID, Platform: PlatformOpenAI, Status: StatusActive, Hydrated: true, CostRoutingPolicy: CostRoutingPolicyLowestCost}
+	repo := stubOpenAIAccountRepo{
+		accounts: []Account{
+			{ID: 1, Platform: PlatformOpenAI, Status: StatusActive, Schedulable: true, Concurrency: 1, Priority: 1, RateMultiplier: ptr(2.0)},
+			{ID: 2, Platform: PlatformOpenAI, Status: StatusActive, Schedulable: true, Concurrency: 1, Priority: 2, RateMultiplier: ptr(0.5)},
+		},
+	}
+	cfg := testConfig()
+	cfg.Gateway.Scheduling.LoadBatchEnabled = true
+	openai := &OpenAIGatewayService{
+		accountRepo:        repo,
+		cache:              &stubGatewayCache{},
+		cfg:                cfg,
+		concurrencyService: NewConcurrencyService(stubConcurrencyCache{}),
+	}
+	gateway := &GatewayService{groupRepo: &mockGroupRepoForGateway{groups: map[int64]*Group{groupID: group}}}
+	svc := NewSchedulerExplainService(gateway, openai, nil)
+
+	exp, err := svc.Explain(ctx, SchedulerExplainInput{GroupID: &groupID, Model: "gpt-4o"})
+	require.NoError(t, err)
+	require.Equal(t, PlatformOpenAI, exp.Platform)
+	require.Equal(t, SchedulerLayerLoadBalance, exp.Layer)
+	require.Equal(t, int64(2), exp.SelectedAccountID)
+	require.Equal(t, 2, explainCandidate(t, exp, 1).Rank)
+}
+
+func TestExplainAccountSelection_FairQueueYield(t *testing.T) {
+	ctx := context.Background()
+	repo := &mockAccountRepoForPlatform{
+		accounts: []Account{
+			{ID: 1, Platform: PlatformAnthropic, Priority: 1, Status: StatusActive, Schedulable: true, Concurrency: 5},
+			{ID: 2, Platform: PlatformAnthropic, Priority: 2, Status: StatusActive, Schedulable: true, Concurrency: 5},
+		},
+		accountsByID: map[int64]*Account{},
+	}
+	for i := range repo.accounts {
+		repo.accountsByID[repo.accounts[i].ID] = &repo.accounts[i]
+	}
+	cfg := testConfig()
+	cfg.Gateway.Scheduling.LoadBatchEnabled = true
+	concurrencyCache := &mockConcurrencyCache{}
+	concurrency := NewConcurrencyService(concurrencyCache)
+	fairQueue := &stubFairQueueCache{waiting: map[int64]int{1: 2}}
+	concurrency.SetFairQueue(fairQueue, fairQueueTestConfig())
+	cache := &mockGatewayCacheForPlatform{}
+	svc := &GatewayService{
+		accountRepo:        repo,
+		cache:              cache,
+		cfg:                cfg,
+		concurrencyService: concurrency,
+	}
+
+	// 首选账号有公平队列排队者：未排队的新请求让行，改选下一个账号
+	exp, err := svc.ExplainAccountSelection(ctx, nil, "new-session", "claude-3-5-sonnet-20241022", nil)
+	require.NoError(t, err)
+	require.Equal(t, SchedulerDecisionAcquire, exp.Decision)
+	require.Equal(t, SchedulerLayerLoadBalance, exp.Layer)
+	require.Equal(t, int64(2), exp.SelectedAccountID)
+	yielded := explainCandidate(t, exp, 1)
+	require.True(t, yielded.Accepted)
+	require.Equal(t, 1, yielded.Rank)
+	require.Equal(t, "yields to 2 fair queue waiter(s)", yielded.Detail)
+	require.Equal(t, 2, explainCandidate(t, exp, 2).Rank)
+	// dry-run 不占用槽位、不写入粘性绑定
+	require.Empty(t, fairQueue.direct)
+	require.Zero(t, concurrencyCache.acquireAccountCalls)
+	require.Empty(t, cache.sessionBindings)
+
+	result, err := svc.SelectAccountWithLoadAwareness(ctx, nil, "new-session", "claude-3-5-sonnet-20241022", nil, "")
+	require.NoError(t, err)
+	require.Equal(t, exp.SelectedAccountID, result.Account.ID)
+}
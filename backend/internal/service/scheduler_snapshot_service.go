@@ -336,7 +336,7 @@ func (s *SchedulerSnapshotService) handleAccountEvent(ctx context.Context, accou
 		}
 	}
 	if len(groupIDs) == 0 {
-		groupIDs = account.GroupIDs
+		groupIDs = append(append([]int64{}, account.GroupIDs...), account.TagGroupIDs...)
 	}
 	return s.rebuildByAccount(ctx, account, groupIDs, "account_change")
 }
@@ -370,6 +370,11 @@ func (s *SchedulerSnapshotService) rebuildByAccount(ctx context.Context, account
 			firstErr = err
 		}
 	}
+	if account.Platform == PlatformOpenAI && account.IsMixedSchedulingEnabled() {
+		if err := s.rebuildBucketsForPlatform(ctx, PlatformAnthropic, groupIDs, reason); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
 	return firstErr
 }
 
@@ -517,6 +522,15 @@ func (s *SchedulerSnapshotService) checkOutboxLag(ctx context.Context, oldest Sc
 }
 
 func (s *SchedulerSnapshotService) loadAccountsFromDB(ctx context.Context, bucket SchedulerBucket, useMixed bool) ([]Account, error) {
+	accounts, err := s.loadBucketAccountsFromDB(ctx, bucket, useMixed)
+	if err != nil {
+		return nil, err
+	}
+	// 可用时间窗口之外的账号不进入快照，窗口切换时由 AccountAvailabilityService 投递 outbox 事件重建
+	return filterAvailableAccounts(accounts, time.Now()), nil
+}
+
+func (s *SchedulerSnapshotService) loadBucketAccountsFromDB(ctx context.Context, bucket SchedulerBucket, useMixed bool) ([]Account, error) {
 	if s.accountRepo == nil {
 		return nil, ErrSchedulerCacheNotReady
 	}
@@ -526,7 +540,7 @@ func (s *SchedulerSnapshotService) loadAccountsFromDB(ctx context.Context, bucke
 	}
 
 	if useMixed {
-		platforms := []string{bucket.Platform, PlatformAntigravity}
+		platforms := MixedSchedulingPlatforms(bucket.Platform)
 		var accounts []Account
 		var err error
 		if groupID > 0 {
@@ -539,7 +553,7 @@ func (s *SchedulerSnapshotService) loadAccountsFromDB(ctx context.Context, bucke
 		}
 		filtered := make([]Account, 0, len(accounts))
 		for _, acc := range accounts {
-			if acc.Platform == PlatformAntigravity && !acc.IsMixedSchedulingEnabled() {
+			if !acc.IsAllowedInMixedScheduling(bucket.Platform) {
 				continue
 			}
 			filtered = append(filtered, acc)
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	"github.com/gin-gonic/gin"
+)
+
+// 分组粘性会话策略
+const (
+	// StickyPolicyContentHash 按请求内容（metadata 会话、cache_control 内容、system、首条消息）计算会话哈希（默认）
+	StickyPolicyContentHash = "content_hash"
+	// StickyPolicyMetadataUserID 按客户端标识的终端用户粘性（Claude metadata.user_id / OpenAI user），缺失时回退 content_hash
+	StickyPolicyMetadataUserID = "metadata_user_id"
+	// StickyPolicyAPIKey 同一 API Key 的所有请求粘性到同一账号
+	StickyPolicyAPIKey = "api_key"
+	// StickyPolicyNone 不使用粘性会话，每次请求独立调度
+	StickyPolicyNone = "none"
+)
+
+// maxStickyTTLSeconds 分组粘性 TTL 上限（7 天）
+const maxStickyTTLSeconds = 7 * 24 * 3600
+
+// IsValidStickyPolicy 校验粘性会话策略（空值视为默认策略）
+func IsValidStickyPolicy(policy string) bool {
+	switch policy {
+	case "", StickyPolicyContentHash, StickyPolicyMetadataUserID, StickyPolicyAPIKey, StickyPolicyNone:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsValidStickyTTLSeconds 校验粘性会话 TTL（0 表示使用默认值）
+func IsValidStickyTTLSeconds(seconds int) bool {
+	return seconds >= 0 && seconds <= maxStickyTTLSeconds
+}
+
+// EffectiveStickyPolicy 返回分组生效的粘性会话策略，未分组或未配置时为 content_hash
+func (g *Group) EffectiveStickyPolicy() string {
+	if g == nil || g.StickyPolicy == "" {
+		return StickyPolicyContentHash
+	}
+	return g.StickyPolicy
+}
+
+// groupStickySessionTTL 返回上下文分组配置的粘性会话 TTL，未配置时返回平台默认值
+func groupStickySessionTTL(ctx context.Context, defaultTTL time.Duration) time.Duration {
+	if ctx == nil {
+		return defaultTTL
+	}
+	if group, ok := ctx.Value(ctxkey.Group).(*Group); ok && IsGroupContextValid(group) && group.StickyTTLSeconds > 0 {
+		return time.Duration(group.StickyTTLSeconds) * time.Second
+	}
+	return defaultTTL
+}
+
+// stickyIdentityHash 将策略标识（API Key / 终端用户）散列为会话哈希，与 hashContent 长度一致
+func stickyIdentityHash(kind, identity string) string {
+	hash := sha256.Sum256([]byte(kind + ":" + identity))
+	return hex.EncodeToString(hash[:16])
+}
+
+// ResolveSessionHash 按分组粘性策略计算会话哈希；返回空串表示本次请求不使用粘性会话
+func (s *GatewayService) ResolveSessionHash(group *Group, apiKeyID int64, parsed *ParsedRequest) string {
+	switch group.EffectiveStickyPolicy() {
+	case StickyPolicyNone:
+		return ""
+	case StickyPolicyAPIKey:
+		if apiKeyID > 0 {
+			return stickyIdentityHash("api_key", strconv.FormatInt(apiKeyID, 10))
+		}
+	case StickyPolicyMetadataUserID:
+		if parsed != nil && strings.TrimSpace(parsed.MetadataUserID) != "" {
+			return stickyIdentityHash("user", strings.TrimSpace(parsed.MetadataUserID))
+		}
+	}
+	return s.GenerateSessionHash(parsed)
+}
+
+// ResolveSessionHash 按分组粘性策略计算 OpenAI 会话哈希；content_hash 策略沿用 GenerateSessionHash
+// （session_id / conversation_id / prompt_cache_key），metadata_user_id 策略使用请求体 user 字段
+func (s *OpenAIGatewayService) ResolveSessionHash(c *gin.Context, group *Group, apiKeyID int64, reqBody map[string]any) string {
+	switch group.EffectiveStickyPolicy() {
+	case StickyPolicyNone:
+		return ""
+	case StickyPolicyAPIKey:
+		if apiKeyID > 0 {
+			return stickyIdentityHash("api_key", strconv.FormatInt(apiKeyID, 10))
+		}
+	case StickyPolicyMetadataUserID:
+		if user, _ := reqBody["user"].(string); strings.TrimSpace(user) != "" {
+			return stickyIdentityHash("user", strings.TrimSpace(user))
+		}
+	}
+	return s.GenerateSessionHash(c, reqBody)
+}
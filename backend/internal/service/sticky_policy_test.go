@@ -0,0 +1,86 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGatewayService_ResolveSessionHash(t *testing.T) {
+	svc := &GatewayService{}
+	parsed := &ParsedRequest{
+		MetadataUserID: "user_abc_account__session_123e4567-e89b-12d3-a456-426614174000",
+		System:         "You are a helpful assistant.",
+	}
+	contentHash := svc.GenerateSessionHash(parsed)
+	require.NotEmpty(t, contentHash)
+
+	// 未分组或未配置策略时沿用内容哈希
+	require.Equal(t, contentHash, svc.ResolveSessionHash(nil, 1, parsed))
+	require.Equal(t, contentHash, svc.ResolveSessionHash(&Group{}, 1, parsed))
+
+	require.Empty(t, svc.ResolveSessionHash(&Group{StickyPolicy: StickyPolicyNone}, 1, parsed))
+
+	byKey := &Group{StickyPolicy: StickyPolicyAPIKey}
+	keyHash := svc.ResolveSessionHash(byKey, 7, parsed)
+	require.Len(t, keyHash, 32)
+	require.Equal(t, keyHash, svc.ResolveSessionHash(byKey, 7, &ParsedRequest{System: "other"}))
+	require.NotEqual(t, keyHash, svc.ResolveSessionHash(byKey, 8, parsed))
+
+	byUser := &Group{StickyPolicy: StickyPolicyMetadataUserID}
+	userHash := svc.ResolveSessionHash(byUser, 7, parsed)
+	require.NotEqual(t, contentHash, userHash)
+	require.NotEqual(t, keyHash, userHash)
+	// 缺少 metadata.user_id 时回退内容哈希
+	noUser := &ParsedRequest{System: "You are a helpful assistant."}
+	require.Equal(t, svc.GenerateSessionHash(noUser), svc.ResolveSessionHash(byUser, 7, noUser))
+}
+
+func TestOpenAIGatewayService_ResolveSessionHash(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("POST", "/v1/responses", nil)
+	c.Request.Header.Set("session_id", "sess-1")
+
+	svc := &OpenAIGatewayService{}
+	body := map[string]any{"user": "end-user-1"}
+	headerHash := svc.GenerateSessionHash(c, body)
+
+	require.Equal(t, headerHash, svc.ResolveSessionHash(c, nil, 1, body))
+	require.Empty(t, svc.ResolveSessionHash(c, &Group{StickyPolicy: StickyPolicyNone}, 1, body))
+	require.NotEqual(t, headerHash, svc.ResolveSessionHash(c, &Group{StickyPolicy: StickyPolicyMetadataUserID}, 1, body))
+	require.Equal(t,
+		svc.ResolveSessionHash(c, &Group{StickyPolicy: StickyPolicyAPIKey}, 1, body),
+		svc.ResolveSessionHash(c, &Group{StickyPolicy: StickyPolicyAPIKey}, 1, map[string]any{}))
+}
+
+func TestGroupStickySessionTTL(t *testing.T) {
+	require.Equal(t, time.Hour, groupStickySessionTTL(context.Background(), time.Hour))
+
+	group := &Group{ID: 1, Platform: PlatformAnthropic, Status: StatusActive, Hydrated: true, StickyTTLSeconds: 300}
+	ctx := context.WithValue(context.Background(), ctxkey.Group, group)
+	require.Equal(t, 5*time.Minute, groupStickySessionTTL(ctx, time.Hour))
+
+	group.StickyTTLSeconds = 0
+	require.Equal(t, time.Hour, groupStickySessionTTL(ctx, time.Hour))
+}
+
+func TestPromptCacheHitRatio(t *testing.T) {
+	require.Zero(t, PromptCacheHitRatio(0, 0))
+	require.InDelta(t, 0.75, PromptCacheHitRatio(300, 100), 1e-9)
+	require.InDelta(t, 1.0, PromptCacheHitRatio(10, 0), 1e-9)
+}
+
+func TestUsageSessionHash(t *testing.T) {
+	require.Nil(t, usageSessionHash(""))
+	long := "resp:" + string(make([]byte, 100))
+	require.Len(t, *usageSessionHash(long), maxUsageSessionHashLen)
+	require.Equal(t, "abc", *usageSessionHash("abc"))
+}
@@ -0,0 +1,405 @@
+package service
+
+import (
+	"context"
+	"log"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/tokenizer"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	tokenCountDriftRecordTimeout = 3 * time.Second
+	tokenCountDriftMaxHours      = 7 * 24
+)
+
+// TokenCountDriftBucket 某小时某 tokenizer 族的估算偏差累计值
+type TokenCountDriftBucket struct {
+	Hour            time.Time
+	Family          string
+	Samples         int64
+	EstimatedTokens int64
+	UpstreamTokens  int64
+	AbsErrorTokens  int64
+}
+
+// TokenCountDriftCache 存储本地估算与上游结果的对比采样（按小时聚合）
+type TokenCountDriftCache interface {
+	RecordTokenCountDrift(ctx context.Context, at time.Time, family string, estimated, upstream int) error
+	GetTokenCountDrift(ctx context.Context, hours []time.Time) ([]TokenCountDriftBucket, error)
+}
+
+// TokenCountDriftStat 某 tokenizer 族在窗口内的偏差统计
+// Heuristic 表示该族没有公开 tokenizer（如 Claude），本地结果为启发式估算
+type TokenCountDriftStat struct {
+	Family          string  `json:"family"`
+	Heuristic       bool    `json:"heuristic"`
+	Samples         int64   `json:"samples"`
+	EstimatedTokens int64   `json:"estimated_tokens"`
+	UpstreamTokens  int64   `json:"upstream_tokens"`
+	MeanAbsErrorPct float64 `json:"mean_abs_error_pct"`
+	BiasPct         float64 `json:"bias_pct"`
+}
+
+// TokenCountDriftPoint 偏差按小时的时间序列点
+type TokenCountDriftPoint struct {
+	Hour            time.Time `json:"hour"`
+	Family          string    `json:"family"`
+	Samples         int64     `json:"samples"`
+	MeanAbsErrorPct float64   `json:"mean_abs_error_pct"`
+	BiasPct         float64   `json:"bias_pct"`
+}
+
+// TokenCountDriftReport 运维监控中的 count_tokens 估算偏差报告
+type TokenCountDriftReport struct {
+	Mode        string                 `json:"mode"`
+	SampleRate  float64                `json:"sample_rate"`
+	WindowHours int                    `json:"window_hours"`
+	Families    []TokenCountDriftStat  `json:"families"`
+	Hourly      []TokenCountDriftPoint `json:"hourly"`
+}
+
+// TokenCountEstimator 使用内置 tokenizer 在本地估算 count_tokens 结果，
+// 并可按采样率记录与上游结果的偏差。
+type TokenCountEstimator struct {
+	cfg        *config.Config
+	driftCache TokenCountDriftCache
+}
+
+// NewTokenCountEstimator creates a new TokenCountEstimator
+func NewTokenCountEstimator(cfg *config.Config, driftCache TokenCountDriftCache) *TokenCountEstimator {
+	return &TokenCountEstimator{cfg: cfg, driftCache: driftCache}
+}
+
+// Mode 返回当前 count_tokens 处理模式（默认 upstream）
+func (e *TokenCountEstimator) Mode() string {
+	if e == nil || e.cfg == nil || e.cfg.Gateway.CountTokens.Mode == "" {
+		return config.CountTokensModeUpstream
+	}
+	return e.cfg.Gateway.CountTokens.Mode
+}
+
+// LocalOnly 是否始终本地估算（不占用上游账号）
+func (e *TokenCountEstimator) LocalOnly() bool {
+	return e.Mode() == config.CountTokensModeLocal
+}
+
+// FallbackEnabled 上游不支持或失败时是否返回本地估算
+func (e *TokenCountEstimator) FallbackEnabled() bool {
+	return e.Mode() != config.CountTokensModeUpstream
+}
+
+func (e *TokenCountEstimator) sampleRate() float64 {
+	if e == nil || e.cfg == nil {
+		return 0
+	}
+	return e.cfg.Gateway.CountTokens.DriftSampleRate
+}
+
+// EstimateClaudeRequest 估算 Anthropic Messages 请求的 input_tokens
+func (e *TokenCountEstimator) EstimateClaudeRequest(model string, body []byte) int {
+	return estimateClaudeRequestTokens(model, body)
+}
+
+// estimateClaudeRequestTokens 只统计 system、messages 内容与工具定义，不计 model/role/type 等结构字段
+func estimateClaudeRequestTokens(model string, body []byte) int {
+	tk := tokenizer.ForModel(model)
+	p := tk.Profile()
+	root := gjson.ParseBytes(body)
+
+	total := p.RequestOverhead
+	system := root.Get("system")
+	if system.Type == gjson.String {
+		total += tk.Count(system.String())
+	} else if system.IsArray() {
+		for _, block := range system.Array() {
+			total += countClaudeContentBlock(tk, block)
+		}
+	}
+
+	for _, msg := range root.Get("messages").Array() {
+		total += p.MessageOverhead
+		total += countClaudeContent(tk, msg.Get("content"))
+	}
+
+	for _, tool := range root.Get("tools").Array() {
+		total += p.ToolOverhead
+		total += tk.Count(tool.Get("name").String())
+		total += tk.Count(tool.Get("description").String())
+		if schema := tool.Get("input_schema"); schema.Exists() {
+			total += tk.Count(schema.Raw)
+		}
+	}
+	return total
+}
+
+func countClaudeContent(tk *tokenizer.Tokenizer, content gjson.Result) int {
+	if content.Type == gjson.String {
+		return tk.Count(content.String())
+	}
+	total := 0
+	for _, block := range content.Array() {
+		total += countClaudeContentBlock(tk, block)
+	}
+	return total
+}
+
+func countClaudeContentBlock(tk *tokenizer.Tokenizer, block gjson.Result) int {
+	switch block.Get("type").String() {
+	case "text":
+		return tk.Count(block.Get("text").String())
+	case "image":
+		return tk.Profile().ImageTokens
+	case "document":
+		// 纯文本文档按内容计数，其余（PDF/URL）按固定值估算
+		if block.Get("source.type").String() == "text" {
+			return tk.Count(block.Get("source.data").String())
+		}
+		return tk.Profile().ImageTokens
+	case "tool_use", "server_tool_use":
+		return tk.Count(block.Get("name").String()) + tk.Count(block.Get("input").Raw)
+	case "tool_result":
+		return countClaudeContent(tk, block.Get("content"))
+	case "thinking", "redacted_thinking":
+		// 历史轮次的 thinking 不计入上下文
+		return 0
+	default:
+		return tk.Count(block.Raw)
+	}
+}
+
+// EstimateGeminiRequest 估算 Gemini generateContent/countTokens 请求的 totalTokens
+func (e *TokenCountEstimator) EstimateGeminiRequest(model string, body []byte) int {
+	return estimateGeminiRequestTokens(model, body)
+}
+
+// estimateGeminiRequestTokens 只统计 systemInstruction、contents 各 part 与函数声明
+func estimateGeminiRequestTokens(model string, body []byte) int {
+	tk := tokenizer.ForModel(model)
+	p := tk.Profile()
+	root := gjson.ParseBytes(body)
+	// countTokens 支持 {"generateContentRequest": {...}} 包装；Code Assist 使用 {"request": {...}}
+	for _, wrapper := range []string{"generateContentRequest", "request"} {
+		if inner := root.Get(wrapper); inner.IsObject() {
+			root = inner
+			break
+		}
+	}
+
+	total := p.RequestOverhead
+	for _, part := range root.Get("systemInstruction.parts").Array() {
+		total += countGeminiPart(tk, part)
+	}
+	for _, content := range root.Get("contents").Array() {
+		total += p.MessageOverhead
+		for _, part := range content.Get("parts").Array() {
+			total += countGeminiPart(tk, part)
+		}
+	}
+	for _, tool := range root.Get("tools").Array() {
+		for _, decl := range tool.Get("functionDeclarations").Array() {
+			total += p.ToolOverhead
+			total += tk.Count(decl.Get("name").String())
+			total += tk.Count(decl.Get("description").String())
+			if params := decl.Get("parameters"); params.Exists() {
+				total += tk.Count(params.Raw)
+			}
+		}
+	}
+	return total
+}
+
+func countGeminiPart(tk *tokenizer.Tokenizer, part gjson.Result) int {
+	switch {
+	case part.Get("text").Exists():
+		if part.Get("thought").Bool() {
+			return 0
+		}
+		return tk.Count(part.Get("text").String())
+	case part.Get("inlineData").Exists(), part.Get("fileData").Exists():
+		return tk.Profile().ImageTokens
+	case part.Get("functionCall").Exists():
+		return tk.Count(part.Get("functionCall.name").String()) + tk.Count(part.Get("functionCall.args").Raw)
+	case part.Get("functionResponse").Exists():
+		return tk.Count(part.Get("functionResponse.name").String()) + tk.Count(part.Get("functionResponse.response").Raw)
+	default:
+		return 0
+	}
+}
+
+// estimateOpenAIResponsesRequestTokens 估算 OpenAI Responses 请求的输入 token（instructions、input 内容与工具定义）
+func estimateOpenAIResponsesRequestTokens(model string, body []byte) int {
+	tk := tokenizer.ForModel(model)
+	p := tk.Profile()
+	root := gjson.ParseBytes(body)
+
+	total := p.RequestOverhead + tk.Count(root.Get("instructions").String())
+	input := root.Get("input")
+	if input.Type == gjson.String {
+		total += p.MessageOverhead + tk.Count(input.String())
+	} else {
+		for _, item := range input.Array() {
+			total += p.MessageOverhead
+			total += countOpenAIInputItem(tk, item)
+		}
+	}
+	for _, tool := range root.Get("tools").Array() {
+		total += p.ToolOverhead
+		total += tk.Count(tool.Get("name").String())
+		total += tk.Count(tool.Get("description").String())
+		if params := tool.Get("parameters"); params.Exists() {
+			total += tk.Count(params.Raw)
+		}
+	}
+	return total
+}
+
+func countOpenAIInputItem(tk *tokenizer.Tokenizer, item gjson.Result) int {
+	switch item.Get("type").String() {
+	case "", "message":
+		return countOpenAIContent(tk, item.Get("content"))
+	case "function_call", "custom_tool_call":
+		return tk.Count(item.Get("name").String()) + tk.Count(item.Get("arguments").String()) + tk.Count(item.Get("input").String())
+	case "function_call_output", "custom_tool_call_output":
+		return countOpenAIContent(tk, item.Get("output"))
+	case "reasoning":
+		// 历史轮次的推理内容不计入上下文
+		return 0
+	default:
+		return 0
+	}
+}
+
+func countOpenAIContent(tk *tokenizer.Tokenizer, content gjson.Result) int {
+	if content.Type == gjson.String {
+		return tk.Count(content.String())
+	}
+	total := 0
+	for _, part := range content.Array() {
+		switch part.Get("type").String() {
+		case "input_text", "output_text", "text":
+			total += tk.Count(part.Get("text").String())
+		case "input_image", "input_file", "image_url":
+			total += tk.Profile().ImageTokens
+		}
+	}
+	return total
+}
+
+// ObserveUpstream 按采样率记录上游计数与本地估算的偏差（异步，不影响请求）
+func (e *TokenCountEstimator) ObserveUpstream(model string, upstream int, estimate func() int) {
+	if e == nil || e.driftCache == nil || upstream <= 0 || estimate == nil {
+		return
+	}
+	rate := e.sampleRate()
+	if rate <= 0 || (rate < 1 && rand.Float64() >= rate) {
+		return
+	}
+	estimated := estimate()
+	family := string(tokenizer.FamilyForModel(model))
+	now := time.Now()
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tokenCountDriftRecordTimeout)
+		defer cancel()
+		if err := e.driftCache.RecordTokenCountDrift(ctx, now, family, estimated, upstream); err != nil {
+			log.Printf("[TokenCount] record drift failed: %v", err)
+		}
+	}()
+}
+
+// GetDriftReport 汇总最近 hours 小时的估算偏差
+func (e *TokenCountEstimator) GetDriftReport(ctx context.Context, hours int) (*TokenCountDriftReport, error) {
+	if hours <= 0 {
+		hours = 24
+	}
+	if hours > tokenCountDriftMaxHours {
+		hours = tokenCountDriftMaxHours
+	}
+	report := &TokenCountDriftReport{
+		Mode:        e.Mode(),
+		SampleRate:  e.sampleRate(),
+		WindowHours: hours,
+		Families:    []TokenCountDriftStat{},
+		Hourly:      []TokenCountDriftPoint{},
+	}
+	if e == nil || e.driftCache == nil {
+		return report, nil
+	}
+
+	current := time.Now().UTC().Truncate(time.Hour)
+	slots := make([]time.Time, 0, hours)
+	for i := hours - 1; i >= 0; i-- {
+		slots = append(slots, current.Add(-time.Duration(i)*time.Hour))
+	}
+	buckets, err := e.driftCache.GetTokenCountDrift(ctx, slots)
+	if err != nil {
+		return nil, err
+	}
+	report.Families, report.Hourly = summarizeTokenCountDrift(buckets)
+	return report, nil
+}
+
+func summarizeTokenCountDrift(buckets []TokenCountDriftBucket) ([]TokenCountDriftStat, []TokenCountDriftPoint) {
+	totals := make(map[string]*TokenCountDriftBucket)
+	hourly := make([]TokenCountDriftPoint, 0, len(buckets))
+	for _, b := range buckets {
+		if b.Samples <= 0 {
+			continue
+		}
+		agg, ok := totals[b.Family]
+		if !ok {
+			agg = &TokenCountDriftBucket{Family: b.Family}
+			totals[b.Family] = agg
+		}
+		agg.Samples += b.Samples
+		agg.EstimatedTokens += b.EstimatedTokens
+		agg.UpstreamTokens += b.UpstreamTokens
+		agg.AbsErrorTokens += b.AbsErrorTokens
+
+		mae, bias := tokenCountDriftPct(b)
+		hourly = append(hourly, TokenCountDriftPoint{
+			Hour:            b.Hour,
+			Family:          b.Family,
+			Samples:         b.Samples,
+			MeanAbsErrorPct: mae,
+			BiasPct:         bias,
+		})
+	}
+
+	stats := make([]TokenCountDriftStat, 0, len(totals))
+	for _, agg := range totals {
+		mae, bias := tokenCountDriftPct(*agg)
+		stats = append(stats, TokenCountDriftStat{
+			Family:          agg.Family,
+			Heuristic:       tokenizer.Get(tokenizer.Family(agg.Family)).Heuristic(),
+			Samples:         agg.Samples,
+			EstimatedTokens: agg.EstimatedTokens,
+			UpstreamTokens:  agg.UpstreamTokens,
+			MeanAbsErrorPct: mae,
+			BiasPct:         bias,
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Family < stats[j].Family })
+	sort.SliceStable(hourly, func(i, j int) bool {
+		if !hourly[i].Hour.Equal(hourly[j].Hour) {
+			return hourly[i].Hour.Before(hourly[j].Hour)
+		}
+		return hourly[i].Family < hourly[j].Family
+	})
+	return stats, hourly
+}
+
+// tokenCountDriftPct 按 token 加权计算平均绝对误差与偏差百分比
+func tokenCountDriftPct(b TokenCountDriftBucket) (mae float64, bias float64) {
+	if b.UpstreamTokens <= 0 {
+		return 0, 0
+	}
+	up := float64(b.UpstreamTokens)
+	mae = roundTo1DP(float64(b.AbsErrorTokens) / up * 100)
+	bias = roundTo1DP(float64(b.EstimatedTokens-b.UpstreamTokens) / up * 100)
+	return mae, bias
+}
@@ -0,0 +1,166 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/tokenizer"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+type tokenCountDriftCacheStub struct {
+	recorded chan [2]int
+	buckets  []TokenCountDriftBucket
+	hours    []time.Time
+}
+
+func (s *tokenCountDriftCacheStub) RecordTokenCountDrift(_ context.Context, _ time.Time, _ string, estimated, upstream int) error {
+	s.recorded <- [2]int{estimated, upstream}
+	return nil
+}
+
+func (s *tokenCountDriftCacheStub) GetTokenCountDrift(_ context.Context, hours []time.Time) ([]TokenCountDriftBucket, error) {
+	s.hours = hours
+	return s.buckets, nil
+}
+
+func newTokenCountEstimatorForTest(mode string, rate float64, cache TokenCountDriftCache) *TokenCountEstimator {
+	cfg := &config.Config{}
+	cfg.Gateway.CountTokens.Mode = mode
+	cfg.Gateway.CountTokens.DriftSampleRate = rate
+	return NewTokenCountEstimator(cfg, cache)
+}
+
+func TestTokenCountEstimatorModes(t *testing.T) {
+	var nilEstimator *TokenCountEstimator
+	require.Equal(t, config.CountTokensModeUpstream, nilEstimator.Mode())
+	require.False(t, nilEstimator.FallbackEnabled())
+
+	fallback := newTokenCountEstimatorForTest(config.CountTokensModeFallback, 0, nil)
+	require.True(t, fallback.FallbackEnabled())
+	require.False(t, fallback.LocalOnly())
+
+	local := newTokenCountEstimatorForTest(config.CountTokensModeLocal, 0, nil)
+	require.True(t, local.FallbackEnabled())
+	require.True(t, local.LocalOnly())
+}
+
+func TestEstimateClaudeRequest(t *testing.T) {
+	e := newTokenCountEstimatorForTest(config.CountTokensModeLocal, 0, nil)
+	tk := tokenizer.Get(tokenizer.FamilyClaude)
+	p := tk.Profile()
+
+	got := e.EstimateClaudeRequest("claude-sonnet-4-5", []byte(`{
+		"model":"claude-sonnet-4-5",
+		"system":"You are a helpful assistant.",
+		"messages":[
+			{"role":"user","content":"Hello there"},
+			{"role":"assistant","content":[{"type":"thinking","thinking":"long hidden reasoning"},{"type":"text","text":"Hi"}]},
+			{"role":"user","content":[{"type":"image","source":{"type":"base64","media_type":"image/png","data":"AAAA"}}]}
+		]
+	}`))
+	want := p.RequestOverhead +
+		tk.Count("You are a helpful assistant.") +
+		3*p.MessageOverhead +
+		tk.Count("Hello there") +
+		tk.Count("Hi") +
+		p.ImageTokens
+	require.Equal(t, want, got)
+
+	withTools := e.EstimateClaudeRequest("claude-sonnet-4-5", []byte(`{
+		"messages":[{"role":"user","content":"Hello there"}],
+		"tools":[{"name":"get_weather","description":"Get weather","input_schema":{"type":"object"}}]
+	}`))
+	require.Greater(t, withTools, p.RequestOverhead+p.MessageOverhead+tk.Count("Hello there")+p.ToolOverhead)
+}
+
+func TestEstimateGeminiRequestUnwrapsCountTokensRequest(t *testing.T) {
+	e := newTokenCountEstimatorForTest(config.CountTokensModeLocal, 0, nil)
+	plain := e.EstimateGeminiRequest("gemini-2.5-pro", []byte(`{"contents":[{"role":"user","parts":[{"text":"Hello there"}]}]}`))
+	wrapped := e.EstimateGeminiRequest("gemini-2.5-pro", []byte(`{"generateContentRequest":{"model":"models/gemini-2.5-pro","contents":[{"role":"user","parts":[{"text":"Hello there"}]}]}}`))
+	require.Equal(t, plain, wrapped)
+
+	tk := tokenizer.Get(tokenizer.FamilyGemini)
+	require.Equal(t, tk.Count("Hello there"), plain)
+
+	withImage := e.EstimateGeminiRequest("gemini-2.5-pro", []byte(`{"contents":[{"parts":[{"text":"Hello there"},{"inlineData":{"mimeType":"image/png","data":"AAAA"}}]}]}`))
+	require.Equal(t, plain+tk.Profile().ImageTokens, withImage)
+}
+
+func TestForwardCountTokensEstimatesForMixedOpenAIAccount(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	body := []byte(`{"model":"claude-sonnet-4-5","messages":[{"role":"user","content":"Hello there"}]}`)
+	parsed, err := ParseGatewayRequest(body)
+	require.NoError(t, err)
+
+	// upstream 模式下 OpenAI 账户也必须返回估算值，而不是 0
+	estimator := newTokenCountEstimatorForTest(config.CountTokensModeUpstream, 0, nil)
+	svc := &GatewayService{tokenCountEstimator: estimator}
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/messages/count_tokens", nil)
+
+	err = svc.ForwardCountTokens(context.Background(), c, &Account{ID: 1, Platform: PlatformOpenAI}, parsed)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, rec.Code)
+	want := estimator.EstimateClaudeRequest("claude-sonnet-4-5", body)
+	require.Positive(t, want)
+	require.JSONEq(t, fmt.Sprintf(`{"input_tokens":%d}`, want), rec.Body.String())
+}
+
+func TestTokenCountEstimatorObserveUpstreamSamples(t *testing.T) {
+	cache := &tokenCountDriftCacheStub{recorded: make(chan [2]int, 1)}
+	e := newTokenCountEstimatorForTest(config.CountTokensModeFallback, 1, cache)
+
+	e.ObserveUpstream("claude-sonnet-4-5", 120, func() int { return 100 })
+	select {
+	case got := <-cache.recorded:
+		require.Equal(t, [2]int{100, 120}, got)
+	case <-time.After(time.Second):
+		t.Fatal("drift sample was not recorded")
+	}
+
+	disabled := newTokenCountEstimatorForTest(config.CountTokensModeFallback, 0, cache)
+	disabled.ObserveUpstream("claude-sonnet-4-5", 120, func() int {
+		t.Fatal("estimate should not run when sampling is disabled")
+		return 0
+	})
+}
+
+func TestTokenCountEstimatorDriftReport(t *testing.T) {
+	hour := time.Now().UTC().Truncate(time.Hour)
+	cache := &tokenCountDriftCacheStub{buckets: []TokenCountDriftBucket{
+		{Hour: hour.Add(-time.Hour), Family: "claude", Samples: 2, EstimatedTokens: 90, UpstreamTokens: 100, AbsErrorTokens: 10},
+		{Hour: hour, Family: "claude", Samples: 1, EstimatedTokens: 120, UpstreamTokens: 100, AbsErrorTokens: 20},
+		{Hour: hour, Family: "gemini", Samples: 1, EstimatedTokens: 50, UpstreamTokens: 50},
+		{Hour: hour, Family: "openai"},
+	}}
+	e := newTokenCountEstimatorForTest(config.CountTokensModeFallback, 0.1, cache)
+
+	report, err := e.GetDriftReport(context.Background(), 3)
+	require.NoError(t, err)
+	require.Len(t, cache.hours, 3)
+	require.Equal(t, hour, cache.hours[2])
+	require.Equal(t, config.CountTokensModeFallback, report.Mode)
+	require.Equal(t, 3, report.WindowHours)
+
+	require.Len(t, report.Families, 2)
+	require.Equal(t, "claude", report.Families[0].Family)
+	require.Equal(t, int64(3), report.Families[0].Samples)
+	require.Equal(t, 15.0, report.Families[0].MeanAbsErrorPct)
+	require.Equal(t, 5.0, report.Families[0].BiasPct)
+	require.True(t, report.Families[0].Heuristic)
+	require.Equal(t, 0.0, report.Families[1].MeanAbsErrorPct)
+	require.False(t, report.Families[1].Heuristic)
+
+	require.Len(t, report.Hourly, 3)
+	require.Equal(t, -10.0, report.Hourly[0].BiasPct)
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+// 充值订单类型
+const (
+	// TopupOrderTypeBalance 余额充值
+	TopupOrderTypeBalance = "balance"
+	// TopupOrderTypeSubscription 购买订阅
+	TopupOrderTypeSubscription = "subscription"
+)
+
+// 充值订单状态
+const (
+	TopupStatusPending   = "pending"
+	TopupStatusPaid      = "paid"
+	TopupStatusFailed    = "failed"
+	TopupStatusExpired   = "expired"
+	TopupStatusCancelled = "cancelled"
+)
+
+var (
+	ErrTopupDisabled           = infraerrors.Forbidden("TOPUP_DISABLED", "top-up is not enabled")
+	ErrTopupOrderNotFound      = infraerrors.NotFound("TOPUP_ORDER_NOT_FOUND", "top-up order not found")
+	ErrTopupOrderNotPending    = infraerrors.Conflict("TOPUP_ORDER_NOT_PENDING", "top-up order is no longer pending")
+	ErrInvalidTopupOrder       = infraerrors.BadRequest("INVALID_TOPUP_ORDER", "invalid top-up order")
+	ErrTopupPlanNotFound       = infraerrors.NotFound("TOPUP_PLAN_NOT_FOUND", "subscription plan not found")
+	ErrPaymentProviderNotFound = infraerrors.NotFound("PAYMENT_PROVIDER_NOT_FOUND", "payment provider not found")
+	ErrInvalidPaymentSignature = infraerrors.Unauthorized("INVALID_PAYMENT_SIGNATURE", "invalid payment webhook signature")
+	ErrInvalidPaymentEvent     = infraerrors.BadRequest("INVALID_PAYMENT_EVENT", "invalid payment webhook payload")
+	ErrPaymentAmountMismatch   = infraerrors.BadRequest("PAYMENT_AMOUNT_MISMATCH", "paid amount does not match the order")
+)
+
+// TopupOrder 充值订单
+//
+// 余额充值在支付成功后按 CreditAmount 入账（余额流水类型 topup），
+// 购买订阅在支付成功后通过 SubscriptionService.AssignOrExtendSubscription 分配或续期 GroupID 的订阅。
+// 回调处理以 status <> paid 的条件更新实现幂等，入账与状态变更在同一事务中完成。
+type TopupOrder struct {
+	ID              int64
+	OrderNo         string
+	UserID          int64
+	OrderType       string
+	Amount          float64
+	Currency        string
+	CreditAmount    float64
+	PlanID          string
+	GroupID         *int64
+	ValidityDays    int
+	Provider        string
+	ProviderOrderID string
+	PayURL          string
+	Status          string
+	PaidAt          *time.Time
+	ExpiresAt       time.Time
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// IsPending 是否待支付
+func (o *TopupOrder) IsPending() bool {
+	return o.Status == TopupStatusPending
+}
+
+// TopupOrderFilters 充值订单查询条件
+type TopupOrderFilters struct {
+	UserID   *int64
+	Status   string
+	Provider string
+}
+
+// TopupOrderRepository 充值订单存储
+// 状态变更均为条件更新，处于事务上下文时随事务提交或回滚。
+type TopupOrderRepository interface {
+	Create(ctx context.Context, order *TopupOrder) error
+	GetByID(ctx context.Context, id int64) (*TopupOrder, error)
+	GetByOrderNo(ctx context.Context, orderNo string) (*TopupOrder, error)
+	List(ctx context.Context, params pagination.PaginationParams, filters TopupOrderFilters) ([]TopupOrder, *pagination.PaginationResult, error)
+	// SetPayment 记录支付渠道返回的渠道订单号与支付链接
+	SetPayment(ctx context.Context, id int64, providerOrderID, payURL string) error
+	// MarkPaid 将未支付的订单标记为已支付；订单已支付时返回 false（重复回调）
+	MarkPaid(ctx context.Context, id int64, providerOrderID string, paidAt time.Time) (bool, error)
+	// TransitionStatus 仅当订单当前状态为 from 时变更为 to，返回是否变更
+	TransitionStatus(ctx context.Context, id int64, from, to string) (bool, error)
+	// ExpirePending 将 before 之前到期的待支付订单标记为已过期
+	ExpirePending(ctx context.Context, before time.Time) (int64, error)
+}
@@ -0,0 +1,433 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	dbent "github.com/Wei-Shaw/sub2api/ent"
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+// topupExpireInterval 待支付订单过期扫描间隔
+const topupExpireInterval = time.Minute
+
+// CreateTopupOrderInput 创建充值订单参数
+// 余额充值填写 Amount；购买订阅填写 PlanID（金额取自套餐）。Provider 为空且仅启用一个渠道时使用该渠道。
+type CreateTopupOrderInput struct {
+	OrderType string
+	Amount    float64
+	PlanID    string
+	Provider  string
+}
+
+// TopupOptions 充值选项（供前端展示）
+type TopupOptions struct {
+	Enabled    bool
+	Currency   string
+	CreditRate float64
+	MinAmount  float64
+	MaxAmount  float64
+	Providers  []string
+	Plans      []config.PaymentSubscriptionPlan
+}
+
+// TopupService 充值订单服务
+type TopupService struct {
+	orderRepo            TopupOrderRepository
+	userRepo             UserRepository
+	subscriptionService  *SubscriptionService
+	billingCacheService  *BillingCacheService
+	entClient            *dbent.Client
+	authCacheInvalidator APIKeyAuthCacheInvalidator
+	cfg                  *config.Config
+
+	providersMu sync.RWMutex
+	providers   map[string]PaymentProvider
+	// providerOrder 渠道注册顺序（用于展示）
+	providerOrder []string
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewTopupService 创建充值订单服务，并按配置注册内置支付渠道
+func NewTopupService(
+	orderRepo TopupOrderRepository,
+	userRepo UserRepository,
+	subscriptionService *SubscriptionService,
+	billingCacheService *BillingCacheService,
+	entClient *dbent.Client,
+	authCacheInvalidator APIKeyAuthCacheInvalidator,
+	cfg *config.Config,
+) *TopupService {
+	s := &TopupService{
+		orderRepo:            orderRepo,
+		userRepo:             userRepo,
+		subscriptionService:  subscriptionService,
+		billingCacheService:  billingCacheService,
+		entClient:            entClient,
+		authCacheInvalidator: authCacheInvalidator,
+		cfg:                  cfg,
+		providers:            make(map[string]PaymentProvider),
+		stopCh:               make(chan struct{}),
+	}
+	if cfg != nil {
+		if cfg.Payment.Webhook.Enabled {
+			s.RegisterProvider(newHMACWebhookProvider(cfg.Payment.Webhook))
+		}
+		if cfg.Payment.Mock.Enabled {
+			s.RegisterProvider(mockPaymentProvider{})
+		}
+	}
+	return s
+}
+
+// RegisterProvider 注册支付渠道（同名渠道会被替换）
+func (s *TopupService) RegisterProvider(provider PaymentProvider) {
+	s.providersMu.Lock()
+	defer s.providersMu.Unlock()
+	name := provider.Name()
+	if _, exists := s.providers[name]; !exists {
+		s.providerOrder = append(s.providerOrder, name)
+	}
+	s.providers[name] = provider
+}
+
+func (s *TopupService) provider(name string) (PaymentProvider, error) {
+	s.providersMu.RLock()
+	defer s.providersMu.RUnlock()
+	if name == "" && len(s.providerOrder) == 1 {
+		name = s.providerOrder[0]
+	}
+	p, ok := s.providers[name]
+	if !ok {
+		return nil, ErrPaymentProviderNotFound
+	}
+	return p, nil
+}
+
+func (s *TopupService) enabled() bool {
+	return s.cfg != nil && s.cfg.Payment.Enabled
+}
+
+// Start 启动待支付订单过期扫描（未启用充值时不启动）
+func (s *TopupService) Start() {
+	if s == nil || !s.enabled() {
+		return
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(topupExpireInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.expirePending()
+			case <-s.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止过期扫描
+func (s *TopupService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+	s.wg.Wait()
+}
+
+func (s *TopupService) expirePending() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	n, err := s.orderRepo.ExpirePending(ctx, time.Now())
+	if err != nil {
+		log.Printf("[Topup] Expire pending orders failed: %v", err)
+		return
+	}
+	if n > 0 {
+		log.Printf("[Topup] Expired %d pending orders", n)
+	}
+}
+
+// Options 返回充值选项
+func (s *TopupService) Options() *TopupOptions {
+	if !s.enabled() {
+		return &TopupOptions{Enabled: false}
+	}
+	s.providersMu.RLock()
+	providers := append([]string(nil), s.providerOrder...)
+	s.providersMu.RUnlock()
+	return &TopupOptions{
+		Enabled:    true,
+		Currency:   s.cfg.Payment.Currency,
+		CreditRate: s.cfg.Payment.CreditRate,
+		MinAmount:  s.cfg.Payment.MinAmount,
+		MaxAmount:  s.cfg.Payment.MaxAmount,
+		Providers:  providers,
+		Plans:      s.cfg.Payment.SubscriptionPlans,
+	}
+}
+
+// CreateOrder 创建充值订单并向支付渠道发起支付
+func (s *TopupService) CreateOrder(ctx context.Context, userID int64, input *CreateTopupOrderInput) (*TopupOrder, error) {
+	if !s.enabled() {
+		return nil, ErrTopupDisabled
+	}
+	provider, err := s.provider(strings.TrimSpace(input.Provider))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	order := &TopupOrder{
+		UserID:    userID,
+		OrderType: input.OrderType,
+		Currency:  s.cfg.Payment.Currency,
+		Provider:  provider.Name(),
+		Status:    TopupStatusPending,
+		ExpiresAt: now.Add(time.Duration(s.cfg.Payment.OrderExpireMinutes) * time.Minute),
+	}
+	switch input.OrderType {
+	case TopupOrderTypeBalance:
+		amount := math.Round(input.Amount*100) / 100
+		if amount < s.cfg.Payment.MinAmount || amount > s.cfg.Payment.MaxAmount {
+			return nil, fmt.Errorf("%w: amount must be between %.2f and %.2f", ErrInvalidTopupOrder, s.cfg.Payment.MinAmount, s.cfg.Payment.MaxAmount)
+		}
+		order.Amount = amount
+		order.CreditAmount = amount * s.cfg.Payment.CreditRate
+	case TopupOrderTypeSubscription:
+		plan := s.findPlan(input.PlanID)
+		if plan == nil {
+			return nil, ErrTopupPlanNotFound
+		}
+		groupID := plan.GroupID
+		order.Amount = plan.Price
+		order.PlanID = plan.ID
+		order.GroupID = &groupID
+		order.ValidityDays = plan.ValidityDays
+	default:
+		return nil, fmt.Errorf("%w: unsupported order type %q", ErrInvalidTopupOrder, input.OrderType)
+	}
+
+	orderNo, err := generateTopupOrderNo(now)
+	if err != nil {
+		return nil, err
+	}
+	order.OrderNo = orderNo
+	if err := s.orderRepo.Create(ctx, order); err != nil {
+		return nil, fmt.Errorf("create topup order: %w", err)
+	}
+
+	session, err := provider.CreatePayment(ctx, order)
+	if err != nil {
+		if _, markErr := s.orderRepo.TransitionStatus(ctx, order.ID, TopupStatusPending, TopupStatusFailed); markErr != nil {
+			log.Printf("[Topup] Mark order %s failed: %v", order.OrderNo, markErr)
+		}
+		return nil, fmt.Errorf("create payment: %w", err)
+	}
+	if err := s.orderRepo.SetPayment(ctx, order.ID, session.ProviderOrderID, session.PayURL); err != nil {
+		return nil, fmt.Errorf("save payment: %w", err)
+	}
+	order.ProviderOrderID = session.ProviderOrderID
+	order.PayURL = session.PayURL
+	return order, nil
+}
+
+func (s *TopupService) findPlan(planID string) *config.PaymentSubscriptionPlan {
+	planID = strings.TrimSpace(planID)
+	for i := range s.cfg.Payment.SubscriptionPlans {
+		if s.cfg.Payment.SubscriptionPlans[i].ID == planID {
+			return &s.cfg.Payment.SubscriptionPlans[i]
+		}
+	}
+	return nil
+}
+
+// GetUserOrder 获取用户自己的订单
+func (s *TopupService) GetUserOrder(ctx context.Context, userID int64, orderNo string) (*TopupOrder, error) {
+	order, err := s.orderRepo.GetByOrderNo(ctx, orderNo)
+	if err != nil {
+		return nil, err
+	}
+	if order.UserID != userID {
+		return nil, ErrTopupOrderNotFound
+	}
+	return order, nil
+}
+
+// ListUserOrders 列出用户的充值订单
+func (s *TopupService) ListUserOrders(ctx context.Context, userID int64, params pagination.PaginationParams) ([]TopupOrder, *pagination.PaginationResult, error) {
+	return s.orderRepo.List(ctx, params, TopupOrderFilters{UserID: &userID})
+}
+
+// ListOrders 列出充值订单（管理员）
+func (s *TopupService) ListOrders(ctx context.Context, params pagination.PaginationParams, filters TopupOrderFilters) ([]TopupOrder, *pagination.PaginationResult, error) {
+	return s.orderRepo.List(ctx, params, filters)
+}
+
+// CancelOrder 用户取消待支付订单
+func (s *TopupService) CancelOrder(ctx context.Context, userID int64, orderNo string) (*TopupOrder, error) {
+	order, err := s.GetUserOrder(ctx, userID, orderNo)
+	if err != nil {
+		return nil, err
+	}
+	changed, err := s.orderRepo.TransitionStatus(ctx, order.ID, TopupStatusPending, TopupStatusCancelled)
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		return nil, ErrTopupOrderNotPending
+	}
+	order.Status = TopupStatusCancelled
+	return order, nil
+}
+
+// HandleWebhook 处理支付渠道回调（幂等）
+// 支付成功时入账或分配订阅；已支付订单的重复回调直接返回成功。
+// 订单已过期或已取消但渠道确认收款时仍会入账，避免用户付款后丢失权益。
+func (s *TopupService) HandleWebhook(ctx context.Context, providerName string, header http.Header, body []byte) error {
+	if !s.enabled() {
+		return ErrTopupDisabled
+	}
+	if providerName == "" {
+		return ErrPaymentProviderNotFound
+	}
+	provider, err := s.provider(providerName)
+	if err != nil {
+		return err
+	}
+	event, err := provider.ParseWebhook(header, body)
+	if err != nil {
+		return err
+	}
+
+	order, err := s.orderRepo.GetByOrderNo(ctx, event.OrderNo)
+	if err != nil {
+		return err
+	}
+	// 订单只能由下单时的渠道确认，防止其他渠道（如模拟渠道）伪造支付结果
+	if order.Provider != provider.Name() {
+		return ErrTopupOrderNotFound
+	}
+
+	switch event.Status {
+	case PaymentEventPaid:
+		// 支付成功事件必须携带金额，缺失或非正数一律视为不一致，避免按订单金额全额入账
+		if event.Amount <= 0 || math.Abs(event.Amount-order.Amount) > 0.005 {
+			return ErrPaymentAmountMismatch
+		}
+		if event.Currency != "" && !strings.EqualFold(event.Currency, order.Currency) {
+			return ErrPaymentAmountMismatch
+		}
+		providerOrderID := event.ProviderOrderID
+		if providerOrderID == "" {
+			providerOrderID = order.ProviderOrderID
+		}
+		return s.fulfill(ctx, order, providerOrderID)
+	case PaymentEventFailed:
+		if _, err := s.orderRepo.TransitionStatus(ctx, order.ID, TopupStatusPending, TopupStatusFailed); err != nil {
+			return err
+		}
+		return nil
+	default:
+		return ErrInvalidPaymentEvent
+	}
+}
+
+// fulfill 在同一事务中标记订单已支付并发放权益
+func (s *TopupService) fulfill(ctx context.Context, order *TopupOrder, providerOrderID string) error {
+	tx, err := s.entClient.Tx(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+	txCtx := dbent.NewTxContext(ctx, tx)
+
+	// 条件更新保证幂等：并发或重复回调只有一次能完成状态变更
+	changed, err := s.orderRepo.MarkPaid(txCtx, order.ID, providerOrderID, time.Now())
+	if err != nil {
+		return fmt.Errorf("mark order paid: %w", err)
+	}
+	if !changed {
+		return nil
+	}
+
+	switch order.OrderType {
+	case TopupOrderTypeBalance:
+		if err := s.userRepo.ApplyBalanceEntry(txCtx, &BalanceLedgerEntry{
+			UserID:       order.UserID,
+			EntryType:    BalanceEntryTopup,
+			Amount:       order.CreditAmount,
+			TopupOrderID: &order.ID,
+			Notes:        order.OrderNo,
+		}); err != nil {
+			return fmt.Errorf("update user balance: %w", err)
+		}
+	case TopupOrderTypeSubscription:
+		if order.GroupID == nil {
+			return fmt.Errorf("%w: subscription order %s has no group", ErrInvalidTopupOrder, order.OrderNo)
+		}
+		if _, _, err := s.subscriptionService.AssignOrExtendSubscription(txCtx, &AssignSubscriptionInput{
+			UserID:       order.UserID,
+			GroupID:      *order.GroupID,
+			ValidityDays: order.ValidityDays,
+			AssignedBy:   0, // 系统分配
+			Notes:        fmt.Sprintf("通过充值订单 %s 购买", order.OrderNo),
+		}); err != nil {
+			return fmt.Errorf("assign or extend subscription: %w", err)
+		}
+	default:
+		return fmt.Errorf("%w: unsupported order type %q", ErrInvalidTopupOrder, order.OrderType)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	s.invalidateTopupCaches(ctx, order)
+	return nil
+}
+
+// invalidateTopupCaches 入账后失效余额/订阅缓存
+func (s *TopupService) invalidateTopupCaches(ctx context.Context, order *TopupOrder) {
+	if s.authCacheInvalidator != nil {
+		s.authCacheInvalidator.InvalidateAuthCacheByUserID(ctx, order.UserID)
+	}
+	if s.billingCacheService == nil {
+		return
+	}
+	userID := order.UserID
+	go func() {
+		cacheCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if order.OrderType == TopupOrderTypeSubscription && order.GroupID != nil {
+			_ = s.billingCacheService.InvalidateSubscription(cacheCtx, userID, *order.GroupID)
+			return
+		}
+		_ = s.billingCacheService.InvalidateUserBalance(cacheCtx, userID)
+	}()
+}
+
+// generateTopupOrderNo 生成订单号：TP + 时间 + 随机后缀
+func generateTopupOrderNo(now time.Time) (string, error) {
+	suffix := make([]byte, 6)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", fmt.Errorf("generate order no: %w", err)
+	}
+	return "TP" + now.UTC().Format("20060102150405") + strings.ToUpper(hex.EncodeToString(suffix)), nil
+}
@@ -0,0 +1,197 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+type topupOrderRepoStub struct {
+	TopupOrderRepository
+	orders []*TopupOrder
+}
+
+func (r *topupOrderRepoStub) Create(ctx context.Context, order *TopupOrder) error {
+	order.ID = int64(len(r.orders) + 1)
+	r.orders = append(r.orders, order)
+	return nil
+}
+
+func (r *topupOrderRepoStub) GetByOrderNo(ctx context.Context, orderNo string) (*TopupOrder, error) {
+	for _, o := range r.orders {
+		if o.OrderNo == orderNo {
+			cp := *o
+			return &cp, nil
+		}
+	}
+	return nil, ErrTopupOrderNotFound
+}
+
+func (r *topupOrderRepoStub) SetPayment(ctx context.Context, id int64, providerOrderID, payURL string) error {
+	r.orders[id-1].ProviderOrderID = providerOrderID
+	r.orders[id-1].PayURL = payURL
+	return nil
+}
+
+func (r *topupOrderRepoStub) TransitionStatus(ctx context.Context, id int64, from, to string) (bool, error) {
+	o := r.orders[id-1]
+	if o.Status != from {
+		return false, nil
+	}
+	o.Status = to
+	return true, nil
+}
+
+func newTopupTestService(repo TopupOrderRepository) *TopupService {
+	cfg := &config.Config{}
+	cfg.Payment = config.PaymentConfig{
+		Enabled:            true,
+		Currency:           "USD",
+		CreditRate:         2,
+		MinAmount:          1,
+		MaxAmount:          100,
+		OrderExpireMinutes: 30,
+		SubscriptionPlans:  []config.PaymentSubscriptionPlan{{ID: "pro", GroupID: 9, ValidityDays: 30, Price: 20}},
+		Webhook: config.PaymentWebhookProviderConfig{
+			Enabled:                   true,
+			Secret:                    "s3cret",
+			CheckoutURL:               "https://pay.example.com/checkout?order={order_no}&amount={amount}&currency={currency}",
+			SignatureToleranceSeconds: 300,
+		},
+		Mock: config.PaymentMockProviderConfig{Enabled: true},
+	}
+	return NewTopupService(repo, nil, nil, nil, nil, nil, cfg)
+}
+
+func signPaymentWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestHMACWebhookProvider_ParseWebhook(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	p := newHMACWebhookProvider(config.PaymentWebhookProviderConfig{Secret: "s3cret", SignatureToleranceSeconds: 300})
+	p.now = func() time.Time { return now }
+
+	body := []byte(`{"order_no":"TP1","provider_order_id":"ch_1","status":"PAID","amount":10,"currency":"usd"}`)
+	ts := strconv.FormatInt(now.Unix(), 10)
+	header := http.Header{}
+	header.Set(PaymentTimestampHeader, ts)
+	header.Set(PaymentSignatureHeader, "sha256="+signPaymentWebhook("s3cret", ts, body))
+
+	event, err := p.ParseWebhook(header, body)
+	require.NoError(t, err)
+	require.Equal(t, "TP1", event.OrderNo)
+	require.Equal(t, "ch_1", event.ProviderOrderID)
+	require.Equal(t, PaymentEventPaid, event.Status)
+	require.InDelta(t, 10, event.Amount, 1e-9)
+
+	tampered := []byte(`{"order_no":"TP1","status":"paid","amount":1000}`)
+	_, err = p.ParseWebhook(header, tampered)
+	require.ErrorIs(t, err, ErrInvalidPaymentSignature)
+
+	wrongKey := header.Clone()
+	wrongKey.Set(PaymentSignatureHeader, signPaymentWebhook("other", ts, body))
+	_, err = p.ParseWebhook(wrongKey, body)
+	require.ErrorIs(t, err, ErrInvalidPaymentSignature)
+
+	// 超出时间戳容差视为重放
+	p.now = func() time.Time { return now.Add(10 * time.Minute) }
+	_, err = p.ParseWebhook(header, body)
+	require.ErrorIs(t, err, ErrInvalidPaymentSignature)
+
+	_, err = p.ParseWebhook(http.Header{}, body)
+	require.ErrorIs(t, err, ErrInvalidPaymentSignature)
+}
+
+func TestTopupService_CreateOrder(t *testing.T) {
+	repo := &topupOrderRepoStub{}
+	svc := newTopupTestService(repo)
+	ctx := context.Background()
+
+	order, err := svc.CreateOrder(ctx, 5, &CreateTopupOrderInput{OrderType: TopupOrderTypeBalance, Amount: 12.345, Provider: PaymentProviderWebhook})
+	require.NoError(t, err)
+	require.Equal(t, TopupStatusPending, order.Status)
+	require.InDelta(t, 12.35, order.Amount, 1e-9, "amount is rounded to cents")
+	require.InDelta(t, 24.7, order.CreditAmount, 1e-9, "credit applies credit_rate")
+	require.Contains(t, order.PayURL, "order="+order.OrderNo)
+	require.Contains(t, order.PayURL, "amount=12.35")
+	require.True(t, order.ExpiresAt.After(time.Now()))
+
+	sub, err := svc.CreateOrder(ctx, 5, &CreateTopupOrderInput{OrderType: TopupOrderTypeSubscription, PlanID: "pro", Provider: PaymentProviderMock})
+	require.NoError(t, err)
+	require.InDelta(t, 20, sub.Amount, 1e-9)
+	require.Equal(t, int64(9), *sub.GroupID)
+	require.Equal(t, 30, sub.ValidityDays)
+	require.Equal(t, "mock_"+sub.OrderNo, sub.ProviderOrderID)
+
+	_, err = svc.CreateOrder(ctx, 5, &CreateTopupOrderInput{OrderType: TopupOrderTypeBalance, Amount: 1000, Provider: PaymentProviderWebhook})
+	require.ErrorIs(t, err, ErrInvalidTopupOrder)
+	_, err = svc.CreateOrder(ctx, 5, &CreateTopupOrderInput{OrderType: TopupOrderTypeSubscription, PlanID: "missing", Provider: PaymentProviderWebhook})
+	require.ErrorIs(t, err, ErrTopupPlanNotFound)
+	_, err = svc.CreateOrder(ctx, 5, &CreateTopupOrderInput{OrderType: TopupOrderTypeBalance, Amount: 10})
+	require.ErrorIs(t, err, ErrPaymentProviderNotFound, "provider is required when several are enabled")
+
+	svc.cfg.Payment.Enabled = false
+	_, err = svc.CreateOrder(ctx, 5, &CreateTopupOrderInput{OrderType: TopupOrderTypeBalance, Amount: 10, Provider: PaymentProviderMock})
+	require.ErrorIs(t, err, ErrTopupDisabled)
+}
+
+func TestTopupService_HandleWebhookRejectsBeforeFulfilling(t *testing.T) {
+	repo := &topupOrderRepoStub{}
+	svc := newTopupTestService(repo)
+	ctx := context.Background()
+
+	order, err := svc.CreateOrder(ctx, 5, &CreateTopupOrderInput{OrderType: TopupOrderTypeBalance, Amount: 10, Provider: PaymentProviderWebhook})
+	require.NoError(t, err)
+
+	// 模拟渠道不能确认其他渠道的订单
+	err = svc.HandleWebhook(ctx, PaymentProviderMock, http.Header{}, []byte(`{"order_no":"`+order.OrderNo+`","status":"paid"}`))
+	require.ErrorIs(t, err, ErrTopupOrderNotFound)
+
+	err = svc.HandleWebhook(ctx, "unknown", http.Header{}, []byte(`{}`))
+	require.ErrorIs(t, err, ErrPaymentProviderNotFound)
+
+	signed := func(body string) http.Header {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		h := http.Header{}
+		h.Set(PaymentTimestampHeader, ts)
+		h.Set(PaymentSignatureHeader, signPaymentWebhook("s3cret", ts, []byte(body)))
+		return h
+	}
+
+	body := `{"order_no":"` + order.OrderNo + `","status":"paid"}`
+	require.ErrorIs(t, svc.HandleWebhook(ctx, PaymentProviderWebhook, signed(body), []byte(body)), ErrPaymentAmountMismatch, "paid events must carry the amount")
+	body = `{"order_no":"` + order.OrderNo + `","status":"paid","amount":9.99}`
+	require.ErrorIs(t, svc.HandleWebhook(ctx, PaymentProviderWebhook, signed(body), []byte(body)), ErrPaymentAmountMismatch)
+	body = `{"order_no":"` + order.OrderNo + `","status":"paid","amount":10,"currency":"EUR"}`
+	require.ErrorIs(t, svc.HandleWebhook(ctx, PaymentProviderWebhook, signed(body), []byte(body)), ErrPaymentAmountMismatch)
+
+	body = `{"order_no":"` + order.OrderNo + `","status":"failed"}`
+	require.NoError(t, svc.HandleWebhook(ctx, PaymentProviderWebhook, signed(body), []byte(body)))
+	require.Equal(t, TopupStatusFailed, repo.orders[0].Status)
+	// 重复的失败回调幂等
+	require.NoError(t, svc.HandleWebhook(ctx, PaymentProviderWebhook, signed(body), []byte(body)))
+
+	_, err = svc.CancelOrder(ctx, 5, order.OrderNo)
+	require.ErrorIs(t, err, ErrTopupOrderNotPending)
+	_, err = svc.CancelOrder(ctx, 6, order.OrderNo)
+	require.ErrorIs(t, err, ErrTopupOrderNotFound, "users cannot see other users' orders")
+
+	// 关闭充值后回调通道同样关闭
+	svc.cfg.Payment.Enabled = false
+	body = `{"order_no":"` + order.OrderNo + `","status":"paid","amount":10}`
+	require.ErrorIs(t, svc.HandleWebhook(ctx, PaymentProviderWebhook, signed(body), []byte(body)), ErrTopupDisabled)
+}
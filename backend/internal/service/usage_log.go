@@ -42,6 +42,13 @@ type UsageLog struct {
 	FirstTokenMs *int
 	UserAgent    *string
 	IPAddress    *string
+	// SessionHash 粘性会话哈希（nil 表示请求未使用粘性会话）
+	SessionHash *string
+	// CostRoutingBaselineFactor/CostRoutingChosenFactor 成本优先路由下默认账号与实际账号的成本系数（nil 表示未按成本策略调度）
+	CostRoutingBaselineFactor *float64
+	CostRoutingChosenFactor   *float64
+	// PricingOverrideID 计费使用的管理员自定义价格（nil 表示默认价格）
+	PricingOverrideID *int64
 
 	// 图片生成字段
 	ImageCount int
@@ -59,3 +66,28 @@ type UsageLog struct {
 func (u *UsageLog) TotalTokens() int {
 	return u.InputTokens + u.OutputTokens + u.CacheCreationTokens + u.CacheReadTokens
 }
+
+// maxUsageSessionHashLen usage_logs.session_hash 列长度
+const maxUsageSessionHashLen = 64
+
+// usageSessionHash 将会话键转换为 usage log 字段，超长时截断
+func usageSessionHash(sessionHash string) *string {
+	if sessionHash == "" {
+		return nil
+	}
+	if len(sessionHash) > maxUsageSessionHashLen {
+		sessionHash = sessionHash[:maxUsageSessionHashLen]
+	}
+	return &sessionHash
+}
+
+// applyCostRouting 记录成本优先路由决策
+func (u *UsageLog) applyCostRouting(decision *CostRoutingDecision) {
+	if decision == nil {
+		return
+	}
+	baseline := decision.BaselineFactor
+	chosen := decision.ChosenFactor
+	u.CostRoutingBaselineFactor = &baseline
+	u.CostRoutingChosenFactor = &chosen
+}
@@ -122,7 +122,12 @@ func (s *UsageService) Create(ctx context.Context, req CreateUsageLogRequest) (*
 	// 扣除用户余额
 	balanceUpdated := false
 	if inserted && req.ActualCost > 0 {
-		if err := s.userRepo.UpdateBalance(txCtx, req.UserID, -req.ActualCost); err != nil {
+		if err := s.userRepo.ApplyBalanceEntry(txCtx, &BalanceLedgerEntry{
+			UserID:     req.UserID,
+			EntryType:  BalanceEntryUsage,
+			Amount:     -req.ActualCost,
+			UsageLogID: &usageLog.ID,
+		}); err != nil {
 			return nil, fmt.Errorf("update user balance: %w", err)
 		}
 		balanceUpdated = true
@@ -27,6 +27,7 @@ type UserRepository interface {
 	GetByID(ctx context.Context, id int64) (*User, error)
 	GetByEmail(ctx context.Context, email string) (*User, error)
 	GetFirstAdmin(ctx context.Context) (*User, error)
+	// Update 更新用户资料（不含余额，余额变更使用 ApplyBalanceEntry）
 	Update(ctx context.Context, user *User) error
 	Delete(ctx context.Context, id int64) error
 
@@ -35,6 +36,8 @@ type UserRepository interface {
 
 	UpdateBalance(ctx context.Context, id int64, amount float64) error
 	DeductBalance(ctx context.Context, id int64, amount float64) error
+	// ApplyBalanceEntry 原子地按 entry.Amount 调整余额并追加一条余额流水，回填 ID、BalanceAfter 与 CreatedAt
+	ApplyBalanceEntry(ctx context.Context, entry *BalanceLedgerEntry) error
 	UpdateConcurrency(ctx context.Context, id int64, amount int) error
 	ExistsByEmail(ctx context.Context, email string) (bool, error)
 	RemoveGroupFromAllowedGroups(ctx context.Context, groupID int64) (int64, error)
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"time"
 
+	dbent "github.com/Wei-Shaw/sub2api/ent"
 	"github.com/Wei-Shaw/sub2api/internal/config"
 	"github.com/google/wire"
 	"github.com/redis/go-redis/v9"
@@ -65,6 +66,88 @@ func ProvideUsageCleanupService(repo UsageCleanupRepository, timingWheel *Timing
 	return svc
 }
 
+// ProvideMessageBatchService 创建并启动 Message Batches 执行器
+func ProvideMessageBatchService(
+	repo MessageBatchRepository,
+	apiKeyRepo APIKeyRepository,
+	subscriptionService *SubscriptionService,
+	billingCacheService *BillingCacheService,
+	concurrencyService *ConcurrencyService,
+	gatewayService *GatewayService,
+	openAIGatewayService *OpenAIGatewayService,
+	geminiCompatService *GeminiMessagesCompatService,
+	antigravityGatewayService *AntigravityGatewayService,
+	timingWheel *TimingWheelService,
+	cfg *config.Config,
+) *MessageBatchService {
+	svc := NewMessageBatchService(repo, apiKeyRepo, subscriptionService, billingCacheService, concurrencyService, gatewayService, openAIGatewayService, geminiCompatService, antigravityGatewayService, timingWheel, cfg)
+	svc.Start()
+	return svc
+}
+
+// ProvideGatewayFileService 创建 Files API 透传服务并启动过期映射清理
+func ProvideGatewayFileService(
+	repo GatewayFileRepository,
+	accountRepo AccountRepository,
+	gatewayService *GatewayService,
+	openAIGatewayService *OpenAIGatewayService,
+	httpUpstream HTTPUpstream,
+	cfg *config.Config,
+) *GatewayFileService {
+	svc := NewGatewayFileService(repo, accountRepo, gatewayService, openAIGatewayService, httpUpstream, cfg)
+	svc.Start()
+	return svc
+}
+
+// ProvideAccountHealthCheckService 创建账号定时健康巡检服务并启动（未启用时不启动）
+func ProvideAccountHealthCheckService(
+	accountRepo AccountRepository,
+	checkRepo AccountHealthCheckRepository,
+	accountTestService *AccountTestService,
+	opsService *OpsService,
+	redisClient *redis.Client,
+	cfg *config.Config,
+) *AccountHealthCheckService {
+	svc := NewAccountHealthCheckService(accountRepo, checkRepo, accountTestService, opsService, redisClient, cfg)
+	svc.Start()
+	return svc
+}
+
+// ProvideBalanceLedgerService 创建余额流水服务并启动定时对账（未启用时不启动）
+func ProvideBalanceLedgerService(
+	ledgerRepo BalanceLedgerRepository,
+	billingCache BillingCache,
+	opsService *OpsService,
+	redisClient *redis.Client,
+	cfg *config.Config,
+) *BalanceLedgerService {
+	svc := NewBalanceLedgerService(ledgerRepo, billingCache, opsService, redisClient, cfg)
+	svc.Start()
+	return svc
+}
+
+// ProvidePricingOverrideService 创建自定义价格服务并加载价格快照
+func ProvidePricingOverrideService(repo PricingOverrideRepository, cfg *config.Config) *PricingOverrideService {
+	svc := NewPricingOverrideService(repo, cfg)
+	svc.Start()
+	return svc
+}
+
+// ProvideTopupService 创建充值订单服务并启动待支付订单过期扫描（未启用时不启动）
+func ProvideTopupService(
+	orderRepo TopupOrderRepository,
+	userRepo UserRepository,
+	subscriptionService *SubscriptionService,
+	billingCacheService *BillingCacheService,
+	entClient *dbent.Client,
+	authCacheInvalidator APIKeyAuthCacheInvalidator,
+	cfg *config.Config,
+) *TopupService {
+	svc := NewTopupService(orderRepo, userRepo, subscriptionService, billingCacheService, entClient, authCacheInvalidator, cfg)
+	svc.Start()
+	return svc
+}
+
 // ProvideAccountExpiryService creates and starts AccountExpiryService.
 func ProvideAccountExpiryService(accountRepo AccountRepository) *AccountExpiryService {
 	svc := NewAccountExpiryService(accountRepo, time.Minute)
@@ -72,6 +155,13 @@ func ProvideAccountExpiryService(accountRepo AccountRepository) *AccountExpirySe
 	return svc
 }
 
+// ProvideAccountAvailabilityService creates and starts AccountAvailabilityService.
+func ProvideAccountAvailabilityService(accountRepo AccountRepository) *AccountAvailabilityService {
+	svc := NewAccountAvailabilityService(accountRepo, 15*time.Second)
+	svc.Start()
+	return svc
+}
+
 // ProvideSubscriptionExpiryService creates and starts SubscriptionExpiryService.
 func ProvideSubscriptionExpiryService(userSubRepo UserSubscriptionRepository) *SubscriptionExpiryService {
 	svc := NewSubscriptionExpiryService(userSubRepo, time.Minute)
@@ -96,10 +186,11 @@ func ProvideDeferredService(accountRepo AccountRepository, timingWheel *TimingWh
 	return svc
 }
 
-// ProvideConcurrencyService creates ConcurrencyService and starts slot cleanup worker.
-func ProvideConcurrencyService(cache ConcurrencyCache, accountRepo AccountRepository, cfg *config.Config) *ConcurrencyService {
+// ProvideConcurrencyService creates ConcurrencyService, wires the fair queue and starts slot cleanup worker.
+func ProvideConcurrencyService(cache ConcurrencyCache, fairQueueCache FairQueueCache, accountRepo AccountRepository, cfg *config.Config) *ConcurrencyService {
 	svc := NewConcurrencyService(cache)
 	if cfg != nil {
+		svc.SetFairQueue(fairQueueCache, cfg.Gateway.FairQueue)
 		svc.StartSlotCleanupWorker(accountRepo, cfg.Gateway.Scheduling.SlotCleanupInterval)
 	}
 	return svc
@@ -128,11 +219,15 @@ func ProvideRateLimitService(
 	timeoutCounterCache TimeoutCounterCache,
 	settingService *SettingService,
 	tokenCacheInvalidator TokenCacheInvalidator,
+	scoringService *AccountScoringService,
+	circuitBreaker *AccountCircuitBreakerService,
 ) *RateLimitService {
 	svc := NewRateLimitService(accountRepo, usageRepo, cfg, geminiQuotaService, tempUnschedCache)
 	svc.SetTimeoutCounterCache(timeoutCounterCache)
 	svc.SetSettingService(settingService)
 	svc.SetTokenCacheInvalidator(tokenCacheInvalidator)
+	svc.SetAccountScoringService(scoringService)
+	svc.SetCircuitBreakerService(circuitBreaker)
 	return svc
 }
 
@@ -223,11 +318,16 @@ var ProviderSet = wire.NewSet(
 	NewPromoService,
 	NewUsageService,
 	NewDashboardService,
+	NewPromptCacheStatsService,
+	NewRequestHedgingService,
+	NewCostRoutingReportService,
 	ProvidePricingService,
 	NewBillingService,
 	NewBillingCacheService,
 	NewAdminService,
 	NewGatewayService,
+	NewTokenCountEstimator,
+	NewModelCatalogService,
 	NewOpenAIGatewayService,
 	NewOAuthService,
 	NewOpenAIOAuthService,
@@ -244,6 +344,9 @@ var ProviderSet = wire.NewSet(
 	NewAntigravityGatewayService,
 	ProvideRateLimitService,
 	NewAccountUsageService,
+	NewAccountRateBudgetService,
+	NewAccountScoringService,
+	NewAccountCircuitBreakerService,
 	NewAccountTestService,
 	NewSettingService,
 	NewOpsService,
@@ -263,10 +366,18 @@ var ProviderSet = wire.NewSet(
 	ProvideUpdateService,
 	ProvideTokenRefreshService,
 	ProvideAccountExpiryService,
+	ProvideAccountAvailabilityService,
 	ProvideSubscriptionExpiryService,
 	ProvideTimingWheelService,
 	ProvideDashboardAggregationService,
 	ProvideUsageCleanupService,
+	ProvideMessageBatchService,
+	ProvideGatewayFileService,
+	ProvideAccountHealthCheckService,
+	ProvideBalanceLedgerService,
+	ProvidePricingOverrideService,
+	ProvideTopupService,
+	NewSchedulerExplainService,
 	ProvideDeferredService,
 	NewAntigravityQuotaFetcher,
 	NewUserAttributeService,
@@ -0,0 +1,45 @@
+-- 045_add_message_batches.sql
+-- Message Batches 网关侧批处理任务与请求表
+
+CREATE TABLE IF NOT EXISTS message_batches (
+    id BIGSERIAL PRIMARY KEY,
+    batch_id VARCHAR(64) NOT NULL UNIQUE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    api_key_id BIGINT NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+    group_id BIGINT,
+    processing_status VARCHAR(20) NOT NULL,
+    cancel_initiated_at TIMESTAMPTZ,
+    ended_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_batches_user_id_id
+    ON message_batches(user_id, id DESC);
+
+CREATE INDEX IF NOT EXISTS idx_message_batches_processing_status
+    ON message_batches(processing_status);
+
+CREATE TABLE IF NOT EXISTS message_batch_items (
+    id BIGSERIAL PRIMARY KEY,
+    batch_id BIGINT NOT NULL REFERENCES message_batches(id) ON DELETE CASCADE,
+    custom_id VARCHAR(64) NOT NULL,
+    params JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    result JSONB,
+    attempts INT NOT NULL DEFAULT 0,
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (batch_id, custom_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_batch_items_batch_id_status
+    ON message_batch_items(batch_id, status);
+
+-- 执行器按 id 顺序抢占待执行请求
+CREATE INDEX IF NOT EXISTS idx_message_batch_items_active
+    ON message_batch_items(status, id)
+    WHERE status IN ('pending', 'running');
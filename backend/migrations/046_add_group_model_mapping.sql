@@ -0,0 +1,9 @@
+-- 046_add_group_model_mapping.sql
+-- 添加分组级别的模型映射配置（跨协议转发时将客户端请求的模型名映射为上游模型）
+
+-- 格式: {"requested_model_pattern": "upstream_model", ...}
+-- 例如: {"gemini-2.5-pro": "claude-sonnet-4-5", "gemini-*": "claude-haiku-4-5"}
+ALTER TABLE groups
+ADD COLUMN IF NOT EXISTS model_mapping JSONB NOT NULL DEFAULT '{}';
+
+COMMENT ON COLUMN groups.model_mapping IS '模型映射配置：{"model_pattern": "upstream_model", ...}，支持末尾 * 通配符';
@@ -0,0 +1,31 @@
+-- 047_add_gateway_files.sql
+-- Files API 文件与上游账号的归属映射（后续请求引用 file_id 时固定到上传账号）
+
+CREATE TABLE IF NOT EXISTS gateway_files (
+    id BIGSERIAL PRIMARY KEY,
+    file_id VARCHAR(128) NOT NULL UNIQUE,
+    platform VARCHAR(20) NOT NULL,
+    account_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    api_key_id BIGINT NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+    filename VARCHAR(512) NOT NULL DEFAULT '',
+    mime_type VARCHAR(255) NOT NULL DEFAULT '',
+    purpose VARCHAR(64) NOT NULL DEFAULT '',
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_gateway_files_user_platform_id
+    ON gateway_files(user_id, platform, id DESC);
+
+-- 账号删除时按账号清理映射
+CREATE INDEX IF NOT EXISTS idx_gateway_files_account_id
+    ON gateway_files(account_id);
+
+-- 过期清理
+CREATE INDEX IF NOT EXISTS idx_gateway_files_expires_at
+    ON gateway_files(expires_at)
+    WHERE expires_at IS NOT NULL;
+
+COMMENT ON TABLE gateway_files IS 'Files API 上传文件归属：file_id -> 上游账号/用户';
@@ -0,0 +1,22 @@
+-- 048_add_sticky_policy_and_session_hash.sql
+-- 分组级粘性会话策略与 TTL；usage_logs 记录会话哈希，用于统计粘性路由的 prompt cache 命中率
+
+-- sticky_policy: content_hash（默认，按请求内容）/ metadata_user_id / api_key / none
+ALTER TABLE groups
+ADD COLUMN IF NOT EXISTS sticky_policy VARCHAR(20) NOT NULL DEFAULT 'content_hash';
+
+-- sticky_ttl_seconds: 0 表示使用平台默认 TTL
+ALTER TABLE groups
+ADD COLUMN IF NOT EXISTS sticky_ttl_seconds INT NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN groups.sticky_policy IS '粘性会话策略：content_hash/metadata_user_id/api_key/none';
+COMMENT ON COLUMN groups.sticky_ttl_seconds IS '粘性会话 TTL（秒），0 表示使用默认值';
+
+ALTER TABLE usage_logs
+ADD COLUMN IF NOT EXISTS session_hash VARCHAR(64);
+
+COMMENT ON COLUMN usage_logs.session_hash IS '粘性会话哈希（未命中粘性策略时为空）';
+
+CREATE INDEX IF NOT EXISTS idx_usage_logs_account_session_created
+    ON usage_logs(account_id, created_at)
+    WHERE session_hash IS NOT NULL;
@@ -0,0 +1,24 @@
+-- 049_add_account_health_checks.sql
+-- 账号定时健康巡检记录（结果与延迟历史，用于账号健康时间线）
+
+CREATE TABLE IF NOT EXISTS account_health_checks (
+    id BIGSERIAL PRIMARY KEY,
+    account_id BIGINT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    platform VARCHAR(20) NOT NULL DEFAULT '',
+    success BOOLEAN NOT NULL,
+    latency_ms INT NOT NULL DEFAULT 0,
+    error_message TEXT NOT NULL DEFAULT '',
+    -- 触发方式：scheduled（定时巡检）
+    trigger VARCHAR(20) NOT NULL DEFAULT 'scheduled',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- 按账号查询时间线 / 最近连续结果
+CREATE INDEX IF NOT EXISTS idx_account_health_checks_account_created
+    ON account_health_checks(account_id, created_at DESC);
+
+-- 过期清理
+CREATE INDEX IF NOT EXISTS idx_account_health_checks_created_at
+    ON account_health_checks(created_at);
+
+COMMENT ON TABLE account_health_checks IS '账号定时健康巡检记录：成功/失败、延迟与错误信息';
@@ -0,0 +1,21 @@
+-- 050_add_account_tags.sql
+-- 账号自由标签（如 tier:max、region:us、owner:teamA）；分组可通过标签选择器自动纳入账号，
+-- model_routing 可引用标签选择器代替账号 ID 列表，由调度快照在构建分桶时解析
+
+ALTER TABLE accounts
+ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+
+COMMENT ON COLUMN accounts.tags IS '账号标签（小写，去重排序）';
+
+CREATE INDEX IF NOT EXISTS idx_accounts_tags ON accounts USING GIN (tags);
+
+-- account_tag_selector: 逗号分隔的标签条件（全部满足），! 前缀表示排除，空值表示不按标签纳入
+ALTER TABLE groups
+ADD COLUMN IF NOT EXISTS account_tag_selector VARCHAR(512) NOT NULL DEFAULT '';
+
+-- model_routing_tags: 模型匹配模式 -> 标签选择器
+ALTER TABLE groups
+ADD COLUMN IF NOT EXISTS model_routing_tags JSONB NOT NULL DEFAULT '{}'::jsonb;
+
+COMMENT ON COLUMN groups.account_tag_selector IS '账号标签选择器，匹配的可调度账号自动加入分组';
+COMMENT ON COLUMN groups.model_routing_tags IS '模型路由标签选择器：模型匹配模式 -> 标签选择器';
@@ -0,0 +1,16 @@
+-- 051_add_account_availability_schedule.sql
+-- 账号可用时间窗口：按周配置的可用时段（带时区），窗口外账号不参与调度；
+-- availability_next_transition_at 记录下一次可用状态切换时间，由后台任务到期时投递调度 outbox 事件
+
+ALTER TABLE accounts
+ADD COLUMN IF NOT EXISTS availability_schedule JSONB;
+
+ALTER TABLE accounts
+ADD COLUMN IF NOT EXISTS availability_next_transition_at TIMESTAMPTZ;
+
+COMMENT ON COLUMN accounts.availability_schedule IS '可用时间窗口：{"timezone": "...", "windows": [{"days": ["mon"], "start": "HH:MM", "end": "HH:MM"}]}，NULL 表示始终可用';
+COMMENT ON COLUMN accounts.availability_next_transition_at IS '下一次可用状态切换时间';
+
+CREATE INDEX IF NOT EXISTS idx_accounts_availability_next_transition_at
+ON accounts (availability_next_transition_at)
+WHERE availability_next_transition_at IS NOT NULL AND deleted_at IS NULL;
@@ -0,0 +1,21 @@
+-- 052_add_cost_routing.sql
+-- 分组级成本优先路由策略；usage_logs 记录按策略调度时默认账号与实际账号的成本系数，用于统计策略转移的花费
+
+-- cost_routing_policy: 空（关闭）/ lowest_cost
+ALTER TABLE groups
+ADD COLUMN IF NOT EXISTS cost_routing_policy VARCHAR(20) NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN groups.cost_routing_policy IS '成本优先路由策略：空表示关闭，lowest_cost 表示优先选择有效成本最低的账号';
+
+ALTER TABLE usage_logs
+ADD COLUMN IF NOT EXISTS cost_routing_baseline_factor DECIMAL(12,6);
+
+ALTER TABLE usage_logs
+ADD COLUMN IF NOT EXISTS cost_routing_chosen_factor DECIMAL(12,6);
+
+COMMENT ON COLUMN usage_logs.cost_routing_baseline_factor IS '默认调度顺序下选中账号的成本系数（未按成本策略调度时为空）';
+COMMENT ON COLUMN usage_logs.cost_routing_chosen_factor IS '实际选中账号的成本系数（未按成本策略调度时为空）';
+
+CREATE INDEX IF NOT EXISTS idx_usage_logs_group_cost_routing_created
+    ON usage_logs(group_id, created_at)
+    WHERE cost_routing_baseline_factor IS NOT NULL;
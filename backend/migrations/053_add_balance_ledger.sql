@@ -0,0 +1,49 @@
+-- 053_add_balance_ledger.sql
+-- 用户余额流水（只追加）：每次余额变动记录类型、金额、变动后余额与来源引用，用于账单与对账
+
+CREATE TABLE IF NOT EXISTS balance_ledger_entries (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    -- 类型：initial（初始/期初余额）、usage（用量扣费）、redeem（兑换码）、promo（优惠码）、
+    --       admin_adjustment（管理员调整）、adjustment（其他系统调整）
+    entry_type VARCHAR(32) NOT NULL,
+    -- 变动金额（正数为入账，负数为扣减）
+    amount DECIMAL(20, 8) NOT NULL,
+    -- 变动后的用户余额
+    balance_after DECIMAL(20, 8) NOT NULL,
+    usage_log_id BIGINT,
+    redeem_code_id BIGINT,
+    promo_code_id BIGINT,
+    admin_id BIGINT,
+    notes TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- 用户账单按时间倒序查询
+CREATE INDEX IF NOT EXISTS idx_balance_ledger_entries_user_created
+    ON balance_ledger_entries(user_id, created_at DESC, id DESC);
+
+CREATE INDEX IF NOT EXISTS idx_balance_ledger_entries_usage_log_id
+    ON balance_ledger_entries(usage_log_id)
+    WHERE usage_log_id IS NOT NULL;
+
+COMMENT ON TABLE balance_ledger_entries IS '用户余额流水（只追加，禁止更新与删除）';
+
+-- 流水只追加：拒绝 UPDATE / DELETE
+CREATE OR REPLACE FUNCTION balance_ledger_entries_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'balance_ledger_entries is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_balance_ledger_entries_immutable ON balance_ledger_entries;
+CREATE TRIGGER trg_balance_ledger_entries_immutable
+    BEFORE UPDATE OR DELETE ON balance_ledger_entries
+    FOR EACH ROW EXECUTE FUNCTION balance_ledger_entries_immutable();
+
+-- 期初余额：为已有用户写入一条 initial 流水，使流水合计与当前余额一致
+INSERT INTO balance_ledger_entries (user_id, entry_type, amount, balance_after, notes)
+SELECT u.id, 'initial', u.balance, u.balance, 'opening balance'
+FROM users u
+WHERE u.balance <> 0
+  AND NOT EXISTS (SELECT 1 FROM balance_ledger_entries l WHERE l.user_id = u.id);
@@ -0,0 +1,36 @@
+-- 054_add_api_key_limits.sql
+-- API Key 级别的额度、请求配额、有效期与模型/平台白名单；均为空表示不限制。
+-- 用量计数以 usage_logs（按 api_key_id 聚合 actual_cost / 请求数）为准，Redis 计费缓存中保存热计数。
+
+ALTER TABLE api_keys
+ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ;
+
+ALTER TABLE api_keys
+ADD COLUMN IF NOT EXISTS total_limit_usd DECIMAL(20,8);
+
+ALTER TABLE api_keys
+ADD COLUMN IF NOT EXISTS daily_limit_usd DECIMAL(20,8);
+
+ALTER TABLE api_keys
+ADD COLUMN IF NOT EXISTS monthly_limit_usd DECIMAL(20,8);
+
+ALTER TABLE api_keys
+ADD COLUMN IF NOT EXISTS daily_request_limit BIGINT;
+
+ALTER TABLE api_keys
+ADD COLUMN IF NOT EXISTS monthly_request_limit BIGINT;
+
+ALTER TABLE api_keys
+ADD COLUMN IF NOT EXISTS allowed_models JSONB;
+
+ALTER TABLE api_keys
+ADD COLUMN IF NOT EXISTS allowed_platforms JSONB;
+
+COMMENT ON COLUMN api_keys.expires_at IS 'API Key 过期时间，NULL 表示永不过期';
+COMMENT ON COLUMN api_keys.total_limit_usd IS '累计消费上限（USD，按 actual_cost 计），NULL 表示不限制';
+COMMENT ON COLUMN api_keys.daily_limit_usd IS '自然日消费上限（USD），NULL 表示不限制';
+COMMENT ON COLUMN api_keys.monthly_limit_usd IS '自然月消费上限（USD），NULL 表示不限制';
+COMMENT ON COLUMN api_keys.daily_request_limit IS '自然日请求数上限，NULL 表示不限制';
+COMMENT ON COLUMN api_keys.monthly_request_limit IS '自然月请求数上限，NULL 表示不限制';
+COMMENT ON COLUMN api_keys.allowed_models IS '允许的模型列表（支持末尾 * 通配），NULL 或空数组表示不限制';
+COMMENT ON COLUMN api_keys.allowed_platforms IS '允许的平台列表（anthropic/openai/gemini/antigravity），NULL 或空数组表示不限制';
@@ -0,0 +1,33 @@
+-- 055_add_pricing_overrides.sql
+-- 管理员自定义价格（全局或分组级，按模型模式匹配，带生效时间）。
+-- 已生效的价格不可修改，调价需新增一条更晚生效的记录；删除为软删除，保证 usage_logs 引用的历史价格可追溯。
+
+CREATE TABLE IF NOT EXISTS pricing_overrides (
+    id BIGSERIAL PRIMARY KEY,
+    -- 分组 ID，NULL 表示全局
+    group_id BIGINT REFERENCES groups(id) ON DELETE CASCADE,
+    -- 模型模式：精确模型名或末尾 * 通配（如 claude-opus-*）
+    model_pattern VARCHAR(255) NOT NULL,
+    -- 价格（USD / 百万 token）
+    input_price DECIMAL(20, 8) NOT NULL,
+    output_price DECIMAL(20, 8) NOT NULL,
+    cache_creation_price DECIMAL(20, 8) NOT NULL DEFAULT 0,
+    cache_read_price DECIMAL(20, 8) NOT NULL DEFAULT 0,
+    effective_from TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    notes TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    deleted_at TIMESTAMPTZ
+);
+
+-- 同一作用域、模型模式与生效时间只能有一条有效记录
+CREATE UNIQUE INDEX IF NOT EXISTS idx_pricing_overrides_scope_version
+    ON pricing_overrides(COALESCE(group_id, 0), model_pattern, effective_from)
+    WHERE deleted_at IS NULL;
+
+COMMENT ON TABLE pricing_overrides IS '管理员自定义价格（覆盖 LiteLLM/内置价格），已生效的记录不可修改';
+
+ALTER TABLE usage_logs
+ADD COLUMN IF NOT EXISTS pricing_override_id BIGINT;
+
+COMMENT ON COLUMN usage_logs.pricing_override_id IS '计费时使用的自定义价格记录（为空表示使用默认价格）';
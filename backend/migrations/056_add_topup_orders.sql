@@ -0,0 +1,47 @@
+-- 056_add_topup_orders.sql
+-- 充值订单：用户通过支付渠道充值余额或购买订阅。
+-- 支付回调通过条件更新（status <> 'paid'）保证幂等，入账与订单状态变更在同一事务中完成。
+
+CREATE TABLE IF NOT EXISTS topup_orders (
+    id BIGSERIAL PRIMARY KEY,
+    -- 对外订单号（支付渠道回调使用）
+    order_no VARCHAR(64) NOT NULL UNIQUE,
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    -- 类型：balance（余额充值）、subscription（购买订阅）
+    order_type VARCHAR(20) NOT NULL,
+    -- 支付金额与币种
+    amount DECIMAL(20, 8) NOT NULL,
+    currency VARCHAR(8) NOT NULL,
+    -- 余额充值：支付成功后入账的余额（美元）
+    credit_amount DECIMAL(20, 8) NOT NULL DEFAULT 0,
+    -- 购买订阅：套餐、分组与有效天数
+    plan_id VARCHAR(64) NOT NULL DEFAULT '',
+    group_id BIGINT REFERENCES groups(id) ON DELETE SET NULL,
+    validity_days INT NOT NULL DEFAULT 0,
+    -- 支付渠道与渠道侧订单号
+    provider VARCHAR(32) NOT NULL,
+    provider_order_id VARCHAR(128) NOT NULL DEFAULT '',
+    pay_url TEXT NOT NULL DEFAULT '',
+    -- 状态：pending、paid、failed、expired、cancelled
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    paid_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_topup_orders_user_created
+    ON topup_orders(user_id, created_at DESC, id DESC);
+
+-- 过期扫描只关心待支付订单
+CREATE INDEX IF NOT EXISTS idx_topup_orders_pending_expires
+    ON topup_orders(expires_at)
+    WHERE status = 'pending';
+
+COMMENT ON TABLE topup_orders IS '充值订单（余额充值 / 购买订阅）';
+
+-- 余额流水关联充值订单（entry_type = 'topup'）
+ALTER TABLE balance_ledger_entries
+ADD COLUMN IF NOT EXISTS topup_order_id BIGINT;
+
+COMMENT ON COLUMN balance_ledger_entries.topup_order_id IS '充值订单 ID（entry_type = topup 时）';
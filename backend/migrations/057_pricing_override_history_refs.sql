@@ -0,0 +1,34 @@
+-- 057_pricing_override_history_refs.sql
+-- 自定义价格的历史可追溯性修正，并支持按图片计费的自定义价格。
+-- 1. pricing_overrides.group_id 由 ON DELETE CASCADE 改为 ON DELETE RESTRICT：自定义价格只允许软删除，
+--    分组本身也是软删除，硬删除分组不应连带删除被 usage_logs 引用的价格记录。
+-- 2. usage_logs.pricing_override_id 增加外键与索引，保证用量记录引用的价格存在且可按价格回查用量。
+-- 3. 新增 image_price（USD / 张），为空表示不覆盖图片价格。
+
+ALTER TABLE pricing_overrides DROP CONSTRAINT IF EXISTS pricing_overrides_group_id_fkey;
+ALTER TABLE pricing_overrides
+    ADD CONSTRAINT pricing_overrides_group_id_fkey
+    FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE RESTRICT;
+
+ALTER TABLE pricing_overrides
+ADD COLUMN IF NOT EXISTS image_price DECIMAL(20, 8);
+
+COMMENT ON COLUMN pricing_overrides.image_price IS '图片生成单价（USD / 张，1K/2K 尺寸，4K 翻倍），为空表示不覆盖图片价格';
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint
+        WHERE conrelid = 'usage_logs'::regclass
+        AND conname = 'usage_logs_pricing_override_id_fkey'
+    ) THEN
+        ALTER TABLE usage_logs
+            ADD CONSTRAINT usage_logs_pricing_override_id_fkey
+            FOREIGN KEY (pricing_override_id) REFERENCES pricing_overrides(id) ON DELETE RESTRICT;
+    END IF;
+END
+$$;
+
+CREATE INDEX IF NOT EXISTS idx_usage_logs_pricing_override_id
+    ON usage_logs(pricing_override_id)
+    WHERE pricing_override_id IS NOT NULL;
@@ -0,0 +1,14 @@
+-- 058_add_api_key_request_counts.sql
+-- API Key 已接纳请求计数：按自然日/自然月各一行，准入时原子累加。
+-- 已接纳但失败（未写入 usage_logs）的请求同样计入，Redis 缓存过期后以此表为准，保证请求数配额跨缓存过期生效。
+
+CREATE TABLE IF NOT EXISTS api_key_request_counts (
+    api_key_id BIGINT NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+    -- 周期标识（系统时区）：自然日 YYYY-MM-DD 或自然月 YYYY-MM
+    period VARCHAR(10) NOT NULL,
+    requests BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (api_key_id, period)
+);
+
+COMMENT ON TABLE api_key_request_counts IS 'API Key 已接纳请求计数（按自然日/自然月），用于 daily_request_limit/monthly_request_limit';
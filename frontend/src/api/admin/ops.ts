@@ -313,6 +313,8 @@ export interface GroupConcurrencyInfo {
   max_capacity: number
   load_percentage: number
   waiting_in_queue: number
+  fair_queue_waiting?: number
+  fair_queue_users?: number
 }
 
 export interface AccountConcurrencyInfo {
@@ -286,6 +286,12 @@ export interface AdminGroup extends Group {
   // 模型路由配置（仅管理员可见，内部信息）
   model_routing: Record<string, number[]> | null
   model_routing_enabled: boolean
+  // 模型路由标签选择器（模型模式 -> 账号标签选择器）
+  model_routing_tags?: Record<string, string> | null
+  // 账号标签选择器：匹配的账号自动加入该分组
+  account_tag_selector?: string
+  // 成本优先路由策略（空表示关闭）
+  cost_routing_policy?: '' | 'lowest_cost'
 
   // 分组下账号数量（仅管理员可见）
   account_count?: number
@@ -302,15 +308,38 @@ export interface ApiKey {
   ip_blacklist: string[]
   created_at: string
   updated_at: string
+  limits?: ApiKeyLimits
+  usage?: ApiKeyUsage
   group?: Group
 }
 
+// Per-key limits; null means unlimited
+export interface ApiKeyLimits {
+  expires_at: string | null
+  total_limit_usd: number | null
+  daily_limit_usd: number | null
+  monthly_limit_usd: number | null
+  daily_request_limit: number | null
+  monthly_request_limit: number | null
+  allowed_models: string[] | null
+  allowed_platforms: GroupPlatform[] | null
+}
+
+export interface ApiKeyUsage {
+  total_cost: number
+  daily_cost: number
+  monthly_cost: number
+  daily_requests: number
+  monthly_requests: number
+}
+
 export interface CreateApiKeyRequest {
   name: string
   group_id?: number | null
   custom_key?: string // Optional custom API Key
   ip_whitelist?: string[]
   ip_blacklist?: string[]
+  limits?: Partial<ApiKeyLimits>
 }
 
 export interface UpdateApiKeyRequest {
@@ -319,6 +348,7 @@ export interface UpdateApiKeyRequest {
   status?: 'active' | 'inactive'
   ip_whitelist?: string[]
   ip_blacklist?: string[]
+  limits?: Partial<ApiKeyLimits> // Replaces all limits when present
 }
 
 export interface CreateGroupRequest {
@@ -472,6 +502,10 @@ export interface Account {
   proxy?: Proxy
   group_ids?: number[] // Groups this account belongs to
   groups?: Group[] // Preloaded group objects
+  tags?: string[] // Free-form tags, e.g. tier:max, region:us
+  tag_group_ids?: number[] // Groups joined through tag selectors
+  availability_schedule?: AvailabilitySchedule | null // null means always available
+  in_availability_window?: boolean
 
   // Rate limit & scheduling fields
   schedulable: boolean
@@ -567,6 +601,18 @@ export interface CodexUsageSnapshot {
   codex_usage_updated_at?: string // Last update timestamp
 }
 
+export interface AvailabilityWindow {
+  days?: Array<'mon' | 'tue' | 'wed' | 'thu' | 'fri' | 'sat' | 'sun'> // empty means every day
+  start: string // HH:MM
+  end: string // HH:MM, end <= start crosses midnight, 24:00 means end of day
+}
+
+export interface AvailabilitySchedule {
+  timezone?: string // IANA timezone, empty means server timezone
+  windows: AvailabilityWindow[]
+  next_transition_at?: string
+}
+
 export interface CreateAccountRequest {
   name: string
   notes?: string | null
@@ -579,6 +625,8 @@ export interface CreateAccountRequest {
   priority?: number
   rate_multiplier?: number // Account billing multiplier (>=0, 0 means free)
   group_ids?: number[]
+  tags?: string[]
+  availability_schedule?: AvailabilitySchedule | null // empty windows clears the schedule
   expires_at?: number | null
   auto_pause_on_expired?: boolean
   confirm_mixed_channel_risk?: boolean
@@ -597,6 +645,8 @@ export interface UpdateAccountRequest {
   schedulable?: boolean
   status?: 'active' | 'inactive'
   group_ids?: number[]
+  tags?: string[]
+  availability_schedule?: AvailabilitySchedule | null // empty windows clears the schedule
   expires_at?: number | null
   auto_pause_on_expired?: boolean
   confirm_mixed_channel_risk?: boolean